@@ -15,6 +15,8 @@ package main
 
 import (
 	"context"
+	"os"
+
 	"github.com/winc-link/hummingbird/internal/hummingbird/core"
 
 	"github.com/gin-gonic/gin"
@@ -35,6 +37,11 @@ import (
 // @name x-token
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
+	// hummingbird-core migrate [options] 在时序库之间迁移历史数据
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		core.Migrate(ctx, cancel, os.Args[2:])
+		return
+	}
 	gin.SetMode(gin.ReleaseMode)
 	core.Main(ctx, cancel, gin.Default())
 }
@@ -55,8 +55,11 @@ type Notify struct {
 	EndEffectTime   string             `json:"end_effect_time"`   //生效结束时间
 }
 
-func (b *RuleUpdateRequest) BuildEkuiperSql(deviceId string, specsType constants.SpecsType) string {
+// BuildEkuiperSql deviceCondition 为设备过滤条件，见 EkuiperDeviceCondition
+// specsType 为规则字段的数据类型，结构体字段、数组元素取其自身的类型
+func (b *RuleUpdateRequest) BuildEkuiperSql(deviceCondition string, specsType constants.SpecsType) string {
 	var sql string
+	property, valuePath, alias := EkuiperPropertyPath(b.SubRule[0].Option["code"])
 	switch specsType {
 	case constants.SpecsTypeInt, constants.SpecsTypeFloat:
 		var s int
@@ -78,63 +81,55 @@ func (b *RuleUpdateRequest) BuildEkuiperSql(deviceId string, specsType constants
 		}
 		switch b.SubRule[0].Option["value_type"] {
 		case constants.Original:
-			code := b.SubRule[0].Option["code"]
 			decideCondition := b.SubRule[0].Option["decide_condition"]
-			originalTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time ,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s.value") %s`
-			sql = fmt.Sprintf(originalTemp, code, deviceId, code, code, decideCondition)
+			originalTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time ,deviceId FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s") %s`
+			sql = fmt.Sprintf(originalTemp, property, deviceCondition, valuePath, valuePath, decideCondition)
 
 		case constants.Avg:
-			code := b.SubRule[0].Option["code"]
 			decideCondition := b.SubRule[0].Option["decide_condition"]
-			sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,avg(json_path_query(data, "$.%s.value")) as avg_%s FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING avg_%s %s`
-			sql = fmt.Sprintf(sqlTemp, code, code, deviceId, code, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", s), code, decideCondition)
+			sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,avg(json_path_query(data, "$.%s")) as avg_%s FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING avg_%s %s`
+			sql = fmt.Sprintf(sqlTemp, valuePath, alias, deviceCondition, valuePath, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", s), alias, decideCondition)
 		case constants.Max:
-			code := b.SubRule[0].Option["code"]
 			decideCondition := b.SubRule[0].Option["decide_condition"]
-			sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,max(json_path_query(data, "$.%s.value")) as max_%s FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING max_%s %s`
-			sql = fmt.Sprintf(sqlTemp, code, code, deviceId, code, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", s), code, decideCondition)
+			sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,max(json_path_query(data, "$.%s")) as max_%s FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING max_%s %s`
+			sql = fmt.Sprintf(sqlTemp, valuePath, alias, deviceCondition, valuePath, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", s), alias, decideCondition)
 		case constants.Min:
-			code := b.SubRule[0].Option["code"]
 			decideCondition := b.SubRule[0].Option["decide_condition"]
-			sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,min(json_path_query(data, "$.%s.value")) as min_%s FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING min_%s %s`
-			sql = fmt.Sprintf(sqlTemp, code, code, deviceId, code, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", s), code, decideCondition)
+			sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,min(json_path_query(data, "$.%s")) as min_%s FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING min_%s %s`
+			sql = fmt.Sprintf(sqlTemp, valuePath, alias, deviceCondition, valuePath, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", s), alias, decideCondition)
 		case constants.Sum:
-			code := b.SubRule[0].Option["code"]
 			decideCondition := b.SubRule[0].Option["decide_condition"]
-			sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,sum(json_path_query(data, "$.%s.value")) as sum_%s FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING sum_%s %s`
-			sql = fmt.Sprintf(sqlTemp, code, code, deviceId, code, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", s), code, decideCondition)
+			sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,sum(json_path_query(data, "$.%s")) as sum_%s FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING sum_%s %s`
+			sql = fmt.Sprintf(sqlTemp, valuePath, alias, deviceCondition, valuePath, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", s), alias, decideCondition)
 		}
 		return sql
 	case constants.SpecsTypeText:
-		code := b.SubRule[0].Option["code"]
 		decideCondition := b.SubRule[0].Option["decide_condition"]
 		st := strings.Split(decideCondition, " ")
 		if len(st) != 2 {
 			return ""
 		}
-		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s.value") = "%s"`
-		sql = fmt.Sprintf(sqlTemp, code, deviceId, code, code, st[1])
+		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s") = "%s"`
+		sql = fmt.Sprintf(sqlTemp, property, deviceCondition, valuePath, valuePath, st[1])
 	case constants.SpecsTypeEnum:
-		code := b.SubRule[0].Option["code"]
 		decideCondition := b.SubRule[0].Option["decide_condition"]
 		st := strings.Split(decideCondition, " ")
 		if len(st) != 2 {
 			return ""
 		}
-		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s.value") = %s`
-		sql = fmt.Sprintf(sqlTemp, code, deviceId, code, code, st[1])
+		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s") = %s`
+		sql = fmt.Sprintf(sqlTemp, property, deviceCondition, valuePath, valuePath, st[1])
 	case constants.SpecsTypeBool:
-		code := b.SubRule[0].Option["code"]
 		decideCondition := b.SubRule[0].Option["decide_condition"]
 		st := strings.Split(decideCondition, " ")
 		if len(st) != 2 {
 			return ""
 		}
-		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s.value") = %s`
+		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s") = %s`
 		if st[1] == "true" {
-			sql = fmt.Sprintf(sqlTemp, code, deviceId, code, code, "1")
+			sql = fmt.Sprintf(sqlTemp, property, deviceCondition, valuePath, valuePath, "1")
 		} else if st[1] == "false" {
-			sql = fmt.Sprintf(sqlTemp, code, deviceId, code, code, "0")
+			sql = fmt.Sprintf(sqlTemp, property, deviceCondition, valuePath, valuePath, "0")
 		}
 
 	}
@@ -167,6 +162,7 @@ func ReplaceRuleModelFields(ds *models.AlertRule, patch RuleUpdateRequest) {
 				Trigger:   rule.Trigger,
 				ProductId: rule.ProductId,
 				DeviceId:  rule.DeviceId,
+				GroupId:   rule.GroupId,
 				Option:    rule.Option,
 			})
 		}
@@ -193,6 +189,7 @@ type SubRule struct {
 	Trigger   constants.Trigger `json:"trigger"`
 	ProductId string            `json:"product_id"`
 	DeviceId  string            `json:"device_id"`
+	GroupId   string            `json:"group_id"` //设备分组，设置后规则作用于分组内该产品的全部设备
 	Option    map[string]string `json:"option"`
 }
 
@@ -219,6 +216,8 @@ type RuleSubRule struct {
 	ProductName string            `json:"product_name"`
 	DeviceId    string            `json:"device_id"`
 	DeviceName  string            `json:"device_name"`
+	GroupId     string            `json:"group_id"`
+	GroupName   string            `json:"group_name"`
 	Code        string            `json:"code"`
 	Condition   string            `json:"condition"`
 	Option      map[string]string `json:"option"`
@@ -258,6 +257,7 @@ func RuleSearchQueryResponseFromModel(p models.AlertRule) AlertRuleSearchQueryRe
 			Trigger:   rule.Trigger,
 			ProductId: rule.ProductId,
 			DeviceId:  rule.DeviceId,
+			GroupId:   rule.GroupId,
 			Option:    rule.Option,
 		})
 	}
@@ -0,0 +1,50 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dtos
+
+import "github.com/winc-link/hummingbird/internal/pkg/constants"
+
+// DataExportRequest 导出设备的历史数据，DeviceIds 为空时导出产品下的全部设备
+type DataExportRequest struct {
+	ProductId string   `json:"productId" binding:"required"`
+	DeviceIds []string `json:"deviceIds"`
+	// Kind 导出的消息类型 property/event/action，默认 property
+	Kind string `json:"kind"`
+	// Codes 导出的标识符，为空时导出物模型中该类型的全部标识符
+	Codes []string `json:"codes"`
+	// Start、End 时间范围(毫秒)，End 晚于当前时间时为创建任务的时间
+	Start  int64                      `json:"start"`
+	End    int64                      `json:"end" binding:"required"`
+	Format constants.DataExportFormat `json:"format" binding:"required,oneof=csv ndjson parquet"`
+}
+
+type DataExportJobResponse struct {
+	JobId     string                        `json:"jobId"`
+	Status    constants.DataExportJobStatus `json:"status"`
+	ProductId string                        `json:"productId"`
+	Kind      string                        `json:"kind"`
+	Format    constants.DataExportFormat    `json:"format"`
+	Start     int64                         `json:"start"`
+	End       int64                         `json:"end"`
+	// FileName 导出完成后下载的文件名
+	FileName        string `json:"fileName"`
+	TotalDevices    int    `json:"totalDevices"`
+	ExportedDevices int    `json:"exportedDevices"`
+	Rows            int64  `json:"rows"`
+	Size            int64  `json:"size"`
+	Error           string `json:"error,omitempty"`
+	Created         int64  `json:"created"`
+	Finished        int64  `json:"finished"`
+}
@@ -0,0 +1,69 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dtos
+
+const (
+	DataMigrationIdle      = "idle"
+	DataMigrationRunning   = "running"
+	DataMigrationCompleted = "completed"
+	DataMigrationStopped   = "stopped"
+	DataMigrationFailed    = "failed"
+)
+
+// DataMigrationDB 迁移的源库或目标库，Type 为空时使用当前运行的时序库
+type DataMigrationDB struct {
+	Type       string `json:"type"`
+	Dsn        string `json:"dsn"`
+	DataSource string `json:"dataSource"`
+}
+
+type DataMigrationRequest struct {
+	Source DataMigrationDB `json:"source"`
+	Target DataMigrationDB `json:"target"`
+	// DeviceIds 为空时迁移全部设备
+	DeviceIds []string `json:"deviceIds"`
+	// Start、End 迁移的时间范围(毫秒)，End 为 0 时为开始迁移的时间
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// Resume 从上次中断的位置继续，源库、目标库和时间范围使用上次的参数
+	Resume bool `json:"resume"`
+}
+
+// DataMigrationMismatch 迁移后目标库的数据条数少于源库
+type DataMigrationMismatch struct {
+	DeviceId string `json:"deviceId"`
+	Kind     string `json:"kind"`
+	Code     string `json:"code"`
+	Source   int    `json:"source"`
+	Target   int    `json:"target"`
+}
+
+type DataMigrationProgress struct {
+	Status          string                  `json:"status"`
+	Source          string                  `json:"source"`
+	Target          string                  `json:"target"`
+	Start           int64                   `json:"start"`
+	End             int64                   `json:"end"`
+	TotalDevices    int                     `json:"totalDevices"`
+	MigratedDevices int                     `json:"migratedDevices"`
+	CurrentDevice   string                  `json:"currentDevice"`
+	Properties      int64                   `json:"properties"`
+	Events          int64                   `json:"events"`
+	Services        int64                   `json:"services"`
+	Mismatches      []DataMigrationMismatch `json:"mismatches"`
+	Error           string                  `json:"error"`
+	StartTime       int64                   `json:"startTime"`
+	UpdateTime      int64                   `json:"updateTime"`
+}
@@ -28,4 +28,31 @@ type Configuration struct {
 	DataSource string
 	// 添加tqlite集群地址
 	Cluster []string
+	// 时序数据保存天数，0表示永久保存，用于只能在打开时设置保存时间的时序库
+	RetentionDays int
+}
+
+// DataRecord 一次上报写入时序库的数据，Time 为毫秒时间戳，
+// 写缓冲按记录批量写入，写入失败时以 json 落盘
+type DataRecord struct {
+	Table    string                          `json:"table"`
+	Time     int64                           `json:"time"`
+	Values   map[string]interface{}          `json:"values,omitempty"`
+	Events   map[string]EventData            `json:"events,omitempty"`
+	Services map[string]SaveServiceIssueData `json:"services,omitempty"`
+}
+
+// Fields 合并属性、事件和服务，与 Insert 的 data 参数格式相同
+func (r DataRecord) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(r.Values)+len(r.Events)+len(r.Services))
+	for code, value := range r.Values {
+		fields[code] = value
+	}
+	for code, event := range r.Events {
+		fields[code] = event
+	}
+	for code, service := range r.Services {
+		fields[code] = service
+	}
+	return fields
 }
@@ -43,23 +43,34 @@ type DeviceSearchQueryRequest struct {
 	CloudInstanceId          string `schema:"cloud_instance_id,omitempty"`
 	DriveInstanceId          string `schema:"drive_instance_id,omitempty"`
 	Status                   string `schema:"status,omitempty"`
+	ParentId                 string `schema:"parent_id,omitempty"`
+	Tags                     string `schema:"tags,omitempty"` //标签条件，格式为 key1=value1,key2=value2，多个标签需同时满足
+	GroupId                  string `schema:"group_id,omitempty"`
+	// Tree 为 true 时只分页查询顶层设备，网关的子设备通过 children 返回
+	Tree      bool     `schema:"tree,omitempty"`
+	ParentIds []string `schema:"-"`
+	DeviceSns []string `schema:"-"`
 }
 
 type DeviceSearchQueryResponse struct {
-	Id                string                 `json:"id"`
-	Name              string                 `json:"name"`
-	ProductId         string                 `json:"product_id"`
-	Status            constants.DeviceStatus `json:"status"`
-	Platform          constants.IotPlatform  `json:"platform"`
-	CloudInstanceId   string                 `json:"cloud_instance_id"`
-	CloudProductId    string                 `json:"cloud_product_id"`
-	DriverServiceName string                 `json:"driver_service_name"`
-	ProductName       string                 `json:"product_name"`
-	LastSyncTime      int64                  `json:"last_sync_time"`
-	LastOnlineTime    int64                  `json:"last_online_time"`
-	DriveInstanceId   string                 `json:"drive_instance_id"`
-	Created           int64                  `json:"created"`
-	Description       string                 `json:"description"`
+	Id                string                      `json:"id"`
+	Name              string                      `json:"name"`
+	ProductId         string                      `json:"product_id"`
+	Status            constants.DeviceStatus      `json:"status"`
+	Platform          constants.IotPlatform       `json:"platform"`
+	CloudInstanceId   string                      `json:"cloud_instance_id"`
+	CloudProductId    string                      `json:"cloud_product_id"`
+	DriverServiceName string                      `json:"driver_service_name"`
+	ProductName       string                      `json:"product_name"`
+	LastSyncTime      int64                       `json:"last_sync_time"`
+	LastOnlineTime    int64                       `json:"last_online_time"`
+	DriveInstanceId   string                      `json:"drive_instance_id"`
+	Created           int64                       `json:"created"`
+	Description       string                      `json:"description"`
+	ParentId          string                      `json:"parent_id"`
+	NodeType          string                      `json:"node_type"`
+	Tags              map[string]string           `json:"tags"`
+	Children          []DeviceSearchQueryResponse `json:"children,omitempty"`
 }
 
 func DeviceResponseFromModel(p models.Device, deviceServiceName string) DeviceSearchQueryResponse {
@@ -76,6 +87,8 @@ func DeviceResponseFromModel(p models.Device, deviceServiceName string) DeviceSe
 		LastSyncTime:      p.LastSyncTime,
 		LastOnlineTime:    p.LastOnlineTime,
 		DriveInstanceId:   p.DriveInstanceId,
+		ParentId:          p.ParentId,
+		NodeType:          string(p.Product.NodeType),
 		Created:           p.Created,
 		Description:       p.Description,
 	}
@@ -94,8 +107,9 @@ type OpenApiDeviceInfoResponse struct {
 	ProductId   string                 `json:"product_id"`
 	ProductName string                 `json:"product_name"`
 	//Secret         string                 `json:"secret"`
-	LastOnlineTime int64 `json:"last_online_time"`
-	Created        int64 `json:"created_at"`
+	LastOnlineTime int64  `json:"last_online_time"`
+	ParentId       string `json:"parent_id"`
+	Created        int64  `json:"created_at"`
 }
 
 func OpenApiDeviceInfoResponseFromModel(p models.Device) OpenApiDeviceInfoResponse {
@@ -109,6 +123,7 @@ func OpenApiDeviceInfoResponseFromModel(p models.Device) OpenApiDeviceInfoRespon
 		ProductName: p.Product.Name,
 		//Secret:         p.Secret,
 		LastOnlineTime: p.LastOnlineTime,
+		ParentId:       p.ParentId,
 		Created:        p.Created,
 	}
 }
@@ -128,6 +143,9 @@ type DeviceInfoResponse struct {
 	DeviceServiceName string                 `json:"device_service_name"`
 	LastSyncTime      int64                  `json:"last_sync_time"`
 	LastOnlineTime    int64                  `json:"last_online_time"`
+	KeepAlive         int64                  `json:"keep_alive"`
+	ParentId          string                 `json:"parent_id"`
+	Tags              map[string]string      `json:"tags"`
 	Created           int64                  `json:"create_at"`
 }
 
@@ -146,6 +164,8 @@ func DeviceInfoResponseFromModel(p models.Device, deviceServiceName string) Devi
 		DeviceServiceName: deviceServiceName,
 		LastSyncTime:      p.LastSyncTime,
 		LastOnlineTime:    p.LastOnlineTime,
+		KeepAlive:         p.KeepAlive,
+		ParentId:          p.ParentId,
 		Created:           p.Created,
 		CloudInstanceId:   p.CloudInstanceId,
 	}
@@ -176,6 +196,9 @@ type DeviceAddRequest struct {
 	Description      string                `json:"description"`
 	Platform         constants.IotPlatform `json:"platform"`
 	DriverInstanceId string                `json:"driver_instance_id"`
+	KeepAlive        int64                 `json:"keep_alive"` //心跳超时时间(秒)，0表示使用产品配置
+	ParentId         string                `json:"parent_id"`  //父设备(网关)ID，产品节点类型为网关子设备时可选
+	Tags             map[string]string     `json:"tags"`
 	//CloudDeviceId   string                 `json:"cloud_device_id"`
 	//CloudProductId  string                 `json:"cloud_product_id"`
 	//CloudInstanceId string                 `gorm:"index"`
@@ -208,6 +231,9 @@ type DeviceUpdateRequest struct {
 	Name            *string `json:"name"`
 	InstallLocation *string `json:"install_location"`
 	DriveInstanceId *string `json:"drive_instance_id"`
+	KeepAlive       *int64  `json:"keep_alive"`
+	// Tags 不为 nil 时整体替换设备标签
+	Tags map[string]string `json:"tags"`
 }
 
 func ReplaceDeviceModelFields(ds *models.Device, patch DeviceUpdateRequest) {
@@ -224,6 +250,10 @@ func ReplaceDeviceModelFields(ds *models.Device, patch DeviceUpdateRequest) {
 	if patch.InstallLocation != nil {
 		ds.InstallLocation = *patch.InstallLocation
 	}
+
+	if patch.KeepAlive != nil {
+		ds.KeepAlive = *patch.KeepAlive
+	}
 }
 
 type DeviceUpdateOrCreateCallBack struct {
@@ -244,6 +274,15 @@ type DeviceDeleteCallBack struct {
 type DeviceImportTemplateRequest struct {
 }
 
+// DeviceExportRequest 设备导出，导出的表格与导入模版格式一致，可修改后重新导入
+type DeviceExportRequest struct {
+	DeviceSearchQueryRequest `schema:",inline"`
+	Format                   string `schema:"format,omitempty"`           //xlsx(默认) 或 csv
+	WithCredentials          bool   `schema:"with_credentials,omitempty"` //导出 mqtt 连接信息
+	WithProperties           bool   `schema:"with_properties,omitempty"`  //导出属性最新值
+	WithStatus               bool   `schema:"with_status,omitempty"`      //导出在线状态
+}
+
 type DevicesImport struct {
 	ProductId        string `schema:"product_id,omitempty"`
 	DriverInstanceId string `schema:"driver_instance_id,omitempty"`
@@ -295,6 +334,7 @@ type JobAction struct {
 	Code        string      `json:"code"`
 	DateType    string      `json:"dateType"`
 	Value       interface{} `json:"value"`
+	GroupId     string      `json:"groupId"` //DeviceId 为空时对分组内该产品的全部设备执行
 }
 
 type InvokeDeviceServiceReq struct {
@@ -317,3 +357,17 @@ type EffectivePropertyData struct {
 	Value interface{} `json:"value"`
 	Time  int64       `json:"time"`
 }
+
+type DeviceTopoRequest struct {
+	ParentId  string   `json:"-"`
+	DeviceIds []string `json:"device_ids" binding:"required"`
+	// DriverInstanceId 驱动通过 rpc 调用时为驱动实例id，网关和子设备需要属于该驱动实例
+	DriverInstanceId string `json:"-"`
+}
+
+// DeviceThingModel 设备及其产品物模型，一条上报消息只查询一次，
+// 在换算、校验、计算属性和影子等处理环节之间共享，不能修改其中的产品
+type DeviceThingModel struct {
+	Device  models.Device
+	Product models.Product
+}
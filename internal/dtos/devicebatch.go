@@ -0,0 +1,76 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package dtos
+
+import "github.com/winc-link/hummingbird/internal/pkg/constants"
+
+// DeviceBatchSelector 批量下发的目标设备，指定 deviceIds 时忽略其他条件，否则按条件筛选设备
+type DeviceBatchSelector struct {
+	DeviceIds       []string `json:"deviceIds"`
+	ProductId       string   `json:"productId"`
+	GroupId         string   `json:"groupId"`
+	Tags            string   `json:"tags"` //格式为 key1=value1,key2=value2
+	DriveInstanceId string   `json:"driveInstanceId"`
+}
+
+func (s DeviceBatchSelector) IsEmpty() bool {
+	return len(s.DeviceIds) == 0 && s.ProductId == "" && s.GroupId == "" && s.Tags == "" && s.DriveInstanceId == ""
+}
+
+func (s DeviceBatchSelector) SearchRequest() DeviceSearchQueryRequest {
+	var req DeviceSearchQueryRequest
+	if len(s.DeviceIds) > 0 {
+		req.BaseSearchConditionQuery.Ids = ApiParamsArrayToString(s.DeviceIds)
+		return req
+	}
+	req.ProductId = s.ProductId
+	req.GroupId = s.GroupId
+	req.Tags = s.Tags
+	req.DriveInstanceId = s.DriveInstanceId
+	return req
+}
+
+type DeviceBatchPropertySetRequest struct {
+	DeviceBatchSelector
+	Item map[string]interface{} `json:"item" binding:"required"`
+}
+
+type DeviceBatchServiceInvokeRequest struct {
+	DeviceBatchSelector
+	Code  string                 `json:"code" binding:"required"`
+	Items map[string]interface{} `json:"inputParams"`
+}
+
+type DeviceBatchJobResponse struct {
+	JobId    string                         `json:"jobId"`
+	Type     constants.DeviceBatchJobType   `json:"type"`
+	Status   constants.DeviceBatchJobStatus `json:"status"`
+	Total    int                            `json:"total"`
+	Success  int                            `json:"success"`
+	Failed   int                            `json:"failed"`
+	Timeout  int                            `json:"timeout"`
+	Pending  int                            `json:"pending"`
+	Created  int64                          `json:"created"`
+	Finished int64                          `json:"finished"`
+	Results  []DeviceBatchResult            `json:"results"`
+}
+
+type DeviceBatchResult struct {
+	DeviceId   string                            `json:"deviceId"`
+	DeviceName string                            `json:"deviceName"`
+	Status     constants.DeviceBatchResultStatus `json:"status"`
+	Message    string                            `json:"message,omitempty"`
+	Output     map[string]interface{}            `json:"output,omitempty"`
+	Finished   int64                             `json:"finished,omitempty"`
+}
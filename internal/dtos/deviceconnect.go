@@ -0,0 +1,88 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package dtos
+
+import (
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+type DeviceConnectLogSearchQueryRequest struct {
+	BaseSearchConditionQuery `schema:",inline"`
+	DeviceId                 string   `schema:"device_id,omitempty"`
+	ProductId                string   `schema:"product_id,omitempty"`
+	Status                   string   `schema:"status,omitempty"`
+	StartAt                  int64    `schema:"start_time,omitempty"`
+	EndAt                    int64    `schema:"end_time,omitempty"`
+	DeviceIds                []string `schema:"-"`
+}
+
+type DeviceConnectLogResponse struct {
+	DeviceId string                        `json:"device_id"`
+	Status   string                        `json:"status"`
+	Reason   constants.DeviceConnectReason `json:"reason"`
+	Time     int64                         `json:"time"`
+}
+
+func DeviceConnectLogResponseFromModel(l models.DeviceConnectLog) DeviceConnectLogResponse {
+	return DeviceConnectLogResponse{
+		DeviceId: l.DeviceId,
+		Status:   l.Status,
+		Reason:   l.Reason,
+		Time:     l.Time,
+	}
+}
+
+// DeviceAvailabilityRequest 统计时间范围(毫秒)，默认最近 24 小时
+type DeviceAvailabilityRequest struct {
+	DeviceId  string `schema:"-"`
+	ProductId string `schema:"-"`
+	StartAt   int64  `schema:"start_time,omitempty"`
+	EndAt     int64  `schema:"end_time,omitempty"`
+}
+
+type DeviceAvailability struct {
+	Availability float64 `json:"availability"` //在线时长占比(%)
+	FlapCount    int     `json:"flap_count"`   //在线变为离线的次数
+	Downtime     int64   `json:"downtime"`     //离线总时长(毫秒)
+}
+
+type DeviceDailyAvailability struct {
+	Date string `json:"date"`
+	DeviceAvailability
+}
+
+type DeviceAvailabilityResponse struct {
+	DeviceId   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+	DeviceAvailability
+	Daily []DeviceDailyAvailability `json:"daily"`
+}
+
+type ProductAvailabilityResponse struct {
+	ProductId   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	DeviceCount int    `json:"device_count"`
+	DeviceAvailability
+	Daily   []DeviceDailyAvailability    `json:"daily"`
+	Devices []DeviceAvailabilityResponse `json:"devices"`
+}
+
+// DeviceFlap 首页展示的频繁上下线设备
+type DeviceFlap struct {
+	DeviceId    string `json:"device_id"`
+	DeviceName  string `json:"device_name"`
+	ProductName string `json:"product_name"`
+	FlapCount   int    `json:"flap_count"`
+}
@@ -0,0 +1,133 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package dtos
+
+import (
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"strings"
+)
+
+type DeviceGroupAddRequest struct {
+	Name        string                    `json:"name" binding:"required"`
+	Description string                    `json:"description"`
+	Type        constants.DeviceGroupType `json:"type" binding:"required"`
+	Tags        map[string]string         `json:"tags"`       //动态分组的标签条件
+	DeviceIds   []string                  `json:"device_ids"` //静态分组的设备
+}
+
+type DeviceGroupUpdateRequest struct {
+	Id          string            `json:"id"`
+	Name        *string           `json:"name"`
+	Description *string           `json:"description"`
+	Tags        map[string]string `json:"tags"`
+}
+
+func ReplaceDeviceGroupModelFields(group *models.DeviceGroup, patch DeviceGroupUpdateRequest) {
+	if patch.Name != nil {
+		group.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		group.Description = *patch.Description
+	}
+	if patch.Tags != nil {
+		group.Tags = patch.Tags
+	}
+}
+
+type DeviceGroupSearchQueryRequest struct {
+	BaseSearchConditionQuery `schema:",inline"`
+	Type                     string `schema:"type,omitempty"`
+}
+
+type DeviceGroupResponse struct {
+	Id          string                    `json:"id"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Type        constants.DeviceGroupType `json:"type"`
+	Tags        map[string]string         `json:"tags"`
+	DeviceCount uint32                    `json:"device_count"`
+	Created     int64                     `json:"created"`
+}
+
+func DeviceGroupResponseFromModel(group models.DeviceGroup, deviceCount uint32) DeviceGroupResponse {
+	return DeviceGroupResponse{
+		Id:          group.Id,
+		Name:        group.Name,
+		Description: group.Description,
+		Type:        group.Type,
+		Tags:        group.Tags,
+		DeviceCount: deviceCount,
+		Created:     group.Created,
+	}
+}
+
+type DeviceGroupMembersRequest struct {
+	GroupId   string   `json:"-"`
+	DeviceIds []string `json:"device_ids" binding:"required"`
+}
+
+type DeviceGroupPropertySetRequest struct {
+	GroupId string                 `json:"-"`
+	Item    map[string]interface{} `json:"item" binding:"required"`
+}
+
+type DeviceGroupServiceInvokeRequest struct {
+	GroupId string                 `json:"-"`
+	Code    string                 `json:"code" binding:"required"`
+	Items   map[string]interface{} `json:"inputParams"`
+}
+
+type DeviceGroupBindDriverRequest struct {
+	GroupId          string `json:"-"`
+	DriverInstanceId string `json:"driver_instance_id" binding:"required"`
+}
+
+// DeviceGroupOperateResult 分组操作中单个设备的执行结果
+type DeviceGroupOperateResult struct {
+	DeviceId   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+}
+
+type DeviceTagResponse struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+// ParseDeviceTags 解析标签查询条件，格式为 key1=value1,key2=value2
+func ParseDeviceTags(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			continue
+		}
+		tags[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return tags
+}
+
+// DeviceTagsFromModel 按设备聚合标签
+func DeviceTagsFromModel(tags []models.DeviceTag) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	for _, tag := range tags {
+		if _, ok := result[tag.DeviceId]; !ok {
+			result[tag.DeviceId] = make(map[string]string)
+		}
+		result[tag.DeviceId][tag.TagKey] = tag.TagValue
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dtos
+
+import (
+	"github.com/winc-link/hummingbird/internal/models"
+)
+
+type DeviceInvalidSampleResponse struct {
+	DeviceId     string `json:"device_id"`
+	InvalidCount int64  `json:"invalid_count"` //不合法数据累计次数
+	LastCode     string `json:"last_code"`     //最近一次不合法的属性或事件标识符
+	LastReason   string `json:"last_reason"`   //最近一次不合法的原因
+	LastTime     int64  `json:"last_time"`
+}
+
+func DeviceInvalidSampleResponseFromModel(s models.DeviceInvalidSample) DeviceInvalidSampleResponse {
+	return DeviceInvalidSampleResponse{
+		DeviceId:     s.Id,
+		InvalidCount: s.InvalidCount,
+		LastCode:     s.LastCode,
+		LastReason:   s.LastReason,
+		LastTime:     s.LastTime,
+	}
+}
@@ -0,0 +1,99 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dtos
+
+import (
+	"encoding/json"
+	"github.com/winc-link/hummingbird/internal/models"
+)
+
+type DeviceShadowResponse struct {
+	DeviceId string                 `json:"device_id"`
+	Desired  models.ShadowState     `json:"desired"`
+	Reported models.ShadowState     `json:"reported"`
+	Delta    map[string]interface{} `json:"delta"`
+	Version  int64                  `json:"version"`
+	Modified int64                  `json:"modified"`
+}
+
+func DeviceShadowResponseFromModel(shadow models.DeviceShadow) DeviceShadowResponse {
+	resp := DeviceShadowResponse{
+		DeviceId: shadow.Id,
+		Desired:  shadow.Desired,
+		Reported: shadow.Reported,
+		Delta:    shadow.Delta(),
+		Version:  shadow.Version,
+		Modified: shadow.Modified,
+	}
+	if resp.Desired == nil {
+		resp.Desired = models.ShadowState{}
+	}
+	if resp.Reported == nil {
+		resp.Reported = models.ShadowState{}
+	}
+	return resp
+}
+
+type DeviceShadowDesiredRequest struct {
+	DeviceId string                 `json:"-"`
+	Desired  map[string]interface{} `json:"desired"` //属性期望值，值为 null 时删除该属性的期望值
+	Version  int64                  `json:"version"` //大于 0 时必须与当前影子版本一致，用于避免并发覆盖
+}
+
+type DeviceShadowDesiredDeleteRequest struct {
+	Codes string `schema:"codes"` //多个属性用逗号分隔，为空时清空全部期望值
+}
+
+// DesiredGetRequest 设备(驱动)向平台获取属性期望值的请求
+type DesiredGetRequest struct {
+	MsgId   string   `json:"msgId"`
+	Version string   `json:"version"`
+	Data    []string `json:"data"` //属性标识，为空时返回全部期望值
+}
+
+// DesiredDeleteRequest 设备(驱动)向平台清除属性期望值的请求
+type DesiredDeleteRequest struct {
+	MsgId   string   `json:"msgId"`
+	Version string   `json:"version"`
+	Data    []string `json:"data"`
+}
+
+type DesiredResponse struct {
+	MsgId   string             `json:"msgId"`
+	Version string             `json:"version"`
+	Time    int64              `json:"time"`
+	Code    uint32             `json:"code"`
+	Success bool               `json:"success"`
+	Data    models.ShadowState `json:"data"`
+	// ShadowVersion 当前影子版本
+	ShadowVersion int64 `json:"shadowVersion"`
+}
+
+func (r *DesiredResponse) ToString() string {
+	s, _ := json.Marshal(r)
+	return string(s)
+}
+
+func (m *ThingModelMessage) TransformMessageDataByDesiredGet() (DesiredGetRequest, error) {
+	var dataMsg DesiredGetRequest
+	err := json.Unmarshal([]byte(m.Data), &dataMsg)
+	return dataMsg, err
+}
+
+func (m *ThingModelMessage) TransformMessageDataByDesiredDelete() (DesiredDeleteRequest, error) {
+	var dataMsg DesiredDeleteRequest
+	err := json.Unmarshal([]byte(m.Data), &dataMsg)
+	return dataMsg, err
+}
@@ -14,6 +14,11 @@
 
 package dtos
 
+import (
+	"fmt"
+	"strings"
+)
+
 type GetRuleInfoResponse struct {
 	Triggered bool                     `json:"triggered"`
 	Id        string                   `json:"id"`
@@ -119,3 +124,32 @@ func GetRuleSceneEkuiperActions(actionUrl string) []Actions {
 	})
 	return a
 }
+
+// EkuiperDeviceCondition 生成规则 sql 中的设备过滤条件，设备为空时不匹配任何数据
+func EkuiperDeviceCondition(deviceIds ...string) string {
+	switch len(deviceIds) {
+	case 0:
+		return `deviceId = ""`
+	case 1:
+		return fmt.Sprintf(`deviceId = "%s"`, deviceIds[0])
+	}
+	conditions := make([]string, 0, len(deviceIds))
+	for _, id := range deviceIds {
+		conditions = append(conditions, fmt.Sprintf(`deviceId = "%s"`, id))
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// EkuiperPropertyPath 将属性标识符转换为规则 sql 中使用的 json 路径。
+// 结构体字段和数组元素如 pos.lat、points[0]，对应消息中的 pos.value.lat、points.value[0]；
+// property 为所属属性的标识符，alias 为可用作 sql 字段别名的名字。
+func EkuiperPropertyPath(code string) (property, valuePath, alias string) {
+	property = code
+	var rest string
+	if n := strings.IndexAny(code, ".["); n >= 0 {
+		property, rest = code[:n], code[n:]
+	}
+	valuePath = property + ".value" + rest
+	alias = strings.NewReplacer(".", "_", "[", "_", "]", "").Replace(code)
+	return
+}
@@ -1,15 +1,19 @@
 package dtos
 
 import (
+	"bytes"
+	"encoding/csv"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
 const (
-	DevicesFilename = "Devices"
+	DevicesFilename        = "Devices"
+	SceneInstancesFilename = "Scenes"
 )
 
 type ExportFile struct {
@@ -40,3 +44,21 @@ func (f *ExportFile) GetRequiredStyle() int {
 	style, _ := f.Excel.NewStyle(`{"alignment":{"horizontal": "center","vertical": "center"},"font":{"color": "#ea4335"}}`)
 	return style
 }
+
+// WriteCSVToBuffer 将指定 sheet 按 csv 格式输出，合并单元格只保留左上角单元格的值
+func (f *ExportFile) WriteCSVToBuffer(sheet string) (*bytes.Buffer, error) {
+	rows, err := f.Excel.GetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err = w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (f *ExportFile) CSVFileName() string {
+	return strings.TrimSuffix(f.FileName, ".xlsx") + ".csv"
+}
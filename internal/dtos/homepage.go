@@ -41,6 +41,7 @@ type HomePageResponse struct {
 	Docs            Docs                      `json:"docs"`
 	AlertPlate      []AlertPlateQueryResponse `json:"alertPlate"`
 	MsgGather       []MsgGather               `json:"msg_gather"`
+	FlapDevices     []DeviceFlap              `json:"flap_devices"` //最近 24 小时上下线最频繁的设备
 }
 
 type PlatformInfo struct {
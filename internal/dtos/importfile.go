@@ -1,6 +1,7 @@
 package dtos
 
 import (
+	"encoding/csv"
 	"io"
 
 	"github.com/xuri/excelize/v2"
@@ -19,3 +20,27 @@ func NewImportFile(f io.Reader) (*ImportFile, error) {
 		Excel: file,
 	}, nil
 }
+
+// NewImportFileFromCSV 读取 csv 文件并转换为只有一个 sheet 的 excel，与 excel 导入共用解析逻辑
+func NewImportFileFromCSV(f io.Reader, sheet string) (*ImportFile, error) {
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	file := excelize.NewFile()
+	file.SetSheetName("Sheet1", sheet)
+	for i, record := range records {
+		axis, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if err = file.SetSheetRow(sheet, axis, &record); err != nil {
+			return nil, err
+		}
+	}
+	return &ImportFile{
+		Excel: file,
+	}, nil
+}
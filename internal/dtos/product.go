@@ -54,24 +54,27 @@ func ProductResponseFromModel(p models.Product) ProductSearchQueryResponse {
 }
 
 type ProductSearchByIdResponse struct {
-	Id              string      `json:"id"`
-	Name            string      `json:"name"`
-	Key             string      `json:"key"`
-	CloudProductId  string      `json:"cloud_product_id"`
-	CloudInstanceId string      `json:"cloud_instance_id"`
-	Platform        string      `json:"platform"`
-	Protocol        string      `json:"protocol"`
-	NodeType        string      `json:"node_type"`
-	NetType         string      `json:"net_type"`
-	DataFormat      string      `json:"data_format"`
-	Factory         string      `json:"factory"`
-	Description     string      `json:"description"`
-	Status          string      `json:"status"`
-	CreatedAt       int64       `json:"created_at"`
-	LastSyncTime    int64       `json:"last_sync_time"`
-	Properties      interface{} `json:"properties"`
-	Events          interface{} `json:"events"`
-	Actions         interface{} `json:"actions"`
+	Id              string               `json:"id"`
+	Name            string               `json:"name"`
+	Key             string               `json:"key"`
+	CloudProductId  string               `json:"cloud_product_id"`
+	CloudInstanceId string               `json:"cloud_instance_id"`
+	Platform        string               `json:"platform"`
+	Protocol        string               `json:"protocol"`
+	NodeType        string               `json:"node_type"`
+	NetType         string               `json:"net_type"`
+	DataFormat      string               `json:"data_format"`
+	Factory         string               `json:"factory"`
+	Description     string               `json:"description"`
+	Status          string               `json:"status"`
+	CreatedAt       int64                `json:"created_at"`
+	LastSyncTime    int64                `json:"last_sync_time"`
+	KeepAlive       int64                `json:"keep_alive"`
+	ValidateMode    string               `json:"validate_mode"`
+	Retention       models.DataRetention `json:"retention"`
+	Properties      interface{}          `json:"properties"`
+	Events          interface{}          `json:"events"`
+	Actions         interface{}          `json:"actions"`
 }
 
 func ProductSearchByIdFromModel(p models.Product) ProductSearchByIdResponse {
@@ -90,6 +93,9 @@ func ProductSearchByIdFromModel(p models.Product) ProductSearchByIdResponse {
 		Description:     p.Description,
 		CreatedAt:       p.Created,
 		LastSyncTime:    p.LastSyncTime,
+		KeepAlive:       p.KeepAlive,
+		ValidateMode:    string(p.ValidateMode),
+		Retention:       p.Retention,
 		Status:          string(p.Status),
 		Properties:      p.Properties,
 		Events:          p.Events,
@@ -229,33 +235,42 @@ type ProductSyncByIdRequest struct {
 type ProductAddRequest struct {
 	Name string `json:"name"` //产品名字
 	//Platform           string `json:"platform"`
-	Key                string `json:"key"`
-	CategoryTemplateId string `json:"category_template_id"` //如果是自定义 id固定传递"1"
-	Protocol           string `json:"protocol"`             //协议
-	NodeType           string `json:"node_type"`            //节点类型
-	NetType            string `json:"net_type"`             //联网模式
-	DataFormat         string `json:"data_format"`          //数据类型
-	Factory            string `json:"factory"`              //厂家
-	Description        string `json:"description"`          //描述
+	Key                string               `json:"key"`
+	CategoryTemplateId string               `json:"category_template_id"` //如果是自定义 id固定传递"1"
+	Protocol           string               `json:"protocol"`             //协议
+	NodeType           string               `json:"node_type"`            //节点类型
+	NetType            string               `json:"net_type"`             //联网模式
+	DataFormat         string               `json:"data_format"`          //数据类型
+	Factory            string               `json:"factory"`              //厂家
+	Description        string               `json:"description"`          //描述
+	KeepAlive          int64                `json:"keep_alive"`           //心跳超时时间(秒)，0表示不检测
+	ValidateMode       string               `json:"validate_mode"`        //上报数据物模型校验方式 reject/drop/flag，默认flag
+	Retention          models.DataRetention `json:"retention"`            //时序数据按消息类型的保存天数，0表示使用全局配置，-1表示永久保存
 }
 
 type OpenApiAddProductRequest struct {
-	Name        string `json:"name"`        //产品名字
-	Protocol    string `json:"protocol"`    //协议
-	NodeType    string `json:"node_type"`   //节点类型
-	NetType     string `json:"net_type"`    //联网模式
-	DataFormat  string `json:"data_format"` //数据类型
-	Factory     string `json:"factory"`     //厂家
-	Description string `json:"description"` //描述
+	Name         string               `json:"name"`          //产品名字
+	Protocol     string               `json:"protocol"`      //协议
+	NodeType     string               `json:"node_type"`     //节点类型
+	NetType      string               `json:"net_type"`      //联网模式
+	DataFormat   string               `json:"data_format"`   //数据类型
+	Factory      string               `json:"factory"`       //厂家
+	Description  string               `json:"description"`   //描述
+	KeepAlive    int64                `json:"keep_alive"`    //心跳超时时间(秒)，0表示不检测
+	ValidateMode string               `json:"validate_mode"` //上报数据物模型校验方式 reject/drop/flag，默认flag
+	Retention    models.DataRetention `json:"retention"`     //时序数据按消息类型的保存天数，0表示使用全局配置，-1表示永久保存
 }
 
 type OpenApiUpdateProductRequest struct {
-	Id          string  `json:"id"`
-	Name        *string `json:"name"`        //产品名字
-	Protocol    *string `json:"protocol"`    //协议
-	NodeType    *string `json:"node_type"`   //节点类型
-	NetType     *string `json:"net_type"`    //联网模式
-	DataFormat  *string `json:"data_format"` //数据类型
-	Factory     *string `json:"factory"`     //厂家
-	Description *string `json:"description"` //描述
+	Id           string                `json:"id"`
+	Name         *string               `json:"name"`          //产品名字
+	Protocol     *string               `json:"protocol"`      //协议
+	NodeType     *string               `json:"node_type"`     //节点类型
+	NetType      *string               `json:"net_type"`      //联网模式
+	DataFormat   *string               `json:"data_format"`   //数据类型
+	Factory      *string               `json:"factory"`       //厂家
+	Description  *string               `json:"description"`   //描述
+	KeepAlive    *int64                `json:"keep_alive"`    //心跳超时时间(秒)，0表示不检测
+	ValidateMode *string               `json:"validate_mode"` //上报数据物模型校验方式 reject/drop/flag，默认flag
+	Retention    *models.DataRetention `json:"retention"`     //时序数据按消息类型的保存天数，0表示使用全局配置，-1表示永久保存
 }
@@ -48,6 +48,7 @@ func ReplaceSceneModelFields(scene *models.Scene, req SceneUpdateRequest) {
 			ProductID:   action.ProductID,
 			DeviceName:  action.DeviceName,
 			DeviceID:    action.DeviceID,
+			GroupID:     action.GroupID,
 			Code:        action.Code,
 			DataType:    action.DataType,
 			Value:       action.Value,
@@ -66,6 +67,7 @@ type Action struct {
 	ProductName string `json:"product_name"`
 	DeviceID    string `json:"device_id"`
 	DeviceName  string `json:"device_name"`
+	GroupID     string `json:"group_id"`
 	Code        string `json:"code"`
 	DataType    string `json:"data_type"`
 	Value       string `json:"value"`
@@ -83,3 +85,20 @@ type SceneLogSearchQueryRequest struct {
 	EndAt                    int64  `schema:"end_time"`
 	SceneId                  string `json:"scene_id"`
 }
+
+type TimerJobSearchQueryRequest struct {
+	Upcoming int `schema:"upcoming"` // 返回接下来的执行时间个数
+}
+
+type TimerJobResponse struct {
+	Id                string  `json:"id"`
+	Name              string  `json:"name"`
+	Expression        string  `json:"expression"`
+	MisfirePolicy     string  `json:"misfire_policy"`
+	MisfireThreshold  int64   `json:"misfire_threshold"`
+	ConcurrencyPolicy string  `json:"concurrency_policy"`
+	Running           bool    `json:"running"`
+	LastRunTime       int64   `json:"last_run_time"`
+	NextRunTime       int64   `json:"next_run_time"`
+	FireTimes         []int64 `json:"fire_times"`
+}
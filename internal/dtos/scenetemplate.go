@@ -0,0 +1,110 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dtos
+
+import (
+	"github.com/winc-link/hummingbird/internal/models"
+)
+
+type SceneTemplateAddRequest struct {
+	Name        string           `json:"name"`        //名字
+	Description string           `json:"description"` //描述
+	Roles       []TemplateRole   `json:"roles"`       //设备角色
+	Conditions  []Condition      `json:"conditions"`  //条件，notify 条件通过 option.device_role 引用设备角色
+	Actions     []TemplateAction `json:"actions"`     //动作
+}
+
+type SceneTemplateUpdateRequest struct {
+	Id string `json:"id"`
+	SceneTemplateAddRequest
+}
+
+type TemplateRole struct {
+	Name      string `json:"name"`
+	ProductID string `json:"product_id"`
+}
+
+type TemplateAction struct {
+	Role     string `json:"role"`
+	Code     string `json:"code"`
+	DataType string `json:"data_type"`
+	Value    string `json:"value"`
+}
+
+func ReplaceSceneTemplateModelFields(template *models.SceneTemplate, req SceneTemplateAddRequest) {
+	template.Name = req.Name
+	template.Description = req.Description
+
+	template.Roles = make(models.TemplateRoles, 0, len(req.Roles))
+	for _, role := range req.Roles {
+		template.Roles = append(template.Roles, models.TemplateRole{
+			Name:      role.Name,
+			ProductID: role.ProductID,
+		})
+	}
+
+	template.Conditions = make(models.Conditions, 0, len(req.Conditions))
+	for _, condition := range req.Conditions {
+		template.Conditions = append(template.Conditions, models.Condition{
+			ConditionType: condition.ConditionType,
+			Option:        condition.Option,
+		})
+	}
+
+	template.Actions = make(models.TemplateActions, 0, len(req.Actions))
+	for _, action := range req.Actions {
+		template.Actions = append(template.Actions, models.TemplateAction{
+			Role:     action.Role,
+			Code:     action.Code,
+			DataType: action.DataType,
+			Value:    action.Value,
+		})
+	}
+}
+
+type SceneTemplateSearchQueryRequest struct {
+	BaseSearchConditionQuery `schema:",inline"`
+	Name                     string `json:"name"`
+}
+
+// SceneInstantiateRequest 按模板批量创建场景，InstanceKey 相同的实例再次导入时会更新已有场景
+type SceneInstantiateRequest struct {
+	Instances []SceneInstanceItem `json:"instances"`
+	Start     bool                `json:"start"` //创建后是否启动场景
+}
+
+type SceneInstanceItem struct {
+	Key      string            `json:"key"`      //实例标识，如房间号
+	Name     string            `json:"name"`     //场景名字，为空时使用"模板名-实例标识"
+	Bindings map[string]string `json:"bindings"` //角色名 -> 设备ID或设备名称
+}
+
+type SceneInstantiateResponse struct {
+	Created   int                   `json:"created"`
+	Updated   int                   `json:"updated"`
+	Instances []SceneInstanceResult `json:"instances"`
+}
+
+type SceneInstanceResult struct {
+	Key     string `json:"key"`
+	SceneId string `json:"scene_id"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"` //场景启动失败原因
+}
+
+type SceneInstanceImportRequest struct {
+	Start bool `schema:"start,omitempty"`
+}
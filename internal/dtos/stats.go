@@ -109,6 +109,59 @@ type SystemNetwork struct {
 	Last         int64  `json:"-"`              // 采集时记录，不做输出
 }
 
+// StorageStats 时序数据的存储统计
+type StorageStats struct {
+	DataType string                `json:"data_type"` // 时序库类型
+	Global   models.DataRetention  `json:"global"`    // 全局保存天数
+	Total    int64                 `json:"total"`     // 全部产品占用的存储空间 bytes
+	Products []ProductStorageStats `json:"products"`  // 各产品的存储统计
+}
+
+type ProductStorageStats struct {
+	ProductId   string               `json:"product_id"`
+	ProductName string               `json:"product_name"`
+	DeviceCount int                  `json:"device_count"` // 设备数量
+	Size        int64                `json:"size"`         // 占用的存储空间 bytes，tstorage 为按分区数据点比例估算的值
+	Retention   models.DataRetention `json:"retention"`    // 合并全局配置后的保存天数，0表示永久保存
+}
+
+// WriteBufferStats 时序数据写缓冲的统计，计数从服务启动开始累计
+type WriteBufferStats struct {
+	Enable           bool  `json:"enable"`             // 是否开启写缓冲，未开启时同步写入
+	QueueLength      int   `json:"queue_length"`       // 缓冲中等待写入的记录数
+	QueueCapacity    int   `json:"queue_capacity"`     // 缓冲的最大记录数
+	Enqueued         int64 `json:"enqueued"`           // 进入缓冲的记录数
+	Written          int64 `json:"written"`            // 写入成功的记录数，包含重放的记录
+	Batches          int64 `json:"batches"`            // 批量写入成功的次数
+	FailedBatches    int64 `json:"failed_batches"`     // 批量写入失败的次数
+	Blocked          int64 `json:"blocked"`            // 缓冲已满时上报等待的次数
+	Spilled          int64 `json:"spilled"`            // 写入失败后落盘的记录数
+	Replayed         int64 `json:"replayed"`           // 从磁盘重放成功的记录数
+	Dropped          int64 `json:"dropped"`            // 落盘失败或重放时单独写入仍失败而丢弃的记录数
+	SpillFiles       int   `json:"spill_files"`        // 待重放的落盘文件数
+	SpillSize        int64 `json:"spill_size"`         // 待重放的落盘文件大小 bytes
+	LastFlushLatency int64 `json:"last_flush_latency"` // 最近一次批量写入耗时(毫秒)
+}
+
+// MessageBufferStats 消息总线磁盘队列的统计，计数从服务启动开始累计
+type MessageBufferStats struct {
+	Enable         bool   `json:"enable"`          // 是否开启磁盘队列，未开启时 broker 断开期间的消息直接丢弃
+	Connected      bool   `json:"connected"`       // 是否已连接 broker
+	Depth          int    `json:"depth"`           // 队列中等待推送的消息数
+	Size           int64  `json:"size"`            // 队列中消息内容的大小 bytes
+	MaxMessages    int    `json:"max_messages"`    // 队列的最大消息数，0 表示不限制
+	MaxSize        int64  `json:"max_size"`        // 队列中消息内容的最大大小 bytes，0 表示不限制
+	DropPolicy     string `json:"drop_policy"`     // 队列已满时的丢弃策略
+	OldestTime     int64  `json:"oldest_time"`     // 队列中最早的消息进入队列的时间(毫秒)，队列为空时为 0
+	Enqueued       int64  `json:"enqueued"`        // 进入队列的消息数
+	Published      int64  `json:"published"`       // broker 确认的消息数
+	FailedPublish  int64  `json:"failed_publish"`  // 推送失败或等待确认超时的次数
+	Dropped        int64  `json:"dropped"`         // 丢弃的消息总数
+	DroppedFull    int64  `json:"dropped_full"`    // 队列已满丢弃的消息数
+	DroppedExpired int64  `json:"dropped_expired"` // 超过最长保存时间丢弃的消息数
+	DroppedError   int64  `json:"dropped_error"`   // 写入队列失败丢弃的消息数
+}
+
 func FromModelsSystemMetricsToDTO(m models.SystemMetrics) (SystemMetrics, error) {
 	var s SystemMetrics
 	if err := json.Unmarshal([]byte(m.Data), &s); err != nil {
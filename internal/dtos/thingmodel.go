@@ -34,10 +34,12 @@ type ThingModelAddOrUpdateReq struct {
 }
 
 type ThingModelProperties struct {
-	AccessModel string              `json:"access_model"`
-	Require     bool                `json:"require"`
-	DataType    constants.SpecsType `json:"type"`
-	TypeSpec    interface{}         `json:"specs"`
+	AccessModel string                  `json:"access_model"`
+	Require     bool                    `json:"require"`
+	DataType    constants.SpecsType     `json:"type"`
+	TypeSpec    interface{}             `json:"specs"`
+	Expression  string                  `json:"expression"` // 计算属性表达式，为空表示设备上报的属性
+	Scaling     *models.PropertyScaling `json:"scaling"`    // 原始值转换工程值
 }
 
 type ThingModelEventAction struct {
@@ -69,13 +71,15 @@ type SystemThingModelSearchReq struct {
 }
 
 type OpenApiThingModelProperties struct {
-	Id          string          `json:"id"`
-	Name        string          `json:"name"`        // 属性名称
-	Code        string          `json:"code"`        // 标识符
-	AccessMode  string          `json:"access_mode"` // 数据传输类型
-	Require     bool            `json:"require"`
-	TypeSpec    models.TypeSpec `json:"type_spec"` // 数据属性
-	Description string          `json:"description"`
+	Id          string                 `json:"id"`
+	Name        string                 `json:"name"`        // 属性名称
+	Code        string                 `json:"code"`        // 标识符
+	AccessMode  string                 `json:"access_mode"` // 数据传输类型
+	Require     bool                   `json:"require"`
+	TypeSpec    models.TypeSpec        `json:"type_spec"` // 数据属性
+	Description string                 `json:"description"`
+	Expression  string                 `json:"expression"` // 计算属性表达式
+	Scaling     models.PropertyScaling `json:"scaling"`    // 原始值转换工程值
 }
 
 type OpenApiThingModelEvents struct {
@@ -14,6 +14,8 @@
 
 package dtos
 
+import "github.com/winc-link/hummingbird/internal/pkg/aggregate"
+
 type ThingModelDataBaseRequest struct {
 	First bool    `json:"first"`
 	Last  bool    `json:"last"`
@@ -25,6 +27,17 @@ type ThingModelPropertyDataRequest struct {
 	ThingModelDataBaseRequest
 	DeviceId string ` json:"deviceId"`
 	Code     string `json:"code"`
+	// Unit 历史数据按该单位换算后返回，如 °F，为空时返回上报时的单位
+	Unit string `json:"unit"`
+	// Codes 结构体和数组属性拆分存储时的全部子字段，见 models.TypeSpec.FlattenCodes
+	Codes []string `json:"-" schema:"-"`
+	// Interval 不为空时按时间窗口聚合 Range 内的历史数据，如 30s、5m、1h、1d
+	Interval string `json:"interval"`
+	// Function 聚合函数 avg/min/max/sum/count/first/last，默认 avg
+	Function string `json:"function"`
+	// Fill 没有数据的窗口的填充方式 none/null/prev/linear/value，默认 none
+	Fill      string  `json:"fill"`
+	FillValue float64 `json:"fillValue"`
 }
 
 type ThingModelEventDataRequest struct {
@@ -103,3 +116,17 @@ type ThingModelServiceDataRequest struct {
 	DeviceId string ` json:"deviceId"`
 	Code     string `json:"code"`
 }
+
+// ReportDataFromBuckets 聚合结果转换为属性数据，没有数据的窗口值为 nil
+func ReportDataFromBuckets(buckets []aggregate.Bucket) []ReportData {
+	response := make([]ReportData, 0, len(buckets))
+	for _, bucket := range buckets {
+		var reportData ReportData
+		reportData.Time = bucket.Time
+		if bucket.Value != nil {
+			reportData.Value = *bucket.Value
+		}
+		response = append(response, reportData)
+	}
+	return response
+}
@@ -0,0 +1,227 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dtos
+
+import (
+	"encoding/json"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+// ThingModelFileVersion 物模型文件格式版本
+const ThingModelFileVersion = "1.0"
+
+// ThingModelFile 物模型导入导出文件，包含产品信息和产品的全部自定义功能(不含系统内置功能)：
+//
+//	{
+//	  "version": "1.0",
+//	  "product": {"name": "温湿度传感器", "key": "xxx", "protocol": "MQTT", "node_type": "...", ...},
+//	  "properties": [{"code": "temp", "name": "温度", "access_mode": "R", "require": false,
+//	                  "type_spec": {"type": "float", "specs": "{\"min\":\"-40\",\"max\":\"120\",\"unit\":\"°C\"}"},
+//	                  "description": "", "expression": "", "scaling": {}}],
+//	  "events": [{"code": "overheat", "name": "过热", "event_type": "alert", "output_params": [...]}],
+//	  "services": [{"code": "reboot", "name": "重启", "call_type": "ASYNC", "input_params": [...], "output_params": [...]}]
+//	}
+//
+// type_spec 与 OpenApiQueryThingModel 返回的相同。导入时按标识符匹配现有功能，产品信息只用于说明来源，不会修改目标产品
+type ThingModelFile struct {
+	Version    string                   `json:"version"`
+	Product    ThingModelFileProduct    `json:"product"`
+	Properties []ThingModelFileProperty `json:"properties"`
+	Events     []ThingModelFileEvent    `json:"events"`
+	Services   []ThingModelFileService  `json:"services"`
+}
+
+type ThingModelFileProduct struct {
+	Name         string `json:"name"`
+	Key          string `json:"key"`
+	Protocol     string `json:"protocol"`
+	NodeType     string `json:"node_type"`
+	NetType      string `json:"net_type"`
+	DataFormat   string `json:"data_format"`
+	Factory      string `json:"factory"`
+	Description  string `json:"description"`
+	KeepAlive    int64  `json:"keep_alive"`
+	ValidateMode string `json:"validate_mode"`
+}
+
+type ThingModelFileProperty struct {
+	Code        string                 `json:"code"`
+	Name        string                 `json:"name"`
+	AccessMode  string                 `json:"access_mode"`
+	Require     bool                   `json:"require"`
+	TypeSpec    models.TypeSpec        `json:"type_spec"`
+	Description string                 `json:"description"`
+	Expression  string                 `json:"expression,omitempty"`
+	Scaling     models.PropertyScaling `json:"scaling"`
+}
+
+type ThingModelFileEvent struct {
+	Code         string              `json:"code"`
+	Name         string              `json:"name"`
+	EventType    string              `json:"event_type"`
+	Require      bool                `json:"require"`
+	Description  string              `json:"description"`
+	OutputParams models.OutPutParams `json:"output_params"`
+}
+
+type ThingModelFileService struct {
+	Code         string              `json:"code"`
+	Name         string              `json:"name"`
+	CallType     constants.CallType  `json:"call_type"`
+	Require      bool                `json:"require"`
+	Description  string              `json:"description"`
+	InputParams  models.InPutParams  `json:"input_params"`
+	OutputParams models.OutPutParams `json:"output_params"`
+}
+
+func ThingModelFileFromModel(p models.Product) ThingModelFile {
+	file := ThingModelFile{
+		Version: ThingModelFileVersion,
+		Product: ThingModelFileProduct{
+			Name:         p.Name,
+			Key:          p.Key,
+			Protocol:     p.Protocol,
+			NodeType:     string(p.NodeType),
+			NetType:      string(p.NetType),
+			DataFormat:   p.DataFormat,
+			Factory:      p.Factory,
+			Description:  p.Description,
+			KeepAlive:    p.KeepAlive,
+			ValidateMode: string(p.ValidateMode),
+		},
+		Properties: make([]ThingModelFileProperty, 0),
+		Events:     make([]ThingModelFileEvent, 0),
+		Services:   make([]ThingModelFileService, 0),
+	}
+	for _, property := range p.Properties {
+		if property.System {
+			continue
+		}
+		file.Properties = append(file.Properties, ThingModelFileProperty{
+			Code:        property.Code,
+			Name:        property.Name,
+			AccessMode:  property.AccessMode,
+			Require:     property.Require,
+			TypeSpec:    property.TypeSpec,
+			Description: property.Description,
+			Expression:  property.Expression,
+			Scaling:     property.Scaling,
+		})
+	}
+	for _, event := range p.Events {
+		if event.System {
+			continue
+		}
+		file.Events = append(file.Events, ThingModelFileEvent{
+			Code:         event.Code,
+			Name:         event.Name,
+			EventType:    event.EventType,
+			Require:      event.Require,
+			Description:  event.Description,
+			OutputParams: event.OutputParams,
+		})
+	}
+	for _, action := range p.Actions {
+		if action.System {
+			continue
+		}
+		file.Services = append(file.Services, ThingModelFileService{
+			Code:         action.Code,
+			Name:         action.Name,
+			CallType:     action.CallType,
+			Require:      action.Require,
+			Description:  action.Description,
+			InputParams:  action.InputParams,
+			OutputParams: action.OutputParams,
+		})
+	}
+	return file
+}
+
+// ToModel 转换为物模型，不含主键和产品ID
+func (p ThingModelFileProperty) ToModel() models.Properties {
+	return models.Properties{
+		Code:        p.Code,
+		Name:        p.Name,
+		AccessMode:  p.AccessMode,
+		Require:     p.Require,
+		TypeSpec:    p.TypeSpec,
+		Description: p.Description,
+		Expression:  p.Expression,
+		Scaling:     p.Scaling,
+		Tag:         string(constants.TagNameCustom),
+	}
+}
+
+func (e ThingModelFileEvent) ToModel() models.Events {
+	return models.Events{
+		Code:         e.Code,
+		Name:         e.Name,
+		EventType:    e.EventType,
+		Require:      e.Require,
+		Description:  e.Description,
+		OutputParams: e.OutputParams,
+		Tag:          string(constants.TagNameCustom),
+	}
+}
+
+func (s ThingModelFileService) ToModel() models.Actions {
+	return models.Actions{
+		Code:         s.Code,
+		Name:         s.Name,
+		CallType:     s.CallType,
+		Require:      s.Require,
+		Description:  s.Description,
+		InputParams:  s.InputParams,
+		OutputParams: s.OutputParams,
+		Tag:          string(constants.TagNameCustom),
+	}
+}
+
+// ThingModelImportRequest 导入物模型，Apply 为 false 时只返回差异不导入；
+// Content 为 Format 对应格式的文件内容
+type ThingModelImportRequest struct {
+	ProductId string          `json:"product_id"`
+	Format    string          `json:"format"`
+	Apply     bool            `json:"apply"`
+	Content   json.RawMessage `json:"content"`
+}
+
+type ThingModelExportRequest struct {
+	ProductId string `json:"product_id" schema:"product_id,omitempty"`
+	Format    string `json:"format" schema:"format,omitempty"`
+}
+
+// ThingModelImportItem 单个功能的差异，Changes 为修改的字段
+type ThingModelImportItem struct {
+	ThingModelType string   `json:"thing_model_type"`
+	Code           string   `json:"code"`
+	Name           string   `json:"name"`
+	Action         string   `json:"action"`
+	Changes        []string `json:"changes,omitempty"`
+	Reason         string   `json:"reason,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+type ThingModelImportResponse struct {
+	Applied   bool                   `json:"applied"`
+	Add       int                    `json:"add"`
+	Update    int                    `json:"update"`
+	Unchanged int                    `json:"unchanged"`
+	Conflict  int                    `json:"conflict"`
+	Failed    int                    `json:"failed"`
+	Items     []ThingModelImportItem `json:"items"`
+}
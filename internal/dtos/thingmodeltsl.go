@@ -0,0 +1,396 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dtos
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"strconv"
+	"strings"
+)
+
+const ThingModelTSLSchema = "https://iotx-tsl.oss-ap-southeast-1.aliyuncs.com/schema.json"
+
+// 阿里云 TSL 中由平台自动生成的属性上报事件和属性设置、获取服务，导入时忽略
+const (
+	tslMethodPropertyPost = "thing.event.property.post"
+	tslMethodPropertySet  = "thing.service.property.set"
+	tslMethodPropertyGet  = "thing.service.property.get"
+)
+
+// ThingModelTSL 阿里云物模型 TSL(完整物模型)格式
+type ThingModelTSL struct {
+	Schema     string                  `json:"schema"`
+	Profile    ThingModelTSLProfile    `json:"profile"`
+	Properties []ThingModelTSLProperty `json:"properties"`
+	Events     []ThingModelTSLEvent    `json:"events"`
+	Services   []ThingModelTSLService  `json:"services"`
+}
+
+type ThingModelTSLProfile struct {
+	Version    string `json:"version,omitempty"`
+	ProductKey string `json:"productKey"`
+}
+
+// ThingModelTSLDataType 结构体的 specs 为成员数组，其他类型为对象
+type ThingModelTSLDataType struct {
+	Type  string          `json:"type"`
+	Specs json.RawMessage `json:"specs,omitempty"`
+}
+
+type ThingModelTSLProperty struct {
+	Identifier string                `json:"identifier"`
+	Name       string                `json:"name"`
+	AccessMode string                `json:"accessMode"`
+	Required   bool                  `json:"required"`
+	Desc       string                `json:"desc,omitempty"`
+	DataType   ThingModelTSLDataType `json:"dataType"`
+}
+
+type ThingModelTSLParam struct {
+	Identifier string                `json:"identifier"`
+	Name       string                `json:"name"`
+	DataType   ThingModelTSLDataType `json:"dataType"`
+}
+
+type ThingModelTSLEvent struct {
+	Identifier string               `json:"identifier"`
+	Name       string               `json:"name"`
+	Desc       string               `json:"desc,omitempty"`
+	Type       string               `json:"type"`
+	Required   bool                 `json:"required"`
+	Method     string               `json:"method"`
+	OutputData []ThingModelTSLParam `json:"outputData"`
+}
+
+type ThingModelTSLService struct {
+	Identifier string               `json:"identifier"`
+	Name       string               `json:"name"`
+	Desc       string               `json:"desc,omitempty"`
+	Required   bool                 `json:"required"`
+	CallType   string               `json:"callType"`
+	Method     string               `json:"method"`
+	InputData  []ThingModelTSLParam `json:"inputData"`
+	OutputData []ThingModelTSLParam `json:"outputData"`
+}
+
+// ThingModelTSLFromModel 导出产品的自定义功能为阿里云 TSL，计算属性和工程值转换没有对应的定义，不导出
+func ThingModelTSLFromModel(p models.Product) ThingModelTSL {
+	tsl := ThingModelTSL{
+		Schema:     ThingModelTSLSchema,
+		Profile:    ThingModelTSLProfile{Version: ThingModelFileVersion, ProductKey: p.Key},
+		Properties: make([]ThingModelTSLProperty, 0),
+		Events:     make([]ThingModelTSLEvent, 0),
+		Services:   make([]ThingModelTSLService, 0),
+	}
+	for _, property := range p.Properties {
+		if property.System {
+			continue
+		}
+		accessMode := "rw"
+		if property.AccessMode == "R" {
+			accessMode = "r"
+		}
+		tsl.Properties = append(tsl.Properties, ThingModelTSLProperty{
+			Identifier: property.Code,
+			Name:       property.Name,
+			AccessMode: accessMode,
+			Required:   property.Require,
+			Desc:       property.Description,
+			DataType:   tslDataTypeFromModel(property.TypeSpec),
+		})
+	}
+	for _, event := range p.Events {
+		if event.System {
+			continue
+		}
+		tsl.Events = append(tsl.Events, ThingModelTSLEvent{
+			Identifier: event.Code,
+			Name:       event.Name,
+			Desc:       event.Description,
+			Type:       event.EventType,
+			Required:   event.Require,
+			Method:     "thing.event." + event.Code + ".post",
+			OutputData: tslParamsFromModel(event.OutputParams),
+		})
+	}
+	for _, action := range p.Actions {
+		if action.System {
+			continue
+		}
+		tsl.Services = append(tsl.Services, ThingModelTSLService{
+			Identifier: action.Code,
+			Name:       action.Name,
+			Desc:       action.Description,
+			Required:   action.Require,
+			CallType:   strings.ToLower(string(action.CallType)),
+			Method:     "thing.service." + action.Code,
+			InputData:  tslParamsFromModel(action.InputParams),
+			OutputData: tslParamsFromModel(action.OutputParams),
+		})
+	}
+	return tsl
+}
+
+func tslParamsFromModel(params []models.InputOutput) []ThingModelTSLParam {
+	result := make([]ThingModelTSLParam, 0, len(params))
+	for _, param := range params {
+		result = append(result, ThingModelTSLParam{
+			Identifier: param.Code,
+			Name:       param.Name,
+			DataType:   tslDataTypeFromModel(param.TypeSpec),
+		})
+	}
+	return result
+}
+
+// tslDataTypeFromModel 除结构体和数组外 specs 的字段与本平台相同
+func tslDataTypeFromModel(t models.TypeSpec) ThingModelTSLDataType {
+	dataType := ThingModelTSLDataType{Type: string(t.Type)}
+	if t.Type == constants.SpecsTypeArray {
+		var array models.TypeSpecArray
+		_ = json.Unmarshal([]byte(t.Specs), &array)
+		dataType.Specs, _ = json.Marshal(struct {
+			Size string                `json:"size,omitempty"`
+			Item ThingModelTSLDataType `json:"item"`
+		}{
+			Size: array.Size,
+			Item: tslDataTypeFromModel(models.TypeSpec{Type: constants.SpecsType(array.Item.Type), Specs: array.Item.Specs}),
+		})
+		return dataType
+	}
+	if t.Type == constants.SpecsTypeStruct {
+		var fields []models.TypeSpecStruct
+		_ = json.Unmarshal([]byte(t.Specs), &fields)
+		params := make([]ThingModelTSLParam, 0, len(fields))
+		for _, field := range fields {
+			params = append(params, ThingModelTSLParam{
+				Identifier: field.Code,
+				Name:       field.Name,
+				DataType:   tslDataTypeFromModel(field.DataType),
+			})
+		}
+		dataType.Specs, _ = json.Marshal(params)
+		return dataType
+	}
+	if t.Specs == "" || !json.Valid([]byte(t.Specs)) {
+		dataType.Specs = json.RawMessage("{}")
+		return dataType
+	}
+	dataType.Specs = json.RawMessage(t.Specs)
+	return dataType
+}
+
+// ToThingModelFile 转换为本平台的物模型文件，忽略平台自动生成的属性上报事件和属性设置、获取服务
+func (t ThingModelTSL) ToThingModelFile() (ThingModelFile, error) {
+	file := ThingModelFile{
+		Version: ThingModelFileVersion,
+		Product: ThingModelFileProduct{Key: t.Profile.ProductKey},
+	}
+	for _, property := range t.Properties {
+		typeSpec, err := tslDataTypeToModel(property.DataType)
+		if err != nil {
+			return file, fmt.Errorf("property %s: %v", property.Identifier, err)
+		}
+		accessMode := "RW"
+		if strings.ToLower(property.AccessMode) == "r" {
+			accessMode = "R"
+		}
+		file.Properties = append(file.Properties, ThingModelFileProperty{
+			Code:        property.Identifier,
+			Name:        property.Name,
+			AccessMode:  accessMode,
+			Require:     property.Required,
+			TypeSpec:    typeSpec,
+			Description: property.Desc,
+		})
+	}
+	for _, event := range t.Events {
+		if event.Method == tslMethodPropertyPost {
+			continue
+		}
+		params, err := tslParamsToModel(event.OutputData)
+		if err != nil {
+			return file, fmt.Errorf("event %s: %v", event.Identifier, err)
+		}
+		file.Events = append(file.Events, ThingModelFileEvent{
+			Code:         event.Identifier,
+			Name:         event.Name,
+			EventType:    strings.ToLower(event.Type),
+			Require:      event.Required,
+			Description:  event.Desc,
+			OutputParams: params,
+		})
+	}
+	for _, service := range t.Services {
+		if service.Method == tslMethodPropertySet || service.Method == tslMethodPropertyGet {
+			continue
+		}
+		inputParams, err := tslParamsToModel(service.InputData)
+		if err != nil {
+			return file, fmt.Errorf("service %s: %v", service.Identifier, err)
+		}
+		outputParams, err := tslParamsToModel(service.OutputData)
+		if err != nil {
+			return file, fmt.Errorf("service %s: %v", service.Identifier, err)
+		}
+		callType := constants.CallTypeAsync
+		if strings.ToLower(service.CallType) == "sync" {
+			callType = constants.CallTypeSync
+		}
+		file.Services = append(file.Services, ThingModelFileService{
+			Code:         service.Identifier,
+			Name:         service.Name,
+			CallType:     callType,
+			Require:      service.Required,
+			Description:  service.Desc,
+			InputParams:  models.InPutParams(inputParams),
+			OutputParams: outputParams,
+		})
+	}
+	return file, nil
+}
+
+func tslParamsToModel(params []ThingModelTSLParam) (models.OutPutParams, error) {
+	var result models.OutPutParams
+	for _, param := range params {
+		typeSpec, err := tslDataTypeToModel(param.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: %v", param.Identifier, err)
+		}
+		result = append(result, models.InputOutput{
+			Code:     param.Identifier,
+			Name:     param.Name,
+			TypeSpec: typeSpec,
+		})
+	}
+	return result, nil
+}
+
+func tslSpecsType(t string) (constants.SpecsType, error) {
+	switch strings.ToLower(t) {
+	case "int":
+		return constants.SpecsTypeInt, nil
+	case "float", "double":
+		return constants.SpecsTypeFloat, nil
+	case "text":
+		return constants.SpecsTypeText, nil
+	case "date":
+		return constants.SpecsTypeDate, nil
+	case "bool":
+		return constants.SpecsTypeBool, nil
+	case "enum":
+		return constants.SpecsTypeEnum, nil
+	case "struct":
+		return constants.SpecsTypeStruct, nil
+	case "array":
+		return constants.SpecsTypeArray, nil
+	default:
+		return "", fmt.Errorf("data type %s is not supported", t)
+	}
+}
+
+// tslDataTypeToModel TSL 中的数值可能是字符串也可能是数字，统一转换为字符串
+func tslDataTypeToModel(d ThingModelTSLDataType) (models.TypeSpec, error) {
+	specsType, err := tslSpecsType(d.Type)
+	if err != nil {
+		return models.TypeSpec{}, err
+	}
+	typeSpec := models.TypeSpec{Type: specsType}
+	switch specsType {
+	case constants.SpecsTypeStruct:
+		var params []ThingModelTSLParam
+		if len(d.Specs) > 0 {
+			if err = json.Unmarshal(d.Specs, &params); err != nil {
+				return typeSpec, fmt.Errorf("struct specs is invalid: %v", err)
+			}
+		}
+		fields := make([]models.TypeSpecStruct, 0, len(params))
+		for _, param := range params {
+			fieldType, err := tslDataTypeToModel(param.DataType)
+			if err != nil {
+				return typeSpec, fmt.Errorf("field %s: %v", param.Identifier, err)
+			}
+			fields = append(fields, models.TypeSpecStruct{Code: param.Identifier, Name: param.Name, DataType: fieldType})
+		}
+		b, _ := json.Marshal(fields)
+		typeSpec.Specs = string(b)
+		return typeSpec, nil
+	case constants.SpecsTypeArray:
+		var spec struct {
+			Size interface{}           `json:"size"`
+			Item ThingModelTSLDataType `json:"item"`
+		}
+		if len(d.Specs) > 0 {
+			if err = json.Unmarshal(d.Specs, &spec); err != nil {
+				return typeSpec, fmt.Errorf("array specs is invalid: %v", err)
+			}
+		}
+		item, err := tslDataTypeToModel(spec.Item)
+		if err != nil {
+			return typeSpec, fmt.Errorf("array item: %v", err)
+		}
+		array := models.TypeSpecArray{Size: tslSpecString(spec.Size), Item: models.Item{Type: string(item.Type), Specs: item.Specs}}
+		typeSpec.Specs = array.TransformTostring()
+		return typeSpec, nil
+	}
+	specs := make(map[string]interface{})
+	if len(d.Specs) > 0 {
+		if err = json.Unmarshal(d.Specs, &specs); err != nil {
+			return typeSpec, fmt.Errorf("specs is invalid: %v", err)
+		}
+	}
+	values := make(map[string]string, len(specs))
+	for key, value := range specs {
+		values[key] = tslSpecString(value)
+	}
+	switch specsType {
+	case constants.SpecsTypeInt, constants.SpecsTypeFloat:
+		spec := models.TypeSpecIntOrFloat{
+			Min:      values["min"],
+			Max:      values["max"],
+			Step:     values["step"],
+			Unit:     values["unit"],
+			UnitName: values["unitName"],
+		}
+		typeSpec.Specs = spec.TransformTostring()
+	case constants.SpecsTypeText:
+		spec := models.TypeSpecText{Length: values["length"]}
+		typeSpec.Specs = spec.TransformTostring()
+	case constants.SpecsTypeDate:
+		var spec models.TypeSpecDate
+		typeSpec.Specs = spec.TransformTostring()
+	default:
+		// bool、enum 为 值 -> 名称
+		b, _ := json.Marshal(values)
+		typeSpec.Specs = string(b)
+	}
+	return typeSpec, nil
+}
+
+func tslSpecString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,137 @@
+package dtos
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+func tslTestProduct() models.Product {
+	intSpec := models.TypeSpecIntOrFloat{Min: "-40", Max: "120", Step: "1", Unit: "°C", UnitName: "摄氏度"}
+	floatSpec := models.TypeSpecIntOrFloat{Min: "0", Max: "100.5", Step: "0.1"}
+	textSpec := models.TypeSpecText{Length: "64"}
+	dateSpec := models.TypeSpecDate{}
+	boolSpec := models.TypeSpecBool{"0": "off", "1": "on"}
+	enumSpec := models.TypeSpecEnum{"1": "low", "2": "high"}
+	intType := models.TypeSpec{Type: constants.SpecsTypeInt, Specs: intSpec.TransformTostring()}
+	fields, _ := json.Marshal([]models.TypeSpecStruct{
+		{Code: "lat", Name: "纬度", DataType: models.TypeSpec{Type: constants.SpecsTypeFloat, Specs: floatSpec.TransformTostring()}},
+		{Code: "level", Name: "等级", DataType: intType},
+	})
+	structType := models.TypeSpec{Type: constants.SpecsTypeStruct, Specs: string(fields)}
+	arraySpec := models.TypeSpecArray{Size: "4", Item: models.Item{Type: string(structType.Type), Specs: structType.Specs}}
+
+	return models.Product{
+		Key: "pk1",
+		Properties: []models.Properties{
+			{Code: "temperature", Name: "温度", AccessMode: "R", Require: true, TypeSpec: intType, Description: "环境温度"},
+			{Code: "humidity", Name: "湿度", AccessMode: "RW", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat, Specs: floatSpec.TransformTostring()}},
+			{Code: "label", Name: "标签", AccessMode: "RW", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeText, Specs: textSpec.TransformTostring()}},
+			{Code: "updated", Name: "更新时间", AccessMode: "R", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeDate, Specs: dateSpec.TransformTostring()}},
+			{Code: "switch", Name: "开关", AccessMode: "RW", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeBool, Specs: boolSpec.TransformTostring()}},
+			{Code: "mode", Name: "模式", AccessMode: "RW", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeEnum, Specs: enumSpec.TransformTostring()}},
+			{Code: "location", Name: "位置", AccessMode: "R", TypeSpec: structType},
+			{Code: "points", Name: "采样点", AccessMode: "R", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeArray, Specs: arraySpec.TransformTostring()}},
+			{Code: "rssi", Name: "信号强度", AccessMode: "R", System: true, TypeSpec: intType},
+		},
+		Events: []models.Events{
+			{Code: "alarm", Name: "告警", EventType: "alert", Require: true, Description: "温度告警",
+				OutputParams: models.OutPutParams{{Code: "level", Name: "等级", TypeSpec: intType}}},
+		},
+		Actions: []models.Actions{
+			{Code: "reboot", Name: "重启", CallType: constants.CallTypeSync,
+				InputParams:  models.InPutParams{{Code: "delay", Name: "延时", TypeSpec: intType}},
+				OutputParams: models.OutPutParams{{Code: "location", Name: "位置", TypeSpec: structType}}},
+			{Code: "upgrade", Name: "升级", CallType: constants.CallTypeAsync},
+		},
+	}
+}
+
+func TestThingModelTSLRoundTrip(t *testing.T) {
+	product := tslTestProduct()
+
+	b, err := json.Marshal(ThingModelTSLFromModel(product))
+	require.NoError(t, err)
+	var tsl ThingModelTSL
+	require.NoError(t, json.Unmarshal(b, &tsl))
+	file, err := tsl.ToThingModelFile()
+	require.NoError(t, err)
+
+	// 系统属性不导出，计算属性和工程值转换 TSL 中没有对应的定义
+	expected := ThingModelFileFromModel(product)
+	assert.Equal(t, product.Key, file.Product.Key)
+	assert.Equal(t, expected.Properties, file.Properties)
+	assert.Equal(t, expected.Events, file.Events)
+	assert.Equal(t, expected.Services, file.Services)
+
+	// 再次导出结果不变
+	again, err := json.Marshal(ThingModelTSLFromModel(models.Product{
+		Key:        file.Product.Key,
+		Properties: fileProperties(file),
+		Events:     fileEvents(file),
+		Actions:    fileActions(file),
+	}))
+	require.NoError(t, err)
+	assert.JSONEq(t, string(b), string(again))
+}
+
+func TestThingModelTSLImportAliyunSpecs(t *testing.T) {
+	content := `{
+		"profile": {"productKey": "pk1"},
+		"properties": [
+			{"identifier": "temperature", "name": "温度", "accessMode": "r",
+			 "dataType": {"type": "double", "specs": {"min": -40, "max": 120.5, "step": 0.1, "unit": "°C"}}},
+			{"identifier": "points", "name": "采样点", "accessMode": "rw",
+			 "dataType": {"type": "array", "specs": {"size": 10, "item": {"type": "int"}}}}
+		],
+		"events": [{"identifier": "post", "method": "thing.event.property.post"}],
+		"services": [{"identifier": "set", "method": "thing.service.property.set"}]
+	}`
+	var tsl ThingModelTSL
+	require.NoError(t, json.Unmarshal([]byte(content), &tsl))
+	file, err := tsl.ToThingModelFile()
+	require.NoError(t, err)
+
+	require.Len(t, file.Properties, 2)
+	assert.Empty(t, file.Events)
+	assert.Empty(t, file.Services)
+	assert.Equal(t, "R", file.Properties[0].AccessMode)
+	assert.Equal(t, constants.SpecsTypeFloat, file.Properties[0].TypeSpec.Type)
+	assert.JSONEq(t, `{"min":"-40","max":"120.5","step":"0.1","unit":"°C"}`, file.Properties[0].TypeSpec.Specs)
+	var array models.TypeSpecArray
+	require.NoError(t, json.Unmarshal([]byte(file.Properties[1].TypeSpec.Specs), &array))
+	assert.Equal(t, "10", array.Size)
+	assert.Equal(t, constants.SpecsTypeInt, array.ItemTypeSpec().Type)
+
+	tsl.Properties[0].DataType.Type = "binary"
+	_, err = tsl.ToThingModelFile()
+	assert.Error(t, err)
+}
+
+func fileProperties(file ThingModelFile) []models.Properties {
+	var properties []models.Properties
+	for _, property := range file.Properties {
+		properties = append(properties, property.ToModel())
+	}
+	return properties
+}
+
+func fileEvents(file ThingModelFile) []models.Events {
+	var events []models.Events
+	for _, event := range file.Events {
+		events = append(events, event.ToModel())
+	}
+	return events
+}
+
+func fileActions(file ThingModelFile) []models.Actions {
+	var actions []models.Actions
+	for _, service := range file.Services {
+		actions = append(actions, service.ToModel())
+	}
+	return actions
+}
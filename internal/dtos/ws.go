@@ -24,4 +24,6 @@ const (
 
 	WsCodeCheckLang WsCode = 30001 // 切换语言
 
+	WsCodeDataMigration WsCode = 40001 // 时序库数据迁移进度
+
 )
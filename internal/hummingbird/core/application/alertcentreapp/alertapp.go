@@ -97,31 +97,88 @@ func (p alertApp) UpdateAlertRule(ctx context.Context, req dtos.RuleUpdateReques
 	if len(req.SubRule) != 1 {
 		return errors.New("")
 	}
-	device, err := p.dbClient.DeviceById(req.SubRule[0].DeviceId)
+	alertRule, err := p.dbClient.AlertRuleById(req.Id)
 	if err != nil {
 		return err
 	}
-
-	product, err := p.dbClient.ProductById(device.ProductId)
+	if len(req.Notify) > 0 {
+		if err = checkNotifyParam(req.Notify); err != nil {
+			return err
+		}
+	}
+	sql, deviceId, err := p.buildAlertRuleSql(ctx, req)
 	if err != nil {
 		return err
 	}
 
-	alertRule, err := p.dbClient.AlertRuleById(req.Id)
+	ekuiperApp := resourceContainer.EkuiperAppFrom(p.dic.Get)
+	exist, err := ekuiperApp.RuleExist(ctx, alertRule.Id)
 	if err != nil {
 		return err
 	}
+	configapp := resourceContainer.ConfigurationFrom(p.dic.Get)
+	if !exist {
+		if err = ekuiperApp.CreateRule(ctx, dtos.GetRuleAlertEkuiperActions(configapp.Service.Url()), alertRule.Id, sql); err != nil {
+			return err
+		}
+	} else {
+		if err = ekuiperApp.UpdateRule(ctx, dtos.GetRuleAlertEkuiperActions(configapp.Service.Url()), alertRule.Id, sql); err != nil {
+			return err
+		}
+	}
 
-	if req.SubRule[0].ProductId != device.ProductId {
-		return errort.NewCommonEdgeX(errort.AlertRuleParamsError, "device product id not equal to req product id", nil)
+	dtos.ReplaceRuleModelFields(&alertRule, req)
+	//alertRule.Status = constants.RuleStop
+	alertRule.DeviceId = deviceId
+	err = p.dbClient.GetDBInstance().Table(alertRule.TableName()).Select("*").Updates(alertRule).Error
+	if err != nil {
+		return err
 	}
-	if len(req.Notify) > 0 {
-		if err = checkNotifyParam(req.Notify); err != nil {
-			return err
+
+	return nil
+}
+
+// alertRuleTarget 解析规则作用的设备，以分组为目标时返回分组内该产品的全部设备，deviceId 为空
+func (p alertApp) alertRuleTarget(ctx context.Context, subRule dtos.SubRule) (product models.Product, deviceId string, deviceCondition string, err error) {
+	if subRule.GroupId != "" {
+		product, err = p.dbClient.ProductById(subRule.ProductId)
+		if err != nil {
+			return
 		}
+		var devices []models.Device
+		devices, err = resourceContainer.DeviceItfFrom(p.dic.Get).DeviceGroupDevices(ctx, subRule.GroupId, product.Id)
+		if err != nil {
+			return
+		}
+		ids := make([]string, 0, len(devices))
+		for _, device := range devices {
+			ids = append(ids, device.Id)
+		}
+		deviceCondition = dtos.EkuiperDeviceCondition(ids...)
+		return
+	}
+	device, err := p.dbClient.DeviceById(subRule.DeviceId)
+	if err != nil {
+		return
 	}
+	if subRule.ProductId != device.ProductId {
+		err = errort.NewCommonEdgeX(errort.AlertRuleParamsError, "device product id not equal to req product id", nil)
+		return
+	}
+	product, err = p.dbClient.ProductById(device.ProductId)
+	if err != nil {
+		return
+	}
+	deviceId = device.Id
+	deviceCondition = dtos.EkuiperDeviceCondition(device.Id)
+	return
+}
 
-	var sql string
+func (p alertApp) buildAlertRuleSql(ctx context.Context, req dtos.RuleUpdateRequest) (sql string, deviceId string, err error) {
+	product, deviceId, deviceCondition, err := p.alertRuleTarget(ctx, req.SubRule[0])
+	if err != nil {
+		return "", "", err
+	}
 
 	switch req.SubRule[0].Trigger {
 	case constants.DeviceDataTrigger:
@@ -129,51 +186,44 @@ func (p alertApp) UpdateAlertRule(ctx context.Context, req dtos.RuleUpdateReques
 		if v, ok := req.SubRule[0].Option["code"]; ok {
 			code = v
 		} else {
-			return errort.NewCommonEdgeX(errort.AlertRuleParamsError, "update rule code is required", nil)
+			return "", "", errort.NewCommonEdgeX(errort.AlertRuleParamsError, "update rule code is required", nil)
 		}
 
-		var find bool
-		var productProperty models.Properties
-		for _, property := range product.Properties {
-			if property.Code == code {
-				find = true
-				productProperty = property
-				break
-			}
-		}
+		// 结构体字段、数组元素按其自身的类型校验和生成 sql
+		_, typeSpec, find := product.PropertyByPath(code)
 		if !find {
-			return errort.NewCommonEdgeX(errort.ProductPropertyCodeNotExist, "product property code exist", nil)
+			return "", "", errort.NewCommonEdgeX(errort.ProductPropertyCodeNotExist, "product property code exist", nil)
 		}
 
-		switch productProperty.TypeSpec.Type {
+		switch typeSpec.Type {
 		case constants.SpecsTypeInt, constants.SpecsTypeFloat:
 			if err = checkSpecsTypeIntOrFloatParam(req.SubRule[0]); err != nil {
-				return err
+				return "", "", err
 			}
 		case constants.SpecsTypeText:
 			if err = checkSpecsTypeTextParam(req.SubRule[0]); err != nil {
-				return err
+				return "", "", err
 			}
 		case constants.SpecsTypeBool:
 			if err = checkSpecsTypeBoolParam(req.SubRule[0]); err != nil {
-				return err
+				return "", "", err
 			}
 		case constants.SpecsTypeEnum:
 			if err = checkSpecsTypeEnumParam(req.SubRule[0]); err != nil {
-				return err
+				return "", "", err
 			}
 		default:
-			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "update rule code verify failed", nil)
+			return "", "", errort.NewCommonEdgeX(errort.DefaultReqParamsError, "update rule code verify failed", nil)
 		}
 
-		sql = req.BuildEkuiperSql(device.Id, productProperty.TypeSpec.Type)
+		sql = req.BuildEkuiperSql(deviceCondition, typeSpec.Type)
 
 	case constants.DeviceEventTrigger:
 		var code string
 		if v, ok := req.SubRule[0].Option["code"]; ok {
 			code = v
 		} else {
-			return errort.NewCommonEdgeX(errort.AlertRuleParamsError, "update rule code is required", nil)
+			return "", "", errort.NewCommonEdgeX(errort.AlertRuleParamsError, "update rule code is required", nil)
 		}
 		var find bool
 		//var productProperty models.Properties
@@ -185,10 +235,10 @@ func (p alertApp) UpdateAlertRule(ctx context.Context, req dtos.RuleUpdateReques
 			}
 		}
 		if !find {
-			return errort.NewCommonEdgeX(errort.ProductPropertyCodeNotExist, "product event code exist", nil)
+			return "", "", errort.NewCommonEdgeX(errort.ProductPropertyCodeNotExist, "product event code exist", nil)
 		}
-		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.eventTime") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "EVENT_REPORT" and  json_path_exists(data, "$.eventCode") = true and json_path_query(data, "$.eventCode") = "%s"`
-		sql = fmt.Sprintf(sqlTemp, device.Id, code)
+		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.eventTime") as report_time,deviceId FROM mqtt_stream where %s and messageType = "EVENT_REPORT" and  json_path_exists(data, "$.eventCode") = true and json_path_query(data, "$.eventCode") = "%s"`
+		sql = fmt.Sprintf(sqlTemp, deviceCondition, code)
 	case constants.DeviceStatusTrigger:
 		//{"code":"","device_id":"2499708","end_at":null,"start_at":null}
 
@@ -196,7 +246,7 @@ func (p alertApp) UpdateAlertRule(ctx context.Context, req dtos.RuleUpdateReques
 		deviceStatus := req.SubRule[0].Option["status"]
 		if deviceStatus == "" {
 			err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required status parameter missing", nil)
-			return err
+			return "", "", err
 		}
 		if deviceStatus == "在线" {
 			status = constants.DeviceOnline
@@ -204,44 +254,19 @@ func (p alertApp) UpdateAlertRule(ctx context.Context, req dtos.RuleUpdateReques
 			status = constants.DeviceOffline
 		} else {
 			err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required status parameter missing", nil)
-			return err
+			return "", "", err
 		}
-		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.time") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "DEVICE_STATUS" and  json_path_exists(data, "$.status") = true and json_path_query(data, "$.status") = "%s"`
-		sql = fmt.Sprintf(sqlTemp, device.Id, status)
+		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.time") as report_time,deviceId FROM mqtt_stream where %s and messageType = "DEVICE_STATUS" and  json_path_exists(data, "$.status") = true and json_path_query(data, "$.status") = "%s"`
+		sql = fmt.Sprintf(sqlTemp, deviceCondition, status)
 	default:
-		return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "update rule trigger is required", nil)
+		return "", "", errort.NewCommonEdgeX(errort.DefaultReqParamsError, "update rule trigger is required", nil)
 	}
 
 	if sql == "" {
-		return errort.NewCommonEdgeX(errort.AlertRuleParamsError, "sql is null", nil)
-
-	}
-
-	ekuiperApp := resourceContainer.EkuiperAppFrom(p.dic.Get)
-	exist, err := ekuiperApp.RuleExist(ctx, alertRule.Id)
-	if err != nil {
-		return err
-	}
-	configapp := resourceContainer.ConfigurationFrom(p.dic.Get)
-	if !exist {
-		if err = ekuiperApp.CreateRule(ctx, dtos.GetRuleAlertEkuiperActions(configapp.Service.Url()), alertRule.Id, sql); err != nil {
-			return err
-		}
-	} else {
-		if err = ekuiperApp.UpdateRule(ctx, dtos.GetRuleAlertEkuiperActions(configapp.Service.Url()), alertRule.Id, sql); err != nil {
-			return err
-		}
-	}
+		return "", "", errort.NewCommonEdgeX(errort.AlertRuleParamsError, "sql is null", nil)
 
-	dtos.ReplaceRuleModelFields(&alertRule, req)
-	//alertRule.Status = constants.RuleStop
-	alertRule.DeviceId = device.Id
-	err = p.dbClient.GetDBInstance().Table(alertRule.TableName()).Select("*").Updates(alertRule).Error
-	if err != nil {
-		return err
 	}
-
-	return nil
+	return sql, deviceId, nil
 }
 
 func checkNotifyParam(notify []dtos.Notify) error {
@@ -394,9 +419,22 @@ func (p alertApp) AlertRuleById(ctx context.Context, id string) (dtos.RuleRespon
 
 	var ruleSubRules dtos.RuleSubRules
 	for _, rule := range alertRule.SubRule {
-		device, err := p.dbClient.DeviceById(alertRule.DeviceId)
-		if err != nil {
-			return response, err
+		var (
+			device models.Device
+			group  models.DeviceGroup
+		)
+		if rule.GroupId != "" {
+			group, err = p.dbClient.DeviceGroupById(rule.GroupId)
+			if err != nil {
+				return response, err
+			}
+			device.Name = "分组(" + group.Name + ")"
+			device.ProductId = rule.ProductId
+		} else {
+			device, err = p.dbClient.DeviceById(alertRule.DeviceId)
+			if err != nil {
+				return response, err
+			}
 		}
 		product, err := p.dbClient.ProductById(device.ProductId)
 		if err != nil {
@@ -413,10 +451,8 @@ func (p alertApp) AlertRuleById(ctx context.Context, id string) (dtos.RuleRespon
 				eventCodeName = event.Name
 			}
 		}
-		for _, property := range product.Properties {
-			if property.Code == code {
-				propertyCodeName = property.Name
-			}
+		if property, _, ok := product.PropertyByPath(code); ok {
+			propertyCodeName = property.Name + strings.TrimPrefix(code, property.Code)
 		}
 		var valueType string
 		switch rule.Option["value_type"] {
@@ -464,6 +500,8 @@ func (p alertApp) AlertRuleById(ctx context.Context, id string) (dtos.RuleRespon
 			ProductName: product.Name,
 			DeviceId:    rule.DeviceId,
 			DeviceName:  device.Name,
+			GroupId:     group.Id,
+			GroupName:   group.Name,
 			Trigger:     rule.Trigger,
 			Code:        code,
 			Condition:   condition,
@@ -624,20 +662,26 @@ func (p alertApp) checkAlertRuleParam(ctx context.Context, rule models.AlertRule
 		if subRule.Trigger == "" {
 			return errort.NewCommonErr(errort.AlertRuleParamsError, fmt.Errorf("alertRule id(%s) subrule trigger is null", rule.Id))
 		}
-		if subRule.ProductId == "" || subRule.DeviceId == "" {
+		if subRule.ProductId == "" || (subRule.DeviceId == "" && subRule.GroupId == "") {
 			return errort.NewCommonErr(errort.AlertRuleParamsError, fmt.Errorf("alertRule id(%s) device id or product id is null", rule.Id))
 		}
 		product, err := p.dbClient.ProductById(subRule.ProductId)
 		if err != nil {
 			return errort.NewCommonErr(errort.AlertRuleProductOrDeviceUpdate, fmt.Errorf("alertRule id(%s) device id or product id is null", rule.Id))
 		}
-		device, err := p.dbClient.DeviceById(subRule.DeviceId)
-		if err != nil {
-			return errort.NewCommonErr(errort.AlertRuleProductOrDeviceUpdate, fmt.Errorf("alertRule id(%s) product or device has been modified. Please edit the rule again", rule.Id))
-		}
+		if subRule.GroupId != "" {
+			if _, err = p.dbClient.DeviceGroupById(subRule.GroupId); err != nil {
+				return errort.NewCommonErr(errort.AlertRuleProductOrDeviceUpdate, fmt.Errorf("alertRule id(%s) device group has been deleted. Please edit the rule again", rule.Id))
+			}
+		} else {
+			device, err := p.dbClient.DeviceById(subRule.DeviceId)
+			if err != nil {
+				return errort.NewCommonErr(errort.AlertRuleProductOrDeviceUpdate, fmt.Errorf("alertRule id(%s) product or device has been modified. Please edit the rule again", rule.Id))
+			}
 
-		if device.ProductId != product.Id {
-			return errort.NewCommonErr(errort.AlertRuleProductOrDeviceUpdate, fmt.Errorf("alertRule id(%s) product or device has been modified. Please edit the rule again", rule.Id))
+			if device.ProductId != product.Id {
+				return errort.NewCommonErr(errort.AlertRuleProductOrDeviceUpdate, fmt.Errorf("alertRule id(%s) product or device has been modified. Please edit the rule again", rule.Id))
+			}
 		}
 		code := subRule.Option["code"]
 		switch subRule.Trigger {
@@ -645,15 +689,8 @@ func (p alertApp) checkAlertRuleParam(ctx context.Context, rule models.AlertRule
 			if code == "" {
 				return errort.NewCommonErr(errort.AlertRuleParamsError, fmt.Errorf("alertRule id(%s) code is null", rule.Id))
 			}
-			var find bool
-			var typeSpecType constants.SpecsType
-			for _, property := range product.Properties {
-				if property.Code == code {
-					find = true
-					typeSpecType = property.TypeSpec.Type
-					break
-				}
-			}
+			_, typeSpec, find := product.PropertyByPath(code)
+			typeSpecType := typeSpec.Type
 			if !find {
 				return errort.NewCommonErr(errort.AlertRuleProductOrDeviceUpdate, fmt.Errorf("alertRule id(%s) product or device has been modified. Please edit the rule again", rule.Id))
 			}
@@ -947,3 +984,67 @@ func (p alertApp) CheckRuleByDeviceId(ctx context.Context, deviceId string) erro
 	}
 	return nil
 }
+
+// CheckRuleByDeviceGroupId 分组被告警规则引用时不允许删除
+func (p alertApp) CheckRuleByDeviceGroupId(ctx context.Context, groupId string) error {
+	alertRules, _, err := p.dbClient.AlertRuleSearch(0, -1, dtos.AlertRuleSearchQueryRequest{})
+	if err != nil {
+		return err
+	}
+	for _, rule := range alertRules {
+		for _, subRule := range rule.SubRule {
+			if subRule.GroupId == groupId {
+				return errort.NewCommonEdgeX(errort.DeviceGroupAssociationRule, "This group has been bound to alarm"+
+					" rules. Please edit or delete relevant alarm rules before proceeding with the operation", nil)
+			}
+		}
+	}
+	return nil
+}
+
+// RefreshRulesByDeviceGroupId 分组成员变化后重新生成以该分组为目标的规则 sql
+func (p alertApp) RefreshRulesByDeviceGroupId(ctx context.Context, groupId string) error {
+	alertRules, _, err := p.dbClient.AlertRuleSearch(0, -1, dtos.AlertRuleSearchQueryRequest{})
+	if err != nil {
+		return err
+	}
+	ekuiperApp := resourceContainer.EkuiperAppFrom(p.dic.Get)
+	configapp := resourceContainer.ConfigurationFrom(p.dic.Get)
+	for _, rule := range alertRules {
+		if len(rule.SubRule) != 1 || rule.SubRule[0].GroupId != groupId {
+			continue
+		}
+		exist, err := ekuiperApp.RuleExist(ctx, rule.Id)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			continue
+		}
+		req := dtos.RuleUpdateRequest{
+			Id:        rule.Id,
+			Condition: rule.Condition,
+			SubRule: []dtos.SubRule{{
+				Trigger:   rule.SubRule[0].Trigger,
+				ProductId: rule.SubRule[0].ProductId,
+				GroupId:   rule.SubRule[0].GroupId,
+				Option:    rule.SubRule[0].Option,
+			}},
+		}
+		sql, _, err := p.buildAlertRuleSql(ctx, req)
+		if err != nil {
+			p.lc.Errorf("alertRule %s rebuild sql err %v", rule.Id, err)
+			continue
+		}
+		if err = ekuiperApp.UpdateRule(ctx, dtos.GetRuleAlertEkuiperActions(configapp.Service.Url()), rule.Id, sql); err != nil {
+			return err
+		}
+		// 规则更新后不会自动运行，运行中的规则需要重新启动
+		if rule.Status == constants.RuleStart {
+			if err = ekuiperApp.StartRule(ctx, rule.Id); err != nil {
+				p.lc.Errorf("alertRule %s start err %v", rule.Id, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dataexport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/container"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+)
+
+const (
+	defaultPageSize = 1000
+	// 导出完成的文件默认保留时长
+	defaultExpire = time.Hour
+	// 默认同时运行的导出任务数上限
+	defaultMaxRunning = 4
+	// 检查过期任务的间隔
+	expireCheckInterval = time.Minute
+)
+
+var exportFormats = []constants.DataExportFormat{
+	constants.DataExportFormatCSV,
+	constants.DataExportFormatNDJSON,
+	constants.DataExportFormatParquet,
+}
+
+type exportJob struct {
+	mu      sync.Mutex
+	resp    dtos.DataExportJobResponse
+	path    string
+	expires time.Time
+}
+
+func (j *exportJob) deviceExported(rows int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.resp.ExportedDevices++
+	j.resp.Rows += rows
+}
+
+func (j *exportJob) finish(size int64, err error, expire time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		j.resp.Status = constants.DataExportJobFailed
+		j.resp.Error = err.Error()
+	} else {
+		j.resp.Status = constants.DataExportJobFinished
+		j.resp.Size = size
+	}
+	j.resp.Finished = utils.MakeTimestamp()
+	j.expires = time.Now().Add(expire)
+}
+
+func (j *exportJob) running() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.resp.Status == constants.DataExportJobRunning
+}
+
+func (j *exportJob) expired(now time.Time) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.resp.Status != constants.DataExportJobRunning && now.After(j.expires)
+}
+
+func (j *exportJob) snapshot() dtos.DataExportJobResponse {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.resp
+}
+
+// dataExportApp 导出任务仅保存在内存中，服务重启后丢失，启动时删除上次运行留下的导出文件。
+// 完成的任务过期后由 expireMonitor 定时删除，查询时也不再返回过期的任务
+type dataExportApp struct {
+	ctx          context.Context
+	lc           logger.LoggingClient
+	dbClient     interfaces.DBClient
+	dataDbClient interfaces.DataDBClient
+	dir          string
+	expire       time.Duration
+	pageSize     int
+	maxRunning   int
+	// mu 保证统计运行中的任务数和创建任务是原子的
+	mu sync.Mutex
+	// jobs jobId -> *exportJob
+	jobs sync.Map
+}
+
+func NewDataExportApp(ctx context.Context, dic *di.Container) *dataExportApp {
+	config := resourceContainer.ConfigurationFrom(dic.Get).DataExport
+	app := &dataExportApp{
+		ctx:          ctx,
+		lc:           container.LoggingClientFrom(dic.Get),
+		dbClient:     resourceContainer.DBClientFrom(dic.Get),
+		dataDbClient: resourceContainer.DataDBClientFrom(dic.Get),
+		dir:          config.Dir,
+		expire:       time.Duration(config.Expire) * time.Minute,
+		pageSize:     config.PageSize,
+		maxRunning:   config.MaxRunning,
+	}
+	if app.expire <= 0 {
+		app.expire = defaultExpire
+	}
+	if app.pageSize <= 0 {
+		app.pageSize = defaultPageSize
+	}
+	if app.maxRunning <= 0 {
+		app.maxRunning = defaultMaxRunning
+	}
+	app.removeFiles()
+	go app.expireMonitor(ctx)
+	return app
+}
+
+func (app *dataExportApp) expireMonitor(ctx context.Context) {
+	ticker := time.NewTicker(expireCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			app.removeExpired(time.Now())
+		}
+	}
+}
+
+// removeExpired 删除已过期的任务和文件
+func (app *dataExportApp) removeExpired(now time.Time) {
+	app.jobs.Range(func(key, value interface{}) bool {
+		if job := value.(*exportJob); job.expired(now) {
+			app.remove(job)
+		}
+		return true
+	})
+}
+
+func (app *dataExportApp) remove(job *exportJob) {
+	app.jobs.Delete(job.resp.JobId)
+	_ = os.Remove(job.path)
+}
+
+// removeFiles 只删除导出格式的文件，避免目录配置错误时误删其他文件
+func (app *dataExportApp) removeFiles() {
+	entries, err := os.ReadDir(app.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			app.lc.Errorf("read data export dir %s err: %v", app.dir, err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, format := range exportFormats {
+			if filepath.Ext(entry.Name()) == "."+string(format) {
+				_ = os.Remove(filepath.Join(app.dir, entry.Name()))
+			}
+		}
+	}
+}
+
+// DataExport 校验参数后立即返回任务id，导出进度和结果通过 DataExportJobById 查询
+func (app *dataExportApp) DataExport(ctx context.Context, req dtos.DataExportRequest) (string, error) {
+	product, err := app.dbClient.ProductById(req.ProductId)
+	if err != nil {
+		return "", err
+	}
+	if err = checkRequest(&req, product); err != nil {
+		return "", errort.NewCommonErr(errort.DefaultReqParamsError, err)
+	}
+	devices, err := app.devices(req)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(app.dir, os.ModePerm); err != nil {
+		return "", errort.NewCommonErr(errort.DefaultSystemError, err)
+	}
+
+	job, err := app.add(req, product, len(devices))
+	if err != nil {
+		return "", err
+	}
+	e := &exporter{
+		dataDbClient: app.dataDbClient,
+		req:          req,
+		product:      product,
+		pageSize:     app.pageSize,
+	}
+	go app.run(job, e, devices)
+	return job.resp.JobId, nil
+}
+
+// checkRequest 补全默认的消息类型和标识符，结束时间晚于当前时间时改为当前时间，保证按时间窗口读取时数据不变
+func checkRequest(req *dtos.DataExportRequest, product models.Product) error {
+	if req.Kind == "" {
+		req.Kind = constants.Property
+	}
+	var codes []string
+	switch req.Kind {
+	case constants.Property:
+		for _, property := range product.Properties {
+			codes = append(codes, property.Code)
+		}
+	case constants.Event:
+		for _, event := range product.Events {
+			codes = append(codes, event.Code)
+		}
+	case constants.Action:
+		for _, action := range product.Actions {
+			codes = append(codes, action.Code)
+		}
+	default:
+		return fmt.Errorf("unsupported export kind %q", req.Kind)
+	}
+
+	if len(req.Codes) == 0 {
+		req.Codes = codes
+	} else {
+		exists := make(map[string]bool, len(codes))
+		for _, code := range codes {
+			exists[code] = true
+		}
+		selected := make(map[string]bool, len(req.Codes))
+		var unique []string
+		for _, code := range req.Codes {
+			if !exists[code] {
+				return fmt.Errorf("product(%s) %s(%s) not found", product.Id, req.Kind, code)
+			}
+			if !selected[code] {
+				selected[code] = true
+				unique = append(unique, code)
+			}
+		}
+		req.Codes = unique
+	}
+	if len(req.Codes) == 0 {
+		return fmt.Errorf("product(%s) has no %s to export", product.Id, req.Kind)
+	}
+
+	if now := utils.MakeTimestamp(); req.End > now {
+		req.End = now
+	}
+	if req.Start > req.End {
+		return fmt.Errorf("export start %d is after end %d", req.Start, req.End)
+	}
+	return nil
+}
+
+// devices 按 id 排序，指定的设备必须属于导出的产品
+func (app *dataExportApp) devices(req dtos.DataExportRequest) ([]models.Device, error) {
+	var devices []models.Device
+	if len(req.DeviceIds) == 0 {
+		all, _, err := app.dbClient.DevicesSearch(0, -1, dtos.DeviceSearchQueryRequest{ProductId: req.ProductId})
+		if err != nil {
+			return nil, err
+		}
+		devices = all
+	} else {
+		for _, id := range req.DeviceIds {
+			device, err := app.dbClient.DeviceById(id)
+			if err != nil {
+				return nil, err
+			}
+			if device.ProductId != req.ProductId {
+				return nil, errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("device(%s) does not belong to product(%s)", id, req.ProductId))
+			}
+			devices = append(devices, device)
+		}
+	}
+	if len(devices) == 0 {
+		return nil, errort.NewCommonErr(errort.DeviceNotExist, fmt.Errorf("no device matched"))
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Id < devices[j].Id
+	})
+	return devices, nil
+}
+
+// add 创建任务，运行中的任务数达到上限时拒绝
+func (app *dataExportApp) add(req dtos.DataExportRequest, product models.Product, devices int) (*exportJob, error) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	var running int
+	app.jobs.Range(func(key, value interface{}) bool {
+		if value.(*exportJob).running() {
+			running++
+		}
+		return true
+	})
+	if running >= app.maxRunning {
+		return nil, errort.NewCommonErr(errort.DataExportJobLimit, fmt.Errorf("%d export jobs are running, limit %d", running, app.maxRunning))
+	}
+
+	now := time.Now()
+	jobId := utils.GenUUID()
+	job := &exportJob{
+		resp: dtos.DataExportJobResponse{
+			JobId:        jobId,
+			Status:       constants.DataExportJobRunning,
+			ProductId:    product.Id,
+			Kind:         req.Kind,
+			Format:       req.Format,
+			Start:        req.Start,
+			End:          req.End,
+			FileName:     fmt.Sprintf("%s_%s_%s.%s", product.Id, req.Kind, now.Format("20060102150405"), req.Format),
+			TotalDevices: devices,
+			Created:      utils.MakeTimestamp(),
+		},
+		path: filepath.Join(app.dir, jobId+"."+string(req.Format)),
+	}
+	app.jobs.Store(jobId, job)
+	return job, nil
+}
+
+// get 过期的任务在查询时删除，不等待 expireMonitor
+func (app *dataExportApp) get(jobId string) (*exportJob, bool) {
+	v, ok := app.jobs.Load(jobId)
+	if !ok {
+		return nil, false
+	}
+	job := v.(*exportJob)
+	if job.expired(time.Now()) {
+		app.remove(job)
+		return nil, false
+	}
+	return job, true
+}
+
+// run 导出失败时删除文件
+func (app *dataExportApp) run(job *exportJob, e *exporter, devices []models.Device) {
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			app.lc.Error("Panic:", r)
+			err = fmt.Errorf("%v", r)
+		}
+		var size int64
+		if err == nil {
+			if info, statErr := os.Stat(job.path); statErr == nil {
+				size = info.Size()
+			}
+		} else {
+			app.lc.Errorf("data export job %s err: %v", job.resp.JobId, err)
+			_ = os.Remove(job.path)
+		}
+		job.finish(size, err, app.expire)
+	}()
+	err = app.write(job, e, devices)
+}
+
+func (app *dataExportApp) write(job *exportJob, e *exporter, devices []models.Device) (err error) {
+	f, err := os.Create(job.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	w, err := newRowWriter(e.req.Format, f, e.columns())
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		rows, err := e.exportDevice(app.ctx, device, w)
+		if err != nil {
+			return fmt.Errorf("export device %s: %w", device.Id, err)
+		}
+		job.deviceExported(rows)
+	}
+	return w.Close()
+}
+
+func (app *dataExportApp) DataExportJobById(ctx context.Context, jobId string) (dtos.DataExportJobResponse, error) {
+	job, ok := app.get(jobId)
+	if !ok {
+		return dtos.DataExportJobResponse{}, errort.NewCommonErr(errort.DataExportJobNotExist, fmt.Errorf("export job(%s) not found", jobId))
+	}
+	return job.snapshot(), nil
+}
+
+func (app *dataExportApp) DataExportFile(ctx context.Context, jobId string) (string, string, error) {
+	job, ok := app.get(jobId)
+	if !ok {
+		return "", "", errort.NewCommonErr(errort.DataExportJobNotExist, fmt.Errorf("export job(%s) not found", jobId))
+	}
+	resp := job.snapshot()
+	if resp.Status != constants.DataExportJobFinished {
+		return "", "", errort.NewCommonErr(errort.DataExportJobNotFinished, fmt.Errorf("export job(%s) is %s", jobId, resp.Status))
+	}
+	return job.path, resp.FileName, nil
+}
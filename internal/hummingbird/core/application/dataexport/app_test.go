@@ -0,0 +1,54 @@
+package dataexport
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+func TestDataExportAppJobs(t *testing.T) {
+	app := &dataExportApp{
+		lc:         logger.NewMockClient(),
+		dir:        t.TempDir(),
+		expire:     time.Minute,
+		maxRunning: 1,
+	}
+	req := dtos.DataExportRequest{Kind: constants.Property, Format: constants.DataExportFormatCSV}
+	product := models.Product{Id: "product1"}
+
+	job1, err := app.add(req, product, 1)
+	require.NoError(t, err)
+	_, err = app.add(req, product, 1)
+	assert.True(t, errort.Is(errort.DataExportJobLimit, err))
+
+	require.NoError(t, os.WriteFile(job1.path, []byte("a"), 0644))
+	job1.finish(1, nil, app.expire)
+	job2, err := app.add(req, product, 1)
+	require.NoError(t, err)
+	_, ok := app.get(job1.resp.JobId)
+	assert.True(t, ok)
+
+	// 定时清理只删除过期的任务，运行中的任务保留
+	app.removeExpired(time.Now().Add(2 * time.Minute))
+	_, ok = app.get(job1.resp.JobId)
+	assert.False(t, ok)
+	assert.NoFileExists(t, job1.path)
+	_, ok = app.get(job2.resp.JobId)
+	assert.True(t, ok)
+
+	// 查询时已过期的任务直接删除
+	require.NoError(t, os.WriteFile(job2.path, []byte("b"), 0644))
+	job2.finish(1, nil, -time.Second)
+	_, err = app.DataExportJobById(context.Background(), job2.resp.JobId)
+	assert.True(t, errort.Is(errort.DataExportJobNotExist, err))
+	assert.NoFileExists(t, job2.path)
+}
@@ -0,0 +1,209 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dataexport
+
+import (
+	"context"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/parquet"
+	"github.com/winc-link/hummingbird/internal/tools/datadb"
+)
+
+// cursor 按时间从早到晚读取一个标识符的数据，内存中最多保存一个时间窗口的数据
+type cursor struct {
+	code    string
+	reader  *datadb.HistoryReader
+	records []datadb.HistoryRecord
+}
+
+func newCursor(code string, reader *datadb.HistoryReader) (*cursor, error) {
+	c := &cursor{code: code, reader: reader}
+	return c, c.load()
+}
+
+// load 当前窗口读完后读取下一个窗口
+func (c *cursor) load() error {
+	for len(c.records) == 0 && !c.reader.Done() {
+		records, err := c.reader.Next()
+		if err != nil {
+			return err
+		}
+		c.records = records
+	}
+	return nil
+}
+
+func (c *cursor) head() (datadb.HistoryRecord, bool) {
+	if len(c.records) == 0 {
+		return datadb.HistoryRecord{}, false
+	}
+	return c.records[0], true
+}
+
+func (c *cursor) pop() error {
+	c.records = c.records[1:]
+	return c.load()
+}
+
+// exporter 按设备依次导出，同一设备的各标识符按时间归并：属性每个时间一行、每个属性一列，事件和服务每条记录一行
+type exporter struct {
+	dataDbClient interfaces.DataDBClient
+	req          dtos.DataExportRequest
+	product      models.Product
+	pageSize     int
+}
+
+const (
+	columnDeviceId     = "device_id"
+	columnTime         = "time"
+	columnCode         = "code"
+	columnMsgId        = "msg_id"
+	columnInputParams  = "input_params"
+	columnOutputParams = "output_params"
+)
+
+func (e *exporter) columns() []parquet.Column {
+	columns := []parquet.Column{
+		{Name: columnDeviceId, Type: parquet.ByteArray, Logical: parquet.LogicalString, Required: true},
+		{Name: columnTime, Type: parquet.Int64, Logical: parquet.LogicalTimestampMillis, Required: true},
+	}
+	switch e.req.Kind {
+	case constants.Property:
+		for _, code := range e.req.Codes {
+			columns = append(columns, propertyColumn(e.property(code)))
+		}
+	case constants.Event:
+		columns = append(columns,
+			parquet.Column{Name: columnCode, Type: parquet.ByteArray, Logical: parquet.LogicalString, Required: true},
+			parquet.Column{Name: columnOutputParams, Type: parquet.ByteArray, Logical: parquet.LogicalJSON},
+		)
+	default:
+		columns = append(columns,
+			parquet.Column{Name: columnCode, Type: parquet.ByteArray, Logical: parquet.LogicalString, Required: true},
+			parquet.Column{Name: columnMsgId, Type: parquet.ByteArray, Logical: parquet.LogicalString},
+			parquet.Column{Name: columnInputParams, Type: parquet.ByteArray, Logical: parquet.LogicalJSON},
+			parquet.Column{Name: columnOutputParams, Type: parquet.ByteArray, Logical: parquet.LogicalJSON},
+		)
+	}
+	return columns
+}
+
+// propertyColumn 属性的列类型，枚举和日期按字符串导出，结构体和数组为 json
+func propertyColumn(property models.Properties) parquet.Column {
+	column := parquet.Column{Name: property.Code}
+	switch property.TypeSpec.Type {
+	case constants.SpecsTypeInt:
+		column.Type = parquet.Int64
+	case constants.SpecsTypeFloat:
+		column.Type = parquet.Double
+	case constants.SpecsTypeBool:
+		column.Type = parquet.Boolean
+	case constants.SpecsTypeStruct, constants.SpecsTypeArray:
+		column.Type, column.Logical = parquet.ByteArray, parquet.LogicalJSON
+	default:
+		column.Type, column.Logical = parquet.ByteArray, parquet.LogicalString
+	}
+	return column
+}
+
+func (e *exporter) property(code string) models.Properties {
+	for _, property := range e.product.Properties {
+		if property.Code == code {
+			return property
+		}
+	}
+	return models.Properties{Code: code}
+}
+
+// exportDevice 返回写入的行数
+func (e *exporter) exportDevice(ctx context.Context, device models.Device, w rowWriter) (int64, error) {
+	cursors := make([]*cursor, 0, len(e.req.Codes))
+	for _, code := range e.req.Codes {
+		reader := datadb.NewHistoryReader(e.dataDbClient, device, e.product, e.req.Kind, code, e.req.Start, e.req.End, e.pageSize)
+		c, err := newCursor(code, reader)
+		if err != nil {
+			return 0, err
+		}
+		cursors = append(cursors, c)
+	}
+
+	var rows int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return rows, err
+		}
+		var earliest int64
+		var found bool
+		for _, c := range cursors {
+			if r, ok := c.head(); ok && (!found || r.Time < earliest) {
+				earliest, found = r.Time, true
+			}
+		}
+		if !found {
+			return rows, nil
+		}
+
+		if e.req.Kind == constants.Property {
+			row := make([]interface{}, 2+len(cursors))
+			row[0], row[1] = device.Id, earliest
+			for i, c := range cursors {
+				if r, ok := c.head(); ok && r.Time == earliest {
+					row[2+i] = r.Value
+					if err := c.pop(); err != nil {
+						return rows, err
+					}
+				}
+			}
+			if err := w.Write(row); err != nil {
+				return rows, err
+			}
+			rows++
+			continue
+		}
+		for _, c := range cursors {
+			for r, ok := c.head(); ok && r.Time == earliest; r, ok = c.head() {
+				if err := w.Write(recordRow(device.Id, c.code, r)); err != nil {
+					return rows, err
+				}
+				rows++
+				if err := c.pop(); err != nil {
+					return rows, err
+				}
+			}
+		}
+	}
+}
+
+func recordRow(deviceId, code string, r datadb.HistoryRecord) []interface{} {
+	switch data := r.Value.(type) {
+	case dtos.EventData:
+		return []interface{}{deviceId, r.Time, code, paramsValue(data.OutputParams)}
+	case dtos.SaveServiceIssueData:
+		return []interface{}{deviceId, r.Time, code, data.MsgId, paramsValue(data.InputParams), paramsValue(data.OutputParams)}
+	}
+	return nil
+}
+
+// paramsValue 没有参数时导出为空值
+func paramsValue(params map[string]interface{}) interface{} {
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
@@ -0,0 +1,102 @@
+package dataexport
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/sqlite/sqlitetest"
+)
+
+func setup(t *testing.T) (*exporter, models.Device) {
+	ctx := context.Background()
+	product := models.Product{
+		Id: "product1",
+		Properties: []models.Properties{
+			{Code: "temperature", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat}},
+			{Code: "switch", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeBool}},
+		},
+		Events: []models.Events{{Code: "alarm"}},
+	}
+	device := models.Device{Id: "device1", ProductId: product.Id}
+	client := sqlitetest.NewClientWithDevices(t, product, device)
+
+	// temperature 每秒一条，switch 每两秒一条，alarm 在 3s、4s
+	table := constants.DB_PREFIX + device.Id
+	var records []dtos.DataRecord
+	for i := int64(1); i <= 5; i++ {
+		values := map[string]interface{}{"temperature": float64(i)}
+		if i%2 == 0 {
+			values["switch"] = i == 4
+		}
+		records = append(records, dtos.DataRecord{Table: table, Time: i * 1000, Values: values})
+	}
+	for i := int64(3); i <= 4; i++ {
+		records = append(records, dtos.DataRecord{Table: table, Time: i * 1000, Events: map[string]dtos.EventData{
+			"alarm": {EventCode: "alarm", EventTime: i * 1000, OutputParams: map[string]interface{}{"level": i}},
+		}})
+	}
+	require.NoError(t, client.BatchInsert(ctx, records))
+
+	return &exporter{
+		dataDbClient: client,
+		req: dtos.DataExportRequest{
+			ProductId: product.Id,
+			Kind:      constants.Property,
+			Codes:     []string{"temperature", "switch"},
+			Start:     0,
+			End:       10000,
+		},
+		product:  product,
+		pageSize: 2,
+	}, device
+}
+
+func export(t *testing.T, e *exporter, device models.Device, format constants.DataExportFormat) (string, int64) {
+	var buf bytes.Buffer
+	w, err := newRowWriter(format, &buf, e.columns())
+	require.NoError(t, err)
+	rows, err := e.exportDevice(context.Background(), device, w)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.String(), rows
+}
+
+func TestExportProperty(t *testing.T) {
+	e, device := setup(t)
+
+	out, rows := export(t, e, device, constants.DataExportFormatCSV)
+	assert.Equal(t, int64(5), rows)
+	assert.Equal(t, "device_id,time,temperature,switch\n"+
+		"device1,1000,1,\n"+
+		"device1,2000,2,false\n"+
+		"device1,3000,3,\n"+
+		"device1,4000,4,true\n"+
+		"device1,5000,5,\n", out)
+
+	e.req.Start = 2000
+	e.req.End = 3000
+	out, rows = export(t, e, device, constants.DataExportFormatNDJSON)
+	assert.Equal(t, int64(2), rows)
+	assert.Equal(t, `{"device_id":"device1","time":2000,"temperature":2,"switch":false}`+"\n"+
+		`{"device_id":"device1","time":3000,"temperature":3}`+"\n", out)
+}
+
+func TestExportEvent(t *testing.T) {
+	e, device := setup(t)
+	e.req.Kind = constants.Event
+	e.req.Codes = []string{"alarm"}
+
+	out, rows := export(t, e, device, constants.DataExportFormatNDJSON)
+	assert.Equal(t, int64(2), rows)
+	assert.Equal(t, `{"device_id":"device1","time":3000,"code":"alarm","output_params":{"level":3}}`+"\n"+
+		`{"device_id":"device1","time":4000,"code":"alarm","output_params":{"level":4}}`+"\n", out)
+
+	out, _ = export(t, e, device, constants.DataExportFormatParquet)
+	assert.Equal(t, "PAR1", out[:4])
+}
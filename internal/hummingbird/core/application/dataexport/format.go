@@ -0,0 +1,213 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package dataexport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/parquet"
+)
+
+// rowWriter 按列的顺序写入一行，值为从时序库读取的原始值，由各格式转换
+type rowWriter interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+func newRowWriter(format constants.DataExportFormat, w io.Writer, columns []parquet.Column) (rowWriter, error) {
+	switch format {
+	case constants.DataExportFormatCSV:
+		return newCSVWriter(w, columns)
+	case constants.DataExportFormatNDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(w), columns: columns}, nil
+	case constants.DataExportFormatParquet:
+		pw, err := parquet.NewWriter(w, columns, parquet.DefaultRowGroupSize)
+		if err != nil {
+			return nil, err
+		}
+		return &parquetWriter{w: pw, columns: columns}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+// newCSVWriter 第一行为列名
+func newCSVWriter(w io.Writer, columns []parquet.Column) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	for i, c := range columns {
+		cw.record[i] = c.Name
+	}
+	return cw, cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Write(row []interface{}) error {
+	for i, v := range row {
+		cw.record[i] = formatValue(v)
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// ndjsonWriter 每行一个 json 对象，按列的顺序输出，空值不输出。值按列的类型转换，结构体和数组保持原始结构
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []parquet.Column
+}
+
+func (nw *ndjsonWriter) Write(row []interface{}) error {
+	nw.w.WriteByte('{')
+	first := true
+	for i, v := range row {
+		if nw.columns[i].Logical != parquet.LogicalJSON {
+			v = convertValue(nw.columns[i].Type, v)
+		}
+		if v == nil {
+			continue
+		}
+		name, _ := json.Marshal(nw.columns[i].Name)
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if !first {
+			nw.w.WriteByte(',')
+		}
+		first = false
+		nw.w.Write(name)
+		nw.w.WriteByte(':')
+		nw.w.Write(value)
+	}
+	nw.w.WriteString("}\n")
+	return nil
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.w.Flush()
+}
+
+// parquetWriter 值按列的类型转换，无法转换的值写为空
+type parquetWriter struct {
+	w       *parquet.Writer
+	columns []parquet.Column
+	row     []interface{}
+}
+
+func (pw *parquetWriter) Write(row []interface{}) error {
+	pw.row = pw.row[:0]
+	for i, v := range row {
+		pw.row = append(pw.row, convertValue(pw.columns[i].Type, v))
+	}
+	return pw.w.Write(pw.row)
+}
+
+func (pw *parquetWriter) Close() error {
+	return pw.w.Close()
+}
+
+// formatValue csv 中的值，结构体和数组为 json 字符串
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(value)
+		return string(b)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// convertValue 转换为 parquet 列的类型，tdengine 中的值均为字符串，需要按列的类型解析
+func convertValue(typ parquet.Type, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch typ {
+	case parquet.Int64:
+		switch value := v.(type) {
+		case int64:
+			return value
+		case int:
+			return int64(value)
+		case float64:
+			return int64(value)
+		case json.Number:
+			if i, err := value.Int64(); err == nil {
+				return i
+			}
+		case string:
+			if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return i
+			}
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return int64(f)
+			}
+		}
+	case parquet.Double:
+		switch value := v.(type) {
+		case float64:
+			return value
+		case float32:
+			return float64(value)
+		case int64:
+			return float64(value)
+		case int:
+			return float64(value)
+		case json.Number:
+			if f, err := value.Float64(); err == nil {
+				return f
+			}
+		case string:
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f
+			}
+		}
+	case parquet.Boolean:
+		switch value := v.(type) {
+		case bool:
+			return value
+		case float64:
+			return value != 0
+		case int64:
+			return value != 0
+		case string:
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b
+			}
+		}
+	case parquet.ByteArray:
+		return formatValue(v)
+	}
+	return nil
+}
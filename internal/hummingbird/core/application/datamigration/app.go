@@ -0,0 +1,124 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package datamigration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/pkg/container"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+// dataMigrationApp 在后台运行迁移，进度通过 websocket 推送
+type dataMigrationApp struct {
+	ctx context.Context
+	dic *di.Container
+	lc  logger.LoggingClient
+
+	mutex    sync.Mutex
+	cancel   context.CancelFunc
+	migrator *Migrator
+	// progress 没有迁移在运行时返回的进度
+	progress dtos.DataMigrationProgress
+}
+
+// NewDataMigrationApp 从进度文件读取上次迁移的进度，服务停止时正在运行的迁移状态为已停止
+func NewDataMigrationApp(ctx context.Context, dic *di.Container) *dataMigrationApp {
+	lc := container.LoggingClientFrom(dic.Get)
+	app := &dataMigrationApp{
+		ctx: ctx,
+		dic: dic,
+		lc:  lc,
+		progress: dtos.DataMigrationProgress{
+			Status: dtos.DataMigrationIdle,
+		},
+	}
+	path := resourceContainer.ConfigurationFrom(dic.Get).DataMigration.CheckpointPath
+	cp, err := loadCheckpoint(path)
+	if err != nil {
+		lc.Errorf("load data migration checkpoint %s err: %v", path, err)
+	} else if cp != nil {
+		app.progress = cp.Progress
+		if app.progress.Status == dtos.DataMigrationRunning {
+			app.progress.Status = dtos.DataMigrationStopped
+		}
+	}
+	return app
+}
+
+func (app *dataMigrationApp) Start(ctx context.Context, req dtos.DataMigrationRequest) error {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	if app.cancel != nil {
+		return errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("data migration is running"))
+	}
+
+	cfg := resourceContainer.ConfigurationFrom(app.dic.Get)
+	m, err := NewMigrator(app.lc, resourceContainer.DBClientFrom(app.dic.Get), cfg.DataMigration, req)
+	if err != nil {
+		return errort.NewCommonErr(errort.DefaultReqParamsError, err)
+	}
+	clients, err := OpenClients(m.Request(), cfg, resourceContainer.DataDBClientFrom(app.dic.Get), app.lc)
+	if err != nil {
+		return errort.NewCommonErr(errort.DefaultReqParamsError, err)
+	}
+	m.OnProgress = app.broadcast
+
+	runCtx, cancel := context.WithCancel(app.ctx)
+	app.cancel, app.migrator = cancel, m
+	go func() {
+		defer clients.Close()
+		if err := m.Run(runCtx, clients.Source, clients.Target); err != nil {
+			app.lc.Errorf("data migration err: %v", err)
+		}
+		app.mutex.Lock()
+		app.cancel()
+		app.cancel, app.migrator = nil, nil
+		app.progress = m.Progress()
+		app.mutex.Unlock()
+	}()
+	return nil
+}
+
+func (app *dataMigrationApp) Stop() {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	if app.cancel != nil {
+		app.cancel()
+	}
+}
+
+func (app *dataMigrationApp) Progress() dtos.DataMigrationProgress {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	if app.migrator != nil {
+		return app.migrator.Progress()
+	}
+	return app.progress
+}
+
+func (app *dataMigrationApp) broadcast(progress dtos.DataMigrationProgress) {
+	container.StreamClientFrom(app.dic.Get).Send(dtos.RpcData{
+		Code:    dtos.WsCodeDataMigration,
+		ErrCode: errort.DefaultSuccess,
+		Data:    progress,
+	})
+}
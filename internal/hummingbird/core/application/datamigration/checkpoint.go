@@ -0,0 +1,87 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package datamigration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/tools/datadb"
+)
+
+// position 正在迁移的设备中最后写入的标识符，Cursor 为该标识符下一个要读取的窗口
+type position struct {
+	DeviceId string               `json:"deviceId"`
+	Kind     string               `json:"kind"`
+	Code     string               `json:"code"`
+	Cursor   datadb.HistoryCursor `json:"cursor"`
+}
+
+// checkpoint 迁移的参数和进度，每写入一个时间窗口保存一次。
+// 进程在写入目标库之后、保存进度之前退出时，继续迁移会重新读取最后一个窗口，
+// 写入前跳过目标库中这个窗口已有的记录，tstorage 和 sqlite 中不会保存两份
+type checkpoint struct {
+	Request   dtos.DataMigrationRequest  `json:"request"`
+	Completed map[string]bool            `json:"completed"`
+	Position  position                   `json:"position"`
+	Progress  dtos.DataMigrationProgress `json:"progress"`
+}
+
+func newCheckpoint(req dtos.DataMigrationRequest) *checkpoint {
+	return &checkpoint{
+		Request:   req,
+		Completed: make(map[string]bool),
+	}
+}
+
+// loadCheckpoint 文件不存在时返回 nil
+func loadCheckpoint(path string) (*checkpoint, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c checkpoint
+	if err = json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if c.Completed == nil {
+		c.Completed = make(map[string]bool)
+	}
+	return &c, nil
+}
+
+// save 先写入临时文件再重命名，避免写入中途退出损坏进度文件
+func (c *checkpoint) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
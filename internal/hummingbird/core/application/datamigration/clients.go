@@ -0,0 +1,79 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package datamigration
+
+import (
+	"fmt"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/config"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	bootstrapConfig "github.com/winc-link/hummingbird/internal/pkg/config"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/tools/datadb"
+)
+
+// Clients 迁移的源库和目标库
+type Clients struct {
+	Source interfaces.DataDBClient
+	Target interfaces.DataDBClient
+	opened []interfaces.DataDBClient
+}
+
+// Close 关闭 OpenClients 新建的客户端，当前运行的时序库客户端不关闭
+func (c *Clients) Close() {
+	for _, client := range c.opened {
+		client.CloseSession()
+	}
+	c.opened = nil
+}
+
+// OpenClients 类型为空或与配置的时序库相同时使用 running，running 为 nil 时按配置新建；
+// 其他的按请求中的参数新建，tstorage 的保存天数使用配置的全局保存天数
+func OpenClients(req dtos.DataMigrationRequest, cfg *config.ConfigurationStruct, running interfaces.DataDBClient, lc logger.LoggingClient) (*Clients, error) {
+	primary := cfg.GetDataDatabaseInfo()["Primary"]
+	isPrimary := func(db dtos.DataMigrationDB) bool {
+		return db.Type == "" || (db.Type == primary.Type && db.Dsn == primary.Dsn && db.DataSource == primary.DataSource)
+	}
+	if isPrimary(req.Source) == isPrimary(req.Target) && (isPrimary(req.Source) || req.Source == req.Target) {
+		return nil, fmt.Errorf("data migration source and target are the same database")
+	}
+
+	clients := &Clients{}
+	open := func(db dtos.DataMigrationDB) (interfaces.DataDBClient, error) {
+		info := bootstrapConfig.Database{Type: db.Type, Dsn: db.Dsn, DataSource: db.DataSource}
+		if isPrimary(db) {
+			if running != nil {
+				return running, nil
+			}
+			info = primary
+		}
+		client, err := datadb.NewClient(info, cfg.Retention.DataRetention().Max(), lc)
+		if err != nil {
+			return nil, fmt.Errorf("open %s data database: %w", info.Type, err)
+		}
+		clients.opened = append(clients.opened, client)
+		return client, nil
+	}
+	var err error
+	if clients.Source, err = open(req.Source); err != nil {
+		return nil, err
+	}
+	if clients.Target, err = open(req.Target); err != nil {
+		clients.Close()
+		return nil, err
+	}
+	return clients, nil
+}
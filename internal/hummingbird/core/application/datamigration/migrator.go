@@ -0,0 +1,416 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package datamigration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/config"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/tools/datadb"
+)
+
+const (
+	defaultPageSize = 1000
+	reportInterval  = time.Second
+)
+
+// task 设备的一个属性、事件或服务的历史数据
+type task struct {
+	kind     string
+	code     string
+	property models.Properties
+}
+
+func productTasks(product models.Product) []task {
+	var tasks []task
+	for _, property := range product.Properties {
+		tasks = append(tasks, task{kind: constants.Property, code: property.Code, property: property})
+	}
+	for _, event := range product.Events {
+		tasks = append(tasks, task{kind: constants.Event, code: event.Code})
+	}
+	for _, action := range product.Actions {
+		tasks = append(tasks, task{kind: constants.Action, code: action.Code})
+	}
+	return tasks
+}
+
+// Migrator 按设备、属性/事件/服务的标识符依次按时间窗口读取源库写入目标库，每个标识符迁移完成后对比两边的数据条数。
+// 时间范围在开始迁移时固定，迁移过程中新写入的数据不影响读取位置
+type Migrator struct {
+	lc             logger.LoggingClient
+	dbClient       interfaces.DBClient
+	source         interfaces.DataDBClient
+	target         interfaces.DataDBClient
+	pageSize       int
+	checkpointPath string
+	// OnProgress 进度变化时调用，迁移过程中最多每秒调用一次
+	OnProgress func(dtos.DataMigrationProgress)
+
+	mutex      sync.Mutex
+	checkpoint *checkpoint
+	reportTime time.Time
+}
+
+// NewMigrator 继续迁移时从进度文件读取上次的参数，否则覆盖进度文件重新开始
+func NewMigrator(lc logger.LoggingClient, dbClient interfaces.DBClient, cfg config.DataMigrationInfo, req dtos.DataMigrationRequest) (*Migrator, error) {
+	m := &Migrator{
+		lc:             lc,
+		dbClient:       dbClient,
+		pageSize:       cfg.PageSize,
+		checkpointPath: cfg.CheckpointPath,
+	}
+	if m.pageSize <= 0 {
+		m.pageSize = defaultPageSize
+	}
+	if req.Resume {
+		cp, err := loadCheckpoint(m.checkpointPath)
+		if err != nil {
+			return nil, err
+		}
+		if cp == nil {
+			return nil, fmt.Errorf("no data migration checkpoint to resume in %q", m.checkpointPath)
+		}
+		m.checkpoint = cp
+		return m, nil
+	}
+	if req.End == 0 {
+		req.End = time.Now().UnixMilli()
+	}
+	if req.Start > req.End {
+		return nil, fmt.Errorf("data migration start %d is after end %d", req.Start, req.End)
+	}
+	m.checkpoint = newCheckpoint(req)
+	return m, nil
+}
+
+// Request 迁移的参数，继续迁移时为上次的参数
+func (m *Migrator) Request() dtos.DataMigrationRequest {
+	return m.checkpoint.Request
+}
+
+func (m *Migrator) Progress() dtos.DataMigrationProgress {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	progress := m.checkpoint.Progress
+	progress.Mismatches = append([]dtos.DataMigrationMismatch(nil), progress.Mismatches...)
+	return progress
+}
+
+// Run 把源库的数据迁移到目标库，ctx 取消时停止，已迁移的位置保存在进度文件中
+func (m *Migrator) Run(ctx context.Context, source, target interfaces.DataDBClient) error {
+	m.source, m.target = source, target
+	req := m.checkpoint.Request
+	m.update(true, func(p *dtos.DataMigrationProgress) {
+		p.Status = dtos.DataMigrationRunning
+		p.Source = string(source.GetDataDBType())
+		p.Target = string(target.GetDataDBType())
+		p.Start, p.End = req.Start, req.End
+		p.Error = ""
+		if p.StartTime == 0 {
+			p.StartTime = time.Now().UnixMilli()
+		}
+	})
+
+	err := m.run(ctx)
+	m.update(true, func(p *dtos.DataMigrationProgress) {
+		switch {
+		case err == nil:
+			p.Status = dtos.DataMigrationCompleted
+			p.CurrentDevice = ""
+		case ctx.Err() != nil:
+			p.Status = dtos.DataMigrationStopped
+		default:
+			p.Status = dtos.DataMigrationFailed
+			p.Error = err.Error()
+		}
+	})
+	if err := m.save(); err != nil {
+		m.lc.Errorf("save data migration checkpoint err: %v", err)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (m *Migrator) run(ctx context.Context) error {
+	devices, err := m.devices()
+	if err != nil {
+		return err
+	}
+	m.update(false, func(p *dtos.DataMigrationProgress) {
+		p.TotalDevices = len(devices)
+		p.MigratedDevices = 0
+		for _, device := range devices {
+			if m.checkpoint.Completed[device.Id] {
+				p.MigratedDevices++
+			}
+		}
+	})
+
+	products := make(map[string]models.Product)
+	for _, device := range devices {
+		if m.checkpoint.Completed[device.Id] {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		product, ok := products[device.ProductId]
+		if !ok {
+			if product, err = m.dbClient.ProductById(device.ProductId); err != nil {
+				return fmt.Errorf("device %s product %s: %w", device.Id, device.ProductId, err)
+			}
+			if err = m.target.CreateStable(ctx, product); err != nil {
+				return fmt.Errorf("create stable of product %s: %w", product.Id, err)
+			}
+			products[product.Id] = product
+		}
+		if err = m.target.CreateTable(ctx, product.Id, device.Id); err != nil {
+			return fmt.Errorf("create table of device %s: %w", device.Id, err)
+		}
+		m.update(true, func(p *dtos.DataMigrationProgress) {
+			p.CurrentDevice = device.Id
+		})
+		if err = m.migrateDevice(ctx, device, product); err != nil {
+			return err
+		}
+		m.update(true, func(p *dtos.DataMigrationProgress) {
+			m.checkpoint.Completed[device.Id] = true
+			m.checkpoint.Position = position{}
+			p.MigratedDevices++
+		})
+		if err = m.save(); err != nil {
+			return err
+		}
+		m.lc.Infof("data migration of device %s completed", device.Id)
+	}
+	return nil
+}
+
+// devices 按 id 排序，保证继续迁移时顺序不变
+func (m *Migrator) devices() ([]models.Device, error) {
+	var devices []models.Device
+	if len(m.checkpoint.Request.DeviceIds) == 0 {
+		all, _, err := m.dbClient.DevicesSearch(0, -1, dtos.DeviceSearchQueryRequest{})
+		if err != nil {
+			return nil, err
+		}
+		devices = all
+	} else {
+		for _, id := range m.checkpoint.Request.DeviceIds {
+			device, err := m.dbClient.DeviceById(id)
+			if err != nil {
+				return nil, fmt.Errorf("device %s: %w", id, err)
+			}
+			devices = append(devices, device)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Id < devices[j].Id
+	})
+	return devices, nil
+}
+
+// migrateDevice 继续迁移时从进度中记录的标识符的下一个窗口开始，标识符已从物模型中删除时从头开始
+func (m *Migrator) migrateDevice(ctx context.Context, device models.Device, product models.Product) error {
+	tasks := productTasks(product)
+	start := 0
+	var cursor *datadb.HistoryCursor
+	if pos := m.checkpoint.Position; pos.DeviceId == device.Id && pos.Cursor.Span > 0 {
+		for i, t := range tasks {
+			if t.kind == pos.Kind && t.code == pos.Code {
+				start, cursor = i, &pos.Cursor
+				break
+			}
+		}
+	}
+	for _, t := range tasks[start:] {
+		if err := m.migrateTask(ctx, device, product, t, cursor); err != nil {
+			return fmt.Errorf("migrate %s %s of device %s: %w", t.kind, t.code, device.Id, err)
+		}
+		cursor = nil
+	}
+	return nil
+}
+
+func (m *Migrator) migrateTask(ctx context.Context, device models.Device, product models.Product, t task, cursor *datadb.HistoryCursor) error {
+	req := m.checkpoint.Request
+	reader := datadb.NewHistoryReader(m.source, device, product, t.kind, t.code, req.Start, req.End, m.pageSize)
+	if cursor != nil {
+		reader.Cursor = *cursor
+	}
+	table := constants.DB_PREFIX + device.Id
+	resumed := cursor != nil
+	for !reader.Done() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := reader.Cursor.Time
+		history, err := reader.Next()
+		if err != nil {
+			return err
+		}
+		migrated := len(history)
+		if resumed && len(history) > 0 {
+			// 上次退出时这个窗口可能已经写入目标库，跳过目标库中已有的记录
+			if history, err = m.skipMigrated(device, product, t, history, start, reader.Cursor.Time); err != nil {
+				return err
+			}
+			resumed = false
+		}
+		records := make([]dtos.DataRecord, 0, len(history))
+		for _, h := range history {
+			record := dtos.DataRecord{Table: table, Time: h.Time}
+			switch v := h.Value.(type) {
+			case dtos.EventData:
+				record.Events = map[string]dtos.EventData{t.code: v}
+			case dtos.SaveServiceIssueData:
+				record.Services = map[string]dtos.SaveServiceIssueData{t.code: v}
+			default:
+				record.Values = map[string]interface{}{t.code: v}
+			}
+			records = append(records, record)
+		}
+		if len(records) > 0 {
+			if err = m.target.BatchInsert(ctx, records); err != nil {
+				return err
+			}
+		}
+		m.update(false, func(p *dtos.DataMigrationProgress) {
+			m.checkpoint.Position = position{DeviceId: device.Id, Kind: t.kind, Code: t.code, Cursor: reader.Cursor}
+			switch t.kind {
+			case constants.Property:
+				p.Properties += int64(migrated)
+			case constants.Event:
+				p.Events += int64(migrated)
+			default:
+				p.Services += int64(migrated)
+			}
+		})
+		if err = m.save(); err != nil {
+			return err
+		}
+	}
+
+	total, err := m.count(m.source, device, product, t)
+	if err != nil {
+		return err
+	}
+	migrated, err := m.count(m.target, device, product, t)
+	if err != nil {
+		return err
+	}
+	if migrated < total {
+		m.lc.Warnf("data migration of device %s %s %s: source %d, target %d", device.Id, t.kind, t.code, total, migrated)
+		m.update(true, func(p *dtos.DataMigrationProgress) {
+			p.Mismatches = append(p.Mismatches, dtos.DataMigrationMismatch{
+				DeviceId: device.Id,
+				Kind:     t.kind,
+				Code:     t.code,
+				Source:   total,
+				Target:   migrated,
+			})
+		})
+	}
+	return nil
+}
+
+// skipMigrated 去掉目标库 [start, limit) 内已有的记录。同一时间目标库中有 n 条记录时，跳过源库该时间的前 n 条，
+// 重复执行写入目标库之后、保存进度之前中断的窗口时不会重复写入
+func (m *Migrator) skipMigrated(device models.Device, product models.Product, t task, history []datadb.HistoryRecord, start, limit int64) ([]datadb.HistoryRecord, error) {
+	existing := make(map[int64]int)
+	reader := datadb.NewHistoryReader(m.target, device, product, t.kind, t.code, start, limit-1, m.pageSize)
+	for !reader.Done() {
+		records, err := reader.Next()
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			existing[record.Time]++
+		}
+	}
+	if len(existing) == 0 {
+		return history, nil
+	}
+	remaining := history[:0]
+	for _, record := range history {
+		if existing[record.Time] > 0 {
+			existing[record.Time]--
+			continue
+		}
+		remaining = append(remaining, record)
+	}
+	m.lc.Infof("data migration of device %s %s %s skip %d records already in target", device.Id, t.kind, t.code, len(history)-len(remaining))
+	return remaining, nil
+}
+
+// count 迁移时间范围内的总条数
+func (m *Migrator) count(client interfaces.DataDBClient, device models.Device, product models.Product, t task) (int, error) {
+	req := m.checkpoint.Request
+	timeRange := []int64{req.Start, req.End}
+	switch t.kind {
+	case constants.Property:
+		propertyReq := dtos.ThingModelPropertyDataRequest{DeviceId: device.Id, Code: t.code}
+		propertyReq.Range = timeRange
+		propertyReq.Page, propertyReq.PageSize = 1, 1
+		_, count, err := datadb.GetDeviceProperty(client, propertyReq, device, t.property)
+		return count, err
+	case constants.Event:
+		eventReq := dtos.ThingModelEventDataRequest{DeviceId: device.Id, EventCode: t.code}
+		eventReq.Range = timeRange
+		eventReq.Page, eventReq.PageSize = 1, 1
+		_, count, err := client.GetDeviceEvent(eventReq, device, product)
+		return count, err
+	default:
+		serviceReq := dtos.ThingModelServiceDataRequest{DeviceId: device.Id, Code: t.code}
+		serviceReq.Range = timeRange
+		serviceReq.Page, serviceReq.PageSize = 1, 1
+		_, count, err := client.GetDeviceService(serviceReq, device, product)
+		return count, err
+	}
+}
+
+// update 修改进度并回调，force 为 false 时距上次回调不足 reportInterval 不回调
+func (m *Migrator) update(force bool, fn func(p *dtos.DataMigrationProgress)) {
+	m.mutex.Lock()
+	fn(&m.checkpoint.Progress)
+	now := time.Now()
+	m.checkpoint.Progress.UpdateTime = now.UnixMilli()
+	report := m.OnProgress != nil && (force || now.Sub(m.reportTime) >= reportInterval)
+	if report {
+		m.reportTime = now
+	}
+	m.mutex.Unlock()
+	if report {
+		m.OnProgress(m.Progress())
+	}
+}
+
+func (m *Migrator) save() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.checkpoint.save(m.checkpointPath)
+}
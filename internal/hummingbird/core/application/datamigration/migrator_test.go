@@ -0,0 +1,188 @@
+package datamigration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/config"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/tools/datadb"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/leveldb"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/sqlite/sqlitetest"
+)
+
+type fakeDBClient struct {
+	interfaces.DBClient
+	product models.Product
+	devices []models.Device
+}
+
+func (c *fakeDBClient) DevicesSearch(offset int, limit int, req dtos.DeviceSearchQueryRequest) ([]models.Device, uint32, error) {
+	return c.devices, uint32(len(c.devices)), nil
+}
+
+func (c *fakeDBClient) ProductById(id string) (models.Product, error) {
+	return c.product, nil
+}
+
+// cancelTarget 第一次写入后取消迁移，模拟迁移中途停止
+type cancelTarget struct {
+	interfaces.DataDBClient
+	cancel context.CancelFunc
+}
+
+func (t *cancelTarget) BatchInsert(ctx context.Context, records []dtos.DataRecord) error {
+	defer t.cancel()
+	return t.DataDBClient.BatchInsert(ctx, records)
+}
+
+func setup(t *testing.T) (*fakeDBClient, interfaces.DataDBClient, interfaces.DataDBClient) {
+	source, err := leveldb.NewClient(dtos.Configuration{DataSource: t.TempDir()}, logger.NewMockClient())
+	require.NoError(t, err)
+	t.Cleanup(source.CloseSession)
+	target := sqlitetest.NewClient(t)
+
+	db := &fakeDBClient{
+		product: models.Product{
+			Id: "product1",
+			Properties: []models.Properties{
+				{Code: "temperature", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat}},
+				{Code: "location", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeStruct}},
+			},
+			Events:  []models.Events{{Code: "alarm"}},
+			Actions: []models.Actions{{Code: "reboot"}},
+		},
+		devices: []models.Device{{Id: "device2", ProductId: "product1"}, {Id: "device1", ProductId: "product1"}},
+	}
+	for _, device := range db.devices {
+		table := constants.DB_PREFIX + device.Id
+		var records []dtos.DataRecord
+		for i := int64(1); i <= 25; i++ {
+			records = append(records, dtos.DataRecord{Table: table, Time: i * 1000, Values: map[string]interface{}{
+				"temperature": float64(i),
+				"location":    map[string]interface{}{"lat": float64(i)},
+			}})
+		}
+		records = append(records,
+			dtos.DataRecord{Table: table, Time: 500, Events: map[string]dtos.EventData{
+				"alarm": {EventCode: "alarm", EventTime: 500, OutputParams: map[string]interface{}{"level": 1.0}},
+			}},
+			dtos.DataRecord{Table: table, Time: 600, Services: map[string]dtos.SaveServiceIssueData{
+				"reboot": {Code: "reboot", Time: 600, InputParams: map[string]interface{}{"delay": 5.0}},
+			}},
+		)
+		require.NoError(t, source.BatchInsert(context.Background(), records))
+	}
+	return db, source, target
+}
+
+func TestMigrateAndResume(t *testing.T) {
+	db, source, target := setup(t)
+	cfg := config.DataMigrationInfo{CheckpointPath: t.TempDir() + "/checkpoint.json", PageSize: 10}
+	req := dtos.DataMigrationRequest{Start: 0, End: 100000}
+
+	m, err := NewMigrator(logger.NewMockClient(), db, cfg, req)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	err = m.Run(ctx, source, &cancelTarget{DataDBClient: target, cancel: cancel})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, dtos.DataMigrationStopped, m.Progress().Status)
+	// 第一个窗口超过两页后缩短为 [0, 16000)，包含 15 条属性
+	assert.EqualValues(t, 15, m.Progress().Properties)
+	cp, err := loadCheckpoint(cfg.CheckpointPath)
+	require.NoError(t, err)
+	assert.Equal(t, position{DeviceId: "device1", Kind: constants.Property, Code: "temperature",
+		Cursor: datadb.HistoryCursor{Time: 16000, Span: 16000}}, cp.Position)
+
+	req.Resume = true
+	m, err = NewMigrator(logger.NewMockClient(), db, cfg, req)
+	require.NoError(t, err)
+	var reported []dtos.DataMigrationProgress
+	m.OnProgress = func(p dtos.DataMigrationProgress) {
+		reported = append(reported, p)
+	}
+	require.NoError(t, m.Run(context.Background(), source, target))
+
+	progress := m.Progress()
+	assert.Equal(t, dtos.DataMigrationCompleted, progress.Status)
+	assert.Equal(t, 2, progress.MigratedDevices)
+	assert.EqualValues(t, 100, progress.Properties)
+	assert.EqualValues(t, 2, progress.Events)
+	assert.EqualValues(t, 2, progress.Services)
+	assert.Empty(t, progress.Mismatches)
+	require.NotEmpty(t, reported)
+	assert.Equal(t, dtos.DataMigrationCompleted, reported[len(reported)-1].Status)
+
+	device := db.devices[0]
+	propertyReq := dtos.ThingModelPropertyDataRequest{DeviceId: device.Id, Code: "location"}
+	propertyReq.Range = []int64{0, 100000}
+	propertyReq.Page, propertyReq.PageSize = 1, 1
+	data, count, err := datadb.GetDeviceProperty(target, propertyReq, device, db.product.Properties[1])
+	require.NoError(t, err)
+	assert.Equal(t, 25, count)
+	assert.Equal(t, map[string]interface{}{"lat": 25.0}, data[0].Value)
+
+	eventReq := dtos.ThingModelEventDataRequest{DeviceId: device.Id, EventCode: "alarm"}
+	eventReq.Range = []int64{0, 100000}
+	eventReq.Page, eventReq.PageSize = 1, 10
+	events, _, err := target.GetDeviceEvent(eventReq, device, db.product)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1.0, events[0].OutputParams["level"])
+}
+
+func TestOpenClients(t *testing.T) {
+	cfg := &config.ConfigurationStruct{}
+	_, err := OpenClients(dtos.DataMigrationRequest{}, cfg, nil, logger.NewMockClient())
+	assert.Error(t, err)
+
+	_, err = OpenClients(dtos.DataMigrationRequest{Resume: true, Target: dtos.DataMigrationDB{Type: "unknown"}}, cfg, nil, logger.NewMockClient())
+	assert.Error(t, err)
+}
+
+func TestResumeWithoutCheckpoint(t *testing.T) {
+	cfg := config.DataMigrationInfo{CheckpointPath: t.TempDir() + "/checkpoint.json"}
+	_, err := NewMigrator(logger.NewMockClient(), &fakeDBClient{}, cfg, dtos.DataMigrationRequest{Resume: true})
+	assert.Error(t, err)
+}
+
+func TestResumeSkipsMigratedWindow(t *testing.T) {
+	db, source, target := setup(t)
+	cfg := config.DataMigrationInfo{CheckpointPath: t.TempDir() + "/checkpoint.json", PageSize: 10}
+	req := dtos.DataMigrationRequest{Start: 0, End: 100000}
+
+	m, err := NewMigrator(logger.NewMockClient(), db, cfg, req)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	err = m.Run(ctx, source, &cancelTarget{DataDBClient: target, cancel: cancel})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// 模拟写入目标库之后、保存进度之前退出：进度回到第一个窗口之前
+	cp, err := loadCheckpoint(cfg.CheckpointPath)
+	require.NoError(t, err)
+	cp.Position.Cursor = datadb.HistoryCursor{Time: 0, Span: 100001}
+	cp.Progress.Properties = 0
+	require.NoError(t, cp.save(cfg.CheckpointPath))
+
+	req.Resume = true
+	m, err = NewMigrator(logger.NewMockClient(), db, cfg, req)
+	require.NoError(t, err)
+	require.NoError(t, m.Run(context.Background(), source, target))
+	progress := m.Progress()
+	assert.EqualValues(t, 100, progress.Properties)
+	assert.Empty(t, progress.Mismatches)
+
+	device := models.Device{Id: "device1", ProductId: "product1"}
+	propertyReq := dtos.ThingModelPropertyDataRequest{DeviceId: device.Id, Code: "temperature"}
+	propertyReq.Range = []int64{0, 100000}
+	propertyReq.Page, propertyReq.PageSize = 1, 1
+	_, count, err := target.GetDeviceProperty(propertyReq, device)
+	require.NoError(t, err)
+	assert.Equal(t, 25, count)
+}
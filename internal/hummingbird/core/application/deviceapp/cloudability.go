@@ -20,6 +20,7 @@ import (
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
 	"strconv"
 
 	"github.com/winc-link/edge-driver-proto/drivercommon"
@@ -51,6 +52,12 @@ func (p deviceApp) ConnectIotPlatform(ctx context.Context, request *driverdevice
 		response.BaseResponse = baseResponse
 		return response
 	} else {
+		if deviceInfo.Status != constants.DeviceStatusOnline {
+			p.recordDeviceConnect(deviceInfo.Id, deviceInfo.ProductId, constants.DeviceOnline, constants.DeviceConnectReasonConnect)
+		}
+		p.keepAlive.expired.Delete(request.DeviceId)
+		p.keepAlive.lastActive.Store(request.DeviceId, utils.MakeTimestamp())
+		go p.DeviceShadowDeliver(context.Background(), request.DeviceId, true)
 		baseResponse.Success = true
 		baseResponse.RequestId = uuid.Generate().String()
 		response.Data = new(driverdevice.ConnectIotPlatformResponse_Data)
@@ -83,6 +90,12 @@ func (p deviceApp) DisConnectIotPlatform(ctx context.Context, request *driverdev
 		response.BaseResponse = baseResponse
 		return response
 	}
+	if deviceInfo.Status == constants.DeviceStatusOnline {
+		p.recordDeviceConnect(deviceInfo.Id, deviceInfo.ProductId, constants.DeviceOffline, constants.DeviceConnectReasonDisconnect)
+	}
+	p.keepAlive.expired.Delete(request.DeviceId)
+	p.keepAlive.lastActive.Delete(request.DeviceId)
+	p.subDevicesOffline(ctx, request.DeviceId)
 	baseResponse.Success = true
 	baseResponse.RequestId = uuid.Generate().String()
 	response.Data = new(driverdevice.DisconnectIotPlatformResponse_Data)
@@ -0,0 +1,251 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// computedState 计算属性的运行状态，保存在内存中。服务重启后 integral 从计算属性上次的值继续累计，
+// delta、rate 从重启后的第一个数据点重新开始计算
+type computedState struct {
+	// expressions 表达式 -> *models.PropertyExpression
+	expressions sync.Map
+	// devices deviceId -> *deviceComputed
+	devices sync.Map
+}
+
+// deviceComputed 单个设备被引用属性的最新值及各计算属性时间函数的状态
+type deviceComputed struct {
+	mu     sync.Mutex
+	values map[string]float64
+	// states 计算属性标识符+表达式 -> 时间函数状态，表达式修改后状态重新开始
+	states map[string]*models.ExpressionState
+}
+
+func newComputedState() *computedState {
+	return &computedState{}
+}
+
+func (s *computedState) expression(expression string) (*models.PropertyExpression, error) {
+	if v, ok := s.expressions.Load(expression); ok {
+		return v.(*models.PropertyExpression), nil
+	}
+	e, err := models.ParsePropertyExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	s.expressions.Store(expression, e)
+	return e, nil
+}
+
+func (s *computedState) device(deviceId string) *deviceComputed {
+	v, _ := s.devices.LoadOrStore(deviceId, &deviceComputed{
+		values: make(map[string]float64),
+		states: make(map[string]*models.ExpressionState),
+	})
+	return v.(*deviceComputed)
+}
+
+func (s *computedState) forget(deviceIds []string) {
+	for _, id := range deviceIds {
+		s.devices.Delete(id)
+	}
+}
+
+// DeviceComputedProperties 根据设备上报的属性计算产品中的计算属性，并将计算结果加入上报消息，
+// 计算属性随消息一起推送、写入影子和持久化，规则、场景和告警可以像普通属性一样使用。
+// thingModel 为 nil 表示设备或产品查询失败，不计算。
+func (p deviceApp) DeviceComputedProperties(ctx context.Context, msg dtos.ThingModelMessage, thingModel *dtos.DeviceThingModel) dtos.ThingModelMessage {
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT, thingmodel.OperationType_DATA_BATCH_REPORT:
+	default:
+		return msg
+	}
+	if thingModel == nil {
+		return msg
+	}
+	device := thingModel.Device
+	var computed []models.Properties
+	for _, property := range thingModel.Product.Properties {
+		if property.IsComputed() {
+			computed = append(computed, property)
+		}
+	}
+	if len(computed) == 0 {
+		return msg
+	}
+
+	reported := make(map[string]interface{})
+	var reportTime int64
+	var data interface{}
+	var output func(code string, value interface{}, t int64)
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT:
+		report, err := msg.TransformMessageDataByProperty()
+		if err != nil || report.Data == nil {
+			return msg
+		}
+		for code, value := range report.Data {
+			models.FlattenValue(code, value.Value, reported)
+			if value.Time > reportTime {
+				reportTime = value.Time
+			}
+		}
+		data = &report
+		output = func(code string, value interface{}, t int64) {
+			report.Data[code] = dtos.ReportData{Value: value, Time: t}
+		}
+	case thingmodel.OperationType_DATA_BATCH_REPORT:
+		report, err := msg.TransformMessageDataByBatchReport()
+		if err != nil || report.Data.Properties == nil {
+			return msg
+		}
+		for code, value := range report.Data.Properties {
+			models.FlattenValue(code, value.Value, reported)
+		}
+		reportTime = report.Time
+		data = &report
+		output = func(code string, value interface{}, t int64) {
+			report.Data.Properties[code] = dtos.BatchProperty{Value: value}
+		}
+	}
+	if reportTime == 0 {
+		reportTime = utils.MakeTimestamp()
+	}
+
+	state := p.computed.device(device.Id)
+	state.mu.Lock()
+	for code, value := range reported {
+		if v, ok := computedNumber(value); ok {
+			state.values[code] = v
+		}
+	}
+	var changed bool
+	for _, property := range computed {
+		expression, err := p.computed.expression(property.Expression)
+		if err != nil {
+			p.lc.Warnf("device %s computed property %s: %v", device.Id, property.Code, err)
+			continue
+		}
+		// 本次上报包含被引用的属性且被引用的属性都有值时才计算
+		var triggered, missing bool
+		for _, code := range expression.Codes() {
+			if _, ok := reported[code]; ok {
+				triggered = true
+			}
+			if _, ok := state.values[code]; !ok {
+				missing = true
+			}
+		}
+		if !triggered || missing {
+			continue
+		}
+		key := property.Code + ":" + property.Expression
+		exprState, ok := state.states[key]
+		if !ok {
+			exprState = models.NewExpressionState()
+			if expression.Accumulates() {
+				if last, ok := p.lastComputedValue(device, property.Code); ok {
+					exprState.Seed(last)
+				}
+			}
+			state.states[key] = exprState
+		}
+		value, err := expression.Eval(state.values, reportTime, exprState)
+		if errors.Is(err, models.ErrExpressionNotReady) {
+			continue
+		}
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			p.lc.Debugf("device %s computed property %s value invalid: %v", device.Id, property.Code, err)
+			continue
+		}
+		if property.TypeSpec.Type == constants.SpecsTypeInt {
+			output(property.Code, int64(math.Round(value)), reportTime)
+		} else {
+			output(property.Code, value, reportTime)
+		}
+		changed = true
+	}
+	state.mu.Unlock()
+	if !changed {
+		return msg
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return msg
+	}
+	msg.Data = string(b)
+	return msg
+}
+
+// lastComputedValue 计算属性上次的值，先查询最新值缓存，缓存中没有时查询时序库中的最后一条数据
+func (p deviceApp) lastComputedValue(device models.Device, code string) (float64, bool) {
+	if data, ok := resourceContainer.LatestValueItfFrom(p.dic.Get).Get(device.Id, code); ok {
+		return computedNumber(data.Value)
+	}
+	req := dtos.ThingModelPropertyDataRequest{DeviceId: device.Id, Code: code}
+	req.Last = true
+	data, _, err := resourceContainer.DataDBClientFrom(p.dic.Get).GetDeviceProperty(req, device)
+	if err != nil {
+		p.lc.Warnf("device %s computed property %s last value err: %v", device.Id, code, err)
+		return 0, false
+	}
+	if len(data) == 0 {
+		return 0, false
+	}
+	return computedNumber(data[0].Value)
+}
+
+// computedNumber 被引用属性的数值，bool 转换为 0/1
+func computedNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			switch v {
+			case "true":
+				return 1, true
+			case "false":
+				return 0, true
+			}
+		}
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
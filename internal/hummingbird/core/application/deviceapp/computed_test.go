@@ -0,0 +1,83 @@
+package deviceapp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+type computedLatestValue struct {
+	interfaces.LatestValueItf
+	values map[string]dtos.ReportData
+}
+
+func (c computedLatestValue) Get(deviceId, code string) (dtos.ReportData, bool) {
+	data, ok := c.values[deviceId+"/"+code]
+	return data, ok
+}
+
+// computedDataDB 最后一条属性数据
+type computedDataDB struct {
+	interfaces.DataDBClient
+	last    map[string]dtos.ReportData
+	queried []string
+}
+
+func (c *computedDataDB) GetDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device) ([]dtos.ReportData, int, error) {
+	c.queried = append(c.queried, device.Id+"/"+req.Code)
+	if data, ok := c.last[device.Id+"/"+req.Code]; ok && req.Last {
+		return []dtos.ReportData{data}, 1, nil
+	}
+	return nil, 0, nil
+}
+
+func computedReport(t *testing.T, deviceId string, power float64, ts int64) dtos.ThingModelMessage {
+	b, err := json.Marshal(dtos.DevicePropertyReport{Data: map[string]dtos.ReportData{"power": {Value: power, Time: ts}}})
+	require.NoError(t, err)
+	return dtos.ThingModelMessage{Cid: deviceId, OpType: int32(thingmodel.OperationType_PROPERTY_REPORT), Data: string(b)}
+}
+
+func computedEnergy(t *testing.T, msg dtos.ThingModelMessage) interface{} {
+	report, err := msg.TransformMessageDataByProperty()
+	require.NoError(t, err)
+	return report.Data["energy"].Value
+}
+
+func TestDeviceComputedPropertiesSeed(t *testing.T) {
+	latest := computedLatestValue{values: map[string]dtos.ReportData{"d1/energy": {Value: 100.0, Time: 500}}}
+	dataDB := &computedDataDB{last: map[string]dtos.ReportData{"d2/energy": {Value: int64(50), Time: 500}}}
+	dic := di.NewContainer(di.ServiceConstructorMap{
+		resourceContainer.LatestValueItfName:        func(get di.Get) interface{} { return latest },
+		resourceContainer.DataDBClientInterfaceName: func(get di.Get) interface{} { return dataDB },
+	})
+	p := deviceApp{dic: dic, lc: logger.NewMockClient(), computed: newComputedState()}
+	product := models.Product{Id: "product1", Properties: []models.Properties{
+		{Code: "power", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat}},
+		{Code: "energy", Expression: "integral(power)/1000", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat}},
+	}}
+	evaluate := func(deviceId string, power float64, ts int64) interface{} {
+		thingModel := &dtos.DeviceThingModel{Device: models.Device{Id: deviceId, ProductId: product.Id}, Product: product}
+		return computedEnergy(t, p.DeviceComputedProperties(context.Background(), computedReport(t, deviceId, power, ts), thingModel))
+	}
+
+	// 重启后从最新值缓存中的值继续累计
+	assert.Equal(t, 100.0, evaluate("d1", 1000, 1000))
+	assert.Equal(t, 104.0, evaluate("d1", 3000, 3000))
+	// 缓存中没有时使用时序库的最后一条数据
+	assert.Equal(t, 50.0, evaluate("d2", 1000, 1000))
+	assert.Equal(t, 52.0, evaluate("d2", 1000, 3000))
+	// 没有历史数据时从 0 开始
+	assert.Equal(t, 0.0, evaluate("d3", 1000, 1000))
+	assert.Equal(t, []string{"d2/energy", "d3/energy"}, dataDB.queried)
+}
@@ -0,0 +1,311 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"math"
+	"time"
+)
+
+const (
+	// 上下线记录保留天数
+	deviceConnectLogRetentionDays = 90
+	// 未指定统计范围时默认统计最近 24 小时
+	deviceAvailabilityDefaultRange = 24 * time.Hour
+)
+
+// recordDeviceConnect 记录设备上下线，调用方保证设备状态确实发生了变化
+func (p deviceApp) recordDeviceConnect(deviceId, productId, status string, reason constants.DeviceConnectReason) {
+	err := p.dbClient.AddDeviceConnectLog(models.DeviceConnectLog{
+		DeviceId:  deviceId,
+		ProductId: productId,
+		Status:    status,
+		Reason:    reason,
+		Time:      utils.MakeTimestamp(),
+	})
+	if err != nil {
+		p.lc.Errorf("device %s record connect log err %v", deviceId, err)
+	}
+}
+
+func (p deviceApp) DeviceConnectLogsSearch(ctx context.Context, req dtos.DeviceConnectLogSearchQueryRequest) ([]dtos.DeviceConnectLogResponse, uint32, error) {
+	offset, limit := req.BaseSearchConditionQuery.GetPage()
+	logs, total, err := p.dbClient.DeviceConnectLogsSearch(offset, limit, req)
+	if err != nil {
+		return []dtos.DeviceConnectLogResponse{}, 0, err
+	}
+	resp := make([]dtos.DeviceConnectLogResponse, len(logs))
+	for i, log := range logs {
+		resp[i] = dtos.DeviceConnectLogResponseFromModel(log)
+	}
+	return resp, total, nil
+}
+
+// DeviceConnectLogsClean 清理超过保留天数的上下线记录
+func (p deviceApp) DeviceConnectLogsClean(ctx context.Context) error {
+	return p.dbClient.DeleteDeviceConnectLogsBefore(time.Now().AddDate(0, 0, -deviceConnectLogRetentionDays).UnixMilli())
+}
+
+// availabilityStat 一段时间内的在线统计，毫秒
+type availabilityStat struct {
+	total    int64
+	downtime int64
+	flaps    int
+}
+
+func (s *availabilityStat) add(o availabilityStat) {
+	s.total += o.total
+	s.downtime += o.downtime
+	s.flaps += o.flaps
+}
+
+func (s availabilityStat) toDto() dtos.DeviceAvailability {
+	var availability float64
+	if s.total > 0 {
+		availability = math.Round(float64(s.total-s.downtime)/float64(s.total)*10000) / 100
+	}
+	return dtos.DeviceAvailability{
+		Availability: availability,
+		FlapCount:    s.flaps,
+		Downtime:     s.downtime,
+	}
+}
+
+// connectTimeline 设备在统计范围内的状态变化
+type connectTimeline struct {
+	// created 设备创建时间，之前的时间不参与统计
+	created int64
+	// online 统计范围开始时设备是否在线
+	online bool
+	// logs 统计范围内按时间升序的上下线记录
+	logs []models.DeviceConnectLog
+}
+
+// stat 统计 [start, end) 内的离线时长和在线变为离线的次数，start 需不早于统计范围的开始时间
+func (t connectTimeline) stat(start, end int64) availabilityStat {
+	if start < t.created {
+		start = t.created
+	}
+	if start >= end {
+		return availabilityStat{}
+	}
+	online := t.online
+	last := start
+	var s availabilityStat
+	for _, log := range t.logs {
+		if log.Time < start {
+			online = log.Online()
+			continue
+		}
+		if log.Time >= end {
+			break
+		}
+		if !online {
+			s.downtime += log.Time - last
+		}
+		if online && !log.Online() {
+			s.flaps++
+		}
+		online = log.Online()
+		last = log.Time
+	}
+	if !online {
+		s.downtime += end - last
+	}
+	s.total = end - start
+	return s
+}
+
+type dayWindow struct {
+	date       string
+	start, end int64
+}
+
+// splitDays 按自然日切分统计范围
+func splitDays(start, end int64) []dayWindow {
+	var windows []dayWindow
+	for t := start; t < end; {
+		tm := time.UnixMilli(t)
+		next := time.Date(tm.Year(), tm.Month(), tm.Day()+1, 0, 0, 0, 0, tm.Location()).UnixMilli()
+		if next > end {
+			next = end
+		}
+		windows = append(windows, dayWindow{date: tm.Format("2006-01-02"), start: t, end: next})
+		t = next
+	}
+	return windows
+}
+
+func availabilityRange(req dtos.DeviceAvailabilityRequest) (int64, int64, error) {
+	now := utils.MakeTimestamp()
+	end := req.EndAt
+	if end <= 0 || end > now {
+		end = now
+	}
+	start := req.StartAt
+	if start <= 0 {
+		start = end - deviceAvailabilityDefaultRange.Milliseconds()
+	}
+	if start >= end {
+		return 0, 0, errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("start time must be before end time"))
+	}
+	return start, end, nil
+}
+
+// connectTimelines 查询设备在 [start, end) 内的状态变化。
+// 开始时的状态取开始前最后一条记录；没有记录时根据范围内第一条记录推断，范围内也没有记录时取设备当前状态。
+func (p deviceApp) connectTimelines(devices []models.Device, start, end int64) (map[string]*connectTimeline, error) {
+	ids := make([]string, 0, len(devices))
+	timelines := make(map[string]*connectTimeline, len(devices))
+	for _, device := range devices {
+		ids = append(ids, device.Id)
+		timelines[device.Id] = &connectTimeline{
+			created: device.Created,
+			online:  device.Status == constants.DeviceStatusOnline,
+		}
+	}
+	logs, err := p.dbClient.DeviceConnectLogsBetween(ids, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		t := timelines[log.DeviceId]
+		if len(t.logs) == 0 {
+			t.online = !log.Online()
+		}
+		t.logs = append(t.logs, log)
+	}
+	lasts, err := p.dbClient.DeviceConnectLogsLastBefore(ids, start)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range lasts {
+		timelines[log.DeviceId].online = log.Online()
+	}
+	return timelines, nil
+}
+
+func (p deviceApp) DeviceAvailability(ctx context.Context, req dtos.DeviceAvailabilityRequest) (dtos.DeviceAvailabilityResponse, error) {
+	start, end, err := availabilityRange(req)
+	if err != nil {
+		return dtos.DeviceAvailabilityResponse{}, err
+	}
+	device, err := p.dbClient.DeviceById(req.DeviceId)
+	if err != nil {
+		return dtos.DeviceAvailabilityResponse{}, err
+	}
+	timelines, err := p.connectTimelines([]models.Device{device}, start, end)
+	if err != nil {
+		return dtos.DeviceAvailabilityResponse{}, err
+	}
+	return deviceAvailabilityResponse(device, timelines[device.Id], splitDays(start, end), nil), nil
+}
+
+// deviceAvailabilityResponse 统计单个设备，daily 不为 nil 时同时按日累加到 daily
+func deviceAvailabilityResponse(device models.Device, timeline *connectTimeline, days []dayWindow, daily []availabilityStat) dtos.DeviceAvailabilityResponse {
+	resp := dtos.DeviceAvailabilityResponse{
+		DeviceId:   device.Id,
+		DeviceName: device.Name,
+		Daily:      make([]dtos.DeviceDailyAvailability, len(days)),
+	}
+	var total availabilityStat
+	for i, day := range days {
+		s := timeline.stat(day.start, day.end)
+		total.add(s)
+		if daily != nil {
+			daily[i].add(s)
+		}
+		resp.Daily[i] = dtos.DeviceDailyAvailability{
+			Date:               day.date,
+			DeviceAvailability: s.toDto(),
+		}
+	}
+	resp.DeviceAvailability = total.toDto()
+	return resp
+}
+
+// ProductAvailability 统计产品下全部设备，产品的在线率为所有设备在线时长之和占统计时长之和的比例
+func (p deviceApp) ProductAvailability(ctx context.Context, req dtos.DeviceAvailabilityRequest) (dtos.ProductAvailabilityResponse, error) {
+	start, end, err := availabilityRange(req)
+	if err != nil {
+		return dtos.ProductAvailabilityResponse{}, err
+	}
+	product, err := p.dbClient.ProductById(req.ProductId)
+	if err != nil {
+		return dtos.ProductAvailabilityResponse{}, err
+	}
+	var searchReq dtos.DeviceSearchQueryRequest
+	searchReq.ProductId = product.Id
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, searchReq)
+	if err != nil {
+		return dtos.ProductAvailabilityResponse{}, err
+	}
+	timelines, err := p.connectTimelines(devices, start, end)
+	if err != nil {
+		return dtos.ProductAvailabilityResponse{}, err
+	}
+
+	days := splitDays(start, end)
+	daily := make([]availabilityStat, len(days))
+	resp := dtos.ProductAvailabilityResponse{
+		ProductId:   product.Id,
+		ProductName: product.Name,
+		DeviceCount: len(devices),
+		Daily:       make([]dtos.DeviceDailyAvailability, len(days)),
+		Devices:     make([]dtos.DeviceAvailabilityResponse, 0, len(devices)),
+	}
+	for _, device := range devices {
+		resp.Devices = append(resp.Devices, deviceAvailabilityResponse(device, timelines[device.Id], days, daily))
+	}
+	var total availabilityStat
+	for i, day := range days {
+		total.add(daily[i])
+		resp.Daily[i] = dtos.DeviceDailyAvailability{
+			Date:               day.date,
+			DeviceAvailability: daily[i].toDto(),
+		}
+	}
+	resp.DeviceAvailability = total.toDto()
+	return resp, nil
+}
+
+// DeviceFlapTop 查询 start 之后上下线最频繁的设备
+func (p deviceApp) DeviceFlapTop(ctx context.Context, start int64, limit int) ([]dtos.DeviceFlap, error) {
+	counts, err := p.dbClient.DeviceFlapCounts(start, limit)
+	if err != nil {
+		return nil, err
+	}
+	flaps := make([]dtos.DeviceFlap, 0, len(counts))
+	for _, count := range counts {
+		device, err := p.dbClient.DeviceById(count.DeviceId)
+		if err != nil {
+			continue
+		}
+		flaps = append(flaps, dtos.DeviceFlap{
+			DeviceId:    device.Id,
+			DeviceName:  device.Name,
+			ProductName: device.Product.Name,
+			FlapCount:   count.FlapCount,
+		})
+	}
+	return flaps, nil
+}
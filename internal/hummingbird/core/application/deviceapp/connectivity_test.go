@@ -0,0 +1,144 @@
+package deviceapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+const hour = int64(time.Hour / time.Millisecond)
+
+func connectLog(online bool, ts int64) models.DeviceConnectLog {
+	status := constants.DeviceOffline
+	if online {
+		status = constants.DeviceOnline
+	}
+	return models.DeviceConnectLog{Status: status, Time: ts}
+}
+
+func TestConnectTimelineStat(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local).UnixMilli()
+	midnight := day + 24*hour
+	tests := []struct {
+		name       string
+		timeline   connectTimeline
+		start, end int64
+		want       availabilityStat
+	}{
+		{
+			name:     "没有变化一直在线",
+			timeline: connectTimeline{online: true},
+			start:    day, end: midnight,
+			want: availabilityStat{total: 24 * hour},
+		},
+		{
+			name:     "没有变化一直离线",
+			timeline: connectTimeline{},
+			start:    day, end: midnight,
+			want: availabilityStat{total: 24 * hour, downtime: 24 * hour},
+		},
+		{
+			name:     "跨零点离线的前一天",
+			timeline: connectTimeline{online: true, logs: []models.DeviceConnectLog{connectLog(false, midnight-2*hour), connectLog(true, midnight+2*hour)}},
+			start:    day, end: midnight,
+			want: availabilityStat{total: 24 * hour, downtime: 2 * hour, flaps: 1},
+		},
+		{
+			name:     "跨零点离线的后一天",
+			timeline: connectTimeline{online: true, logs: []models.DeviceConnectLog{connectLog(false, midnight-2*hour), connectLog(true, midnight+2*hour)}},
+			start:    midnight, end: midnight + 24*hour,
+			want: availabilityStat{total: 24 * hour, downtime: 2 * hour},
+		},
+		{
+			name:     "离线到统计结束",
+			timeline: connectTimeline{online: true, logs: []models.DeviceConnectLog{connectLog(false, day+20*hour)}},
+			start:    day, end: midnight,
+			want: availabilityStat{total: 24 * hour, downtime: 4 * hour, flaps: 1},
+		},
+		{
+			name: "多次上下线，重复的离线记录不计次数",
+			timeline: connectTimeline{online: true, logs: []models.DeviceConnectLog{
+				connectLog(false, day+1*hour),
+				connectLog(true, day+2*hour),
+				connectLog(false, day+3*hour),
+				connectLog(false, day+4*hour),
+				connectLog(true, day+5*hour),
+				connectLog(true, day+6*hour),
+				connectLog(false, day+23*hour),
+			}},
+			start: day, end: midnight,
+			want: availabilityStat{total: 24 * hour, downtime: 4 * hour, flaps: 3},
+		},
+		{
+			name:     "设备在统计范围内创建",
+			timeline: connectTimeline{created: day + 12*hour, logs: []models.DeviceConnectLog{connectLog(true, day+13*hour)}},
+			start:    day, end: midnight,
+			want: availabilityStat{total: 12 * hour, downtime: hour},
+		},
+		{
+			name:     "设备在统计范围后创建",
+			timeline: connectTimeline{created: midnight + hour},
+			start:    day, end: midnight,
+			want: availabilityStat{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.timeline.stat(tt.start, tt.end))
+		})
+	}
+}
+
+func TestSplitDays(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local).UnixMilli()
+	tests := []struct {
+		name       string
+		start, end int64
+		want       []dayWindow
+	}{
+		{
+			name:  "跨两个零点",
+			start: day + 20*hour, end: day + 50*hour,
+			want: []dayWindow{
+				{date: "2024-03-01", start: day + 20*hour, end: day + 24*hour},
+				{date: "2024-03-02", start: day + 24*hour, end: day + 48*hour},
+				{date: "2024-03-03", start: day + 48*hour, end: day + 50*hour},
+			},
+		},
+		{
+			name:  "同一天内",
+			start: day + hour, end: day + 2*hour,
+			want: []dayWindow{{date: "2024-03-01", start: day + hour, end: day + 2*hour}},
+		},
+		{
+			name:  "结束于零点",
+			start: day, end: day + 24*hour,
+			want: []dayWindow{{date: "2024-03-01", start: day, end: day + 24*hour}},
+		},
+		{
+			name:  "空范围",
+			start: day, end: day,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitDays(tt.start, tt.end))
+		})
+	}
+}
+
+func TestAvailabilityStatToDto(t *testing.T) {
+	timeline := connectTimeline{online: true, logs: []models.DeviceConnectLog{connectLog(false, 22*hour), connectLog(true, 26*hour)}}
+	var total availabilityStat
+	for _, d := range []dayWindow{{start: 0, end: 24 * hour}, {start: 24 * hour, end: 48 * hour}} {
+		total.add(timeline.stat(d.start, d.end))
+	}
+	dto := total.toDto()
+	assert.Equal(t, 91.67, dto.Availability)
+	assert.Equal(t, 4*hour, dto.Downtime)
+	assert.Equal(t, 1, dto.FlapCount)
+	assert.Equal(t, 0.0, availabilityStat{}.toDto().Availability)
+}
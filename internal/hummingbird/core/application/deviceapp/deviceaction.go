@@ -22,6 +22,7 @@ import (
 	"github.com/winc-link/edge-driver-proto/thingmodel"
 	"github.com/winc-link/hummingbird/internal/dtos"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/di"
 	"github.com/winc-link/hummingbird/internal/pkg/errort"
@@ -37,6 +38,9 @@ func (p *deviceApp) DeviceAction(jobAction dtos.JobAction) dtos.DeviceExecRes {
 		}
 	}()
 
+	if jobAction.DeviceId == "" && jobAction.GroupId != "" {
+		return p.deviceGroupAction(jobAction)
+	}
 	device, err := p.dbClient.DeviceById(jobAction.DeviceId)
 	if err != nil {
 		return dtos.DeviceExecRes{
@@ -44,6 +48,19 @@ func (p *deviceApp) DeviceAction(jobAction dtos.JobAction) dtos.DeviceExecRes {
 			Message: "device not found",
 		}
 	}
+	product, err := p.dbClient.ProductById(device.ProductId)
+	if err != nil {
+		return dtos.DeviceExecRes{
+			Result:  false,
+			Message: "product not found",
+		}
+	}
+	if err = checkPropertySet(product, jobAction.Code, jobAction.Value); err != nil {
+		return dtos.DeviceExecRes{
+			Result:  false,
+			Message: err.Error(),
+		}
+	}
 	deviceService, err := p.dbClient.DeviceServiceById(device.DriveInstanceId)
 	if err != nil {
 		return dtos.DeviceExecRes{
@@ -128,10 +145,15 @@ func (p *deviceApp) SetDeviceProperty(req dtos.OpenApiSetDeviceThingModel) error
 		return err
 	}
 
-	_, err = p.dbClient.ProductById(device.ProductId)
+	product, err := p.dbClient.ProductById(device.ProductId)
 	if err != nil {
 		return err
 	}
+	for code, value := range req.Item {
+		if err = checkPropertySet(product, code, value); err != nil {
+			return err
+		}
+	}
 
 	deviceService, err := p.dbClient.DeviceServiceById(device.DriveInstanceId)
 	if err != nil {
@@ -189,6 +211,8 @@ func (p *deviceApp) SetDeviceProperty(req dtos.OpenApiSetDeviceThingModel) error
 	return errort.NewCommonErr(errort.DeviceServiceNotStarted, fmt.Errorf("driver id(%s) not start", deviceService.Id))
 }
 
+// DeviceEffectivePropertyData 优先返回缓存的最新值，缓存中没有的属性再向驱动查询，
+// 驱动未启动或查询超时的时候返回已缓存的属性
 func (p *deviceApp) DeviceEffectivePropertyData(deviceEffectivePropertyDataReq dtos.DeviceEffectivePropertyDataReq) (dtos.DeviceEffectivePropertyDataResponse, error) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -201,15 +225,58 @@ func (p *deviceApp) DeviceEffectivePropertyData(deviceEffectivePropertyDataReq d
 		return dtos.DeviceEffectivePropertyDataResponse{}, err
 	}
 
-	_, err = p.dbClient.ProductById(device.ProductId)
+	product, err := p.dbClient.ProductById(device.ProductId)
 	if err != nil {
 		return dtos.DeviceEffectivePropertyDataResponse{}, err
 	}
 
-	deviceService, err := p.dbClient.DeviceServiceById(device.DriveInstanceId)
+	codes := deviceEffectivePropertyDataReq.Codes
+	if len(codes) == 0 {
+		for _, property := range product.Properties {
+			codes = append(codes, property.Code)
+		}
+	}
+	latestValue := container.LatestValueItfFrom(p.dic.Get)
+	var cached []dtos.EffectivePropertyData
+	var missing []string
+	for _, code := range codes {
+		if data, ok := latestValue.Get(device.Id, code); ok {
+			cached = append(cached, dtos.EffectivePropertyData{
+				Code:  code,
+				Value: data.Value,
+				Time:  data.Time,
+			})
+		} else {
+			missing = append(missing, code)
+		}
+	}
+	if len(codes) > 0 && len(missing) == 0 {
+		return dtos.DeviceEffectivePropertyDataResponse{
+			Data: cached,
+		}, nil
+	}
+
+	data, err := p.getEffectivePropertyData(device, missing)
 	if err != nil {
+		if len(cached) > 0 {
+			p.lc.Warnf("get device %s property data from driver err: %v, return cached data", device.Id, err)
+			return dtos.DeviceEffectivePropertyDataResponse{
+				Data: cached,
+			}, nil
+		}
 		return dtos.DeviceEffectivePropertyDataResponse{}, err
 	}
+	return dtos.DeviceEffectivePropertyDataResponse{
+		Data: append(cached, data...),
+	}, nil
+}
+
+// getEffectivePropertyData 向驱动查询设备属性的当前值
+func (p *deviceApp) getEffectivePropertyData(device models.Device, codes []string) ([]dtos.EffectivePropertyData, error) {
+	deviceService, err := p.dbClient.DeviceServiceById(device.DriveInstanceId)
+	if err != nil {
+		return nil, err
+	}
 
 	driverService := container.DriverServiceAppFrom(di.GContainer.Get)
 	status := driverService.GetState(deviceService.Id)
@@ -217,17 +284,17 @@ func (p *deviceApp) DeviceEffectivePropertyData(deviceEffectivePropertyDataReq d
 
 		client, errX := rpcclient.NewDriverRpcClient(deviceService.BaseAddress, false, "", deviceService.Id, p.lc)
 		if errX != nil {
-			return dtos.DeviceEffectivePropertyDataResponse{}, err
+			return nil, errX
 		}
 		defer client.Close()
 		var rpcRequest thingmodel.ThingModelIssueMsg
-		rpcRequest.DeviceId = deviceEffectivePropertyDataReq.DeviceId
+		rpcRequest.DeviceId = device.Id
 		rpcRequest.OperationType = thingmodel.OperationType_PROPERTY_GET
 		var data dtos.DeviceGetPropertyData
 		data.Version = "v1.0"
 		data.MsgId = uuid.Generate().String()
 		data.Time = time.Now().UnixMilli()
-		data.Data = deviceEffectivePropertyDataReq.Codes
+		data.Data = codes
 		rpcRequest.Data = data.ToString()
 
 		messageStore := container.MessageStoreItfFrom(p.dic.Get)
@@ -239,23 +306,21 @@ func (p *deviceApp) DeviceEffectivePropertyData(deviceEffectivePropertyDataReq d
 
 		if err != nil {
 			ch.TryCloseChan()
-			return dtos.DeviceEffectivePropertyDataResponse{}, errort.NewCommonErr(errort.DefaultSystemError, fmt.Errorf("system error"))
+			return nil, errort.NewCommonErr(errort.DefaultSystemError, fmt.Errorf("system error"))
 		}
 		select {
 		case <-time.After(10 * time.Second):
 			ch.TryCloseChan()
-			return dtos.DeviceEffectivePropertyDataResponse{}, errort.NewCommonErr(errort.DeviceLibraryResponseTimeOut, fmt.Errorf("driver id(%s) time out", deviceService.Id))
+			return nil, errort.NewCommonErr(errort.DeviceLibraryResponseTimeOut, fmt.Errorf("driver id(%s) time out", deviceService.Id))
 		case <-ctx.Done():
-			return dtos.DeviceEffectivePropertyDataResponse{}, errort.NewCommonErr(errort.DeviceLibraryResponseTimeOut, fmt.Errorf("driver id(%s) time out", deviceService.Id))
+			return nil, errort.NewCommonErr(errort.DeviceLibraryResponseTimeOut, fmt.Errorf("driver id(%s) time out", deviceService.Id))
 		case resp := <-ch.DataChan:
 			if v, ok := resp.([]dtos.EffectivePropertyData); ok {
-				return dtos.DeviceEffectivePropertyDataResponse{
-					Data: v,
-				}, nil
+				return v, nil
 			}
 		}
 	}
-	return dtos.DeviceEffectivePropertyDataResponse{}, errort.NewCommonErr(errort.DeviceServiceNotStarted, fmt.Errorf("driver id(%s) not start", deviceService.Id))
+	return nil, errort.NewCommonErr(errort.DeviceServiceNotStarted, fmt.Errorf("driver id(%s) not start", deviceService.Id))
 }
 
 func (p *deviceApp) DeviceInvokeThingService(invokeDeviceServiceReq dtos.InvokeDeviceServiceReq) (map[string]interface{}, error) {
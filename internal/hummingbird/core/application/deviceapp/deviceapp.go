@@ -32,20 +32,32 @@ import (
 
 type deviceApp struct {
 	//*propertyTyApp
-	dic      *di.Container
-	dbClient interfaces.DBClient
-	lc       logger.LoggingClient
+	dic       *di.Container
+	dbClient  interfaces.DBClient
+	lc        logger.LoggingClient
+	keepAlive *keepAlive
+	shadow    *shadowState
+	batch     *batchJobs
+	computed  *computedState
+	products  *productCache
 }
 
 func NewDeviceApp(ctx context.Context, dic *di.Container) interfaces.DeviceItf {
 	lc := container.LoggingClientFrom(dic.Get)
 	dbClient := resourceContainer.DBClientFrom(dic.Get)
 
-	return &deviceApp{
-		dic:      dic,
-		dbClient: dbClient,
-		lc:       lc,
-	}
+	app := &deviceApp{
+		dic:       dic,
+		dbClient:  dbClient,
+		lc:        lc,
+		keepAlive: newKeepAlive(),
+		shadow:    newShadowState(),
+		batch:     newBatchJobs(),
+		computed:  newComputedState(),
+		products:  newProductCache(),
+	}
+	go app.keepAliveMonitor(ctx)
+	return app
 }
 
 func (p deviceApp) DeviceById(ctx context.Context, id string) (dtos.DeviceInfoResponse, error) {
@@ -61,6 +73,11 @@ func (p deviceApp) DeviceById(ctx context.Context, id string) (dtos.DeviceInfoRe
 	}
 
 	response = dtos.DeviceInfoResponseFromModel(device, deviceServiceName)
+	tags, err := p.deviceTags(device.Id)
+	if err != nil {
+		return response, err
+	}
+	response.Tags = tags[device.Id]
 	return response, nil
 }
 
@@ -98,10 +115,24 @@ func (p *deviceApp) DevicesSearch(ctx context.Context, req dtos.DeviceSearchQuer
 	if err != nil {
 		return []dtos.DeviceSearchQueryResponse{}, 0, err
 	}
+	ids := make([]string, len(resp))
+	for i, dev := range resp {
+		ids[i] = dev.Id
+	}
+	tags, err := p.deviceTags(ids...)
+	if err != nil {
+		return []dtos.DeviceSearchQueryResponse{}, 0, err
+	}
 	devices := make([]dtos.DeviceSearchQueryResponse, len(resp))
 	for i, dev := range resp {
 		deviceService, _ := p.dbClient.DeviceServiceById(dev.DriveInstanceId)
 		devices[i] = dtos.DeviceResponseFromModel(dev, deviceService.Name)
+		devices[i].Tags = tags[dev.Id]
+	}
+	if req.Tree {
+		if err = p.subDevicesTree(devices); err != nil {
+			return []dtos.DeviceSearchQueryResponse{}, 0, err
+		}
 	}
 	return devices, total, nil
 }
@@ -140,6 +171,15 @@ func (p *deviceApp) AddDevice(ctx context.Context, req dtos.DeviceAddRequest) (s
 	if productInfo.Status == constants.ProductUnRelease {
 		return "", errort.NewCommonEdgeX(errort.ProductUnRelease, "The product has not been released yet. Please release the product before adding devices", nil)
 	}
+	if req.ParentId != "" {
+		parent, err := p.checkGatewayDevice(req.ParentId)
+		if err != nil {
+			return "", err
+		}
+		if err = checkSubDevice(parent, models.Device{Product: productInfo}); err != nil {
+			return "", err
+		}
+	}
 	deviceId := utils.RandomNum()
 	if err != nil {
 		return "", err
@@ -159,10 +199,17 @@ func (p *deviceApp) AddDevice(ctx context.Context, req dtos.DeviceAddRequest) (s
 	insertDevice.Status = constants.DeviceStatusOffline
 	insertDevice.Secret = utils.GenerateDeviceSecret(12)
 	insertDevice.Description = req.Description
+	insertDevice.KeepAlive = req.KeepAlive
+	insertDevice.ParentId = req.ParentId
 	id, err := p.dbClient.AddDevice(insertDevice)
 	if err != nil {
 		return "", err
 	}
+	if len(req.Tags) > 0 {
+		if err = p.setDeviceTags(ctx, id, nil, req.Tags); err != nil {
+			return "", err
+		}
+	}
 	go func() {
 		p.CreateDeviceCallBack(insertDevice)
 	}()
@@ -176,6 +223,9 @@ func (p *deviceApp) BatchDeleteDevice(ctx context.Context, ids []string) error {
 	if err != nil {
 		return err
 	}
+	if err = p.checkDevicesDeletable(ids); err != nil {
+		return err
+	}
 	alertApp := resourceContainer.AlertRuleAppNameFrom(p.dic.Get)
 	for _, device := range devices {
 		edgeXErr := alertApp.CheckRuleByDeviceId(ctx, device.Id)
@@ -187,7 +237,9 @@ func (p *deviceApp) BatchDeleteDevice(ctx context.Context, ids []string) error {
 	if err != nil {
 		return err
 	}
+	p.deleteDeviceRelations(ctx, ids)
 	for _, device := range devices {
+		p.deleteDeviceShadow(device.Id)
 		delDevice := device
 		go func() {
 			p.DeleteDeviceCallBack(delDevice)
@@ -221,6 +273,9 @@ func (p *deviceApp) DeleteDeviceById(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
+	if err = p.checkDevicesDeletable([]string{id}); err != nil {
+		return err
+	}
 	alertApp := resourceContainer.AlertRuleAppNameFrom(p.dic.Get)
 	edgeXErr := alertApp.CheckRuleByDeviceId(ctx, id)
 	if edgeXErr != nil {
@@ -238,6 +293,8 @@ func (p *deviceApp) DeleteDeviceById(ctx context.Context, id string) error {
 		return err
 	}
 	_ = resourceContainer.DataDBClientFrom(p.dic.Get).DropTable(ctx, id)
+	p.deleteDeviceShadow(id)
+	p.deleteDeviceRelations(ctx, []string{id})
 
 	go func() {
 		p.DeleteDeviceCallBack(models.Device{
@@ -278,6 +335,15 @@ func (p *deviceApp) DeviceUpdate(ctx context.Context, req dtos.DeviceUpdateReque
 	if edgeXErr != nil {
 		return edgeXErr
 	}
+	if req.Tags != nil {
+		oldTags, err := p.deviceTags(device.Id)
+		if err != nil {
+			return err
+		}
+		if err = p.setDeviceTags(ctx, device.Id, oldTags[device.Id], req.Tags); err != nil {
+			return err
+		}
+	}
 	go func() {
 		p.UpdateDeviceCallBack(device)
 	}()
@@ -390,6 +456,7 @@ func (p *deviceApp) DeviceUpdateConnectStatus(id string, status constants.Device
 	if edgeXErr != nil {
 		return edgeXErr
 	}
+	changed := device.Status != status
 	device.Status = status
 	if status == constants.DeviceStatusOnline {
 		device.LastOnlineTime = utils.MakeTimestamp()
@@ -398,20 +465,18 @@ func (p *deviceApp) DeviceUpdateConnectStatus(id string, status constants.Device
 	if edgeXErr != nil {
 		return edgeXErr
 	}
+	if changed {
+		connectStatus := constants.DeviceOffline
+		if status == constants.DeviceStatusOnline {
+			connectStatus = constants.DeviceOnline
+		}
+		p.recordDeviceConnect(device.Id, device.ProductId, connectStatus, constants.DeviceConnectReasonManual)
+	}
 	return nil
 }
 
 func setDeviceInfoSheet(file *dtos.ExportFile, req dtos.DeviceImportTemplateRequest) error {
-	file.Excel.SetSheetName("Sheet1", dtos.DevicesFilename)
-
-	file.Excel.SetCellStyle(dtos.DevicesFilename, "A1", "A1", file.GetCenterStyle())
-	file.Excel.MergeCell(dtos.DevicesFilename, "A1", "B1")
-	file.Excel.SetCellStr(dtos.DevicesFilename, "A1", "Device Base Info")
-
-	file.Excel.SetCellStr(dtos.DevicesFilename, "A2", "DeviceName")
-	file.Excel.SetCellStr(dtos.DevicesFilename, "B2", "Description")
-
-	return nil
+	return writeDeviceSheet(file, deviceSheetImportColumns, nil)
 }
 
 func (p *deviceApp) DeviceImportTemplateDownload(ctx context.Context, req dtos.DeviceImportTemplateRequest) (*dtos.ExportFile, error) {
@@ -431,6 +496,7 @@ func (p *deviceApp) UploadValidated(ctx context.Context, file *dtos.ImportFile)
 	if err != nil {
 		return errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode, "read rows error", err)
 	}
+	var header map[string]int
 	idx := 0
 	for rows.Next() {
 		idx++
@@ -442,8 +508,8 @@ func (p *deviceApp) UploadValidated(ctx context.Context, file *dtos.ImportFile)
 			continue
 		}
 		if idx == 2 {
-			if len(cols) != 2 {
-				return errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode, fmt.Sprintf("read cols error need len %d,but read len %d", 2, len(cols)), err)
+			if header, err = deviceSheetHeader(cols); err != nil {
+				return err
 			}
 			continue
 		}
@@ -453,13 +519,14 @@ func (p *deviceApp) UploadValidated(ctx context.Context, file *dtos.ImportFile)
 			continue
 		}
 
-		if cols[0] == "" {
+		if deviceSheetCell(cols, header, deviceSheetColName) == "" {
 			return errort.NewCommonEdgeX(errort.DefaultReadExcelErrorParamsRequiredCode, fmt.Sprintf("read excel params required %+v", "deviceName"), nil)
 		}
 	}
 	return nil
 }
 
+// DevicesImport 导入设备，DeviceSn 与已有设备相同时更新该设备的名称和描述，其他设备新增
 func (p *deviceApp) DevicesImport(ctx context.Context, file *dtos.ImportFile, productId, driverInstanceId string) (int64, error) {
 	productService := resourceContainer.ProductAppNameFrom(p.dic.Get)
 	productInfo, err := productService.ProductById(ctx, productId)
@@ -486,7 +553,10 @@ func (p *deviceApp) DevicesImport(ctx context.Context, file *dtos.ImportFile, pr
 	if err != nil {
 		return 0, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode, "read rows error", err)
 	}
+	var header map[string]int
 	devices := make([]models.Device, 0)
+	// deviceSn -> devices 下标
+	sns := make(map[string]int)
 	idx := 0
 	for rows.Next() {
 		idx++
@@ -502,8 +572,8 @@ func (p *deviceApp) DevicesImport(ctx context.Context, file *dtos.ImportFile, pr
 			continue
 		}
 		if idx == 2 {
-			if len(cols) != 2 {
-				return 0, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode, fmt.Sprintf("read cols error need len %d,but read len %d", 2, len(cols)), err)
+			if header, err = deviceSheetHeader(cols); err != nil {
+				return 0, err
 			}
 			continue
 		}
@@ -514,10 +584,9 @@ func (p *deviceApp) DevicesImport(ctx context.Context, file *dtos.ImportFile, pr
 		}
 
 		deviceAddRequest.Id = utils.RandomNum()
-		deviceAddRequest.Name = cols[0]
-		if len(cols) >= 2 {
-			deviceAddRequest.Description = cols[1]
-		}
+		deviceAddRequest.Name = deviceSheetCell(cols, header, deviceSheetColName)
+		deviceAddRequest.Description = deviceSheetCell(cols, header, deviceSheetColDescription)
+		deviceAddRequest.DeviceSn = deviceSheetCell(cols, header, deviceSheetColDeviceSn)
 		deviceAddRequest.Status = constants.DeviceStatusOffline
 		deviceAddRequest.Platform = constants.IotPlatform_LocalIot
 		deviceAddRequest.Created = utils.MakeTimestamp()
@@ -525,10 +594,24 @@ func (p *deviceApp) DevicesImport(ctx context.Context, file *dtos.ImportFile, pr
 		if deviceAddRequest.Name == "" {
 			return 0, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorParamsRequiredCode, fmt.Sprintf("read excel params required %+v", deviceAddRequest), nil)
 		}
+		if deviceAddRequest.DeviceSn != "" {
+			if _, ok := sns[deviceAddRequest.DeviceSn]; ok {
+				return 0, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode, fmt.Sprintf("duplicate device sn %s in row %d", deviceAddRequest.DeviceSn, idx), nil)
+			}
+			sns[deviceAddRequest.DeviceSn] = len(devices)
+		}
 		devices = append(devices, deviceAddRequest)
 	}
 
+	updated, err := p.importExistedDevices(productId, sns, devices)
+	if err != nil {
+		return 0, err
+	}
+
 	for _, device := range devices {
+		if _, ok := updated[device.Id]; ok {
+			continue
+		}
 		err = resourceContainer.DataDBClientFrom(p.dic.Get).CreateTable(ctx, productInfo.Id, device.Id)
 		if err != nil {
 			return 0, err
@@ -542,6 +625,12 @@ func (p *deviceApp) DevicesImport(ctx context.Context, file *dtos.ImportFile, pr
 
 	for _, device := range devices {
 		addDevice := device
+		if _, ok := updated[device.Id]; ok {
+			go func() {
+				p.UpdateDeviceCallBack(addDevice)
+			}()
+			continue
+		}
 		go func() {
 			p.CreateDeviceCallBack(addDevice)
 		}()
@@ -549,6 +638,37 @@ func (p *deviceApp) DevicesImport(ctx context.Context, file *dtos.ImportFile, pr
 	return total, nil
 }
 
+// importExistedDevices 按 DeviceSn 查找已存在的设备，用已有设备替换导入行并只更新名称和描述，返回被更新的设备id
+func (p *deviceApp) importExistedDevices(productId string, sns map[string]int, devices []models.Device) (map[string]struct{}, error) {
+	updated := make(map[string]struct{})
+	if len(sns) == 0 {
+		return updated, nil
+	}
+	var req dtos.DeviceSearchQueryRequest
+	for sn := range sns {
+		req.DeviceSns = append(req.DeviceSns, sn)
+	}
+	existDevices, _, err := p.dbClient.DevicesSearch(0, -1, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, exist := range existDevices {
+		i := sns[exist.DeviceSn]
+		if _, ok := updated[devices[i].Id]; ok {
+			continue
+		}
+		if exist.ProductId != productId {
+			return nil, errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("device sn %s belongs to another product", exist.DeviceSn), nil)
+		}
+		exist.Name = devices[i].Name
+		exist.Description = devices[i].Description
+		exist.Product = models.Product{}
+		devices[i] = exist
+		updated[exist.Id] = struct{}{}
+	}
+	return updated, nil
+}
+
 func (p *deviceApp) DevicesReportMsgGather(ctx context.Context) error {
 	var count int
 	var err error
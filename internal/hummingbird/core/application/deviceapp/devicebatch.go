@@ -0,0 +1,408 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/docker/distribution/uuid"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"github.com/winc-link/hummingbird/internal/tools/rpcclient"
+	"sync"
+	"time"
+)
+
+const (
+	// 每个驱动实例同时下发的设备数
+	deviceBatchWorkers = 20
+	// 等待驱动响应的超时时间
+	deviceBatchAckTimeout = 10 * time.Second
+	// 已完成的任务保留时长，超过后在创建新任务时清理
+	deviceBatchJobExpire = time.Hour
+)
+
+type batchJob struct {
+	mu      sync.Mutex
+	resp    dtos.DeviceBatchJobResponse
+	index   map[string]int
+	expires time.Time
+}
+
+func (j *batchJob) setResult(deviceId string, status constants.DeviceBatchResultStatus, message string, output map[string]interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	i, ok := j.index[deviceId]
+	if !ok {
+		return
+	}
+	r := &j.resp.Results[i]
+	r.Status = status
+	r.Message = message
+	r.Output = output
+	r.Finished = utils.MakeTimestamp()
+}
+
+func (j *batchJob) finish() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.resp.Status = constants.DeviceBatchJobFinished
+	j.resp.Finished = utils.MakeTimestamp()
+	j.expires = time.Now().Add(deviceBatchJobExpire)
+}
+
+func (j *batchJob) snapshot() dtos.DeviceBatchJobResponse {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	resp := j.resp
+	resp.Results = make([]dtos.DeviceBatchResult, len(j.resp.Results))
+	copy(resp.Results, j.resp.Results)
+	resp.Success, resp.Failed, resp.Timeout, resp.Pending = 0, 0, 0, 0
+	for _, r := range resp.Results {
+		switch r.Status {
+		case constants.DeviceBatchResultSuccess:
+			resp.Success++
+		case constants.DeviceBatchResultFailed:
+			resp.Failed++
+		case constants.DeviceBatchResultTimeout:
+			resp.Timeout++
+		default:
+			resp.Pending++
+		}
+	}
+	return resp
+}
+
+// batchJobs 批量下发任务，仅保存在内存中，服务重启后丢失
+type batchJobs struct {
+	// jobs jobId -> *batchJob
+	jobs sync.Map
+}
+
+func newBatchJobs() *batchJobs {
+	return &batchJobs{}
+}
+
+func (b *batchJobs) add(jobType constants.DeviceBatchJobType, devices []models.Device) *batchJob {
+	now := time.Now()
+	b.jobs.Range(func(key, value interface{}) bool {
+		job := value.(*batchJob)
+		job.mu.Lock()
+		expired := job.resp.Status == constants.DeviceBatchJobFinished && now.After(job.expires)
+		job.mu.Unlock()
+		if expired {
+			b.jobs.Delete(key)
+		}
+		return true
+	})
+
+	job := &batchJob{
+		resp: dtos.DeviceBatchJobResponse{
+			JobId:   utils.GenUUID(),
+			Type:    jobType,
+			Status:  constants.DeviceBatchJobRunning,
+			Total:   len(devices),
+			Created: utils.MakeTimestamp(),
+			Results: make([]dtos.DeviceBatchResult, len(devices)),
+		},
+		index: make(map[string]int, len(devices)),
+	}
+	for i, device := range devices {
+		job.index[device.Id] = i
+		job.resp.Results[i] = dtos.DeviceBatchResult{
+			DeviceId:   device.Id,
+			DeviceName: device.Name,
+			Status:     constants.DeviceBatchResultPending,
+		}
+	}
+	b.jobs.Store(job.resp.JobId, job)
+	return job
+}
+
+func (b *batchJobs) get(jobId string) (*batchJob, bool) {
+	v, ok := b.jobs.Load(jobId)
+	if !ok {
+		return nil, false
+	}
+	return v.(*batchJob), true
+}
+
+// batchDevices 查询批量下发的目标设备，并加载设备所属产品的物模型
+func (p *deviceApp) batchDevices(selector dtos.DeviceBatchSelector) ([]models.Device, error) {
+	if selector.IsEmpty() {
+		return nil, errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("batch target devices is empty"))
+	}
+	if selector.GroupId != "" {
+		if _, err := p.dbClient.DeviceGroupById(selector.GroupId); err != nil {
+			return nil, err
+		}
+	}
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, selector.SearchRequest())
+	if err != nil {
+		return nil, err
+	}
+	if len(selector.DeviceIds) > 0 && len(devices) != len(selector.DeviceIds) {
+		return nil, errort.NewCommonErr(errort.DeviceNotExist, fmt.Errorf("some devices not found"))
+	}
+	if len(devices) == 0 {
+		return nil, errort.NewCommonErr(errort.DeviceNotExist, fmt.Errorf("no device matched"))
+	}
+	products := make(map[string]models.Product)
+	for i := range devices {
+		product, ok := products[devices[i].ProductId]
+		if !ok {
+			if product, err = p.dbClient.ProductById(devices[i].ProductId); err != nil {
+				return nil, err
+			}
+			products[devices[i].ProductId] = product
+		}
+		devices[i].Product = product
+	}
+	return devices, nil
+}
+
+// DeviceBatchPropertySet 批量设置设备属性，立即返回任务id，下发结果通过 DeviceBatchJobById 查询
+func (p *deviceApp) DeviceBatchPropertySet(ctx context.Context, req dtos.DeviceBatchPropertySetRequest) (string, error) {
+	devices, err := p.batchDevices(req.DeviceBatchSelector)
+	if err != nil {
+		return "", err
+	}
+	for _, device := range devices {
+		for code, value := range req.Item {
+			if err = checkPropertySet(device.Product, code, value); err != nil {
+				return "", err
+			}
+		}
+	}
+	job := p.batch.add(constants.DeviceBatchPropertySet, devices)
+	go p.runBatchJob(job, devices, func(client *rpcclient.DriverRpcClient, device models.Device) (map[string]interface{}, error) {
+		resp, err := p.issueBatchPropertySet(client, device.Id, req.Item)
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, errort.NewCommonErr(resp.Code, errors.New(resp.ErrorMessage))
+		}
+		return nil, nil
+	})
+	return job.resp.JobId, nil
+}
+
+// DeviceBatchServiceInvoke 批量调用设备服务，立即返回任务id
+func (p *deviceApp) DeviceBatchServiceInvoke(ctx context.Context, req dtos.DeviceBatchServiceInvokeRequest) (string, error) {
+	devices, err := p.batchDevices(req.DeviceBatchSelector)
+	if err != nil {
+		return "", err
+	}
+	for _, device := range devices {
+		var find bool
+		for _, action := range device.Product.Actions {
+			if action.Code == req.Code {
+				find = true
+				break
+			}
+		}
+		if !find {
+			return "", errort.NewCommonErr(errort.DeviceCommandNotExist, fmt.Errorf("device(%s) service(%s) not found", device.Id, req.Code))
+		}
+	}
+	job := p.batch.add(constants.DeviceBatchServiceInvoke, devices)
+	go p.runBatchJob(job, devices, func(client *rpcclient.DriverRpcClient, device models.Device) (map[string]interface{}, error) {
+		return p.issueBatchServiceInvoke(client, device.Id, req.Code, req.Items)
+	})
+	return job.resp.JobId, nil
+}
+
+func (p *deviceApp) DeviceBatchJobById(ctx context.Context, jobId string) (dtos.DeviceBatchJobResponse, error) {
+	job, ok := p.batch.get(jobId)
+	if !ok {
+		return dtos.DeviceBatchJobResponse{}, errort.NewCommonErr(errort.DeviceBatchJobNotExist, fmt.Errorf("batch job(%s) not found", jobId))
+	}
+	return job.snapshot(), nil
+}
+
+type batchIssueFunc func(client *rpcclient.DriverRpcClient, device models.Device) (map[string]interface{}, error)
+
+// runBatchJob 按驱动实例分组下发，每个驱动实例复用一个连接，并限制同时下发的设备数
+func (p *deviceApp) runBatchJob(job *batchJob, devices []models.Device, issue batchIssueFunc) {
+	defer func() {
+		if err := recover(); err != nil {
+			p.lc.Error("Panic:", err)
+		}
+		job.finish()
+	}()
+
+	drivers := make(map[string][]models.Device)
+	for _, device := range devices {
+		drivers[device.DriveInstanceId] = append(drivers[device.DriveInstanceId], device)
+	}
+	var wg sync.WaitGroup
+	for driverId, driverDevices := range drivers {
+		wg.Add(1)
+		go func(driverId string, driverDevices []models.Device) {
+			defer wg.Done()
+			p.runBatchDriver(job, driverId, driverDevices, issue)
+		}(driverId, driverDevices)
+	}
+	wg.Wait()
+}
+
+func (p *deviceApp) runBatchDriver(job *batchJob, driverId string, devices []models.Device, issue batchIssueFunc) {
+	failAll := func(err error) {
+		for _, device := range devices {
+			job.setResult(device.Id, constants.DeviceBatchResultFailed, err.Error(), nil)
+		}
+	}
+	if driverId == "" {
+		failAll(errort.NewCommonErr(errort.DeviceServiceNotExist, fmt.Errorf("device not bind driver")))
+		return
+	}
+	deviceService, err := p.dbClient.DeviceServiceById(driverId)
+	if err != nil {
+		failAll(err)
+		return
+	}
+	driverService := container.DriverServiceAppFrom(di.GContainer.Get)
+	if driverService.GetState(deviceService.Id) != constants.RunStatusStarted {
+		failAll(errort.NewCommonErr(errort.DeviceServiceNotStarted, fmt.Errorf("driver id(%s) not start", deviceService.Id)))
+		return
+	}
+	client, err := rpcclient.NewDriverRpcClient(deviceService.BaseAddress, false, "", deviceService.Id, p.lc)
+	if err != nil {
+		failAll(err)
+		return
+	}
+	defer client.Close()
+
+	tasks := make(chan models.Device)
+	var wg sync.WaitGroup
+	for i := 0; i < deviceBatchWorkers && i < len(devices); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for device := range tasks {
+				output, err := issue(client, device)
+				switch {
+				case err == nil:
+					job.setResult(device.Id, constants.DeviceBatchResultSuccess, "", output)
+				case errort.Is(errort.DeviceLibraryResponseTimeOut, err):
+					job.setResult(device.Id, constants.DeviceBatchResultTimeout, err.Error(), nil)
+				default:
+					job.setResult(device.Id, constants.DeviceBatchResultFailed, err.Error(), nil)
+				}
+			}
+		}()
+	}
+	for _, device := range devices {
+		tasks <- device
+	}
+	close(tasks)
+	wg.Wait()
+}
+
+// issueThingModelMsg 下发物模型消息并等待驱动响应
+func (p *deviceApp) issueThingModelMsg(client *rpcclient.DriverRpcClient, deviceId string, opType thingmodel.OperationType, msgId, data string) (interface{}, error) {
+	messageStore := container.MessageStoreItfFrom(p.dic.Get)
+	ch := messageStore.GenAckChan(msgId)
+
+	ctx, cancel := context.WithTimeout(context.Background(), deviceBatchAckTimeout)
+	defer cancel()
+	_, err := client.ThingModelDownServiceClient.ThingModelMsgIssue(ctx, &thingmodel.ThingModelIssueMsg{
+		DeviceId:      deviceId,
+		OperationType: opType,
+		Data:          data,
+	})
+	if err != nil {
+		ch.TryCloseChan()
+		return nil, errort.NewCommonErr(errort.DefaultSystemError, err)
+	}
+	select {
+	case <-ctx.Done():
+		ch.TryCloseChan()
+		return nil, errort.NewCommonErr(errort.DeviceLibraryResponseTimeOut, fmt.Errorf("device(%s) wait response time out", deviceId))
+	case resp := <-ch.DataChan:
+		return resp, nil
+	}
+}
+
+func (p *deviceApp) issueBatchPropertySet(client *rpcclient.DriverRpcClient, deviceId string, params map[string]interface{}) (dtos.DevicePropertySetData, error) {
+	var data dtos.PropertySet
+	data.Version = "v1.0"
+	data.MsgId = uuid.Generate().String()
+	data.Time = time.Now().UnixMilli()
+	data.Params = params
+	resp, err := p.issueThingModelMsg(client, deviceId, thingmodel.OperationType_PROPERTY_SET, data.MsgId, data.ToString())
+	if err != nil {
+		return dtos.DevicePropertySetData{}, err
+	}
+	if v, ok := resp.(dtos.DevicePropertySetData); ok {
+		return v, nil
+	}
+	return dtos.DevicePropertySetData{}, fmt.Errorf("unexpected property set response %v", resp)
+}
+
+// issueBatchServiceInvoke 调用设备服务，与单设备调用一样保存服务调用记录
+func (p *deviceApp) issueBatchServiceInvoke(client *rpcclient.DriverRpcClient, deviceId, code string, inputParams map[string]interface{}) (map[string]interface{}, error) {
+	var data dtos.InvokeDeviceService
+	data.Version = "v1.0"
+	data.MsgId = uuid.Generate().String()
+	data.Time = time.Now().UnixMilli()
+	data.Data.Code = code
+	data.Data.InputParams = inputParams
+	resp, err := p.issueThingModelMsg(client, deviceId, thingmodel.OperationType_SERVICE_EXECUTE, data.MsgId, data.ToString())
+	if err != nil && !errort.Is(errort.DeviceLibraryResponseTimeOut, err) {
+		return nil, err
+	}
+
+	var saveData dtos.SaveServiceIssueData
+	saveData.MsgId = data.MsgId
+	saveData.Code = code
+	saveData.Time = data.Time
+	saveData.InputParams = map[string]interface{}{
+		"code":        code,
+		"inputParams": inputParams,
+	}
+	output, ok := resp.(map[string]interface{})
+	if err != nil || !ok {
+		saveData.OutputParams = map[string]interface{}{
+			"result":  false,
+			"message": "wait response timeout",
+		}
+	} else {
+		saveData.OutputParams = output
+	}
+	s, _ := json.Marshal(saveData)
+	persistItf := container.PersistItfFrom(p.dic.Get)
+	_ = persistItf.SaveDeviceThingModelData(dtos.ThingModelMessage{
+		OpType: int32(thingmodel.OperationType_SERVICE_EXECUTE),
+		Cid:    deviceId,
+		Data:   string(s),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("unexpected service invoke response %v", resp)
+	}
+	return output, nil
+}
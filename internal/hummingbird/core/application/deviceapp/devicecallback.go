@@ -89,6 +89,7 @@ func (p *deviceApp) DeleteDeviceCallBack(deleteDevice models.Device) {
 			p.lc.Error("DeleteDeviceCallBack Panic:", err)
 		}
 	}()
+	container.LatestValueItfFrom(p.dic.Get).DeleteDevice(deleteDevice.Id)
 	deviceService, err := p.dbClient.DeviceServiceById(deleteDevice.DriveInstanceId)
 	if err != nil {
 		return
@@ -0,0 +1,201 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"github.com/xuri/excelize/v2"
+	"time"
+)
+
+// 设备导入导出表格的列名，导入时按第二行的列名读取，不认识的列忽略
+const (
+	deviceSheetColName        = "DeviceName"
+	deviceSheetColDescription = "Description"
+	deviceSheetColDeviceSn    = "DeviceSn"
+
+	deviceSheetColDeviceId       = "DeviceId"
+	deviceSheetColProductName    = "ProductName"
+	deviceSheetColStatus         = "Status"
+	deviceSheetColLastOnlineTime = "LastOnlineTime"
+	deviceSheetColClientId       = "MqttClientId"
+	deviceSheetColUserName       = "MqttUsername"
+	deviceSheetColPassword       = "MqttPassword"
+	deviceSheetColHost           = "MqttHost"
+	deviceSheetColPort           = "MqttPort"
+	// 属性列名为 Property.{code}
+	deviceSheetColPropertyPrefix = "Property."
+)
+
+// deviceSheetImportColumns 导入时读取的列
+var deviceSheetImportColumns = []string{deviceSheetColName, deviceSheetColDescription, deviceSheetColDeviceSn}
+
+// writeDeviceSheet 第一行为标题，第二行为列名，数据从第三行开始
+func writeDeviceSheet(file *dtos.ExportFile, header []string, rows [][]interface{}) error {
+	file.Excel.SetSheetName("Sheet1", dtos.DevicesFilename)
+
+	lastCol, err := excelize.ColumnNumberToName(len(deviceSheetImportColumns))
+	if err != nil {
+		return err
+	}
+	file.Excel.SetCellStyle(dtos.DevicesFilename, "A1", "A1", file.GetCenterStyle())
+	file.Excel.MergeCell(dtos.DevicesFilename, "A1", lastCol+"1")
+	file.Excel.SetCellStr(dtos.DevicesFilename, "A1", "Device Base Info")
+
+	if err = file.Excel.SetSheetRow(dtos.DevicesFilename, "A2", &header); err != nil {
+		return err
+	}
+	for i := range rows {
+		axis, err := excelize.CoordinatesToCellName(1, i+3)
+		if err != nil {
+			return err
+		}
+		if err = file.Excel.SetSheetRow(dtos.DevicesFilename, axis, &rows[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deviceSheetHeader 解析列名所在行，返回列名到列下标的映射
+func deviceSheetHeader(cols []string) (map[string]int, error) {
+	header := make(map[string]int, len(cols))
+	for i, col := range cols {
+		header[col] = i
+	}
+	if _, ok := header[deviceSheetColName]; !ok {
+		return nil, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode, fmt.Sprintf("read cols error need column %s, but read %v", deviceSheetColName, cols), nil)
+	}
+	return header, nil
+}
+
+func deviceSheetCell(cols []string, header map[string]int, name string) string {
+	i, ok := header[name]
+	if !ok || i >= len(cols) {
+		return ""
+	}
+	return cols[i]
+}
+
+// DevicesExport 按查询条件导出设备，导出的表格可修改后通过 DevicesImport 重新导入
+func (p *deviceApp) DevicesExport(ctx context.Context, req dtos.DeviceExportRequest) (*dtos.ExportFile, error) {
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, req.DeviceSearchQueryRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]string{}, deviceSheetImportColumns...)
+	header = append(header, deviceSheetColDeviceId, deviceSheetColProductName)
+	if req.WithStatus {
+		header = append(header, deviceSheetColStatus, deviceSheetColLastOnlineTime)
+	}
+	if req.WithCredentials {
+		header = append(header, deviceSheetColClientId, deviceSheetColUserName, deviceSheetColPassword, deviceSheetColHost, deviceSheetColPort)
+	}
+
+	// 不同产品的属性合并为一组列，设备没有的属性留空
+	var propertyCodes []string
+	if req.WithProperties {
+		products := make(map[string]models.Product)
+		codes := make(map[string]struct{})
+		for _, device := range devices {
+			if _, ok := products[device.ProductId]; ok {
+				continue
+			}
+			product, err := p.dbClient.ProductById(device.ProductId)
+			if err != nil {
+				return nil, err
+			}
+			products[device.ProductId] = product
+			for _, property := range product.Properties {
+				if _, ok := codes[property.Code]; ok {
+					continue
+				}
+				codes[property.Code] = struct{}{}
+				propertyCodes = append(propertyCodes, property.Code)
+				header = append(header, deviceSheetColPropertyPrefix+property.Code)
+			}
+		}
+	}
+
+	rows := make([][]interface{}, 0, len(devices))
+	for _, device := range devices {
+		row := []interface{}{device.Name, device.Description, device.DeviceSn, device.Id, device.Product.Name}
+		if req.WithStatus {
+			var lastOnline string
+			if device.LastOnlineTime > 0 {
+				lastOnline = time.UnixMilli(device.LastOnlineTime).Format("2006-01-02 15:04:05")
+			}
+			row = append(row, string(device.Status), lastOnline)
+		}
+		if req.WithCredentials {
+			row = append(row, p.deviceExportCredentials(device.Id)...)
+		}
+		if req.WithProperties {
+			row = append(row, p.deviceExportProperties(device.Id, propertyCodes)...)
+		}
+		rows = append(rows, row)
+	}
+
+	file, err := dtos.NewExportFile(dtos.DevicesFilename)
+	if err != nil {
+		return nil, err
+	}
+	if err = writeDeviceSheet(file, header, rows); err != nil {
+		p.lc.Error(err.Error())
+		return nil, err
+	}
+	return file, nil
+}
+
+func (p *deviceApp) deviceExportCredentials(deviceId string) []interface{} {
+	mqttAuth, err := p.dbClient.DeviceMqttAuthInfo(deviceId)
+	if err != nil {
+		return []interface{}{"", "", "", "", ""}
+	}
+	auth := dtos.DeviceAuthInfoResponseFromModel(mqttAuth)
+	return []interface{}{auth.ClientId, auth.UserName, auth.Password, auth.Host, auth.Port}
+}
+
+// deviceExportProperties 按 codes 顺序返回属性最新值，没有上报过的属性留空
+func (p *deviceApp) deviceExportProperties(deviceId string, codes []string) []interface{} {
+	values := make([]interface{}, len(codes))
+	for i := range values {
+		values[i] = ""
+	}
+	persistApp := container.PersistItfFrom(p.dic.Get)
+	data, err := persistApp.SearchDeviceThingModelPropertyData(dtos.ThingModelPropertyDataRequest{DeviceId: deviceId})
+	if err != nil {
+		p.lc.Errorf("device %s export property data err %v", deviceId, err)
+		return values
+	}
+	properties, _ := data.([]dtos.ThingModelDataResponse)
+	latest := make(map[string]dtos.ReportData, len(properties))
+	for _, property := range properties {
+		latest[property.Code] = property.ReportData
+	}
+	for i, code := range codes {
+		if v, ok := latest[code]; ok && v.Time > 0 {
+			values[i] = utils.InterfaceToString(v.Value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,476 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"sort"
+	"sync"
+)
+
+// 分组批量下发时同时执行的设备数
+const deviceGroupOperateConcurrency = 10
+
+func checkDeviceGroupType(groupType constants.DeviceGroupType, tags map[string]string) error {
+	switch groupType {
+	case constants.DeviceGroupStatic:
+	case constants.DeviceGroupDynamic:
+		if len(tags) == 0 {
+			return errort.NewCommonErr(errort.DeviceGroupTagsRequired, fmt.Errorf("dynamic group tags is empty"))
+		}
+	default:
+		return errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("device group type(%s) not support", groupType))
+	}
+	return nil
+}
+
+func (p *deviceApp) DeviceGroupAdd(ctx context.Context, req dtos.DeviceGroupAddRequest) (string, error) {
+	if err := checkDeviceGroupType(req.Type, req.Tags); err != nil {
+		return "", err
+	}
+	group := models.DeviceGroup{
+		Id:          utils.RandomNum(),
+		Name:        req.Name,
+		Description: req.Description,
+		Type:        req.Type,
+	}
+	if req.Type == constants.DeviceGroupDynamic {
+		group.Tags = req.Tags
+	}
+	if req.Type == constants.DeviceGroupStatic && len(req.DeviceIds) > 0 {
+		if _, err := p.topoDevices(req.DeviceIds); err != nil {
+			return "", err
+		}
+	}
+	group, err := p.dbClient.AddDeviceGroup(group)
+	if err != nil {
+		return "", err
+	}
+	if req.Type == constants.DeviceGroupStatic && len(req.DeviceIds) > 0 {
+		if err = p.dbClient.AddDeviceGroupMembers(group.Id, req.DeviceIds); err != nil {
+			return "", err
+		}
+	}
+	return group.Id, nil
+}
+
+func (p *deviceApp) DeviceGroupUpdate(ctx context.Context, req dtos.DeviceGroupUpdateRequest) error {
+	group, err := p.dbClient.DeviceGroupById(req.Id)
+	if err != nil {
+		return err
+	}
+	if req.Tags != nil && group.Type != constants.DeviceGroupDynamic {
+		return errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("static group(%s) does not support tags", group.Id))
+	}
+	dtos.ReplaceDeviceGroupModelFields(&group, req)
+	if err = checkDeviceGroupType(group.Type, group.Tags); err != nil {
+		return err
+	}
+	if err = p.dbClient.UpdateDeviceGroup(group); err != nil {
+		return err
+	}
+	if req.Tags != nil {
+		p.refreshDeviceGroupRules(ctx, group.Id)
+	}
+	return nil
+}
+
+func (p *deviceApp) DeviceGroupById(ctx context.Context, id string) (dtos.DeviceGroupResponse, error) {
+	group, err := p.dbClient.DeviceGroupById(id)
+	if err != nil {
+		return dtos.DeviceGroupResponse{}, err
+	}
+	count, err := p.deviceGroupDeviceCount(group.Id)
+	if err != nil {
+		return dtos.DeviceGroupResponse{}, err
+	}
+	return dtos.DeviceGroupResponseFromModel(group, count), nil
+}
+
+func (p *deviceApp) DeviceGroupsSearch(ctx context.Context, req dtos.DeviceGroupSearchQueryRequest) ([]dtos.DeviceGroupResponse, uint32, error) {
+	offset, limit := req.BaseSearchConditionQuery.GetPage()
+	groups, total, err := p.dbClient.DeviceGroupsSearch(offset, limit, req)
+	if err != nil {
+		return []dtos.DeviceGroupResponse{}, 0, err
+	}
+	resp := make([]dtos.DeviceGroupResponse, len(groups))
+	for i, group := range groups {
+		count, err := p.deviceGroupDeviceCount(group.Id)
+		if err != nil {
+			return []dtos.DeviceGroupResponse{}, 0, err
+		}
+		resp[i] = dtos.DeviceGroupResponseFromModel(group, count)
+	}
+	return resp, total, nil
+}
+
+// DeviceGroupDelete 删除分组，不删除分组内的设备
+func (p *deviceApp) DeviceGroupDelete(ctx context.Context, id string) error {
+	if _, err := p.dbClient.DeviceGroupById(id); err != nil {
+		return err
+	}
+	alertApp := container.AlertRuleAppNameFrom(p.dic.Get)
+	if err := alertApp.CheckRuleByDeviceGroupId(ctx, id); err != nil {
+		return err
+	}
+	sceneApp := container.SceneAppNameFrom(p.dic.Get)
+	if err := sceneApp.CheckSceneByDeviceGroupId(ctx, id); err != nil {
+		return err
+	}
+	return p.dbClient.DeleteDeviceGroupById(id)
+}
+
+func (p *deviceApp) staticDeviceGroup(groupId string) (models.DeviceGroup, error) {
+	group, err := p.dbClient.DeviceGroupById(groupId)
+	if err != nil {
+		return group, err
+	}
+	if group.Type != constants.DeviceGroupStatic {
+		return group, errort.NewCommonErr(errort.DeviceGroupNotStatic, fmt.Errorf("device group(%s) is not static", groupId))
+	}
+	return group, nil
+}
+
+func (p *deviceApp) DeviceGroupMembersAdd(ctx context.Context, req dtos.DeviceGroupMembersRequest) error {
+	group, err := p.staticDeviceGroup(req.GroupId)
+	if err != nil {
+		return err
+	}
+	if _, err = p.topoDevices(req.DeviceIds); err != nil {
+		return err
+	}
+	if err = p.dbClient.AddDeviceGroupMembers(group.Id, req.DeviceIds); err != nil {
+		return err
+	}
+	p.refreshDeviceGroupRules(ctx, group.Id)
+	return nil
+}
+
+func (p *deviceApp) DeviceGroupMembersRemove(ctx context.Context, req dtos.DeviceGroupMembersRequest) error {
+	group, err := p.staticDeviceGroup(req.GroupId)
+	if err != nil {
+		return err
+	}
+	if err = p.dbClient.DeleteDeviceGroupMembers(group.Id, req.DeviceIds); err != nil {
+		return err
+	}
+	p.refreshDeviceGroupRules(ctx, group.Id)
+	return nil
+}
+
+// DeviceGroupDevices 查询分组内的设备，productId 不为空时只返回该产品的设备
+func (p *deviceApp) DeviceGroupDevices(ctx context.Context, groupId, productId string) ([]models.Device, error) {
+	if _, err := p.dbClient.DeviceGroupById(groupId); err != nil {
+		return nil, err
+	}
+	var req dtos.DeviceSearchQueryRequest
+	req.GroupId = groupId
+	req.ProductId = productId
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, req)
+	return devices, err
+}
+
+func (p *deviceApp) deviceGroupDeviceCount(groupId string) (uint32, error) {
+	var req dtos.DeviceSearchQueryRequest
+	req.GroupId = groupId
+	_, total, err := p.dbClient.DevicesSearch(0, 1, req)
+	return total, err
+}
+
+// deviceGroupOperate 对分组内的设备并发执行操作，单个设备失败不影响其他设备
+func (p *deviceApp) deviceGroupOperate(ctx context.Context, groupId string, operate func(device models.Device) error) ([]dtos.DeviceGroupOperateResult, error) {
+	devices, err := p.DeviceGroupDevices(ctx, groupId, "")
+	if err != nil {
+		return nil, err
+	}
+	// 操作需要校验物模型，按产品加载一次
+	products := make(map[string]models.Product)
+	for i := range devices {
+		product, ok := products[devices[i].ProductId]
+		if !ok {
+			if product, err = p.dbClient.ProductById(devices[i].ProductId); err != nil {
+				return nil, err
+			}
+			products[devices[i].ProductId] = product
+		}
+		devices[i].Product = product
+	}
+	results := make([]dtos.DeviceGroupOperateResult, len(devices))
+	sem := make(chan struct{}, deviceGroupOperateConcurrency)
+	var wg sync.WaitGroup
+	for i := range devices {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = dtos.DeviceGroupOperateResult{
+				DeviceId:   devices[i].Id,
+				DeviceName: devices[i].Name,
+				Success:    true,
+			}
+			if err := operate(devices[i]); err != nil {
+				results[i].Success = false
+				results[i].Message = err.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// DeviceGroupPropertySet 向分组内的设备下发属性，设备不存在该属性时跳过
+func (p *deviceApp) DeviceGroupPropertySet(ctx context.Context, req dtos.DeviceGroupPropertySetRequest) ([]dtos.DeviceGroupOperateResult, error) {
+	return p.deviceGroupOperate(ctx, req.GroupId, func(device models.Device) error {
+		item := make(map[string]interface{})
+		for code, value := range req.Item {
+			for _, property := range device.Product.Properties {
+				if property.Code == code {
+					item[code] = value
+					break
+				}
+			}
+		}
+		if len(item) == 0 {
+			return errort.NewCommonErr(errort.ProductPropertyCodeNotExist, fmt.Errorf("device(%s) has no matching property", device.Id))
+		}
+		return p.SetDeviceProperty(dtos.OpenApiSetDeviceThingModel{
+			DeviceId: device.Id,
+			Item:     item,
+		})
+	})
+}
+
+func (p *deviceApp) DeviceGroupServiceInvoke(ctx context.Context, req dtos.DeviceGroupServiceInvokeRequest) ([]dtos.DeviceGroupOperateResult, error) {
+	return p.deviceGroupOperate(ctx, req.GroupId, func(device models.Device) error {
+		var find bool
+		for _, action := range device.Product.Actions {
+			if action.Code == req.Code {
+				find = true
+				break
+			}
+		}
+		if !find {
+			return errort.NewCommonErr(errort.DeviceCommandNotExist, fmt.Errorf("device(%s) service(%s) not found", device.Id, req.Code))
+		}
+		_, err := p.DeviceInvokeThingService(dtos.InvokeDeviceServiceReq{
+			DeviceId: device.Id,
+			Code:     req.Code,
+			Items:    req.Items,
+		})
+		return err
+	})
+}
+
+// DeviceGroupBindDriver 分组内尚未绑定该驱动的设备绑定驱动
+func (p *deviceApp) DeviceGroupBindDriver(ctx context.Context, req dtos.DeviceGroupBindDriverRequest) error {
+	devices, err := p.DeviceGroupDevices(ctx, req.GroupId, "")
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, device := range devices {
+		if device.DriveInstanceId != req.DriverInstanceId {
+			ids = append(ids, device.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return p.DevicesBindDriver(ctx, dtos.DevicesBindDriver{
+		DeviceIds:        ids,
+		DriverInstanceId: req.DriverInstanceId,
+	})
+}
+
+// DeviceGroupDeleteDevices 删除分组内的全部设备，分组保留
+func (p *deviceApp) DeviceGroupDeleteDevices(ctx context.Context, groupId string) error {
+	devices, err := p.DeviceGroupDevices(ctx, groupId, "")
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(devices))
+	for _, device := range devices {
+		ids = append(ids, device.Id)
+	}
+	return p.BatchDeleteDevice(ctx, ids)
+}
+
+// DeviceTags 查询已使用的标签及其取值
+func (p *deviceApp) DeviceTags(ctx context.Context) ([]dtos.DeviceTagResponse, error) {
+	tags, err := p.dbClient.DeviceTagsDistinct()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	resp := make([]dtos.DeviceTagResponse, 0)
+	for _, tag := range tags {
+		i, ok := index[tag.TagKey]
+		if !ok {
+			i = len(resp)
+			index[tag.TagKey] = i
+			resp = append(resp, dtos.DeviceTagResponse{Key: tag.TagKey})
+		}
+		resp[i].Values = append(resp[i].Values, tag.TagValue)
+	}
+	sort.Slice(resp, func(i, j int) bool {
+		return resp[i].Key < resp[j].Key
+	})
+	return resp, nil
+}
+
+// setDeviceTags 更新设备标签，动态分组的成员可能随之变化
+func (p *deviceApp) setDeviceTags(ctx context.Context, deviceId string, oldTags, newTags map[string]string) error {
+	if err := p.dbClient.SetDeviceTags(deviceId, newTags); err != nil {
+		return err
+	}
+	groupIds, err := p.dynamicDeviceGroups(oldTags, newTags)
+	if err != nil {
+		p.lc.Errorf("device %s query dynamic groups err %v", deviceId, err)
+		return nil
+	}
+	p.refreshDeviceGroupRules(ctx, groupIds...)
+	return nil
+}
+
+func (p *deviceApp) deviceTags(deviceIds ...string) (map[string]map[string]string, error) {
+	if len(deviceIds) == 0 {
+		return map[string]map[string]string{}, nil
+	}
+	tags, err := p.dbClient.DeviceTagsByDeviceIds(deviceIds)
+	if err != nil {
+		return nil, err
+	}
+	return dtos.DeviceTagsFromModel(tags), nil
+}
+
+// dynamicDeviceGroups 查询与任一标签集合匹配的动态分组
+func (p *deviceApp) dynamicDeviceGroups(tagsList ...map[string]string) ([]string, error) {
+	var req dtos.DeviceGroupSearchQueryRequest
+	req.Type = string(constants.DeviceGroupDynamic)
+	groups, _, err := p.dbClient.DeviceGroupsSearch(0, -1, req)
+	if err != nil {
+		return nil, err
+	}
+	var groupIds []string
+	for _, group := range groups {
+		for _, tags := range tagsList {
+			if group.Match(tags) {
+				groupIds = append(groupIds, group.Id)
+				break
+			}
+		}
+	}
+	return groupIds, nil
+}
+
+// deleteDeviceRelations 删除设备的标签、分组关系和上下线记录，并刷新受影响分组的规则
+func (p *deviceApp) deleteDeviceRelations(ctx context.Context, deviceIds []string) {
+	p.computed.forget(deviceIds)
+	if err := p.dbClient.DeleteDeviceConnectLogsByDeviceIds(deviceIds); err != nil {
+		p.lc.Errorf("delete device connect logs err %v", err)
+	}
+	if err := p.dbClient.DeleteDeviceInvalidSamplesByDeviceIds(deviceIds); err != nil {
+		p.lc.Errorf("delete device invalid samples err %v", err)
+	}
+	tags, _ := p.deviceTags(deviceIds...)
+	members, err := p.dbClient.DeviceGroupMembersByDeviceIds(deviceIds)
+	if err != nil {
+		p.lc.Errorf("query device group members err %v", err)
+	}
+	if err = p.dbClient.DeleteDeviceRelationsByDeviceIds(deviceIds); err != nil {
+		p.lc.Errorf("delete device relations err %v", err)
+		return
+	}
+	var groupIds []string
+	for _, member := range members {
+		groupIds = append(groupIds, member.GroupId)
+	}
+	tagsList := make([]map[string]string, 0, len(tags))
+	for _, t := range tags {
+		tagsList = append(tagsList, t)
+	}
+	dynamicIds, err := p.dynamicDeviceGroups(tagsList...)
+	if err != nil {
+		p.lc.Errorf("query dynamic groups err %v", err)
+	}
+	p.refreshDeviceGroupRules(ctx, append(groupIds, dynamicIds...)...)
+}
+
+// refreshDeviceGroupRules 分组成员变化后，重新生成以分组为目标的告警规则和场景
+func (p *deviceApp) refreshDeviceGroupRules(ctx context.Context, groupIds ...string) {
+	if len(groupIds) == 0 {
+		return
+	}
+	alertApp := container.AlertRuleAppNameFrom(p.dic.Get)
+	sceneApp := container.SceneAppNameFrom(p.dic.Get)
+	refreshed := make(map[string]struct{}, len(groupIds))
+	for _, groupId := range groupIds {
+		if _, ok := refreshed[groupId]; ok {
+			continue
+		}
+		refreshed[groupId] = struct{}{}
+		if err := alertApp.RefreshRulesByDeviceGroupId(ctx, groupId); err != nil {
+			p.lc.Errorf("device group %s refresh alert rules err %v", groupId, err)
+		}
+		if err := sceneApp.RefreshScenesByDeviceGroupId(ctx, groupId); err != nil {
+			p.lc.Errorf("device group %s refresh scenes err %v", groupId, err)
+		}
+	}
+}
+
+// deviceGroupAction 场景联动、定时任务以分组为目标时，对分组内该产品的全部设备执行
+func (p *deviceApp) deviceGroupAction(jobAction dtos.JobAction) dtos.DeviceExecRes {
+	devices, err := p.DeviceGroupDevices(context.Background(), jobAction.GroupId, jobAction.ProductId)
+	if err != nil {
+		return dtos.DeviceExecRes{
+			Result:  false,
+			Message: err.Error(),
+		}
+	}
+	if len(devices) == 0 {
+		return dtos.DeviceExecRes{
+			Result:  false,
+			Message: "device group has no device",
+		}
+	}
+	var failed int
+	for _, device := range devices {
+		action := jobAction
+		action.GroupId = ""
+		action.DeviceId = device.Id
+		action.DeviceName = device.Name
+		if res := p.DeviceAction(action); !res.Result {
+			failed++
+			p.lc.Errorf("device group %s device %s action err %s", jobAction.GroupId, device.Id, res.Message)
+		}
+	}
+	return dtos.DeviceExecRes{
+		Result:  failed == 0,
+		Message: fmt.Sprintf("device group %s: %d succeeded, %d failed", jobAction.GroupId, len(devices)-failed, failed),
+	}
+}
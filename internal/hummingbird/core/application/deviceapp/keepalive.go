@@ -0,0 +1,150 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"sync"
+	"time"
+)
+
+// keepAliveCheckInterval 心跳超时检测周期
+const keepAliveCheckInterval = 10 * time.Second
+
+// keepAlive 记录设备最近一次活跃时间（上报消息或连接），用于心跳超时离线检测
+type keepAlive struct {
+	startTime int64
+	// lastActive deviceId -> 最近活跃时间(毫秒)
+	lastActive sync.Map
+	// expired 因心跳超时被置为离线的设备，再次活跃时自动恢复在线
+	expired sync.Map
+}
+
+func newKeepAlive() *keepAlive {
+	return &keepAlive{
+		startTime: utils.MakeTimestamp(),
+	}
+}
+
+// DeviceKeepAlive 刷新设备活跃时间，设备此前因心跳超时离线时恢复为在线
+func (p deviceApp) DeviceKeepAlive(ctx context.Context, deviceId string) {
+	p.keepAlive.lastActive.Store(deviceId, utils.MakeTimestamp())
+	if _, ok := p.keepAlive.expired.LoadAndDelete(deviceId); !ok {
+		return
+	}
+	device, err := p.dbClient.DeviceById(deviceId)
+	if err != nil || device.Status == constants.DeviceStatusOnline {
+		return
+	}
+	if err = p.dbClient.DeviceOnlineById(deviceId); err != nil {
+		p.lc.Errorf("keepalive device %s online err %v", deviceId, err)
+		return
+	}
+	p.recordDeviceConnect(deviceId, device.ProductId, constants.DeviceOnline, constants.DeviceConnectReasonKeepAliveRecover)
+	container.MessageItfFrom(p.dic.Get).DeviceStatusToMessageBus(ctx, deviceId, constants.DeviceOnline)
+	go p.DeviceShadowDeliver(context.Background(), deviceId, true)
+}
+
+func (p deviceApp) keepAliveMonitor(ctx context.Context) {
+	ticker := time.NewTicker(keepAliveCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkKeepAlive(ctx)
+		}
+	}
+}
+
+// checkKeepAlive 将超过心跳超时时间未活跃的在线设备置为离线。
+// 超时时间优先取设备配置，未配置时取产品配置，均为 0 时不检测。
+func (p deviceApp) checkKeepAlive(ctx context.Context) {
+	var req dtos.DeviceSearchQueryRequest
+	req.Status = string(constants.DeviceStatusOnline)
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, req)
+	if err != nil {
+		p.lc.Errorf("keepalive search online devices err %v", err)
+		return
+	}
+	now := utils.MakeTimestamp()
+	for _, device := range devices {
+		timeout := deviceKeepAliveTimeout(device)
+		if timeout <= 0 {
+			continue
+		}
+		// 重启后内存中无活跃记录，从启动时间开始计算，避免重启时误判离线
+		last := p.keepAlive.startTime
+		if device.LastOnlineTime > last {
+			last = device.LastOnlineTime
+		}
+		if v, ok := p.keepAlive.lastActive.Load(device.Id); ok && v.(int64) > last {
+			last = v.(int64)
+		}
+		if now-last <= timeout*1000 {
+			continue
+		}
+		p.lc.Infof("device %s keepalive timeout(%ds), last active at %d", device.Id, timeout, last)
+		if err = p.deviceOffline(ctx, device, constants.DeviceConnectReasonKeepAliveTimeout); err != nil {
+			p.lc.Errorf("keepalive device %s offline err %v", device.Id, err)
+			continue
+		}
+		p.keepAlive.expired.Store(device.Id, struct{}{})
+	}
+}
+
+func deviceKeepAliveTimeout(device models.Device) int64 {
+	if device.KeepAlive > 0 {
+		return device.KeepAlive
+	}
+	return device.Product.KeepAlive
+}
+
+func (p deviceApp) deviceOffline(ctx context.Context, device models.Device, reason constants.DeviceConnectReason) error {
+	if err := p.dbClient.DeviceOfflineById(device.Id); err != nil {
+		return err
+	}
+	p.recordDeviceConnect(device.Id, device.ProductId, constants.DeviceOffline, reason)
+	p.keepAlive.lastActive.Delete(device.Id)
+	container.MessageItfFrom(p.dic.Get).DeviceStatusToMessageBus(ctx, device.Id, constants.DeviceOffline)
+	p.subDevicesOffline(ctx, device.Id)
+	return nil
+}
+
+// DevicesOfflineByDriveInstanceId 驱动实例停止后，将其下所有在线设备置为离线
+func (p deviceApp) DevicesOfflineByDriveInstanceId(ctx context.Context, driveInstanceId string) error {
+	if driveInstanceId == "" {
+		return nil
+	}
+	var req dtos.DeviceSearchQueryRequest
+	req.DriveInstanceId = driveInstanceId
+	req.Status = string(constants.DeviceStatusOnline)
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, req)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if err = p.deviceOffline(ctx, device, constants.DeviceConnectReasonDriverStopped); err != nil {
+			p.lc.Errorf("driver instance %s device %s offline err %v", driveInstanceId, device.Id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"math"
+)
+
+// DeviceThingModelScale 按属性配置将设备上报的原始值转换为工程值，在物模型校验之前执行，
+// 无法转换为数值的上报值保持原样，由物模型校验处理。thingModel 为 nil 表示设备或产品查询失败，不转换。
+func (p deviceApp) DeviceThingModelScale(ctx context.Context, msg dtos.ThingModelMessage, thingModel *dtos.DeviceThingModel) dtos.ThingModelMessage {
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT, thingmodel.OperationType_DATA_BATCH_REPORT:
+	default:
+		return msg
+	}
+	if thingModel == nil {
+		return msg
+	}
+	scaling := make(map[string]models.Properties)
+	for _, property := range thingModel.Product.Properties {
+		if property.Scaling.Enabled() && !property.IsComputed() {
+			scaling[property.Code] = property
+		}
+	}
+	if len(scaling) == 0 {
+		return msg
+	}
+
+	var changed bool
+	scale := func(code string, value interface{}) (interface{}, bool) {
+		property, ok := scaling[code]
+		if !ok {
+			return value, false
+		}
+		raw, ok := computedNumber(value)
+		if !ok {
+			return value, false
+		}
+		v := property.Scaling.Apply(raw)
+		changed = true
+		if property.TypeSpec.Type == constants.SpecsTypeInt {
+			return int64(math.Round(v)), true
+		}
+		return v, true
+	}
+	var data interface{}
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT:
+		report, err := msg.TransformMessageDataByProperty()
+		if err != nil {
+			return msg
+		}
+		for code, value := range report.Data {
+			if v, ok := scale(code, value.Value); ok {
+				value.Value = v
+				report.Data[code] = value
+			}
+		}
+		data = report
+	case thingmodel.OperationType_DATA_BATCH_REPORT:
+		report, err := msg.TransformMessageDataByBatchReport()
+		if err != nil {
+			return msg
+		}
+		for code, value := range report.Data.Properties {
+			if v, ok := scale(code, value.Value); ok {
+				value.Value = v
+				report.Data.Properties[code] = value
+			}
+		}
+		data = report
+	}
+	if !changed {
+		return msg
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return msg
+	}
+	msg.Data = string(b)
+	return msg
+}
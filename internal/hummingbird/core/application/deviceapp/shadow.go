@@ -0,0 +1,513 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/distribution/uuid"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"github.com/winc-link/hummingbird/internal/tools/rpcclient"
+	"sync"
+	"time"
+)
+
+// shadowRetryInterval 设备上报触发期望值下发的最小间隔，避免设备拒绝设置时每次上报都重复下发
+const shadowRetryInterval = 30 * time.Second
+
+// shadowFlushInterval 设备上报只更新内存中的影子，按该间隔将 reported 有变化的影子批量写回数据库
+const shadowFlushInterval = 5 * time.Second
+
+// shadowState 设备影子的缓存、并发控制及下发状态
+type shadowState struct {
+	// locks deviceId -> *sync.Mutex，同一设备影子的读改写串行执行
+	locks sync.Map
+	// delivering 正在下发期望值的设备
+	delivering sync.Map
+	// lastDeliver deviceId -> 最近一次下发时间(毫秒)
+	lastDeliver sync.Map
+	// shadows deviceId -> models.DeviceShadow，缓存的影子不再修改，更新时复制后整体替换
+	shadows sync.Map
+	// dirty reported 已更新、尚未写回数据库的设备
+	dirty sync.Map
+	// flushMu 批量写回、期望值修改和影子删除的数据库写入串行执行，
+	// 避免写回的旧影子覆盖新的期望值，或恢复已删除的影子
+	flushMu sync.Mutex
+}
+
+func newShadowState() *shadowState {
+	return &shadowState{}
+}
+
+func (s *shadowState) lock(deviceId string) func() {
+	v, _ := s.locks.LoadOrStore(deviceId, new(sync.Mutex))
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// loadDeviceShadow 优先读取缓存，返回影子的副本，设备影子不存在时返回空影子
+func (p deviceApp) loadDeviceShadow(deviceId string) (models.DeviceShadow, error) {
+	if v, ok := p.shadow.shadows.Load(deviceId); ok {
+		shadow := v.(models.DeviceShadow)
+		return shadow.Clone(), nil
+	}
+	shadow, err := p.dbClient.DeviceShadowById(deviceId)
+	if err != nil {
+		if !errort.Is(errort.DefaultResourcesNotFound, err) {
+			return shadow, err
+		}
+		shadow = models.DeviceShadow{Id: deviceId}
+	}
+	if shadow.Desired == nil {
+		shadow.Desired = models.ShadowState{}
+	}
+	if shadow.Reported == nil {
+		shadow.Reported = models.ShadowState{}
+	}
+	v, _ := p.shadow.shadows.LoadOrStore(deviceId, shadow)
+	shadow = v.(models.DeviceShadow)
+	return shadow.Clone(), nil
+}
+
+// saveDeviceShadow 期望值修改立即写入数据库，同时写入缓存中 reported 未写回的部分
+func (p deviceApp) saveDeviceShadow(shadow models.DeviceShadow) error {
+	p.shadow.flushMu.Lock()
+	defer p.shadow.flushMu.Unlock()
+	if err := p.dbClient.UpsertDeviceShadow(shadow); err != nil {
+		return err
+	}
+	p.shadow.shadows.Store(shadow.Id, shadow)
+	p.shadow.dirty.Delete(shadow.Id)
+	return nil
+}
+
+// Run 启动影子的批量写回，退出前写回全部未保存的影子
+func (p deviceApp) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(shadowFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.flushDeviceShadows()
+			case <-ctx.Done():
+				p.flushDeviceShadows()
+				return
+			}
+		}
+	}()
+}
+
+// flushDeviceShadows 将 reported 有变化的影子批量写回数据库，写入失败时下次重试
+func (p deviceApp) flushDeviceShadows() {
+	p.shadow.flushMu.Lock()
+	defer p.shadow.flushMu.Unlock()
+	var shadows []models.DeviceShadow
+	p.shadow.dirty.Range(func(key, _ interface{}) bool {
+		// 先清除标记再读取影子，读取之后的更新会重新标记，在下次写回
+		p.shadow.dirty.Delete(key)
+		if v, ok := p.shadow.shadows.Load(key); ok {
+			shadows = append(shadows, v.(models.DeviceShadow))
+		}
+		return true
+	})
+	if len(shadows) == 0 {
+		return
+	}
+	if err := p.dbClient.BatchUpsertDeviceShadow(shadows); err != nil {
+		p.lc.Errorf("flush %d device shadows err %v", len(shadows), err)
+		for _, shadow := range shadows {
+			p.shadow.dirty.Store(shadow.Id, struct{}{})
+		}
+	}
+}
+
+func (p deviceApp) DeviceShadowById(ctx context.Context, deviceId string) (dtos.DeviceShadowResponse, error) {
+	if _, err := p.dbClient.DeviceById(deviceId); err != nil {
+		return dtos.DeviceShadowResponse{}, err
+	}
+	shadow, err := p.loadDeviceShadow(deviceId)
+	if err != nil {
+		return dtos.DeviceShadowResponse{}, err
+	}
+	return dtos.DeviceShadowResponseFromModel(shadow), nil
+}
+
+// DeviceShadowUpdateDesired 修改属性期望值，驱动运行中时立即尝试下发，
+// 设备不在线或下发失败时保留期望值，待设备重新上线或上报数据时再次下发。
+func (p deviceApp) DeviceShadowUpdateDesired(ctx context.Context, req dtos.DeviceShadowDesiredRequest) (dtos.DeviceShadowResponse, error) {
+	var response dtos.DeviceShadowResponse
+	device, err := p.dbClient.DeviceById(req.DeviceId)
+	if err != nil {
+		return response, err
+	}
+	product, err := p.dbClient.ProductById(device.ProductId)
+	if err != nil {
+		return response, err
+	}
+	for code, value := range req.Desired {
+		if value == nil {
+			continue
+		}
+		if err = checkPropertySet(product, code, value); err != nil {
+			return response, err
+		}
+	}
+
+	unlock := p.shadow.lock(req.DeviceId)
+	shadow, err := p.loadDeviceShadow(req.DeviceId)
+	if err != nil {
+		unlock()
+		return response, err
+	}
+	if req.Version > 0 && req.Version != shadow.Version {
+		unlock()
+		return response, errort.NewCommonErr(errort.DeviceShadowVersionConflict,
+			fmt.Errorf("device shadow version is %d, request version is %d", shadow.Version, req.Version))
+	}
+	now := utils.MakeTimestamp()
+	for code, value := range req.Desired {
+		if value == nil {
+			delete(shadow.Desired, code)
+			continue
+		}
+		shadow.Desired[code] = models.ShadowValue{Value: value, Time: now}
+	}
+	shadow.Version++
+	err = p.saveDeviceShadow(shadow)
+	unlock()
+	if err != nil {
+		return response, err
+	}
+
+	if len(shadow.Delta()) > 0 {
+		go p.DeviceShadowDeliver(context.Background(), req.DeviceId, true)
+	}
+	return dtos.DeviceShadowResponseFromModel(shadow), nil
+}
+
+// checkPropertySet 校验下发的属性可写，且属性值符合物模型定义
+func checkPropertySet(product models.Product, code string, value interface{}) error {
+	for _, property := range product.Properties {
+		if property.Code != code {
+			continue
+		}
+		if property.AccessMode == "R" || property.IsComputed() {
+			return errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("property code(%s) is read only", code))
+		}
+		if err := property.TypeSpec.Validate(value); err != nil {
+			return errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("property code(%s) %v", code, err))
+		}
+		return nil
+	}
+	return errort.NewCommonErr(errort.ProductPropertyCodeNotExist, fmt.Errorf("property code(%s) not found", code))
+}
+
+// DeviceShadowDeleteDesired 清除属性期望值，codes 为空时清除全部
+func (p deviceApp) DeviceShadowDeleteDesired(ctx context.Context, deviceId string, codes []string) (dtos.DeviceShadowResponse, error) {
+	var response dtos.DeviceShadowResponse
+	if _, err := p.dbClient.DeviceById(deviceId); err != nil {
+		return response, err
+	}
+	shadow, err := p.deleteShadowDesired(deviceId, codes)
+	if err != nil {
+		return response, err
+	}
+	return dtos.DeviceShadowResponseFromModel(shadow), nil
+}
+
+func (p deviceApp) deleteShadowDesired(deviceId string, codes []string) (models.DeviceShadow, error) {
+	defer p.shadow.lock(deviceId)()
+	shadow, err := p.loadDeviceShadow(deviceId)
+	if err != nil {
+		return shadow, err
+	}
+	if len(codes) == 0 {
+		shadow.Desired = models.ShadowState{}
+	}
+	for _, code := range codes {
+		delete(shadow.Desired, code)
+	}
+	shadow.Version++
+	return shadow, p.saveDeviceShadow(shadow)
+}
+
+// DeviceShadowReport 根据设备属性上报更新影子的 reported，设备仍有未同步的期望值时尝试下发。
+// thingModel 为 nil 表示设备查询失败，不更新影子。
+func (p deviceApp) DeviceShadowReport(ctx context.Context, msg dtos.ThingModelMessage, thingModel *dtos.DeviceThingModel) {
+	if thingModel == nil {
+		return
+	}
+	reported := make(models.ShadowState)
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT:
+		propertyMsg, err := msg.TransformMessageDataByProperty()
+		if err != nil {
+			return
+		}
+		for code, data := range propertyMsg.Data {
+			reported[code] = models.ShadowValue{Value: data.Value, Time: data.Time}
+		}
+	case thingmodel.OperationType_DATA_BATCH_REPORT:
+		batchMsg, err := msg.TransformMessageDataByBatchReport()
+		if err != nil {
+			return
+		}
+		for code, data := range batchMsg.Data.Properties {
+			reported[code] = models.ShadowValue{Value: data.Value, Time: batchMsg.Time}
+		}
+	default:
+		return
+	}
+	if len(reported) == 0 {
+		return
+	}
+	delta, err := p.updateShadowReported(msg.Cid, reported, nil)
+	if err != nil {
+		p.lc.Errorf("device %s shadow reported update err %v", msg.Cid, err)
+		return
+	}
+	if len(delta) > 0 {
+		go p.deliverDeviceShadow(context.Background(), thingModel.Device, false)
+	}
+}
+
+// updateShadowReported 合并 reported，expect 不为空时仅更新期望值仍与 expect 一致的属性。
+// 只更新缓存，由 flushDeviceShadows 批量写回数据库。
+func (p deviceApp) updateShadowReported(deviceId string, reported models.ShadowState, expect map[string]interface{}) (map[string]interface{}, error) {
+	defer p.shadow.lock(deviceId)()
+	shadow, err := p.loadDeviceShadow(deviceId)
+	if err != nil {
+		return nil, err
+	}
+	for code, value := range reported {
+		if expect != nil {
+			desired, ok := shadow.Desired[code]
+			if !ok || !desired.Equal(models.ShadowValue{Value: expect[code]}) {
+				continue
+			}
+		}
+		shadow.Reported[code] = value
+	}
+	p.shadow.shadows.Store(deviceId, shadow)
+	p.shadow.dirty.Store(deviceId, struct{}{})
+	return shadow.Delta(), nil
+}
+
+// DeviceShadowDeliver 将影子中未同步的期望值(delta)下发给设备，设备确认后写入 reported。
+// force 为 false 时距离上次下发不足 shadowRetryInterval 则跳过。
+func (p deviceApp) DeviceShadowDeliver(ctx context.Context, deviceId string, force bool) {
+	device, err := p.dbClient.DeviceById(deviceId)
+	if err != nil {
+		return
+	}
+	p.deliverDeviceShadow(ctx, device, force)
+}
+
+func (p deviceApp) deliverDeviceShadow(ctx context.Context, device models.Device, force bool) {
+	deviceId := device.Id
+	defer func() {
+		if err := recover(); err != nil {
+			p.lc.Error("Panic:", err)
+		}
+	}()
+	if _, loaded := p.shadow.delivering.LoadOrStore(deviceId, struct{}{}); loaded {
+		return
+	}
+	defer p.shadow.delivering.Delete(deviceId)
+
+	now := utils.MakeTimestamp()
+	if v, ok := p.shadow.lastDeliver.Load(deviceId); ok && !force && now-v.(int64) < shadowRetryInterval.Milliseconds() {
+		return
+	}
+
+	shadow, err := p.loadDeviceShadow(deviceId)
+	if err != nil {
+		p.lc.Errorf("device %s shadow load err %v", deviceId, err)
+		return
+	}
+	delta := shadow.Delta()
+	if len(delta) == 0 {
+		return
+	}
+	deviceService, err := p.dbClient.DeviceServiceById(device.DriveInstanceId)
+	if err != nil {
+		return
+	}
+	if container.DriverServiceAppFrom(di.GContainer.Get).GetState(deviceService.Id) != constants.RunStatusStarted {
+		return
+	}
+
+	p.shadow.lastDeliver.Store(deviceId, now)
+	resp, err := p.issuePropertySet(ctx, deviceService, deviceId, delta)
+	if err != nil {
+		p.lc.Infof("device %s shadow delta deliver err %v", deviceId, err)
+		return
+	}
+	if !resp.Success {
+		p.lc.Infof("device %s shadow delta rejected, code %d, %s", deviceId, resp.Code, resp.ErrorMessage)
+		return
+	}
+	reported := make(models.ShadowState)
+	ts := utils.MakeTimestamp()
+	for code, value := range delta {
+		reported[code] = models.ShadowValue{Value: value, Time: ts}
+	}
+	if _, err = p.updateShadowReported(deviceId, reported, delta); err != nil {
+		p.lc.Errorf("device %s shadow reported update err %v", deviceId, err)
+	}
+}
+
+func (p deviceApp) issuePropertySet(ctx context.Context, deviceService models.DeviceService, deviceId string, params map[string]interface{}) (dtos.DevicePropertySetData, error) {
+	var resp dtos.DevicePropertySetData
+	client, err := rpcclient.NewDriverRpcClient(deviceService.BaseAddress, false, "", deviceService.Id, p.lc)
+	if err != nil {
+		return resp, err
+	}
+	defer client.Close()
+
+	var rpcRequest thingmodel.ThingModelIssueMsg
+	rpcRequest.DeviceId = deviceId
+	rpcRequest.OperationType = thingmodel.OperationType_PROPERTY_SET
+	var data dtos.PropertySet
+	data.Version = "v1.0"
+	data.MsgId = uuid.Generate().String()
+	data.Time = time.Now().UnixMilli()
+	data.Params = params
+	rpcRequest.Data = data.ToString()
+
+	messageStore := container.MessageStoreItfFrom(p.dic.Get)
+	ch := messageStore.GenAckChan(data.MsgId)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	if _, err = client.ThingModelDownServiceClient.ThingModelMsgIssue(ctx, &rpcRequest); err != nil {
+		ch.TryCloseChan()
+		return resp, err
+	}
+	select {
+	case <-ctx.Done():
+		ch.TryCloseChan()
+		return resp, errort.NewCommonErr(errort.DeviceLibraryResponseTimeOut, fmt.Errorf("driver id(%s) time out", deviceService.Id))
+	case v := <-ch.DataChan:
+		if r, ok := v.(dtos.DevicePropertySetData); ok {
+			return r, nil
+		}
+		return resp, fmt.Errorf("unexpected property set response %v", v)
+	}
+}
+
+// DeviceShadowDesired 响应设备(驱动)获取或清除属性期望值的请求
+func (p deviceApp) DeviceShadowDesired(ctx context.Context, msg dtos.ThingModelMessage) {
+	defer func() {
+		if err := recover(); err != nil {
+			p.lc.Error("Panic:", err)
+		}
+	}()
+	var (
+		msgId    string
+		codes    []string
+		response dtos.DesiredResponse
+		opType   thingmodel.OperationType
+		shadow   models.DeviceShadow
+		err      error
+	)
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_DESIRED_GET:
+		req, e := msg.TransformMessageDataByDesiredGet()
+		if e != nil {
+			return
+		}
+		msgId, codes, opType = req.MsgId, req.Data, thingmodel.OperationType_PROPERTY_DESIRED_GET_RESPONSE
+		shadow, err = p.loadDeviceShadow(msg.Cid)
+	case thingmodel.OperationType_PROPERTY_DESIRED_DELETE:
+		req, e := msg.TransformMessageDataByDesiredDelete()
+		if e != nil {
+			return
+		}
+		msgId, codes, opType = req.MsgId, req.Data, thingmodel.OperationType_PROPERTY_DESIRED_DELETE_RESPONSE
+		// 设备清除期望值时必须指定属性，避免误清空全部期望值
+		if len(codes) > 0 {
+			shadow, err = p.deleteShadowDesired(msg.Cid, codes)
+		} else {
+			shadow, err = p.loadDeviceShadow(msg.Cid)
+		}
+	default:
+		return
+	}
+
+	response.MsgId = msgId
+	response.Version = "v1.0"
+	response.Time = utils.MakeTimestamp()
+	if err != nil {
+		response.Code = errort.NewCommonEdgeXWrapper(err).Code()
+	} else {
+		response.Success = true
+		response.ShadowVersion = shadow.Version
+		response.Data = models.ShadowState{}
+		if opType == thingmodel.OperationType_PROPERTY_DESIRED_GET_RESPONSE {
+			for code, value := range shadow.Desired {
+				if len(codes) == 0 || utils.InStringSlice(code, codes) {
+					response.Data[code] = value
+				}
+			}
+		}
+	}
+
+	device, err := p.dbClient.DeviceById(msg.Cid)
+	if err != nil {
+		return
+	}
+	deviceService, err := p.dbClient.DeviceServiceById(device.DriveInstanceId)
+	if err != nil {
+		return
+	}
+	client, err := rpcclient.NewDriverRpcClient(deviceService.BaseAddress, false, "", deviceService.Id, p.lc)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	var rpcRequest thingmodel.ThingModelIssueMsg
+	rpcRequest.DeviceId = msg.Cid
+	rpcRequest.OperationType = opType
+	rpcRequest.Data = response.ToString()
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	if _, err = client.ThingModelDownServiceClient.ThingModelMsgIssue(ctx, &rpcRequest); err != nil {
+		p.lc.Errorf("device %s shadow desired response err %v", msg.Cid, err)
+	}
+}
+
+func (p deviceApp) deleteDeviceShadow(deviceId string) {
+	p.shadow.flushMu.Lock()
+	p.shadow.shadows.Delete(deviceId)
+	p.shadow.dirty.Delete(deviceId)
+	if err := p.dbClient.DeleteDeviceShadowById(deviceId); err != nil {
+		p.lc.Errorf("delete device %s shadow err %v", deviceId, err)
+	}
+	p.shadow.flushMu.Unlock()
+	p.shadow.locks.Delete(deviceId)
+	p.shadow.lastDeliver.Delete(deviceId)
+}
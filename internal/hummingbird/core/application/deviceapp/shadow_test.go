@@ -0,0 +1,164 @@
+package deviceapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+// shadowDBClient 记录影子的数据库写入
+type shadowDBClient struct {
+	interfaces.DBClient
+	mu      sync.Mutex
+	shadows map[string]models.DeviceShadow
+	upserts int
+	batches int
+	fail    bool
+}
+
+func (c *shadowDBClient) DeviceById(id string) (models.Device, error) {
+	return models.Device{Id: id, ProductId: "p1"}, nil
+}
+
+func (c *shadowDBClient) ProductById(id string) (models.Product, error) {
+	return models.Product{Id: id, Properties: []models.Properties{
+		{Code: "temp", AccessMode: "RW", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeInt}},
+	}}, nil
+}
+
+func (c *shadowDBClient) DeviceServiceById(id string) (models.DeviceService, error) {
+	return models.DeviceService{}, errort.NewCommonErr(errort.DeviceServiceNotExist, nil)
+}
+
+func (c *shadowDBClient) DeviceShadowById(id string) (models.DeviceShadow, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shadow, ok := c.shadows[id]
+	if !ok {
+		return shadow, errort.NewCommonErr(errort.DefaultResourcesNotFound, nil)
+	}
+	return shadow, nil
+}
+
+func (c *shadowDBClient) UpsertDeviceShadow(shadow models.DeviceShadow) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.upserts++
+	c.shadows[shadow.Id] = shadow.Clone()
+	return nil
+}
+
+func (c *shadowDBClient) BatchUpsertDeviceShadow(shadows []models.DeviceShadow) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return errors.New("database is locked")
+	}
+	c.batches++
+	for _, shadow := range shadows {
+		c.shadows[shadow.Id] = shadow.Clone()
+	}
+	return nil
+}
+
+func (c *shadowDBClient) DeleteDeviceShadowById(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.shadows, id)
+	return nil
+}
+
+func (c *shadowDBClient) stored(id string) (models.DeviceShadow, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shadow, ok := c.shadows[id]
+	return shadow, ok
+}
+
+func reportTemp(p deviceApp, deviceId string, value string) {
+	msg := dtos.ThingModelMessage{
+		Cid:    deviceId,
+		OpType: int32(thingmodel.OperationType_PROPERTY_REPORT),
+		Data:   `{"data":{"temp":{"value":` + value + `,"time":1000}}}`,
+	}
+	p.DeviceShadowReport(context.Background(), msg, &dtos.DeviceThingModel{Device: models.Device{Id: deviceId}})
+}
+
+func TestDeviceShadowWriteBack(t *testing.T) {
+	db := &shadowDBClient{shadows: make(map[string]models.DeviceShadow)}
+	p := deviceApp{dbClient: db, lc: logger.NewMockClient(), shadow: newShadowState()}
+
+	// 上报只更新内存，写回时每个设备只写入最新的影子
+	reportTemp(p, "d1", "1")
+	reportTemp(p, "d1", "2")
+	reportTemp(p, "d2", "3")
+	_, ok := db.stored("d1")
+	assert.False(t, ok)
+	response, err := p.DeviceShadowById(context.Background(), "d1")
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), response.Reported["temp"].Value)
+
+	p.flushDeviceShadows()
+	assert.Equal(t, 1, db.batches)
+	shadow, ok := db.stored("d1")
+	require.True(t, ok)
+	assert.Equal(t, float64(2), shadow.Reported["temp"].Value)
+	p.flushDeviceShadows()
+	assert.Equal(t, 1, db.batches)
+
+	// 期望值修改立即写入，同时写入尚未写回的 reported
+	reportTemp(p, "d1", "4")
+	_, err = p.DeviceShadowUpdateDesired(context.Background(), dtos.DeviceShadowDesiredRequest{
+		DeviceId: "d1",
+		Desired:  map[string]interface{}{"temp": float64(5)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, db.upserts)
+	shadow, _ = db.stored("d1")
+	assert.Equal(t, float64(4), shadow.Reported["temp"].Value)
+	assert.Equal(t, float64(5), shadow.Desired["temp"].Value)
+	assert.Equal(t, int64(1), shadow.Version)
+	p.flushDeviceShadows()
+	assert.Equal(t, 1, db.batches)
+
+	// 写回失败时下次重试
+	reportTemp(p, "d2", "6")
+	db.fail = true
+	p.flushDeviceShadows()
+	db.fail = false
+	p.flushDeviceShadows()
+	shadow, _ = db.stored("d2")
+	assert.Equal(t, float64(6), shadow.Reported["temp"].Value)
+
+	// 已删除的影子不会被写回恢复
+	reportTemp(p, "d2", "7")
+	p.deleteDeviceShadow("d2")
+	p.flushDeviceShadows()
+	_, ok = db.stored("d2")
+	assert.False(t, ok)
+}
+
+func TestDeviceShadowRunFlushOnExit(t *testing.T) {
+	db := &shadowDBClient{shadows: make(map[string]models.DeviceShadow)}
+	p := deviceApp{dbClient: db, lc: logger.NewMockClient(), shadow: newShadowState()}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	p.Run(ctx, &wg)
+	reportTemp(p, "d1", "1")
+	cancel()
+	wg.Wait()
+	shadow, ok := db.stored("d1")
+	require.True(t, ok)
+	assert.Equal(t, float64(1), shadow.Reported["temp"].Value)
+}
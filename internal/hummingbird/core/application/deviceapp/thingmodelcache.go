@@ -0,0 +1,79 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"sync"
+)
+
+// productCache 上报消息处理使用的产品物模型缓存，产品或物模型修改、删除后失效
+type productCache struct {
+	mu       sync.RWMutex
+	products map[string]models.Product
+	// version 每次失效加一，查询期间发生失效时查询结果不写入缓存，避免缓存修改前的物模型
+	version uint64
+}
+
+func newProductCache() *productCache {
+	return &productCache{products: make(map[string]models.Product)}
+}
+
+func (c *productCache) get(productId string, load func(string) (models.Product, error)) (models.Product, error) {
+	c.mu.RLock()
+	product, ok := c.products[productId]
+	version := c.version
+	c.mu.RUnlock()
+	if ok {
+		return product, nil
+	}
+	product, err := load(productId)
+	if err != nil {
+		return product, err
+	}
+	c.mu.Lock()
+	if c.version == version {
+		c.products[productId] = product
+	}
+	c.mu.Unlock()
+	return product, nil
+}
+
+func (c *productCache) invalidate(productId string) {
+	c.mu.Lock()
+	delete(c.products, productId)
+	c.version++
+	c.mu.Unlock()
+}
+
+// DeviceThingModel 查询设备及其产品物模型，产品从缓存读取
+func (p deviceApp) DeviceThingModel(ctx context.Context, deviceId string) (dtos.DeviceThingModel, error) {
+	device, err := p.dbClient.DeviceById(deviceId)
+	if err != nil {
+		return dtos.DeviceThingModel{}, err
+	}
+	product, err := p.products.get(device.ProductId, p.dbClient.ProductById)
+	if err != nil {
+		return dtos.DeviceThingModel{}, err
+	}
+	return dtos.DeviceThingModel{Device: device, Product: product}, nil
+}
+
+// ProductThingModelChanged 产品或物模型修改、删除后调用，使缓存的产品物模型失效
+func (p deviceApp) ProductThingModelChanged(productId string) {
+	p.products.invalidate(productId)
+}
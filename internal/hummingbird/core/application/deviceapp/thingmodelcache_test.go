@@ -0,0 +1,41 @@
+package deviceapp
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/models"
+)
+
+func TestProductCache(t *testing.T) {
+	cache := newProductCache()
+	var loads int
+	load := func(id string) (models.Product, error) {
+		loads++
+		return models.Product{Id: id, Name: "v" + strconv.Itoa(loads)}, nil
+	}
+
+	product, err := cache.get("p1", load)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", product.Name)
+	product, _ = cache.get("p1", load)
+	assert.Equal(t, "v1", product.Name)
+	assert.Equal(t, 1, loads)
+
+	cache.invalidate("p1")
+	product, _ = cache.get("p1", load)
+	assert.Equal(t, "v2", product.Name)
+	assert.Equal(t, 2, loads)
+
+	// 查询期间产品被修改，查询到的旧物模型不写入缓存
+	cache.invalidate("p1")
+	product, _ = cache.get("p1", func(id string) (models.Product, error) {
+		cache.invalidate(id)
+		return models.Product{Id: id, Name: "stale"}, nil
+	})
+	assert.Equal(t, "stale", product.Name)
+	product, _ = cache.get("p1", load)
+	assert.Equal(t, "v3", product.Name)
+}
@@ -0,0 +1,226 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"strings"
+)
+
+// thingModelInvalid 一个未通过物模型校验的属性、事件或事件参数
+type thingModelInvalid struct {
+	code   string
+	reason string
+}
+
+// thingModelValidator 按产品物模型校验一条上报消息，drop 为 true 时不合法的字段从消息中删除
+type thingModelValidator struct {
+	properties map[string]models.Properties
+	events     map[string]models.Events
+	drop       bool
+	invalids   []thingModelInvalid
+}
+
+func newThingModelValidator(product models.Product) *thingModelValidator {
+	v := &thingModelValidator{
+		properties: make(map[string]models.Properties, len(product.Properties)),
+		events:     make(map[string]models.Events, len(product.Events)),
+		drop:       product.ValidateMode == constants.ThingModelValidateModeDrop,
+	}
+	for _, property := range product.Properties {
+		v.properties[property.Code] = property
+	}
+	for _, event := range product.Events {
+		v.events[event.Code] = event
+	}
+	return v
+}
+
+func (v *thingModelValidator) invalid(code string, reason string) {
+	v.invalids = append(v.invalids, thingModelInvalid{code: code, reason: reason})
+}
+
+// property 校验单个属性值，返回该属性是否保留
+func (v *thingModelValidator) property(code string, value interface{}) bool {
+	property, ok := v.properties[code]
+	if !ok {
+		v.invalid(code, "property is not defined")
+		return !v.drop
+	}
+	if err := property.TypeSpec.Validate(value); err != nil {
+		v.invalid(code, err.Error())
+		return !v.drop
+	}
+	return true
+}
+
+// event 校验事件及其输出参数，drop 模式下删除不合法的参数，返回该事件是否保留
+func (v *thingModelValidator) event(code string, params map[string]interface{}) bool {
+	event, ok := v.events[code]
+	if !ok {
+		v.invalid(code, "event is not defined")
+		return !v.drop
+	}
+	for paramCode, value := range params {
+		var spec *models.InputOutput
+		for i := range event.OutputParams {
+			if event.OutputParams[i].Code == paramCode {
+				spec = &event.OutputParams[i]
+				break
+			}
+		}
+		var err error
+		if spec == nil {
+			err = fmt.Errorf("output param is not defined")
+		} else {
+			err = spec.TypeSpec.Validate(value)
+		}
+		if err == nil {
+			continue
+		}
+		v.invalid(code+"."+paramCode, err.Error())
+		if v.drop {
+			delete(params, paramCode)
+		}
+	}
+	return true
+}
+
+// DeviceThingModelValidate 按产品物模型校验设备上报的属性和事件，返回校验处理后的消息。
+// keep 为 false 表示 drop 模式下消息中的数据已全部丢弃，无需继续处理；
+// reject 模式下存在不合法数据时返回错误，整条消息不处理；flag 模式只记录不合法次数。
+// thingModel 为 nil 表示设备或产品查询失败，不做校验，保持原有的处理流程。
+func (p deviceApp) DeviceThingModelValidate(ctx context.Context, msg dtos.ThingModelMessage, thingModel *dtos.DeviceThingModel) (dtos.ThingModelMessage, bool, error) {
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT, thingmodel.OperationType_EVENT_REPORT, thingmodel.OperationType_DATA_BATCH_REPORT:
+	default:
+		return msg, true, nil
+	}
+	if thingModel == nil {
+		return msg, true, nil
+	}
+	device, product := thingModel.Device, thingModel.Product
+	validator := newThingModelValidator(product)
+	keep := true
+	var data interface{}
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT:
+		report, err := msg.TransformMessageDataByProperty()
+		if err != nil {
+			return msg, true, nil
+		}
+		for code, value := range report.Data {
+			if !validator.property(code, value.Value) {
+				delete(report.Data, code)
+			}
+		}
+		keep = len(report.Data) > 0
+		data = report
+	case thingmodel.OperationType_EVENT_REPORT:
+		report, err := msg.TransformMessageDataByEvent()
+		if err != nil {
+			return msg, true, nil
+		}
+		keep = validator.event(report.Data.EventCode, report.Data.OutputParams)
+		data = report
+	case thingmodel.OperationType_DATA_BATCH_REPORT:
+		report, err := msg.TransformMessageDataByBatchReport()
+		if err != nil {
+			return msg, true, nil
+		}
+		for code, value := range report.Data.Properties {
+			if !validator.property(code, value.Value) {
+				delete(report.Data.Properties, code)
+			}
+		}
+		for code, value := range report.Data.Events {
+			if !validator.event(code, value.OutputParams) {
+				delete(report.Data.Events, code)
+			}
+		}
+		keep = len(report.Data.Properties) > 0 || len(report.Data.Events) > 0
+		data = report
+	}
+	if len(validator.invalids) == 0 {
+		return msg, true, nil
+	}
+
+	reasons := make([]string, 0, len(validator.invalids))
+	for _, invalid := range validator.invalids {
+		reasons = append(reasons, invalid.code+": "+invalid.reason)
+	}
+	p.lc.Warnf("device %s report data does not match thing model(%s): %s", msg.Cid, product.ValidateMode, strings.Join(reasons, "; "))
+	p.recordInvalidSamples(device, validator.invalids)
+
+	switch product.ValidateMode {
+	case constants.ThingModelValidateModeReject:
+		return msg, false, errort.NewCommonEdgeX(errort.DeviceThingModelDataInvalid, strings.Join(reasons, "; "), nil)
+	case constants.ThingModelValidateModeDrop:
+		b, err := json.Marshal(data)
+		if err != nil {
+			return msg, false, err
+		}
+		msg.Data = string(b)
+		return msg, keep, nil
+	default:
+		return msg, true, nil
+	}
+}
+
+func (p deviceApp) recordInvalidSamples(device models.Device, invalids []thingModelInvalid) {
+	last := invalids[len(invalids)-1]
+	err := p.dbClient.IncrDeviceInvalidSample(models.DeviceInvalidSample{
+		Id:           device.Id,
+		ProductId:    device.ProductId,
+		InvalidCount: int64(len(invalids)),
+		LastCode:     last.code,
+		LastReason:   last.reason,
+		LastTime:     utils.MakeTimestamp(),
+	})
+	if err != nil {
+		p.lc.Errorf("record device %s invalid samples err %v", device.Id, err)
+	}
+}
+
+// DeviceInvalidSample 查询设备上报数据未通过物模型校验的统计
+func (p deviceApp) DeviceInvalidSample(ctx context.Context, deviceId string) (dtos.DeviceInvalidSampleResponse, error) {
+	if _, err := p.dbClient.DeviceById(deviceId); err != nil {
+		return dtos.DeviceInvalidSampleResponse{}, err
+	}
+	sample, err := p.dbClient.DeviceInvalidSampleById(deviceId)
+	if err != nil {
+		if errort.Is(errort.DefaultResourcesNotFound, err) {
+			return dtos.DeviceInvalidSampleResponse{DeviceId: deviceId}, nil
+		}
+		return dtos.DeviceInvalidSampleResponse{}, err
+	}
+	return dtos.DeviceInvalidSampleResponseFromModel(sample), nil
+}
+
+// DeviceInvalidSampleReset 清零设备的不合法数据统计
+func (p deviceApp) DeviceInvalidSampleReset(ctx context.Context, deviceId string) error {
+	if _, err := p.dbClient.DeviceById(deviceId); err != nil {
+		return err
+	}
+	return p.dbClient.DeleteDeviceInvalidSamplesByDeviceIds([]string{deviceId})
+}
@@ -0,0 +1,226 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package deviceapp
+
+import (
+	"context"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+)
+
+// checkGatewayDevice 校验设备是否为网关
+func (p deviceApp) checkGatewayDevice(parentId string) (models.Device, error) {
+	parent, err := p.dbClient.DeviceById(parentId)
+	if err != nil {
+		return parent, err
+	}
+	if parent.Product.NodeType != constants.ProductNodeTypeGateway {
+		return parent, errort.NewCommonErr(errort.DeviceNotGateway, fmt.Errorf("device id(%s) is not gateway", parentId))
+	}
+	return parent, nil
+}
+
+// checkSubDevice 校验设备能否添加到网关下
+func checkSubDevice(parent models.Device, device models.Device) error {
+	if device.Product.NodeType != constants.ProductNodeTypeSubDevice {
+		return errort.NewCommonErr(errort.DeviceNotSubDevice, fmt.Errorf("device id(%s) is not sub device", device.Id))
+	}
+	if device.ParentId != "" && device.ParentId != parent.Id {
+		return errort.NewCommonErr(errort.DeviceAlreadyHasParent, fmt.Errorf("device id(%s) already belongs to gateway(%s)", device.Id, device.ParentId))
+	}
+	return nil
+}
+
+// checkDriverDevices 驱动只能管理属于该驱动实例的设备，driverInstanceId 为空时不校验
+func checkDriverDevices(driverInstanceId string, devices ...models.Device) error {
+	if driverInstanceId == "" {
+		return nil
+	}
+	for _, device := range devices {
+		if device.DriveInstanceId != driverInstanceId {
+			return errort.NewCommonErr(errort.DeviceNotBelongDriver, fmt.Errorf("device id(%s) does not belong to driver instance(%s)", device.Id, driverInstanceId))
+		}
+	}
+	return nil
+}
+
+// DeviceSubDevicesAdd 添加网关子设备
+func (p *deviceApp) DeviceSubDevicesAdd(ctx context.Context, req dtos.DeviceTopoRequest) error {
+	parent, err := p.checkGatewayDevice(req.ParentId)
+	if err != nil {
+		return err
+	}
+	devices, err := p.topoDevices(req.DeviceIds)
+	if err != nil {
+		return err
+	}
+	if err = checkDriverDevices(req.DriverInstanceId, append(devices, parent)...); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if err = checkSubDevice(parent, device); err != nil {
+			return err
+		}
+	}
+	if err = p.dbClient.BatchUpdateDeviceParentId(req.DeviceIds, parent.Id); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		updateDevice := device
+		updateDevice.ParentId = parent.Id
+		go func() {
+			p.UpdateDeviceCallBack(updateDevice)
+		}()
+	}
+	return nil
+}
+
+// DeviceSubDevicesRemove 从网关移除子设备，不在该网关下的设备忽略
+func (p *deviceApp) DeviceSubDevicesRemove(ctx context.Context, req dtos.DeviceTopoRequest) error {
+	parent, err := p.dbClient.DeviceById(req.ParentId)
+	if err != nil {
+		return err
+	}
+	devices, err := p.topoDevices(req.DeviceIds)
+	if err != nil {
+		return err
+	}
+	if err = checkDriverDevices(req.DriverInstanceId, append(devices, parent)...); err != nil {
+		return err
+	}
+	var ids []string
+	for _, device := range devices {
+		if device.ParentId == req.ParentId {
+			ids = append(ids, device.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err = p.dbClient.BatchUpdateDeviceParentId(ids, ""); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if device.ParentId != req.ParentId {
+			continue
+		}
+		updateDevice := device
+		updateDevice.ParentId = ""
+		go func() {
+			p.UpdateDeviceCallBack(updateDevice)
+		}()
+	}
+	return nil
+}
+
+func (p deviceApp) topoDevices(ids []string) ([]models.Device, error) {
+	if len(ids) == 0 {
+		return nil, errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("device ids is empty"))
+	}
+	var req dtos.DeviceSearchQueryRequest
+	req.BaseSearchConditionQuery.Ids = dtos.ApiParamsArrayToString(ids)
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) != len(ids) {
+		return nil, errort.NewCommonErr(errort.DeviceNotExist, fmt.Errorf("some devices not found"))
+	}
+	return devices, nil
+}
+
+// subDevices 查询网关下的所有子设备
+func (p deviceApp) subDevices(parentIds ...string) ([]models.Device, error) {
+	if len(parentIds) == 0 {
+		return nil, nil
+	}
+	var req dtos.DeviceSearchQueryRequest
+	req.ParentIds = parentIds
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, req)
+	return devices, err
+}
+
+// checkDevicesDeletable 网关下存在子设备时不允许删除，同时删除网关及其全部子设备除外
+func (p deviceApp) checkDevicesDeletable(ids []string) error {
+	children, err := p.subDevices(ids...)
+	if err != nil {
+		return err
+	}
+	deleting := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		deleting[id] = struct{}{}
+	}
+	for _, child := range children {
+		if _, ok := deleting[child.Id]; !ok {
+			return errort.NewCommonErr(errort.DeviceHasSubDevices, fmt.Errorf("gateway(%s) has sub device(%s)", child.ParentId, child.Id))
+		}
+	}
+	return nil
+}
+
+// subDevicesOffline 网关离线后，其下在线的子设备同步置为离线，子设备再次上报数据时恢复在线
+func (p deviceApp) subDevicesOffline(ctx context.Context, parentId string) {
+	children, err := p.subDevices(parentId)
+	if err != nil {
+		p.lc.Errorf("gateway %s query sub devices err %v", parentId, err)
+		return
+	}
+	messageApp := container.MessageItfFrom(p.dic.Get)
+	for _, child := range children {
+		if child.Status != constants.DeviceStatusOnline {
+			continue
+		}
+		if err = p.dbClient.DeviceOfflineById(child.Id); err != nil {
+			p.lc.Errorf("gateway %s sub device %s offline err %v", parentId, child.Id, err)
+			continue
+		}
+		p.recordDeviceConnect(child.Id, child.ProductId, constants.DeviceOffline, constants.DeviceConnectReasonGatewayOffline)
+		p.keepAlive.lastActive.Delete(child.Id)
+		p.keepAlive.expired.Store(child.Id, struct{}{})
+		messageApp.DeviceStatusToMessageBus(ctx, child.Id, constants.DeviceOffline)
+	}
+}
+
+// subDevicesTree 为顶层设备填充子设备
+func (p deviceApp) subDevicesTree(devices []dtos.DeviceSearchQueryResponse) error {
+	var parentIds []string
+	index := make(map[string]int)
+	for i, device := range devices {
+		if device.NodeType == string(constants.ProductNodeTypeGateway) {
+			parentIds = append(parentIds, device.Id)
+			index[device.Id] = i
+		}
+	}
+	if len(parentIds) == 0 {
+		return nil
+	}
+	children, err := p.subDevices(parentIds...)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		i, ok := index[child.ParentId]
+		if !ok {
+			continue
+		}
+		deviceService, _ := p.dbClient.DeviceServiceById(child.DriveInstanceId)
+		devices[i].Children = append(devices[i].Children, dtos.DeviceResponseFromModel(child, deviceService.Name))
+	}
+	return nil
+}
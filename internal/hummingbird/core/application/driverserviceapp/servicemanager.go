@@ -154,6 +154,9 @@ func (m *driverServiceAppM) Stop(id string) error {
 		return errort.NewCommonErr(errort.ContainerStopFail, pkgerr.WithMessage(stopErr, "stop driverService fail"))
 	}
 	m.SetState(id, constants.RunStatusStopped)
+	if err = container.DeviceItfFrom(m.dic.Get).DevicesOfflineByDriveInstanceId(context.Background(), id); err != nil {
+		m.lc.Errorf("driver instance %s stopped, offline devices err %v", id, err)
+	}
 	return nil
 }
 
@@ -95,7 +95,9 @@ func (dsm *DeviceServiceMonitor) CheckServiceAvailable() {
 		}
 	}
 	if dsm.isRunning && !isRunning {
-		//OfflineDevicesByServiceId(ctx, dsm.dic, dsm.ds.Id)
+		if err = resourceContainer.DeviceItfFrom(dsm.dic.Get).DevicesOfflineByDriveInstanceId(ctx, dsm.ds.Id); err != nil {
+			dsm.lc.Errorf("driver instance %s stopped, offline devices err %v", dsm.ds.Id, err)
+		}
 	}
 	dsm.isRunning = isRunning
 }
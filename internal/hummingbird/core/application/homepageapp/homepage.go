@@ -26,6 +26,8 @@ import (
 
 const (
 	HummingbridDoc = "https://doc.hummingbird.winc-link.com/"
+	// 首页展示上下线最频繁的设备数
+	flapDevicesTop = 10
 )
 
 type homePageApp struct {
@@ -146,6 +148,13 @@ func (h homePageApp) HomePageInfo(ctx context.Context, req dtos.HomePageRequest)
 		Date:  time.Now().AddDate(0, 0, -6).Format("2006-01-02"),
 		Count: getMsgGatherCountByDate(msgGather, time.Now().AddDate(0, 0, -6).Format("2006-01-02")),
 	})
+
+	deviceItf := resourceContainer.DeviceItfFrom(h.dic.Get)
+	flapDevices, err := deviceItf.DeviceFlapTop(ctx, time.Now().Add(-24*time.Hour).UnixMilli(), flapDevicesTop)
+	if err != nil {
+		h.lc.Errorf("home page query flap devices err %v", err)
+	}
+	responseResponse.FlapDevices = flapDevices
 	return responseResponse, nil
 }
 
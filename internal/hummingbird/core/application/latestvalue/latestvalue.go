@@ -0,0 +1,239 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package latestvalue
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/pkg/container"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+const defaultSnapshotInterval = time.Minute
+
+type snapshot struct {
+	Time    int64                                 `json:"time"`
+	Devices map[string]map[string]dtos.ReportData `json:"devices"`
+}
+
+// LatestValueCache 按设备和属性标识符保存最新值，定期保存快照并在启动时加载
+type LatestValueCache struct {
+	lc     logger.LoggingClient
+	mutex  sync.RWMutex
+	values map[string]map[string]dtos.ReportData
+	// version 每次修改加一，与 saved 不同时需要保存快照
+	version uint64
+	// saveMutex 保证快照按顺序写入，saved 只在持有 saveMutex 时访问
+	saveMutex sync.Mutex
+	saved     uint64
+	path      string
+	interval  time.Duration
+}
+
+func NewLatestValueCache(dic *di.Container) *LatestValueCache {
+	lc := container.LoggingClientFrom(dic.Get)
+	config := resourceContainer.ConfigurationFrom(dic.Get).LatestValue
+	c := newLatestValueCache(lc, config.SnapshotPath, time.Duration(config.SnapshotInterval)*time.Second)
+	if err := c.load(); err != nil {
+		lc.Errorf("load latest value snapshot %s err: %v", c.path, err)
+	}
+	return c
+}
+
+func newLatestValueCache(lc logger.LoggingClient, path string, interval time.Duration) *LatestValueCache {
+	if interval <= 0 {
+		interval = defaultSnapshotInterval
+	}
+	return &LatestValueCache{
+		lc:       lc,
+		values:   make(map[string]map[string]dtos.ReportData),
+		path:     path,
+		interval: interval,
+	}
+}
+
+func (c *LatestValueCache) Update(msg dtos.ThingModelMessage) {
+	values := make(map[string]dtos.ReportData)
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT:
+		propertyMsg, err := msg.TransformMessageDataByProperty()
+		if err != nil {
+			return
+		}
+		for code, data := range propertyMsg.Data {
+			values[code] = data
+		}
+	case thingmodel.OperationType_DATA_BATCH_REPORT:
+		batchMsg, err := msg.TransformMessageDataByBatchReport()
+		if err != nil {
+			return
+		}
+		for code, property := range batchMsg.Data.Properties {
+			values[code] = dtos.ReportData{Value: property.Value, Time: batchMsg.Time}
+		}
+	case thingmodel.OperationType_PROPERTY_GET_RESPONSE:
+		getMsg, err := msg.TransformMessageDataByGetProperty()
+		if err != nil {
+			return
+		}
+		for _, data := range getMsg.Data {
+			values[data.Code] = dtos.ReportData{Value: data.Value, Time: data.Time}
+		}
+	default:
+		return
+	}
+	c.Set(msg.Cid, values)
+}
+
+func (c *LatestValueCache) Set(deviceId string, values map[string]dtos.ReportData) {
+	if deviceId == "" || len(values) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device, ok := c.values[deviceId]
+	if !ok {
+		device = make(map[string]dtos.ReportData, len(values))
+		c.values[deviceId] = device
+	}
+	for code, data := range values {
+		if data.Time <= 0 {
+			data.Time = time.Now().UnixMilli()
+		}
+		if old, ok := device[code]; ok && old.Time > data.Time {
+			continue
+		}
+		device[code] = data
+		c.version++
+	}
+}
+
+func (c *LatestValueCache) Get(deviceId, code string) (dtos.ReportData, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	data, ok := c.values[deviceId][code]
+	return data, ok
+}
+
+func (c *LatestValueCache) DeleteDevice(deviceId string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.values[deviceId]; ok {
+		delete(c.values, deviceId)
+		c.version++
+	}
+}
+
+// Run 定期保存快照，退出时保存最后一次
+func (c *LatestValueCache) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if c.path == "" {
+		return
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.save(); err != nil {
+					c.lc.Errorf("save latest value snapshot %s err: %v", c.path, err)
+				}
+			case <-ctx.Done():
+				if err := c.save(); err != nil {
+					c.lc.Errorf("save latest value snapshot %s err: %v", c.path, err)
+				}
+				return
+			}
+		}
+	}()
+}
+
+func (c *LatestValueCache) load() error {
+	if c.path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var s snapshot
+	if err = json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for deviceId, values := range s.Devices {
+		c.Set(deviceId, values)
+	}
+	c.saveMutex.Lock()
+	c.mutex.RLock()
+	c.saved = c.version
+	c.mutex.RUnlock()
+	c.saveMutex.Unlock()
+	c.lc.Infof("load latest value snapshot of %d devices", len(s.Devices))
+	return nil
+}
+
+// save 没有变化时不写文件。读锁内只复制数据，序列化和写文件不阻塞上报数据的更新；
+// 先写入临时文件再重命名，避免写入中途退出损坏快照
+func (c *LatestValueCache) save() error {
+	c.saveMutex.Lock()
+	defer c.saveMutex.Unlock()
+
+	c.mutex.RLock()
+	version := c.version
+	if version == c.saved {
+		c.mutex.RUnlock()
+		return nil
+	}
+	devices := make(map[string]map[string]dtos.ReportData, len(c.values))
+	for deviceId, values := range c.values {
+		device := make(map[string]dtos.ReportData, len(values))
+		for code, data := range values {
+			device[code] = data
+		}
+		devices[deviceId] = device
+	}
+	c.mutex.RUnlock()
+
+	b, err := json.Marshal(snapshot{Time: time.Now().UnixMilli(), Devices: devices})
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(c.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	c.saved = version
+	return nil
+}
@@ -0,0 +1,130 @@
+package latestvalue
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+func propertyReport(t *testing.T, deviceId string, data map[string]dtos.ReportData) dtos.ThingModelMessage {
+	b, err := json.Marshal(dtos.DevicePropertyReport{Data: data})
+	require.NoError(t, err)
+	return dtos.ThingModelMessage{Cid: deviceId, OpType: int32(thingmodel.OperationType_PROPERTY_REPORT), Data: string(b)}
+}
+
+func TestLatestValueOrdering(t *testing.T) {
+	c := newLatestValueCache(logger.NewMockClient(), "", 0)
+
+	c.Update(propertyReport(t, "device1", map[string]dtos.ReportData{
+		"temperature": {Value: 20.5, Time: 2000},
+		"humidity":    {Value: 60.0, Time: 2000},
+	}))
+	// 乱序到达的旧数据不覆盖新数据，同一消息中较新的属性仍然更新
+	c.Update(propertyReport(t, "device1", map[string]dtos.ReportData{
+		"temperature": {Value: 18.0, Time: 1000},
+		"humidity":    {Value: 65.0, Time: 3000},
+	}))
+	data, ok := c.Get("device1", "temperature")
+	require.True(t, ok)
+	assert.Equal(t, dtos.ReportData{Value: 20.5, Time: 2000}, data)
+	data, ok = c.Get("device1", "humidity")
+	require.True(t, ok)
+	assert.Equal(t, 65.0, data.Value)
+
+	// 时间相同时后到的数据覆盖，没有时间的数据使用当前时间
+	c.Set("device1", map[string]dtos.ReportData{"temperature": {Value: 21.0, Time: 2000}})
+	data, _ = c.Get("device1", "temperature")
+	assert.Equal(t, 21.0, data.Value)
+	before := time.Now().UnixMilli()
+	c.Set("device1", map[string]dtos.ReportData{"switch": {Value: true}})
+	data, _ = c.Get("device1", "switch")
+	assert.GreaterOrEqual(t, data.Time, before)
+
+	// 与属性无关的消息不更新缓存
+	c.Update(dtos.ThingModelMessage{Cid: "device2", OpType: int32(thingmodel.OperationType_EVENT_REPORT), Data: "{}"})
+	_, ok = c.Get("device2", "temperature")
+	assert.False(t, ok)
+}
+
+func TestLatestValueDeleteDevice(t *testing.T) {
+	c := newLatestValueCache(logger.NewMockClient(), "", 0)
+	c.Set("device1", map[string]dtos.ReportData{"temperature": {Value: 20.0, Time: 1000}})
+	c.Set("device2", map[string]dtos.ReportData{"temperature": {Value: 30.0, Time: 1000}})
+
+	version := c.version
+	c.DeleteDevice("device1")
+	_, ok := c.Get("device1", "temperature")
+	assert.False(t, ok)
+	_, ok = c.Get("device2", "temperature")
+	assert.True(t, ok)
+	assert.Equal(t, version+1, c.version)
+
+	// 删除不存在的设备不需要保存快照
+	c.DeleteDevice("device3")
+	assert.Equal(t, version+1, c.version)
+}
+
+func TestLatestValueSnapshot(t *testing.T) {
+	path := t.TempDir() + "/snapshot/latest.json"
+	c := newLatestValueCache(logger.NewMockClient(), path, time.Second)
+	c.Set("device1", map[string]dtos.ReportData{"temperature": {Value: 20.0, Time: 1000}})
+	c.Set("device2", map[string]dtos.ReportData{"temperature": {Value: 30.0, Time: 1000}})
+	c.DeleteDevice("device2")
+	require.NoError(t, c.save())
+
+	// 没有变化时不重写快照
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, c.save())
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	c.Set("device1", map[string]dtos.ReportData{"humidity": {Value: 60.0, Time: 2000}})
+	require.NoError(t, c.save())
+
+	restored := newLatestValueCache(logger.NewMockClient(), path, time.Second)
+	require.NoError(t, restored.load())
+	data, ok := restored.Get("device1", "temperature")
+	require.True(t, ok)
+	assert.Equal(t, dtos.ReportData{Value: 20.0, Time: 1000}, data)
+	data, ok = restored.Get("device1", "humidity")
+	require.True(t, ok)
+	assert.Equal(t, 60.0, data.Value)
+	_, ok = restored.Get("device2", "temperature")
+	assert.False(t, ok)
+	// 加载后没有新的修改，不需要保存
+	assert.Equal(t, restored.version, restored.saved)
+
+	// 快照之后的修改与快照中的旧数据按时间比较
+	restored.Set("device1", map[string]dtos.ReportData{"temperature": {Value: 19.0, Time: 500}})
+	data, _ = restored.Get("device1", "temperature")
+	assert.Equal(t, 20.0, data.Value)
+}
+
+func TestLatestValueSaveConcurrentUpdate(t *testing.T) {
+	path := t.TempDir() + "/latest.json"
+	c := newLatestValueCache(logger.NewMockClient(), path, time.Second)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := int64(1); i <= 1000; i++ {
+			c.Set("device1", map[string]dtos.ReportData{"counter": {Value: float64(i), Time: i}})
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.save())
+	}
+	<-done
+	require.NoError(t, c.save())
+
+	restored := newLatestValueCache(logger.NewMockClient(), path, time.Second)
+	require.NoError(t, restored.load())
+	data, ok := restored.Get("device1", "counter")
+	require.True(t, ok)
+	assert.Equal(t, 1000.0, data.Value)
+}
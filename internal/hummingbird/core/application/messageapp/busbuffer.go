@@ -0,0 +1,232 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package messageapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/config"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+const (
+	defaultBusBatchSize = 100
+	// busRetryInterval 推送失败或未连接时重试及检查过期消息的间隔
+	busRetryInterval = time.Second
+	// busAckTimeout 等待 broker 确认的超时时间
+	busAckTimeout = 10 * time.Second
+)
+
+var errBusAckTimeout = errors.New("wait for broker ack timeout")
+
+// busPublisher 消息总线的 mqtt 客户端
+type busPublisher interface {
+	GetConnectStatus() bool
+	Publish(topic string, payload []byte) mqtt.Token
+}
+
+// busBuffer 推送到消息总线的消息先写入磁盘队列，由单独的协程按顺序推送，broker 确认后从队列删除。
+// broker 不可用时消息保留在队列中，恢复连接后按进入队列的顺序推送。
+// 一批消息中某条推送失败时，之后已推送的消息会在重试时重复推送，可能先于失败的消息到达 broker
+type busBuffer struct {
+	lc        logger.LoggingClient
+	queue     *busQueue
+	client    busPublisher
+	topic     string
+	batchSize int
+	maxAge    time.Duration
+	notify    chan struct{}
+
+	enqueued       int64
+	published      int64
+	failedPublish  int64
+	droppedFull    int64
+	droppedExpired int64
+	droppedError   int64
+}
+
+func newBusBuffer(lc logger.LoggingClient, cfg config.MessageBufferInfo, topic string) (*busBuffer, error) {
+	dropPolicy := constants.MessageBufferDropPolicy(cfg.DropPolicy)
+	if dropPolicy != constants.MessageBufferDropNewest {
+		dropPolicy = constants.MessageBufferDropOldest
+	}
+	queue, err := openBusQueue(cfg.Path, cfg.MaxMessages, int64(cfg.MaxSize)<<20, dropPolicy)
+	if err != nil {
+		return nil, err
+	}
+	b := &busBuffer{
+		lc:        lc,
+		queue:     queue,
+		topic:     topic,
+		batchSize: cfg.BatchSize,
+		maxAge:    time.Duration(cfg.MaxAge) * time.Second,
+		notify:    make(chan struct{}, 1),
+	}
+	if b.batchSize <= 0 {
+		b.batchSize = defaultBusBatchSize
+	}
+	if queue.count > 0 {
+		lc.Infof("message buffer has %d messages to publish", queue.count)
+	}
+	return b, nil
+}
+
+// Run 启动推送协程，退出时关闭队列，未推送的消息在下次启动后继续推送
+func (b *busBuffer) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.forwardLoop(ctx)
+		if err := b.queue.close(); err != nil {
+			b.lc.Errorf("message buffer close err: %v", err)
+		}
+	}()
+}
+
+// wake 有新消息或 broker 重新连接后立即推送
+func (b *busBuffer) wake() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (b *busBuffer) push(payload []byte) {
+	dropped, err := b.queue.push(time.Now().UnixMilli(), payload)
+	if dropped > 0 {
+		atomic.AddInt64(&b.droppedFull, int64(dropped))
+		b.lc.Debugf("message buffer is full, drop %d oldest messages", dropped)
+	}
+	switch {
+	case err == errBusQueueFull:
+		atomic.AddInt64(&b.droppedFull, 1)
+		b.lc.Debugf("message buffer is full, drop new message")
+		return
+	case err != nil:
+		atomic.AddInt64(&b.droppedError, 1)
+		b.lc.Errorf("message buffer push err: %v", err)
+		return
+	}
+	atomic.AddInt64(&b.enqueued, 1)
+	b.wake()
+}
+
+func (b *busBuffer) connected() bool {
+	return b.client != nil && b.client.GetConnectStatus()
+}
+
+func (b *busBuffer) forwardLoop(ctx context.Context) {
+	ticker := time.NewTicker(busRetryInterval)
+	defer ticker.Stop()
+	for {
+		if b.connected() {
+			b.forward(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.notify:
+		case <-ticker.C:
+			b.expire()
+		}
+	}
+}
+
+func (b *busBuffer) expire() {
+	if b.maxAge <= 0 {
+		return
+	}
+	expired, err := b.queue.expire(time.Now().Add(-b.maxAge).UnixMilli())
+	if err != nil {
+		b.lc.Errorf("message buffer expire err: %v", err)
+	}
+	if expired > 0 {
+		atomic.AddInt64(&b.droppedExpired, int64(expired))
+		b.lc.Warnf("message buffer drop %d expired messages", expired)
+	}
+}
+
+// forward 按顺序推送一批消息，不等待确认，全部推送后按顺序等待 broker 确认。
+// 遇到第一条失败的消息时只删除它之前的消息，等待下次从失败的消息开始重试
+func (b *busBuffer) forward(ctx context.Context) {
+	for ctx.Err() == nil && b.connected() {
+		messages, err := b.queue.peek(b.batchSize)
+		if err != nil {
+			b.lc.Errorf("message buffer read err: %v", err)
+			return
+		}
+		if len(messages) == 0 {
+			return
+		}
+		tokens := make([]mqtt.Token, 0, len(messages))
+		for _, m := range messages {
+			tokens = append(tokens, b.client.Publish(b.topic, m.payload))
+			if !b.connected() {
+				break
+			}
+		}
+		var acked int
+		for _, token := range tokens {
+			if !token.WaitTimeout(busAckTimeout) {
+				err = errBusAckTimeout
+			} else {
+				err = token.Error()
+			}
+			if err != nil {
+				break
+			}
+			acked++
+		}
+		if ackErr := b.queue.ack(messages[:acked]); ackErr != nil {
+			b.lc.Errorf("message buffer delete published messages err: %v", ackErr)
+			return
+		}
+		atomic.AddInt64(&b.published, int64(acked))
+		if err != nil {
+			atomic.AddInt64(&b.failedPublish, 1)
+			b.lc.Warnf("message buffer publish err: %v, %d of %d messages acked, retry later", err, acked, len(messages))
+			return
+		}
+	}
+}
+
+func (b *busBuffer) Stats() dtos.MessageBufferStats {
+	depth, size, oldest := b.queue.stats()
+	stats := dtos.MessageBufferStats{
+		Enable:         true,
+		Connected:      b.connected(),
+		Depth:          depth,
+		Size:           size,
+		MaxMessages:    b.queue.maxMessages,
+		MaxSize:        b.queue.maxSize,
+		DropPolicy:     string(b.queue.dropPolicy),
+		OldestTime:     oldest,
+		Enqueued:       atomic.LoadInt64(&b.enqueued),
+		Published:      atomic.LoadInt64(&b.published),
+		FailedPublish:  atomic.LoadInt64(&b.failedPublish),
+		DroppedFull:    atomic.LoadInt64(&b.droppedFull),
+		DroppedExpired: atomic.LoadInt64(&b.droppedExpired),
+		DroppedError:   atomic.LoadInt64(&b.droppedError),
+	}
+	stats.Dropped = stats.DroppedFull + stats.DroppedExpired + stats.DroppedError
+	return stats
+}
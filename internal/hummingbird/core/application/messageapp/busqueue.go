@@ -0,0 +1,256 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package messageapp
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+var (
+	errBusQueueClosed = errors.New("message buffer is closed")
+	errBusQueueFull   = errors.New("message buffer is full")
+)
+
+// busMessage 队列中的消息，value 为 8 字节的进入队列时间(毫秒)加消息内容
+type busMessage struct {
+	seq     uint64
+	time    int64
+	payload []byte
+}
+
+// busQueue 消息总线的磁盘队列，key 为 8 字节大端的递增序号，按 key 的顺序即为进入队列的顺序。
+// 消息只从队首删除：推送确认、过期和队列已满时丢弃最早的消息
+type busQueue struct {
+	db          *leveldb.DB
+	maxMessages int
+	maxSize     int64
+	dropPolicy  constants.MessageBufferDropPolicy
+
+	mutex sync.Mutex
+	// head 队首消息的序号，tail 下一条消息的序号
+	head   uint64
+	tail   uint64
+	count  int
+	size   int64
+	closed bool
+}
+
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+func decodeBusMessage(key, value []byte) (busMessage, bool) {
+	if len(key) != 8 || len(value) < 8 {
+		return busMessage{}, false
+	}
+	return busMessage{
+		seq:  binary.BigEndian.Uint64(key),
+		time: int64(binary.BigEndian.Uint64(value)),
+		// leveldb 迭代器的 value 在下次迭代时会被覆盖
+		payload: append([]byte(nil), value[8:]...),
+	}, true
+}
+
+// openBusQueue 打开队列并统计上次运行留下的消息
+func openBusQueue(dir string, maxMessages int, maxSize int64, dropPolicy constants.MessageBufferDropPolicy) (*busQueue, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		if db, err = leveldb.RecoverFile(dir, nil); err != nil {
+			return nil, err
+		}
+	}
+	q := &busQueue{db: db, maxMessages: maxMessages, maxSize: maxSize, dropPolicy: dropPolicy}
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		if len(iter.Key()) != 8 || len(iter.Value()) < 8 {
+			continue
+		}
+		seq := binary.BigEndian.Uint64(iter.Key())
+		if q.count == 0 {
+			q.head = seq
+		}
+		q.tail = seq + 1
+		q.count++
+		q.size += int64(len(iter.Value()) - 8)
+	}
+	if err = iter.Error(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if q.count == 0 {
+		q.head, q.tail = 0, 0
+	}
+	return q, nil
+}
+
+func (q *busQueue) full(count int, size int64, n int) bool {
+	return (q.maxMessages > 0 && count+1 > q.maxMessages) || (q.maxSize > 0 && size+int64(n) > q.maxSize)
+}
+
+// push 队列已满时按丢弃策略删除最早的消息，返回删除的消息数；丢弃新消息时返回 errBusQueueFull
+func (q *busQueue) push(time int64, payload []byte) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return 0, errBusQueueClosed
+	}
+	var dropped int
+	if q.full(q.count, q.size, len(payload)) {
+		if q.dropPolicy == constants.MessageBufferDropNewest || q.full(0, 0, len(payload)) {
+			return 0, errBusQueueFull
+		}
+		var err error
+		dropped, err = q.removeHead(func(m busMessage, count int, size int64) bool {
+			return !q.full(count, size, len(payload))
+		})
+		if err != nil {
+			return dropped, err
+		}
+	}
+	value := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint64(value, uint64(time))
+	copy(value[8:], payload)
+	if err := q.db.Put(seqKey(q.tail), value, nil); err != nil {
+		return dropped, err
+	}
+	q.tail++
+	q.count++
+	q.size += int64(len(payload))
+	return dropped, nil
+}
+
+// removeHead 从队首删除消息直到 stop 返回 true，stop 的参数为当前消息和删除前的消息数、大小
+func (q *busQueue) removeHead(stop func(m busMessage, count int, size int64) bool) (int, error) {
+	iter := q.db.NewIterator(&util.Range{Start: seqKey(q.head)}, nil)
+	defer iter.Release()
+	batch := new(leveldb.Batch)
+	head, count, size := q.head, q.count, q.size
+	for count > 0 && iter.Next() {
+		m, ok := decodeBusMessage(iter.Key(), iter.Value())
+		if !ok {
+			continue
+		}
+		if stop(m, count, size) {
+			break
+		}
+		batch.Delete(seqKey(m.seq))
+		head = m.seq + 1
+		count--
+		size -= int64(len(m.payload))
+	}
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+	if batch.Len() == 0 {
+		return 0, nil
+	}
+	if err := q.db.Write(batch, nil); err != nil {
+		return 0, err
+	}
+	removed := q.count - count
+	q.head, q.count, q.size = head, count, size
+	return removed, nil
+}
+
+// expire 删除进入队列的时间早于 before 的消息，返回删除的消息数
+func (q *busQueue) expire(before int64) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed || q.count == 0 {
+		return 0, nil
+	}
+	return q.removeHead(func(m busMessage, count int, size int64) bool {
+		return m.time >= before
+	})
+}
+
+// peek 按顺序读取队首的 n 条消息
+func (q *busQueue) peek(n int) ([]busMessage, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed || q.count == 0 {
+		return nil, nil
+	}
+	iter := q.db.NewIterator(&util.Range{Start: seqKey(q.head)}, nil)
+	defer iter.Release()
+	var messages []busMessage
+	for len(messages) < n && iter.Next() {
+		if m, ok := decodeBusMessage(iter.Key(), iter.Value()); ok {
+			messages = append(messages, m)
+		}
+	}
+	return messages, iter.Error()
+}
+
+// ack 删除 peek 读取的消息，读取后已被丢弃的消息跳过
+func (q *busQueue) ack(messages []busMessage) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return errBusQueueClosed
+	}
+	batch := new(leveldb.Batch)
+	head, count, size := q.head, q.count, q.size
+	for _, m := range messages {
+		if m.seq < q.head {
+			continue
+		}
+		batch.Delete(seqKey(m.seq))
+		head = m.seq + 1
+		count--
+		size -= int64(len(m.payload))
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	if err := q.db.Write(batch, nil); err != nil {
+		return err
+	}
+	q.head, q.count, q.size = head, count, size
+	return nil
+}
+
+// stats 返回消息数、大小和队首消息进入队列的时间
+func (q *busQueue) stats() (int, int64, int64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed || q.count == 0 {
+		return q.count, q.size, 0
+	}
+	value, err := q.db.Get(seqKey(q.head), nil)
+	if err != nil || len(value) < 8 {
+		return q.count, q.size, 0
+	}
+	return q.count, q.size, int64(binary.BigEndian.Uint64(value))
+}
+
+func (q *busQueue) close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	return q.db.Close()
+}
@@ -0,0 +1,175 @@
+package messageapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/config"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+func payloads(messages []busMessage) []string {
+	var result []string
+	for _, m := range messages {
+		result = append(result, string(m.payload))
+	}
+	return result
+}
+
+func TestBusQueue(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openBusQueue(dir, 3, 0, constants.MessageBufferDropOldest)
+	require.NoError(t, err)
+	for i := 1; i <= 4; i++ {
+		dropped, err := q.push(int64(i*1000), []byte(fmt.Sprint("m", i)))
+		require.NoError(t, err)
+		assert.Equal(t, i/4, dropped)
+	}
+	messages, err := q.peek(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m2", "m3"}, payloads(messages))
+
+	// peek 之后 m2 被丢弃，ack 时跳过
+	_, err = q.push(5000, []byte("m5"))
+	require.NoError(t, err)
+	require.NoError(t, q.ack(messages))
+	count, size, oldest := q.stats()
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(4), size)
+	assert.Equal(t, int64(4000), oldest)
+	require.NoError(t, q.close())
+
+	// 重新打开后继续按顺序读取
+	q, err = openBusQueue(dir, 3, 0, constants.MessageBufferDropNewest)
+	require.NoError(t, err)
+	defer q.close()
+	_, err = q.push(6000, []byte("m6"))
+	require.NoError(t, err)
+	_, err = q.push(7000, []byte("m7"))
+	assert.Equal(t, errBusQueueFull, err)
+	expired, err := q.expire(5000)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	messages, err = q.peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m5", "m6"}, payloads(messages))
+}
+
+func TestBusQueueMaxSize(t *testing.T) {
+	q, err := openBusQueue(t.TempDir(), 0, 10, constants.MessageBufferDropOldest)
+	require.NoError(t, err)
+	defer q.close()
+	for _, payload := range []string{"aaaa", "bbbb", "cccc"} {
+		_, err = q.push(0, []byte(payload))
+		require.NoError(t, err)
+	}
+	_, err = q.push(0, []byte("too large message"))
+	assert.Equal(t, errBusQueueFull, err)
+	messages, err := q.peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bbbb", "cccc"}, payloads(messages))
+}
+
+type fakeToken struct {
+	mqtt.Token
+	err error
+}
+
+func (t fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (t fakeToken) Error() error                   { return t.err }
+
+// fakePublisher 在 fail 条消息后断开，reject 中的消息推送失败一次但不断开
+type fakePublisher struct {
+	mutex     sync.Mutex
+	connected bool
+	fail      int
+	reject    map[string]bool
+	published []string
+}
+
+func (p *fakePublisher) GetConnectStatus() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.connected
+}
+
+func (p *fakePublisher) Publish(topic string, payload []byte) mqtt.Token {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.fail == 0 {
+		p.connected = false
+		return fakeToken{err: errors.New("not connected")}
+	}
+	p.fail--
+	p.published = append(p.published, string(payload))
+	if p.reject[string(payload)] {
+		delete(p.reject, string(payload))
+		return fakeToken{err: errors.New("publish rejected")}
+	}
+	return fakeToken{}
+}
+
+func TestBusBufferReplay(t *testing.T) {
+	b, err := newBusBuffer(logger.NewMockClient(), config.MessageBufferInfo{
+		Path:      t.TempDir(),
+		BatchSize: 2,
+	}, "eventbus/in")
+	require.NoError(t, err)
+	defer b.queue.close()
+	publisher := &fakePublisher{connected: true, fail: 3}
+	b.client = publisher
+
+	for i := 1; i <= 5; i++ {
+		b.push([]byte(fmt.Sprint("m", i)))
+	}
+	b.forward(context.Background())
+	stats := b.Stats()
+	assert.False(t, stats.Connected)
+	assert.Equal(t, int64(3), stats.Published)
+	assert.Equal(t, int64(1), stats.FailedPublish)
+	assert.Equal(t, 2, stats.Depth)
+
+	// 重新连接后按顺序推送剩余的消息
+	publisher.connected, publisher.fail = true, 10
+	b.push([]byte("m6"))
+	b.forward(context.Background())
+	assert.Equal(t, []string{"m1", "m2", "m3", "m4", "m5", "m6"}, publisher.published)
+	stats = b.Stats()
+	assert.Equal(t, 0, stats.Depth)
+	assert.Equal(t, int64(6), stats.Enqueued)
+	assert.Equal(t, int64(6), stats.Published)
+}
+
+func TestBusBufferRetryFromFailed(t *testing.T) {
+	b, err := newBusBuffer(logger.NewMockClient(), config.MessageBufferInfo{
+		Path:      t.TempDir(),
+		BatchSize: 3,
+	}, "eventbus/in")
+	require.NoError(t, err)
+	defer b.queue.close()
+	publisher := &fakePublisher{connected: true, fail: 10, reject: map[string]bool{"m2": true}}
+	b.client = publisher
+
+	for i := 1; i <= 3; i++ {
+		b.push([]byte(fmt.Sprint("m", i)))
+	}
+	// 一批消息连续推送，m2 失败时只删除 m1，重试时从 m2 开始，m3 重复推送
+	b.forward(context.Background())
+	assert.Equal(t, []string{"m1", "m2", "m3"}, publisher.published)
+	stats := b.Stats()
+	assert.True(t, stats.Connected)
+	assert.Equal(t, int64(1), stats.Published)
+	assert.Equal(t, 2, stats.Depth)
+
+	b.forward(context.Background())
+	assert.Equal(t, []string{"m1", "m2", "m3", "m2", "m3"}, publisher.published)
+	assert.Equal(t, 0, b.Stats().Depth)
+}
@@ -35,6 +35,10 @@ func (msp *MessageApp) connectMQTT() (mqttClient pkgMQTT.MQTTClient) {
 	}
 	connF := func(ctx context.Context) {
 		msp.lc.Info("ekuiper mqtt connect")
+		// 重新连接后推送断开期间进入队列的消息
+		if msp.buffer != nil {
+			msp.buffer.wake()
+		}
 	}
 
 	disConnF := func(ctx context.Context, msg dtos.CallbackMessage) {
@@ -66,7 +70,12 @@ func (tmq *MessageApp) ekuiperMsgHandle(client mqtt.Client, message mqtt.Message
 
 }
 
+// pushMsgToMessageBus 开启磁盘队列时写入队列，由推送协程按顺序推送
 func (tmq *MessageApp) pushMsgToMessageBus(msg []byte) {
+	if tmq.buffer != nil {
+		tmq.buffer.push(msg)
+		return
+	}
 	config := container.ConfigurationFrom(tmq.dic.Get)
 	tmq.ekuiperMqttClient.AsyncPublish(nil, config.MessageQueue.PublishTopicPrefix, msg, false)
 }
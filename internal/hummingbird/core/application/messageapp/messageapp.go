@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"github.com/kirinlabs/HttpRequest"
 	"github.com/winc-link/edge-driver-proto/drivercommon"
+	"github.com/winc-link/edge-driver-proto/thingmodel"
 	"github.com/winc-link/hummingbird/internal/dtos"
 	coreContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
 	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
@@ -28,6 +29,7 @@ import (
 	"github.com/winc-link/hummingbird/internal/pkg/logger"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	
 	pkgMQTT "github.com/winc-link/hummingbird/internal/tools/mqttclient"
@@ -39,6 +41,8 @@ type MessageApp struct {
 	dbClient          interfaces.DBClient
 	ekuiperMqttClient pkgMQTT.MQTTClient
 	ekuiperaddr       string
+	// buffer 未开启或打开失败时为 nil，broker 断开期间的消息直接丢弃
+	buffer *busBuffer
 }
 
 func NewMessageApp(dic *di.Container, ekuiperaddr string) *MessageApp {
@@ -50,12 +54,39 @@ func NewMessageApp(dic *di.Container, ekuiperaddr string) *MessageApp {
 		lc:          lc,
 		ekuiperaddr: ekuiperaddr,
 	}
+	// 连接成功的回调中唤醒推送，需要在连接前打开队列
+	config := coreContainer.ConfigurationFrom(dic.Get)
+	if config.MessageBuffer.Enable {
+		buffer, err := newBusBuffer(lc, config.MessageBuffer, config.MessageQueue.PublishTopicPrefix)
+		if err != nil {
+			lc.Errorf("open message buffer %s err: %v", config.MessageBuffer.Path, err)
+		} else {
+			msgApp.buffer = buffer
+		}
+	}
 	mqttClient := msgApp.connectMQTT()
 	msgApp.ekuiperMqttClient = mqttClient
+	if msgApp.buffer != nil && mqttClient != nil {
+		msgApp.buffer.client = mqttClient
+	}
 	msgApp.initeKuiperStreams()
 	return msgApp
 }
 
+// Run 启动消息总线磁盘队列的推送
+func (tmq *MessageApp) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if tmq.buffer != nil {
+		tmq.buffer.Run(ctx, wg)
+	}
+}
+
+func (tmq *MessageApp) MessageBufferStats() dtos.MessageBufferStats {
+	if tmq.buffer == nil {
+		return dtos.MessageBufferStats{Connected: tmq.ekuiperMqttClient != nil && tmq.ekuiperMqttClient.GetConnectStatus()}
+	}
+	return tmq.buffer.Stats()
+}
+
 func (tmq *MessageApp) initeKuiperStreams() {
 	req := HttpRequest.NewRequest()
 	r := make(map[string]string)
@@ -105,9 +136,43 @@ func (tmq *MessageApp) DeviceStatusToMessageBus(ctx context.Context, deviceId, d
 	
 }
 func (tmq *MessageApp) ThingModelMsgReport(ctx context.Context, msg dtos.ThingModelMessage) (*drivercommon.CommonResponse, error) {
+	deviceItf := coreContainer.DeviceItfFrom(tmq.dic.Get)
+	deviceItf.DeviceKeepAlive(ctx, msg.Cid)
+	// 设备及产品物模型每条消息只查询一次，各处理环节共享
+	var thingModel *dtos.DeviceThingModel
+	if tm, err := deviceItf.DeviceThingModel(ctx, msg.Cid); err == nil {
+		thingModel = &tm
+	}
+	// 原始值转换为工程值后再按物模型校验
+	msg = deviceItf.DeviceThingModelScale(ctx, msg, thingModel)
+	// 按产品物模型校验上报数据，不合法的数据根据产品配置拒绝、丢弃或仅记录
+	msg, keep, err := deviceItf.DeviceThingModelValidate(ctx, msg, thingModel)
+	if err != nil {
+		response := new(drivercommon.CommonResponse)
+		response.Success = false
+		response.Code = strconv.Itoa(errort.DeviceThingModelDataInvalid)
+		response.ErrorMessage = err.Error()
+		return response, nil
+	}
+	if !keep {
+		response := new(drivercommon.CommonResponse)
+		response.Code = "0"
+		response.Success = true
+		return response, nil
+	}
+	// 计算属性加入上报消息，与设备上报的属性一起推送和持久化
+	msg = deviceItf.DeviceComputedProperties(ctx, msg, thingModel)
 	tmq.pushMsgToMessageBus(msg.TransformMessageBus())
+	switch msg.GetOpType() {
+	case thingmodel.OperationType_PROPERTY_REPORT, thingmodel.OperationType_DATA_BATCH_REPORT:
+		deviceItf.DeviceShadowReport(ctx, msg, thingModel)
+	case thingmodel.OperationType_PROPERTY_DESIRED_GET, thingmodel.OperationType_PROPERTY_DESIRED_DELETE:
+		// 驱动调用上报接口期间再回调驱动，异步处理避免阻塞
+		go deviceItf.DeviceShadowDesired(context.Background(), msg)
+	}
+	coreContainer.LatestValueItfFrom(tmq.dic.Get).Update(msg)
 	persistItf := coreContainer.PersistItfFrom(tmq.dic.Get)
-	err := persistItf.SaveDeviceThingModelData(msg)
+	err = persistItf.SaveDeviceThingModelData(msg)
 	if err != nil {
 		tmq.lc.Error("saveDeviceThingModelData error:", err.Error())
 	}
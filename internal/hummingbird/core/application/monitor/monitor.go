@@ -116,3 +116,43 @@ func (m *monitor) GetSystemMetrics(ctx context.Context, query dtos.SystemMetrics
 
 	return resp, nil
 }
+
+// GetStorageStats 统计各产品下全部设备的时序数据大小，单个设备统计失败时记为 0
+func (m *monitor) GetStorageStats(ctx context.Context) (dtos.StorageStats, error) {
+	dbClient := container.DBClientFrom(m.dic.Get)
+	dataDbClient := container.DataDBClientFrom(m.dic.Get)
+	global := container.ConfigurationFrom(m.dic.Get).Retention.DataRetention()
+
+	products, _, err := dbClient.ProductsSearch(0, -1, true, dtos.ProductSearchQueryRequest{})
+	if err != nil {
+		return dtos.StorageStats{}, err
+	}
+	stats := dtos.StorageStats{
+		DataType: string(dataDbClient.GetDataDBType()),
+		Global:   global,
+		Products: make([]dtos.ProductStorageStats, 0, len(products)),
+	}
+	for _, product := range products {
+		devices, _, err := dbClient.DevicesSearch(0, -1, dtos.DeviceSearchQueryRequest{ProductId: product.Id})
+		if err != nil {
+			return dtos.StorageStats{}, err
+		}
+		item := dtos.ProductStorageStats{
+			ProductId:   product.Id,
+			ProductName: product.Name,
+			DeviceCount: len(devices),
+			Retention:   product.Retention.Merge(global),
+		}
+		for _, device := range devices {
+			size, err := dataDbClient.GetDeviceStorageSize(ctx, device, product)
+			if err != nil {
+				m.lc.Warnf("get storage size of device %s err: %v", device.Id, err)
+				continue
+			}
+			item.Size += size
+		}
+		stats.Total += item.Size
+		stats.Products = append(stats.Products, item)
+	}
+	return stats, nil
+}
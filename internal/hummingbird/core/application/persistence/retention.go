@@ -0,0 +1,71 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+)
+
+// DataRetentionCompact 按产品合并后的保存天数删除各设备的过期数据，
+// 并将时序库的全局保存天数设置为全部产品中最长的保存天数
+func (pst *persistApp) DataRetentionCompact(ctx context.Context) error {
+	global := resourceContainer.ConfigurationFrom(pst.dic.Get).Retention.DataRetention()
+	products, _, err := pst.dbClient.ProductsSearch(0, -1, true, dtos.ProductSearchQueryRequest{})
+	if err != nil {
+		return err
+	}
+
+	keep, forever := global.Max(), global.Max() == 0
+	var failed int
+	for _, product := range products {
+		retention := product.Retention.Merge(global)
+		days := retention.Max()
+		if days == 0 {
+			forever = true
+		} else if days > keep {
+			keep = days
+		}
+		if retention.Property == 0 && retention.Event == 0 && retention.Service == 0 {
+			continue
+		}
+		devices, _, err := pst.dbClient.DevicesSearch(0, -1, dtos.DeviceSearchQueryRequest{ProductId: product.Id})
+		if err != nil {
+			return err
+		}
+		for _, device := range devices {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			if err = pst.dataDbClient.DeleteExpiredData(ctx, device, product, retention); err != nil {
+				pst.lc.Errorf("delete expired data of device %s err: %v", device.Id, err)
+				failed++
+			}
+		}
+	}
+
+	if !forever {
+		if err = pst.dataDbClient.SetRetention(ctx, keep); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("delete expired data of %d devices failed", failed)
+	}
+	return nil
+}
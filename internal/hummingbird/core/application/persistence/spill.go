@@ -0,0 +1,155 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package persistence
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+)
+
+const (
+	// spillSegmentSize 单个落盘文件的大小上限，超过后写入新文件
+	spillSegmentSize = 16 << 20
+	// spillMaxLine 落盘文件中单条记录的最大长度
+	spillMaxLine = 16 << 20
+	spillPattern = "spill-*.ndjson"
+)
+
+// spillStore 写入失败的记录按行写入 json，文件名中的纳秒时间戳保证按写入顺序重放
+type spillStore struct {
+	dir   string
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+func newSpillStore(dir string) *spillStore {
+	return &spillStore{dir: dir}
+}
+
+func (s *spillStore) write(records []dtos.DataRecord) error {
+	var buf []byte
+	for _, record := range records {
+		b, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.file == nil {
+		if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+			return err
+		}
+		name := filepath.Join(s.dir, fmt.Sprintf("spill-%020d.ndjson", time.Now().UnixNano()))
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		s.file, s.size = f, 0
+	}
+	n, err := s.file.Write(buf)
+	s.size += int64(n)
+	if err == nil {
+		err = s.file.Sync()
+	}
+	// 写入失败时文件末尾可能有不完整的行，读取时跳过
+	if err != nil || s.size >= spillSegmentSize {
+		s.closeFile()
+	}
+	return err
+}
+
+func (s *spillStore) closeFile() {
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+}
+
+// segments 关闭正在写入的文件，返回全部待重放的文件
+func (s *spillStore) segments() ([]string, error) {
+	s.mutex.Lock()
+	s.closeFile()
+	s.mutex.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, spillPattern))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (s *spillStore) stats() (int, int64) {
+	files, _ := filepath.Glob(filepath.Join(s.dir, spillPattern))
+	var size int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			size += info.Size()
+		}
+	}
+	return len(files), size
+}
+
+// read 读取落盘文件，无法解析的行跳过
+func (s *spillStore) read(file string) ([]dtos.DataRecord, int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var records []dtos.DataRecord
+	var skipped int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), spillMaxLine)
+	for scanner.Scan() {
+		var record dtos.DataRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			skipped++
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, skipped, scanner.Err()
+}
+
+// rewrite 部分重放成功后用剩余的记录替换原文件
+func (s *spillStore) rewrite(file string, records []dtos.DataRecord) error {
+	var buf []byte
+	for _, record := range records {
+		b, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
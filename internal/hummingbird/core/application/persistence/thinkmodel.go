@@ -17,18 +17,23 @@ package persistence
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/winc-link/edge-driver-proto/thingmodel"
 	"github.com/winc-link/hummingbird/internal/dtos"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/messagestore"
 	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
 	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
 	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/aggregate"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/container"
 	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
 	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/pkg/unitconv"
 	"github.com/winc-link/hummingbird/internal/pkg/utils"
-	"strconv"
+	"github.com/winc-link/hummingbird/internal/tools/datadb"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/leveldb"
 )
 
 type persistApp struct {
@@ -36,6 +41,7 @@ type persistApp struct {
 	lc           logger.LoggingClient
 	dbClient     interfaces.DBClient
 	dataDbClient interfaces.DataDBClient
+	writeBuffer  *writeBuffer
 }
 
 func NewPersistApp(dic *di.Container) *persistApp {
@@ -48,6 +54,13 @@ func NewPersistApp(dic *di.Container) *persistApp {
 		dbClient:     dbClient,
 		dataDbClient: dataDbClient,
 	}
+	cfg := resourceContainer.ConfigurationFrom(dic.Get).WriteBuffer
+	switch dataDbClient.GetDataDBType() {
+	case constants.TDengine, constants.Tstorage, constants.SQLiteDB:
+		if cfg.Enable {
+			pstApp.writeBuffer = newWriteBuffer(lc, dataDbClient, cfg)
+		}
+	}
 
 	return pstApp
 }
@@ -56,7 +69,7 @@ func (pst *persistApp) SaveDeviceThingModelData(req dtos.ThingModelMessage) erro
 	switch pst.dataDbClient.GetDataDBType() {
 	case constants.LevelDB:
 		return pst.saveDeviceThingModelToLevelDB(req)
-	case constants.TDengine:
+	case constants.TDengine, constants.SQLiteDB:
 		return pst.saveDeviceThingModelToTdengine(req)
 	case constants.Tstorage:
 		return pst.saveDeviceThingModelToTstorage(req)
@@ -248,11 +261,11 @@ func (pst *persistApp) saveDeviceThingModelToTdengine(req dtos.ThingModelMessage
 		if err != nil {
 			return err
 		}
-		data := make(map[string]interface{})
+		values := make(map[string]interface{})
 		for s, reportData := range propertyMsg.Data {
-			data[s] = reportData.Value
+			values[s] = reportData.Value
 		}
-		err = pst.dataDbClient.Insert(context.Background(), constants.DB_PREFIX+req.Cid, data)
+		err = pst.writeRecord(dtos.DataRecord{Table: constants.DB_PREFIX + req.Cid, Values: values})
 		if err != nil {
 			return err
 		}
@@ -262,9 +275,10 @@ func (pst *persistApp) saveDeviceThingModelToTdengine(req dtos.ThingModelMessage
 		if err != nil {
 			return err
 		}
-		data := make(map[string]interface{})
-		data[eventMsg.Data.EventCode] = eventMsg.Data
-		err = pst.dataDbClient.Insert(context.Background(), constants.DB_PREFIX+req.Cid, data)
+		err = pst.writeRecord(dtos.DataRecord{
+			Table:  constants.DB_PREFIX + req.Cid,
+			Events: map[string]dtos.EventData{eventMsg.Data.EventCode: eventMsg.Data},
+		})
 		if err != nil {
 			return err
 		}
@@ -274,10 +288,10 @@ func (pst *persistApp) saveDeviceThingModelToTdengine(req dtos.ThingModelMessage
 		if err != nil {
 			return err
 		}
-		v, _ := serviceMsg.Marshal()
-		data := make(map[string]interface{})
-		data[serviceMsg.Code] = string(v)
-		err = pst.dataDbClient.Insert(context.Background(), constants.DB_PREFIX+req.Cid, data)
+		err = pst.writeRecord(dtos.DataRecord{
+			Table:    constants.DB_PREFIX + req.Cid,
+			Services: map[string]dtos.SaveServiceIssueData{serviceMsg.Code: serviceMsg},
+		})
 		if err != nil {
 			return err
 		}
@@ -318,21 +332,24 @@ func (pst *persistApp) saveDeviceThingModelToTdengine(req dtos.ThingModelMessage
 		if err != nil {
 			return err
 		}
-		data := make(map[string]interface{})
-
+		record := dtos.DataRecord{
+			Table:  constants.DB_PREFIX + req.Cid,
+			Values: make(map[string]interface{}),
+			Events: make(map[string]dtos.EventData),
+		}
 		for code, property := range msg.Data.Properties {
-			data[code] = property.Value
+			record.Values[code] = property.Value
 		}
 		for code, event := range msg.Data.Events {
 			var eventData dtos.EventData
 			eventData.OutputParams = event.OutputParams
 			eventData.EventCode = code
 			eventData.EventTime = msg.Time
-			data[code] = eventData
+			record.Events[code] = eventData
 
 		}
 		//批量写。
-		err = pst.dataDbClient.Insert(context.Background(), constants.DB_PREFIX+req.Cid, data)
+		err = pst.writeRecord(record)
 
 		if err != nil {
 			return err
@@ -415,12 +432,11 @@ func (pst *persistApp) saveDeviceThingModelToTstorage(req dtos.ThingModelMessage
 		if err != nil {
 			return err
 		}
-		data := make(map[string]interface{})
+		values := make(map[string]interface{})
 		for s, reportData := range propertyMsg.Data {
-			data[s] = reportData.Value
+			values[s] = reportData.Value
 		}
-
-		err = pst.dataDbClient.Insert(context.Background(), constants.DB_PREFIX+req.Cid, data)
+		err = pst.writeRecord(dtos.DataRecord{Table: constants.DB_PREFIX + req.Cid, Values: values})
 		if err != nil {
 			return err
 		}
@@ -430,9 +446,10 @@ func (pst *persistApp) saveDeviceThingModelToTstorage(req dtos.ThingModelMessage
 		if err != nil {
 			return err
 		}
-		data := make(map[string]interface{})
-		data[eventMsg.Data.EventCode] = eventMsg.Data
-		err = pst.dataDbClient.Insert(context.Background(), constants.DB_PREFIX+req.Cid, data)
+		err = pst.writeRecord(dtos.DataRecord{
+			Table:  constants.DB_PREFIX + req.Cid,
+			Events: map[string]dtos.EventData{eventMsg.Data.EventCode: eventMsg.Data},
+		})
 		if err != nil {
 			return err
 		}
@@ -442,10 +459,10 @@ func (pst *persistApp) saveDeviceThingModelToTstorage(req dtos.ThingModelMessage
 		if err != nil {
 			return err
 		}
-		v, _ := serviceMsg.Marshal()
-		data := make(map[string]interface{})
-		data[serviceMsg.Code] = string(v)
-		err = pst.dataDbClient.Insert(context.Background(), constants.DB_PREFIX+req.Cid, data)
+		err = pst.writeRecord(dtos.DataRecord{
+			Table:    constants.DB_PREFIX + req.Cid,
+			Services: map[string]dtos.SaveServiceIssueData{serviceMsg.Code: serviceMsg},
+		})
 		if err != nil {
 			return err
 		}
@@ -486,21 +503,24 @@ func (pst *persistApp) saveDeviceThingModelToTstorage(req dtos.ThingModelMessage
 		if err != nil {
 			return err
 		}
-		data := make(map[string]interface{})
-
+		record := dtos.DataRecord{
+			Table:  constants.DB_PREFIX + req.Cid,
+			Values: make(map[string]interface{}),
+			Events: make(map[string]dtos.EventData),
+		}
 		for code, property := range msg.Data.Properties {
-			data[code] = property.Value
+			record.Values[code] = property.Value
 		}
 		for code, event := range msg.Data.Events {
 			var eventData dtos.EventData
 			eventData.OutputParams = event.OutputParams
 			eventData.EventCode = code
 			eventData.EventTime = msg.Time
-			data[code] = eventData
+			record.Events[code] = eventData
 
 		}
 		//批量写。
-		err = pst.dataDbClient.Insert(context.Background(), constants.DB_PREFIX+req.Cid, data)
+		err = pst.writeRecord(record)
 
 		if err != nil {
 			return err
@@ -577,27 +597,69 @@ func (pst *persistApp) saveDeviceThingModelToTstorage(req dtos.ThingModelMessage
 }
 
 func generatePropertyLeveldbKey(cid, code string, reportTime int64) string {
-	return cid + "-" + constants.Property + "-" + code + "-" + strconv.Itoa(int(reportTime))
+	return leveldb.HistoryKey(cid, constants.Property, code, reportTime)
 }
 
-func generateOncePropertyLeveldbKey(cid, code string) string {
-	return cid + "-" + constants.Property + "-" + code
+func generateEventLeveldbKey(cid, code string, reportTime int64) string {
+	return leveldb.HistoryKey(cid, constants.Event, code, reportTime)
 }
 
-func generateEventLeveldbKey(cid, code string, reportTime int64) string {
-	return cid + "-" + constants.Event + "-" + code + "-" + strconv.Itoa(int(reportTime))
+func generateActionLeveldbKey(cid, code string, reportTime int64) string {
+	return leveldb.HistoryKey(cid, constants.Action, code, reportTime)
 }
 
-func generateOnceEventLeveldbKey(cid, code string) string {
-	return cid + "-" + constants.Event + "-" + code
+// propertyUnit int、float 属性定义的单位
+func propertyUnit(property models.Properties) string {
+	var typeSpecIntOrFloat models.TypeSpecIntOrFloat
+	_ = json.Unmarshal([]byte(property.TypeSpec.Specs), &typeSpecIntOrFloat)
+	return typeSpecIntOrFloat.Unit
 }
 
-func generateActionLeveldbKey(cid, code string, reportTime int64) string {
-	return cid + "-" + constants.Action + "-" + code + "-" + strconv.Itoa(int(reportTime))
+// convertUnit 历史数据按请求的单位换算，属性需定义单位且与请求的单位属于同一物理量
+func convertUnit(req dtos.ThingModelPropertyDataRequest, property models.Properties, response []dtos.ReportData) ([]dtos.ReportData, error) {
+	if req.Unit == "" {
+		return response, nil
+	}
+	if property.TypeSpec.Type != constants.SpecsTypeInt && property.TypeSpec.Type != constants.SpecsTypeFloat {
+		return nil, errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("property %s does not support unit conversion", property.Code), nil)
+	}
+	unit := propertyUnit(property)
+	if !unitconv.Convertible(unit, req.Unit) {
+		return nil, errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("unit %s can not convert to %s", unit, req.Unit), nil)
+	}
+	for i := range response {
+		if response[i].Value == nil {
+			continue
+		}
+		value, err := unitconv.Convert(utils.ConvertToFloat64(response[i].Value), unit, req.Unit)
+		if err != nil {
+			return nil, errort.NewCommonEdgeX(errort.DefaultReqParamsError, err.Error(), nil)
+		}
+		response[i].Value = value
+	}
+	return response, nil
+}
+
+// getDeviceProperty 查询属性数据，结构体和数组属性还原为原始结构
+func (pst *persistApp) getDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device, property models.Properties) ([]dtos.ReportData, int, error) {
+	return datadb.GetDeviceProperty(pst.dataDbClient, req, device, property)
 }
 
-func generateOnceActionLeveldbKey(cid, code string) string {
-	return cid + "-" + constants.Action + "-" + code
+// latestDeviceProperty 属性的当前值优先从最新值缓存读取，缓存中没有时查询时序库中最新的一条并写入缓存
+func (pst *persistApp) latestDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device, property models.Properties) (dtos.ReportData, error) {
+	cache := resourceContainer.LatestValueItfFrom(pst.dic.Get)
+	if data, ok := cache.Get(device.Id, property.Code); ok {
+		return data, nil
+	}
+	req.Code = property.Code
+	req.Range = nil
+	req.First, req.Last = false, true
+	ksv, _, err := pst.getDeviceProperty(req, device, property)
+	if err != nil || len(ksv) == 0 {
+		return dtos.ReportData{}, err
+	}
+	cache.Set(device.Id, map[string]dtos.ReportData{property.Code: ksv[0]})
+	return ksv[0], nil
 }
 
 func (pst *persistApp) searchDeviceThingModelPropertyDataFromLevelDB(req dtos.ThingModelPropertyDataRequest) (interface{}, error) {
@@ -614,15 +676,11 @@ func (pst *persistApp) searchDeviceThingModelPropertyDataFromLevelDB(req dtos.Th
 	if req.Code == "" {
 		for _, property := range productInfo.Properties {
 			req.Code = property.Code
-			ksv, _, err := pst.dataDbClient.GetDeviceProperty(req, deviceInfo)
+			reportData, err := pst.latestDeviceProperty(req, deviceInfo, property)
 			if err != nil {
 				pst.lc.Errorf("GetDeviceProperty error %+v", err)
 				continue
 			}
-			var reportData dtos.ReportData
-			if len(ksv) > 0 {
-				reportData = ksv[0]
-			}
 			var unit string
 			if property.TypeSpec.Type == constants.SpecsTypeInt || property.TypeSpec.Type == constants.SpecsTypeFloat {
 				var typeSpecIntOrFloat models.TypeSpecIntOrFloat
@@ -669,10 +727,13 @@ func (pst *persistApp) searchDeviceThingModelHistoryPropertyDataFromTDengine(req
 	for _, property := range productInfo.Properties {
 		if property.Code == req.Code {
 			req.Code = property.Code
-			response, count, err = pst.dataDbClient.GetDeviceProperty(req, deviceInfo)
+			response, count, err = pst.getDeviceProperty(req, deviceInfo, property)
 			if err != nil {
 				pst.lc.Errorf("GetDeviceProperty error %+v", err)
 			}
+			if response, err = convertUnit(req, property, response); err != nil {
+				return nil, 0, err
+			}
 			var typeSpecIntOrFloat models.TypeSpecIntOrFloat
 			if property.TypeSpec.Type == constants.SpecsTypeInt || property.TypeSpec.Type == constants.SpecsTypeFloat {
 				_ = json.Unmarshal([]byte(property.TypeSpec.Specs), &typeSpecIntOrFloat)
@@ -703,10 +764,13 @@ func (pst *persistApp) searchDeviceThingModelHistoryPropertyDataFromTstorage(req
 	for _, property := range productInfo.Properties {
 		if property.Code == req.Code {
 			req.Code = property.Code
-			response, count, err = pst.dataDbClient.GetDeviceProperty(req, deviceInfo)
+			response, count, err = pst.getDeviceProperty(req, deviceInfo, property)
 			if err != nil {
 				pst.lc.Errorf("GetDeviceProperty error %+v", err)
 			}
+			if response, err = convertUnit(req, property, response); err != nil {
+				return nil, 0, err
+			}
 			var typeSpecIntOrFloat models.TypeSpecIntOrFloat
 			if property.TypeSpec.Type == constants.SpecsTypeInt || property.TypeSpec.Type == constants.SpecsTypeFloat {
 				_ = json.Unmarshal([]byte(property.TypeSpec.Specs), &typeSpecIntOrFloat)
@@ -735,15 +799,11 @@ func (pst *persistApp) searchDeviceThingModelPropertyDataFromTstorage(req dtos.T
 	if req.Code == "" {
 		for _, property := range productInfo.Properties {
 			req.Code = property.Code
-			ksv, _, err := pst.dataDbClient.GetDeviceProperty(req, deviceInfo)
+			reportData, err := pst.latestDeviceProperty(req, deviceInfo, property)
 			if err != nil {
 				pst.lc.Errorf("GetDeviceProperty error %+v", err)
 				continue
 			}
-			var reportData dtos.ReportData
-			if len(ksv) > 0 {
-				reportData = ksv[0]
-			}
 			var unit string
 			if property.TypeSpec.Type == constants.SpecsTypeInt || property.TypeSpec.Type == constants.SpecsTypeFloat {
 				var typeSpecIntOrFloat models.TypeSpecIntOrFloat
@@ -791,15 +851,11 @@ func (pst *persistApp) searchDeviceThingModelPropertyDataFromTDengine(req dtos.T
 	if req.Code == "" {
 		for _, property := range productInfo.Properties {
 			req.Code = property.Code
-			ksv, _, err := pst.dataDbClient.GetDeviceProperty(req, deviceInfo)
+			reportData, err := pst.latestDeviceProperty(req, deviceInfo, property)
 			if err != nil {
 				pst.lc.Errorf("GetDeviceProperty error %+v", err)
 				continue
 			}
-			var reportData dtos.ReportData
-			if len(ksv) > 0 {
-				reportData = ksv[0]
-			}
 			var unit string
 			if property.TypeSpec.Type == constants.SpecsTypeInt || property.TypeSpec.Type == constants.SpecsTypeFloat {
 				var typeSpecIntOrFloat models.TypeSpecIntOrFloat
@@ -838,7 +894,7 @@ func (pst *persistApp) SearchDeviceThingModelPropertyData(req dtos.ThingModelPro
 	switch pst.dataDbClient.GetDataDBType() {
 	case constants.LevelDB:
 		return pst.searchDeviceThingModelPropertyDataFromLevelDB(req)
-	case constants.TDengine:
+	case constants.TDengine, constants.SQLiteDB:
 		return pst.searchDeviceThingModelPropertyDataFromTDengine(req)
 	case constants.Tstorage:
 		return pst.searchDeviceThingModelPropertyDataFromTstorage(req)
@@ -862,10 +918,13 @@ func (pst *persistApp) searchDeviceThingModelHistoryPropertyDataFromLevelDB(req
 	var response []dtos.ReportData
 	for _, property := range productInfo.Properties {
 		if property.Code == req.Code {
-			response, count, err = pst.dataDbClient.GetDeviceProperty(req, deviceInfo)
+			response, count, err = pst.getDeviceProperty(req, deviceInfo, property)
 			if err != nil {
 				pst.lc.Errorf("GetHistoryDeviceProperty error %+v", err)
 			}
+			if response, err = convertUnit(req, property, response); err != nil {
+				return nil, 0, err
+			}
 			break
 		}
 	}
@@ -873,10 +932,13 @@ func (pst *persistApp) searchDeviceThingModelHistoryPropertyDataFromLevelDB(req
 }
 
 func (pst *persistApp) SearchDeviceThingModelHistoryPropertyData(req dtos.ThingModelPropertyDataRequest) (interface{}, int, error) {
+	if req.Interval != "" {
+		return pst.searchDeviceThingModelAggregatePropertyData(req)
+	}
 	switch pst.dataDbClient.GetDataDBType() {
 	case constants.LevelDB:
 		return pst.searchDeviceThingModelHistoryPropertyDataFromLevelDB(req)
-	case constants.TDengine:
+	case constants.TDengine, constants.SQLiteDB:
 		return pst.searchDeviceThingModelHistoryPropertyDataFromTDengine(req)
 	case constants.Tstorage:
 		return pst.searchDeviceThingModelHistoryPropertyDataFromTstorage(req)
@@ -885,6 +947,69 @@ func (pst *persistApp) SearchDeviceThingModelHistoryPropertyData(req dtos.ThingM
 	return response, 0, nil
 }
 
+// searchDeviceThingModelAggregatePropertyData 按时间窗口聚合属性历史数据，返回全部窗口，总数为窗口数。
+// count 支持全部非结构体、数组属性，其他聚合函数只支持 int 和 float 属性
+func (pst *persistApp) searchDeviceThingModelAggregatePropertyData(req dtos.ThingModelPropertyDataRequest) (interface{}, int, error) {
+	if len(req.Range) != 2 {
+		return nil, 0, errort.NewCommonEdgeX(errort.DefaultReqParamsError, "range is required", nil)
+	}
+	interval, err := aggregate.ParseInterval(req.Interval)
+	if err != nil {
+		return nil, 0, errort.NewCommonEdgeX(errort.DefaultReqParamsError, err.Error(), nil)
+	}
+	query := aggregate.Query{
+		Start:     req.Range[0],
+		End:       req.Range[1],
+		Interval:  interval,
+		Function:  aggregate.Function(req.Function),
+		Fill:      aggregate.Fill(req.Fill),
+		FillValue: req.FillValue,
+	}
+	if err = query.Check(); err != nil {
+		return nil, 0, errort.NewCommonEdgeX(errort.DefaultReqParamsError, err.Error(), nil)
+	}
+
+	deviceInfo, err := pst.dbClient.DeviceById(req.DeviceId)
+	if err != nil {
+		return nil, 0, err
+	}
+	productInfo, err := pst.dbClient.ProductById(deviceInfo.ProductId)
+	if err != nil {
+		return nil, 0, err
+	}
+	var property models.Properties
+	var ok bool
+	for _, p := range productInfo.Properties {
+		if p.Code == req.Code {
+			property, ok = p, true
+			break
+		}
+	}
+	if !ok {
+		return nil, 0, errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("property %s not found", req.Code), nil)
+	}
+	numeric := property.TypeSpec.Type == constants.SpecsTypeInt || property.TypeSpec.Type == constants.SpecsTypeFloat
+	if property.TypeSpec.Type.IsNested() || (query.Function != aggregate.Count && !numeric) {
+		return nil, 0, errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("property %s does not support %s", property.Code, query.Function), nil)
+	}
+
+	// 带偏移量的单位(如 °C 与 °F)换算与值的个数有关，和不能按单个值换算
+	if query.Function == aggregate.Sum && req.Unit != "" && unitconv.Affine(propertyUnit(property), req.Unit) {
+		return nil, 0, errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("sum of property %s can not convert from %s to %s", property.Code, propertyUnit(property), req.Unit), nil)
+	}
+
+	response, err := pst.dataDbClient.GetDevicePropertyAggregate(req, deviceInfo, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	if query.Function != aggregate.Count {
+		if response, err = convertUnit(req, property, response); err != nil {
+			return nil, 0, err
+		}
+	}
+	return response, len(response), nil
+}
+
 func (pst *persistApp) searchDeviceThingModelServiceDataFromLevelDB(req dtos.ThingModelServiceDataRequest) ([]dtos.ThingModelServiceDataResponse, int, error) {
 	var count int
 	deviceInfo, err := pst.dbClient.DeviceById(req.DeviceId)
@@ -976,7 +1101,7 @@ func (pst *persistApp) SearchDeviceThingModelServiceData(req dtos.ThingModelServ
 	switch pst.dataDbClient.GetDataDBType() {
 	case constants.LevelDB:
 		return pst.searchDeviceThingModelServiceDataFromLevelDB(req)
-	case constants.TDengine:
+	case constants.TDengine, constants.Tstorage, constants.SQLiteDB:
 		return pst.searchDeviceThingModelServiceDataFromTDengine(req)
 	}
 	return response, 0, nil
@@ -1055,7 +1180,7 @@ func (pst *persistApp) SearchDeviceThingModelEventData(req dtos.ThingModelEventD
 	switch pst.dataDbClient.GetDataDBType() {
 	case constants.LevelDB:
 		return pst.searchDeviceThingModelEventDataFromLevelDB(req)
-	case constants.TDengine:
+	case constants.TDengine, constants.Tstorage, constants.SQLiteDB:
 		return pst.searchDeviceThingModelEventDataFromTDengine(req)
 	}
 	return response, 0, nil
@@ -1125,7 +1250,7 @@ func (pst *persistApp) searchDeviceMsgCountFromTDengine(startTime, endTime int64
 		}
 		count += msgCount
 	}
-	return 0, nil
+	return count, nil
 }
 
 // SearchDeviceMsgCount 统计设备的消息总数（属性、事件都算在内）
@@ -1134,7 +1259,7 @@ func (pst *persistApp) SearchDeviceMsgCount(startTime, endTime int64) (int, erro
 	switch pst.dataDbClient.GetDataDBType() {
 	case constants.LevelDB:
 		return pst.searchDeviceMsgCountFromLevelDB(startTime, endTime)
-	case constants.TDengine:
+	case constants.TDengine, constants.SQLiteDB:
 		return pst.searchDeviceMsgCountFromTDengine(startTime, endTime)
 	}
 
@@ -0,0 +1,359 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package persistence
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/config"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+const (
+	defaultBufferSize     = 100000
+	defaultBatchSize      = 1000
+	defaultFlushInterval  = time.Second
+	defaultReplayInterval = 30 * time.Second
+	// writeTimeout 单次批量写入的超时时间
+	writeTimeout = 30 * time.Second
+)
+
+// Run 启动写缓冲，退出时写入缓冲中剩余的数据
+func (pst *persistApp) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if pst.writeBuffer != nil {
+		pst.writeBuffer.Run(ctx, wg)
+	}
+}
+
+func (pst *persistApp) WriteBufferStats() dtos.WriteBufferStats {
+	if pst.writeBuffer == nil {
+		return dtos.WriteBufferStats{}
+	}
+	return pst.writeBuffer.Stats()
+}
+
+// writeRecord 开启写缓冲时放入缓冲，否则同步写入
+func (pst *persistApp) writeRecord(record dtos.DataRecord) error {
+	if record.Time <= 0 {
+		record.Time = time.Now().UnixMilli()
+	}
+	if pst.writeBuffer != nil && pst.writeBuffer.Enqueue(record) {
+		return nil
+	}
+	return pst.dataDbClient.BatchInsert(context.Background(), []dtos.DataRecord{record})
+}
+
+// writeBuffer 时序数据的写缓冲。上报的数据进入缓冲后立即返回，
+// 缓冲达到 BatchSize 或等待 FlushInterval 后批量写入；缓冲已满时上报阻塞等待，
+// 写入失败的数据落盘，定期重放
+type writeBuffer struct {
+	lc             logger.LoggingClient
+	client         interfaces.DataDBClient
+	batchSize      int
+	flushInterval  time.Duration
+	replayInterval time.Duration
+	spill          *spillStore
+
+	mutex   sync.RWMutex
+	closed  bool
+	records chan dtos.DataRecord
+
+	enqueued         int64
+	written          int64
+	batches          int64
+	failedBatches    int64
+	blocked          int64
+	spilled          int64
+	replayed         int64
+	dropped          int64
+	lastFlushLatency int64
+}
+
+func newWriteBuffer(lc logger.LoggingClient, client interfaces.DataDBClient, cfg config.WriteBufferInfo) *writeBuffer {
+	b := &writeBuffer{
+		lc:             lc,
+		client:         client,
+		batchSize:      cfg.BatchSize,
+		flushInterval:  time.Duration(cfg.FlushInterval) * time.Millisecond,
+		replayInterval: time.Duration(cfg.ReplayInterval) * time.Second,
+	}
+	size := cfg.Size
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	if b.batchSize <= 0 {
+		b.batchSize = defaultBatchSize
+	}
+	if b.flushInterval <= 0 {
+		b.flushInterval = defaultFlushInterval
+	}
+	if b.replayInterval <= 0 {
+		b.replayInterval = defaultReplayInterval
+	}
+	if cfg.SpillPath != "" {
+		b.spill = newSpillStore(cfg.SpillPath)
+	}
+	b.records = make(chan dtos.DataRecord, size)
+	return b
+}
+
+func (b *writeBuffer) Run(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.flushLoop()
+	}()
+	go func() {
+		<-ctx.Done()
+		b.close()
+	}()
+	if b.spill != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.replayLoop(ctx)
+		}()
+	}
+}
+
+// Enqueue 缓冲已关闭时返回 false，由调用方同步写入
+func (b *writeBuffer) Enqueue(record dtos.DataRecord) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if b.closed {
+		return false
+	}
+	select {
+	case b.records <- record:
+	default:
+		atomic.AddInt64(&b.blocked, 1)
+		b.records <- record
+	}
+	atomic.AddInt64(&b.enqueued, 1)
+	return true
+}
+
+func (b *writeBuffer) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.records)
+	}
+}
+
+func (b *writeBuffer) Stats() dtos.WriteBufferStats {
+	stats := dtos.WriteBufferStats{
+		Enable:           true,
+		QueueLength:      len(b.records),
+		QueueCapacity:    cap(b.records),
+		Enqueued:         atomic.LoadInt64(&b.enqueued),
+		Written:          atomic.LoadInt64(&b.written),
+		Batches:          atomic.LoadInt64(&b.batches),
+		FailedBatches:    atomic.LoadInt64(&b.failedBatches),
+		Blocked:          atomic.LoadInt64(&b.blocked),
+		Spilled:          atomic.LoadInt64(&b.spilled),
+		Replayed:         atomic.LoadInt64(&b.replayed),
+		Dropped:          atomic.LoadInt64(&b.dropped),
+		LastFlushLatency: atomic.LoadInt64(&b.lastFlushLatency),
+	}
+	if b.spill != nil {
+		stats.SpillFiles, stats.SpillSize = b.spill.stats()
+	}
+	return stats
+}
+
+func (b *writeBuffer) flushLoop() {
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+	batch := make([]dtos.DataRecord, 0, b.batchSize)
+	for {
+		select {
+		case record, ok := <-b.records:
+			if !ok {
+				b.flush(batch)
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= b.batchSize {
+				b.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			b.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (b *writeBuffer) flush(batch []dtos.DataRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	start := time.Now()
+	remaining, _, err := b.write(batch)
+	atomic.StoreInt64(&b.lastFlushLatency, time.Since(start).Milliseconds())
+	if len(remaining) == 0 {
+		return
+	}
+	b.lc.Errorf("write buffer insert %d records err: %v", len(remaining), err)
+	if b.spill == nil {
+		atomic.AddInt64(&b.dropped, int64(len(remaining)))
+		return
+	}
+	if err = b.spill.write(remaining); err != nil {
+		b.lc.Errorf("write buffer spill %d records err: %v", len(remaining), err)
+		atomic.AddInt64(&b.dropped, int64(len(remaining)))
+		return
+	}
+	atomic.AddInt64(&b.spilled, int64(len(remaining)))
+}
+
+func (b *writeBuffer) insert(records []dtos.DataRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := b.client.BatchInsert(ctx, records); err != nil {
+		atomic.AddInt64(&b.failedBatches, 1)
+		return err
+	}
+	atomic.AddInt64(&b.batches, 1)
+	atomic.AddInt64(&b.written, int64(len(records)))
+	return nil
+}
+
+// write 批量写入，返回因时序库不可用而未写入的记录。
+// 其它错误时逐条写入，部分记录写入成功说明失败的记录本身无法写入(如设备已删除)，丢弃这些记录
+func (b *writeBuffer) write(records []dtos.DataRecord) ([]dtos.DataRecord, int, error) {
+	err := b.insert(records)
+	if err == nil {
+		return nil, 0, nil
+	}
+	if len(records) == 1 || isUnavailable(err) {
+		return records, 0, err
+	}
+	var failed []dtos.DataRecord
+	var lastErr error
+	for i, record := range records {
+		if err = b.insert([]dtos.DataRecord{record}); err == nil {
+			continue
+		}
+		if isUnavailable(err) {
+			return append(failed, records[i:]...), 0, err
+		}
+		failed = append(failed, record)
+		lastErr = err
+	}
+	if len(failed) == len(records) {
+		return failed, 0, lastErr
+	}
+	for _, record := range failed {
+		b.lc.Errorf("write buffer drop record of %s: %v", record.Table, lastErr)
+	}
+	atomic.AddInt64(&b.dropped, int64(len(failed)))
+	return nil, len(failed), nil
+}
+
+// isUnavailable 连接失败或超时，时序库恢复后可以重试
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) ||
+		errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr)
+}
+
+func (b *writeBuffer) replayLoop(ctx context.Context) {
+	ticker := time.NewTicker(b.replayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.replay(ctx)
+		}
+	}
+}
+
+// replay 按写入顺序重放落盘文件，时序库不可用时停止，等待下次重放。
+// 其它错误说明记录本身无法写入，丢弃后继续重放，落盘文件只保留因时序库不可用而未写入的记录
+func (b *writeBuffer) replay(ctx context.Context) {
+	files, err := b.spill.segments()
+	if err != nil {
+		b.lc.Errorf("write buffer list spill files err: %v", err)
+		return
+	}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		records, skipped, err := b.spill.read(file)
+		if err != nil {
+			b.lc.Errorf("write buffer read spill file %s err: %v", file, err)
+			continue
+		}
+		if skipped > 0 {
+			b.lc.Warnf("write buffer skip %d invalid records of spill file %s", skipped, file)
+			atomic.AddInt64(&b.dropped, int64(skipped))
+		}
+
+		var remaining []dtos.DataRecord
+		var replayErr error
+		for start := 0; start < len(records); start += b.batchSize {
+			end := start + b.batchSize
+			if end > len(records) {
+				end = len(records)
+			}
+			var failed []dtos.DataRecord
+			var dropped int
+			failed, dropped, replayErr = b.write(records[start:end])
+			if len(failed) > 0 && !isUnavailable(replayErr) {
+				// 时序库可以访问但记录全部写入失败，重放也不会成功，丢弃这些记录
+				for _, record := range failed {
+					b.lc.Errorf("write buffer drop replayed record of %s: %v", record.Table, replayErr)
+				}
+				atomic.AddInt64(&b.dropped, int64(len(failed)))
+				dropped += len(failed)
+				failed = nil
+			}
+			atomic.AddInt64(&b.replayed, int64(end-start-len(failed)-dropped))
+			if len(failed) > 0 {
+				b.lc.Errorf("write buffer replay spill file %s err: %v", file, replayErr)
+				remaining = append(failed, records[end:]...)
+				break
+			}
+		}
+		if len(remaining) == 0 {
+			if err = os.Remove(file); err != nil {
+				b.lc.Errorf("write buffer remove spill file %s err: %v", file, err)
+			}
+			continue
+		}
+		if err = b.spill.rewrite(file, remaining); err != nil {
+			b.lc.Errorf("write buffer rewrite spill file %s err: %v", file, err)
+		}
+		if isUnavailable(replayErr) {
+			return
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/config"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/tstorage"
+)
+
+// fakeDataDB 模拟每条语句固定耗时的时序库
+type fakeDataDB struct {
+	interfaces.DataDBClient
+	latency     time.Duration
+	unavailable int32
+	invalid     string
+
+	mutex   sync.Mutex
+	records []dtos.DataRecord
+}
+
+func (f *fakeDataDB) BatchInsert(ctx context.Context, records []dtos.DataRecord) error {
+	time.Sleep(f.latency)
+	if atomic.LoadInt32(&f.unavailable) == 1 {
+		return &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	}
+	for _, record := range records {
+		if record.Table == f.invalid {
+			return errors.New("table does not exist")
+		}
+	}
+	f.mutex.Lock()
+	f.records = append(f.records, records...)
+	f.mutex.Unlock()
+	return nil
+}
+
+func (f *fakeDataDB) count() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.records)
+}
+
+func testRecord(device int) dtos.DataRecord {
+	return dtos.DataRecord{
+		Table:  fmt.Sprintf("%sdevice%d", constants.DB_PREFIX, device),
+		Time:   time.Now().UnixMilli(),
+		Values: map[string]interface{}{"temperature": 25.5, "humidity": 60.0},
+	}
+}
+
+func runWriteBuffer(b *writeBuffer) (context.CancelFunc, *sync.WaitGroup) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := new(sync.WaitGroup)
+	b.Run(ctx, wg)
+	return cancel, wg
+}
+
+func TestWriteBufferSpillAndReplay(t *testing.T) {
+	client := &fakeDataDB{unavailable: 1}
+	b := newWriteBuffer(logger.NewMockClient(), client, config.WriteBufferInfo{
+		BatchSize:     10,
+		FlushInterval: 10,
+		SpillPath:     t.TempDir(),
+	})
+	cancel, wg := runWriteBuffer(b)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for i := 0; i < 25; i++ {
+		require.True(t, b.Enqueue(testRecord(i)))
+	}
+	require.Eventually(t, func() bool {
+		return b.Stats().Spilled == 25
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, client.count())
+
+	// 时序库不可用时保留落盘文件
+	b.replay(context.Background())
+	stats := b.Stats()
+	assert.Equal(t, 1, stats.SpillFiles)
+	assert.Zero(t, stats.Replayed)
+
+	atomic.StoreInt32(&client.unavailable, 0)
+	b.replay(context.Background())
+	stats = b.Stats()
+	assert.Equal(t, 25, client.count())
+	assert.Equal(t, int64(25), stats.Replayed)
+	assert.Zero(t, stats.SpillFiles)
+	assert.Zero(t, stats.Dropped)
+}
+
+func TestWriteBufferDropInvalidRecord(t *testing.T) {
+	client := &fakeDataDB{invalid: testRecord(3).Table}
+	b := newWriteBuffer(logger.NewMockClient(), client, config.WriteBufferInfo{
+		BatchSize:     10,
+		FlushInterval: 10,
+		SpillPath:     t.TempDir(),
+	})
+	cancel, wg := runWriteBuffer(b)
+	for i := 0; i < 10; i++ {
+		require.True(t, b.Enqueue(testRecord(i)))
+	}
+	cancel()
+	wg.Wait()
+
+	// 关闭后同步写入
+	assert.False(t, b.Enqueue(testRecord(0)))
+	stats := b.Stats()
+	assert.Equal(t, 9, client.count())
+	assert.Equal(t, int64(1), stats.Dropped)
+	assert.Zero(t, stats.Spilled)
+}
+
+func TestWriteBufferReplayDropInvalidBatch(t *testing.T) {
+	client := &fakeDataDB{invalid: testRecord(1).Table}
+	b := newWriteBuffer(logger.NewMockClient(), client, config.WriteBufferInfo{
+		BatchSize: 10,
+		SpillPath: t.TempDir(),
+	})
+	// 第一个落盘文件中的记录全部无法写入，第二个文件可以写入
+	require.NoError(t, b.spill.write([]dtos.DataRecord{testRecord(1), testRecord(1), testRecord(1)}))
+	b.spill.closeFile()
+	require.NoError(t, b.spill.write([]dtos.DataRecord{testRecord(2)}))
+
+	b.replay(context.Background())
+	stats := b.Stats()
+	assert.Equal(t, int64(3), stats.Dropped)
+	assert.Equal(t, int64(1), stats.Replayed)
+	assert.Equal(t, 1, client.count())
+	assert.Zero(t, stats.SpillFiles)
+}
+
+const (
+	benchDevices = 10000
+	// benchLatency 模拟 tdengine 单条语句的网络和执行耗时
+	benchLatency = time.Millisecond
+)
+
+// BenchmarkSyncWrite 10k 设备并发上报，每条上报同步执行一条 INSERT
+func BenchmarkSyncWrite(b *testing.B) {
+	client := &fakeDataDB{latency: benchLatency}
+	var device int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			record := testRecord(int(atomic.AddInt64(&device, 1) % benchDevices))
+			if err := client.BatchInsert(context.Background(), []dtos.DataRecord{record}); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+// BenchmarkBufferedWrite 10k 设备并发上报，写缓冲批量写入，计时包含缓冲全部写入的时间
+func BenchmarkBufferedWrite(b *testing.B) {
+	client := &fakeDataDB{latency: benchLatency}
+	buffer := newWriteBuffer(logger.NewMockClient(), client, config.WriteBufferInfo{
+		Size:          benchDevices,
+		BatchSize:     1000,
+		FlushInterval: 100,
+	})
+	cancel, wg := runWriteBuffer(buffer)
+	var device int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buffer.Enqueue(testRecord(int(atomic.AddInt64(&device, 1) % benchDevices)))
+		}
+	})
+	cancel()
+	wg.Wait()
+	b.StopTimer()
+	if client.count() != b.N {
+		b.Fatalf("written %d records, want %d", client.count(), b.N)
+	}
+}
+
+func newBenchTstorage(b *testing.B) interfaces.DataDBClient {
+	client, err := tstorage.NewClient(dtos.Configuration{DataSource: b.TempDir() + "/data/"}, logger.NewMockClient())
+	require.NoError(b, err)
+	return client
+}
+
+// BenchmarkTstorageSyncInsert 10k 设备的数据逐条写入 tstorage
+func BenchmarkTstorageSyncInsert(b *testing.B) {
+	client := newBenchTstorage(b)
+	defer client.CloseSession()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		record := testRecord(i % benchDevices)
+		if err := client.Insert(context.Background(), record.Table, record.Fields()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkTstorageBatchInsert 10k 设备的数据按 1000 条一批写入 tstorage
+func BenchmarkTstorageBatchInsert(b *testing.B) {
+	client := newBenchTstorage(b)
+	defer client.CloseSession()
+	batch := make([]dtos.DataRecord, 0, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch = append(batch, testRecord(i%benchDevices))
+		if len(batch) == cap(batch) || i == b.N-1 {
+			if err := client.BatchInsert(context.Background(), batch); err != nil {
+				b.Fatal(err)
+			}
+			batch = batch[:0]
+		}
+	}
+}
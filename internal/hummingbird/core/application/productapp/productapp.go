@@ -17,6 +17,7 @@ package productapp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/winc-link/hummingbird/internal/dtos"
 	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
 	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
@@ -128,6 +129,7 @@ func (p *productApp) ProductDelete(ctx context.Context, id string) error {
 	if err = p.dbClient.AssociationsDeleteProductObject(productInfo); err != nil {
 		return err
 	}
+	resourceContainer.DeviceItfFrom(p.dic.Get).ProductThingModelChanged(productInfo.Id)
 	_ = resourceContainer.DataDBClientFrom(p.dic.Get).DropStable(ctx, productInfo.Id)
 	go func() {
 		p.DeleteProductCallBack(models.Product{
@@ -157,6 +159,13 @@ func (p *productApp) AddProduct(ctx context.Context, req dtos.ProductAddRequest)
 			properties, events, actions = dtos.GetModelPropertyEventActionByThingModelTemplate(thingModelTemplateInfo.ThingModelJSON)
 		}
 	}
+	validateMode := constants.ThingModelValidateMode(req.ValidateMode)
+	if !validateMode.IsValid() {
+		return "", errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("validate mode(%s) is invalid", req.ValidateMode))
+	}
+	if err = p.checkRetention(req.Retention); err != nil {
+		return "", err
+	}
 	secret := utils.GenerateDeviceSecret(15)
 	var insertProduct models.Product
 	insertProduct.Id = utils.RandomNum()
@@ -169,6 +178,9 @@ func (p *productApp) AddProduct(ctx context.Context, req dtos.ProductAddRequest)
 	insertProduct.DataFormat = req.DataFormat
 	insertProduct.Factory = req.Factory
 	insertProduct.Description = req.Description
+	insertProduct.KeepAlive = req.KeepAlive
+	insertProduct.ValidateMode = validateMode
+	insertProduct.Retention = req.Retention
 	insertProduct.Key = secret
 	insertProduct.Status = constants.ProductUnRelease
 	insertProduct.Properties = properties
@@ -185,6 +197,18 @@ func (p *productApp) AddProduct(ctx context.Context, req dtos.ProductAddRequest)
 	return ps.Id, nil
 }
 
+// checkRetention 校验产品的保存天数。tstorage 只有打开时按全局配置设置的保存时间，
+// 不能按产品删除数据，不允许产品覆盖全局配置
+func (p *productApp) checkRetention(retention models.DataRetention) error {
+	if err := retention.Check(); err != nil {
+		return errort.NewCommonErr(errort.DefaultReqParamsError, err)
+	}
+	if !retention.IsZero() && resourceContainer.DataDBClientFrom(p.dic.Get).GetDataDBType() == constants.Tstorage {
+		return errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("%s does not support product retention", constants.Tstorage))
+	}
+	return nil
+}
+
 func (p *productApp) ProductRelease(ctx context.Context, productId string) error {
 
 	var err error
@@ -204,7 +228,7 @@ func (p *productApp) ProductRelease(ctx context.Context, productId string) error
 		return err
 	}
 	productInfo.Status = constants.ProductRelease
-	return p.dbClient.UpdateProduct(productInfo)
+	return p.updateProduct(productInfo)
 }
 
 func (p *productApp) ProductUnRelease(ctx context.Context, productId string) error {
@@ -220,7 +244,16 @@ func (p *productApp) ProductUnRelease(ctx context.Context, productId string) err
 		return errors.New("")
 	}
 	productInfo.Status = constants.ProductUnRelease
-	return p.dbClient.UpdateProduct(productInfo)
+	return p.updateProduct(productInfo)
+}
+
+// updateProduct 更新产品并使上报消息处理缓存的产品物模型失效
+func (p *productApp) updateProduct(product models.Product) error {
+	if err := p.dbClient.UpdateProduct(product); err != nil {
+		return err
+	}
+	resourceContainer.DeviceItfFrom(p.dic.Get).ProductThingModelChanged(product.Id)
+	return nil
 }
 
 func (p *productApp) OpenApiAddProduct(ctx context.Context, req dtos.OpenApiAddProductRequest) (productId string, err error) {
@@ -240,6 +273,13 @@ func (p *productApp) OpenApiAddProduct(ctx context.Context, req dtos.OpenApiAddP
 	//if len(actions) == 0 {
 	//	actions = make([]models.Actions, 0)
 	//}
+	validateMode := constants.ThingModelValidateMode(req.ValidateMode)
+	if !validateMode.IsValid() {
+		return "", errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("validate mode(%s) is invalid", req.ValidateMode))
+	}
+	if err = p.checkRetention(req.Retention); err != nil {
+		return "", err
+	}
 	var insertProduct models.Product
 	insertProduct.Name = req.Name
 	insertProduct.CloudProductId = utils.GenerateDeviceSecret(15)
@@ -250,6 +290,9 @@ func (p *productApp) OpenApiAddProduct(ctx context.Context, req dtos.OpenApiAddP
 	insertProduct.DataFormat = req.DataFormat
 	insertProduct.Factory = req.Factory
 	insertProduct.Description = req.Description
+	insertProduct.KeepAlive = req.KeepAlive
+	insertProduct.ValidateMode = validateMode
+	insertProduct.Retention = req.Retention
 	//insertProduct.Properties = properties
 	//insertProduct.Events = events
 	//insertProduct.Actions = actions
@@ -278,7 +321,7 @@ func (p *productApp) OpenApiUpdateProduct(ctx context.Context, req dtos.OpenApiU
 		product.Protocol = *req.Protocol
 	}
 
-	if req.NetType != nil {
+	if req.NodeType != nil {
 		product.NodeType = constants.ProductNodeType(*req.NodeType)
 	}
 
@@ -298,7 +341,26 @@ func (p *productApp) OpenApiUpdateProduct(ctx context.Context, req dtos.OpenApiU
 		product.Description = *req.Description
 	}
 
-	err = p.dbClient.UpdateProduct(product)
+	if req.KeepAlive != nil {
+		product.KeepAlive = *req.KeepAlive
+	}
+
+	if req.ValidateMode != nil {
+		validateMode := constants.ThingModelValidateMode(*req.ValidateMode)
+		if !validateMode.IsValid() {
+			return errort.NewCommonErr(errort.DefaultReqParamsError, fmt.Errorf("validate mode(%s) is invalid", *req.ValidateMode))
+		}
+		product.ValidateMode = validateMode
+	}
+
+	if req.Retention != nil {
+		if err = p.checkRetention(*req.Retention); err != nil {
+			return err
+		}
+		product.Retention = *req.Retention
+	}
+
+	err = p.updateProduct(product)
 	if err != nil {
 		return err
 	}
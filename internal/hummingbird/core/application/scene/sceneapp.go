@@ -194,6 +194,9 @@ func (p sceneApp) DelSceneById(ctx context.Context, sceneId string) error {
 	case "timer":
 		conJobApp := resourceContainer.ConJobAppNameFrom(p.dic.Get)
 		conJobApp.DeleteJob(scene.Id)
+		if err = p.dbClient.DeleteTimerJobById(scene.Id); err != nil {
+			p.lc.Errorf("delete timer job err %v", err.Error())
+		}
 	case "notify":
 		ekuiperApp := resourceContainer.EkuiperAppFrom(p.dic.Get)
 		err = ekuiperApp.DeleteRule(ctx, sceneId)
@@ -203,7 +206,13 @@ func (p sceneApp) DelSceneById(ctx context.Context, sceneId string) error {
 	default:
 		return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "condition Type value not much", nil)
 	}
-	return p.dbClient.DeleteSceneById(sceneId)
+	if err = p.dbClient.DeleteSceneById(sceneId); err != nil {
+		return err
+	}
+	if err = p.dbClient.DeleteSceneInstanceById(sceneId); err != nil {
+		p.lc.Errorf("delete scene instance err %v", err.Error())
+	}
+	return nil
 }
 
 func (p sceneApp) SceneSearch(ctx context.Context, req dtos.SceneSearchQueryRequest) ([]models.Scene, uint32, error) {
@@ -231,172 +240,169 @@ func (p sceneApp) buildEkuiperSqlAndAction(req dtos.SceneUpdateRequest) (actions
 	option := req.Conditions[0].Option
 	deviceId := option["device_id"]
 	deviceName := option["device_name"]
+	groupId := option["group_id"]
 	productId := option["product_id"]
 	productName := option["product_name"]
 	trigger := option["trigger"]
 	code := option["code"]
-	if deviceId == "" || deviceName == "" || productId == "" || productName == "" || code == "" || trigger == "" {
+	if (groupId == "" && (deviceId == "" || deviceName == "")) || productId == "" || productName == "" || code == "" || trigger == "" {
 		err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required parameter missing", nil)
 		return
 	}
-	device, err := p.dbClient.DeviceById(deviceId)
-	if err != nil {
-		return
-	}
 	product, err := p.dbClient.ProductById(productId)
 	if err != nil {
 		return
 	}
-	if device.ProductId != product.Id {
-		err = errort.NewCommonEdgeX(errort.DefaultSystemError, "", nil)
+	deviceCondition, err := p.sceneDeviceCondition(groupId, deviceId, product.Id)
+	if err != nil {
 		return
 	}
 
 	switch trigger {
 	case string(constants.DeviceDataTrigger):
 		var codeFind bool
-		for _, property := range product.Properties {
-			if code == property.Code {
-				codeFind = true
-				if !property.TypeSpec.Type.AllowSendInEkuiper() {
-					err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required parameter missing", nil)
-				}
+		if _, typeSpec, ok := product.PropertyByPath(code); ok {
+			codeFind = true
+			property, valuePath, alias := dtos.EkuiperPropertyPath(code)
+			if !typeSpec.Type.AllowSendInEkuiper() {
+				err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required parameter missing", nil)
+				return
+			}
 
-				var s int
-				switch option["value_cycle"] {
-				case "1分钟周期":
-					s = 60
-				case "5分钟周期":
-					s = 60 * 5
-				case "15分钟周期":
-					s = 60 * 15
-				case "30分钟周期":
-					s = 60 * 30
-				case "60分钟周期":
-					s = 60 * 60
-				default:
-				}
+			var s int
+			switch option["value_cycle"] {
+			case "1分钟周期":
+				s = 60
+			case "5分钟周期":
+				s = 60 * 5
+			case "15分钟周期":
+				s = 60 * 15
+			case "30分钟周期":
+				s = 60 * 30
+			case "60分钟周期":
+				s = 60 * 60
+			default:
+			}
 
-				switch property.TypeSpec.Type {
+			switch typeSpec.Type {
 
-				case constants.SpecsTypeInt, constants.SpecsTypeFloat:
-					valueType := option["value_type"]
-					if valueType == "" {
-						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_type parameter missing", nil)
+			case constants.SpecsTypeInt, constants.SpecsTypeFloat:
+				valueType := option["value_type"]
+				if valueType == "" {
+					err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_type parameter missing", nil)
+					return
+				}
+				switch valueType {
+				case constants.Original: //原始值
+					decideCondition := option["decide_condition"]
+					if decideCondition == "" {
+						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
 						return
 					}
-					switch valueType {
-					case constants.Original: //原始值
-						decideCondition := option["decide_condition"]
-						if decideCondition == "" {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
-							return
-						}
-						originalTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time ,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s.value") %s`
-						sql = fmt.Sprintf(originalTemp, code, deviceId, code, code, decideCondition)
-						return
-					case constants.Max:
-						sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,max(json_path_query(data, "$.%s.value")) as max_%s FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING max_%s %s`
-						valueCycle := s
-						if valueCycle == 0 {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_cycle parameter missing", nil)
-							return
-						}
-						decideCondition := option["decide_condition"]
-						if decideCondition == "" {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
-							return
-						}
-						sql = fmt.Sprintf(sqlTemp, code, code, device.Id, code, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", valueCycle), code, decideCondition)
-						return
-					case constants.Min:
-						sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,min(json_path_query(data, "$.%s.value")) as min_%s FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING min_%s %s`
-						valueCycle := s
-						if valueCycle == 0 {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_cycle parameter missing", nil)
-							return
-						}
-						decideCondition := option["decide_condition"]
-						if decideCondition == "" {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
-							return
-						}
-						sql = fmt.Sprintf(sqlTemp, code, code, device.Id, code, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", valueCycle), code, decideCondition)
-						return
-					case constants.Sum:
-						sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,sum(json_path_query(data, "$.%s.value")) as sum_%s FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING sum_%s %s`
-						valueCycle := s
-						if valueCycle == 0 {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_cycle parameter missing", nil)
-							return
-						}
-						decideCondition := option["decide_condition"]
-						if decideCondition == "" {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
-							return
-						}
-						sql = fmt.Sprintf(sqlTemp, code, code, device.Id, code, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", valueCycle), code, decideCondition)
-						return
-					case constants.Avg:
-						sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,avg(json_path_query(data, "$.%s.value")) as avg_%s FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING avg_%s %s`
-						valueCycle := s
-						if valueCycle == 0 {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_cycle parameter missing", nil)
-							return
-						}
-						decideCondition := option["decide_condition"]
-						if decideCondition == "" {
-							err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
-							return
-						}
-						sql = fmt.Sprintf(sqlTemp, code, code, device.Id, code, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", valueCycle), code, decideCondition)
+					originalTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time ,deviceId FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s") %s`
+					sql = fmt.Sprintf(originalTemp, property, deviceCondition, valuePath, valuePath, decideCondition)
+					return
+				case constants.Max:
+					sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,max(json_path_query(data, "$.%s")) as max_%s FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING max_%s %s`
+					valueCycle := s
+					if valueCycle == 0 {
+						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_cycle parameter missing", nil)
 						return
 					}
-				case constants.SpecsTypeText:
 					decideCondition := option["decide_condition"]
 					if decideCondition == "" {
 						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
 						return
 					}
-					st := strings.Split(decideCondition, " ")
-					if len(st) != 2 {
+					sql = fmt.Sprintf(sqlTemp, valuePath, alias, deviceCondition, valuePath, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", valueCycle), alias, decideCondition)
+					return
+				case constants.Min:
+					sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,min(json_path_query(data, "$.%s")) as min_%s FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING min_%s %s`
+					valueCycle := s
+					if valueCycle == 0 {
+						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_cycle parameter missing", nil)
 						return
 					}
-					sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s.value") = "%s"`
-					sql = fmt.Sprintf(sqlTemp, code, deviceId, code, code, st[1])
-					return
-
-				case constants.SpecsTypeBool:
 					decideCondition := option["decide_condition"]
 					if decideCondition == "" {
 						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
 						return
 					}
-					st := strings.Split(decideCondition, " ")
-					if len(st) != 2 {
+					sql = fmt.Sprintf(sqlTemp, valuePath, alias, deviceCondition, valuePath, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", valueCycle), alias, decideCondition)
+					return
+				case constants.Sum:
+					sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,sum(json_path_query(data, "$.%s")) as sum_%s FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING sum_%s %s`
+					valueCycle := s
+					if valueCycle == 0 {
+						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_cycle parameter missing", nil)
 						return
 					}
-					sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s.value") = "%s"`
-					if st[1] == "true" {
-						sql = fmt.Sprintf(sqlTemp, code, deviceId, code, code, "1")
-					} else if st[1] == "false" {
-						sql = fmt.Sprintf(sqlTemp, code, deviceId, code, code, "0")
-					}
-					return
-				case constants.SpecsTypeEnum:
 					decideCondition := option["decide_condition"]
 					if decideCondition == "" {
 						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
 						return
 					}
-					st := strings.Split(decideCondition, " ")
-					if len(st) != 2 {
+					sql = fmt.Sprintf(sqlTemp, valuePath, alias, deviceCondition, valuePath, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", valueCycle), alias, decideCondition)
+					return
+				case constants.Avg:
+					sqlTemp := `SELECT window_start(),window_end(),rule_id(),deviceId,avg(json_path_query(data, "$.%s")) as avg_%s FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and json_path_exists(data, "$.%s") = true GROUP BY %s HAVING avg_%s %s`
+					valueCycle := s
+					if valueCycle == 0 {
+						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required value_cycle parameter missing", nil)
+						return
+					}
+					decideCondition := option["decide_condition"]
+					if decideCondition == "" {
+						err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
 						return
 					}
-					sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s.value") = "%s"`
-					sql = fmt.Sprintf(sqlTemp, code, deviceId, code, code, st[1])
+					sql = fmt.Sprintf(sqlTemp, valuePath, alias, deviceCondition, valuePath, fmt.Sprintf("TUMBLINGWINDOW(ss, %d)", valueCycle), alias, decideCondition)
+					return
+				}
+			case constants.SpecsTypeText:
+				decideCondition := option["decide_condition"]
+				if decideCondition == "" {
+					err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
+					return
+				}
+				st := strings.Split(decideCondition, " ")
+				if len(st) != 2 {
+					return
+				}
+				sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s") = "%s"`
+				sql = fmt.Sprintf(sqlTemp, property, deviceCondition, valuePath, valuePath, st[1])
+				return
+
+			case constants.SpecsTypeBool:
+				decideCondition := option["decide_condition"]
+				if decideCondition == "" {
+					err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
 					return
 				}
+				st := strings.Split(decideCondition, " ")
+				if len(st) != 2 {
+					return
+				}
+				sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s") = "%s"`
+				if st[1] == "true" {
+					sql = fmt.Sprintf(sqlTemp, property, deviceCondition, valuePath, valuePath, "1")
+				} else if st[1] == "false" {
+					sql = fmt.Sprintf(sqlTemp, property, deviceCondition, valuePath, valuePath, "0")
+				}
+				return
+			case constants.SpecsTypeEnum:
+				decideCondition := option["decide_condition"]
+				if decideCondition == "" {
+					err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required decide_condition parameter missing", nil)
+					return
+				}
+				st := strings.Split(decideCondition, " ")
+				if len(st) != 2 {
+					return
+				}
+				sqlTemp := `SELECT rule_id(),json_path_query(data, "$.%s.time") as report_time,deviceId FROM mqtt_stream where %s and messageType = "PROPERTY_REPORT" and  json_path_exists(data, "$.%s") = true and json_path_query(data, "$.%s") = "%s"`
+				sql = fmt.Sprintf(sqlTemp, property, deviceCondition, valuePath, valuePath, st[1])
+				return
 			}
 		}
 		if !codeFind {
@@ -407,8 +413,8 @@ func (p sceneApp) buildEkuiperSqlAndAction(req dtos.SceneUpdateRequest) (actions
 		for _, event := range product.Events {
 			if code == event.Code {
 				codeFind = true
-				sqlTemp := `SELECT rule_id(),json_path_query(data, "$.eventTime") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "EVENT_REPORT" and  json_path_exists(data, "$.eventCode") = true and json_path_query(data, "$.eventCode") = "%s"`
-				sql = fmt.Sprintf(sqlTemp, device.Id, code)
+				sqlTemp := `SELECT rule_id(),json_path_query(data, "$.eventTime") as report_time,deviceId FROM mqtt_stream where %s and messageType = "EVENT_REPORT" and  json_path_exists(data, "$.eventCode") = true and json_path_query(data, "$.eventCode") = "%s"`
+				sql = fmt.Sprintf(sqlTemp, deviceCondition, code)
 				return
 			}
 		}
@@ -430,8 +436,8 @@ func (p sceneApp) buildEkuiperSqlAndAction(req dtos.SceneUpdateRequest) (actions
 			err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required status parameter missing", nil)
 			return
 		}
-		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.time") as report_time,deviceId FROM mqtt_stream where deviceId = "%s" and messageType = "DEVICE_STATUS" and  json_path_exists(data, "$.status") = true and json_path_query(data, "$.status") = "%s"`
-		sql = fmt.Sprintf(sqlTemp, device.Id, status)
+		sqlTemp := `SELECT rule_id(),json_path_query(data, "$.time") as report_time,deviceId FROM mqtt_stream where %s and messageType = "DEVICE_STATUS" and  json_path_exists(data, "$.status") = true and json_path_query(data, "$.status") = "%s"`
+		sql = fmt.Sprintf(sqlTemp, deviceCondition, status)
 		return
 	default:
 		err = errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required trigger parameter missing", nil)
@@ -440,6 +446,29 @@ func (p sceneApp) buildEkuiperSqlAndAction(req dtos.SceneUpdateRequest) (actions
 	return
 }
 
+// sceneDeviceCondition 生成场景条件的设备过滤条件，以分组为目标时匹配分组内该产品的全部设备
+func (p sceneApp) sceneDeviceCondition(groupId, deviceId, productId string) (string, error) {
+	if groupId != "" {
+		devices, err := resourceContainer.DeviceItfFrom(p.dic.Get).DeviceGroupDevices(context.Background(), groupId, productId)
+		if err != nil {
+			return "", err
+		}
+		ids := make([]string, 0, len(devices))
+		for _, device := range devices {
+			ids = append(ids, device.Id)
+		}
+		return dtos.EkuiperDeviceCondition(ids...), nil
+	}
+	device, err := p.dbClient.DeviceById(deviceId)
+	if err != nil {
+		return "", err
+	}
+	if device.ProductId != productId {
+		return "", errort.NewCommonEdgeX(errort.DefaultSystemError, "", nil)
+	}
+	return dtos.EkuiperDeviceCondition(device.Id), nil
+}
+
 func (p sceneApp) checkAlertRuleParam(ctx context.Context, scene models.Scene, operate string) error {
 	if operate == "start" {
 		if scene.Status == constants.SceneStart {
@@ -474,25 +503,19 @@ func (p sceneApp) checkAlertRuleParam(ctx context.Context, scene models.Scene, o
 			option := scene.Conditions[0].Option
 			deviceId := option["device_id"]
 			deviceName := option["device_name"]
+			groupId := option["group_id"]
 			productId := option["product_id"]
 			productName := option["product_name"]
 			//trigger := option["trigger"]
 			code := option["code"]
-			if deviceId == "" || deviceName == "" || productId == "" || productName == "" || code == "" || trigger == "" {
+			if (groupId == "" && (deviceId == "" || deviceName == "")) || productId == "" || productName == "" || code == "" || trigger == "" {
 				return errort.NewCommonEdgeX(errort.SceneRuleParamsError, "required parameter missing", nil)
 			}
-			device, err := p.dbClient.DeviceById(deviceId)
-			if err != nil {
-				return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) device not found", scene.Id))
-
-			}
-			product, err := p.dbClient.ProductById(productId)
-			if err != nil {
+			if _, err = p.dbClient.ProductById(productId); err != nil {
 				return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) actions is null", scene.Id))
-
 			}
-			if device.ProductId != product.Id {
-				return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) actions is null", scene.Id))
+			if _, err = p.sceneDeviceCondition(groupId, deviceId, productId); err != nil {
+				return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) device not found", scene.Id))
 			}
 
 		default:
@@ -509,17 +532,22 @@ func (p sceneApp) checkAlertRuleParam(ctx context.Context, scene models.Scene, o
 
 	for _, action := range scene.Actions {
 		//检查产品和设备是否存在
-		device, err := p.dbClient.DeviceById(action.DeviceID)
-		if err != nil {
-			return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) device not found", scene.Id))
-		}
-
 		product, err := p.dbClient.ProductById(action.ProductID)
 		if err != nil {
 			return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) product not found", scene.Id))
 		}
-		if device.ProductId != product.Id {
-			return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) actions is null", scene.Id))
+		if action.DeviceID == "" && action.GroupID != "" {
+			if _, err = p.dbClient.DeviceGroupById(action.GroupID); err != nil {
+				return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) device group not found", scene.Id))
+			}
+		} else {
+			device, err := p.dbClient.DeviceById(action.DeviceID)
+			if err != nil {
+				return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) device not found", scene.Id))
+			}
+			if device.ProductId != product.Id {
+				return errort.NewCommonErr(errort.SceneRuleParamsError, fmt.Errorf("scene id(%s) actions is null", scene.Id))
+			}
 		}
 
 		var find bool
@@ -586,7 +614,14 @@ func (p sceneApp) EkuiperNotify(ctx context.Context, req map[string]interface{})
 	for _, action := range scene.Actions {
 		deviceApp := resourceContainer.DeviceItfFrom(p.dic.Get)
 		execRes := deviceApp.DeviceAction(dtos.JobAction{
-			ProductId: action.ProductID,
+			ProductId:   action.ProductID,
+			ProductName: action.ProductName,
+			DeviceId:    action.DeviceID,
+			DeviceName:  action.DeviceName,
+			GroupId:     action.GroupID,
+			Code:        action.Code,
+			DateType:    action.DataType,
+			Value:       action.Value,
 		})
 		_, err := p.dbClient.AddSceneLog(models.SceneLog{
 			SceneId: scene.Id,
@@ -626,3 +661,68 @@ func (p sceneApp) CheckSceneByDeviceId(ctx context.Context, deviceId string) err
 
 	return nil
 }
+
+// CheckSceneByDeviceGroupId 分组被场景引用时不允许删除
+func (p sceneApp) CheckSceneByDeviceGroupId(ctx context.Context, groupId string) error {
+	scenes, _, err := p.dbClient.SceneSearch(0, -1, dtos.SceneSearchQueryRequest{})
+	if err != nil {
+		return err
+	}
+	for _, scene := range scenes {
+		for _, condition := range scene.Conditions {
+			if condition.Option != nil && condition.Option["group_id"] == groupId {
+				return errort.NewCommonEdgeX(errort.DeviceGroupAssociationRule, "This group has been bound to scene rules. Please edit or delete relevant scene rules before proceeding with the operation", nil)
+			}
+		}
+		for _, action := range scene.Actions {
+			if action.GroupID == groupId {
+				return errort.NewCommonEdgeX(errort.DeviceGroupAssociationRule, "This group has been bound to scene rules. Please edit or delete relevant scene rules before proceeding with the operation", nil)
+			}
+		}
+	}
+	return nil
+}
+
+// RefreshScenesByDeviceGroupId 分组成员变化后重新生成以该分组为触发条件的场景规则 sql，
+// 以分组为执行动作的场景在执行时查询分组成员，无需刷新
+func (p sceneApp) RefreshScenesByDeviceGroupId(ctx context.Context, groupId string) error {
+	scenes, _, err := p.dbClient.SceneSearch(0, -1, dtos.SceneSearchQueryRequest{})
+	if err != nil {
+		return err
+	}
+	ekuiperApp := resourceContainer.EkuiperAppFrom(p.dic.Get)
+	for _, scene := range scenes {
+		if len(scene.Conditions) != 1 || scene.Conditions[0].ConditionType != "notify" ||
+			scene.Conditions[0].Option == nil || scene.Conditions[0].Option["group_id"] != groupId {
+			continue
+		}
+		exist, err := ekuiperApp.RuleExist(ctx, scene.Id)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			continue
+		}
+		actions, sql, err := p.buildEkuiperSqlAndAction(dtos.SceneUpdateRequest{
+			Id: scene.Id,
+			Conditions: []dtos.Condition{{
+				ConditionType: scene.Conditions[0].ConditionType,
+				Option:        scene.Conditions[0].Option,
+			}},
+		})
+		if err != nil {
+			p.lc.Errorf("scene %s rebuild sql err %v", scene.Id, err)
+			continue
+		}
+		if err = ekuiperApp.UpdateRule(ctx, actions, scene.Id, sql); err != nil {
+			return err
+		}
+		// 规则更新后不会自动运行，运行中的场景需要重新启动
+		if scene.Status == constants.SceneStart {
+			if err = ekuiperApp.StartRule(ctx, scene.Id); err != nil {
+				p.lc.Errorf("scene %s start err %v", scene.Id, err)
+			}
+		}
+	}
+	return nil
+}
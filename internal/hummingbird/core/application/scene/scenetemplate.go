@@ -0,0 +1,488 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package scene
+
+import (
+	"context"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/timer/jobs"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"github.com/xuri/excelize/v2"
+	"strings"
+)
+
+const templateDeviceRole = "device_role"
+
+func (p sceneApp) SceneTemplateAdd(ctx context.Context, req dtos.SceneTemplateAddRequest) (string, error) {
+	var template models.SceneTemplate
+	dtos.ReplaceSceneTemplateModelFields(&template, req)
+	if err := p.checkSceneTemplate(&template); err != nil {
+		return "", err
+	}
+	resp, err := p.dbClient.AddSceneTemplate(template)
+	if err != nil {
+		return "", err
+	}
+	return resp.Id, nil
+}
+
+// SceneTemplateUpdate 只更新模板本身，已创建的场景需重新导入或同步后才会应用修改
+func (p sceneApp) SceneTemplateUpdate(ctx context.Context, req dtos.SceneTemplateUpdateRequest) error {
+	if req.Id == "" {
+		return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "update req id is required", nil)
+	}
+	template, err := p.dbClient.SceneTemplateById(req.Id)
+	if err != nil {
+		return err
+	}
+	dtos.ReplaceSceneTemplateModelFields(&template, req.SceneTemplateAddRequest)
+	if err = p.checkSceneTemplate(&template); err != nil {
+		return err
+	}
+	return p.dbClient.UpdateSceneTemplate(template)
+}
+
+func (p sceneApp) SceneTemplateById(ctx context.Context, templateId string) (models.SceneTemplate, error) {
+	return p.dbClient.SceneTemplateById(templateId)
+}
+
+func (p sceneApp) SceneTemplateSearch(ctx context.Context, req dtos.SceneTemplateSearchQueryRequest) ([]models.SceneTemplate, uint32, error) {
+	offset, limit := req.BaseSearchConditionQuery.GetPage()
+	resp, total, err := p.dbClient.SceneTemplateSearch(offset, limit, req)
+	if err != nil {
+		return []models.SceneTemplate{}, 0, err
+	}
+	return resp, total, nil
+}
+
+func (p sceneApp) SceneTemplateDelete(ctx context.Context, templateId string) error {
+	if _, err := p.dbClient.SceneTemplateById(templateId); err != nil {
+		return err
+	}
+	instances, err := p.dbClient.SceneInstancesByTemplateId(templateId)
+	if err != nil {
+		return err
+	}
+	if len(instances) > 0 {
+		return errort.NewCommonEdgeX(errort.SceneTemplateHasInstances,
+			fmt.Sprintf("scene template id(%s) still has %d scenes", templateId, len(instances)), nil)
+	}
+	return p.dbClient.DeleteSceneTemplateById(templateId)
+}
+
+func (p sceneApp) SceneTemplateInstances(ctx context.Context, templateId string) ([]models.SceneInstance, error) {
+	return p.dbClient.SceneInstancesByTemplateId(templateId)
+}
+
+// checkSceneTemplate 校验模板的角色、条件和动作，并补全角色的产品名称
+func (p sceneApp) checkSceneTemplate(template *models.SceneTemplate) error {
+	if template.Name == "" {
+		return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "scene template name is required", nil)
+	}
+	if len(template.Roles) == 0 {
+		return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "scene template roles is required", nil)
+	}
+	roleNames := make(map[string]struct{}, len(template.Roles))
+	for i, role := range template.Roles {
+		if role.Name == "" || role.ProductID == "" {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "role name and product_id are required", nil)
+		}
+		if _, ok := roleNames[role.Name]; ok {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("duplicate role %s", role.Name), nil)
+		}
+		roleNames[role.Name] = struct{}{}
+		product, err := p.dbClient.ProductById(role.ProductID)
+		if err != nil {
+			return err
+		}
+		template.Roles[i].ProductName = product.Name
+	}
+
+	if len(template.Conditions) != 1 {
+		return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "conditions len not eq 1", nil)
+	}
+	option := template.Conditions[0].Option
+	switch template.Conditions[0].ConditionType {
+	case "timer":
+		if _, err := jobs.ParseStandard(option["cron_expression"]); err != nil {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "invalid cron_expression", err)
+		}
+	case "notify":
+		if option == nil || option["trigger"] == "" || option["code"] == "" {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "required parameter missing", nil)
+		}
+		if _, ok := roleNames[option[templateDeviceRole]]; !ok {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError,
+				fmt.Sprintf("condition %s(%s) is not a template role", templateDeviceRole, option[templateDeviceRole]), nil)
+		}
+	default:
+		return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "condition Type value not much", nil)
+	}
+
+	if len(template.Actions) == 0 {
+		return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "scene template actions is required", nil)
+	}
+	for _, action := range template.Actions {
+		if _, ok := roleNames[action.Role]; !ok {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("action role(%s) is not a template role", action.Role), nil)
+		}
+		if action.Code == "" {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "action code is required", nil)
+		}
+	}
+	return nil
+}
+
+// sceneInstancePlan 实例化过程中单个场景的中间结果
+type sceneInstancePlan struct {
+	scene      models.Scene
+	instance   models.SceneInstance
+	exist      bool
+	wasRunning bool
+	ruleSql    string
+	ruleAction []dtos.Actions
+}
+
+// SceneTemplateInstantiate 按模板批量创建或更新场景。
+// 先校验全部实例并解析设备绑定，再停止要更新的运行中场景，随后在一个事务中写入所有场景，
+// 最后创建 ekuiper 规则并按需启动场景。任何实例校验失败都不会产生修改。
+func (p sceneApp) SceneTemplateInstantiate(ctx context.Context, templateId string, req dtos.SceneInstantiateRequest) (dtos.SceneInstantiateResponse, error) {
+	var resp dtos.SceneInstantiateResponse
+	template, err := p.dbClient.SceneTemplateById(templateId)
+	if err != nil {
+		return resp, err
+	}
+	if len(req.Instances) == 0 {
+		return resp, errort.NewCommonEdgeX(errort.DefaultReqParamsError, "instances is required", nil)
+	}
+	existInstances, err := p.dbClient.SceneInstancesByTemplateId(templateId)
+	if err != nil {
+		return resp, err
+	}
+	existByKey := make(map[string]models.SceneInstance, len(existInstances))
+	for _, instance := range existInstances {
+		existByKey[instance.InstanceKey] = instance
+	}
+
+	plans := make([]sceneInstancePlan, 0, len(req.Instances))
+	keys := make(map[string]struct{}, len(req.Instances))
+	for _, item := range req.Instances {
+		item.Key = strings.TrimSpace(item.Key)
+		if item.Key == "" {
+			return resp, errort.NewCommonEdgeX(errort.DefaultReqParamsError, "instance key is required", nil)
+		}
+		if _, ok := keys[item.Key]; ok {
+			return resp, errort.NewCommonEdgeX(errort.DefaultReqParamsError, fmt.Sprintf("duplicate instance key %s", item.Key), nil)
+		}
+		keys[item.Key] = struct{}{}
+
+		plan, err := p.planSceneInstance(template, item, existByKey)
+		if err != nil {
+			return resp, err
+		}
+		plans = append(plans, plan)
+	}
+
+	for _, plan := range plans {
+		if plan.wasRunning {
+			if err = p.SceneStopById(ctx, plan.scene.Id); err != nil {
+				return resp, err
+			}
+		}
+	}
+
+	scenes := make([]models.Scene, 0, len(plans))
+	instances := make([]models.SceneInstance, 0, len(plans))
+	for _, plan := range plans {
+		scenes = append(scenes, plan.scene)
+		instances = append(instances, plan.instance)
+	}
+	if err = p.dbClient.BatchUpsertSceneInstances(scenes, instances); err != nil {
+		for _, plan := range plans {
+			if plan.wasRunning {
+				if startErr := p.SceneStartById(ctx, plan.scene.Id); startErr != nil {
+					p.lc.Errorf("restart scene %s err %v", plan.scene.Id, startErr)
+				}
+			}
+		}
+		return resp, err
+	}
+
+	ekuiperApp := resourceContainer.EkuiperAppFrom(p.dic.Get)
+	for _, plan := range plans {
+		result := dtos.SceneInstanceResult{
+			Key:     plan.instance.InstanceKey,
+			SceneId: plan.scene.Id,
+			Name:    plan.scene.Name,
+			Status:  string(constants.SceneStop),
+		}
+		if plan.exist {
+			resp.Updated++
+		} else {
+			resp.Created++
+		}
+		err = nil
+		if plan.ruleSql != "" {
+			var exist bool
+			exist, err = ekuiperApp.RuleExist(ctx, plan.scene.Id)
+			if err == nil && exist {
+				err = ekuiperApp.UpdateRule(ctx, plan.ruleAction, plan.scene.Id, plan.ruleSql)
+			} else if err == nil {
+				err = ekuiperApp.CreateRule(ctx, plan.ruleAction, plan.scene.Id, plan.ruleSql)
+			}
+		}
+		if err == nil && (req.Start || plan.wasRunning) {
+			if err = p.SceneStartById(ctx, plan.scene.Id); err == nil {
+				result.Status = string(constants.SceneStart)
+			}
+		}
+		if err != nil {
+			p.lc.Errorf("scene instance %s(%s) err %v", plan.instance.InstanceKey, plan.scene.Id, err)
+			result.Error = err.Error()
+		}
+		resp.Instances = append(resp.Instances, result)
+	}
+	return resp, nil
+}
+
+// SceneTemplateSync 使用已保存的设备绑定，将模板的修改应用到所有已创建的场景
+func (p sceneApp) SceneTemplateSync(ctx context.Context, templateId string) (dtos.SceneInstantiateResponse, error) {
+	instances, err := p.dbClient.SceneInstancesByTemplateId(templateId)
+	if err != nil {
+		return dtos.SceneInstantiateResponse{}, err
+	}
+	if len(instances) == 0 {
+		return dtos.SceneInstantiateResponse{}, nil
+	}
+	var req dtos.SceneInstantiateRequest
+	for _, instance := range instances {
+		req.Instances = append(req.Instances, dtos.SceneInstanceItem{
+			Key:      instance.InstanceKey,
+			Name:     instance.Name,
+			Bindings: instance.Bindings,
+		})
+	}
+	return p.SceneTemplateInstantiate(ctx, templateId, req)
+}
+
+func (p sceneApp) planSceneInstance(template models.SceneTemplate, item dtos.SceneInstanceItem, existByKey map[string]models.SceneInstance) (plan sceneInstancePlan, err error) {
+	devices := make(map[string]models.Device, len(template.Roles))
+	bindings := make(models.MapStringString, len(template.Roles))
+	for _, role := range template.Roles {
+		value := strings.TrimSpace(item.Bindings[role.Name])
+		if value == "" {
+			return plan, errort.NewCommonEdgeX(errort.DefaultReqParamsError,
+				fmt.Sprintf("instance %s role %s is not bound", item.Key, role.Name), nil)
+		}
+		device, err := p.resolveRoleDevice(role, value)
+		if err != nil {
+			return plan, errort.NewCommonEdgeX(errort.SceneTemplateBindingNotMatch,
+				fmt.Sprintf("instance %s role %s bind device %s failed", item.Key, role.Name, value), err)
+		}
+		devices[role.Name] = device
+		bindings[role.Name] = device.Id
+	}
+
+	scene := models.Scene{
+		Name:        item.Name,
+		Description: template.Description,
+		Status:      constants.SceneStop,
+	}
+	if scene.Name == "" {
+		scene.Name = template.Name + "-" + item.Key
+	}
+	if exist, ok := existByKey[item.Key]; ok {
+		current, err := p.dbClient.SceneById(exist.Id)
+		if err == nil {
+			plan.exist = true
+			plan.wasRunning = current.Status == constants.SceneStart
+			scene.Created = current.Created
+		}
+		scene.Id = exist.Id
+		plan.instance.Created = exist.Created
+	} else {
+		scene.Id = utils.RandomNum()
+	}
+
+	condition := template.Conditions[0]
+	option := make(map[string]string, len(condition.Option)+4)
+	for k, v := range condition.Option {
+		option[k] = v
+	}
+	if condition.ConditionType == "notify" {
+		role, _ := template.Role(option[templateDeviceRole])
+		device := devices[role.Name]
+		delete(option, templateDeviceRole)
+		option["device_id"] = device.Id
+		option["device_name"] = device.Name
+		option["product_id"] = role.ProductID
+		option["product_name"] = role.ProductName
+	}
+	scene.Conditions = models.Conditions{{ConditionType: condition.ConditionType, Option: option}}
+	for _, action := range template.Actions {
+		role, _ := template.Role(action.Role)
+		device := devices[role.Name]
+		scene.Actions = append(scene.Actions, models.Action{
+			ProductID:   role.ProductID,
+			ProductName: role.ProductName,
+			DeviceID:    device.Id,
+			DeviceName:  device.Name,
+			Code:        action.Code,
+			DataType:    action.DataType,
+			Value:       action.Value,
+		})
+	}
+
+	if condition.ConditionType == "notify" {
+		plan.ruleAction, plan.ruleSql, err = p.buildEkuiperSqlAndAction(dtos.SceneUpdateRequest{
+			Id:         scene.Id,
+			Conditions: []dtos.Condition{{ConditionType: condition.ConditionType, Option: option}},
+		})
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	plan.scene = scene
+	plan.instance.Id = scene.Id
+	plan.instance.TemplateId = template.Id
+	plan.instance.InstanceKey = item.Key
+	plan.instance.Name = item.Name
+	plan.instance.Bindings = bindings
+	return plan, nil
+}
+
+// resolveRoleDevice 按设备ID或角色产品下的设备名称查找设备
+func (p sceneApp) resolveRoleDevice(role models.TemplateRole, value string) (models.Device, error) {
+	device, err := p.dbClient.DeviceById(value)
+	if err == nil && device.ProductId == role.ProductID {
+		return device, nil
+	}
+	var req dtos.DeviceSearchQueryRequest
+	req.BaseSearchConditionQuery.Name = value
+	req.ProductId = role.ProductID
+	devices, _, err := p.dbClient.DevicesSearch(0, -1, req)
+	if err != nil {
+		return models.Device{}, err
+	}
+	if len(devices) != 1 {
+		return models.Device{}, fmt.Errorf("found %d devices named %s in product %s", len(devices), value, role.ProductName)
+	}
+	return devices[0], nil
+}
+
+func setSceneInstanceSheet(file *dtos.ExportFile, template models.SceneTemplate) error {
+	file.Excel.SetSheetName("Sheet1", dtos.SceneInstancesFilename)
+
+	lastCol, err := excelize.ColumnNumberToName(len(template.Roles) + 2)
+	if err != nil {
+		return err
+	}
+	file.Excel.SetCellStyle(dtos.SceneInstancesFilename, "A1", "A1", file.GetCenterStyle())
+	file.Excel.MergeCell(dtos.SceneInstancesFilename, "A1", lastCol+"1")
+	file.Excel.SetCellStr(dtos.SceneInstancesFilename, "A1", template.Name)
+
+	file.Excel.SetCellStr(dtos.SceneInstancesFilename, "A2", "InstanceKey")
+	file.Excel.SetCellStr(dtos.SceneInstancesFilename, "B2", "SceneName")
+	for i, role := range template.Roles {
+		col, err := excelize.ColumnNumberToName(i + 3)
+		if err != nil {
+			return err
+		}
+		file.Excel.SetCellStr(dtos.SceneInstancesFilename, col+"2", role.Name)
+	}
+	return nil
+}
+
+func (p sceneApp) SceneInstanceImportTemplateDownload(ctx context.Context, templateId string) (*dtos.ExportFile, error) {
+	template, err := p.dbClient.SceneTemplateById(templateId)
+	if err != nil {
+		return nil, err
+	}
+	file, err := dtos.NewExportFile(dtos.SceneInstancesFilename)
+	if err != nil {
+		return nil, err
+	}
+	if err = setSceneInstanceSheet(file, template); err != nil {
+		p.lc.Error(err.Error())
+		return nil, err
+	}
+	return file, nil
+}
+
+// SceneInstancesImport 从 Excel 读取实例和设备绑定并实例化，表头第三列起为角色名
+func (p sceneApp) SceneInstancesImport(ctx context.Context, templateId string, file *dtos.ImportFile, start bool) (dtos.SceneInstantiateResponse, error) {
+	template, err := p.dbClient.SceneTemplateById(templateId)
+	if err != nil {
+		return dtos.SceneInstantiateResponse{}, err
+	}
+	rows, err := file.Excel.Rows(dtos.SceneInstancesFilename)
+	if err != nil {
+		return dtos.SceneInstantiateResponse{}, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode, "read rows error", err)
+	}
+	req := dtos.SceneInstantiateRequest{Start: start}
+	var header []string
+	idx := 0
+	for rows.Next() {
+		idx++
+		cols, err := rows.Columns()
+		if err != nil {
+			return dtos.SceneInstantiateResponse{}, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode, "read cols error", err)
+		}
+		if idx == 1 {
+			continue
+		}
+		if idx == 2 {
+			if len(cols) != len(template.Roles)+2 {
+				return dtos.SceneInstantiateResponse{}, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode,
+					fmt.Sprintf("read cols error need len %d,but read len %d", len(template.Roles)+2, len(cols)), nil)
+			}
+			for _, role := range cols[2:] {
+				if _, ok := template.Role(role); !ok {
+					return dtos.SceneInstantiateResponse{}, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorCode,
+						fmt.Sprintf("column %s is not a template role", role), nil)
+				}
+			}
+			header = cols
+			continue
+		}
+
+		// 空行过滤
+		if len(cols) <= 0 || strings.TrimSpace(strings.Join(cols, "")) == "" {
+			continue
+		}
+		if cols[0] == "" {
+			return dtos.SceneInstantiateResponse{}, errort.NewCommonEdgeX(errort.DefaultReadExcelErrorParamsRequiredCode,
+				fmt.Sprintf("read excel params required %+v", "InstanceKey"), nil)
+		}
+		item := dtos.SceneInstanceItem{
+			Key:      cols[0],
+			Bindings: make(map[string]string, len(template.Roles)),
+		}
+		if len(cols) >= 2 {
+			item.Name = cols[1]
+		}
+		for i := 2; i < len(cols) && i < len(header); i++ {
+			item.Bindings[header[i]] = cols[i]
+		}
+		req.Instances = append(req.Instances, item)
+	}
+	return p.SceneTemplateInstantiate(ctx, templateId, req)
+}
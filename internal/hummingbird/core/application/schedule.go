@@ -23,7 +23,24 @@ func InitSchedule(dic *di.Container, lc logger.LoggingClient) {
 		if err != nil {
 			lc.Error("schedule statistic device err:", err)
 		}
+		if err = deviceItf.DeviceConnectLogsClean(context.Background()); err != nil {
+			lc.Error("schedule clean device connect logs err:", err)
+		}
 	})
 
+	// 按保存天数清理过期的时序数据
+	retention := resourceContainer.ConfigurationFrom(dic.Get).Retention
+	if retention.Schedule != "" {
+		_, err := crontab.Schedule.AddFunc(retention.Schedule, func() {
+			lc.Debugf("schedule compact expired data: %v", time.Now().Format("2006-01-02 15:04:05"))
+			if err := resourceContainer.PersistItfFrom(dic.Get).DataRetentionCompact(context.Background()); err != nil {
+				lc.Error("schedule compact expired data err:", err)
+			}
+		})
+		if err != nil {
+			lc.Errorf("add retention schedule(%s) err: %v", retention.Schedule, err)
+		}
+	}
+
 	crontab.Start()
 }
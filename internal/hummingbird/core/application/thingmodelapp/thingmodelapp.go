@@ -74,8 +74,15 @@ func (t thingModelApp) AddThingModel(ctx context.Context, req dtos.ThingModelAdd
 			property.Require = req.Property.Require
 			property.TypeSpec.Type = req.Property.DataType
 			property.TypeSpec.Specs = string(typeSpec)
+			property.Expression = strings.TrimSpace(req.Property.Expression)
+			if req.Property.Scaling != nil {
+				property.Scaling = *req.Property.Scaling
+			}
 		}
 		property.Tag = req.Tag
+		if err = validatorProperty(&property, product.Properties); err != nil {
+			return "", errort.NewCommonEdgeX(errort.DefaultReqParamsError, "param valida error", err)
+		}
 		err = resourceContainer.DataDBClientFrom(t.dic.Get).AddDatabaseField(ctx, req.ProductId, req.Property.DataType, req.Code, req.Name)
 		if err != nil {
 			return "", err
@@ -197,8 +204,15 @@ func (t thingModelApp) UpdateThingModel(ctx context.Context, req dtos.ThingModel
 			property.Require = req.Property.Require
 			property.TypeSpec.Type = req.Property.DataType
 			property.TypeSpec.Specs = string(typeSpec)
+			property.Expression = strings.TrimSpace(req.Property.Expression)
+			if req.Property.Scaling != nil {
+				property.Scaling = *req.Property.Scaling
+			}
 		}
 		property.Tag = req.Tag
+		if err = validatorProperty(&property, product.Properties); err != nil {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "param valida error", err)
+		}
 
 		err = resourceContainer.DataDBClientFrom(t.dic.Get).ModifyDatabaseField(ctx, req.ProductId, req.Property.DataType, req.Code, req.Name)
 		if err != nil {
@@ -327,6 +341,49 @@ func validatorReq(req dtos.ThingModelAddOrUpdateReq, product models.Product) err
 	return nil
 }
 
+// validatorProperty 校验属性的工程值转换和计算属性的表达式，
+// 计算属性只能引用同产品非计算属性的数值字段，计算属性只读
+func validatorProperty(property *models.Properties, properties []models.Properties) error {
+	if property.Scaling.Enabled() {
+		if property.TypeSpec.Type != constants.SpecsTypeInt && property.TypeSpec.Type != constants.SpecsTypeFloat {
+			return fmt.Errorf("property(%s) scaling requires int or float", property.Code)
+		}
+		if property.IsComputed() {
+			return fmt.Errorf("computed property(%s) does not support scaling", property.Code)
+		}
+		if err := property.Scaling.Check(); err != nil {
+			return err
+		}
+	}
+	if !property.IsComputed() {
+		return nil
+	}
+	if property.TypeSpec.Type != constants.SpecsTypeInt && property.TypeSpec.Type != constants.SpecsTypeFloat {
+		return fmt.Errorf("computed property(%s) must be int or float", property.Code)
+	}
+	expression, err := models.ParsePropertyExpression(property.Expression)
+	if err != nil {
+		return err
+	}
+	product := models.Product{Properties: properties}
+	for _, code := range expression.Codes() {
+		ref, typeSpec, ok := product.PropertyByPath(code)
+		if !ok || ref.Code == property.Code {
+			return fmt.Errorf("expression property(%s) not found", code)
+		}
+		if ref.IsComputed() {
+			return fmt.Errorf("expression property(%s) is computed", code)
+		}
+		switch typeSpec.Type {
+		case constants.SpecsTypeInt, constants.SpecsTypeFloat, constants.SpecsTypeBool, constants.SpecsTypeEnum:
+		default:
+			return fmt.Errorf("expression property(%s) is not a number", code)
+		}
+	}
+	property.AccessMode = "R"
+	return nil
+}
+
 func NewThingModelApp(ctx context.Context, dic *di.Container) interfaces.ThingModelItf {
 	lc := container.LoggingClientFrom(dic.Get)
 	dbClient := resourceContainer.DBClientFrom(dic.Get)
@@ -389,6 +446,7 @@ func (t thingModelApp) ThingModelDelete(ctx context.Context, id string, thingMod
 }
 
 func (t thingModelApp) ProductUpdateCallback(productId string) {
+	resourceContainer.DeviceItfFrom(t.dic.Get).ProductThingModelChanged(productId)
 	go func() {
 		productService := resourceContainer.ProductAppNameFrom(t.dic.Get)
 		product, err := productService.ProductModelById(context.Background(), productId)
@@ -421,6 +479,8 @@ func (t thingModelApp) OpenApiQueryThingModel(ctx context.Context, productId str
 			Require:     property.Require,
 			TypeSpec:    property.TypeSpec,
 			Description: property.Description,
+			Expression:  property.Expression,
+			Scaling:     property.Scaling,
 		})
 	}
 
@@ -467,7 +527,7 @@ func (t thingModelApp) OpenApiQueryThingModel(ctx context.Context, productId str
 
 func (t thingModelApp) OpenApiAddThingModel(ctx context.Context, req dtos.OpenApiThingModelAddOrUpdateReq) error {
 
-	_, err := t.dbClient.ProductById(req.ProductId)
+	product, err := t.dbClient.ProductById(req.ProductId)
 	if err != nil {
 		return err
 	}
@@ -488,11 +548,20 @@ func (t thingModelApp) OpenApiAddThingModel(ctx context.Context, req dtos.OpenAp
 			Require:     property.Require,
 			TypeSpec:    property.TypeSpec,
 			Description: property.Description,
+			Expression:  strings.TrimSpace(property.Expression),
+			Scaling:     property.Scaling,
 			Timestamps: models.Timestamps{
 				Created: time.Now().UnixMilli(),
 			},
 		})
 	}
+	// 计算属性可以引用本次一起提交的属性
+	candidates := append(append([]models.Properties{}, properties...), product.Properties...)
+	for i := range properties {
+		if err = validatorProperty(&properties[i], candidates); err != nil {
+			return errort.NewCommonEdgeX(errort.DefaultReqParamsError, "param valida error", err)
+		}
+	}
 
 	for _, event := range req.Events {
 		eventId := event.Id
@@ -587,6 +656,8 @@ func (t thingModelApp) OpenApiDeleteThingModel(ctx context.Context, req dtos.Ope
 	if err != nil {
 		return err
 	}
+	// 部分删除失败时已删除的物模型也需要使缓存失效
+	defer resourceContainer.DeviceItfFrom(t.dic.Get).ProductThingModelChanged(req.ProductId)
 
 	var productPropertyIds []string
 	var productEventIds []string
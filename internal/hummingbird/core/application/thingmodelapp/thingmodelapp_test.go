@@ -0,0 +1,44 @@
+package thingmodelapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+func TestValidatorPropertyExpression(t *testing.T) {
+	float := models.TypeSpec{Type: constants.SpecsTypeFloat}
+	properties := []models.Properties{
+		{Code: "voltage", TypeSpec: float},
+		{Code: "current", TypeSpec: float},
+		{Code: "name", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeText}},
+		{Code: "power", TypeSpec: float, Expression: "voltage*current"},
+	}
+	cases := []struct {
+		code       string
+		expression string
+		valid      bool
+	}{
+		{"energy", "integral(voltage*current)/3600000", true},
+		{"energy", "voltage*unknown", false},
+		{"energy", "voltage+name", false},
+		// 引用自身或其它计算属性会形成依赖环，均不允许
+		{"energy", "energy+1", false},
+		{"energy", "power/1000", false},
+		{"power", "power*2", false},
+		{"energy", "voltage+", false},
+	}
+	for _, c := range cases {
+		property := models.Properties{Code: c.code, TypeSpec: float, Expression: c.expression}
+		all := append(append([]models.Properties{}, properties...), property)
+		err := validatorProperty(&property, all)
+		if c.valid {
+			assert.NoError(t, err, c.expression)
+			assert.Equal(t, "R", property.AccessMode, c.expression)
+		} else {
+			assert.Error(t, err, c.expression)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package thingmodelapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+)
+
+// specsTypeTextWidth 以字符串存储的数据类型及列宽等级，tdengine 只支持加宽 NCHAR 列，
+// 所以只允许在这些类型之间向不更窄的类型修改，其他数据类型修改视为冲突
+var specsTypeTextWidth = map[constants.SpecsType]int{
+	constants.SpecsTypeText:   1,
+	constants.SpecsTypeEnum:   1,
+	constants.SpecsTypeStruct: 2,
+	constants.SpecsTypeArray:  2,
+}
+
+func (t thingModelApp) ThingModelExport(ctx context.Context, req dtos.ThingModelExportRequest) (interface{}, error) {
+	product, err := t.dbClient.ProductById(req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	switch req.Format {
+	case "", constants.ThingModelFormatHummingbird:
+		return dtos.ThingModelFileFromModel(product), nil
+	case constants.ThingModelFormatTSL:
+		return dtos.ThingModelTSLFromModel(product), nil
+	default:
+		return nil, errort.NewCommonEdgeX(errort.DefaultReqParamsError, "param valida error", fmt.Errorf("format %s not supported", req.Format))
+	}
+}
+
+// ThingModelImport 按标识符对比文件和产品现有的自定义功能，返回每个功能的差异。
+// 存在冲突时不导入任何功能；文件中没有的功能保持不变
+func (t thingModelApp) ThingModelImport(ctx context.Context, req dtos.ThingModelImportRequest) (dtos.ThingModelImportResponse, error) {
+	var response dtos.ThingModelImportResponse
+	product, err := t.dbClient.ProductById(req.ProductId)
+	if err != nil {
+		return response, err
+	}
+	file, err := parseThingModelFile(req.Format, req.Content)
+	if err != nil {
+		return response, errort.NewCommonEdgeX(errort.DefaultReqParamsError, "param valida error", err)
+	}
+
+	plan := newThingModelImportPlan(product, file)
+	response.Items = plan.items
+	for _, item := range plan.items {
+		switch item.Action {
+		case constants.ThingModelImportAdd:
+			response.Add++
+		case constants.ThingModelImportUpdate:
+			response.Update++
+		case constants.ThingModelImportUnchanged:
+			response.Unchanged++
+		case constants.ThingModelImportConflict:
+			response.Conflict++
+		}
+	}
+	if !req.Apply || response.Conflict > 0 {
+		return response, nil
+	}
+	if product.Status == constants.ProductRelease {
+		//产品已发布，不能修改物模型
+		return response, errort.NewCommonEdgeX(errort.ProductRelease, "Please cancel publishing the product first before proceeding with the operation", nil)
+	}
+
+	// 所有功能在一个事务中写入，任一功能失败时全部回滚。数据库的列无法随事务回滚，
+	// 本次导入新增的列在失败后删除，加宽的列保留不影响已有数据
+	dataDbClient := resourceContainer.DataDBClientFrom(t.dic.Get)
+	var (
+		changed      bool
+		failed       = -1
+		addedColumns []string
+	)
+	err = t.dbClient.GetDBInstance().Transaction(func(tx *gorm.DB) error {
+		for i := range plan.items {
+			item := plan.items[i]
+			if item.Action != constants.ThingModelImportAdd && item.Action != constants.ThingModelImportUpdate {
+				continue
+			}
+			if err := t.applyThingModelImport(ctx, tx, dataDbClient, product, plan, i, &addedColumns); err != nil {
+				failed = i
+				return err
+			}
+			changed = true
+		}
+		return nil
+	})
+	if err != nil {
+		for _, code := range addedColumns {
+			if delErr := dataDbClient.DelDatabaseField(ctx, product.Id, code); delErr != nil {
+				t.lc.Errorf("rollback thing model import column %s error: %v", code, delErr)
+			}
+		}
+		if failed < 0 {
+			return response, errort.NewCommonEdgeX(errort.DefaultSystemError, "thing model import failed", err)
+		}
+		item := &response.Items[failed]
+		t.lc.Errorf("import thing model %s %s error: %v", item.ThingModelType, item.Code, err)
+		item.Error = err.Error()
+		response.Failed++
+		return response, nil
+	}
+	response.Applied = true
+	if changed {
+		t.ProductUpdateCallback(req.ProductId)
+	}
+	return response, nil
+}
+
+// thingModelObject 属性、事件、服务的表记录
+type thingModelObject interface {
+	TableName() string
+}
+
+// saveThingModelObject 在事务中新增或更新一个功能，与 dbClient 的 AddThingModelXxx/UpdateThingModelXxx 写入方式一致
+func saveThingModelObject(tx *gorm.DB, add bool, object thingModelObject) error {
+	var err error
+	if add {
+		err = tx.Table(object.TableName()).Create(object).Error
+	} else {
+		err = tx.Table(object.TableName()).Select("*").Updates(object).Error
+	}
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "thing model import save failed", err)
+	}
+	return nil
+}
+
+// applyThingModelImport 先写入功能记录再修改数据库的列，列修改失败时由事务回滚记录；
+// 新增的列记录到 addedColumns，导入失败时删除
+func (t thingModelApp) applyThingModelImport(ctx context.Context, tx *gorm.DB, dataDbClient interfaces.DataDBClient, product models.Product, plan thingModelImportPlan, i int, addedColumns *[]string) error {
+	item := plan.items[i]
+	add := item.Action == constants.ThingModelImportAdd
+	ts := utils.MakeTimestamp()
+	addColumn := func(specsType constants.SpecsType, code, name string) error {
+		if err := dataDbClient.AddDatabaseField(ctx, product.Id, specsType, code, name); err != nil {
+			return err
+		}
+		*addedColumns = append(*addedColumns, code)
+		return nil
+	}
+	switch item.ThingModelType {
+	case Property:
+		property := plan.properties[i]
+		property.ProductId = product.Id
+		property.Modified = ts
+		if add {
+			property.Id = utils.RandomNum()
+			property.Created = ts
+			if err := saveThingModelObject(tx, true, &property); err != nil {
+				return err
+			}
+			return addColumn(property.TypeSpec.Type, property.Code, property.Name)
+		}
+		old := plan.existProperties[strings.ToLower(property.Code)]
+		property.Id = old.Id
+		property.Code = old.Code
+		property.Tag = old.Tag
+		property.Created = old.Created
+		if err := saveThingModelObject(tx, false, &property); err != nil {
+			return err
+		}
+		if property.TypeSpec.Type != old.TypeSpec.Type && specsTypeTextWidth[property.TypeSpec.Type] > specsTypeTextWidth[old.TypeSpec.Type] {
+			return dataDbClient.ModifyDatabaseField(ctx, product.Id, property.TypeSpec.Type, property.Code, property.Name)
+		}
+		return nil
+	case Event:
+		event := plan.events[i]
+		event.ProductId = product.Id
+		event.Modified = ts
+		if add {
+			event.Id = utils.RandomNum()
+			event.Created = ts
+			if err := saveThingModelObject(tx, true, &event); err != nil {
+				return err
+			}
+			return addColumn("", event.Code, event.Name)
+		}
+		old := plan.existEvents[strings.ToLower(event.Code)]
+		event.Id = old.Id
+		event.Code = old.Code
+		event.Tag = old.Tag
+		event.Created = old.Created
+		return saveThingModelObject(tx, false, &event)
+	case Action:
+		action := plan.actions[i]
+		action.ProductId = product.Id
+		action.Modified = ts
+		if add {
+			action.Id = utils.RandomNum()
+			action.Created = ts
+			if err := saveThingModelObject(tx, true, &action); err != nil {
+				return err
+			}
+			return addColumn("", action.Code, action.Name)
+		}
+		old := plan.existActions[strings.ToLower(action.Code)]
+		action.Id = old.Id
+		action.Code = old.Code
+		action.Tag = old.Tag
+		action.Created = old.Created
+		return saveThingModelObject(tx, false, &action)
+	}
+	return nil
+}
+
+func parseThingModelFile(format string, content json.RawMessage) (dtos.ThingModelFile, error) {
+	var file dtos.ThingModelFile
+	if len(content) == 0 {
+		return file, fmt.Errorf("content is empty")
+	}
+	switch format {
+	case "", constants.ThingModelFormatHummingbird:
+		if err := json.Unmarshal(content, &file); err != nil {
+			return file, err
+		}
+		return file, nil
+	case constants.ThingModelFormatTSL:
+		var tsl dtos.ThingModelTSL
+		if err := json.Unmarshal(content, &tsl); err != nil {
+			return file, err
+		}
+		return tsl.ToThingModelFile()
+	default:
+		return file, fmt.Errorf("format %s not supported", format)
+	}
+}
+
+// thingModelImportPlan 导入计划，properties/events/actions 以 items 的下标为键
+type thingModelImportPlan struct {
+	items      []dtos.ThingModelImportItem
+	properties map[int]models.Properties
+	events     map[int]models.Events
+	actions    map[int]models.Actions
+
+	existProperties map[string]models.Properties
+	existEvents     map[string]models.Events
+	existActions    map[string]models.Actions
+}
+
+func newThingModelImportPlan(product models.Product, file dtos.ThingModelFile) thingModelImportPlan {
+	plan := thingModelImportPlan{
+		items:           make([]dtos.ThingModelImportItem, 0),
+		properties:      make(map[int]models.Properties),
+		events:          make(map[int]models.Events),
+		actions:         make(map[int]models.Actions),
+		existProperties: make(map[string]models.Properties),
+		existEvents:     make(map[string]models.Events),
+		existActions:    make(map[string]models.Actions),
+	}
+	// 属性、事件、服务共用 tdengine 超级表的列，标识符不区分大小写且不能重复
+	existCodes := make(map[string]string)
+	for _, property := range product.Properties {
+		plan.existProperties[strings.ToLower(property.Code)] = property
+		existCodes[strings.ToLower(property.Code)] = Property
+	}
+	for _, event := range product.Events {
+		plan.existEvents[strings.ToLower(event.Code)] = event
+		existCodes[strings.ToLower(event.Code)] = Event
+	}
+	for _, action := range product.Actions {
+		plan.existActions[strings.ToLower(action.Code)] = action
+		existCodes[strings.ToLower(action.Code)] = Action
+	}
+	fileCodes := make(map[string]string)
+	checkCode := func(thingModelType, code, name string) string {
+		key := strings.ToLower(code)
+		if code == "" || name == "" {
+			return "code or name is empty"
+		}
+		if _, ok := fileCodes[key]; ok {
+			return "code is duplicated in file"
+		}
+		fileCodes[key] = thingModelType
+		if exist, ok := existCodes[key]; ok && exist != thingModelType {
+			return fmt.Sprintf("code is used by %s", exist)
+		}
+		return ""
+	}
+
+	// 计算属性可以引用文件中的属性和产品现有的属性
+	candidates := make([]models.Properties, 0, len(file.Properties)+len(product.Properties))
+	fileProperties := make(map[string]bool, len(file.Properties))
+	for _, property := range file.Properties {
+		candidates = append(candidates, property.ToModel())
+		fileProperties[strings.ToLower(property.Code)] = true
+	}
+	for _, property := range product.Properties {
+		if !fileProperties[strings.ToLower(property.Code)] {
+			candidates = append(candidates, property)
+		}
+	}
+
+	for _, p := range file.Properties {
+		property := p.ToModel()
+		item := dtos.ThingModelImportItem{ThingModelType: Property, Code: property.Code, Name: property.Name}
+		item.Reason = checkCode(Property, property.Code, property.Name)
+		if item.Reason == "" && property.TypeSpec.Type == "" {
+			item.Reason = "data type is empty"
+		}
+		if item.Reason == "" {
+			property.Expression = strings.TrimSpace(property.Expression)
+			if err := validatorProperty(&property, candidates); err != nil {
+				item.Reason = err.Error()
+			}
+		}
+		old, exist := plan.existProperties[strings.ToLower(property.Code)]
+		if item.Reason == "" && exist {
+			if old.System {
+				item.Reason = "system property can not be modified"
+			} else if old.TypeSpec.Type != property.TypeSpec.Type &&
+				(specsTypeTextWidth[old.TypeSpec.Type] == 0 || specsTypeTextWidth[property.TypeSpec.Type] < specsTypeTextWidth[old.TypeSpec.Type]) {
+				item.Reason = fmt.Sprintf("data type can not be changed from %s to %s", old.TypeSpec.Type, property.TypeSpec.Type)
+			}
+		}
+		if item.Reason == "" && exist {
+			item.Changes = thingModelChanges(map[string][2]interface{}{
+				"name":        {old.Name, property.Name},
+				"access_mode": {old.AccessMode, property.AccessMode},
+				"require":     {old.Require, property.Require},
+				"type_spec":   {old.TypeSpec, property.TypeSpec},
+				"description": {old.Description, property.Description},
+				"expression":  {old.Expression, property.Expression},
+				"scaling":     {old.Scaling, property.Scaling},
+			})
+		}
+		plan.properties[len(plan.items)] = property
+		plan.items = append(plan.items, thingModelImportAction(item, exist))
+	}
+
+	for _, e := range file.Events {
+		event := e.ToModel()
+		item := dtos.ThingModelImportItem{ThingModelType: Event, Code: event.Code, Name: event.Name}
+		item.Reason = checkCode(Event, event.Code, event.Name)
+		old, exist := plan.existEvents[strings.ToLower(event.Code)]
+		if item.Reason == "" && exist && old.System {
+			item.Reason = "system event can not be modified"
+		}
+		if item.Reason == "" && exist {
+			item.Changes = thingModelChanges(map[string][2]interface{}{
+				"name":          {old.Name, event.Name},
+				"event_type":    {old.EventType, event.EventType},
+				"require":       {old.Require, event.Require},
+				"description":   {old.Description, event.Description},
+				"output_params": {old.OutputParams, event.OutputParams},
+			})
+		}
+		plan.events[len(plan.items)] = event
+		plan.items = append(plan.items, thingModelImportAction(item, exist))
+	}
+
+	for _, s := range file.Services {
+		action := s.ToModel()
+		item := dtos.ThingModelImportItem{ThingModelType: Action, Code: action.Code, Name: action.Name}
+		item.Reason = checkCode(Action, action.Code, action.Name)
+		old, exist := plan.existActions[strings.ToLower(action.Code)]
+		if item.Reason == "" && exist && old.System {
+			item.Reason = "system action can not be modified"
+		}
+		if item.Reason == "" && exist {
+			item.Changes = thingModelChanges(map[string][2]interface{}{
+				"name":          {old.Name, action.Name},
+				"call_type":     {old.CallType, action.CallType},
+				"require":       {old.Require, action.Require},
+				"description":   {old.Description, action.Description},
+				"input_params":  {old.InputParams, action.InputParams},
+				"output_params": {old.OutputParams, action.OutputParams},
+			})
+		}
+		plan.actions[len(plan.items)] = action
+		plan.items = append(plan.items, thingModelImportAction(item, exist))
+	}
+	return plan
+}
+
+func thingModelImportAction(item dtos.ThingModelImportItem, exist bool) dtos.ThingModelImportItem {
+	switch {
+	case item.Reason != "":
+		item.Action = constants.ThingModelImportConflict
+		item.Changes = nil
+	case !exist:
+		item.Action = constants.ThingModelImportAdd
+	case len(item.Changes) == 0:
+		item.Action = constants.ThingModelImportUnchanged
+	default:
+		item.Action = constants.ThingModelImportUpdate
+	}
+	return item
+}
+
+// thingModelChanges 返回新旧值不同的字段，按字段名排序
+func thingModelChanges(fields map[string][2]interface{}) []string {
+	var changes []string
+	for field, values := range fields {
+		if canonicalJSON(values[0]) != canonicalJSON(values[1]) {
+			changes = append(changes, field)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// canonicalJSON 序列化后比较，specs 等以字符串保存的 json 会先解析，忽略格式和字段顺序的差异
+func canonicalJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return string(b)
+	}
+	b, _ = json.Marshal(expandJSONString(data))
+	return string(b)
+}
+
+func expandJSONString(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = expandJSONString(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = expandJSONString(item)
+		}
+		if len(value) == 0 {
+			return nil
+		}
+		return value
+	case string:
+		s := strings.TrimSpace(value)
+		if strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[") {
+			var data interface{}
+			if err := json.Unmarshal([]byte(s), &data); err == nil {
+				return expandJSONString(data)
+			}
+		}
+		return value
+	default:
+		return value
+	}
+}
@@ -0,0 +1,162 @@
+package thingmodelapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type importDBClient struct {
+	interfaces.DBClient
+	db      *gorm.DB
+	product models.Product
+}
+
+func (c *importDBClient) GetDBInstance() *gorm.DB {
+	return c.db
+}
+
+func (c *importDBClient) ProductById(id string) (models.Product, error) {
+	return c.product, nil
+}
+
+// importDataDBClient 标识符为 fail 的列添加失败
+type importDataDBClient struct {
+	interfaces.DataDBClient
+	added   []string
+	deleted []string
+}
+
+func (c *importDataDBClient) AddDatabaseField(ctx context.Context, tableName string, specsType constants.SpecsType, code string, name string) error {
+	if code == "fail" {
+		return fmt.Errorf("add column %s failed", code)
+	}
+	c.added = append(c.added, code)
+	return nil
+}
+
+func (c *importDataDBClient) DelDatabaseField(ctx context.Context, tableName, code string) error {
+	c.deleted = append(c.deleted, code)
+	return nil
+}
+
+type importDeviceItf struct {
+	interfaces.DeviceItf
+	changed []string
+}
+
+func (d *importDeviceItf) ProductThingModelChanged(productId string) {
+	d.changed = append(d.changed, productId)
+}
+
+type importProductItf struct {
+	interfaces.ProductItf
+}
+
+func (p importProductItf) ProductModelById(ctx context.Context, id string) (models.Product, error) {
+	return models.Product{}, fmt.Errorf("product %s not found", id)
+}
+
+func setupImport(t *testing.T) (thingModelApp, *importDBClient, *importDataDBClient, *importDeviceItf) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Properties{}, &models.Events{}, &models.Actions{}))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// 内存数据库每个连接独立，事务和查询需要使用同一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	existing := models.Properties{Id: "p1", ProductId: "product1", Code: "temperature", Name: "温度", AccessMode: "R",
+		TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat, Specs: "{}"}}
+	require.NoError(t, db.Create(&existing).Error)
+
+	dbClient := &importDBClient{db: db, product: models.Product{Id: "product1", Properties: []models.Properties{existing}}}
+	dataDbClient := &importDataDBClient{}
+	deviceItf := &importDeviceItf{}
+	dic := di.NewContainer(di.ServiceConstructorMap{
+		resourceContainer.DataDBClientInterfaceName: func(get di.Get) interface{} { return dataDbClient },
+		resourceContainer.DeviceItfName:             func(get di.Get) interface{} { return deviceItf },
+		resourceContainer.ProductAppName:            func(get di.Get) interface{} { return importProductItf{} },
+	})
+	app := thingModelApp{dic: dic, dbClient: dbClient, lc: logger.NewMockClient()}
+	return app, dbClient, dataDbClient, deviceItf
+}
+
+func importRequest(t *testing.T, file dtos.ThingModelFile) dtos.ThingModelImportRequest {
+	content, err := json.Marshal(file)
+	require.NoError(t, err)
+	return dtos.ThingModelImportRequest{ProductId: "product1", Apply: true, Content: content}
+}
+
+func TestThingModelImportApply(t *testing.T) {
+	app, dbClient, dataDbClient, deviceItf := setupImport(t)
+	float := models.TypeSpec{Type: constants.SpecsTypeFloat, Specs: "{}"}
+	response, err := app.ThingModelImport(context.Background(), importRequest(t, dtos.ThingModelFile{
+		Properties: []dtos.ThingModelFileProperty{
+			{Code: "temperature", Name: "环境温度", AccessMode: "R", TypeSpec: float},
+			{Code: "humidity", Name: "湿度", AccessMode: "R", TypeSpec: float},
+		},
+		Events: []dtos.ThingModelFileEvent{{Code: "alarm", Name: "告警", EventType: "alert"}},
+	}))
+	require.NoError(t, err)
+	assert.True(t, response.Applied)
+	assert.Equal(t, 2, response.Add)
+	assert.Equal(t, 1, response.Update)
+	assert.Equal(t, 0, response.Failed)
+	assert.Equal(t, []string{"humidity", "alarm"}, dataDbClient.added)
+	assert.Empty(t, dataDbClient.deleted)
+	assert.Equal(t, []string{"product1"}, deviceItf.changed)
+
+	var properties []models.Properties
+	require.NoError(t, dbClient.db.Order("code").Find(&properties).Error)
+	require.Len(t, properties, 2)
+	assert.Equal(t, "humidity", properties[0].Code)
+	assert.Equal(t, "product1", properties[0].ProductId)
+	assert.NotEmpty(t, properties[0].Id)
+	assert.Equal(t, "环境温度", properties[1].Name)
+	var events int64
+	require.NoError(t, dbClient.db.Model(&models.Events{}).Count(&events).Error)
+	assert.Equal(t, int64(1), events)
+}
+
+func TestThingModelImportRollback(t *testing.T) {
+	app, dbClient, dataDbClient, deviceItf := setupImport(t)
+	float := models.TypeSpec{Type: constants.SpecsTypeFloat, Specs: "{}"}
+	response, err := app.ThingModelImport(context.Background(), importRequest(t, dtos.ThingModelFile{
+		Properties: []dtos.ThingModelFileProperty{
+			{Code: "temperature", Name: "环境温度", AccessMode: "R", TypeSpec: float},
+			{Code: "humidity", Name: "湿度", AccessMode: "R", TypeSpec: float},
+		},
+		Events: []dtos.ThingModelFileEvent{{Code: "fail", Name: "失败", EventType: "alert"}},
+	}))
+	require.NoError(t, err)
+	assert.False(t, response.Applied)
+	assert.Equal(t, 1, response.Failed)
+	assert.NotEmpty(t, response.Items[2].Error)
+	assert.Empty(t, response.Items[0].Error)
+	// 已添加的列删除，已写入的功能全部回滚
+	assert.Equal(t, []string{"humidity"}, dataDbClient.deleted)
+	assert.Empty(t, deviceItf.changed)
+
+	var properties []models.Properties
+	require.NoError(t, dbClient.db.Find(&properties).Error)
+	require.Len(t, properties, 1)
+	assert.Equal(t, "温度", properties[0].Name)
+	var events int64
+	require.NoError(t, dbClient.db.Model(&models.Events{}).Count(&events).Error)
+	assert.Equal(t, int64(0), events)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/winc-link/hummingbird/internal/dtos"
 	resourceContainer "github.com/winc-link/hummingbird/internal/hummingbird/core/container"
 	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/container"
 	"github.com/winc-link/hummingbird/internal/pkg/di"
@@ -51,12 +52,18 @@ type EdgeTimer struct {
 	add chan *entry
 	// 更新任务
 	//update chan *jobs.UpdateJobStu
-	// 删除任务 uuid
-	rm chan string
+	// 删除任务，run 协程删除并记录后关闭 done
+	rm chan removeRequest
+	// 获取任务快照
+	snapshot chan chan []entry
 	// 启动标志
 	running  bool
 	location *time.Location
 	f        jobrunner.JobRunFunc
+
+	// 正在执行中的任务及其并发数
+	execMutex sync.Mutex
+	executing map[string]int
 }
 
 func NewCronTimer(ctx context.Context,
@@ -64,16 +71,18 @@ func NewCronTimer(ctx context.Context,
 	dbClient := resourceContainer.DBClientFrom(dic.Get)
 	l := container.LoggingClientFrom(dic.Get)
 	et := &EdgeTimer{
-		logger:   l,
-		db:       dbClient,
-		rm:       make(chan string),
-		add:      make(chan *entry),
-		entries:  nil,
-		jobMap:   make(map[string]struct{}),
-		stop:     make(chan struct{}),
-		running:  false,
-		location: time.Local,
-		f:        f,
+		logger:    l,
+		db:        dbClient,
+		rm:        make(chan removeRequest),
+		add:       make(chan *entry),
+		snapshot:  make(chan chan []entry),
+		entries:   nil,
+		jobMap:    make(map[string]struct{}),
+		stop:      make(chan struct{}),
+		running:   false,
+		location:  time.Local,
+		f:         f,
+		executing: make(map[string]int),
 	}
 	// restore
 	et.restoreJobs()
@@ -97,6 +106,7 @@ func (et *EdgeTimer) restoreJobs() {
 					et.logger.Errorf("restore jobs runtime job err %v", err.Error())
 					continue
 				}
+				et.checkMisfire(job)
 				err = et.AddJobToRunQueue(job)
 				if err != nil {
 					et.logger.Errorf("restore jobs add job to queue err %v", err.Error())
@@ -107,6 +117,82 @@ func (et *EdgeTimer) restoreJobs() {
 	return
 }
 
+// checkMisfire 检查进程停止期间错过的执行，按任务的错过执行策略决定是否补执行一次
+func (et *EdgeTimer) checkMisfire(job *jobs.JobSchedule) {
+	record, err := et.db.TimerJobById(job.JobID)
+	if err != nil || record.NextRunTime == 0 {
+		return
+	}
+	now := et.now()
+	missed := time.UnixMilli(record.NextRunTime).In(et.location)
+	if !missed.Before(now) {
+		return
+	}
+	if !job.Policy.ShouldRunMisfire(now.Sub(missed)) {
+		et.logger.Warnf("job %s missed run at %+v, skipped", job.JobID, missed)
+		return
+	}
+	et.logger.Infof("job %s missed run at %+v, run once on startup", job.JobID, missed)
+	record.LastRunTime = now.UnixMilli()
+	if err = et.db.UpsertTimerJob(record); err != nil {
+		et.logger.Errorf("update timer job %s err %v", job.JobID, err)
+	}
+	go et.exec(*job)
+}
+
+// exec 按任务的并发策略执行任务
+func (et *EdgeTimer) exec(job jobs.JobSchedule) {
+	et.execMutex.Lock()
+	if et.executing[job.JobID] > 0 && job.Policy.ConcurrencyPolicy == string(constants.JobConcurrencyForbid) {
+		et.execMutex.Unlock()
+		et.logger.Warnf("job %s is still running, skipped", job.JobID)
+		if _, err := et.db.AddSceneLog(models.SceneLog{
+			SceneId: job.JobID,
+			Name:    job.JobName,
+			ExecRes: "skipped: previous run is still in progress",
+		}); err != nil {
+			et.logger.Errorf("add sceneLog err %v", err.Error())
+		}
+		return
+	}
+	et.executing[job.JobID]++
+	et.execMutex.Unlock()
+
+	defer func() {
+		et.execMutex.Lock()
+		et.executing[job.JobID]--
+		if et.executing[job.JobID] <= 0 {
+			delete(et.executing, job.JobID)
+		}
+		et.execMutex.Unlock()
+	}()
+	et.f(job.JobID, job)
+}
+
+func (et *EdgeTimer) isExecuting(id string) bool {
+	et.execMutex.Lock()
+	defer et.execMutex.Unlock()
+	return et.executing[id] > 0
+}
+
+// persist 记录任务的上次与下次执行时间
+func (et *EdgeTimer) persist(e *entry) {
+	record := models.TimerJob{
+		Id:         e.JobID,
+		Name:       e.Schedule.JobName,
+		Expression: e.Schedule.TimeData.Expression,
+	}
+	if !e.Prev.IsZero() {
+		record.LastRunTime = e.Prev.UnixMilli()
+	}
+	if !e.Next.IsZero() {
+		record.NextRunTime = e.Next.UnixMilli()
+	}
+	if err := et.db.UpsertTimerJob(record); err != nil {
+		et.logger.Errorf("update timer job %s err %v", e.JobID, err)
+	}
+}
+
 func (et *EdgeTimer) Stop() {
 	et.mutex.Lock()
 	defer et.mutex.Unlock()
@@ -160,6 +246,7 @@ func (et *EdgeTimer) run() {
 		if next, b := entry.Schedule.Next(now); !b {
 			entry.Next = next
 		}
+		et.persist(entry)
 	}
 	var timer = time.NewTimer(100000 * time.Hour)
 	for {
@@ -186,20 +273,16 @@ func (et *EdgeTimer) run() {
 					break
 				}
 				// async call
-				go et.f(e.JobID, *et.entries[i].Schedule)
-
-				//times := e.Schedule.ScheduleAdd1()
+				go et.exec(*et.entries[i].Schedule)
 
-				if false {
-					//finished = append(finished, i)
+				e.Prev = e.Next
+				if next, b := e.Schedule.Next(now); !b {
+					e.Next = next
+					et.logger.Infof("run now: %+v, entry: jobId: %s, jobName: %s, next: %+v", now, e.JobID, e.Schedule.JobName, e.Next)
 				} else {
-					e.Prev = e.Next
-					if next, b := e.Schedule.Next(now); !b {
-						e.Next = next
-						et.logger.Infof("run now: %+v, entry: jobId: %s, jobName: %s, next: %+v", now, e.JobID, e.Schedule.JobName, e.Next)
-					}
-					//}
+					e.Next = time.Time{}
 				}
+				et.persist(e)
 			}
 
 		case newEntry := <-et.add:
@@ -208,13 +291,19 @@ func (et *EdgeTimer) run() {
 			if next, b := newEntry.Schedule.Next(now); !b {
 				newEntry.Next = next
 				et.entries = append(et.entries, newEntry)
+				et.persist(newEntry)
 				et.logger.Infof("added job now: %+v, next: %+v", now, newEntry.Next)
 			}
 			et.logger.Infof("added job: %v, now: %+v, next: %+v", newEntry.JobID, now, newEntry.Next)
-		case entryID := <-et.rm:
+		case req := <-et.rm:
+			timer.Stop()
+			now = et.now()
+			et.removeEntry(req.id)
+			close(req.done)
+		case replyChan := <-et.snapshot:
 			timer.Stop()
 			now = et.now()
-			et.removeEntry(entryID)
+			replyChan <- et.entrySnapshot()
 		case <-et.stop:
 			timer.Stop()
 			et.logger.Info("tedge timer stopped...")
@@ -224,12 +313,15 @@ func (et *EdgeTimer) run() {
 }
 
 func (et *EdgeTimer) schedule(schedule *jobs.JobSchedule) {
-	et.mutex.Lock()
-	defer et.mutex.Unlock()
 	entry := &entry{
 		JobID:    schedule.GetJobId(),
 		Schedule: schedule,
 	}
+	if record, err := et.db.TimerJobById(entry.JobID); err == nil && record.LastRunTime > 0 {
+		entry.Prev = time.UnixMilli(record.LastRunTime).In(et.location)
+	}
+	et.mutex.Lock()
+	defer et.mutex.Unlock()
 	if !et.running {
 		et.entries = append(et.entries, entry)
 	} else {
@@ -237,9 +329,18 @@ func (et *EdgeTimer) schedule(schedule *jobs.JobSchedule) {
 	}
 }
 
+// removeRequest 删除任务的请求
+type removeRequest struct {
+	id   string
+	done chan struct{}
+}
+
+// remove 等待任务删除并记录后返回，避免调用方随后删除的任务记录被重新写入
 func (et *EdgeTimer) remove(id string) {
 	if et.running {
-		et.rm <- id
+		done := make(chan struct{})
+		et.rm <- removeRequest{id: id, done: done}
+		<-done
 	} else {
 		et.removeEntry(id)
 	}
@@ -257,6 +358,9 @@ func (et *EdgeTimer) removeEntry(id string) {
 		}
 	}
 	if b {
+		removed := et.entries[len(et.entries)-1]
+		removed.Next = time.Time{}
+		et.persist(removed)
 		et.entries[len(et.entries)-1] = nil
 		et.entries = et.entries[:len(et.entries)-1]
 		delete(et.jobMap, id)
@@ -286,3 +390,66 @@ func (et *EdgeTimer) AddJobToRunQueue(j *jobs.JobSchedule) error {
 	et.jobMap[j.JobID] = struct{}{}
 	return nil
 }
+
+// entrySnapshot 复制当前的任务列表，仅在 run 协程或 timer 未启动时调用
+func (et *EdgeTimer) entrySnapshot() []entry {
+	entries := make([]entry, 0, len(et.entries))
+	for _, e := range et.entries {
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+// JobList 返回当前调度中的任务及其接下来的执行时间
+func (et *EdgeTimer) JobList(upcoming int) []dtos.TimerJobResponse {
+	var entries []entry
+	et.mutex.Lock()
+	if et.running {
+		et.mutex.Unlock()
+		replyChan := make(chan []entry, 1)
+		et.snapshot <- replyChan
+		entries = <-replyChan
+	} else {
+		entries = et.entrySnapshot()
+		et.mutex.Unlock()
+	}
+
+	list := make([]dtos.TimerJobResponse, 0, len(entries))
+	for _, e := range entries {
+		job := dtos.TimerJobResponse{
+			Id:                e.JobID,
+			Name:              e.Schedule.JobName,
+			Expression:        e.Schedule.TimeData.Expression,
+			MisfirePolicy:     e.Schedule.Policy.MisfirePolicy,
+			MisfireThreshold:  e.Schedule.Policy.MisfireThreshold,
+			ConcurrencyPolicy: e.Schedule.Policy.ConcurrencyPolicy,
+			Running:           et.isExecuting(e.JobID),
+			FireTimes:         make([]int64, 0, upcoming),
+		}
+		if !e.Prev.IsZero() {
+			job.LastRunTime = e.Prev.UnixMilli()
+		}
+		next := e.Next
+		for i := 0; i < upcoming && !next.IsZero(); i++ {
+			job.FireTimes = append(job.FireTimes, next.UnixMilli())
+			var end bool
+			if next, end = e.Schedule.Next(next); end {
+				break
+			}
+		}
+		if !e.Next.IsZero() {
+			job.NextRunTime = e.Next.UnixMilli()
+		}
+		list = append(list, job)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].NextRunTime == 0 {
+			return false
+		}
+		if list[j].NextRunTime == 0 {
+			return true
+		}
+		return list[i].NextRunTime < list[j].NextRunTime
+	})
+	return list
+}
@@ -14,13 +14,11 @@ import (
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/infrastructure/mysql"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/infrastructure/sqlite"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
-	"github.com/winc-link/hummingbird/internal/tools/datadb/tdengine"
-	"github.com/winc-link/hummingbird/internal/tools/datadb/tstorage"
+	"github.com/winc-link/hummingbird/internal/tools/datadb"
 
 	"github.com/winc-link/hummingbird/internal/pkg/di"
 	"github.com/winc-link/hummingbird/internal/pkg/logger"
 	"github.com/winc-link/hummingbird/internal/pkg/startup"
-	"github.com/winc-link/hummingbird/internal/tools/datadb/leveldb"
 	"sync"
 
 	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
@@ -39,8 +37,8 @@ func NewDatabase(database *config.ConfigurationStruct) Database {
 	}
 }
 
-// Return the dbClient interfaces
-func (d Database) newDBClient(
+// NewDBClient returns the dbClient interfaces, also used by the migrate command
+func (d Database) NewDBClient(
 	lc logger.LoggingClient) (interfaces.DBClient, error) {
 
 	databaseInfo := d.database.GetDatabaseInfo()["Primary"]
@@ -63,24 +61,8 @@ func (d Database) newDBClient(
 func (d Database) newDataDBClient(
 	lc logger.LoggingClient) (interfaces.DataDBClient, error) {
 	dataDbInfo := d.database.GetDataDatabaseInfo()["Primary"]
-
-	switch dataDbInfo.Type {
-	case string(constants.LevelDB):
-		return leveldb.NewClient(dtos.Configuration{
-			DataSource: dataDbInfo.DataSource,
-		}, lc)
-	case string(constants.Tstorage):
-		return tstorage.NewClient(dtos.Configuration{
-			DataSource: dataDbInfo.DataSource,
-		}, lc)
-	case string(constants.TDengine):
-		return tdengine.NewClient(dtos.Configuration{
-			Dsn: dataDbInfo.Dsn,
-		}, lc)
-	default:
-		panic(errors.New("database configuration error"))
-
-	}
+	// tstorage 只能在打开时设置保存时间，使用全局配置中最长的保存天数
+	return datadb.NewClient(dataDbInfo, d.database.Retention.DataRetention().Max(), lc)
 }
 
 // BootstrapHandler fulfills the BootstrapHandler contract and initializes the database.
@@ -92,7 +74,7 @@ func (d Database) BootstrapHandler(
 	lc := pkgContainer.LoggingClientFrom(dic.Get)
 
 	// initialize Metadata db.
-	dbClient, err := d.newDBClient(lc)
+	dbClient, err := d.NewDBClient(lc)
 	if err != nil {
 		panic(err)
 	}
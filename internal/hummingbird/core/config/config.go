@@ -19,6 +19,7 @@ import (
 
 	"go.uber.org/atomic"
 
+	"github.com/winc-link/hummingbird/internal/models"
 	bootstrapConfig "github.com/winc-link/hummingbird/internal/pkg/config"
 )
 
@@ -39,6 +40,12 @@ type ConfigurationStruct struct {
 	WebServer           bootstrapConfig.ServiceInfo
 	DockerManage        DockerManage
 	ApplicationSettings ApplicationSettings
+	Retention           RetentionInfo
+	LatestValue         LatestValueInfo
+	WriteBuffer         WriteBufferInfo
+	DataMigration       DataMigrationInfo
+	DataExport          DataExportInfo
+	MessageBuffer       MessageBufferInfo
 	Topics              struct {
 		CommandTopic TopicInfo
 	}
@@ -103,6 +110,83 @@ type ApplicationSettings struct {
 	TedgeNumber     string
 }
 
+// RetentionInfo 时序数据的全局保存天数，0表示永久保存，产品可按消息类型覆盖
+type RetentionInfo struct {
+	Property int
+	Event    int
+	Service  int
+	// Schedule 过期数据清理任务的 cron 表达式
+	Schedule string
+}
+
+// LatestValueInfo 设备属性最新值缓存
+type LatestValueInfo struct {
+	// SnapshotPath 快照文件路径，启动时从快照加载，为空时不保存快照
+	SnapshotPath string
+	// SnapshotInterval 保存快照的间隔(秒)
+	SnapshotInterval int
+}
+
+// WriteBufferInfo tdengine、tstorage 和 sqlite 的写缓冲，Enable 为 false 时在上报时同步写入
+type WriteBufferInfo struct {
+	Enable bool
+	// Size 缓冲的最大记录数，缓冲已满时上报阻塞等待
+	Size int
+	// BatchSize 单次批量写入的记录数
+	BatchSize int
+	// FlushInterval 缓冲中的数据不足一批时的最长等待时间(毫秒)
+	FlushInterval int
+	// SpillPath 写入失败的数据落盘目录
+	SpillPath string
+	// ReplayInterval 重放落盘数据的间隔(秒)
+	ReplayInterval int
+}
+
+// DataMigrationInfo 时序库之间的历史数据迁移
+type DataMigrationInfo struct {
+	// CheckpointPath 迁移进度文件，中断后从该位置继续
+	CheckpointPath string
+	// PageSize 每个时间窗口从源库读取的目标记录数，窗口时长按读到的条数调整，最多两倍
+	PageSize int
+}
+
+// DataExportInfo 历史数据导出
+type DataExportInfo struct {
+	// Dir 导出文件的目录，服务启动时删除上次运行留下的导出文件
+	Dir string
+	// Expire 导出完成后任务和文件保留的分钟数，过期后定时删除
+	Expire int
+	// MaxRunning 同时运行的导出任务数上限，超过时拒绝新的导出
+	MaxRunning int
+	// PageSize 每个标识符每个时间窗口从时序库读取的目标记录数，导出时每个标识符在内存中最多保存一个窗口
+	PageSize int
+}
+
+// MessageBufferInfo 推送到消息总线的消息先写入磁盘队列，broker 确认后删除，broker 不可用期间的消息在恢复连接后按顺序重新推送
+type MessageBufferInfo struct {
+	Enable bool
+	// Path 队列的 leveldb 目录
+	Path string
+	// MaxMessages 队列的最大消息数，0 表示不限制
+	MaxMessages int
+	// MaxSize 队列中消息内容的最大总大小(MB)，0 表示不限制
+	MaxSize int
+	// MaxAge 消息在队列中的最长保存时间(秒)，超过后丢弃，0 表示不限制
+	MaxAge int
+	// DropPolicy 队列已满时的丢弃策略，oldest 丢弃最早的消息，newest 丢弃新消息
+	DropPolicy string
+	// BatchSize 每次从磁盘队列读取并连续推送的消息数，确认后一起从队列删除
+	BatchSize int
+}
+
+func (r RetentionInfo) DataRetention() models.DataRetention {
+	return models.DataRetention{
+		Property: r.Property,
+		Event:    r.Event,
+		Service:  r.Service,
+	}
+}
+
 // URL constructs a URL from the protocol, host and port and returns that as a string.
 func (m MessageQueueInfo) URL() string {
 	return fmt.Sprintf("%s://%s:%v", m.Protocol, m.Host, m.Port)
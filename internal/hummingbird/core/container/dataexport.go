@@ -0,0 +1,12 @@
+package container
+
+import (
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+)
+
+var DataExportItfName = di.TypeInstanceToName((*interfaces.DataExportItf)(nil))
+
+func DataExportItfFrom(get di.Get) interfaces.DataExportItf {
+	return get(DataExportItfName).(interfaces.DataExportItf)
+}
@@ -0,0 +1,12 @@
+package container
+
+import (
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+)
+
+var DataMigrationItfName = di.TypeInstanceToName((*interfaces.DataMigrationItf)(nil))
+
+func DataMigrationItfFrom(get di.Get) interfaces.DataMigrationItf {
+	return get(DataMigrationItfName).(interfaces.DataMigrationItf)
+}
@@ -0,0 +1,12 @@
+package container
+
+import (
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+)
+
+var LatestValueItfName = di.TypeInstanceToName((*interfaces.LatestValueItf)(nil))
+
+func LatestValueItfFrom(get di.Get) interfaces.LatestValueItf {
+	return get(LatestValueItfName).(interfaces.LatestValueItf)
+}
@@ -46,6 +46,78 @@ func (c *controller) SystemMetricsHandler(ctx *gin.Context) {
 	httphelper.ResultSuccess(metrics, ctx.Writer, c.lc)
 }
 
+// @Tags 运维管理
+// @Summary 获取时序数据存储统计
+// @Produce json
+// @Success 200 {object} dtos.StorageStats
+// @Router /api/v1/metrics/storage [get]
+func (c *controller) StorageStatsHandler(ctx *gin.Context) {
+	stats, err := c.getSystemMonitorApp().GetStorageStats(ctx)
+	if err != nil {
+		httphelper.RenderFail(ctx, err, ctx.Writer, c.lc)
+		return
+	}
+
+	httphelper.ResultSuccess(stats, ctx.Writer, c.lc)
+}
+
+// @Tags 运维管理
+// @Summary 获取时序数据写缓冲统计
+// @Produce json
+// @Success 200 {object} dtos.WriteBufferStats
+// @Router /api/v1/metrics/write-buffer [get]
+func (c *controller) WriteBufferStatsHandler(ctx *gin.Context) {
+	httphelper.ResultSuccess(c.getPersistApp().WriteBufferStats(), ctx.Writer, c.lc)
+}
+
+// @Tags 运维管理
+// @Summary 获取消息总线磁盘队列统计
+// @Produce json
+// @Success 200 {object} dtos.MessageBufferStats
+// @Router /api/v1/metrics/message-buffer [get]
+func (c *controller) MessageBufferStatsHandler(ctx *gin.Context) {
+	httphelper.ResultSuccess(c.getMessageApp().MessageBufferStats(), ctx.Writer, c.lc)
+}
+
+// @Tags 运维管理
+// @Summary 开始时序库之间的历史数据迁移
+// @Description 迁移在后台运行，进度通过 websocket 推送，resume 为 true 时从上次中断的位置继续
+// @Produce json
+// @Param request body dtos.DataMigrationRequest true "参数"
+// @Success 200 {object} httphelper.CommonResponse
+// @Router /api/v1/data-migration [post]
+func (c *controller) DataMigrationStart(ctx *gin.Context) {
+	var req dtos.DataMigrationRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(ctx, errort.NewCommonErr(errort.DefaultReqParamsError, err), ctx.Writer, c.lc)
+		return
+	}
+	if err := c.getDataMigrationApp().Start(ctx, req); err != nil {
+		httphelper.RenderFail(ctx, err, ctx.Writer, c.lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, ctx.Writer, c.lc)
+}
+
+// @Tags 运维管理
+// @Summary 获取时序库数据迁移进度
+// @Produce json
+// @Success 200 {object} dtos.DataMigrationProgress
+// @Router /api/v1/data-migration [get]
+func (c *controller) DataMigrationProgress(ctx *gin.Context) {
+	httphelper.ResultSuccess(c.getDataMigrationApp().Progress(), ctx.Writer, c.lc)
+}
+
+// @Tags 运维管理
+// @Summary 停止时序库数据迁移
+// @Produce json
+// @Success 200 {object} httphelper.CommonResponse
+// @Router /api/v1/data-migration [delete]
+func (c *controller) DataMigrationStop(ctx *gin.Context) {
+	c.getDataMigrationApp().Stop()
+	httphelper.ResultSuccess(nil, ctx.Writer, c.lc)
+}
+
 // @Tags 运维管理
 // @Summary 操作服务重启
 // @Produce json
@@ -14,6 +14,7 @@ import (
 
 const (
 	UrlParamSceneId         = "sceneId"
+	UrlParamSceneTemplateId = "templateId"
 	UrlParamActionId        = "actionId"
 	UrlParamStrategyId      = "strategyId"
 	UrlParamConditionId     = "conditionId"
@@ -22,6 +23,7 @@ const (
 	UrlParamCategoryKey     = "categoryKey"
 	UrlParamCloudInstanceId = "cloudInstanceId"
 	UrlParamDeviceId        = "deviceId"
+	UrlParamDeviceGroupId   = "groupId"
 	UrlParamFuncPointId     = "funcPointId"
 	UrlParamDeviceLibraryId = "deviceLibraryId"
 	UrlParamDeviceServiceId = "deviceServiceId"
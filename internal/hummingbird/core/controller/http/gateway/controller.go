@@ -71,6 +71,10 @@ func (ctl *controller) getPersistApp() interfaces.PersistItf {
 	return container.PersistItfFrom(ctl.dic.Get)
 }
 
+func (ctl *controller) getMessageApp() interfaces.MessageItf {
+	return container.MessageItfFrom(ctl.dic.Get)
+}
+
 func (ctl *controller) getCategoryTemplateApp() interfaces.CategoryApp {
 	return container.CategoryTemplateAppFrom(ctl.dic.Get)
 }
@@ -114,3 +118,11 @@ func (ctl *controller) getDataResourceApp() interfaces.DataResourceApp {
 func (ctl *controller) getSceneApp() interfaces.SceneApp {
 	return container.SceneAppNameFrom(ctl.dic.Get)
 }
+
+func (ctl *controller) getDataMigrationApp() interfaces.DataMigrationItf {
+	return container.DataMigrationItfFrom(ctl.dic.Get)
+}
+
+func (ctl *controller) getDataExportApp() interfaces.DataExportItf {
+	return container.DataExportItfFrom(ctl.dic.Get)
+}
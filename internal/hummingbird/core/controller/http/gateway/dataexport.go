@@ -0,0 +1,78 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package gateway
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+)
+
+// @Tags    设备管理
+// @Summary 创建历史数据导出任务
+// @Description 导出产品下设备的属性、事件或服务历史数据，属性每个标识符一列，立即返回任务ID
+// @Produce json
+// @Param   request body     dtos.DataExportRequest true "参数"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/devices/data-export [post]
+func (ctl *controller) DataExport(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DataExportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	jobId, edgeXErr := ctl.getDataExportApp().DataExport(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(jobId, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 查询历史数据导出任务
+// @Produce json
+// @Param   jobId path     string true "任务ID"
+// @Success 200   {object} dtos.DataExportJobResponse
+// @Router  /api/v1/devices/data-export/:jobId [get]
+func (ctl *controller) DataExportJobById(c *gin.Context) {
+	lc := ctl.lc
+	jobId := c.Param(UrlParamJobId)
+	data, edgeXErr := ctl.getDataExportApp().DataExportJobById(c, jobId)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 下载历史数据导出文件
+// @Produce octet-stream
+// @Param   jobId path     string true "任务ID"
+// @Success 200   {object} string
+// @Router  /api/v1/devices/data-export/:jobId/download [get]
+func (ctl *controller) DataExportDownload(c *gin.Context) {
+	lc := ctl.lc
+	jobId := c.Param(UrlParamJobId)
+	path, fileName, edgeXErr := ctl.getDataExportApp().DataExportFile(c, jobId)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	c.FileAttachment(path, fileName)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/errort"
 	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+	"strings"
 )
 
 // @Tags    设备管理
@@ -184,14 +185,7 @@ func (ctl *controller) DeviceImportTemplateDownload(c *gin.Context) {
 // @Router /api/v1/device/upload-validated [post]
 func (ctl *controller) UploadValidated(c *gin.Context) {
 	lc := ctl.lc
-	files, _ := c.FormFile("file")
-	f, err := files.Open()
-	if err != nil {
-		err = errort.NewCommonErr(errort.DefaultUploadFileErrorCode, err)
-		httphelper.RenderFail(c, err, c.Writer, lc)
-		return
-	}
-	file, edgeXErr := dtos.NewImportFile(f)
+	file, edgeXErr := deviceImportFile(c)
 	if edgeXErr != nil {
 		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
 		return
@@ -218,14 +212,7 @@ func (ctl *controller) DevicesImport(c *gin.Context) {
 	var req dtos.DevicesImport
 	urlDecodeParam(&req, c.Request, lc)
 
-	files, _ := c.FormFile("file")
-	f, err := files.Open()
-	if err != nil {
-		err = errort.NewCommonErr(errort.DefaultUploadFileErrorCode, err)
-		httphelper.RenderFail(c, err, c.Writer, lc)
-		return
-	}
-	file, edgeXErr := dtos.NewImportFile(f)
+	file, edgeXErr := deviceImportFile(c)
 	if edgeXErr != nil {
 		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
 		return
@@ -239,6 +226,51 @@ func (ctl *controller) DevicesImport(c *gin.Context) {
 	httphelper.ResultSuccess(result, c.Writer, lc)
 }
 
+// deviceImportFile 读取上传的设备表格，支持 xlsx 和 csv
+func deviceImportFile(c *gin.Context) (*dtos.ImportFile, error) {
+	files, err := c.FormFile("file")
+	if err != nil {
+		return nil, errort.NewCommonErr(errort.DefaultUploadFileErrorCode, err)
+	}
+	f, err := files.Open()
+	if err != nil {
+		return nil, errort.NewCommonErr(errort.DefaultUploadFileErrorCode, err)
+	}
+	defer f.Close()
+	if strings.HasSuffix(strings.ToLower(files.Filename), "."+constants.DeviceExportFormatCSV) {
+		return dtos.NewImportFileFromCSV(f, dtos.DevicesFilename)
+	}
+	return dtos.NewImportFile(f)
+}
+
+// @Tags    设备管理
+// @Summary 设备导出
+// @Produce json
+// @Param   request query    dtos.DeviceExportRequest true "参数"
+// @Success 200     {object} string
+// @Router  /api/v1/devices/export [get]
+func (ctl *controller) DevicesExport(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceExportRequest
+	urlDecodeParam(&req, c.Request, lc)
+	file, edgeXErr := ctl.getDeviceApp().DevicesExport(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	if req.Format == constants.DeviceExportFormatCSV {
+		data, err := file.WriteCSVToBuffer(dtos.DevicesFilename)
+		if err != nil {
+			httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultSystemError, err), c.Writer, lc)
+			return
+		}
+		httphelper.ResultExcelData(c, file.CSVFileName(), data)
+		return
+	}
+	data, _ := file.Excel.WriteToBuffer()
+	httphelper.ResultExcelData(c, file.FileName, data)
+}
+
 // @Tags    设备管理
 // @Summary 更新设备
 // @Produce json
@@ -337,6 +369,12 @@ func (ctl *controller) DeviceThingModelPropertyDataSearch(c *gin.Context) {
 	httphelper.ResultSuccess(data, c.Writer, lc)
 }
 
+// @Tags    设备管理
+// @Summary 查看设备属性历史数据，interval 不为空时按时间窗口聚合
+// @Produce json
+// @Param   request query   dtos.ThingModelPropertyDataRequest true "参数"
+// @Success 200     {array} []dtos.ReportData
+// @Router /api/v1/device/:deviceId/thing-model/history-property [get]
 func (ctl *controller) DeviceThingModelHistoryPropertyDataSearch(c *gin.Context) {
 	lc := ctl.lc
 	var req dtos.ThingModelPropertyDataRequest
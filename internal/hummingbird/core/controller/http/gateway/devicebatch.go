@@ -0,0 +1,81 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package gateway
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+)
+
+// @Tags    设备管理
+// @Summary 批量设置设备属性
+// @Produce json
+// @Param   request body     dtos.DeviceBatchPropertySetRequest true "参数"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/devices/batch-property-set [post]
+func (ctl *controller) DeviceBatchPropertySet(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceBatchPropertySetRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	jobId, edgeXErr := ctl.getDeviceApp().DeviceBatchPropertySet(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(jobId, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 批量调用设备服务
+// @Produce json
+// @Param   request body     dtos.DeviceBatchServiceInvokeRequest true "参数"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/devices/batch-service-invoke [post]
+func (ctl *controller) DeviceBatchServiceInvoke(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceBatchServiceInvokeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	jobId, edgeXErr := ctl.getDeviceApp().DeviceBatchServiceInvoke(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(jobId, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 查询批量下发任务结果
+// @Produce json
+// @Param   jobId path     string true "任务ID"
+// @Success 200   {object} dtos.DeviceBatchJobResponse
+// @Router  /api/v1/devices/batch-job/:jobId [get]
+func (ctl *controller) DeviceBatchJobById(c *gin.Context) {
+	lc := ctl.lc
+	jobId := c.Param(UrlParamJobId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceBatchJobById(c, jobId)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
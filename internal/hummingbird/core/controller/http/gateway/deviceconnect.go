@@ -0,0 +1,115 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package gateway
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+)
+
+// @Tags    设备管理
+// @Summary 查询设备上下线记录
+// @Produce json
+// @Param   deviceId path    string                                  true "设备ID"
+// @Param   request  query   dtos.DeviceConnectLogSearchQueryRequest true "参数"
+// @Success 200      {array} []dtos.DeviceConnectLogResponse
+// @Router  /api/v1/device/:deviceId/connect-logs [get]
+func (ctl *controller) DeviceConnectLogsSearch(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceConnectLogSearchQueryRequest
+	urlDecodeParam(&req, c.Request, lc)
+	dtos.CorrectionPageParam(&req.BaseSearchConditionQuery)
+	req.DeviceId = c.Param(UrlParamDeviceId)
+	data, total, edgeXErr := ctl.getDeviceApp().DeviceConnectLogsSearch(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	pageResult := httphelper.NewPageResult(data, total, req.Page, req.PageSize)
+	httphelper.ResultSuccess(pageResult, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 查询设备在线率统计
+// @Produce json
+// @Param   deviceId path     string                         true "设备ID"
+// @Param   request  query    dtos.DeviceAvailabilityRequest true "参数"
+// @Success 200      {object} dtos.DeviceAvailabilityResponse
+// @Router  /api/v1/device/:deviceId/availability [get]
+func (ctl *controller) DeviceAvailability(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceAvailabilityRequest
+	urlDecodeParam(&req, c.Request, lc)
+	req.DeviceId = c.Param(UrlParamDeviceId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceAvailability(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    产品管理
+// @Summary 查询产品下设备的在线率统计
+// @Produce json
+// @Param   productId path     string                         true "产品ID"
+// @Param   request   query    dtos.DeviceAvailabilityRequest true "参数"
+// @Success 200       {object} dtos.ProductAvailabilityResponse
+// @Router  /api/v1/product/:productId/availability [get]
+func (ctl *controller) ProductAvailability(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceAvailabilityRequest
+	urlDecodeParam(&req, c.Request, lc)
+	req.ProductId = c.Param(UrlParamProductId)
+	data, edgeXErr := ctl.getDeviceApp().ProductAvailability(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 查询设备上报数据物模型校验不合法统计
+// @Produce json
+// @Param   deviceId path     string true "设备ID"
+// @Success 200      {object} dtos.DeviceInvalidSampleResponse
+// @Router  /api/v1/device/:deviceId/invalid-samples [get]
+func (ctl *controller) DeviceInvalidSample(c *gin.Context) {
+	lc := ctl.lc
+	data, edgeXErr := ctl.getDeviceApp().DeviceInvalidSample(c, c.Param(UrlParamDeviceId))
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 清零设备上报数据物模型校验不合法统计
+// @Produce json
+// @Param   deviceId path     string true "设备ID"
+// @Success 200      {object} httphelper.CommonResponse
+// @Router  /api/v1/device/:deviceId/invalid-samples [delete]
+func (ctl *controller) DeviceInvalidSampleReset(c *gin.Context) {
+	lc := ctl.lc
+	edgeXErr := ctl.getDeviceApp().DeviceInvalidSampleReset(c, c.Param(UrlParamDeviceId))
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
@@ -0,0 +1,291 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package gateway
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+)
+
+// @Tags    设备分组
+// @Summary 新增设备分组
+// @Produce json
+// @Param   request body     dtos.DeviceGroupAddRequest true "参数"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/device-group [post]
+func (ctl *controller) DeviceGroupAdd(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceGroupAddRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	id, edgeXErr := ctl.getDeviceApp().DeviceGroupAdd(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(id, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 修改设备分组
+// @Produce json
+// @Param   request body     dtos.DeviceGroupUpdateRequest true "参数"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/device-group [put]
+func (ctl *controller) DeviceGroupUpdate(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceGroupUpdateRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	edgeXErr := ctl.getDeviceApp().DeviceGroupUpdate(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 查询设备分组详情
+// @Produce json
+// @Param   groupId path     string true "分组ID"
+// @Success 200     {object} dtos.DeviceGroupResponse
+// @Router  /api/v1/device-group/:groupId [get]
+func (ctl *controller) DeviceGroupById(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamDeviceGroupId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceGroupById(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 查询设备分组列表
+// @Produce json
+// @Param   request query   dtos.DeviceGroupSearchQueryRequest true "参数"
+// @Success 200     {array} []dtos.DeviceGroupResponse
+// @Router  /api/v1/device-groups [get]
+func (ctl *controller) DeviceGroupsSearch(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceGroupSearchQueryRequest
+	urlDecodeParam(&req, c.Request, lc)
+	dtos.CorrectionPageParam(&req.BaseSearchConditionQuery)
+	data, total, edgeXErr := ctl.getDeviceApp().DeviceGroupsSearch(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	pageResult := httphelper.NewPageResult(data, total, req.Page, req.PageSize)
+	httphelper.ResultSuccess(pageResult, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 删除设备分组
+// @Produce json
+// @Param   groupId path     string true "分组ID"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/device-group/:groupId [delete]
+func (ctl *controller) DeviceGroupDelete(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamDeviceGroupId)
+	edgeXErr := ctl.getDeviceApp().DeviceGroupDelete(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 查询分组内的设备
+// @Produce json
+// @Param   groupId path    string                        true "分组ID"
+// @Param   request query   dtos.DeviceSearchQueryRequest true "参数"
+// @Success 200     {array} []dtos.DeviceSearchQueryResponse
+// @Router  /api/v1/device-group/:groupId/devices [get]
+func (ctl *controller) DeviceGroupDevicesSearch(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceSearchQueryRequest
+	urlDecodeParam(&req, c.Request, lc)
+	dtos.CorrectionPageParam(&req.BaseSearchConditionQuery)
+	req.GroupId = c.Param(UrlParamDeviceGroupId)
+	if _, edgeXErr := ctl.getDeviceApp().DeviceGroupById(c, req.GroupId); edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	data, total, edgeXErr := ctl.getDeviceApp().DevicesSearch(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	pageResult := httphelper.NewPageResult(data, total, req.Page, req.PageSize)
+	httphelper.ResultSuccess(pageResult, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 静态分组添加设备
+// @Produce json
+// @Param   groupId path     string                         true "分组ID"
+// @Param   request body     dtos.DeviceGroupMembersRequest true "参数"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/device-group/:groupId/devices [post]
+func (ctl *controller) DeviceGroupMembersAdd(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceGroupMembersRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.GroupId = c.Param(UrlParamDeviceGroupId)
+	edgeXErr := ctl.getDeviceApp().DeviceGroupMembersAdd(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 静态分组移除设备
+// @Produce json
+// @Param   groupId path     string                         true "分组ID"
+// @Param   request body     dtos.DeviceGroupMembersRequest true "参数"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/device-group/:groupId/devices [delete]
+func (ctl *controller) DeviceGroupMembersRemove(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceGroupMembersRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.GroupId = c.Param(UrlParamDeviceGroupId)
+	edgeXErr := ctl.getDeviceApp().DeviceGroupMembersRemove(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 分组设备属性下发
+// @Produce json
+// @Param   groupId path    string                             true "分组ID"
+// @Param   request body    dtos.DeviceGroupPropertySetRequest true "参数"
+// @Success 200     {array} []dtos.DeviceGroupOperateResult
+// @Router  /api/v1/device-group/:groupId/property-set [post]
+func (ctl *controller) DeviceGroupPropertySet(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceGroupPropertySetRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.GroupId = c.Param(UrlParamDeviceGroupId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceGroupPropertySet(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 分组设备服务调用
+// @Produce json
+// @Param   groupId path    string                               true "分组ID"
+// @Param   request body    dtos.DeviceGroupServiceInvokeRequest true "参数"
+// @Success 200     {array} []dtos.DeviceGroupOperateResult
+// @Router  /api/v1/device-group/:groupId/service-invoke [post]
+func (ctl *controller) DeviceGroupServiceInvoke(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceGroupServiceInvokeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.GroupId = c.Param(UrlParamDeviceGroupId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceGroupServiceInvoke(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 分组设备绑定驱动
+// @Produce json
+// @Param   groupId path     string                            true "分组ID"
+// @Param   request body     dtos.DeviceGroupBindDriverRequest true "参数"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/device-group/:groupId/bind-driver [put]
+func (ctl *controller) DeviceGroupBindDriver(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceGroupBindDriverRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.GroupId = c.Param(UrlParamDeviceGroupId)
+	edgeXErr := ctl.getDeviceApp().DeviceGroupBindDriver(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 删除分组内的全部设备
+// @Produce json
+// @Param   groupId path     string true "分组ID"
+// @Success 200     {object} httphelper.CommonResponse
+// @Router  /api/v1/device-group/:groupId/delete-devices [delete]
+func (ctl *controller) DeviceGroupDeleteDevices(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamDeviceGroupId)
+	edgeXErr := ctl.getDeviceApp().DeviceGroupDeleteDevices(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+// @Tags    设备分组
+// @Summary 查询设备标签
+// @Produce json
+// @Success 200 {array} []dtos.DeviceTagResponse
+// @Router  /api/v1/device-tags [get]
+func (ctl *controller) DeviceTags(c *gin.Context) {
+	lc := ctl.lc
+	data, edgeXErr := ctl.getDeviceApp().DeviceTags(c)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
@@ -0,0 +1,86 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package gateway
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+	"strings"
+)
+
+// @Tags    设备管理
+// @Summary 查询设备影子
+// @Produce json
+// @Param   deviceId path     string true "设备ID"
+// @Success 200      {object} dtos.DeviceShadowResponse
+// @Router  /api/v1/device/:deviceId/shadow [get]
+func (ctl *controller) DeviceShadowById(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamDeviceId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceShadowById(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 修改设备影子期望值
+// @Produce json
+// @Param   deviceId path     string                          true "设备ID"
+// @Param   request  body     dtos.DeviceShadowDesiredRequest true "参数"
+// @Success 200      {object} dtos.DeviceShadowResponse
+// @Router  /api/v1/device/:deviceId/shadow [put]
+func (ctl *controller) DeviceShadowUpdate(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceShadowDesiredRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.DeviceId = c.Param(UrlParamDeviceId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceShadowUpdateDesired(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 清除设备影子期望值
+// @Produce json
+// @Param   deviceId path     string                                true "设备ID"
+// @Param   request  query    dtos.DeviceShadowDesiredDeleteRequest true "参数"
+// @Success 200      {object} dtos.DeviceShadowResponse
+// @Router  /api/v1/device/:deviceId/shadow/desired [delete]
+func (ctl *controller) DeviceShadowDesiredDelete(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceShadowDesiredDeleteRequest
+	urlDecodeParam(&req, c.Request, lc)
+	var codes []string
+	if req.Codes != "" {
+		codes = strings.Split(req.Codes, ",")
+	}
+	data, edgeXErr := ctl.getDeviceApp().DeviceShadowDeleteDesired(c, c.Param(UrlParamDeviceId), codes)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
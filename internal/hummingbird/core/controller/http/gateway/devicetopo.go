@@ -0,0 +1,91 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package gateway
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+)
+
+// @Tags    设备管理
+// @Summary 查询网关子设备列表
+// @Produce json
+// @Param   deviceId path    string                        true "网关设备ID"
+// @Param   request  query   dtos.DeviceSearchQueryRequest true "参数"
+// @Success 200      {array} []dtos.DeviceSearchQueryResponse
+// @Router  /api/v1/device/:deviceId/sub-devices [get]
+func (ctl *controller) DeviceSubDevicesSearch(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceSearchQueryRequest
+	urlDecodeParam(&req, c.Request, lc)
+	dtos.CorrectionPageParam(&req.BaseSearchConditionQuery)
+	req.ParentId = c.Param(UrlParamDeviceId)
+	req.Tree = false
+	data, total, edgeXErr := ctl.getDeviceApp().DevicesSearch(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	pageResult := httphelper.NewPageResult(data, total, req.Page, req.PageSize)
+	httphelper.ResultSuccess(pageResult, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 添加网关子设备
+// @Produce json
+// @Param   deviceId path     string                 true "网关设备ID"
+// @Param   request  body     dtos.DeviceTopoRequest true "参数"
+// @Success 200      {object} httphelper.CommonResponse
+// @Router  /api/v1/device/:deviceId/sub-devices [post]
+func (ctl *controller) DeviceSubDevicesAdd(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceTopoRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.ParentId = c.Param(UrlParamDeviceId)
+	edgeXErr := ctl.getDeviceApp().DeviceSubDevicesAdd(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+// @Tags    设备管理
+// @Summary 移除网关子设备
+// @Produce json
+// @Param   deviceId path     string                 true "网关设备ID"
+// @Param   request  body     dtos.DeviceTopoRequest true "参数"
+// @Success 200      {object} httphelper.CommonResponse
+// @Router  /api/v1/device/:deviceId/sub-devices [delete]
+func (ctl *controller) DeviceSubDevicesRemove(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceTopoRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.ParentId = c.Param(UrlParamDeviceId)
+	edgeXErr := ctl.getDeviceApp().DeviceSubDevicesRemove(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
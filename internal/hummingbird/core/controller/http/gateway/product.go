@@ -124,6 +124,30 @@ func (ctl *controller) ProductAdd(c *gin.Context) {
 	httphelper.ResultSuccess(nil, c.Writer, lc)
 }
 
+// @Tags 产品管理
+// @Summary 更新产品
+// @Produce json
+// @Param productId path string true "产品ID"
+// @Param request body dtos.OpenApiUpdateProductRequest true "参数"
+// @Success 200  {object} httphelper.CommonResponse
+// @Router  /api/v1/product/:productId [put]
+// @Security ApiKeyAuth
+func (ctl *controller) ProductUpdate(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.OpenApiUpdateProductRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.Id = c.Param(UrlParamProductId)
+	edgeXErr := ctl.getProductApp().OpenApiUpdateProduct(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
 // @Tags 产品管理
 // @Summary 云平台列表
 // @Produce json
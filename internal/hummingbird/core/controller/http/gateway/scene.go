@@ -17,6 +17,7 @@ package gateway
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
 	"github.com/winc-link/hummingbird/internal/pkg/errort"
 	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
 )
@@ -128,6 +129,17 @@ func (ctl *controller) DeleteScene(c *gin.Context) {
 	httphelper.ResultSuccess(nil, c.Writer, lc)
 }
 
+func (ctl *controller) SceneJobs(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.TimerJobSearchQueryRequest
+	urlDecodeParam(&req, c.Request, lc)
+	if req.Upcoming <= 0 {
+		req.Upcoming = 5
+	}
+	list := container.ConJobAppNameFrom(ctl.dic.Get).JobList(req.Upcoming)
+	httphelper.ResultSuccess(list, c.Writer, lc)
+}
+
 func (ctl *controller) SceneLog(c *gin.Context) {
 	lc := ctl.lc
 	id := c.Param(UrlParamSceneId)
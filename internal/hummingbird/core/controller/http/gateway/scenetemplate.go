@@ -0,0 +1,170 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package gateway
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+)
+
+func (ctl *controller) SceneTemplateAdd(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.SceneTemplateAddRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	id, edgeXErr := ctl.getSceneApp().SceneTemplateAdd(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(id, c.Writer, lc)
+}
+
+func (ctl *controller) SceneTemplateUpdate(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.SceneTemplateUpdateRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	edgeXErr := ctl.getSceneApp().SceneTemplateUpdate(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+func (ctl *controller) SceneTemplateById(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamSceneTemplateId)
+	template, edgeXErr := ctl.getSceneApp().SceneTemplateById(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(template, c.Writer, lc)
+}
+
+func (ctl *controller) SceneTemplateSearch(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.SceneTemplateSearchQueryRequest
+	urlDecodeParam(&req, c.Request, lc)
+	dtos.CorrectionPageParam(&req.BaseSearchConditionQuery)
+
+	list, total, edgeXErr := ctl.getSceneApp().SceneTemplateSearch(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	pageResult := httphelper.NewPageResult(list, total, req.Page, req.PageSize)
+	httphelper.ResultSuccess(pageResult, c.Writer, lc)
+}
+
+func (ctl *controller) SceneTemplateDelete(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamSceneTemplateId)
+	edgeXErr := ctl.getSceneApp().SceneTemplateDelete(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(nil, c.Writer, lc)
+}
+
+func (ctl *controller) SceneTemplateInstances(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamSceneTemplateId)
+	list, edgeXErr := ctl.getSceneApp().SceneTemplateInstances(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(list, c.Writer, lc)
+}
+
+func (ctl *controller) SceneTemplateInstantiate(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamSceneTemplateId)
+	var req dtos.SceneInstantiateRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	result, edgeXErr := ctl.getSceneApp().SceneTemplateInstantiate(c, id, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(result, c.Writer, lc)
+}
+
+func (ctl *controller) SceneTemplateSync(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamSceneTemplateId)
+	result, edgeXErr := ctl.getSceneApp().SceneTemplateSync(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(result, c.Writer, lc)
+}
+
+func (ctl *controller) SceneInstanceImportTemplateDownload(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamSceneTemplateId)
+	file, edgeXErr := ctl.getSceneApp().SceneInstanceImportTemplateDownload(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	data, _ := file.Excel.WriteToBuffer()
+	httphelper.ResultExcelData(c, file.FileName, data)
+}
+
+func (ctl *controller) SceneInstancesImport(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamSceneTemplateId)
+	var req dtos.SceneInstanceImportRequest
+	urlDecodeParam(&req, c.Request, lc)
+
+	files, err := c.FormFile("file")
+	if err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultUploadFileErrorCode, err), c.Writer, lc)
+		return
+	}
+	f, err := files.Open()
+	if err != nil {
+		err = errort.NewCommonErr(errort.DefaultUploadFileErrorCode, err)
+		httphelper.RenderFail(c, err, c.Writer, lc)
+		return
+	}
+	defer f.Close()
+	file, edgeXErr := dtos.NewImportFile(f)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	result, edgeXErr := ctl.getSceneApp().SceneInstancesImport(c, id, file, req.Start)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(result, c.Writer, lc)
+}
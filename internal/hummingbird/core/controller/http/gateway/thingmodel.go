@@ -106,6 +106,47 @@ func (ctl *controller) ThingModelDelete(c *gin.Context) {
 	httphelper.ResultSuccess(nil, c.Writer, lc)
 }
 
+// @Tags 物模型
+// @Summary 导出产品物模型
+// @Produce json
+// @Param request query dtos.ThingModelExportRequest true "参数"
+// @Success 200  {object} dtos.ThingModelFile
+// @Router  /api/v1/thingmodel/export [get]
+// @Security ApiKeyAuth
+func (ctl *controller) ThingModelExport(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.ThingModelExportRequest
+	urlDecodeParam(&req, c.Request, lc)
+	data, edgeXErr := ctl.getThingModelApp().ThingModelExport(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// @Tags 物模型
+// @Summary 导入产品物模型
+// @Produce json
+// @Param request body dtos.ThingModelImportRequest true "参数"
+// @Success 200  {object} dtos.ThingModelImportResponse
+// @Router  /api/v1/thingmodel/import [post]
+// @Security ApiKeyAuth
+func (ctl *controller) ThingModelImport(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.ThingModelImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	data, edgeXErr := ctl.getThingModelApp().ThingModelImport(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
 // @Tags 物模型
 // @Summary 物模型单位
 // @Produce json
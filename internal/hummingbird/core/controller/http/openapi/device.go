@@ -99,3 +99,30 @@ func (ctl *controller) OpenApiDeviceStatus(c *gin.Context) {
 	}
 	httphelper.ResultSuccess(data, c.Writer, lc)
 }
+
+func (ctl *controller) OpenApiDeviceShadowById(c *gin.Context) {
+	lc := ctl.lc
+	id := c.Param(UrlParamDeviceId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceShadowById(c, id)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+func (ctl *controller) OpenApiUpdateDeviceShadow(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceShadowDesiredRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	req.DeviceId = c.Param(UrlParamDeviceId)
+	data, edgeXErr := ctl.getDeviceApp().DeviceShadowUpdateDesired(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
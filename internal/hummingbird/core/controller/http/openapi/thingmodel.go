@@ -63,6 +63,35 @@ func (ctl *controller) OpenApiDeleteThingModel(c *gin.Context) {
 	httphelper.ResultSuccess(nil, c.Writer, lc)
 }
 
+// OpenApiExportThingModel 导出产品物模型
+func (ctl *controller) OpenApiExportThingModel(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.ThingModelExportRequest
+	urlDecodeParam(&req, c.Request, lc)
+	data, edgeXErr := ctl.getThingModelApp().ThingModelExport(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// OpenApiImportThingModel 导入产品物模型
+func (ctl *controller) OpenApiImportThingModel(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.ThingModelImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	data, edgeXErr := ctl.getThingModelApp().ThingModelImport(c, req)
+	if edgeXErr != nil {
+		httphelper.RenderFail(c, edgeXErr, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
 // OpenApiQueryDeviceEffectivePropertyData 查询设备实时属性
 func (ctl *controller) OpenApiQueryDeviceEffectivePropertyData(c *gin.Context) {
 	lc := ctl.lc
@@ -107,6 +136,50 @@ func (ctl *controller) OpenApiInvokeThingService(c *gin.Context) {
 	httphelper.ResultSuccess(data, c.Writer, lc)
 }
 
+// OpenApiBatchSetDeviceProperty 批量设置设备属性
+func (ctl *controller) OpenApiBatchSetDeviceProperty(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceBatchPropertySetRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	jobId, err := ctl.getDeviceApp().DeviceBatchPropertySet(c, req)
+	if err != nil {
+		httphelper.RenderFail(c, err, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(jobId, c.Writer, lc)
+}
+
+// OpenApiBatchInvokeThingService 批量调用设备服务
+func (ctl *controller) OpenApiBatchInvokeThingService(c *gin.Context) {
+	lc := ctl.lc
+	var req dtos.DeviceBatchServiceInvokeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		httphelper.RenderFail(c, errort.NewCommonErr(errort.DefaultReqParamsError, err), c.Writer, lc)
+		return
+	}
+	jobId, err := ctl.getDeviceApp().DeviceBatchServiceInvoke(c, req)
+	if err != nil {
+		httphelper.RenderFail(c, err, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(jobId, c.Writer, lc)
+}
+
+func (ctl *controller) OpenApiBatchJobById(c *gin.Context) {
+	lc := ctl.lc
+	jobId := c.Param(UrlParamJobId)
+	data, err := ctl.getDeviceApp().DeviceBatchJobById(c, jobId)
+	if err != nil {
+		httphelper.RenderFail(c, err, c.Writer, lc)
+		return
+	}
+	httphelper.ResultSuccess(data, c.Writer, lc)
+}
+
+// OpenApiQueryDevicePropertyData 查询设备属性历史数据，interval 不为空时按时间窗口聚合
 func (ctl *controller) OpenApiQueryDevicePropertyData(c *gin.Context) {
 	lc := ctl.lc
 	var req dtos.ThingModelPropertyDataRequest
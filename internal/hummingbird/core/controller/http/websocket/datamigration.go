@@ -0,0 +1,14 @@
+package websocket
+
+import (
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/httphelper"
+)
+
+// DataMigrationProgress 返回当前的迁移进度，迁移过程中的进度由服务端主动推送
+func DataMigrationProgress(c *wsClient, data interface{}, code dtos.WsCode) {
+	progress := container.DataMigrationItfFrom(c.dic.Get).Progress()
+	c.sendData(code, httphelper.WsResult(errort.DefaultSuccess, progress, "", ""))
+}
@@ -28,4 +28,7 @@ var wsFuncMap = map[dtos.WsCode]wsFunc{
 
 	//多语言
 	dtos.WsCodeCheckLang: CheckLang,
+
+	//时序库数据迁移
+	dtos.WsCodeDataMigration: DataMigrationProgress,
 }
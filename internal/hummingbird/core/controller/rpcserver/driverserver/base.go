@@ -27,4 +27,5 @@ func RegisterRPCService(lc logger.LoggingClient, dic *di.Container, s *grpc.Serv
 	NewGatewayServer(lc, dic).RegisterServer(s)
 	//NewDriverStorageServer(lc, dic).RegisterServer(s)
 	NewProductServer(lc, dic).RegisterServer(s)
+	NewTopologyServer(lc, dic).RegisterServer(s)
 }
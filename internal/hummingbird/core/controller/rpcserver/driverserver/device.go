@@ -26,6 +26,7 @@ import (
 	"github.com/winc-link/hummingbird/internal/pkg/errort"
 	"github.com/winc-link/hummingbird/internal/pkg/logger"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"strconv"
 )
 
@@ -59,9 +60,17 @@ func (s *DriverDeviceServer) QueryDeviceList(ctx context.Context, request *devic
 	} else {
 		platform = string(constants.IotPlatform_LocalIot)
 	}
+	// 请求消息中没有父设备字段，网关驱动通过 metadata 指定父设备查询子设备列表
+	var parentId string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(constants.DeviceMetadataParentId); len(values) > 0 {
+			parentId = values[0]
+		}
+	}
 	devices, total, err := deviceItf.DevicesModelSearch(ctx, dtos.DeviceSearchQueryRequest{
 		DriveInstanceId: request.BaseRequest.DriverInstanceId,
 		Platform:        platform,
+		ParentId:        parentId,
 	})
 	response := new(device.QueryDeviceListResponse)
 	response.BaseResponse = new(drivercommon.CommonResponse)
@@ -118,6 +127,7 @@ func (s *DriverDeviceServer) CreateDevice(ctx context.Context, request *device.C
 	insertDevice.DeviceSn = request.Device.DeviceSn
 	//insertDevice.d
 	insertDevice.DriverInstanceId = request.BaseRequest.GetDriverInstanceId()
+	insertDevice.ParentId = request.Device.External[constants.DeviceExternalParentId]
 
 	deviceId, err := deviceItf.AddDevice(ctx, insertDevice)
 	if err != nil {
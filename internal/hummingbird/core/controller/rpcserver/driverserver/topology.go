@@ -0,0 +1,84 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package driverserver
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/winc-link/edge-driver-proto/drivercommon"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/pkg/proto/drivertopology"
+	"google.golang.org/grpc"
+)
+
+// TopologyServer 网关驱动添加、移除子设备，只能操作属于该驱动实例的设备
+type TopologyServer struct {
+	drivertopology.UnimplementedRpcTopologyServer
+	lc  logger.LoggingClient
+	dic *di.Container
+}
+
+func (s *TopologyServer) AddSubDevices(ctx context.Context, request *drivertopology.SubDevicesRequest) (*drivertopology.SubDevicesResponse, error) {
+	deviceItf := container.DeviceItfFrom(s.dic.Get)
+	err := deviceItf.DeviceSubDevicesAdd(ctx, topoRequest(request))
+	return topoResponse(err), nil
+}
+
+func (s *TopologyServer) RemoveSubDevices(ctx context.Context, request *drivertopology.SubDevicesRequest) (*drivertopology.SubDevicesResponse, error) {
+	deviceItf := container.DeviceItfFrom(s.dic.Get)
+	err := deviceItf.DeviceSubDevicesRemove(ctx, topoRequest(request))
+	return topoResponse(err), nil
+}
+
+func topoRequest(request *drivertopology.SubDevicesRequest) dtos.DeviceTopoRequest {
+	return dtos.DeviceTopoRequest{
+		ParentId:         request.GetParentId(),
+		DeviceIds:        request.GetDeviceIds(),
+		DriverInstanceId: request.GetBaseRequest().GetDriverInstanceId(),
+	}
+}
+
+func topoResponse(err error) *drivertopology.SubDevicesResponse {
+	response := new(drivertopology.SubDevicesResponse)
+	response.BaseResponse = new(drivercommon.CommonResponse)
+	if err != nil {
+		errWrapper := errort.NewCommonEdgeXWrapper(err)
+		response.BaseResponse.Success = false
+		response.BaseResponse.Code = strconv.Itoa(int(errWrapper.Code()))
+		response.BaseResponse.ErrorMessage = errWrapper.Message()
+		return response
+	}
+	response.BaseResponse.Success = true
+	response.BaseResponse.Code = "0"
+	return response
+}
+
+var _ drivertopology.RpcTopologyServer = (*TopologyServer)(nil)
+
+func NewTopologyServer(lc logger.LoggingClient, dic *di.Container) *TopologyServer {
+	return &TopologyServer{
+		lc:  lc,
+		dic: dic,
+	}
+}
+
+func (s *TopologyServer) RegisterServer(server *grpc.Server) {
+	drivertopology.RegisterRpcTopologyServer(server, s)
+}
@@ -0,0 +1,140 @@
+package driverserver
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/edge-driver-proto/drivercommon"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/deviceapp"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	pkgContainer "github.com/winc-link/hummingbird/internal/pkg/container"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/pkg/proto/drivertopology"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeDBClient 只实现拓扑管理用到的方法
+type fakeDBClient struct {
+	interfaces.DBClient
+	mutex   sync.Mutex
+	devices map[string]models.Device
+}
+
+func (c *fakeDBClient) DeviceById(id string) (models.Device, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device, ok := c.devices[id]
+	if !ok {
+		return device, errort.NewCommonErr(errort.DeviceNotExist, nil)
+	}
+	return device, nil
+}
+
+func (c *fakeDBClient) DevicesSearch(offset int, limit int, req dtos.DeviceSearchQueryRequest) ([]models.Device, uint32, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var devices []models.Device
+	for _, id := range strings.Split(req.BaseSearchConditionQuery.Ids, ",") {
+		if device, ok := c.devices[id]; ok {
+			devices = append(devices, device)
+		}
+	}
+	return devices, uint32(len(devices)), nil
+}
+
+func (c *fakeDBClient) BatchUpdateDeviceParentId(ids []string, parentId string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, id := range ids {
+		device := c.devices[id]
+		device.ParentId = parentId
+		c.devices[id] = device
+	}
+	return nil
+}
+
+func (c *fakeDBClient) DeviceServiceById(id string) (models.DeviceService, error) {
+	return models.DeviceService{}, errort.NewCommonErr(errort.DeviceServiceNotExist, nil)
+}
+
+func TestTopologyServer(t *testing.T) {
+	gateway := models.Product{NodeType: constants.ProductNodeTypeGateway}
+	subDevice := models.Product{NodeType: constants.ProductNodeTypeSubDevice}
+	db := &fakeDBClient{devices: map[string]models.Device{
+		"gw1":  {Id: "gw1", DriveInstanceId: "driver1", Product: gateway},
+		"sub1": {Id: "sub1", DriveInstanceId: "driver1", Product: subDevice},
+		"sub2": {Id: "sub2", DriveInstanceId: "driver2", Product: subDevice},
+	}}
+	lc := logger.NewMockClient()
+	dic := di.NewContainer(di.ServiceConstructorMap{
+		pkgContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return lc
+		},
+		container.DBClientInterfaceName: func(get di.Get) interface{} {
+			return db
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deviceItf := deviceapp.NewDeviceApp(ctx, dic)
+	dic.Update(di.ServiceConstructorMap{
+		container.DeviceItfName: func(get di.Get) interface{} {
+			return deviceItf
+		},
+	})
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	NewTopologyServer(lc, dic).RegisterServer(server)
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := drivertopology.NewRpcTopologyClient(conn)
+
+	request := func(deviceIds ...string) *drivertopology.SubDevicesRequest {
+		return &drivertopology.SubDevicesRequest{
+			BaseRequest: &drivercommon.BaseRequestMessage{DriverInstanceId: "driver1"},
+			ParentId:    "gw1",
+			DeviceIds:   deviceIds,
+		}
+	}
+
+	resp, err := client.AddSubDevices(context.Background(), request("sub1"))
+	require.NoError(t, err)
+	assert.True(t, resp.BaseResponse.Success, resp.BaseResponse.ErrorMessage)
+	assert.Equal(t, "gw1", db.devices["sub1"].ParentId)
+
+	// 其它驱动实例的设备不能添加
+	resp, err = client.AddSubDevices(context.Background(), request("sub2"))
+	require.NoError(t, err)
+	assert.False(t, resp.BaseResponse.Success)
+	assert.Equal(t, strconv.Itoa(errort.DeviceNotBelongDriver), resp.BaseResponse.Code)
+	assert.Empty(t, db.devices["sub2"].ParentId)
+
+	resp, err = client.AddSubDevices(context.Background(), request("missing"))
+	require.NoError(t, err)
+	assert.False(t, resp.BaseResponse.Success)
+
+	resp, err = client.RemoveSubDevices(context.Background(), request("sub1"))
+	require.NoError(t, err)
+	assert.True(t, resp.BaseResponse.Success, resp.BaseResponse.ErrorMessage)
+	assert.Empty(t, db.devices["sub1"].ParentId)
+}
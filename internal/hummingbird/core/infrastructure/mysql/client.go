@@ -72,6 +72,34 @@ func NewClient(config dtos.Configuration, lc logger.LoggingClient) (c *Client, e
 	//	errEdgeX = errort.NewCommonEdgeX(errort.DefaultSystemError, "database failed to init", err)
 	//	return
 	//}
+	// 新增表自动建表，存量表结构见 manifest/sql/init.sql
+	if err = client.InitTable(
+		&models.TimerJob{},
+		&models.SceneTemplate{},
+		&models.SceneInstance{},
+		&models.DeviceShadow{},
+		&models.DeviceTag{},
+		&models.DeviceGroup{},
+		&models.DeviceGroupMember{},
+		&models.DeviceConnectLog{},
+		&models.DeviceInvalidSample{},
+	); err != nil {
+		errEdgeX = errort.NewCommonEdgeX(errort.DefaultSystemError, "database failed to init", err)
+		return
+	}
+	// 存量表新增字段
+	if err = client.AddColumns(&models.Product{}, "KeepAlive", "ValidateMode", "Retention"); err != nil {
+		errEdgeX = errort.NewCommonEdgeX(errort.DefaultSystemError, "database failed to init", err)
+		return
+	}
+	if err = client.AddColumns(&models.Device{}, "KeepAlive", "ParentId"); err != nil {
+		errEdgeX = errort.NewCommonEdgeX(errort.DefaultSystemError, "database failed to init", err)
+		return
+	}
+	if err = client.AddColumns(&models.Properties{}, "Expression", "Scaling"); err != nil {
+		errEdgeX = errort.NewCommonEdgeX(errort.DefaultSystemError, "database failed to init", err)
+		return
+	}
 	c = &Client{
 		client:        client,
 		loggingClient: lc,
@@ -330,6 +358,10 @@ func (c *Client) BatchBindDevice(ids []string, driverInstanceId string) error {
 	return batchBindDevice(c, ids, driverInstanceId)
 }
 
+func (c *Client) BatchUpdateDeviceParentId(ids []string, parentId string) error {
+	return batchUpdateDeviceParentId(c, ids, parentId)
+}
+
 func (c *Client) DeleteDeviceById(id string) error {
 	return deleteDeviceById(c, id)
 }
@@ -670,6 +702,53 @@ func (c *Client) SceneLogSearch(offset int, limit int, req dtos.SceneLogSearchQu
 	return sceneLogSearch(c, offset, limit, req)
 }
 
+func (c *Client) UpsertTimerJob(job models.TimerJob) error {
+	return upsertTimerJob(c, job)
+}
+
+func (c *Client) TimerJobById(id string) (models.TimerJob, error) {
+	return timerJobById(c, id)
+}
+
+func (c *Client) DeleteTimerJobById(id string) error {
+	return deleteTimerJobById(c, id)
+}
+
+func (c *Client) AddSceneTemplate(template models.SceneTemplate) (models.SceneTemplate, error) {
+	if template.Id == "" {
+		template.Id = utils.RandomNum()
+	}
+	return addSceneTemplate(c, template)
+}
+
+func (c *Client) UpdateSceneTemplate(template models.SceneTemplate) error {
+	return updateSceneTemplate(c, template)
+}
+
+func (c *Client) SceneTemplateById(id string) (models.SceneTemplate, error) {
+	return sceneTemplateById(c, id)
+}
+
+func (c *Client) DeleteSceneTemplateById(id string) error {
+	return deleteSceneTemplateById(c, id)
+}
+
+func (c *Client) SceneTemplateSearch(offset int, limit int, req dtos.SceneTemplateSearchQueryRequest) ([]models.SceneTemplate, uint32, error) {
+	return sceneTemplateSearch(c, offset, limit, req)
+}
+
+func (c *Client) SceneInstancesByTemplateId(templateId string) ([]models.SceneInstance, error) {
+	return sceneInstancesByTemplateId(c, templateId)
+}
+
+func (c *Client) DeleteSceneInstanceById(id string) error {
+	return deleteSceneInstanceById(c, id)
+}
+
+func (c *Client) BatchUpsertSceneInstances(scenes []models.Scene, instances []models.SceneInstance) error {
+	return batchUpsertSceneInstances(c, scenes, instances)
+}
+
 func (c *Client) LanguageSdkByName(name string) (cloudService models.LanguageSdk, edgeXErr error) {
 	return languageByName(c, name)
 }
@@ -700,3 +779,107 @@ func (c *Client) GetSystemMetrics(start, end int64) ([]dtos.SystemMetrics, error
 func (c *Client) RemoveRangeSystemMetrics(min, max string) error {
 	return removeRangeSystemMetrics(c, min, max)
 }
+
+func (c *Client) DeviceShadowById(id string) (models.DeviceShadow, error) {
+	return deviceShadowById(c, id)
+}
+
+func (c *Client) UpsertDeviceShadow(shadow models.DeviceShadow) error {
+	return upsertDeviceShadow(c, shadow)
+}
+
+func (c *Client) BatchUpsertDeviceShadow(shadows []models.DeviceShadow) error {
+	return batchUpsertDeviceShadow(c, shadows)
+}
+
+func (c *Client) DeleteDeviceShadowById(id string) error {
+	return deleteDeviceShadowById(c, id)
+}
+
+func (c *Client) SetDeviceTags(deviceId string, tags map[string]string) error {
+	return setDeviceTags(c, deviceId, tags)
+}
+
+func (c *Client) DeviceTagsByDeviceIds(deviceIds []string) ([]models.DeviceTag, error) {
+	return deviceTagsByDeviceIds(c, deviceIds)
+}
+
+func (c *Client) DeviceTagsDistinct() ([]models.DeviceTag, error) {
+	return deviceTagsDistinct(c)
+}
+
+func (c *Client) DeleteDeviceRelationsByDeviceIds(deviceIds []string) error {
+	return deleteDeviceRelationsByDeviceIds(c, deviceIds)
+}
+
+func (c *Client) AddDeviceGroup(group models.DeviceGroup) (models.DeviceGroup, error) {
+	return addDeviceGroup(c, group)
+}
+
+func (c *Client) UpdateDeviceGroup(group models.DeviceGroup) error {
+	return updateDeviceGroup(c, group)
+}
+
+func (c *Client) DeviceGroupById(id string) (models.DeviceGroup, error) {
+	return deviceGroupById(c, id)
+}
+
+func (c *Client) DeleteDeviceGroupById(id string) error {
+	return deleteDeviceGroupById(c, id)
+}
+
+func (c *Client) DeviceGroupsSearch(offset int, limit int, req dtos.DeviceGroupSearchQueryRequest) ([]models.DeviceGroup, uint32, error) {
+	return deviceGroupsSearch(c, offset, limit, req)
+}
+
+func (c *Client) AddDeviceGroupMembers(groupId string, deviceIds []string) error {
+	return addDeviceGroupMembers(c, groupId, deviceIds)
+}
+
+func (c *Client) DeleteDeviceGroupMembers(groupId string, deviceIds []string) error {
+	return deleteDeviceGroupMembers(c, groupId, deviceIds)
+}
+
+func (c *Client) DeviceGroupMembersByDeviceIds(deviceIds []string) ([]models.DeviceGroupMember, error) {
+	return deviceGroupMembersByDeviceIds(c, deviceIds)
+}
+
+func (c *Client) AddDeviceConnectLog(log models.DeviceConnectLog) error {
+	return addDeviceConnectLog(c, log)
+}
+
+func (c *Client) DeviceConnectLogsSearch(offset int, limit int, req dtos.DeviceConnectLogSearchQueryRequest) ([]models.DeviceConnectLog, uint32, error) {
+	return deviceConnectLogsSearch(c, offset, limit, req)
+}
+
+func (c *Client) DeviceConnectLogsBetween(deviceIds []string, start, end int64) ([]models.DeviceConnectLog, error) {
+	return deviceConnectLogsBetween(c, deviceIds, start, end)
+}
+
+func (c *Client) DeviceConnectLogsLastBefore(deviceIds []string, t int64) ([]models.DeviceConnectLog, error) {
+	return deviceConnectLogsLastBefore(c, deviceIds, t)
+}
+
+func (c *Client) DeviceFlapCounts(start int64, limit int) ([]models.DeviceFlapCount, error) {
+	return deviceFlapCounts(c, start, limit)
+}
+
+func (c *Client) DeleteDeviceConnectLogsByDeviceIds(deviceIds []string) error {
+	return deleteDeviceConnectLogsByDeviceIds(c, deviceIds)
+}
+
+func (c *Client) DeleteDeviceConnectLogsBefore(t int64) error {
+	return deleteDeviceConnectLogsBefore(c, t)
+}
+
+func (c *Client) IncrDeviceInvalidSample(sample models.DeviceInvalidSample) error {
+	return incrDeviceInvalidSample(c, sample)
+}
+
+func (c *Client) DeviceInvalidSampleById(id string) (models.DeviceInvalidSample, error) {
+	return deviceInvalidSampleById(c, id)
+}
+
+func (c *Client) DeleteDeviceInvalidSamplesByDeviceIds(deviceIds []string) error {
+	return deleteDeviceInvalidSamplesByDeviceIds(c, deviceIds)
+}
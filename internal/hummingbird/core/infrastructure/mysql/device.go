@@ -132,6 +132,24 @@ func devicesSearch(c *Client, offset int, limit int, req dtos.DeviceSearchQueryR
 	if req.Status != "" {
 		tx = tx.Where("`status` = ?", req.Status)
 	}
+	if req.ParentId != "" {
+		tx = tx.Where("`parent_id` = ?", req.ParentId)
+	}
+	if len(req.ParentIds) > 0 {
+		tx = tx.Where("`parent_id` IN ?", req.ParentIds)
+	}
+	if len(req.DeviceSns) > 0 {
+		tx = tx.Where("`device_sn` IN ?", req.DeviceSns)
+	}
+	if req.Tree {
+		tx = tx.Where("(`parent_id` = '' OR `parent_id` IS NULL)")
+	}
+	if req.Tags != "" || req.GroupId != "" {
+		var err error
+		if tx, err = deviceSearchTagsCondition(c, tx, req); err != nil {
+			return []models.Device{}, 0, err
+		}
+	}
 
 	err := tx.Count(&total).Error
 	if err != nil {
@@ -192,6 +210,16 @@ func batchBindDevice(c *Client, ids []string, driverInstanceId string) error {
 	return nil
 }
 
+func batchUpdateDeviceParentId(c *Client, ids []string, parentId string) error {
+	d := models.Device{}
+	tx := c.Pool.Table(d.TableName())
+	err := tx.Where("id IN ?", ids).Updates(map[string]interface{}{"parent_id": parentId}).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "batchUpdateDeviceParentId failed", err)
+	}
+	return nil
+}
+
 func deleteDeviceById(c *Client, id string) error {
 	if id == "" {
 		return errort.NewCommonEdgeX(errort.DefaultIdEmpty, "device id is empty", nil)
@@ -0,0 +1,142 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package mysql
+
+import (
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/tools/sqldb/sqlite"
+)
+
+func addDeviceConnectLog(c *Client, log models.DeviceConnectLog) error {
+	err := c.Pool.Table(log.TableName()).Create(&log).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device connect log creation failed", err)
+	}
+	return nil
+}
+
+func deviceConnectLogsSearch(c *Client, offset int, limit int, req dtos.DeviceConnectLogSearchQueryRequest) (logs []models.DeviceConnectLog, count uint32, edgeXErr error) {
+	dl := models.DeviceConnectLog{}
+	var total int64
+	tx := c.Pool.Table(dl.TableName())
+	// 上下线记录没有 created 字段，默认按时间倒序
+	if req.OrderBy == "" {
+		req.OrderBy = "time:desc,id:desc"
+	}
+	tx = sqlite.BuildCommonCondition(tx, dl, req.BaseSearchConditionQuery)
+	if req.DeviceId != "" {
+		tx = tx.Where("`device_id` = ?", req.DeviceId)
+	}
+	if len(req.DeviceIds) > 0 {
+		tx = tx.Where("`device_id` IN ?", req.DeviceIds)
+	}
+	if req.ProductId != "" {
+		tx = tx.Where("`product_id` = ?", req.ProductId)
+	}
+	if req.Status != "" {
+		tx = tx.Where("`status` = ?", req.Status)
+	}
+	if req.StartAt > 0 {
+		tx = tx.Where("`time` >= ?", req.StartAt)
+	}
+	if req.EndAt > 0 {
+		tx = tx.Where("`time` < ?", req.EndAt)
+	}
+
+	err := tx.Count(&total).Error
+	if err != nil {
+		return logs, 0, errort.NewCommonEdgeX(errort.DefaultSystemError, "device connect log search failed query from the database", err)
+	}
+	err = tx.Offset(offset).Limit(limit).Find(&logs).Error
+	if err != nil {
+		return logs, 0, errort.NewCommonEdgeX(errort.DefaultSystemError, "device connect log search failed query from the database", err)
+	}
+	return logs, uint32(total), nil
+}
+
+// deviceConnectLogsBetween 按时间升序查询设备在 [start, end) 内的上下线记录
+func deviceConnectLogsBetween(c *Client, deviceIds []string, start, end int64) (logs []models.DeviceConnectLog, edgeXErr error) {
+	if len(deviceIds) == 0 {
+		return
+	}
+	dl := models.DeviceConnectLog{}
+	err := c.Pool.Table(dl.TableName()).
+		Where("`device_id` IN ?", deviceIds).
+		Where("`time` >= ? AND `time` < ?", start, end).
+		Order("`time`").Order("`id`").
+		Find(&logs).Error
+	if err != nil {
+		return logs, errort.NewCommonEdgeX(errort.DefaultSystemError, "device connect logs query failed", err)
+	}
+	return logs, nil
+}
+
+// deviceConnectLogsLastBefore 查询每个设备在 t 之前的最后一条上下线记录
+func deviceConnectLogsLastBefore(c *Client, deviceIds []string, t int64) (logs []models.DeviceConnectLog, edgeXErr error) {
+	if len(deviceIds) == 0 {
+		return
+	}
+	dl := models.DeviceConnectLog{}
+	last := c.Pool.Table(dl.TableName()).
+		Select("MAX(`id`)").
+		Where("`device_id` IN ?", deviceIds).
+		Where("`time` < ?", t).
+		Group("device_id")
+	err := c.Pool.Table(dl.TableName()).Where("`id` IN (?)", last).Find(&logs).Error
+	if err != nil {
+		return logs, errort.NewCommonEdgeX(errort.DefaultSystemError, "device connect logs query failed", err)
+	}
+	return logs, nil
+}
+
+// deviceFlapCounts 统计 start 之后离线次数最多的设备
+func deviceFlapCounts(c *Client, start int64, limit int) (counts []models.DeviceFlapCount, edgeXErr error) {
+	dl := models.DeviceConnectLog{}
+	err := c.Pool.Table(dl.TableName()).
+		Select("`device_id`, COUNT(*) AS `flap_count`").
+		Where("`status` = ?", constants.DeviceOffline).
+		Where("`time` >= ?", start).
+		Group("device_id").
+		Order("`flap_count` DESC").
+		Limit(limit).
+		Scan(&counts).Error
+	if err != nil {
+		return counts, errort.NewCommonEdgeX(errort.DefaultSystemError, "device flap count query failed", err)
+	}
+	return counts, nil
+}
+
+func deleteDeviceConnectLogsByDeviceIds(c *Client, deviceIds []string) error {
+	if len(deviceIds) == 0 {
+		return nil
+	}
+	dl := models.DeviceConnectLog{}
+	err := c.Pool.Table(dl.TableName()).Where("`device_id` IN ?", deviceIds).Delete(&dl).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device connect logs delete failed", err)
+	}
+	return nil
+}
+
+func deleteDeviceConnectLogsBefore(c *Client, t int64) error {
+	dl := models.DeviceConnectLog{}
+	err := c.Pool.Table(dl.TableName()).Where("`time` < ?", t).Delete(&dl).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device connect logs delete failed", err)
+	}
+	return nil
+}
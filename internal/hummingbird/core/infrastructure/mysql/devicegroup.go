@@ -0,0 +1,229 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package mysql
+
+import (
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"github.com/winc-link/hummingbird/internal/tools/sqldb/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// setDeviceTags 整体替换设备标签
+func setDeviceTags(c *Client, deviceId string, tags map[string]string) error {
+	dt := models.DeviceTag{}
+	err := c.Pool.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(dt.TableName()).Where("`device_id` = ?", deviceId).Delete(&models.DeviceTag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		var rows []models.DeviceTag
+		for k, v := range tags {
+			rows = append(rows, models.DeviceTag{DeviceId: deviceId, TagKey: k, TagValue: v})
+		}
+		return tx.Table(dt.TableName()).Create(&rows).Error
+	})
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device tags update failed", err)
+	}
+	return nil
+}
+
+func deviceTagsByDeviceIds(c *Client, deviceIds []string) (tags []models.DeviceTag, edgeXErr error) {
+	if len(deviceIds) == 0 {
+		return
+	}
+	dt := models.DeviceTag{}
+	err := c.Pool.Table(dt.TableName()).Where("`device_id` IN ?", deviceIds).Find(&tags).Error
+	if err != nil {
+		return tags, errort.NewCommonEdgeX(errort.DefaultSystemError, "device tags query failed", err)
+	}
+	return tags, nil
+}
+
+// deviceTagsDistinct 所有已使用的标签键值
+func deviceTagsDistinct(c *Client) (tags []models.DeviceTag, edgeXErr error) {
+	dt := models.DeviceTag{}
+	err := c.Pool.Table(dt.TableName()).Distinct("tag_key", "tag_value").Order("tag_key, tag_value").Find(&tags).Error
+	if err != nil {
+		return tags, errort.NewCommonEdgeX(errort.DefaultSystemError, "device tags query failed", err)
+	}
+	return tags, nil
+}
+
+// deleteDeviceRelationsByDeviceIds 删除设备的标签及静态分组关系
+func deleteDeviceRelationsByDeviceIds(c *Client, deviceIds []string) error {
+	if len(deviceIds) == 0 {
+		return nil
+	}
+	err := c.Pool.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("`device_id` IN ?", deviceIds).Delete(&models.DeviceTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("`device_id` IN ?", deviceIds).Delete(&models.DeviceGroupMember{}).Error
+	})
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device relations deletion failed", err)
+	}
+	return nil
+}
+
+func addDeviceGroup(c *Client, group models.DeviceGroup) (models.DeviceGroup, error) {
+	ts := utils.MakeTimestamp()
+	if group.Created == 0 {
+		group.Created = ts
+	}
+	group.Modified = ts
+	err := c.client.CreateObject(&group)
+	if err != nil {
+		return group, errort.NewCommonEdgeX(errort.DefaultSystemError, "device group creation failed", err)
+	}
+	return group, nil
+}
+
+func updateDeviceGroup(c *Client, group models.DeviceGroup) error {
+	group.Modified = utils.MakeTimestamp()
+	err := c.client.UpdateObject(&group)
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device group update failed", err)
+	}
+	return nil
+}
+
+func deviceGroupById(c *Client, id string) (group models.DeviceGroup, err error) {
+	if id == "" {
+		return group, errort.NewCommonEdgeX(errort.DefaultIdEmpty, "device group id is empty", nil)
+	}
+	err = c.client.GetObject(&models.DeviceGroup{Id: id}, &group)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return group, errort.NewCommonErr(errort.DeviceGroupNotExist, fmt.Errorf("device group id(%s) not found", id))
+		}
+		return group, err
+	}
+	return
+}
+
+// deleteDeviceGroupById 删除分组及其设备关系
+func deleteDeviceGroupById(c *Client, id string) error {
+	if id == "" {
+		return errort.NewCommonEdgeX(errort.DefaultIdEmpty, "del device group id is empty", nil)
+	}
+	err := c.Pool.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("`group_id` = ?", id).Delete(&models.DeviceGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.DeviceGroup{Id: id}).Error
+	})
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "del device group deletion failed", err)
+	}
+	return nil
+}
+
+func deviceGroupsSearch(c *Client, offset int, limit int, req dtos.DeviceGroupSearchQueryRequest) (groups []models.DeviceGroup, count uint32, edgeXErr error) {
+	dp := models.DeviceGroup{}
+	var total int64
+	tx := c.Pool.Table(dp.TableName())
+	tx = sqlite.BuildCommonCondition(tx, dp, req.BaseSearchConditionQuery)
+
+	if req.Name != "" {
+		tx = tx.Where("`name` LIKE ?", sqlite.MakeLikeParams(req.Name))
+	}
+	if req.Type != "" {
+		tx = tx.Where("`type` = ?", req.Type)
+	}
+	err := tx.Count(&total).Error
+	if err != nil {
+		return groups, 0, errort.NewCommonEdgeX(errort.DefaultSystemError, "device group search failed query from the database", err)
+	}
+
+	err = tx.Offset(offset).Limit(limit).Find(&groups).Error
+	if err != nil {
+		return groups, 0, errort.NewCommonEdgeX(errort.DefaultSystemError, "device group search failed query from the database", err)
+	}
+	return groups, uint32(total), nil
+}
+
+func addDeviceGroupMembers(c *Client, groupId string, deviceIds []string) error {
+	if len(deviceIds) == 0 {
+		return nil
+	}
+	members := make([]models.DeviceGroupMember, 0, len(deviceIds))
+	for _, id := range deviceIds {
+		members = append(members, models.DeviceGroupMember{GroupId: groupId, DeviceId: id})
+	}
+	err := c.Pool.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device group members creation failed", err)
+	}
+	return nil
+}
+
+func deleteDeviceGroupMembers(c *Client, groupId string, deviceIds []string) error {
+	if len(deviceIds) == 0 {
+		return nil
+	}
+	err := c.Pool.Where("`group_id` = ? AND `device_id` IN ?", groupId, deviceIds).Delete(&models.DeviceGroupMember{}).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device group members deletion failed", err)
+	}
+	return nil
+}
+
+func deviceGroupMembersByDeviceIds(c *Client, deviceIds []string) (members []models.DeviceGroupMember, edgeXErr error) {
+	if len(deviceIds) == 0 {
+		return
+	}
+	err := c.Pool.Where("`device_id` IN ?", deviceIds).Find(&members).Error
+	if err != nil {
+		return members, errort.NewCommonEdgeX(errort.DefaultSystemError, "device group members query failed", err)
+	}
+	return members, nil
+}
+
+// deviceSearchTagsCondition 按标签及分组过滤设备，动态分组转换为标签条件
+func deviceSearchTagsCondition(c *Client, tx *gorm.DB, req dtos.DeviceSearchQueryRequest) (*gorm.DB, error) {
+	tags := dtos.ParseDeviceTags(req.Tags)
+	if req.GroupId != "" {
+		group, err := deviceGroupById(c, req.GroupId)
+		if err != nil {
+			return tx, err
+		}
+		switch group.Type {
+		case constants.DeviceGroupStatic:
+			member := models.DeviceGroupMember{}
+			tx = tx.Where("`id` IN (?)", c.Pool.Table(member.TableName()).Select("device_id").Where("`group_id` = ?", group.Id))
+		default:
+			if len(group.Tags) == 0 {
+				return tx.Where("1 = 0"), nil
+			}
+			for k, v := range group.Tags {
+				tags[k] = v
+			}
+		}
+	}
+	dt := models.DeviceTag{}
+	for k, v := range tags {
+		tx = tx.Where("`id` IN (?)", c.Pool.Table(dt.TableName()).Select("device_id").Where("`tag_key` = ? AND `tag_value` = ?", k, v))
+	}
+	return tx, nil
+}
@@ -0,0 +1,72 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package mysql
+
+import (
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// incrDeviceInvalidSample 累加设备不合法数据次数，并记录最近一次的原因
+func incrDeviceInvalidSample(c *Client, sample models.DeviceInvalidSample) error {
+	ts := utils.MakeTimestamp()
+	sample.Created = ts
+	sample.Modified = ts
+	err := c.Pool.Table(sample.TableName()).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"modified":      ts,
+				"product_id":    sample.ProductId,
+				"invalid_count": gorm.Expr("invalid_count + ?", sample.InvalidCount),
+				"last_code":     sample.LastCode,
+				"last_reason":   sample.LastReason,
+				"last_time":     sample.LastTime,
+			}),
+		}).Create(&sample).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device invalid sample upsert failed", err)
+	}
+	return nil
+}
+
+func deviceInvalidSampleById(c *Client, id string) (sample models.DeviceInvalidSample, err error) {
+	if id == "" {
+		return sample, errort.NewCommonEdgeX(errort.DefaultIdEmpty, "device invalid sample id is empty", nil)
+	}
+	err = c.client.GetObject(&models.DeviceInvalidSample{Id: id}, &sample)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return sample, errort.NewCommonErr(errort.DefaultResourcesNotFound, fmt.Errorf("device invalid sample id(%s) not found", id))
+		}
+		return sample, err
+	}
+	return
+}
+
+func deleteDeviceInvalidSamplesByDeviceIds(c *Client, deviceIds []string) error {
+	if len(deviceIds) == 0 {
+		return nil
+	}
+	err := c.Pool.Where("id IN ?", deviceIds).Delete(&models.DeviceInvalidSample{}).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "delete device invalid samples failed", err)
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/winc-link/hummingbird/internal/pkg/utils"
 	"github.com/winc-link/hummingbird/internal/tools/sqldb/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func addScene(c *Client, ds models.Scene) (scene models.Scene, edgeXErr error) {
@@ -157,3 +158,45 @@ func sceneLogSearch(c *Client, offset int, limit int, req dtos.SceneLogSearchQue
 
 	return sceneLogs, uint32(total), nil
 }
+
+func upsertTimerJob(c *Client, job models.TimerJob) error {
+	ts := utils.MakeTimestamp()
+	if job.Created == 0 {
+		job.Created = ts
+	}
+	job.Modified = ts
+	err := c.Pool.Table(job.TableName()).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"modified", "name", "expression", "last_run_time", "next_run_time"}),
+		}).Create(&job).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "timer job upsert failed", err)
+	}
+	return nil
+}
+
+func timerJobById(c *Client, id string) (job models.TimerJob, err error) {
+	if id == "" {
+		return job, errort.NewCommonEdgeX(errort.DefaultIdEmpty, "timer job id is empty", nil)
+	}
+	err = c.client.GetObject(&models.TimerJob{Id: id}, &job)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return job, errort.NewCommonErr(errort.DefaultResourcesNotFound, fmt.Errorf("timer job id(%s) not found", id))
+		}
+		return job, err
+	}
+	return
+}
+
+func deleteTimerJobById(c *Client, id string) error {
+	if id == "" {
+		return errort.NewCommonEdgeX(errort.DefaultIdEmpty, "del timer job id is empty", nil)
+	}
+	err := c.client.DeleteObject(&models.TimerJob{Id: id})
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "del timer job deletion failed", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package sqlite
+
+import (
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func deviceShadowById(c *Client, id string) (shadow models.DeviceShadow, err error) {
+	if id == "" {
+		return shadow, errort.NewCommonEdgeX(errort.DefaultIdEmpty, "device shadow id is empty", nil)
+	}
+	err = c.client.GetObject(&models.DeviceShadow{Id: id}, &shadow)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return shadow, errort.NewCommonErr(errort.DefaultResourcesNotFound, fmt.Errorf("device shadow id(%s) not found", id))
+		}
+		return shadow, err
+	}
+	return
+}
+
+func upsertDeviceShadow(c *Client, shadow models.DeviceShadow) error {
+	ts := utils.MakeTimestamp()
+	if shadow.Created == 0 {
+		shadow.Created = ts
+	}
+	shadow.Modified = ts
+	err := c.Pool.Table(shadow.TableName()).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"modified", "desired", "reported", "version"}),
+		}).Create(&shadow).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device shadow upsert failed", err)
+	}
+	return nil
+}
+
+func batchUpsertDeviceShadow(c *Client, shadows []models.DeviceShadow) error {
+	ts := utils.MakeTimestamp()
+	for i := range shadows {
+		if shadows[i].Created == 0 {
+			shadows[i].Created = ts
+		}
+		shadows[i].Modified = ts
+	}
+	err := c.Pool.Table((&models.DeviceShadow{}).TableName()).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"modified", "desired", "reported", "version"}),
+		}).CreateInBatches(&shadows, 100).Error
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "device shadow batch upsert failed", err)
+	}
+	return nil
+}
+
+func deleteDeviceShadowById(c *Client, id string) error {
+	if id == "" {
+		return errort.NewCommonEdgeX(errort.DefaultIdEmpty, "del device shadow id is empty", nil)
+	}
+	err := c.client.DeleteObject(&models.DeviceShadow{Id: id})
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "del device shadow deletion failed", err)
+	}
+	return nil
+}
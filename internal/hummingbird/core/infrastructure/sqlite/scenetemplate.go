@@ -0,0 +1,152 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package sqlite
+
+import (
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+	"github.com/winc-link/hummingbird/internal/tools/sqldb/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func addSceneTemplate(c *Client, st models.SceneTemplate) (template models.SceneTemplate, edgeXErr error) {
+	ts := utils.MakeTimestamp()
+	if st.Created == 0 {
+		st.Created = ts
+	}
+	st.Modified = ts
+
+	err := c.client.CreateObject(&st)
+	if err != nil {
+		edgeXErr = errort.NewCommonEdgeX(errort.DefaultSystemError, "scene template creation failed", err)
+	}
+	return st, edgeXErr
+}
+
+func updateSceneTemplate(c *Client, st models.SceneTemplate) error {
+	st.Modified = utils.MakeTimestamp()
+	err := c.client.UpdateObject(&st)
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "scene template update failed", err)
+	}
+	return nil
+}
+
+func sceneTemplateById(c *Client, id string) (template models.SceneTemplate, err error) {
+	if id == "" {
+		return template, errort.NewCommonEdgeX(errort.DefaultIdEmpty, "scene template id is empty", nil)
+	}
+	err = c.client.GetObject(&models.SceneTemplate{Id: id}, &template)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return template, errort.NewCommonErr(errort.DefaultResourcesNotFound, fmt.Errorf("scene template id(%s) not found", id))
+		}
+		return template, err
+	}
+	return
+}
+
+func deleteSceneTemplateById(c *Client, id string) error {
+	if id == "" {
+		return errort.NewCommonEdgeX(errort.DefaultIdEmpty, "del scene template id is empty", nil)
+	}
+	err := c.client.DeleteObject(&models.SceneTemplate{Id: id})
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "del scene template deletion failed", err)
+	}
+	return nil
+}
+
+func sceneTemplateSearch(c *Client, offset int, limit int, req dtos.SceneTemplateSearchQueryRequest) (templates []models.SceneTemplate, count uint32, edgeXErr error) {
+	dp := models.SceneTemplate{}
+	var total int64
+	tx := c.Pool.Table(dp.TableName())
+	tx = sqlite.BuildCommonCondition(tx, dp, req.BaseSearchConditionQuery)
+
+	if req.Name != "" {
+		tx = tx.Where("`name` LIKE ?", sqlite.MakeLikeParams(req.Name))
+	}
+	err := tx.Count(&total).Error
+	if err != nil {
+		return templates, 0, errort.NewCommonEdgeX(errort.DefaultSystemError, "scene template search failed query from the database", err)
+	}
+
+	err = tx.Offset(offset).Limit(limit).Find(&templates).Error
+	if err != nil {
+		return templates, 0, errort.NewCommonEdgeX(errort.DefaultSystemError, "scene template search failed query from the database", err)
+	}
+
+	return templates, uint32(total), nil
+}
+
+func sceneInstancesByTemplateId(c *Client, templateId string) (instances []models.SceneInstance, edgeXErr error) {
+	dp := models.SceneInstance{}
+	err := c.Pool.Table(dp.TableName()).Where("`template_id` = ?", templateId).Order("`instance_key`").Find(&instances).Error
+	if err != nil {
+		return instances, errort.NewCommonEdgeX(errort.DefaultSystemError, "scene instance query failed", err)
+	}
+	return instances, nil
+}
+
+func deleteSceneInstanceById(c *Client, id string) error {
+	if id == "" {
+		return errort.NewCommonEdgeX(errort.DefaultIdEmpty, "del scene instance id is empty", nil)
+	}
+	err := c.client.DeleteObject(&models.SceneInstance{Id: id})
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "del scene instance deletion failed", err)
+	}
+	return nil
+}
+
+// batchUpsertSceneInstances 在同一个事务中写入场景及其实例记录，任一失败全部回滚
+func batchUpsertSceneInstances(c *Client, scenes []models.Scene, instances []models.SceneInstance) error {
+	if len(scenes) == 0 {
+		return nil
+	}
+	ts := utils.MakeTimestamp()
+	for i := range scenes {
+		if scenes[i].Created == 0 {
+			scenes[i].Created = ts
+		}
+		scenes[i].Modified = ts
+	}
+	for i := range instances {
+		if instances[i].Created == 0 {
+			instances[i].Created = ts
+		}
+		instances[i].Modified = ts
+	}
+	err := c.Pool.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"modified", "name", "description", "status", "conditions", "actions"}),
+		}).Create(&scenes).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"modified", "name", "bindings"}),
+		}).Create(&instances).Error
+	})
+	if err != nil {
+		return errort.NewCommonEdgeX(errort.DefaultSystemError, "scene instance batch upsert failed", err)
+	}
+	return nil
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/alertcentreapp"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/categorytemplate"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/dataexport"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/datamigration"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/dataresource"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/deviceapp"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/dmi"
@@ -29,6 +31,7 @@ import (
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/driverserviceapp"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/homepageapp"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/languagesdkapp"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/latestvalue"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/messageapp"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/messagestore"
 	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/monitor"
@@ -191,6 +194,7 @@ func (b *Bootstrap) initClient(ctx context.Context, wg *sync.WaitGroup, dic *di.
 	})
 
 	deviceApp := deviceapp.NewDeviceApp(ctx, dic)
+	deviceApp.Run(ctx, wg)
 	dic.Update(di.ServiceConstructorMap{
 		container.DeviceItfName: func(get di.Get) interface{} {
 			return deviceApp
@@ -304,12 +308,27 @@ func (b *Bootstrap) initClient(ctx context.Context, wg *sync.WaitGroup, dic *di.
 	})
 
 	persistItf := persistence.NewPersistApp(dic)
+	persistItf.Run(ctx, wg)
 	dic.Update(di.ServiceConstructorMap{
 		container.PersistItfName: func(get di.Get) interface{} {
 			return persistItf
 		},
 	})
 
+	dataMigrationItf := datamigration.NewDataMigrationApp(ctx, dic)
+	dic.Update(di.ServiceConstructorMap{
+		container.DataMigrationItfName: func(get di.Get) interface{} {
+			return dataMigrationItf
+		},
+	})
+
+	dataExportItf := dataexport.NewDataExportApp(ctx, dic)
+	dic.Update(di.ServiceConstructorMap{
+		container.DataExportItfName: func(get di.Get) interface{} {
+			return dataExportItf
+		},
+	})
+
 	userItf := userapp.New(dic)
 	dic.Update(di.ServiceConstructorMap{
 		container.UserItfName: func(get di.Get) interface{} {
@@ -317,7 +336,16 @@ func (b *Bootstrap) initClient(ctx context.Context, wg *sync.WaitGroup, dic *di.
 		},
 	})
 
+	latestValueItf := latestvalue.NewLatestValueCache(dic)
+	latestValueItf.Run(ctx, wg)
+	dic.Update(di.ServiceConstructorMap{
+		container.LatestValueItfName: func(get di.Get) interface{} {
+			return latestValueItf
+		},
+	})
+
 	messageItf := messageapp.NewMessageApp(dic, configuration.Clients["Ekuiper"].Address())
+	messageItf.Run(ctx, wg)
 	dic.Update(di.ServiceConstructorMap{
 		container.MessageItfName: func(get di.Get) interface{} {
 			return messageItf
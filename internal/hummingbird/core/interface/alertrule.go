@@ -37,6 +37,8 @@ type AlertRuleApp interface {
 	AddAlert(ctx context.Context, req map[string]interface{}) error
 	CheckRuleByProductId(ctx context.Context, productId string) error
 	CheckRuleByDeviceId(ctx context.Context, deviceId string) error
+	CheckRuleByDeviceGroupId(ctx context.Context, groupId string) error
+	RefreshRulesByDeviceGroupId(ctx context.Context, groupId string) error
 }
 
 type RuleEngineApp interface {
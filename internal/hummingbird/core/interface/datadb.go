@@ -18,6 +18,7 @@ import (
 	"context"
 	"github.com/winc-link/hummingbird/internal/dtos"
 	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/aggregate"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 )
 
@@ -26,7 +27,11 @@ type DataDBClient interface {
 	CloseSession()
 
 	Insert(ctx context.Context, table string, data map[string]interface{}) (err error)
+	// BatchInsert 批量写入多个设备的数据，全部成功或返回错误
+	BatchInsert(ctx context.Context, records []dtos.DataRecord) error
 	GetDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device) ([]dtos.ReportData, int, error)
+	// GetDevicePropertyAggregate 按时间窗口聚合属性历史数据，结果按时间升序
+	GetDevicePropertyAggregate(req dtos.ThingModelPropertyDataRequest, device models.Device, query aggregate.Query) ([]dtos.ReportData, error)
 	GetDeviceService(req dtos.ThingModelServiceDataRequest, device models.Device, product models.Product) ([]dtos.SaveServiceIssueData, int, error)
 	GetDeviceEvent(req dtos.ThingModelEventDataRequest, device models.Device, product models.Product) ([]dtos.EventData, int, error)
 
@@ -43,4 +48,11 @@ type DataDBClient interface {
 	GetDevicePropertyCount(dtos.ThingModelPropertyDataRequest) (int, error)
 	GetDeviceEventCount(req dtos.ThingModelEventDataRequest) (int, error)
 	GetDeviceMsgCountByGiveTime(deviceId string, startTime, endTime int64) (int, error)
+
+	// DeleteExpiredData 按合并后的保存天数删除设备的过期数据
+	DeleteExpiredData(ctx context.Context, device models.Device, product models.Product, retention models.DataRetention) error
+	// SetRetention 设置时序库的全局保存天数，0表示永久保存
+	SetRetention(ctx context.Context, days int) error
+	// GetDeviceStorageSize 设备时序数据占用的存储空间(字节)
+	GetDeviceStorageSize(ctx context.Context, device models.Device, product models.Product) (int64, error)
 }
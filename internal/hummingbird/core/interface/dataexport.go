@@ -0,0 +1,30 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package interfaces
+
+import (
+	"context"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+)
+
+// DataExportItf 异步导出设备的历史数据到文件
+type DataExportItf interface {
+	// DataExport 创建导出任务并立即返回任务id
+	DataExport(ctx context.Context, req dtos.DataExportRequest) (string, error)
+	DataExportJobById(ctx context.Context, jobId string) (dtos.DataExportJobResponse, error)
+	// DataExportFile 导出完成的文件路径和下载的文件名
+	DataExportFile(ctx context.Context, jobId string) (string, string, error)
+}
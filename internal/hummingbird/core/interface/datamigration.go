@@ -0,0 +1,30 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package interfaces
+
+import (
+	"context"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+)
+
+// DataMigrationItf 在时序库之间迁移设备的属性、事件和服务历史数据
+type DataMigrationItf interface {
+	// Start 在后台开始迁移，已有迁移在运行时返回错误
+	Start(ctx context.Context, req dtos.DataMigrationRequest) error
+	// Stop 停止正在运行的迁移，已迁移的位置保存在进度文件中
+	Stop()
+	Progress() dtos.DataMigrationProgress
+}
@@ -90,6 +90,7 @@ type DBClient interface {
 	BatchDeleteDevice(deviceIds []string) error
 	BatchUnBindDevice(ids []string) error
 	BatchBindDevice(ids []string, driverInstanceId string) error
+	BatchUpdateDeviceParentId(ids []string, parentId string) error
 	DeleteDeviceById(id string) error
 	UpdateDevice(ds models.Device) error
 	DeleteDeviceByCloudInstanceId(cloudInstanceId string) error
@@ -142,6 +143,48 @@ type DBClient interface {
 	UserDB
 	Scene
 	SystemMonitor
+	DeviceShadow
+	DeviceGroup
+	DeviceConnectLog
+	DeviceInvalidSample
+}
+
+type DeviceGroup interface {
+	SetDeviceTags(deviceId string, tags map[string]string) error
+	DeviceTagsByDeviceIds(deviceIds []string) ([]models.DeviceTag, error)
+	DeviceTagsDistinct() ([]models.DeviceTag, error)
+	DeleteDeviceRelationsByDeviceIds(deviceIds []string) error
+	AddDeviceGroup(group models.DeviceGroup) (models.DeviceGroup, error)
+	UpdateDeviceGroup(group models.DeviceGroup) error
+	DeviceGroupById(id string) (models.DeviceGroup, error)
+	DeleteDeviceGroupById(id string) error
+	DeviceGroupsSearch(offset int, limit int, req dtos.DeviceGroupSearchQueryRequest) ([]models.DeviceGroup, uint32, error)
+	AddDeviceGroupMembers(groupId string, deviceIds []string) error
+	DeleteDeviceGroupMembers(groupId string, deviceIds []string) error
+	DeviceGroupMembersByDeviceIds(deviceIds []string) ([]models.DeviceGroupMember, error)
+}
+
+type DeviceConnectLog interface {
+	AddDeviceConnectLog(log models.DeviceConnectLog) error
+	DeviceConnectLogsSearch(offset int, limit int, req dtos.DeviceConnectLogSearchQueryRequest) ([]models.DeviceConnectLog, uint32, error)
+	DeviceConnectLogsBetween(deviceIds []string, start, end int64) ([]models.DeviceConnectLog, error)
+	DeviceConnectLogsLastBefore(deviceIds []string, t int64) ([]models.DeviceConnectLog, error)
+	DeviceFlapCounts(start int64, limit int) ([]models.DeviceFlapCount, error)
+	DeleteDeviceConnectLogsByDeviceIds(deviceIds []string) error
+	DeleteDeviceConnectLogsBefore(t int64) error
+}
+
+type DeviceInvalidSample interface {
+	IncrDeviceInvalidSample(sample models.DeviceInvalidSample) error
+	DeviceInvalidSampleById(id string) (models.DeviceInvalidSample, error)
+	DeleteDeviceInvalidSamplesByDeviceIds(deviceIds []string) error
+}
+
+type DeviceShadow interface {
+	DeviceShadowById(id string) (models.DeviceShadow, error)
+	UpsertDeviceShadow(shadow models.DeviceShadow) error
+	BatchUpsertDeviceShadow(shadows []models.DeviceShadow) error
+	DeleteDeviceShadowById(id string) error
 }
 
 type SystemMonitor interface {
@@ -184,4 +227,17 @@ type Scene interface {
 
 	AddSceneLog(sceneLog models.SceneLog) (models.SceneLog, error)
 	SceneLogSearch(offset int, limit int, req dtos.SceneLogSearchQueryRequest) (sceneLogs []models.SceneLog, total uint32, edgeXErr error)
+
+	UpsertTimerJob(job models.TimerJob) error
+	TimerJobById(id string) (models.TimerJob, error)
+	DeleteTimerJobById(id string) error
+
+	AddSceneTemplate(template models.SceneTemplate) (models.SceneTemplate, error)
+	UpdateSceneTemplate(template models.SceneTemplate) error
+	SceneTemplateById(id string) (models.SceneTemplate, error)
+	DeleteSceneTemplateById(id string) error
+	SceneTemplateSearch(offset int, limit int, req dtos.SceneTemplateSearchQueryRequest) ([]models.SceneTemplate, uint32, error)
+	SceneInstancesByTemplateId(templateId string) ([]models.SceneInstance, error)
+	DeleteSceneInstanceById(id string) error
+	BatchUpsertSceneInstances(scenes []models.Scene, instances []models.SceneInstance) error
 }
@@ -5,12 +5,15 @@ import (
 	"github.com/winc-link/edge-driver-proto/driverdevice"
 	"github.com/winc-link/hummingbird/internal/dtos"
 	"github.com/winc-link/hummingbird/internal/models"
+	"sync"
 )
 
 type DeviceItf interface {
 	DeviceCtlItf
 	DeviceSyncItf
 	OpenApiDeviceItf
+	// Run 启动设备影子的批量写回
+	Run(ctx context.Context, wg *sync.WaitGroup)
 }
 
 type DeviceCtlItf interface {
@@ -34,12 +37,88 @@ type DeviceCtlItf interface {
 
 	DevicesBindProductId(ctx context.Context, req dtos.DevicesBindProductId) error
 
+	DeviceSubDevicesAdd(ctx context.Context, req dtos.DeviceTopoRequest) error
+
+	DeviceSubDevicesRemove(ctx context.Context, req dtos.DeviceTopoRequest) error
+
+	DeviceGroupAdd(ctx context.Context, req dtos.DeviceGroupAddRequest) (string, error)
+
+	DeviceGroupUpdate(ctx context.Context, req dtos.DeviceGroupUpdateRequest) error
+
+	DeviceGroupById(ctx context.Context, id string) (dtos.DeviceGroupResponse, error)
+
+	DeviceGroupsSearch(ctx context.Context, req dtos.DeviceGroupSearchQueryRequest) ([]dtos.DeviceGroupResponse, uint32, error)
+
+	DeviceGroupDelete(ctx context.Context, id string) error
+
+	DeviceGroupMembersAdd(ctx context.Context, req dtos.DeviceGroupMembersRequest) error
+
+	DeviceGroupMembersRemove(ctx context.Context, req dtos.DeviceGroupMembersRequest) error
+
+	DeviceGroupDevices(ctx context.Context, groupId, productId string) ([]models.Device, error)
+
+	DeviceGroupPropertySet(ctx context.Context, req dtos.DeviceGroupPropertySetRequest) ([]dtos.DeviceGroupOperateResult, error)
+
+	DeviceGroupServiceInvoke(ctx context.Context, req dtos.DeviceGroupServiceInvokeRequest) ([]dtos.DeviceGroupOperateResult, error)
+
+	DeviceGroupBindDriver(ctx context.Context, req dtos.DeviceGroupBindDriverRequest) error
+
+	DeviceGroupDeleteDevices(ctx context.Context, groupId string) error
+
+	DeviceTags(ctx context.Context) ([]dtos.DeviceTagResponse, error)
+
+	DeviceBatchPropertySet(ctx context.Context, req dtos.DeviceBatchPropertySetRequest) (string, error)
+
+	DeviceBatchServiceInvoke(ctx context.Context, req dtos.DeviceBatchServiceInvokeRequest) (string, error)
+
+	DeviceBatchJobById(ctx context.Context, jobId string) (dtos.DeviceBatchJobResponse, error)
+
 	ConnectIotPlatform(ctx context.Context, request *driverdevice.ConnectIotPlatformRequest) *driverdevice.ConnectIotPlatformResponse
 
 	DisConnectIotPlatform(ctx context.Context, request *driverdevice.DisconnectIotPlatformRequest) *driverdevice.DisconnectIotPlatformResponse
 
 	GetDeviceConnectStatus(ctx context.Context, request *driverdevice.GetDeviceConnectStatusRequest) *driverdevice.GetDeviceConnectStatusResponse
 
+	DeviceKeepAlive(ctx context.Context, deviceId string)
+
+	DevicesOfflineByDriveInstanceId(ctx context.Context, driveInstanceId string) error
+
+	DeviceConnectLogsSearch(ctx context.Context, req dtos.DeviceConnectLogSearchQueryRequest) ([]dtos.DeviceConnectLogResponse, uint32, error)
+
+	DeviceConnectLogsClean(ctx context.Context) error
+
+	DeviceAvailability(ctx context.Context, req dtos.DeviceAvailabilityRequest) (dtos.DeviceAvailabilityResponse, error)
+
+	ProductAvailability(ctx context.Context, req dtos.DeviceAvailabilityRequest) (dtos.ProductAvailabilityResponse, error)
+
+	DeviceFlapTop(ctx context.Context, start int64, limit int) ([]dtos.DeviceFlap, error)
+
+	DeviceThingModel(ctx context.Context, deviceId string) (dtos.DeviceThingModel, error)
+
+	ProductThingModelChanged(productId string)
+
+	DeviceThingModelScale(ctx context.Context, msg dtos.ThingModelMessage, thingModel *dtos.DeviceThingModel) dtos.ThingModelMessage
+
+	DeviceThingModelValidate(ctx context.Context, msg dtos.ThingModelMessage, thingModel *dtos.DeviceThingModel) (dtos.ThingModelMessage, bool, error)
+
+	DeviceComputedProperties(ctx context.Context, msg dtos.ThingModelMessage, thingModel *dtos.DeviceThingModel) dtos.ThingModelMessage
+
+	DeviceInvalidSample(ctx context.Context, deviceId string) (dtos.DeviceInvalidSampleResponse, error)
+
+	DeviceInvalidSampleReset(ctx context.Context, deviceId string) error
+
+	DeviceShadowById(ctx context.Context, deviceId string) (dtos.DeviceShadowResponse, error)
+
+	DeviceShadowUpdateDesired(ctx context.Context, req dtos.DeviceShadowDesiredRequest) (dtos.DeviceShadowResponse, error)
+
+	DeviceShadowDeleteDesired(ctx context.Context, deviceId string, codes []string) (dtos.DeviceShadowResponse, error)
+
+	DeviceShadowReport(ctx context.Context, msg dtos.ThingModelMessage, thingModel *dtos.DeviceThingModel)
+
+	DeviceShadowDesired(ctx context.Context, msg dtos.ThingModelMessage)
+
+	DeviceShadowDeliver(ctx context.Context, deviceId string, force bool)
+
 	DeviceMqttAuthInfo(ctx context.Context, id string) (dtos.DeviceAuthInfoResponse, error)
 
 	AddMqttAuth(ctx context.Context, req dtos.AddMqttAuthInfoRequest) (string, error)
@@ -52,6 +131,8 @@ type DeviceCtlItf interface {
 
 	DevicesImport(ctx context.Context, file *dtos.ImportFile, productId, driverInstanceId string) (int64, error)
 
+	DevicesExport(ctx context.Context, req dtos.DeviceExportRequest) (*dtos.ExportFile, error)
+
 	UploadValidated(ctx context.Context, file *dtos.ImportFile) error
 
 	DevicesReportMsgGather(ctx context.Context) error
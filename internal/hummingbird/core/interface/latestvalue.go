@@ -0,0 +1,29 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package interfaces
+
+import (
+	"github.com/winc-link/hummingbird/internal/dtos"
+)
+
+// LatestValueItf 设备属性最新值缓存，当前值的查询不再访问时序库
+type LatestValueItf interface {
+	// Update 使用上报消息中的属性更新缓存，时间更早的值不覆盖
+	Update(msg dtos.ThingModelMessage)
+	// Set 更新设备的属性，时间更早的值不覆盖
+	Set(deviceId string, values map[string]dtos.ReportData)
+	Get(deviceId, code string) (dtos.ReportData, bool)
+	DeleteDevice(deviceId string)
+}
@@ -26,6 +26,8 @@ type MessageStores interface {
 
 type MessageItf interface {
 	TyCloudMqttItf
+	// MessageBufferStats 消息总线磁盘队列的统计
+	MessageBufferStats() dtos.MessageBufferStats
 }
 
 type PublishCallback func(ctx context.Context, params ...interface{}) (bool, interface{})
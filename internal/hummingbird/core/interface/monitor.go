@@ -21,4 +21,6 @@ import (
 
 type MonitorItf interface {
 	GetSystemMetrics(ctx context.Context, query dtos.SystemMetricsQuery) (dtos.SystemMetricsResponse, error)
+	// GetStorageStats 各产品时序数据占用的存储空间及保存天数
+	GetStorageStats(ctx context.Context) (dtos.StorageStats, error)
 }
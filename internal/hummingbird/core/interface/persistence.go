@@ -1,11 +1,16 @@
 package interfaces
 
 import (
+	"context"
 	"github.com/winc-link/hummingbird/internal/dtos"
 )
 
 type PersistItf interface {
 	PersistDeviceItf
+	// DataRetentionCompact 按保存天数清理过期的时序数据
+	DataRetentionCompact(ctx context.Context) error
+	// WriteBufferStats 时序数据写缓冲的统计
+	WriteBufferStats() dtos.WriteBufferStats
 }
 
 type PersistDeviceItf interface {
@@ -30,11 +30,25 @@ type SceneApp interface {
 	DelSceneById(ctx context.Context, sceneId string) error
 	SceneSearch(ctx context.Context, req dtos.SceneSearchQueryRequest) ([]models.Scene, uint32, error)
 	CheckSceneByDeviceId(ctx context.Context, deviceId string) error
+	CheckSceneByDeviceGroupId(ctx context.Context, groupId string) error
+	RefreshScenesByDeviceGroupId(ctx context.Context, groupId string) error
 	SceneLogSearch(ctx context.Context, req dtos.SceneLogSearchQueryRequest) ([]models.SceneLog, uint32, error)
 	EkuiperNotify(ctx context.Context, req map[string]interface{}) error
+
+	SceneTemplateAdd(ctx context.Context, req dtos.SceneTemplateAddRequest) (string, error)
+	SceneTemplateUpdate(ctx context.Context, req dtos.SceneTemplateUpdateRequest) error
+	SceneTemplateById(ctx context.Context, templateId string) (models.SceneTemplate, error)
+	SceneTemplateSearch(ctx context.Context, req dtos.SceneTemplateSearchQueryRequest) ([]models.SceneTemplate, uint32, error)
+	SceneTemplateDelete(ctx context.Context, templateId string) error
+	SceneTemplateInstances(ctx context.Context, templateId string) ([]models.SceneInstance, error)
+	SceneTemplateInstantiate(ctx context.Context, templateId string, req dtos.SceneInstantiateRequest) (dtos.SceneInstantiateResponse, error)
+	SceneTemplateSync(ctx context.Context, templateId string) (dtos.SceneInstantiateResponse, error)
+	SceneInstanceImportTemplateDownload(ctx context.Context, templateId string) (*dtos.ExportFile, error)
+	SceneInstancesImport(ctx context.Context, templateId string, file *dtos.ImportFile, start bool) (dtos.SceneInstantiateResponse, error)
 }
 
 type ConJob interface {
 	AddJobToRunQueue(j *jobs.JobSchedule) error
 	DeleteJob(id string)
+	JobList(upcoming int) []dtos.TimerJobResponse
 }
@@ -31,4 +31,6 @@ type ThingModelCtlItf interface {
 	OpenApiAddThingModel(ctx context.Context, req dtos.OpenApiThingModelAddOrUpdateReq) error
 	OpenApiQueryThingModel(ctx context.Context, productId string) (dtos.OpenApiQueryThingModel, error)
 	OpenApiDeleteThingModel(ctx context.Context, req dtos.OpenApiThingModelDeleteReq) error
+	ThingModelExport(ctx context.Context, req dtos.ThingModelExportRequest) (interface{}, error)
+	ThingModelImport(ctx context.Context, req dtos.ThingModelImportRequest) (dtos.ThingModelImportResponse, error)
 }
@@ -0,0 +1,146 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/application/datamigration"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/bootstrap/database"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/config"
+	"github.com/winc-link/hummingbird/internal/hummingbird/core/container"
+	"github.com/winc-link/hummingbird/internal/pkg/bootstrap"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	pkgContainer "github.com/winc-link/hummingbird/internal/pkg/container"
+	"github.com/winc-link/hummingbird/internal/pkg/di"
+	"github.com/winc-link/hummingbird/internal/pkg/flags"
+	"github.com/winc-link/hummingbird/internal/pkg/handlers"
+	"github.com/winc-link/hummingbird/internal/pkg/startup"
+)
+
+const migrateUsage = "Migrate Options:\n" +
+	"    --source <type>                 Source data database type leveldb/tstorage/tdengine/sqlite, defaults to the configured one\n" +
+	"    --source-dsn <dsn>              Source tdengine dsn\n" +
+	"    --source-datasource <path>      Source leveldb/tstorage/sqlite data source\n" +
+	"    --target <type>                 Target data database type, defaults to the configured one\n" +
+	"    --target-dsn <dsn>              Target tdengine dsn\n" +
+	"    --target-datasource <path>      Target leveldb/tstorage/sqlite data source\n" +
+	"    --start <ms>                    Start of the time range in milliseconds\n" +
+	"    --end <ms>                      End of the time range in milliseconds, defaults to now\n" +
+	"    --devices <id,id>               Only migrate these devices\n" +
+	"    --resume                        Resume from the checkpoint of the last migration\n"
+
+// migrate 不启动服务，直接在命令行运行迁移。leveldb 和 tstorage 不能被多个进程同时打开，需要先停止服务
+type migrate struct {
+	req dtos.DataMigrationRequest
+	err error
+}
+
+// Migrate hummingbird-core migrate [options]，迁移完成或失败后退出
+func Migrate(ctx context.Context, cancel context.CancelFunc, args []string) {
+	m := &migrate{}
+	var devices string
+	f := flags.NewWithUsage(migrateUsage)
+	f.FlagSet.StringVar(&m.req.Source.Type, "source", "", "")
+	f.FlagSet.StringVar(&m.req.Source.Dsn, "source-dsn", "", "")
+	f.FlagSet.StringVar(&m.req.Source.DataSource, "source-datasource", "", "")
+	f.FlagSet.StringVar(&m.req.Target.Type, "target", "", "")
+	f.FlagSet.StringVar(&m.req.Target.Dsn, "target-dsn", "", "")
+	f.FlagSet.StringVar(&m.req.Target.DataSource, "target-datasource", "", "")
+	f.FlagSet.Int64Var(&m.req.Start, "start", 0, "")
+	f.FlagSet.Int64Var(&m.req.End, "end", 0, "")
+	f.FlagSet.StringVar(&devices, "devices", "", "")
+	f.FlagSet.BoolVar(&m.req.Resume, "resume", false, "")
+	f.Parse(args)
+	if devices != "" {
+		m.req.DeviceIds = strings.Split(devices, ",")
+	}
+
+	configuration := &config.ConfigurationStruct{}
+	di.GContainer = di.NewContainer(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return configuration
+		},
+	})
+	startupTimer := startup.NewStartUpTimer(constants.CoreServiceKey)
+
+	bootstrap.Run(
+		ctx,
+		cancel,
+		f,
+		constants.CoreServiceKey,
+		constants.ConfigStemCore+constants.ConfigMajorVersion,
+		configuration,
+		startupTimer,
+		di.GContainer,
+		[]handlers.BootstrapHandler{
+			m.BootstrapHandler,
+		})
+	if m.err != nil {
+		fmt.Fprintln(os.Stderr, m.err)
+		os.Exit(1)
+	}
+}
+
+func (m *migrate) BootstrapHandler(ctx context.Context, wg *sync.WaitGroup, _ startup.Timer, dic *di.Container) bool {
+	lc := pkgContainer.LoggingClientFrom(dic.Get)
+	configuration := container.ConfigurationFrom(dic.Get)
+	dbClient, err := database.NewDatabase(configuration).NewDBClient(lc)
+	if err != nil {
+		m.err = err
+		return false
+	}
+	migrator, err := datamigration.NewMigrator(lc, dbClient, configuration.DataMigration, m.req)
+	if err != nil {
+		dbClient.CloseSession()
+		m.err = err
+		return false
+	}
+	clients, err := datamigration.OpenClients(migrator.Request(), configuration, nil, lc)
+	if err != nil {
+		dbClient.CloseSession()
+		m.err = err
+		return false
+	}
+	migrator.OnProgress = func(p dtos.DataMigrationProgress) {
+		fmt.Printf("%s %s -> %s: devices %d/%d, properties %d, events %d, services %d, mismatches %d\n",
+			p.Status, p.Source, p.Target, p.MigratedDevices, p.TotalDevices, p.Properties, p.Events, p.Services, len(p.Mismatches))
+	}
+
+	cancel := pkgContainer.CancelFuncFrom(dic.Get)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		defer dbClient.CloseSession()
+		defer clients.Close()
+		m.err = migrator.Run(ctx, clients.Source, clients.Target)
+		mismatches := migrator.Progress().Mismatches
+		for _, mismatch := range mismatches {
+			fmt.Printf("mismatch device %s %s %s: source %d, target %d\n",
+				mismatch.DeviceId, mismatch.Kind, mismatch.Code, mismatch.Source, mismatch.Target)
+		}
+		// 条数不一致时以非 0 退出，脚本可以检测到
+		if m.err == nil && len(mismatches) > 0 {
+			m.err = fmt.Errorf("data migration completed with %d mismatches", len(mismatches))
+		}
+	}()
+	return true
+}
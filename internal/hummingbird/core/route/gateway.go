@@ -48,6 +48,12 @@ func RegisterGateway(engine *gin.Engine, dic *di.Container) {
 	{
 		/******* 运维监控 *******/
 		v1Auth.GET("/metrics/system", ctl.SystemMetricsHandler)
+		v1Auth.GET("/metrics/storage", ctl.StorageStatsHandler)
+		v1Auth.GET("/metrics/write-buffer", ctl.WriteBufferStatsHandler)
+		v1Auth.GET("/metrics/message-buffer", ctl.MessageBufferStatsHandler)
+		v1Auth.POST("/data-migration", ctl.DataMigrationStart)
+		v1Auth.GET("/data-migration", ctl.DataMigrationProgress)
+		v1Auth.DELETE("/data-migration", ctl.DataMigrationStop)
 	}
 
 	/******* 镜像仓库管理 *******/
@@ -83,9 +89,11 @@ func RegisterGateway(engine *gin.Engine, dic *di.Container) {
 		v1Auth.GET("products", ctl.ProductsSearch)
 		v1Auth.GET("product/:productId", ctl.ProductById)
 		v1Auth.POST("product", ctl.ProductAdd)
+		v1Auth.PUT("product/:productId", ctl.ProductUpdate)
 		v1Auth.POST("product-release/:productId", ctl.ProductRelease)
 		v1Auth.POST("product-unrelease/:productId", ctl.ProductUnRelease)
 		v1Auth.DELETE("product/:productId", ctl.ProductDelete)
+		v1Auth.GET("product/:productId/availability", ctl.ProductAvailability)
 		v1Auth.GET("iot-platform", ctl.IotPlatform)
 	}
 	/*******产品物模型管理 *******/
@@ -95,6 +103,8 @@ func RegisterGateway(engine *gin.Engine, dic *di.Container) {
 		v1Auth.PUT("thingmodel", ctl.ThingModelUpdate)
 		v1Auth.DELETE("thingmodel", ctl.ThingModelDelete)
 		v1Auth.GET("thingmodel/unit", ctl.ThingModelUnit)
+		v1Auth.GET("thingmodel/export", ctl.ThingModelExport)
+		v1Auth.POST("thingmodel/import", ctl.ThingModelImport)
 		v1Auth.POST("thingmodel/unit-sync", ctl.ThingModelUnitSync)                       //废弃
 		v1Auth.POST("thingmodel/docs-sync", ctl.ThingModelDocsSync)                       //废弃
 		v1Auth.POST("thingmodel/quicknavigation-sync", ctl.ThingModelQuickNavigationSync) //废弃
@@ -118,12 +128,45 @@ func RegisterGateway(engine *gin.Engine, dic *di.Container) {
 		v1Auth.GET("device/status-template", ctl.DeviceStatusTemplate)
 		v1Auth.GET("devices/import-template", ctl.DeviceImportTemplateDownload)
 		v1Auth.POST("devices/import", ctl.DevicesImport)
+		v1Auth.GET("devices/export", ctl.DevicesExport)
 		v1Auth.POST("device/upload-validated", ctl.UploadValidated)
 		v1Auth.PUT("devices/bind-driver", ctl.DevicesBindDriver)
 		v1Auth.PUT("devices/unbind-driver", ctl.DevicesUnBindDriver)
 		v1Auth.PUT("devices/bind-product", ctl.DevicesBindByProductId)
+		v1Auth.POST("devices/batch-property-set", ctl.DeviceBatchPropertySet)
+		v1Auth.POST("devices/batch-service-invoke", ctl.DeviceBatchServiceInvoke)
+		v1Auth.GET("devices/batch-job/:jobId", ctl.DeviceBatchJobById)
+		v1Auth.POST("devices/data-export", ctl.DataExport)
+		v1Auth.GET("devices/data-export/:jobId", ctl.DataExportJobById)
+		v1Auth.GET("devices/data-export/:jobId/download", ctl.DataExportDownload)
+		v1Auth.GET("device/:deviceId/shadow", ctl.DeviceShadowById)
+		v1Auth.PUT("device/:deviceId/shadow", ctl.DeviceShadowUpdate)
+		v1Auth.DELETE("device/:deviceId/shadow/desired", ctl.DeviceShadowDesiredDelete)
+		v1Auth.GET("device/:deviceId/connect-logs", ctl.DeviceConnectLogsSearch)
+		v1Auth.GET("device/:deviceId/availability", ctl.DeviceAvailability)
+		v1Auth.GET("device/:deviceId/invalid-samples", ctl.DeviceInvalidSample)
+		v1Auth.DELETE("device/:deviceId/invalid-samples", ctl.DeviceInvalidSampleReset)
+		v1Auth.GET("device/:deviceId/sub-devices", ctl.DeviceSubDevicesSearch)
+		v1Auth.POST("device/:deviceId/sub-devices", ctl.DeviceSubDevicesAdd)
+		v1Auth.DELETE("device/:deviceId/sub-devices", ctl.DeviceSubDevicesRemove)
 
 	}
+	/*******设备分组 *******/
+	{
+		v1Auth.POST("device-group", ctl.DeviceGroupAdd)
+		v1Auth.PUT("device-group", ctl.DeviceGroupUpdate)
+		v1Auth.GET("device-group/:groupId", ctl.DeviceGroupById)
+		v1Auth.GET("device-groups", ctl.DeviceGroupsSearch)
+		v1Auth.DELETE("device-group/:groupId", ctl.DeviceGroupDelete)
+		v1Auth.GET("device-group/:groupId/devices", ctl.DeviceGroupDevicesSearch)
+		v1Auth.POST("device-group/:groupId/devices", ctl.DeviceGroupMembersAdd)
+		v1Auth.DELETE("device-group/:groupId/devices", ctl.DeviceGroupMembersRemove)
+		v1Auth.POST("device-group/:groupId/property-set", ctl.DeviceGroupPropertySet)
+		v1Auth.POST("device-group/:groupId/service-invoke", ctl.DeviceGroupServiceInvoke)
+		v1Auth.PUT("device-group/:groupId/bind-driver", ctl.DeviceGroupBindDriver)
+		v1Auth.DELETE("device-group/:groupId/delete-devices", ctl.DeviceGroupDeleteDevices)
+		v1Auth.GET("device-tags", ctl.DeviceTags)
+	}
 	/*******品类、物模型同步接口 *******/
 	{
 		v1Auth.GET("category-template", ctl.CategoryTemplateSearch)
@@ -184,6 +227,18 @@ func RegisterGateway(engine *gin.Engine, dic *di.Container) {
 		v1Auth.POST("scene/:sceneId/stop", ctl.SceneStop)
 		v1Auth.DELETE("scene/:sceneId", ctl.DeleteScene)
 		v1Auth.GET("scene/:sceneId/log", ctl.SceneLogSearch)
+		v1Auth.GET("scene-jobs", ctl.SceneJobs)
+
+		v1Auth.POST("scene-template", ctl.SceneTemplateAdd)
+		v1Auth.PUT("scene-template", ctl.SceneTemplateUpdate)
+		v1Auth.GET("scene-template/:templateId", ctl.SceneTemplateById)
+		v1Auth.GET("scene-template", ctl.SceneTemplateSearch)
+		v1Auth.DELETE("scene-template/:templateId", ctl.SceneTemplateDelete)
+		v1Auth.GET("scene-template/:templateId/instances", ctl.SceneTemplateInstances)
+		v1Auth.POST("scene-template/:templateId/instances", ctl.SceneTemplateInstantiate)
+		v1Auth.POST("scene-template/:templateId/sync", ctl.SceneTemplateSync)
+		v1Auth.GET("scene-template/:templateId/import-template", ctl.SceneInstanceImportTemplateDownload)
+		v1Auth.POST("scene-template/:templateId/import", ctl.SceneInstancesImport)
 	}
 	/*******文档中心（sdk） *******/
 	{
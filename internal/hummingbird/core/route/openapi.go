@@ -64,6 +64,10 @@ func RegisterOpenApi(engine *gin.Engine, dic *di.Container) {
 		v1.GET("/device/:deviceId", ctl.OpenApiDeviceById)
 		//删除指定设备。
 		v1.DELETE("/device/:deviceId", ctl.OpenApiDeleteDevice)
+		//查询设备影子。
+		v1.GET("/device/:deviceId/shadow", ctl.OpenApiDeviceShadowById)
+		//修改设备影子的属性期望值，设备离线时在设备上线后下发。
+		v1.PUT("/device/:deviceId/shadow", ctl.OpenApiUpdateDeviceShadow)
 		//获取设备的运行状态。
 		//v1.GET("/deviceStatus/:deviceId", ctl.OpenApiDeviceStatus)
 
@@ -78,6 +82,10 @@ func RegisterOpenApi(engine *gin.Engine, dic *di.Container) {
 		v1.GET("/thingModel", ctl.OpenApiThingModel)
 		//DeleteThingModel
 		v1.DELETE("/thingModel", ctl.OpenApiDeleteThingModel)
+		//导出产品物模型，支持阿里云 TSL 格式
+		v1.GET("/thingModel/export", ctl.OpenApiExportThingModel)
+		//导入产品物模型，apply 为 false 时只返回差异
+		v1.POST("/thingModel/import", ctl.OpenApiImportThingModel)
 
 	}
 	//物模型使用的API
@@ -88,7 +96,13 @@ func RegisterOpenApi(engine *gin.Engine, dic *di.Container) {
 		v1.POST("/setDeviceProperty", ctl.OpenApiSetDeviceProperty)
 		//调用设备的服务。
 		v1.POST("/invokeThingService", ctl.OpenApiInvokeThingService)
-		//查询设备的属性历史数据。
+		//批量设置设备的属性，返回任务ID。
+		v1.POST("/batchSetDeviceProperty", ctl.OpenApiBatchSetDeviceProperty)
+		//批量调用设备的服务，返回任务ID。
+		v1.POST("/batchInvokeThingService", ctl.OpenApiBatchInvokeThingService)
+		//查询批量下发任务的执行结果。
+		v1.GET("/batchJob/:jobId", ctl.OpenApiBatchJobById)
+		//查询设备的属性历史数据，interval 不为空时按时间窗口聚合。
 		v1.GET("/queryDevicePropertyData", ctl.OpenApiQueryDevicePropertyData)
 		//查询设备的事件历史数据。
 		v1.GET("/queryDeviceEventData", ctl.OpenApiQueryDeviceEventData)
@@ -46,6 +46,11 @@ type TypeSpecArray struct {
 	Item Item   `json:"item,omitempty"`
 }
 
+// ItemTypeSpec 数组元素的数据类型
+func (t TypeSpecArray) ItemTypeSpec() TypeSpec {
+	return TypeSpec{Type: constants.SpecsType(t.Item.Type), Specs: t.Item.Specs}
+}
+
 func (t *TypeSpecArray) TransformTostring() string {
 	b, _ := json.Marshal(t)
 	return string(b)
@@ -78,7 +83,8 @@ func (t *TypeSpecDate) TransformTostring() string {
 }
 
 type Item struct {
-	Type string `json:"type,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Specs string `json:"specs,omitempty"` // 数组元素的 specs，格式与 TypeSpec.Specs 相同
 }
 
 func (c Item) Value() (driver.Value, error) {
@@ -90,19 +96,26 @@ func (c *Item) Scan(value interface{}) error {
 }
 
 type Properties struct {
-	Id          string   `json:"id" gorm:"id;primaryKey;not null;type:string;size:255;comment:主键"`
-	ProductId   string   `json:"product_id" gorm:"type:string;size:255;comment:产品ID"`
-	Name        string   `json:"name" gorm:"type:string;size:255;comment:名字"`
-	Code        string   `json:"code" gorm:"type:string;size:255;comment:标识符"`
-	AccessMode  string   `json:"access_mode" gorm:"type:string;size:50;comment:读写模型"`
-	Require     bool     `json:"require" gorm:"comment:是否必须"`
-	TypeSpec    TypeSpec `json:"type_spec" gorm:"type:text;comment:属性物模型详情"`
-	Description string   `json:"description" gorm:"type:text;comment:描述"`
-	Tag         string   `json:"tag" gorm:"type:string;size:50;comment:标签"`
-	System      bool     `json:"system" gorm:"comment:系统内置"`
+	Id          string          `json:"id" gorm:"id;primaryKey;not null;type:string;size:255;comment:主键"`
+	ProductId   string          `json:"product_id" gorm:"type:string;size:255;comment:产品ID"`
+	Name        string          `json:"name" gorm:"type:string;size:255;comment:名字"`
+	Code        string          `json:"code" gorm:"type:string;size:255;comment:标识符"`
+	AccessMode  string          `json:"access_mode" gorm:"type:string;size:50;comment:读写模型"`
+	Require     bool            `json:"require" gorm:"comment:是否必须"`
+	TypeSpec    TypeSpec        `json:"type_spec" gorm:"type:text;comment:属性物模型详情"`
+	Description string          `json:"description" gorm:"type:text;comment:描述"`
+	Tag         string          `json:"tag" gorm:"type:string;size:50;comment:标签"`
+	System      bool            `json:"system" gorm:"comment:系统内置"`
+	Expression  string          `json:"expression" gorm:"type:text;comment:计算属性表达式"`
+	Scaling     PropertyScaling `json:"scaling" gorm:"type:text;comment:原始值转换工程值"`
 	Timestamps
 }
 
+// IsComputed 计算属性的值由表达式根据其他属性计算，设备不上报
+func (p Properties) IsComputed() bool {
+	return p.Expression != ""
+}
+
 func (c TypeSpec) Value() (driver.Value, error) {
 	return GormValueWrap(c)
 }
@@ -49,6 +49,7 @@ type Rule struct {
 	Trigger   constants.Trigger `json:"trigger"` //触发方式
 	ProductId string            `json:"product_id"`
 	DeviceId  string            `json:"device_id"`
+	GroupId   string            `json:"group_id"`
 	Option    MapStringString   `json:"option"`
 }
 
@@ -41,6 +41,8 @@ type Device struct {
 	InstallLocation string                 `gorm:"type:string;size:255;comment:安装地址"`
 	LastSyncTime    int64                  `gorm:"comment:最后一次同步时间"`
 	LastOnlineTime  int64                  `gorm:"comment:最后一次在线时间"`
+	KeepAlive       int64                  `gorm:"comment:心跳超时时间(秒)，0表示使用产品配置"`
+	ParentId        string                 `gorm:"type:string;size:255;comment:父设备(网关)ID"`
 	Product         Product                `gorm:"foreignKey:ProductId"`
 }
 
@@ -75,6 +77,9 @@ func (table *Device) TransformToDriverDevice() *driverdevice.Device {
 	driverDevice.ProductId = table.ProductId
 	driverDevice.Secret = table.Secret
 	driverDevice.Platform = table.Platform.TransformToDriverDevicePlatform()
+	if table.ParentId != "" {
+		driverDevice.External = map[string]string{constants.DeviceExternalParentId: table.ParentId}
+	}
 	return driverDevice
 }
 
@@ -0,0 +1,44 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package models
+
+import "github.com/winc-link/hummingbird/internal/pkg/constants"
+
+// DeviceConnectLog 设备上下线记录，只记录状态发生变化的事件
+type DeviceConnectLog struct {
+	Id        int64                         `gorm:"primaryKey;autoIncrement;comment:主键"`
+	DeviceId  string                        `gorm:"index:idx_device_connect_log_device;not null;type:string;size:255;comment:设备ID"`
+	ProductId string                        `gorm:"index;type:string;size:255;comment:产品ID"`
+	Status    string                        `gorm:"type:string;size:50;comment:上线(online)或离线(offline)"`
+	Reason    constants.DeviceConnectReason `gorm:"type:string;size:50;comment:状态变化原因"`
+	Time      int64                         `gorm:"index:idx_device_connect_log_device;index;comment:状态变化时间(毫秒)"`
+}
+
+func (d *DeviceConnectLog) TableName() string {
+	return "device_connect_log"
+}
+
+func (d *DeviceConnectLog) Get() interface{} {
+	return *d
+}
+
+func (d *DeviceConnectLog) Online() bool {
+	return d.Status == constants.DeviceOnline
+}
+
+// DeviceFlapCount 设备在一段时间内的离线次数
+type DeviceFlapCount struct {
+	DeviceId  string
+	FlapCount int
+}
@@ -0,0 +1,76 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package models
+
+import "github.com/winc-link/hummingbird/internal/pkg/constants"
+
+// DeviceTag 设备标签，同一设备的标签键唯一
+type DeviceTag struct {
+	DeviceId string `gorm:"primaryKey;not null;type:string;size:255;comment:设备ID"`
+	TagKey   string `gorm:"primaryKey;not null;type:string;size:255;comment:标签键"`
+	TagValue string `gorm:"index;type:string;size:255;comment:标签值"`
+}
+
+func (d *DeviceTag) TableName() string {
+	return "device_tag"
+}
+
+func (d *DeviceTag) Get() interface{} {
+	return *d
+}
+
+// DeviceGroup 设备分组，静态分组的设备由用户维护，动态分组的设备为标签全部匹配的设备
+type DeviceGroup struct {
+	Timestamps  `gorm:"embedded"`
+	Id          string                    `gorm:"id;primaryKey;not null;type:string;size:255;comment:主键"`
+	Name        string                    `gorm:"type:string;size:255;comment:名字"`
+	Description string                    `gorm:"type:text;comment:描述"`
+	Type        constants.DeviceGroupType `gorm:"type:string;size:50;comment:分组类型"`
+	Tags        MapStringString           `gorm:"type:text;comment:动态分组的标签条件"`
+}
+
+func (d *DeviceGroup) TableName() string {
+	return "device_group"
+}
+
+func (d *DeviceGroup) Get() interface{} {
+	return *d
+}
+
+// Match 设备标签是否满足动态分组的标签条件
+func (d *DeviceGroup) Match(tags map[string]string) bool {
+	if d.Type != constants.DeviceGroupDynamic || len(d.Tags) == 0 {
+		return false
+	}
+	for k, v := range d.Tags {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// DeviceGroupMember 静态分组的设备
+type DeviceGroupMember struct {
+	GroupId  string `gorm:"primaryKey;not null;type:string;size:255;comment:分组ID"`
+	DeviceId string `gorm:"primaryKey;not null;type:string;size:255;comment:设备ID"`
+}
+
+func (d *DeviceGroupMember) TableName() string {
+	return "device_group_member"
+}
+
+func (d *DeviceGroupMember) Get() interface{} {
+	return *d
+}
@@ -0,0 +1,34 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+// DeviceInvalidSample 设备上报数据未通过物模型校验的统计，每个不合法的属性或事件计一次
+type DeviceInvalidSample struct {
+	Timestamps   `gorm:"embedded"`
+	Id           string `gorm:"id;primaryKey;not null;type:string;size:255;comment:主键(设备ID)"`
+	ProductId    string `gorm:"type:string;size:255;comment:产品ID"`
+	InvalidCount int64  `gorm:"comment:不合法数据累计次数"`
+	LastCode     string `gorm:"type:string;size:255;comment:最近一次不合法的属性或事件标识符"`
+	LastReason   string `gorm:"type:text;comment:最近一次不合法的原因"`
+	LastTime     int64  `gorm:"comment:最近一次不合法数据的时间"`
+}
+
+func (d *DeviceInvalidSample) TableName() string {
+	return "device_invalid_sample"
+}
+
+func (d *DeviceInvalidSample) Get() interface{} {
+	return *d
+}
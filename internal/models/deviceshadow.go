@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// DeviceShadow 设备影子，desired 为平台期望的属性值，reported 为设备最近一次上报的属性值
+type DeviceShadow struct {
+	Timestamps `gorm:"embedded"`
+	Id         string      `gorm:"id;primaryKey;not null;type:string;size:255;comment:主键(设备ID)"`
+	Desired    ShadowState `gorm:"type:text;comment:期望值"`
+	Reported   ShadowState `gorm:"type:text;comment:上报值"`
+	Version    int64       `gorm:"comment:版本号，desired 每次修改加一"`
+}
+
+func (d *DeviceShadow) TableName() string {
+	return "device_shadow"
+}
+
+func (d *DeviceShadow) Get() interface{} {
+	return *d
+}
+
+// Delta desired 中与 reported 不一致的属性，即需要下发给设备的部分
+func (d *DeviceShadow) Delta() map[string]interface{} {
+	delta := make(map[string]interface{})
+	for code, desired := range d.Desired {
+		if reported, ok := d.Reported[code]; ok && reported.Equal(desired) {
+			continue
+		}
+		delta[code] = desired.Value
+	}
+	return delta
+}
+
+// Clone 复制 desired 和 reported，属性值本身不复制
+func (d *DeviceShadow) Clone() DeviceShadow {
+	shadow := *d
+	shadow.Desired = make(ShadowState, len(d.Desired))
+	for code, value := range d.Desired {
+		shadow.Desired[code] = value
+	}
+	shadow.Reported = make(ShadowState, len(d.Reported))
+	for code, value := range d.Reported {
+		shadow.Reported[code] = value
+	}
+	return shadow
+}
+
+type ShadowState map[string]ShadowValue
+
+type ShadowValue struct {
+	Value interface{} `json:"value"`
+	Time  int64       `json:"time"`
+}
+
+// Equal 按值的字符串形式比较，避免数字与字符串形式的同一个值被认为不一致
+func (v ShadowValue) Equal(o ShadowValue) bool {
+	return fmt.Sprint(v.Value) == fmt.Sprint(o.Value)
+}
+
+func (c ShadowState) Value() (driver.Value, error) {
+	return GormValueWrap(c)
+}
+
+func (c *ShadowState) Scan(value interface{}) error {
+	return GormScanWrap(value, c)
+}
@@ -21,24 +21,27 @@ import (
 
 type Product struct {
 	Timestamps      `gorm:"embedded"`
-	Id              string                    `gorm:"id;primaryKey;not null;type:string;size:255;comment:主键"`
-	Name            string                    `gorm:"type:string;size:255;comment:名字"`
-	Key             string                    `gorm:"type:string;size:255;comment:产品标识"`
-	CloudProductId  string                    `gorm:"type:string;size:255;comment:云产品ID"`
-	CloudInstanceId string                    `gorm:"index;type:string;size:255;comment:云实例ID"`
-	Platform        constants.IotPlatform     `gorm:"type:string;size:255;comment:平台"`
-	Protocol        string                    `gorm:"type:string;size:255;comment:协议"`
-	NodeType        constants.ProductNodeType `gorm:"type:string;size:255;comment:节点类型"`
-	NetType         constants.ProductNetType  `gorm:"type:string;size:255;comment:网络类型"`
-	DataFormat      string                    `gorm:"type:string;size:255;comment:数据类型"`
-	LastSyncTime    int64                     `gorm:"comment:最后一次同步时间"`
-	Factory         string                    `gorm:"type:string;size:255;comment:工厂名称"`
-	Description     string                    `gorm:"type:text;comment:描述"`
-	Status          constants.ProductStatus   `gorm:"type:string;size:255;comment:产品状态"`
-	Extra           MapStringString           `gorm:"type:string;size:255;comment:扩展字段"`
-	Properties      []Properties              `gorm:"foreignKey:ProductId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 物模型的属性列表
-	Events          []Events                  `gorm:"foreignKey:ProductId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 物模型的事件列表
-	Actions         []Actions                 `gorm:"foreignKey:ProductId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 物模型的动作列表
+	Id              string                           `gorm:"id;primaryKey;not null;type:string;size:255;comment:主键"`
+	Name            string                           `gorm:"type:string;size:255;comment:名字"`
+	Key             string                           `gorm:"type:string;size:255;comment:产品标识"`
+	CloudProductId  string                           `gorm:"type:string;size:255;comment:云产品ID"`
+	CloudInstanceId string                           `gorm:"index;type:string;size:255;comment:云实例ID"`
+	Platform        constants.IotPlatform            `gorm:"type:string;size:255;comment:平台"`
+	Protocol        string                           `gorm:"type:string;size:255;comment:协议"`
+	NodeType        constants.ProductNodeType        `gorm:"type:string;size:255;comment:节点类型"`
+	NetType         constants.ProductNetType         `gorm:"type:string;size:255;comment:网络类型"`
+	DataFormat      string                           `gorm:"type:string;size:255;comment:数据类型"`
+	LastSyncTime    int64                            `gorm:"comment:最后一次同步时间"`
+	Factory         string                           `gorm:"type:string;size:255;comment:工厂名称"`
+	Description     string                           `gorm:"type:text;comment:描述"`
+	Status          constants.ProductStatus          `gorm:"type:string;size:255;comment:产品状态"`
+	Extra           MapStringString                  `gorm:"type:string;size:255;comment:扩展字段"`
+	KeepAlive       int64                            `gorm:"comment:心跳超时时间(秒)，0表示不检测"`
+	ValidateMode    constants.ThingModelValidateMode `gorm:"type:string;size:50;comment:上报数据物模型校验方式"`
+	Retention       DataRetention                    `gorm:"type:string;size:255;comment:时序数据保存天数，0表示使用全局配置"`
+	Properties      []Properties                     `gorm:"foreignKey:ProductId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 物模型的属性列表
+	Events          []Events                         `gorm:"foreignKey:ProductId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 物模型的事件列表
+	Actions         []Actions                        `gorm:"foreignKey:ProductId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 物模型的动作列表
 }
 
 func (d *Product) TableName() string {
@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// RetentionForever 产品中设置为永久保存，0 已表示使用全局配置，需要单独的取值覆盖全局的保存天数
+const RetentionForever = -1
+
+// DataRetention 时序数据按消息类型的保存天数。
+// 产品中为 0 表示使用全局配置，为 RetentionForever 表示永久保存；合并后为 0 表示永久保存
+type DataRetention struct {
+	Property int `json:"property"`
+	Event    int `json:"event"`
+	Service  int `json:"service"`
+}
+
+func (r DataRetention) Value() (driver.Value, error) {
+	return GormValueWrap(r)
+}
+
+func (r *DataRetention) Scan(value interface{}) error {
+	return GormScanWrap(value, r)
+}
+
+func (r DataRetention) Check() error {
+	if r.Property < RetentionForever || r.Event < RetentionForever || r.Service < RetentionForever {
+		return fmt.Errorf("retention days can not be less than %d", RetentionForever)
+	}
+	return nil
+}
+
+// IsZero 各类型均使用全局配置
+func (r DataRetention) IsZero() bool {
+	return r == DataRetention{}
+}
+
+// Merge 产品未设置的类型使用全局配置，永久保存统一为 0
+func (r DataRetention) Merge(global DataRetention) DataRetention {
+	merge := func(days, global int) int {
+		if days == 0 {
+			days = global
+		}
+		if days < 0 {
+			return 0
+		}
+		return days
+	}
+	r.Property = merge(r.Property, global.Property)
+	r.Event = merge(r.Event, global.Event)
+	r.Service = merge(r.Service, global.Service)
+	return r
+}
+
+// Max 最长的保存天数，任一类型永久保存时返回 0
+func (r DataRetention) Max() int {
+	if r.Property <= 0 || r.Event <= 0 || r.Service <= 0 {
+		return 0
+	}
+	max := r.Property
+	if r.Event > max {
+		max = r.Event
+	}
+	if r.Service > max {
+		max = r.Service
+	}
+	return max
+}
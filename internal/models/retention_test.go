@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataRetentionMerge(t *testing.T) {
+	global := DataRetention{Property: 30, Event: 90, Service: 0}
+
+	merged := DataRetention{Property: 7}.Merge(global)
+	assert.Equal(t, DataRetention{Property: 7, Event: 90, Service: 0}, merged)
+	assert.Equal(t, 0, merged.Max())
+
+	// 永久保存覆盖全局配置，合并后为 0
+	merged = DataRetention{Property: RetentionForever, Service: 180}.Merge(global)
+	assert.Equal(t, DataRetention{Property: 0, Event: 90, Service: 180}, merged)
+	assert.Equal(t, 0, merged.Max())
+
+	assert.Equal(t, 180, DataRetention{Service: 180}.Merge(DataRetention{Property: 30, Event: 90, Service: 60}).Max())
+}
+
+func TestDataRetentionCheck(t *testing.T) {
+	assert.NoError(t, DataRetention{Property: RetentionForever, Event: 0, Service: 30}.Check())
+	assert.Error(t, DataRetention{Property: -2}.Check())
+	assert.True(t, DataRetention{}.IsZero())
+	assert.False(t, DataRetention{Event: RetentionForever}.IsZero())
+}
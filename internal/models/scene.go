@@ -18,6 +18,7 @@ import (
 	"database/sql/driver"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/timer/jobs"
+	"strconv"
 )
 
 type Scene struct {
@@ -49,8 +50,20 @@ func (d *Scene) ToRuntimeJob() (schedule *jobs.JobSchedule, err error) {
 		}
 	)
 
+	option := d.Conditions[0].Option
 	rj.TimeData = jobs.TimeData{
-		Expression: d.Conditions[0].Option["cron_expression"],
+		Expression: option["cron_expression"],
+	}
+	rj.Policy = jobs.Policy{
+		MisfirePolicy:     string(constants.JobMisfireSkip),
+		ConcurrencyPolicy: string(constants.JobConcurrencyAllow),
+	}
+	if option["misfire_policy"] == string(constants.JobMisfireRunOnce) {
+		rj.Policy.MisfirePolicy = string(constants.JobMisfireRunOnce)
+		rj.Policy.MisfireThreshold, _ = strconv.ParseInt(option["misfire_threshold"], 10, 64)
+	}
+	if option["concurrency_policy"] == string(constants.JobConcurrencyForbid) {
+		rj.Policy.ConcurrencyPolicy = string(constants.JobConcurrencyForbid)
 	}
 
 	for _, action := range d.Actions {
@@ -59,6 +72,7 @@ func (d *Scene) ToRuntimeJob() (schedule *jobs.JobSchedule, err error) {
 			ProductName: action.ProductName,
 			DeviceId:    action.DeviceID,
 			DeviceName:  action.DeviceName,
+			GroupId:     action.GroupID,
 			Code:        action.Code,
 			DateType:    action.DataType,
 			Value:       action.Value,
@@ -89,6 +103,7 @@ type Action struct {
 	ProductName string `json:"product_name"`
 	DeviceID    string `json:"device_id"`
 	DeviceName  string `json:"device_name"`
+	GroupID     string `json:"group_id"`
 	Code        string `json:"code"`
 	DataType    string `json:"data_type"`
 	Value       string `json:"value"`
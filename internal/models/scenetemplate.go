@@ -0,0 +1,98 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+import "database/sql/driver"
+
+// SceneTemplate 场景模板，通过产品和角色引用设备，实例化时替换为具体设备
+type SceneTemplate struct {
+	Timestamps  `gorm:"embedded"`
+	Id          string          `json:"id" gorm:"id;primaryKey;not null;type:string;size:255;comment:主键"`
+	Name        string          `json:"name" gorm:"type:string;size:255;comment:名字"`
+	Description string          `json:"description" gorm:"type:text;comment:描述"`
+	Roles       TemplateRoles   `json:"roles" gorm:"type:text;comment:设备角色"`
+	Conditions  Conditions      `json:"conditions" gorm:"type:text;comment:条件"`
+	Actions     TemplateActions `json:"actions" gorm:"type:text;comment:动作"`
+}
+
+func (d *SceneTemplate) TableName() string {
+	return "scene_template"
+}
+
+func (d *SceneTemplate) Get() interface{} {
+	return *d
+}
+
+// Role 按名字查找设备角色
+func (d *SceneTemplate) Role(name string) (TemplateRole, bool) {
+	for _, role := range d.Roles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+	return TemplateRole{}, false
+}
+
+type TemplateRoles []TemplateRole
+
+// TemplateRole 设备角色，如"主灯"、"空调"，每个角色绑定一个产品
+type TemplateRole struct {
+	Name        string `json:"name"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+}
+
+func (c TemplateRoles) Value() (driver.Value, error) {
+	return GormValueWrap(c)
+}
+
+func (c *TemplateRoles) Scan(value interface{}) error {
+	return GormScanWrap(value, c)
+}
+
+type TemplateActions []TemplateAction
+
+type TemplateAction struct {
+	Role     string `json:"role"`
+	Code     string `json:"code"`
+	DataType string `json:"data_type"`
+	Value    string `json:"value"`
+}
+
+func (c TemplateActions) Value() (driver.Value, error) {
+	return GormValueWrap(c)
+}
+
+func (c *TemplateActions) Scan(value interface{}) error {
+	return GormScanWrap(value, c)
+}
+
+// SceneInstance 由场景模板实例化出的场景，记录角色与设备的绑定关系
+type SceneInstance struct {
+	Timestamps  `gorm:"embedded"`
+	Id          string          `json:"id" gorm:"id;primaryKey;not null;type:string;size:255;comment:主键(场景ID)"`
+	TemplateId  string          `json:"template_id" gorm:"uniqueIndex:idx_template_instance;type:string;size:255;comment:场景模板ID"`
+	InstanceKey string          `json:"instance_key" gorm:"uniqueIndex:idx_template_instance;type:string;size:255;comment:实例标识"`
+	Name        string          `json:"name" gorm:"type:string;size:255;comment:场景名字"`
+	Bindings    MapStringString `json:"bindings" gorm:"type:text;comment:角色绑定的设备"`
+}
+
+func (d *SceneInstance) TableName() string {
+	return "scene_instance"
+}
+
+func (d *SceneInstance) Get() interface{} {
+	return *d
+}
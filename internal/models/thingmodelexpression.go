@@ -0,0 +1,343 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+)
+
+// ErrExpressionNotReady 时间函数缺少历史数据(如 delta、rate 的第一个点)，本次不产生计算值
+var ErrExpressionNotReady = errors.New("expression is not ready")
+
+// expressionFuncs 计算属性表达式支持的数学函数及参数个数
+var expressionFuncs = map[string]int{
+	"abs":   1,
+	"sqrt":  1,
+	"exp":   1,
+	"log":   1,
+	"log10": 1,
+	"floor": 1,
+	"ceil":  1,
+	"round": 1,
+	"pow":   2,
+	"min":   2,
+	"max":   2,
+}
+
+// expressionTimeFuncs 依赖历史数据的时间函数：
+// delta 与上一个值的差，rate 每秒变化率，integral 按秒的梯形累计积分
+var expressionTimeFuncs = map[string]bool{
+	"delta":    true,
+	"rate":     true,
+	"integral": true,
+}
+
+// PropertyExpression 计算属性的表达式，如 voltage*current、integral(power)/3600000，
+// 属性标识符支持结构体字段和数组元素(pos.lat、points[0])
+type PropertyExpression struct {
+	expr  ast.Expr
+	codes []string
+}
+
+// ExpressionState 时间函数的历史状态，每个设备的每个计算属性各一份
+type ExpressionState struct {
+	points map[token.Pos]expressionPoint
+	// seed 不为 nil 时下一次计算的结果为该值，offset 为之后每次计算结果的修正值
+	seed   *float64
+	offset float64
+}
+
+type expressionPoint struct {
+	time  int64
+	value float64
+	total float64
+}
+
+func NewExpressionState() *ExpressionState {
+	return &ExpressionState{points: make(map[token.Pos]expressionPoint)}
+}
+
+// Seed 从计算属性上次的值继续累计：下一次计算的结果为 last，之后的结果在此基础上加上新的积分。
+// 用于服务重启后恢复 integral 的累计值，停止期间的数据没有上报，不参与累计
+func (s *ExpressionState) Seed(last float64) {
+	s.seed = &last
+}
+
+// ParsePropertyExpression 解析计算属性表达式，支持四则运算、取模、括号、数学函数和时间函数
+func ParsePropertyExpression(s string) (*PropertyExpression, error) {
+	expr, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, fmt.Errorf("expression(%s) is invalid: %v", s, err)
+	}
+	e := &PropertyExpression{expr: expr}
+	seen := make(map[string]bool)
+	var check func(node ast.Expr) error
+	check = func(node ast.Expr) error {
+		if code, ok := expressionCode(node); ok {
+			if !seen[code] {
+				seen[code] = true
+				e.codes = append(e.codes, code)
+			}
+			return nil
+		}
+		switch n := node.(type) {
+		case *ast.BasicLit:
+			if n.Kind != token.INT && n.Kind != token.FLOAT {
+				return fmt.Errorf("literal %s is not a number", n.Value)
+			}
+		case *ast.ParenExpr:
+			return check(n.X)
+		case *ast.UnaryExpr:
+			if n.Op != token.ADD && n.Op != token.SUB {
+				return fmt.Errorf("operator %s is not supported", n.Op)
+			}
+			return check(n.X)
+		case *ast.BinaryExpr:
+			switch n.Op {
+			case token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+			default:
+				return fmt.Errorf("operator %s is not supported", n.Op)
+			}
+			if err := check(n.X); err != nil {
+				return err
+			}
+			return check(n.Y)
+		case *ast.CallExpr:
+			name, ok := n.Fun.(*ast.Ident)
+			if !ok {
+				return fmt.Errorf("function is invalid")
+			}
+			argc, ok := expressionFuncs[name.Name]
+			if expressionTimeFuncs[name.Name] {
+				argc, ok = 1, true
+			}
+			if !ok {
+				return fmt.Errorf("function %s is not supported", name.Name)
+			}
+			if len(n.Args) != argc {
+				return fmt.Errorf("function %s requires %d argument(s)", name.Name, argc)
+			}
+			for _, arg := range n.Args {
+				if err := check(arg); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("expression is not supported")
+		}
+		return nil
+	}
+	if err = check(expr); err != nil {
+		return nil, fmt.Errorf("expression(%s) is invalid: %v", s, err)
+	}
+	if len(e.codes) == 0 {
+		return nil, fmt.Errorf("expression(%s) does not reference any property", s)
+	}
+	return e, nil
+}
+
+// expressionCode 将标识符、字段选择和数组下标还原为属性标识符
+func expressionCode(node ast.Expr) (string, bool) {
+	switch n := node.(type) {
+	case *ast.Ident:
+		return n.Name, true
+	case *ast.SelectorExpr:
+		parent, ok := expressionCode(n.X)
+		if !ok {
+			return "", false
+		}
+		return parent + "." + n.Sel.Name, true
+	case *ast.IndexExpr:
+		parent, ok := expressionCode(n.X)
+		if !ok {
+			return "", false
+		}
+		index, ok := n.Index.(*ast.BasicLit)
+		if !ok || index.Kind != token.INT {
+			return "", false
+		}
+		return parent + "[" + index.Value + "]", true
+	}
+	return "", false
+}
+
+// Codes 表达式引用的属性标识符
+func (e *PropertyExpression) Codes() []string {
+	return e.codes
+}
+
+// Accumulates 表达式是否包含 integral，计算结果随时间累计
+func (e *PropertyExpression) Accumulates() bool {
+	var found bool
+	ast.Inspect(e.expr, func(node ast.Node) bool {
+		if call, ok := node.(*ast.CallExpr); ok {
+			if name, ok := call.Fun.(*ast.Ident); ok && name.Name == "integral" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// Eval 计算表达式，values 为引用属性的当前值，t 为数据时间(毫秒)，用于时间函数
+func (e *PropertyExpression) Eval(values map[string]float64, t int64, state *ExpressionState) (float64, error) {
+	value, err := e.eval(e.expr, values, t, state)
+	if err != nil || state == nil {
+		return value, err
+	}
+	if state.seed != nil {
+		state.offset = *state.seed - value
+		state.seed = nil
+	}
+	return value + state.offset, nil
+}
+
+func (e *PropertyExpression) eval(node ast.Expr, values map[string]float64, t int64, state *ExpressionState) (float64, error) {
+	if code, ok := expressionCode(node); ok {
+		v, ok := values[code]
+		if !ok {
+			return 0, fmt.Errorf("property %s has no value", code)
+		}
+		return v, nil
+	}
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		return strconv.ParseFloat(n.Value, 64)
+	case *ast.ParenExpr:
+		return e.eval(n.X, values, t, state)
+	case *ast.UnaryExpr:
+		x, err := e.eval(n.X, values, t, state)
+		if n.Op == token.SUB {
+			x = -x
+		}
+		return x, err
+	case *ast.BinaryExpr:
+		x, err := e.eval(n.X, values, t, state)
+		if err != nil {
+			return 0, err
+		}
+		y, err := e.eval(n.Y, values, t, state)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case token.ADD:
+			return x + y, nil
+		case token.SUB:
+			return x - y, nil
+		case token.MUL:
+			return x * y, nil
+		}
+		// 除数为 0 时不产生计算值，避免 Inf、NaN 写入时序数据
+		if y == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		if n.Op == token.QUO {
+			return x / y, nil
+		}
+		return math.Mod(x, y), nil
+	case *ast.CallExpr:
+		name := n.Fun.(*ast.Ident).Name
+		args := make([]float64, len(n.Args))
+		var notReady bool
+		for i, arg := range n.Args {
+			v, err := e.eval(arg, values, t, state)
+			if errors.Is(err, ErrExpressionNotReady) {
+				notReady = true
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			args[i] = v
+		}
+		// 嵌套的时间函数未就绪时不更新外层状态
+		if notReady {
+			return 0, ErrExpressionNotReady
+		}
+		if expressionTimeFuncs[name] {
+			return state.apply(n.Pos(), name, args[0], t)
+		}
+		return callExpressionFunc(name, args), nil
+	}
+	return 0, fmt.Errorf("expression is not supported")
+}
+
+func callExpressionFunc(name string, args []float64) float64 {
+	switch name {
+	case "abs":
+		return math.Abs(args[0])
+	case "sqrt":
+		return math.Sqrt(args[0])
+	case "exp":
+		return math.Exp(args[0])
+	case "log":
+		return math.Log(args[0])
+	case "log10":
+		return math.Log10(args[0])
+	case "floor":
+		return math.Floor(args[0])
+	case "ceil":
+		return math.Ceil(args[0])
+	case "round":
+		return math.Round(args[0])
+	case "pow":
+		return math.Pow(args[0], args[1])
+	case "min":
+		return math.Min(args[0], args[1])
+	default:
+		return math.Max(args[0], args[1])
+	}
+}
+
+// apply 计算时间函数并记录本次数据点，时间不晚于上一个点的数据(乱序、重复上报)不参与计算
+func (s *ExpressionState) apply(pos token.Pos, name string, value float64, t int64) (float64, error) {
+	prev, ok := s.points[pos]
+	if ok && t <= prev.time {
+		switch name {
+		case "integral":
+			return prev.total, nil
+		default:
+			return 0, ErrExpressionNotReady
+		}
+	}
+	point := expressionPoint{time: t, value: value, total: prev.total}
+	if ok {
+		seconds := float64(t-prev.time) / 1000
+		point.total += (prev.value + value) / 2 * seconds
+	}
+	s.points[pos] = point
+	switch name {
+	case "delta":
+		if !ok {
+			return 0, ErrExpressionNotReady
+		}
+		return value - prev.value, nil
+	case "rate":
+		if !ok {
+			return 0, ErrExpressionNotReady
+		}
+		return (value - prev.value) / (float64(t-prev.time) / 1000), nil
+	default:
+		return point.total, nil
+	}
+}
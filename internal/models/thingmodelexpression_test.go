@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertyExpressionEval(t *testing.T) {
+	values := map[string]float64{
+		"a":         2,
+		"b":         3,
+		"c":         4,
+		"zero":      0,
+		"pos.lat":   30.5,
+		"points[1]": 7,
+	}
+	cases := []struct {
+		expression string
+		expect     float64
+	}{
+		{"a+b*c", 14},
+		{"(a+b)*c", 20},
+		{"a-b-c", -5},
+		{"c/a/a", 1},
+		{"a*b%c", 2},
+		{"-a+b", 1},
+		{"-(a+b)", -5},
+		{"c-a*-b", 10},
+		{"pow(a, b)+1", 9},
+		{"max(a, min(b, c))", 3},
+		{"round(pos.lat)+points[1]", 38},
+		{"abs(a-c)/2", 1},
+		{"1.5*a", 3},
+	}
+	for _, c := range cases {
+		e, err := ParsePropertyExpression(c.expression)
+		require.NoError(t, err, c.expression)
+		v, err := e.Eval(values, 0, NewExpressionState())
+		require.NoError(t, err, c.expression)
+		assert.InDelta(t, c.expect, v, 1e-9, c.expression)
+	}
+
+	for _, expression := range []string{"a/zero", "a%zero", "a/(b-b)"} {
+		e, err := ParsePropertyExpression(expression)
+		require.NoError(t, err, expression)
+		_, err = e.Eval(values, 0, NewExpressionState())
+		assert.EqualError(t, err, "division by zero", expression)
+	}
+
+	// 引用的属性没有值
+	e, err := ParsePropertyExpression("a+unknown")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "unknown"}, e.Codes())
+	_, err = e.Eval(values, 0, NewExpressionState())
+	assert.EqualError(t, err, "property unknown has no value")
+}
+
+func TestParsePropertyExpressionError(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"a+",
+		"1+2",
+		"a==b",
+		"a&&b",
+		"a<<1",
+		"!a",
+		`a+"x"`,
+		"foo(a)",
+		"pow(a)",
+		"delta(a, b)",
+		"a[b]",
+		"a.b()",
+		"func() {}",
+	} {
+		_, err := ParsePropertyExpression(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestPropertyExpressionTimeFunc(t *testing.T) {
+	e, err := ParsePropertyExpression("integral(power)/1000")
+	require.NoError(t, err)
+	state := NewExpressionState()
+	v, err := e.Eval(map[string]float64{"power": 1000}, 0, state)
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), v)
+	v, err = e.Eval(map[string]float64{"power": 3000}, 2000, state)
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), v)
+	// 乱序的数据不参与累计
+	v, err = e.Eval(map[string]float64{"power": 9000}, 1000, state)
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), v)
+
+	e, err = ParsePropertyExpression("delta(counter)")
+	require.NoError(t, err)
+	state = NewExpressionState()
+	_, err = e.Eval(map[string]float64{"counter": 5}, 0, state)
+	assert.ErrorIs(t, err, ErrExpressionNotReady)
+	v, err = e.Eval(map[string]float64{"counter": 8}, 1000, state)
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), v)
+}
+
+func TestPropertyExpressionSeed(t *testing.T) {
+	e, err := ParsePropertyExpression("integral(power)/1000 + 1")
+	require.NoError(t, err)
+	assert.True(t, e.Accumulates())
+
+	// 重启后从上次的值 10 继续累计
+	state := NewExpressionState()
+	state.Seed(10)
+	v, err := e.Eval(map[string]float64{"power": 1000}, 0, state)
+	require.NoError(t, err)
+	assert.Equal(t, float64(10), v)
+	v, err = e.Eval(map[string]float64{"power": 3000}, 2000, state)
+	require.NoError(t, err)
+	assert.Equal(t, float64(14), v)
+
+	for _, expression := range []string{"delta(counter)", "voltage*current", "max(integral, 1)"} {
+		e, err = ParsePropertyExpression(expression)
+		require.NoError(t, err)
+		assert.False(t, e.Accumulates(), expression)
+	}
+}
@@ -0,0 +1,201 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"strconv"
+	"strings"
+)
+
+// arrayFlattenMaxSize 数组未定义元素个数时，拆分存储查询的最大元素个数
+const arrayFlattenMaxSize = 32
+
+// PropertyPathSegment 属性标识符中的一段，结构体字段或数组下标
+type PropertyPathSegment struct {
+	Field   string
+	Index   int
+	IsIndex bool
+}
+
+// ParsePropertyPath 解析属性标识符，结构体字段用 . 分隔、数组元素用 [下标]，如 pos.lat、points[0]
+func ParsePropertyPath(code string) (string, []PropertyPathSegment, error) {
+	end := strings.IndexAny(code, ".[")
+	if end < 0 {
+		return code, nil, nil
+	}
+	property := code[:end]
+	var path []PropertyPathSegment
+	rest := code[end:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			n := strings.IndexAny(rest, ".[")
+			if n < 0 {
+				n = len(rest)
+			}
+			if n == 0 {
+				return "", nil, fmt.Errorf("property code(%s) is invalid", code)
+			}
+			path = append(path, PropertyPathSegment{Field: rest[:n]})
+			rest = rest[n:]
+		case '[':
+			n := strings.IndexByte(rest, ']')
+			if n < 0 {
+				return "", nil, fmt.Errorf("property code(%s) is invalid", code)
+			}
+			index, err := strconv.Atoi(rest[1:n])
+			if err != nil || index < 0 {
+				return "", nil, fmt.Errorf("property code(%s) is invalid", code)
+			}
+			path = append(path, PropertyPathSegment{Index: index, IsIndex: true})
+			rest = rest[n+1:]
+		default:
+			return "", nil, fmt.Errorf("property code(%s) is invalid", code)
+		}
+	}
+	return property, path, nil
+}
+
+// Child 结构体字段或数组元素的数据类型
+func (t TypeSpec) Child(segment PropertyPathSegment) (TypeSpec, bool) {
+	switch t.Type {
+	case constants.SpecsTypeStruct:
+		if segment.IsIndex {
+			return TypeSpec{}, false
+		}
+		var fields []TypeSpecStruct
+		_ = json.Unmarshal([]byte(t.Specs), &fields)
+		for _, field := range fields {
+			if field.Code == segment.Field {
+				return field.DataType, true
+			}
+		}
+	case constants.SpecsTypeArray:
+		if !segment.IsIndex {
+			return TypeSpec{}, false
+		}
+		var spec TypeSpecArray
+		_ = json.Unmarshal([]byte(t.Specs), &spec)
+		if size, err := strconv.Atoi(spec.Size); err == nil && size > 0 && segment.Index >= size {
+			return TypeSpec{}, false
+		}
+		return spec.ItemTypeSpec(), true
+	}
+	return TypeSpec{}, false
+}
+
+// PropertyByPath 按标识符查找属性，支持结构体字段和数组元素，返回所属属性及该字段的数据类型
+func (d *Product) PropertyByPath(code string) (Properties, TypeSpec, bool) {
+	name, path, err := ParsePropertyPath(code)
+	if err != nil {
+		return Properties{}, TypeSpec{}, false
+	}
+	for _, property := range d.Properties {
+		if property.Code != name {
+			continue
+		}
+		typeSpec := property.TypeSpec
+		for _, segment := range path {
+			var ok bool
+			if typeSpec, ok = typeSpec.Child(segment); !ok {
+				return property, TypeSpec{}, false
+			}
+		}
+		return property, typeSpec, true
+	}
+	return Properties{}, TypeSpec{}, false
+}
+
+// FlattenValue 将结构体和数组的值拆分为 pos.lat、points[0] 形式的子字段，用于只能存储标量的时序库
+func FlattenValue(code string, value interface{}, out map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			FlattenValue(code+"."+field, fieldValue, out)
+		}
+	case []interface{}:
+		for i, item := range v {
+			FlattenValue(code+"["+strconv.Itoa(i)+"]", item, out)
+		}
+	default:
+		out[code] = value
+	}
+}
+
+// FlattenCodes 按物模型定义列出拆分存储后的全部子字段标识符，标量类型返回自身。
+// 数组按定义的元素个数列出，未定义元素个数时最多列出 arrayFlattenMaxSize 个
+func (t TypeSpec) FlattenCodes(code string) []string {
+	switch t.Type {
+	case constants.SpecsTypeStruct:
+		var fields []TypeSpecStruct
+		_ = json.Unmarshal([]byte(t.Specs), &fields)
+		var codes []string
+		for _, field := range fields {
+			codes = append(codes, field.DataType.FlattenCodes(code+"."+field.Code)...)
+		}
+		return codes
+	case constants.SpecsTypeArray:
+		var spec TypeSpecArray
+		_ = json.Unmarshal([]byte(t.Specs), &spec)
+		size, err := strconv.Atoi(spec.Size)
+		if err != nil || size <= 0 {
+			size = arrayFlattenMaxSize
+		}
+		item := spec.ItemTypeSpec()
+		var codes []string
+		for i := 0; i < size; i++ {
+			codes = append(codes, item.FlattenCodes(code+"["+strconv.Itoa(i)+"]")...)
+		}
+		return codes
+	default:
+		return []string{code}
+	}
+}
+
+// Unflatten 按物模型定义将拆分存储的子字段还原为结构体或数组，没有任何子字段时返回 false
+func (t TypeSpec) Unflatten(code string, values map[string]interface{}) (interface{}, bool) {
+	switch t.Type {
+	case constants.SpecsTypeStruct:
+		var fields []TypeSpecStruct
+		_ = json.Unmarshal([]byte(t.Specs), &fields)
+		value := make(map[string]interface{})
+		for _, field := range fields {
+			if v, ok := field.DataType.Unflatten(code+"."+field.Code, values); ok {
+				value[field.Code] = v
+			}
+		}
+		return value, len(value) > 0
+	case constants.SpecsTypeArray:
+		var spec TypeSpecArray
+		_ = json.Unmarshal([]byte(t.Specs), &spec)
+		item := spec.ItemTypeSpec()
+		var value []interface{}
+		for i := 0; ; i++ {
+			v, ok := item.Unflatten(code+"["+strconv.Itoa(i)+"]", values)
+			if !ok {
+				break
+			}
+			value = append(value, v)
+		}
+		return value, len(value) > 0
+	default:
+		v, ok := values[code]
+		return v, ok
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+func TestTypeSpecFlattenStructArray(t *testing.T) {
+	spec := TypeSpec{Type: constants.SpecsTypeArray,
+		Specs: `{"size":"2","item":{"type":"struct","specs":"[{\"code\":\"x\",\"name\":\"x\",\"data_type\":{\"type\":\"int\"}},{\"code\":\"y\",\"name\":\"y\",\"data_type\":{\"type\":\"int\"}}]"}}`}
+	assert.Equal(t, []string{"pts[0].x", "pts[0].y", "pts[1].x", "pts[1].y"}, spec.FlattenCodes("pts"))
+
+	value := []interface{}{
+		map[string]interface{}{"x": float64(1), "y": float64(2)},
+		map[string]interface{}{"x": float64(3)},
+	}
+	flat := make(map[string]interface{})
+	FlattenValue("pts", value, flat)
+	assert.Equal(t, map[string]interface{}{"pts[0].x": float64(1), "pts[0].y": float64(2), "pts[1].x": float64(3)}, flat)
+	restored, ok := spec.Unflatten("pts", flat)
+	assert.True(t, ok)
+	assert.Equal(t, value, restored)
+
+	product := Product{Properties: []Properties{{Code: "pts", TypeSpec: spec}}}
+	_, typeSpec, ok := product.PropertyByPath("pts[1].y")
+	assert.True(t, ok)
+	assert.Equal(t, constants.SpecsTypeInt, typeSpec.Type)
+	_, _, ok = product.PropertyByPath("pts[2].y")
+	assert.False(t, ok)
+}
+
+func TestTypeSpecFlattenArraySize(t *testing.T) {
+	declared := TypeSpec{Type: constants.SpecsTypeArray, Specs: `{"size":"100","item":{"type":"float"}}`}
+	codes := declared.FlattenCodes("points")
+	require.Len(t, codes, 100)
+	assert.Equal(t, "points[99]", codes[99])
+
+	// 拆分写入的全部元素都能按子字段查询和还原
+	value := make([]interface{}, 100)
+	for i := range value {
+		value[i] = float64(i)
+	}
+	flat := make(map[string]interface{})
+	FlattenValue("points", value, flat)
+	assert.ElementsMatch(t, codes, keys(flat))
+	restored, ok := declared.Unflatten("points", flat)
+	require.True(t, ok)
+	assert.Equal(t, value, restored)
+
+	for _, specs := range []string{`{"item":{"type":"float"}}`, `{"size":"many","item":{"type":"float"}}`, `{"size":"0","item":{"type":"float"}}`} {
+		undeclared := TypeSpec{Type: constants.SpecsTypeArray, Specs: specs}
+		assert.Len(t, undeclared.FlattenCodes("points"), arrayFlattenMaxSize, specs)
+	}
+}
+
+func keys(m map[string]interface{}) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+)
+
+// PropertyScaling 设备上报的原始值到工程值的转换，入库前执行。
+// Table 不为空时按分段线性插值，超出表格范围取两端的值；否则 value = raw*Gain + Offset，Gain 为 0 时按 1 处理
+type PropertyScaling struct {
+	Gain   float64        `json:"gain,omitempty"`
+	Offset float64        `json:"offset,omitempty"`
+	Table  []ScalingPoint `json:"table,omitempty"`
+}
+
+// ScalingPoint 分段线性插值的一个点
+type ScalingPoint struct {
+	Raw   float64 `json:"raw"`
+	Value float64 `json:"value"`
+}
+
+func (s PropertyScaling) Value() (driver.Value, error) {
+	return GormValueWrap(s)
+}
+
+func (s *PropertyScaling) Scan(value interface{}) error {
+	return GormScanWrap(value, s)
+}
+
+// Enabled 是否配置了转换
+func (s PropertyScaling) Enabled() bool {
+	return (s.Gain != 0 && s.Gain != 1) || s.Offset != 0 || len(s.Table) > 0
+}
+
+// Check 分段表至少两个点，且原始值不能重复
+func (s PropertyScaling) Check() error {
+	if len(s.Table) == 0 {
+		return nil
+	}
+	if len(s.Table) < 2 {
+		return fmt.Errorf("scaling table requires at least 2 points")
+	}
+	seen := make(map[float64]bool, len(s.Table))
+	for _, point := range s.Table {
+		if seen[point.Raw] {
+			return fmt.Errorf("scaling table raw value %v is duplicated", point.Raw)
+		}
+		seen[point.Raw] = true
+	}
+	return nil
+}
+
+// Apply 原始值转换为工程值
+func (s PropertyScaling) Apply(raw float64) float64 {
+	if len(s.Table) == 0 {
+		gain := s.Gain
+		if gain == 0 {
+			gain = 1
+		}
+		return raw*gain + s.Offset
+	}
+	table := make([]ScalingPoint, len(s.Table))
+	copy(table, s.Table)
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].Raw < table[j].Raw
+	})
+	if raw <= table[0].Raw {
+		return table[0].Value
+	}
+	for i := 1; i < len(table); i++ {
+		if raw <= table[i].Raw {
+			prev := table[i-1]
+			return prev.Value + (raw-prev.Raw)*(table[i].Value-prev.Value)/(table[i].Raw-prev.Raw)
+		}
+	}
+	return table[len(table)-1].Value
+}
@@ -0,0 +1,235 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// stepEpsilon 判断是否满足步长时允许的浮点误差
+const stepEpsilon = 1e-6
+
+// Validate 按物模型定义校验设备上报的值，specs 为空或无法解析时只校验值的类型
+func (t TypeSpec) Validate(value interface{}) error {
+	switch t.Type {
+	case constants.SpecsTypeInt, constants.SpecsTypeFloat:
+		return t.validateNumber(value)
+	case constants.SpecsTypeText:
+		return t.validateText(value)
+	case constants.SpecsTypeDate:
+		if _, ok := value.(string); ok {
+			return nil
+		}
+		if _, ok := toFloat(value); !ok {
+			return fmt.Errorf("value %v is not a date", value)
+		}
+		return nil
+	case constants.SpecsTypeBool:
+		return t.validateBool(value)
+	case constants.SpecsTypeEnum:
+		return t.validateEnum(value)
+	case constants.SpecsTypeStruct:
+		return t.validateStruct(value)
+	case constants.SpecsTypeArray:
+		return t.validateArray(value)
+	default:
+		return nil
+	}
+}
+
+func (t TypeSpec) validateNumber(value interface{}) error {
+	v, ok := toFloat(value)
+	if !ok {
+		return fmt.Errorf("value %v is not a number", value)
+	}
+	if t.Type == constants.SpecsTypeInt && v != math.Trunc(v) {
+		return fmt.Errorf("value %v is not an integer", value)
+	}
+	var spec TypeSpecIntOrFloat
+	if t.Specs == "" || json.Unmarshal([]byte(t.Specs), &spec) != nil {
+		return nil
+	}
+	min, hasMin := parseSpecFloat(spec.Min)
+	if hasMin && v < min {
+		return fmt.Errorf("value %v is less than min %s", value, spec.Min)
+	}
+	if max, ok := parseSpecFloat(spec.Max); ok && v > max {
+		return fmt.Errorf("value %v is greater than max %s", value, spec.Max)
+	}
+	if step, ok := parseSpecFloat(spec.Step); ok && step > 0 {
+		n := (v - min) / step
+		if math.Abs(n-math.Round(n)) > stepEpsilon {
+			return fmt.Errorf("value %v does not match step %s", value, spec.Step)
+		}
+	}
+	return nil
+}
+
+func (t TypeSpec) validateText(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("value %v is not a text", value)
+	}
+	var spec TypeSpecText
+	if t.Specs == "" || json.Unmarshal([]byte(t.Specs), &spec) != nil {
+		return nil
+	}
+	if length, err := strconv.Atoi(spec.Length); err == nil && length > 0 && utf8.RuneCountInString(s) > length {
+		return fmt.Errorf("text length %d exceeds %d", utf8.RuneCountInString(s), length)
+	}
+	return nil
+}
+
+func (t TypeSpec) validateBool(value interface{}) error {
+	var key string
+	switch v := value.(type) {
+	case bool:
+		key = "0"
+		if v {
+			key = "1"
+		}
+	case string:
+		key = v
+		switch v {
+		case "true":
+			key = "1"
+		case "false":
+			key = "0"
+		}
+	default:
+		if f, ok := toFloat(v); ok {
+			key = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	if key != "0" && key != "1" {
+		return fmt.Errorf("value %v is not a bool", value)
+	}
+	var spec TypeSpecBool
+	if t.Specs == "" || json.Unmarshal([]byte(t.Specs), &spec) != nil || len(spec) == 0 {
+		return nil
+	}
+	if _, ok := spec[key]; !ok {
+		return fmt.Errorf("bool value %v is not defined", value)
+	}
+	return nil
+}
+
+func (t TypeSpec) validateEnum(value interface{}) error {
+	var key string
+	switch v := value.(type) {
+	case string:
+		key = v
+	default:
+		f, ok := toFloat(v)
+		if !ok {
+			return fmt.Errorf("value %v is not an enum", value)
+		}
+		key = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	var spec TypeSpecEnum
+	if t.Specs == "" || json.Unmarshal([]byte(t.Specs), &spec) != nil || len(spec) == 0 {
+		return nil
+	}
+	if _, ok := spec[key]; !ok {
+		return fmt.Errorf("enum value %v is not defined", value)
+	}
+	return nil
+}
+
+func (t TypeSpec) validateStruct(value interface{}) error {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("value %v is not a struct", value)
+	}
+	var spec []TypeSpecStruct
+	if t.Specs == "" || json.Unmarshal([]byte(t.Specs), &spec) != nil {
+		return nil
+	}
+	for code, v := range fields {
+		var field *TypeSpecStruct
+		for i := range spec {
+			if spec[i].Code == code {
+				field = &spec[i]
+				break
+			}
+		}
+		if field == nil {
+			return fmt.Errorf("struct field %s is not defined", code)
+		}
+		if err := field.DataType.Validate(v); err != nil {
+			return fmt.Errorf("struct field %s: %v", code, err)
+		}
+	}
+	return nil
+}
+
+func (t TypeSpec) validateArray(value interface{}) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("value %v is not an array", value)
+	}
+	var spec TypeSpecArray
+	if t.Specs == "" || json.Unmarshal([]byte(t.Specs), &spec) != nil {
+		return nil
+	}
+	if size, err := strconv.Atoi(spec.Size); err == nil && size > 0 && len(items) > size {
+		return fmt.Errorf("array size %d exceeds %d", len(items), size)
+	}
+	// 每个元素都按元素的类型及 specs 校验
+	item := spec.ItemTypeSpec()
+	for i, v := range items {
+		if err := item.Validate(v); err != nil {
+			return fmt.Errorf("array item %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func parseSpecFloat(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// toFloat 驱动上报的数值可能是 json 数字，也可能是数字字符串
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+func TestTypeSpecValidate(t *testing.T) {
+	intSpec := TypeSpec{Type: constants.SpecsTypeInt, Specs: `{"min":"0","max":"100","step":"5"}`}
+	floatSpec := TypeSpec{Type: constants.SpecsTypeFloat, Specs: `{"min":"-1.5","max":"1.5"}`}
+	textSpec := TypeSpec{Type: constants.SpecsTypeText, Specs: `{"length":"3"}`}
+	boolSpec := TypeSpec{Type: constants.SpecsTypeBool, Specs: `{"0":"off","1":"on"}`}
+	enumSpec := TypeSpec{Type: constants.SpecsTypeEnum, Specs: `{"1":"low","2":"high"}`}
+	structSpec := TypeSpec{Type: constants.SpecsTypeStruct,
+		Specs: `[{"code":"x","name":"x","data_type":{"type":"int","specs":"{\"max\":\"10\"}"}},{"code":"s","name":"s","data_type":{"type":"text"}}]`}
+	arraySpec := TypeSpec{Type: constants.SpecsTypeArray, Specs: `{"size":"3","item":{"type":"int","specs":"{\"min\":\"0\",\"max\":\"9\"}"}}`}
+	structArraySpec := TypeSpec{Type: constants.SpecsTypeArray,
+		Specs: `{"item":{"type":"struct","specs":"[{\"code\":\"x\",\"name\":\"x\",\"data_type\":{\"type\":\"int\"}}]"}}`}
+
+	cases := []struct {
+		name  string
+		spec  TypeSpec
+		value interface{}
+		valid bool
+	}{
+		{"int in range", intSpec, float64(50), true},
+		{"int numeric string", intSpec, "25", true},
+		{"int below min", intSpec, float64(-5), false},
+		{"int above max", intSpec, float64(105), false},
+		{"int off step", intSpec, float64(7), false},
+		{"int fraction", intSpec, 5.5, false},
+		{"int not number", intSpec, "abc", false},
+		{"int without specs", TypeSpec{Type: constants.SpecsTypeInt}, float64(-1000), true},
+		{"float in range", floatSpec, 1.25, true},
+		{"float above max", floatSpec, 1.6, false},
+		{"text length", textSpec, "中文字", true},
+		{"text too long", textSpec, "abcd", false},
+		{"text not string", textSpec, float64(1), false},
+		{"date timestamp", TypeSpec{Type: constants.SpecsTypeDate}, float64(1700000000000), true},
+		{"date bool", TypeSpec{Type: constants.SpecsTypeDate}, true, false},
+		{"bool true", boolSpec, true, true},
+		{"bool number", boolSpec, float64(0), true},
+		{"bool string", boolSpec, "false", true},
+		{"bool out of range", boolSpec, float64(2), false},
+		{"bool undefined key", TypeSpec{Type: constants.SpecsTypeBool, Specs: `{"1":"on"}`}, false, false},
+		{"enum defined", enumSpec, float64(2), true},
+		{"enum defined string", enumSpec, "1", true},
+		{"enum undefined", enumSpec, float64(3), false},
+		{"struct valid", structSpec, map[string]interface{}{"x": float64(3), "s": "a"}, true},
+		{"struct field invalid", structSpec, map[string]interface{}{"x": float64(11)}, false},
+		{"struct field undefined", structSpec, map[string]interface{}{"y": float64(1)}, false},
+		{"struct not object", structSpec, "x", false},
+		{"array valid", arraySpec, []interface{}{float64(0), float64(9)}, true},
+		{"array too long", arraySpec, []interface{}{float64(1), float64(2), float64(3), float64(4)}, false},
+		{"array item type", arraySpec, []interface{}{float64(1), "a"}, false},
+		{"array last item out of range", arraySpec, []interface{}{float64(1), float64(2), float64(10)}, false},
+		{"array not array", arraySpec, float64(1), false},
+		{"array of struct valid", structArraySpec, []interface{}{map[string]interface{}{"x": float64(1)}}, true},
+		{"array of struct invalid field", structArraySpec, []interface{}{map[string]interface{}{"x": float64(1)}, map[string]interface{}{"y": float64(1)}}, false},
+		{"unknown type", TypeSpec{Type: "unknown"}, nil, true},
+	}
+	for _, c := range cases {
+		err := c.spec.Validate(c.value)
+		if c.valid {
+			assert.NoError(t, err, c.name)
+		} else {
+			assert.Error(t, err, c.name)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package models
+
+// TimerJob 定时任务的执行记录，进程重启后用于补偿错过的执行
+type TimerJob struct {
+	Timestamps  `gorm:"embedded"`
+	Id          string `json:"id" gorm:"id;primaryKey;not null;type:string;size:255;comment:主键(场景ID)"`
+	Name        string `json:"name" gorm:"type:string;size:255;comment:名字"`
+	Expression  string `json:"expression" gorm:"type:string;size:255;comment:定时表达式"`
+	LastRunTime int64  `json:"last_run_time" gorm:"comment:上次执行时间"`
+	NextRunTime int64  `json:"next_run_time" gorm:"comment:下次执行时间"`
+}
+
+func (t *TimerJob) TableName() string {
+	return "timer_job"
+}
+
+func (t *TimerJob) Get() interface{} {
+	return *t
+}
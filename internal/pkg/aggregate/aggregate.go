@@ -0,0 +1,300 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+// Package aggregate 属性历史数据按时间窗口聚合，语义与 tdengine 的 INTERVAL/FILL 相同：
+// 窗口按 unix 时间对齐，左闭右开，以窗口开始时间作为时间戳
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Function string
+
+const (
+	Avg   Function = "avg"
+	Min   Function = "min"
+	Max   Function = "max"
+	Sum   Function = "sum"
+	Count Function = "count"
+	First Function = "first"
+	Last  Function = "last"
+)
+
+type Fill string
+
+const (
+	// FillNone 不返回没有数据的窗口
+	FillNone Fill = "none"
+	// FillNull 没有数据的窗口值为 null
+	FillNull Fill = "null"
+	// FillPrev 使用前一个窗口的值
+	FillPrev Fill = "prev"
+	// FillLinear 使用前后两个窗口的值线性插值
+	FillLinear Fill = "linear"
+	// FillValue 使用指定的值
+	FillValue Fill = "value"
+)
+
+const (
+	// MinInterval 最小窗口
+	MinInterval = time.Second
+	// MaxBuckets 单次查询最多返回的窗口数
+	MaxBuckets = 10000
+)
+
+var (
+	ErrInvalidInterval = errors.New("invalid interval")
+	ErrTooManyBuckets  = fmt.Errorf("too many buckets, at most %d", MaxBuckets)
+)
+
+// Query 聚合查询，Start/End 为毫秒时间戳，Interval 为窗口长度
+type Query struct {
+	Start     int64
+	End       int64
+	Interval  time.Duration
+	Function  Function
+	Fill      Fill
+	FillValue float64
+}
+
+type Point struct {
+	Time  int64
+	Value float64
+}
+
+// Bucket 窗口的聚合结果，Value 为 nil 表示没有数据且按 FillNull 填充
+type Bucket struct {
+	Time  int64
+	Value *float64
+}
+
+// ParseInterval 解析窗口长度，支持 time.ParseDuration 的格式及 d(天)、w(周)，如 30s、5m、1h、1d
+func ParseInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var interval time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"), strings.HasSuffix(s, "w"):
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, ErrInvalidInterval
+		}
+		interval = time.Duration(n) * 24 * time.Hour
+		if strings.HasSuffix(s, "w") {
+			interval *= 7
+		}
+	default:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, ErrInvalidInterval
+		}
+		interval = d
+	}
+	if interval < MinInterval || interval%time.Millisecond != 0 {
+		return 0, ErrInvalidInterval
+	}
+	return interval, nil
+}
+
+// Check 校验参数，Function 为空时为 avg，Fill 为空时为 none，Start 大于 End 时交换
+func (q *Query) Check() error {
+	if q.Start > q.End {
+		q.Start, q.End = q.End, q.Start
+	}
+	if q.Function == "" {
+		q.Function = Avg
+	}
+	if q.Fill == "" {
+		q.Fill = FillNone
+	}
+	switch q.Function {
+	case Avg, Min, Max, Sum, Count, First, Last:
+	default:
+		return fmt.Errorf("aggregate function %s not supported", q.Function)
+	}
+	switch q.Fill {
+	case FillNone, FillNull, FillPrev, FillLinear, FillValue:
+	default:
+		return fmt.Errorf("fill %s not supported", q.Fill)
+	}
+	if q.Interval < MinInterval {
+		return ErrInvalidInterval
+	}
+	if (q.BucketStart(q.End)-q.BucketStart(q.Start))/q.Interval.Milliseconds()+1 > MaxBuckets {
+		return ErrTooManyBuckets
+	}
+	return nil
+}
+
+// BucketStart 时间戳所在窗口的开始时间
+func (q Query) BucketStart(t int64) int64 {
+	interval := q.Interval.Milliseconds()
+	start := t - t%interval
+	if t < 0 && t%interval != 0 {
+		start -= interval
+	}
+	return start
+}
+
+type accumulator struct {
+	count       int
+	sum         float64
+	min, max    float64
+	first, last Point
+}
+
+func (a *accumulator) add(p Point) {
+	if a.count == 0 {
+		a.min, a.max, a.first, a.last = p.Value, p.Value, p, p
+	}
+	a.count++
+	a.sum += p.Value
+	if p.Value < a.min {
+		a.min = p.Value
+	}
+	if p.Value > a.max {
+		a.max = p.Value
+	}
+	if p.Time < a.first.Time {
+		a.first = p
+	}
+	if p.Time >= a.last.Time {
+		a.last = p
+	}
+}
+
+func (a *accumulator) value(fn Function) float64 {
+	switch fn {
+	case Min:
+		return a.min
+	case Max:
+		return a.max
+	case Sum:
+		return a.sum
+	case Count:
+		return float64(a.count)
+	case First:
+		return a.first.Value
+	case Last:
+		return a.last.Value
+	default:
+		return a.sum / float64(a.count)
+	}
+}
+
+// Aggregate 按窗口聚合数据点，只统计 [Start, End] 内的数据，结果按时间升序。
+// 除 FillNone 外返回查询范围内的全部窗口，调用前需先执行 Check
+func Aggregate(points []Point, q Query) []Bucket {
+	aggregator := NewAggregator(q)
+	for _, p := range points {
+		aggregator.Add(p)
+	}
+	return aggregator.Result()
+}
+
+// Aggregator 逐个累加数据点，只保存每个窗口的统计值，内存占用与窗口数成正比，
+// 与数据点数量无关，用于遍历存储中的数据时边读边聚合
+type Aggregator struct {
+	query        Query
+	accumulators map[int64]*accumulator
+}
+
+// NewAggregator 调用前需先执行 Query.Check
+func NewAggregator(q Query) *Aggregator {
+	return &Aggregator{query: q, accumulators: make(map[int64]*accumulator)}
+}
+
+// Add 累加一个数据点，[Start, End] 之外的数据点忽略
+func (g *Aggregator) Add(p Point) {
+	if p.Time < g.query.Start || p.Time > g.query.End {
+		return
+	}
+	start := g.query.BucketStart(p.Time)
+	a, ok := g.accumulators[start]
+	if !ok {
+		a = &accumulator{}
+		g.accumulators[start] = a
+	}
+	a.add(p)
+}
+
+// Result 按时间升序返回各窗口的聚合结果
+func (g *Aggregator) Result() []Bucket {
+	q := g.query
+	if q.Fill == FillNone {
+		buckets := make([]Bucket, 0, len(g.accumulators))
+		for start, a := range g.accumulators {
+			v := a.value(q.Function)
+			buckets = append(buckets, Bucket{Time: start, Value: &v})
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			return buckets[i].Time < buckets[j].Time
+		})
+		return buckets
+	}
+
+	interval := q.Interval.Milliseconds()
+	var buckets []Bucket
+	for start := q.BucketStart(q.Start); start <= q.End; start += interval {
+		bucket := Bucket{Time: start}
+		if a, ok := g.accumulators[start]; ok {
+			v := a.value(q.Function)
+			bucket.Value = &v
+		}
+		buckets = append(buckets, bucket)
+	}
+	fill(buckets, q)
+	return buckets
+}
+
+func fill(buckets []Bucket, q Query) {
+	switch q.Fill {
+	case FillValue:
+		for i := range buckets {
+			if buckets[i].Value == nil {
+				v := q.FillValue
+				buckets[i].Value = &v
+			}
+		}
+	case FillPrev:
+		var prev *float64
+		for i := range buckets {
+			if buckets[i].Value == nil {
+				buckets[i].Value = prev
+			} else {
+				prev = buckets[i].Value
+			}
+		}
+	case FillLinear:
+		prev := -1
+		for i := range buckets {
+			if buckets[i].Value == nil {
+				continue
+			}
+			if prev >= 0 && i-prev > 1 {
+				from, to := *buckets[prev].Value, *buckets[i].Value
+				for j := prev + 1; j < i; j++ {
+					v := from + (to-from)*float64(j-prev)/float64(i-prev)
+					buckets[j].Value = &v
+				}
+			}
+			prev = i
+		}
+	}
+}
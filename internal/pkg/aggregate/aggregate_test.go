@@ -0,0 +1,93 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30s":  30 * time.Second,
+		"5m":   5 * time.Minute,
+		"1h":   time.Hour,
+		"1d":   24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"1.5s": 1500 * time.Millisecond,
+	}
+	for s, expect := range cases {
+		interval, err := ParseInterval(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expect, interval, s)
+	}
+	for _, s := range []string{"", "abc", "500ms", "0s", "-1m", "xd"} {
+		_, err := ParseInterval(s)
+		assert.ErrorIs(t, err, ErrInvalidInterval, s)
+	}
+}
+
+func TestQueryCheck(t *testing.T) {
+	q := Query{Start: 60000, End: 0, Interval: time.Second}
+	require.NoError(t, q.Check())
+	assert.Equal(t, int64(0), q.Start)
+	assert.Equal(t, Avg, q.Function)
+	assert.Equal(t, FillNone, q.Fill)
+
+	q = Query{End: int64(MaxBuckets) * 1000, Interval: time.Second}
+	assert.ErrorIs(t, q.Check(), ErrTooManyBuckets)
+
+	q = Query{Interval: time.Second, Function: "median"}
+	assert.Error(t, q.Check())
+}
+
+func values(buckets []Bucket) []interface{} {
+	var result []interface{}
+	for _, b := range buckets {
+		if b.Value == nil {
+			result = append(result, nil)
+		} else {
+			result = append(result, *b.Value)
+		}
+	}
+	return result
+}
+
+func TestAggregate(t *testing.T) {
+	points := []Point{
+		{Time: 1000, Value: 1},
+		{Time: 5000, Value: 3},
+		{Time: 500, Value: 4},
+		{Time: 25000, Value: 10},
+		{Time: 40000, Value: 100},
+	}
+	query := func(fn Function, fill Fill) Query {
+		q := Query{Start: 0, End: 39999, Interval: 10 * time.Second, Function: fn, Fill: fill, FillValue: -1}
+		require.NoError(t, q.Check())
+		return q
+	}
+
+	buckets := Aggregate(points, query(Avg, FillNone))
+	assert.Equal(t, []interface{}{8.0 / 3, 10.0}, values(buckets))
+	assert.Equal(t, int64(20000), buckets[1].Time)
+
+	assert.Equal(t, []interface{}{1.0, nil, 10.0, nil}, values(Aggregate(points, query(Min, FillNull))))
+	assert.Equal(t, []interface{}{4.0, 4.0, 10.0, 10.0}, values(Aggregate(points, query(Max, FillPrev))))
+	assert.Equal(t, []interface{}{8.0, 9.0, 10.0, nil}, values(Aggregate(points, query(Sum, FillLinear))))
+	assert.Equal(t, []interface{}{3.0, -1.0, 1.0, -1.0}, values(Aggregate(points, query(Count, FillValue))))
+	assert.Equal(t, []interface{}{4.0, 10.0}, values(Aggregate(points, query(First, FillNone))))
+	assert.Equal(t, []interface{}{3.0, 10.0}, values(Aggregate(points, query(Last, FillNone))))
+}
+
+func TestAggregatorStreaming(t *testing.T) {
+	q := Query{Start: 0, End: 19999, Interval: 10 * time.Second, Function: Avg, Fill: FillNull}
+	require.NoError(t, q.Check())
+	aggregator := NewAggregator(q)
+	// 数据点逐个加入，顺序不影响结果，范围外的数据点忽略
+	for _, p := range []Point{{Time: 12000, Value: 4}, {Time: 1000, Value: 2}, {Time: 20000, Value: 100}, {Time: 3000, Value: 4}} {
+		aggregator.Add(p)
+	}
+	assert.Equal(t, []interface{}{3.0, 4.0}, values(aggregator.Result()))
+	assert.Equal(t, []interface{}{nil, nil}, values(NewAggregator(q).Result()))
+}
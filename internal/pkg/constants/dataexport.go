@@ -0,0 +1,32 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package constants
+
+// DataExportFormat 历史数据导出的文件格式
+type DataExportFormat string
+
+const (
+	DataExportFormatCSV     DataExportFormat = "csv"
+	DataExportFormatNDJSON  DataExportFormat = "ndjson"
+	DataExportFormatParquet DataExportFormat = "parquet"
+)
+
+type DataExportJobStatus string
+
+const (
+	DataExportJobRunning  DataExportJobStatus = "running"
+	DataExportJobFinished DataExportJobStatus = "finished"
+	DataExportJobFailed   DataExportJobStatus = "failed"
+)
@@ -31,4 +31,5 @@ const (
 	LevelDB  DataType = "leveldb"
 	TDengine DataType = "tdengine"
 	Tstorage DataType = "tstorage"
+	SQLiteDB DataType = "sqlite"
 )
@@ -25,6 +25,33 @@ const (
 	DeviceOffline = "offline"
 )
 
+const (
+	// DeviceExternalParentId 驱动接口中设备 External 字段携带的父设备(网关)ID
+	DeviceExternalParentId = "parentId"
+	// DeviceMetadataParentId 驱动查询设备列表时，通过 grpc metadata 指定父设备(网关)ID 查询其子设备
+	DeviceMetadataParentId = "parent-id"
+)
+
+// DeviceConnectReason 设备上下线原因
+type DeviceConnectReason string
+
+const (
+	DeviceConnectReasonConnect          DeviceConnectReason = "connect"           //驱动上报设备上线
+	DeviceConnectReasonDisconnect       DeviceConnectReason = "disconnect"        //驱动上报设备离线
+	DeviceConnectReasonKeepAliveTimeout DeviceConnectReason = "keepalive_timeout" //心跳超时
+	DeviceConnectReasonKeepAliveRecover DeviceConnectReason = "keepalive_recover" //心跳超时后再次活跃
+	DeviceConnectReasonDriverStopped    DeviceConnectReason = "driver_stopped"    //驱动实例停止
+	DeviceConnectReasonGatewayOffline   DeviceConnectReason = "gateway_offline"   //网关离线
+	DeviceConnectReasonManual           DeviceConnectReason = "manual"            //手动修改状态
+)
+
+type DeviceGroupType string
+
+const (
+	DeviceGroupStatic  DeviceGroupType = "static"
+	DeviceGroupDynamic DeviceGroupType = "dynamic"
+)
+
 const (
 	DeviceStatusUnKnow   DeviceStatus = "未知"
 	DeviceStatusOnline   DeviceStatus = "在线"
@@ -49,3 +76,34 @@ func (i DeviceStatus) TransformToDriverDeviceStatus() driverdevice.DeviceStatus
 		return driverdevice.DeviceStatus_UnKnowStatus
 	}
 }
+
+// DeviceBatchJobType 批量下发任务类型
+type DeviceBatchJobType string
+
+const (
+	DeviceBatchPropertySet   DeviceBatchJobType = "property_set"
+	DeviceBatchServiceInvoke DeviceBatchJobType = "service_invoke"
+)
+
+type DeviceBatchJobStatus string
+
+const (
+	DeviceBatchJobRunning  DeviceBatchJobStatus = "running"
+	DeviceBatchJobFinished DeviceBatchJobStatus = "finished"
+)
+
+// DeviceBatchResultStatus 批量下发任务中单个设备的执行状态
+type DeviceBatchResultStatus string
+
+const (
+	DeviceBatchResultPending DeviceBatchResultStatus = "pending"
+	DeviceBatchResultSuccess DeviceBatchResultStatus = "success"
+	DeviceBatchResultFailed  DeviceBatchResultStatus = "failed"
+	DeviceBatchResultTimeout DeviceBatchResultStatus = "timeout"
+)
+
+// 设备导出格式
+const (
+	DeviceExportFormatXlsx = "xlsx"
+	DeviceExportFormatCSV  = "csv"
+)
@@ -0,0 +1,25 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package constants
+
+// MessageBufferDropPolicy 消息总线队列已满时的丢弃策略
+type MessageBufferDropPolicy string
+
+const (
+	// MessageBufferDropOldest 删除队列中最早的消息
+	MessageBufferDropOldest MessageBufferDropPolicy = "oldest"
+	// MessageBufferDropNewest 丢弃新消息
+	MessageBufferDropNewest MessageBufferDropPolicy = "newest"
+)
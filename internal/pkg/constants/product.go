@@ -115,6 +115,11 @@ func (i SpecsType) AllowSendInEkuiper() bool {
 	return false
 }
 
+// IsNested 结构体和数组，规则引擎中需通过 pos.lat、points[0] 形式引用其标量字段
+func (i SpecsType) IsNested() bool {
+	return i == SpecsTypeStruct || i == SpecsTypeArray
+}
+
 type ProductNodeType string
 
 const (
@@ -204,3 +209,36 @@ const (
 	CallTypeSync  CallType = "SYNC"  //同步
 	CallTypeAsync CallType = "ASYNC" //异步
 )
+
+// ThingModelValidateMode 设备上报数据不符合物模型定义时的处理方式
+type ThingModelValidateMode string
+
+const (
+	ThingModelValidateModeReject ThingModelValidateMode = "reject" //拒绝整条消息
+	ThingModelValidateModeDrop   ThingModelValidateMode = "drop"   //丢弃不合法的字段，其余字段正常入库
+	ThingModelValidateModeFlag   ThingModelValidateMode = "flag"   //正常入库，仅记录不合法次数
+)
+
+// IsValid 空值按 flag 处理，兼容历史产品
+func (m ThingModelValidateMode) IsValid() bool {
+	switch m {
+	case "", ThingModelValidateModeReject, ThingModelValidateModeDrop, ThingModelValidateModeFlag:
+		return true
+	default:
+		return false
+	}
+}
+
+// 物模型导入导出文件格式
+const (
+	ThingModelFormatHummingbird = "hummingbird" //本平台格式，见 dtos.ThingModelFile
+	ThingModelFormatTSL         = "tsl"         //阿里云物模型 TSL 格式，见 dtos.ThingModelTSL
+)
+
+// 物模型导入时单个功能的差异
+const (
+	ThingModelImportAdd       = "add"       //新增
+	ThingModelImportUpdate    = "update"    //修改
+	ThingModelImportUnchanged = "unchanged" //与现有定义相同
+	ThingModelImportConflict  = "conflict"  //冲突，存在冲突时不导入
+)
@@ -27,3 +27,19 @@ const (
 	SceneStart SceneStatus = "running"
 	SceneStop  SceneStatus = "stopped"
 )
+
+// JobMisfirePolicy 定时任务错过执行时间后的处理策略
+type JobMisfirePolicy string
+
+const (
+	JobMisfireSkip    JobMisfirePolicy = "skip"     // 跳过错过的执行
+	JobMisfireRunOnce JobMisfirePolicy = "run_once" // 启动时补执行一次
+)
+
+// JobConcurrencyPolicy 定时任务上一次执行未结束时的处理策略
+type JobConcurrencyPolicy string
+
+const (
+	JobConcurrencyAllow  JobConcurrencyPolicy = "allow"  // 允许并发执行
+	JobConcurrencyForbid JobConcurrencyPolicy = "forbid" // 禁止并发执行，跳过本次
+)
@@ -61,6 +61,21 @@ const (
 	DeviceAndDriverPlatformNotIdentical        = 20414
 	DeviceAssociationAlertRule                 = 20415
 	DeviceAssociationSceneRule                 = 20416
+	DeviceShadowVersionConflict                = 20417
+	DeviceNotGateway                           = 20418
+	DeviceNotSubDevice                         = 20419
+	DeviceHasSubDevices                        = 20420
+	DeviceAlreadyHasParent                     = 20421
+	DeviceGroupNotExist                        = 20422
+	DeviceGroupNotStatic                       = 20423
+	DeviceGroupTagsRequired                    = 20424
+	DeviceGroupAssociationRule                 = 20425
+	DeviceBatchJobNotExist                     = 20426
+	DeviceThingModelDataInvalid                = 20427
+	DataExportJobNotExist                      = 20428
+	DataExportJobNotFinished                   = 20429
+	DeviceNotBelongDriver                      = 20430
+	DataExportJobLimit                         = 20431
 
 	// 产品
 	ProductMustDeleteDevice       uint32 = 20602
@@ -90,7 +105,9 @@ const (
 
 	SceneTimerIsStartingNotAllowUpdate = 21400
 
-	SceneRuleParamsError uint32 = 21402
+	SceneRuleParamsError         uint32 = 21402
+	SceneTemplateHasInstances    uint32 = 21403
+	SceneTemplateBindingNotMatch uint32 = 21404
 
 	RuleEngineIsStartingNotAllowUpdate = 21500
 
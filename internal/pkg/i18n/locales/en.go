@@ -237,6 +237,66 @@ func GetEnMessages() []*i18n.Message {
 			ID:    "20416",
 			Other: `This device has been bound to scene rules. Please stop reporting scene rules before proceeding with the operation`,
 		},
+		{
+			ID:    "20417",
+			Other: `The device shadow has been modified by others, please refresh and try again.`,
+		},
+		{
+			ID:    "20418",
+			Other: `The parent device is not a gateway device`,
+		},
+		{
+			ID:    "20419",
+			Other: `Only gateway sub-devices can be added to a gateway`,
+		},
+		{
+			ID:    "20420",
+			Other: `This gateway still has sub-devices. Please remove or delete the sub-devices first`,
+		},
+		{
+			ID:    "20421",
+			Other: `The sub-device has been added to another gateway. Please remove it from that gateway first`,
+		},
+		{
+			ID:    "20422",
+			Other: `The device group does not exist`,
+		},
+		{
+			ID:    "20423",
+			Other: `Devices can only be added to or removed from static groups`,
+		},
+		{
+			ID:    "20424",
+			Other: `Dynamic groups require at least one tag condition`,
+		},
+		{
+			ID:    "20425",
+			Other: `This group is referenced by alarm or scene rules. Please edit or delete the relevant rules before proceeding with the operation`,
+		},
+		{
+			ID:    "20426",
+			Other: `Batch job does not exist or has expired`,
+		},
+		{
+			ID:    "20427",
+			Other: `Reported data does not match the product thing model`,
+		},
+		{
+			ID:    "20428",
+			Other: `The export job does not exist or has expired`,
+		},
+		{
+			ID:    "20429",
+			Other: `The export job has not finished yet`,
+		},
+		{
+			ID:    "20430",
+			Other: `The device does not belong to this driver instance`,
+		},
+		{
+			ID:    "20431",
+			Other: `Too many export jobs are running, please try again later`,
+		},
 
 		// 产品
 		{
@@ -488,6 +548,14 @@ func GetEnMessages() []*i18n.Message {
 			ID:    "21402",
 			Other: "Parameter error, please edit the scene again.",
 		},
+		{
+			ID:    "21403",
+			Other: "The scene template still has scenes created from it, please delete them first.",
+		},
+		{
+			ID:    "21404",
+			Other: "The device bound to the template role does not exist or does not belong to the role's product.",
+		},
 		//rule
 		{
 			ID:    "21500",
@@ -236,6 +236,66 @@ func GetZhMessages() []*i18n.Message {
 			ID:    "20416",
 			Other: `该设备已与场景联动绑定，请停止场景联动规则，再进行操作`,
 		},
+		{
+			ID:    "20417",
+			Other: `设备影子已被修改，请刷新后重试`,
+		},
+		{
+			ID:    "20418",
+			Other: `父设备不是网关设备`,
+		},
+		{
+			ID:    "20419",
+			Other: `只有网关子设备才能添加到网关下`,
+		},
+		{
+			ID:    "20420",
+			Other: `该网关下存在子设备，请先移除或删除子设备`,
+		},
+		{
+			ID:    "20421",
+			Other: `子设备已添加到其他网关，请先从该网关移除`,
+		},
+		{
+			ID:    "20422",
+			Other: `设备分组不存在`,
+		},
+		{
+			ID:    "20423",
+			Other: `只有静态分组可以添加或移除设备`,
+		},
+		{
+			ID:    "20424",
+			Other: `动态分组至少需要一个标签条件`,
+		},
+		{
+			ID:    "20425",
+			Other: `该分组已被告警规则或场景联动引用，请先修改或删除相关规则后再操作`,
+		},
+		{
+			ID:    "20426",
+			Other: `批量下发任务不存在或已过期`,
+		},
+		{
+			ID:    "20427",
+			Other: `上报数据不符合产品物模型定义`,
+		},
+		{
+			ID:    "20428",
+			Other: `导出任务不存在或已过期`,
+		},
+		{
+			ID:    "20429",
+			Other: `导出任务尚未完成`,
+		},
+		{
+			ID:    "20430",
+			Other: `设备不属于该驱动实例`,
+		},
+		{
+			ID:    "20431",
+			Other: `正在运行的导出任务过多，请稍后重试`,
+		},
 
 		// 产品
 		{
@@ -480,6 +540,14 @@ func GetZhMessages() []*i18n.Message {
 			ID:    "21402",
 			Other: "参数错误，请从新编辑该场景.",
 		},
+		{
+			ID:    "21403",
+			Other: "该场景模板下存在场景实例，请先删除场景实例.",
+		},
+		{
+			ID:    "21404",
+			Other: "模板角色绑定的设备不存在或不属于该角色的产品.",
+		},
 		//rule
 		{
 			ID:    "21500",
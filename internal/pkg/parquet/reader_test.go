@@ -0,0 +1,265 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// 测试用的 parquet 读取器，独立于 Writer 按格式定义解码 thrift compact 元数据、页头、定义级别和 PLAIN 值，
+// 用于校验写入的文件可以被还原
+
+// thriftStruct 解码后的 thrift 结构体，i32/i64 为 int64，binary 为 string，list 为 []interface{}，结构体为 thriftStruct
+type thriftStruct map[int16]interface{}
+
+func (s thriftStruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s thriftStruct) string(id int16) string {
+	v, _ := s[id].(string)
+	return v
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+func (s thriftStruct) child(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+type decoder struct {
+	b   []byte
+	pos int
+}
+
+func (d *decoder) byte() (byte, error) {
+	if d.pos >= len(d.b) {
+		return 0, errors.New("unexpected end of thrift data")
+	}
+	c := d.b[d.pos]
+	d.pos++
+	return c, nil
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.b[d.pos:])
+	if n <= 0 {
+		return 0, errors.New("invalid varint")
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *decoder) zigzag() (int64, error) {
+	v, err := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (d *decoder) value(typ byte) (interface{}, error) {
+	switch typ {
+	case 1, 2:
+		// 字段中的布尔值保存在类型里
+		return typ == 1, nil
+	case 3:
+		c, err := d.byte()
+		return int64(int8(c)), err
+	case 4, 5, 6:
+		return d.zigzag()
+	case 7:
+		if d.pos+8 > len(d.b) {
+			return nil, errors.New("unexpected end of thrift data")
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.b[d.pos:]))
+		d.pos += 8
+		return v, nil
+	case 8:
+		n, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if d.pos+int(n) > len(d.b) {
+			return nil, errors.New("unexpected end of thrift data")
+		}
+		v := string(d.b[d.pos : d.pos+int(n)])
+		d.pos += int(n)
+		return v, nil
+	case 9, 10:
+		header, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = d.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		elems := make([]interface{}, 0, size)
+		for i := uint64(0); i < size; i++ {
+			v, err := d.value(header & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, v)
+		}
+		return elems, nil
+	case 12:
+		return d.structure()
+	}
+	return nil, fmt.Errorf("unsupported thrift type %d", typ)
+}
+
+func (d *decoder) structure() (thriftStruct, error) {
+	s := thriftStruct{}
+	var last int16
+	for {
+		header, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return s, nil
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := d.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		if s[id], err = d.value(header & 0x0f); err != nil {
+			return nil, err
+		}
+		last = id
+	}
+}
+
+// decodeLevels 解码位宽为 1 的 RLE/bit-packing 混合编码，返回 n 个定义级别
+func decodeLevels(b []byte, n int) ([]bool, error) {
+	d := decoder{b: b}
+	levels := make([]bool, 0, n)
+	for len(levels) < n {
+		header, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if header&1 == 0 {
+			v, err := d.byte()
+			if err != nil {
+				return nil, err
+			}
+			for i := uint64(0); i < header>>1; i++ {
+				levels = append(levels, v == 1)
+			}
+			continue
+		}
+		for i := uint64(0); i < header>>1; i++ {
+			v, err := d.byte()
+			if err != nil {
+				return nil, err
+			}
+			for bit := 0; bit < 8; bit++ {
+				levels = append(levels, v&(1<<bit) != 0)
+			}
+		}
+	}
+	if d.pos != len(b) {
+		return nil, fmt.Errorf("%d bytes left after definition levels", len(b)-d.pos)
+	}
+	return levels[:n], nil
+}
+
+// readFile 解码 parquet 文件，返回文件元数据和按行还原的值，空值为 nil，ByteArray 列还原为 string
+func readFile(b []byte) (thriftStruct, [][]interface{}, error) {
+	if len(b) < 12 || string(b[:4]) != magic || string(b[len(b)-4:]) != magic {
+		return nil, nil, errors.New("missing parquet magic")
+	}
+	size := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footer := decoder{b: b[len(b)-8-size : len(b)-8]}
+	meta, err := footer.structure()
+	if err != nil {
+		return nil, nil, err
+	}
+	if footer.pos != size {
+		return nil, nil, fmt.Errorf("file metadata is %d bytes, footer says %d", footer.pos, size)
+	}
+	schema := meta.list(2)[1:]
+
+	var rows [][]interface{}
+	for _, g := range meta.list(4) {
+		group := g.(thriftStruct)
+		groupRows := make([][]interface{}, group.int(3))
+		for i := range groupRows {
+			groupRows[i] = make([]interface{}, len(schema))
+		}
+		for i, c := range group.list(1) {
+			element := schema[i].(thriftStruct)
+			chunk := c.(thriftStruct).child(3)
+			offset := int(chunk.int(9))
+			page := decoder{b: b[offset:]}
+			header, err := page.structure()
+			if err != nil {
+				return nil, nil, err
+			}
+			if int64(page.pos)+header.int(3) != chunk.int(7) {
+				return nil, nil, fmt.Errorf("column %s chunk size %d does not match page", element.string(4), chunk.int(7))
+			}
+			data := decoder{b: b[offset+page.pos : offset+page.pos+int(header.int(3))]}
+			n := int(header.child(5).int(1))
+			if n != len(groupRows) {
+				return nil, nil, fmt.Errorf("column %s has %d values, row group has %d rows", element.string(4), n, len(groupRows))
+			}
+			defined := make([]bool, n)
+			for j := range defined {
+				defined[j] = true
+			}
+			if element.int(3) == repetitionOptional {
+				length := int(binary.LittleEndian.Uint32(data.b[data.pos:]))
+				data.pos += 4
+				if defined, err = decodeLevels(data.b[data.pos:data.pos+length], n); err != nil {
+					return nil, nil, err
+				}
+				data.pos += length
+			}
+			var bit int
+			for j := range groupRows {
+				if !defined[j] {
+					continue
+				}
+				var v interface{}
+				switch Type(element.int(1)) {
+				case Boolean:
+					v = data.b[data.pos+bit/8]&(1<<(bit%8)) != 0
+					bit++
+				case Int64:
+					v = int64(binary.LittleEndian.Uint64(data.b[data.pos:]))
+					data.pos += 8
+				case Double:
+					v = math.Float64frombits(binary.LittleEndian.Uint64(data.b[data.pos:]))
+					data.pos += 8
+				case ByteArray:
+					length := int(binary.LittleEndian.Uint32(data.b[data.pos:]))
+					v = string(data.b[data.pos+4 : data.pos+4+length])
+					data.pos += 4 + length
+				}
+				groupRows[j][i] = v
+			}
+			data.pos += (bit + 7) / 8
+			if data.pos != len(data.b) {
+				return nil, nil, fmt.Errorf("column %s has %d bytes left in page", element.string(4), len(data.b)-data.pos)
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	if int64(len(rows)) != meta.int(3) {
+		return nil, nil, fmt.Errorf("file has %d rows, metadata says %d", len(rows), meta.int(3))
+	}
+	return meta, rows, nil
+}
@@ -0,0 +1,106 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// thrift compact 协议的字段类型
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// encoder 按 thrift compact 协议编码页头和文件元数据，只实现了用到的类型
+type encoder struct {
+	buf bytes.Buffer
+	// last 每层结构体中上一个字段的 id，字段头只保存与上一个字段 id 的差值
+	last []int16
+}
+
+func (e *encoder) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	e.buf.Write(b[:n])
+}
+
+func (e *encoder) varint32(v int32) {
+	e.uvarint(uint64(uint32((v << 1) ^ (v >> 31))))
+}
+
+func (e *encoder) varint64(v int64) {
+	e.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *encoder) fieldHeader(id int16, typ byte) {
+	last := &e.last[len(e.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		e.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		e.buf.WriteByte(typ)
+		e.varint32(int32(id))
+	}
+	*last = id
+}
+
+func (e *encoder) structBegin() {
+	e.last = append(e.last, 0)
+}
+
+func (e *encoder) structEnd() {
+	e.buf.WriteByte(0)
+	e.last = e.last[:len(e.last)-1]
+}
+
+func (e *encoder) fieldStruct(id int16) {
+	e.fieldHeader(id, compactStruct)
+	e.structBegin()
+}
+
+func (e *encoder) fieldI32(id int16, v int32) {
+	e.fieldHeader(id, compactI32)
+	e.varint32(v)
+}
+
+func (e *encoder) fieldI64(id int16, v int64) {
+	e.fieldHeader(id, compactI64)
+	e.varint64(v)
+}
+
+func (e *encoder) fieldString(id int16, v string) {
+	e.fieldHeader(id, compactBinary)
+	e.string(v)
+}
+
+func (e *encoder) string(v string) {
+	e.uvarint(uint64(len(v)))
+	e.buf.WriteString(v)
+}
+
+// fieldList 写入列表的字段头，元素由调用方按元素类型依次写入，结构体元素用 structBegin/structEnd 包裹
+func (e *encoder) fieldList(id int16, elemType byte, size int) {
+	e.fieldHeader(id, compactList)
+	if size < 15 {
+		e.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		e.buf.WriteByte(0xf0 | elemType)
+		e.uvarint(uint64(size))
+	}
+}
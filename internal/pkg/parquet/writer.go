@@ -0,0 +1,366 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+// Package parquet 按行写入扁平结构的 parquet 文件。
+// 只支持 PLAIN 编码、不压缩，每个行组的每列写为一个数据页，可以用 pyarrow、pandas、spark 等读取
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	magic = "PAR1"
+	// DefaultRowGroupSize 每个行组缓存的行数，写满后写入文件
+	DefaultRowGroupSize = 10000
+)
+
+// Type 列的物理类型，取值与 parquet 格式定义相同
+type Type int32
+
+const (
+	Boolean   Type = 0
+	Int64     Type = 2
+	Double    Type = 5
+	ByteArray Type = 6
+)
+
+// Logical 列的逻辑类型，写入文件时转换为 parquet 的 ConvertedType
+type Logical int
+
+const (
+	LogicalNone Logical = iota
+	LogicalString
+	LogicalJSON
+	LogicalTimestampMillis
+)
+
+var convertedTypes = map[Logical]int32{
+	LogicalString:          0,
+	LogicalTimestampMillis: 9,
+	LogicalJSON:            19,
+}
+
+// parquet 格式中的枚举值
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageTypeData       = 0
+)
+
+type Column struct {
+	Name    string
+	Type    Type
+	Logical Logical
+	// Required 为 false 时列可以为空
+	Required bool
+}
+
+// column 当前行组中一列的数据，values 为 PLAIN 编码后的非空值
+type column struct {
+	Column
+	defined []bool
+	values  bytes.Buffer
+	bools   []bool
+}
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	rows   int64
+}
+
+type rowGroup struct {
+	chunks []columnChunk
+	size   int64
+	rows   int64
+}
+
+// Writer 按行缓存一个行组，写满 rowGroupSize 行或 Close 时写入文件，Close 时写入文件元数据
+type Writer struct {
+	w            io.Writer
+	offset       int64
+	columns      []*column
+	rowGroupSize int
+	rows         int
+	rowGroups    []rowGroup
+}
+
+func NewWriter(w io.Writer, columns []Column, rowGroupSize int) (*Writer, error) {
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+	pw := &Writer{
+		w:            w,
+		rowGroupSize: rowGroupSize,
+	}
+	for _, c := range columns {
+		pw.columns = append(pw.columns, &column{Column: c})
+	}
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *Writer) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+// Write 写入一行，值的顺序与列相同。Boolean 列为 bool，Int64 列为 int64，Double 列为 float64，ByteArray 列为 string 或 []byte，nil 为空值
+func (pw *Writer) Write(row []interface{}) error {
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("parquet row has %d values, want %d", len(row), len(pw.columns))
+	}
+	for i, c := range pw.columns {
+		if err := c.check(row[i]); err != nil {
+			return err
+		}
+	}
+	for i, c := range pw.columns {
+		c.append(row[i])
+	}
+	pw.rows++
+	if pw.rows >= pw.rowGroupSize {
+		return pw.flush()
+	}
+	return nil
+}
+
+func (c *column) check(v interface{}) error {
+	if v == nil {
+		if c.Required {
+			return fmt.Errorf("parquet column %s is required", c.Name)
+		}
+		return nil
+	}
+	var ok bool
+	switch c.Type {
+	case Boolean:
+		_, ok = v.(bool)
+	case Int64:
+		_, ok = v.(int64)
+	case Double:
+		_, ok = v.(float64)
+	case ByteArray:
+		switch v.(type) {
+		case string, []byte:
+			ok = true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("parquet column %s can not write %T", c.Name, v)
+	}
+	return nil
+}
+
+func (c *column) append(v interface{}) {
+	c.defined = append(c.defined, v != nil)
+	if v == nil {
+		return
+	}
+	var b [8]byte
+	switch value := v.(type) {
+	case bool:
+		c.bools = append(c.bools, value)
+	case int64:
+		binary.LittleEndian.PutUint64(b[:], uint64(value))
+		c.values.Write(b[:])
+	case float64:
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(value))
+		c.values.Write(b[:])
+	case string:
+		binary.LittleEndian.PutUint32(b[:4], uint32(len(value)))
+		c.values.Write(b[:4])
+		c.values.WriteString(value)
+	case []byte:
+		binary.LittleEndian.PutUint32(b[:4], uint32(len(value)))
+		c.values.Write(b[:4])
+		c.values.Write(value)
+	}
+}
+
+// page 数据页的内容：可为空的列先写入长度和 RLE 编码的定义级别，再写入非空值
+func (c *column) page() []byte {
+	var page bytes.Buffer
+	if !c.Required {
+		levels := encodeLevels(c.defined)
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(len(levels)))
+		page.Write(b[:])
+		page.Write(levels)
+	}
+	if c.Type == Boolean {
+		packed := make([]byte, (len(c.bools)+7)/8)
+		for i, v := range c.bools {
+			if v {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		page.Write(packed)
+	} else {
+		page.Write(c.values.Bytes())
+	}
+	return page.Bytes()
+}
+
+func (c *column) reset() {
+	c.defined = c.defined[:0]
+	c.bools = c.bools[:0]
+	c.values.Reset()
+}
+
+// encodeLevels 按 RLE/bit-packing 混合编码写入位宽为 1 的定义级别，只使用 RLE 游程
+func encodeLevels(defined []bool) []byte {
+	var buf bytes.Buffer
+	var b [binary.MaxVarintLen64]byte
+	for i := 0; i < len(defined); {
+		j := i
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		n := binary.PutUvarint(b[:], uint64(j-i)<<1)
+		buf.Write(b[:n])
+		if defined[i] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+func (pw *Writer) flush() error {
+	if pw.rows == 0 {
+		return nil
+	}
+	group := rowGroup{rows: int64(pw.rows)}
+	for _, c := range pw.columns {
+		page := c.page()
+		var header encoder
+		header.structBegin()
+		header.fieldI32(1, pageTypeData)
+		header.fieldI32(2, int32(len(page)))
+		header.fieldI32(3, int32(len(page)))
+		header.fieldStruct(5)
+		header.fieldI32(1, int32(pw.rows))
+		header.fieldI32(2, encodingPlain)
+		header.fieldI32(3, encodingRLE)
+		header.fieldI32(4, encodingRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunk := columnChunk{
+			offset: pw.offset,
+			size:   int64(header.buf.Len() + len(page)),
+			rows:   int64(pw.rows),
+		}
+		if err := pw.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := pw.write(page); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.size
+		c.reset()
+	}
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.rows = 0
+	return nil
+}
+
+// Close 写入缓存的行和文件元数据，不关闭底层的 io.Writer
+func (pw *Writer) Close() error {
+	if err := pw.flush(); err != nil {
+		return err
+	}
+	var numRows int64
+	for _, group := range pw.rowGroups {
+		numRows += group.rows
+	}
+
+	var meta encoder
+	meta.structBegin()
+	meta.fieldI32(1, 1)
+	meta.fieldList(2, compactStruct, len(pw.columns)+1)
+	meta.structBegin()
+	meta.fieldString(4, "schema")
+	meta.fieldI32(5, int32(len(pw.columns)))
+	meta.structEnd()
+	for _, c := range pw.columns {
+		meta.structBegin()
+		meta.fieldI32(1, int32(c.Type))
+		if c.Required {
+			meta.fieldI32(3, repetitionRequired)
+		} else {
+			meta.fieldI32(3, repetitionOptional)
+		}
+		meta.fieldString(4, c.Name)
+		if converted, ok := convertedTypes[c.Logical]; ok {
+			meta.fieldI32(6, converted)
+		}
+		meta.structEnd()
+	}
+	meta.fieldI64(3, numRows)
+	meta.fieldList(4, compactStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		meta.structBegin()
+		meta.fieldList(1, compactStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			c := pw.columns[i]
+			meta.structBegin()
+			meta.fieldI64(2, chunk.offset)
+			meta.fieldStruct(3)
+			meta.fieldI32(1, int32(c.Type))
+			meta.fieldList(2, compactI32, 2)
+			meta.varint32(encodingPlain)
+			meta.varint32(encodingRLE)
+			meta.fieldList(3, compactBinary, 1)
+			meta.string(c.Name)
+			meta.fieldI32(4, codecUncompressed)
+			meta.fieldI64(5, chunk.rows)
+			meta.fieldI64(6, chunk.size)
+			meta.fieldI64(7, chunk.size)
+			meta.fieldI64(9, chunk.offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.fieldI64(2, group.size)
+		meta.fieldI64(3, group.rows)
+		meta.structEnd()
+	}
+	meta.fieldString(6, "hummingbird")
+	meta.structEnd()
+
+	if err := pw.write(meta.buf.Bytes()); err != nil {
+		return err
+	}
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(meta.buf.Len()))
+	if err := pw.write(b[:]); err != nil {
+		return err
+	}
+	return pw.write([]byte(magic))
+}
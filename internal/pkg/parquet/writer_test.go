@@ -0,0 +1,147 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{
+		{Name: "device_id", Type: ByteArray, Logical: LogicalString, Required: true},
+		{Name: "time", Type: Int64, Logical: LogicalTimestampMillis, Required: true},
+		{Name: "temperature", Type: Double},
+		{Name: "switch", Type: Boolean},
+	}, 2)
+	require.NoError(t, err)
+	require.NoError(t, w.Write([]interface{}{"device1", int64(1000), 1.5, true}))
+	require.NoError(t, w.Write([]interface{}{"device1", int64(2000), nil, false}))
+	require.NoError(t, w.Write([]interface{}{"device1", int64(3000), 2.5, nil}))
+	assert.Len(t, w.rowGroups, 1)
+
+	assert.Error(t, w.Write([]interface{}{nil, int64(4000), 1.0, true}))
+	assert.Error(t, w.Write([]interface{}{"device1", 4000, 1.0, true}))
+	assert.Error(t, w.Write([]interface{}{"device1", int64(4000)}))
+	require.NoError(t, w.Close())
+	assert.Len(t, w.rowGroups, 2)
+
+	b := buf.Bytes()
+	assert.Equal(t, magic, string(b[:4]))
+	assert.Equal(t, magic, string(b[len(b)-4:]))
+	footer := int(binary.LittleEndian.Uint32(b[len(b)-8 : len(b)-4]))
+	assert.Less(t, footer, len(b)-12)
+	assert.Contains(t, string(b[len(b)-8-footer:len(b)-8]), "temperature")
+}
+
+func TestEncodeLevels(t *testing.T) {
+	// 3 个有值、1 个空值、1 个有值，每个游程为 (长度<<1) 和 1 字节的值
+	assert.Equal(t, []byte{6, 1, 2, 0, 2, 1}, encodeLevels([]bool{true, true, true, false, true}))
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	columns := []Column{
+		{Name: "device_id", Type: ByteArray, Logical: LogicalString, Required: true},
+		{Name: "time", Type: Int64, Logical: LogicalTimestampMillis, Required: true},
+		{Name: "temperature", Type: Double},
+		{Name: "count", Type: Int64},
+		{Name: "switch", Type: Boolean},
+		{Name: "payload", Type: ByteArray, Logical: LogicalJSON},
+	}
+	var rows [][]interface{}
+	for i := 0; i < 23; i++ {
+		row := []interface{}{fmt.Sprintf("device%d", i%3), int64(i * 1000), nil, nil, nil, nil}
+		if i%3 != 0 {
+			row[2] = float64(i) / 2
+		}
+		if i < 5 || i > 15 {
+			row[3] = int64(-i)
+		}
+		if i%4 != 1 {
+			// 每页超过 8 个布尔值，跨字节打包
+			row[4] = i%3 == 0
+		}
+		if i%5 == 0 {
+			row[5] = []byte(fmt.Sprintf(`{"i":%d}`, i))
+		}
+		rows = append(rows, row)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns, 10)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+
+	meta, got, err := readFile(buf.Bytes())
+	require.NoError(t, err)
+	assert.EqualValues(t, len(rows), meta.int(3))
+	assert.Len(t, meta.list(4), 3)
+
+	schema := meta.list(2)
+	require.Len(t, schema, len(columns)+1)
+	assert.EqualValues(t, len(columns), schema[0].(thriftStruct).int(5))
+	for i, c := range columns {
+		element := schema[i+1].(thriftStruct)
+		assert.Equal(t, c.Name, element.string(4))
+		assert.EqualValues(t, c.Type, element.int(1))
+		if c.Required {
+			assert.EqualValues(t, repetitionRequired, element.int(3), c.Name)
+		} else {
+			assert.EqualValues(t, repetitionOptional, element.int(3), c.Name)
+		}
+		converted, ok := convertedTypes[c.Logical]
+		_, has := element[6]
+		assert.Equal(t, ok, has, c.Name)
+		assert.EqualValues(t, converted, element.int(6), c.Name)
+	}
+
+	require.Len(t, got, len(rows))
+	for i, row := range rows {
+		if v, ok := row[5].([]byte); ok {
+			row[5] = string(v)
+		}
+		assert.Equal(t, row, got[i], "row %d", i)
+	}
+}
+
+func TestWriterRoundTripWideSchema(t *testing.T) {
+	// 超过 14 列时元数据中的列表长度单独编码
+	var columns []Column
+	var row []interface{}
+	for i := 0; i < 20; i++ {
+		columns = append(columns, Column{Name: fmt.Sprintf("c%d", i), Type: Int64})
+		if i%2 == 0 {
+			row = append(row, int64(i))
+		} else {
+			row = append(row, nil)
+		}
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns, 0)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(row))
+	require.NoError(t, w.Close())
+
+	meta, got, err := readFile(buf.Bytes())
+	require.NoError(t, err)
+	assert.Len(t, meta.list(2), len(columns)+1)
+	assert.Equal(t, [][]interface{}{row}, got)
+}
+
+func TestDecodeLevels(t *testing.T) {
+	levels, err := decodeLevels([]byte{6, 1, 2, 0, 2, 1}, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, true, false, true}, levels)
+
+	// bit-packing 游程：1 组 8 个值
+	levels, err = decodeLevels([]byte{3, 0x05}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true}, levels)
+}
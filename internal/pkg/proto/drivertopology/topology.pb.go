@@ -0,0 +1,260 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.14.0
+// source: drivertopology/topology.proto
+
+package drivertopology
+
+import (
+	drivercommon "github.com/winc-link/edge-driver-proto/drivercommon"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SubDevicesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BaseRequest *drivercommon.BaseRequestMessage `protobuf:"bytes,1,opt,name=baseRequest,proto3" json:"baseRequest,omitempty"`
+	// 网关设备id
+	ParentId string `protobuf:"bytes,2,opt,name=parentId,proto3" json:"parentId,omitempty"`
+	// 子设备id，网关和子设备需要属于调用的驱动实例
+	DeviceIds []string `protobuf:"bytes,3,rep,name=deviceIds,proto3" json:"deviceIds,omitempty"`
+}
+
+func (x *SubDevicesRequest) Reset() {
+	*x = SubDevicesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_drivertopology_topology_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubDevicesRequest) ProtoMessage() {}
+
+func (x *SubDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_drivertopology_topology_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubDevicesRequest.ProtoReflect.Descriptor instead.
+func (*SubDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_drivertopology_topology_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubDevicesRequest) GetBaseRequest() *drivercommon.BaseRequestMessage {
+	if x != nil {
+		return x.BaseRequest
+	}
+	return nil
+}
+
+func (x *SubDevicesRequest) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *SubDevicesRequest) GetDeviceIds() []string {
+	if x != nil {
+		return x.DeviceIds
+	}
+	return nil
+}
+
+type SubDevicesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BaseResponse *drivercommon.CommonResponse `protobuf:"bytes,1,opt,name=baseResponse,proto3" json:"baseResponse,omitempty"`
+}
+
+func (x *SubDevicesResponse) Reset() {
+	*x = SubDevicesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_drivertopology_topology_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubDevicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubDevicesResponse) ProtoMessage() {}
+
+func (x *SubDevicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_drivertopology_topology_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubDevicesResponse.ProtoReflect.Descriptor instead.
+func (*SubDevicesResponse) Descriptor() ([]byte, []int) {
+	return file_drivertopology_topology_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubDevicesResponse) GetBaseResponse() *drivercommon.CommonResponse {
+	if x != nil {
+		return x.BaseResponse
+	}
+	return nil
+}
+
+var File_drivertopology_topology_proto protoreflect.FileDescriptor
+
+var file_drivertopology_topology_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79,
+	0x2f, 0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x1a,
+	0x19, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2f, 0x63, 0x6f,
+	0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x91, 0x01, 0x0a, 0x11, 0x53,
+	0x75, 0x62, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x42, 0x0a, 0x0b, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x63, 0x6f,
+	0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x42, 0x61, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x0b, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x12, 0x1c, 0x0a, 0x09, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x49, 0x64, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x09, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x49, 0x64, 0x73, 0x22, 0x56,
+	0x0a, 0x12, 0x53, 0x75, 0x62, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x0c, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x64, 0x72, 0x69,
+	0x76, 0x65, 0x72, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x0c, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xc4, 0x01, 0x0a, 0x0b, 0x52, 0x70, 0x63, 0x54, 0x6f,
+	0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x12, 0x58, 0x0a, 0x0d, 0x41, 0x64, 0x64, 0x53, 0x75, 0x62,
+	0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x21, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72,
+	0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x2e, 0x53, 0x75, 0x62, 0x44, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x64, 0x72, 0x69,
+	0x76, 0x65, 0x72, 0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x2e, 0x53, 0x75, 0x62, 0x44,
+	0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x12, 0x5b, 0x0a, 0x10, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x53, 0x75, 0x62, 0x44, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x73, 0x12, 0x21, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x74, 0x6f, 0x70,
+	0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x2e, 0x53, 0x75, 0x62, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72,
+	0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x2e, 0x53, 0x75, 0x62, 0x44, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x44, 0x5a,
+	0x42, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x77, 0x69, 0x6e, 0x63,
+	0x2d, 0x6c, 0x69, 0x6e, 0x6b, 0x2f, 0x68, 0x75, 0x6d, 0x6d, 0x69, 0x6e, 0x67, 0x62, 0x69, 0x72,
+	0x64, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x74, 0x6f, 0x70, 0x6f, 0x6c,
+	0x6f, 0x67, 0x79, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_drivertopology_topology_proto_rawDescOnce sync.Once
+	file_drivertopology_topology_proto_rawDescData = file_drivertopology_topology_proto_rawDesc
+)
+
+func file_drivertopology_topology_proto_rawDescGZIP() []byte {
+	file_drivertopology_topology_proto_rawDescOnce.Do(func() {
+		file_drivertopology_topology_proto_rawDescData = protoimpl.X.CompressGZIP(file_drivertopology_topology_proto_rawDescData)
+	})
+	return file_drivertopology_topology_proto_rawDescData
+}
+
+var file_drivertopology_topology_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_drivertopology_topology_proto_goTypes = []interface{}{
+	(*SubDevicesRequest)(nil),               // 0: drivertopology.SubDevicesRequest
+	(*SubDevicesResponse)(nil),              // 1: drivertopology.SubDevicesResponse
+	(*drivercommon.BaseRequestMessage)(nil), // 2: drivercommon.BaseRequestMessage
+	(*drivercommon.CommonResponse)(nil),     // 3: drivercommon.CommonResponse
+}
+var file_drivertopology_topology_proto_depIdxs = []int32{
+	2, // 0: drivertopology.SubDevicesRequest.baseRequest:type_name -> drivercommon.BaseRequestMessage
+	3, // 1: drivertopology.SubDevicesResponse.baseResponse:type_name -> drivercommon.CommonResponse
+	0, // 2: drivertopology.RpcTopology.AddSubDevices:input_type -> drivertopology.SubDevicesRequest
+	0, // 3: drivertopology.RpcTopology.RemoveSubDevices:input_type -> drivertopology.SubDevicesRequest
+	1, // 4: drivertopology.RpcTopology.AddSubDevices:output_type -> drivertopology.SubDevicesResponse
+	1, // 5: drivertopology.RpcTopology.RemoveSubDevices:output_type -> drivertopology.SubDevicesResponse
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_drivertopology_topology_proto_init() }
+func file_drivertopology_topology_proto_init() {
+	if File_drivertopology_topology_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_drivertopology_topology_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubDevicesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_drivertopology_topology_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubDevicesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_drivertopology_topology_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_drivertopology_topology_proto_goTypes,
+		DependencyIndexes: file_drivertopology_topology_proto_depIdxs,
+		MessageInfos:      file_drivertopology_topology_proto_msgTypes,
+	}.Build()
+	File_drivertopology_topology_proto = out.File
+	file_drivertopology_topology_proto_rawDesc = nil
+	file_drivertopology_topology_proto_goTypes = nil
+	file_drivertopology_topology_proto_depIdxs = nil
+}
@@ -33,6 +33,7 @@ func NewJobRunFunc(dic *di.Container) JobRunFunc {
 			ProductName: s.ActionData[0].ProductName,
 			DeviceId:    s.ActionData[0].DeviceId,
 			DeviceName:  s.ActionData[0].DeviceName,
+			GroupId:     s.ActionData[0].GroupId,
 			Code:        s.ActionData[0].Code,
 			DateType:    s.ActionData[0].DateType,
 			Value:       s.ActionData[0].Value,
@@ -1,5 +1,11 @@
 package jobs
 
+import (
+	"time"
+
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
 type (
 	// RuntimeJobStu 所有任务全部使用一个数据结构 通过time type区分任务类型
 	RuntimeJobStu struct {
@@ -10,6 +16,14 @@ type (
 		TimeData    TimeData
 		JobData     JobData
 		Runtimes    int64
+		Policy      Policy
+	}
+
+	// Policy 任务的错过执行策略与并发策略
+	Policy struct {
+		MisfirePolicy     string `json:"misfirePolicy"`     // skip or run_once
+		MisfireThreshold  int64  `json:"misfireThreshold"`  // 允许补执行的最大错过时长(分钟)，0 表示不限制
+		ConcurrencyPolicy string `json:"concurrencyPolicy"` // allow or forbid
 	}
 
 	// TimeData 定时表达式类型
@@ -37,8 +51,17 @@ type (
 		ProductName string `json:"productName"`
 		DeviceId    string `json:"deviceId"`
 		DeviceName  string `json:"deviceName"`
+		GroupId     string `json:"groupId"`
 		Code        string `json:"code"`
 		DateType    string `json:"dateType"`
 		Value       string `json:"value"`
 	}
 )
+
+// ShouldRunMisfire 错过执行 missed 时长后是否补执行一次，MisfireThreshold 为 0 时不限制错过时长
+func (p Policy) ShouldRunMisfire(missed time.Duration) bool {
+	if p.MisfirePolicy != string(constants.JobMisfireRunOnce) {
+		return false
+	}
+	return p.MisfireThreshold <= 0 || missed <= time.Duration(p.MisfireThreshold)*time.Minute
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyShouldRunMisfire(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		missed   time.Duration
+		expected bool
+	}{
+		{"skip", Policy{MisfirePolicy: "skip", MisfireThreshold: 10}, time.Minute, false},
+		{"empty policy", Policy{}, time.Minute, false},
+		{"run once within threshold", Policy{MisfirePolicy: "run_once", MisfireThreshold: 10}, 10 * time.Minute, true},
+		{"run once beyond threshold", Policy{MisfirePolicy: "run_once", MisfireThreshold: 10}, 11 * time.Minute, false},
+		{"run once without threshold", Policy{MisfirePolicy: "run_once"}, 72 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.ShouldRunMisfire(tt.missed))
+		})
+	}
+}
@@ -131,16 +131,22 @@ func (p Parser) Parse(spec string) (*JobSchedule, error) {
 	}
 
 	var (
-		second     = field(fields[0], seconds)
-		minute     = field(fields[1], minutes)
-		hour       = field(fields[2], hours)
-		dayofmonth = field(fields[3], dom)
-		month      = field(fields[4], months)
-		dayofweek  = field(fields[5], dow)
+		second = field(fields[0], seconds)
+		minute = field(fields[1], minutes)
+		hour   = field(fields[2], hours)
+		month  = field(fields[4], months)
 	)
 	if err != nil {
 		return nil, err
 	}
+	dayofmonth, lastDom, err := getDomField(fields[3])
+	if err != nil {
+		return nil, err
+	}
+	dayofweek, nthDow, err := getDowField(fields[5])
+	if err != nil {
+		return nil, err
+	}
 
 	return &JobSchedule{
 		Second:   second,
@@ -149,6 +155,8 @@ func (p Parser) Parse(spec string) (*JobSchedule, error) {
 		Dom:      dayofmonth,
 		Month:    month,
 		Dow:      dayofweek,
+		LastDom:  lastDom,
+		NthDow:   nthDow,
 		Location: loc,
 	}, nil
 }
@@ -216,17 +224,20 @@ func normalizeFields(fields []string, options ParseOption) ([]string, error) {
 }
 
 var standardParser = NewParser(
-	Minute | Hour | Dom | Month | Dow | Descriptor,
+	SecondOptional | Minute | Hour | Dom | Month | Dow | Descriptor,
 )
 
 // ParseStandard returns a new crontab schedule representing the given
 // standardSpec (https://en.wikipedia.org/wiki/Cron). It requires 5 entries
 // representing: minute, hour, day of month, month and day of week, in that
-// order. It returns a descriptive error if the spec is not valid.
+// order, optionally preceded by a seconds entry. It returns a descriptive
+// error if the spec is not valid.
 //
 // It accepts
 //   - Standard crontab specs, e.g. "* * * * ?"
-//   - Descriptors, e.g. "@midnight", "@every 1h30m"
+//   - Specs with seconds, e.g. "*/15 * * * * *"
+//   - Last day of month and nth weekday, e.g. "0 8 L * *", "0 8 * * 5#2", "0 8 * * 5L"
+//   - Descriptors, e.g. "@midnight", "@every 1h30m", "@every 90m 07:00"
 //   - 03:04:05 1,2,3,5,6,7 => 4 3 * * 0-2,4-6
 func ParseStandard(standardSpec string) (*JobSchedule, error) {
 	job, err := standardParser.Parse(standardSpec)
@@ -274,6 +285,85 @@ func getField(field string, r bounds) (uint64, error) {
 	return bits, nil
 }
 
+// getDomField parses the day of month field. Besides the generic ranges it
+// accepts "L" for the last day of the month.
+func getDomField(field string) (uint64, bool, error) {
+	var (
+		bits uint64
+		last bool
+	)
+	ranges := strings.FieldsFunc(field, func(r rune) bool { return r == ',' })
+	for _, expr := range ranges {
+		if strings.EqualFold(expr, "L") {
+			last = true
+			continue
+		}
+		bit, err := getRange(expr, dom)
+		if err != nil {
+			return bits, last, err
+		}
+		bits |= bit
+	}
+	return bits, last, nil
+}
+
+// getDowField parses the day of week field. Besides the generic ranges it
+// accepts "<weekday>#<n>" for the nth weekday of the month (n in 1..5) and
+// "<weekday>L" for the last weekday of the month.
+func getDowField(field string) (uint64, []NthWeekday, error) {
+	var (
+		bits uint64
+		nth  []NthWeekday
+	)
+	ranges := strings.FieldsFunc(field, func(r rune) bool { return r == ',' })
+	for _, expr := range ranges {
+		switch {
+		case strings.Contains(expr, "#"):
+			parts := strings.Split(expr, "#")
+			if len(parts) != 2 {
+				return bits, nth, fmt.Errorf("too many hashes: %s", expr)
+			}
+			day, err := parseWeekday(parts[0], expr)
+			if err != nil {
+				return bits, nth, err
+			}
+			n, err := mustParseInt(parts[1])
+			if err != nil {
+				return bits, nth, err
+			}
+			if n < 1 || n > 5 {
+				return bits, nth, fmt.Errorf("nth weekday (%d) should be between 1 and 5: %s", n, expr)
+			}
+			nth = append(nth, NthWeekday{Weekday: day, Nth: int(n)})
+		case len(expr) > 1 && strings.HasSuffix(strings.ToUpper(expr), "L"):
+			day, err := parseWeekday(expr[:len(expr)-1], expr)
+			if err != nil {
+				return bits, nth, err
+			}
+			nth = append(nth, NthWeekday{Weekday: day, Nth: LastWeekOfMonth})
+		default:
+			bit, err := getRange(expr, dow)
+			if err != nil {
+				return bits, nth, err
+			}
+			bits |= bit
+		}
+	}
+	return bits, nth, nil
+}
+
+// parseWeekday returns the (possibly-named) weekday contained in expr.
+func parseWeekday(expr, field string) (time.Weekday, error) {
+	day, err := parseIntOrName(expr, dow.names)
+	if err != nil {
+		return 0, err
+	}
+	if day > dow.max {
+		return 0, fmt.Errorf("weekday (%d) above maximum (%d): %s", day, dow.max, field)
+	}
+	return time.Weekday(day), nil
+}
+
 // getRange returns the bits indicated by the given expression:
 //   number | number "-" number [ "/" number ]
 // or error parsing range.
@@ -449,15 +539,52 @@ func parseDescriptor(descriptor string, loc *time.Location) (*JobSchedule, error
 
 	}
 
-	/*
-		const every = "@every "
-		if strings.HasPrefix(descriptor, every) {
-			duration, err := time.ParseDuration(descriptor[len(every):])
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse duration %s: %s", descriptor, err)
+	const every = "@every "
+	if strings.HasPrefix(descriptor, every) {
+		return parseEvery(strings.Fields(descriptor[len(every):]), loc)
+	}
+	return nil, fmt.Errorf("unrecognized descriptor: %s", descriptor)
+}
+
+// parseEvery returns an interval schedule for "@every <duration> [anchor]".
+//
+// The anchor is either a time of day ("07:00" or "07:00:30"), in which case
+// the schedule restarts from that time every day, or an RFC3339 timestamp
+// from which the interval is counted. Without anchor the interval is counted
+// from the Unix epoch, so "@every 15s" fires at :00, :15, :30 and :45.
+func parseEvery(args []string, loc *time.Location) (*JobSchedule, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, fmt.Errorf("expected @every <duration> [anchor], found: %s", strings.Join(args, " "))
+	}
+	duration, err := time.ParseDuration(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse duration %s: %s", args[0], err)
+	}
+	if duration < time.Second || duration%time.Second != 0 {
+		return nil, fmt.Errorf("duration should be a positive number of seconds: %s", args[0])
+	}
+	schedule := &JobSchedule{
+		Every:    duration,
+		Anchor:   time.Unix(0, 0),
+		Location: loc,
+	}
+	if len(args) == 1 {
+		return schedule, nil
+	}
+
+	anchor := args[1]
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, anchor, loc); err == nil {
+			if duration > 24*time.Hour {
+				return nil, fmt.Errorf("duration with time of day anchor should not exceed 24h: %s", args[0])
 			}
-			return Every(duration), nil
+			schedule.Anchor = t
+			schedule.DailyAnchor = true
+			return schedule, nil
 		}
-	*/
-	return nil, fmt.Errorf("unrecognized descriptor: %s", descriptor)
+	}
+	if schedule.Anchor, err = time.Parse(time.RFC3339, anchor); err != nil {
+		return nil, fmt.Errorf("failed to parse anchor %s: %s", anchor, err)
+	}
+	return schedule, nil
 }
@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStandard(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected *JobSchedule
+	}{
+		{
+			name: "five fields",
+			spec: "30 8 * * 1-5",
+			expected: &JobSchedule{
+				Second: 1 << 0,
+				Minute: 1 << 30,
+				Hour:   1 << 8,
+				Dom:    all(dom),
+				Month:  all(months),
+				Dow:    getBits(1, 5, 1),
+			},
+		},
+		{
+			name: "six fields with seconds",
+			spec: "*/15 * * * * *",
+			expected: &JobSchedule{
+				Second: getBits(0, 59, 15),
+				Minute: all(minutes),
+				Hour:   all(hours),
+				Dom:    all(dom),
+				Month:  all(months),
+				Dow:    all(dow),
+			},
+		},
+		{
+			name: "last day of month",
+			spec: "0 8 L * *",
+			expected: &JobSchedule{
+				Second:  1 << 0,
+				Minute:  1 << 0,
+				Hour:    1 << 8,
+				LastDom: true,
+				Month:   all(months),
+				Dow:     all(dow),
+			},
+		},
+		{
+			name: "last day of month combined with day",
+			spec: "0 8 15,L * *",
+			expected: &JobSchedule{
+				Second:  1 << 0,
+				Minute:  1 << 0,
+				Hour:    1 << 8,
+				Dom:     1 << 15,
+				LastDom: true,
+				Month:   all(months),
+				Dow:     all(dow),
+			},
+		},
+		{
+			name: "nth weekday",
+			spec: "0 8 * * 5#2",
+			expected: &JobSchedule{
+				Second: 1 << 0,
+				Minute: 1 << 0,
+				Hour:   1 << 8,
+				Dom:    all(dom),
+				Month:  all(months),
+				NthDow: []NthWeekday{{Weekday: time.Friday, Nth: 2}},
+			},
+		},
+		{
+			name: "named nth weekday and last weekday",
+			spec: "0 8 * * mon#1,friL",
+			expected: &JobSchedule{
+				Second: 1 << 0,
+				Minute: 1 << 0,
+				Hour:   1 << 8,
+				Dom:    all(dom),
+				Month:  all(months),
+				NthDow: []NthWeekday{{Weekday: time.Monday, Nth: 1}, {Weekday: time.Friday, Nth: LastWeekOfMonth}},
+			},
+		},
+		{
+			name:     "every without anchor",
+			spec:     "@every 15s",
+			expected: &JobSchedule{Every: 15 * time.Second, Anchor: time.Unix(0, 0)},
+		},
+		{
+			name: "every with time of day anchor",
+			spec: "@every 90m 07:00",
+			expected: &JobSchedule{
+				Every:       90 * time.Minute,
+				Anchor:      time.Date(0, time.January, 1, 7, 0, 0, 0, time.Local),
+				DailyAnchor: true,
+			},
+		},
+		{
+			name: "every with timestamp anchor",
+			spec: "@every 2h 2023-05-01T06:30:00Z",
+			expected: &JobSchedule{
+				Every:  2 * time.Hour,
+				Anchor: time.Date(2023, time.May, 1, 6, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseStandard(tt.spec)
+			require.NoError(t, err)
+			tt.expected.Location = time.Local
+			assert.Equal(t, tt.expected.Second, actual.Second)
+			assert.Equal(t, tt.expected.Minute, actual.Minute)
+			assert.Equal(t, tt.expected.Hour, actual.Hour)
+			assert.Equal(t, tt.expected.Dom, actual.Dom)
+			assert.Equal(t, tt.expected.Month, actual.Month)
+			assert.Equal(t, tt.expected.Dow, actual.Dow)
+			assert.Equal(t, tt.expected.LastDom, actual.LastDom)
+			assert.Equal(t, tt.expected.NthDow, actual.NthDow)
+			assert.Equal(t, tt.expected.Every, actual.Every)
+			assert.True(t, tt.expected.Anchor.Equal(actual.Anchor), "anchor %v != %v", tt.expected.Anchor, actual.Anchor)
+			assert.Equal(t, tt.expected.DailyAnchor, actual.DailyAnchor)
+		})
+	}
+}
+
+func TestParseStandardErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"empty", ""},
+		{"too few fields", "* * * *"},
+		{"too many fields", "* * * * * * *"},
+		{"second out of range", "60 * * * * *"},
+		{"nth weekday out of range", "0 8 * * 5#6"},
+		{"nth weekday zero", "0 8 * * 5#0"},
+		{"nth weekday too many hashes", "0 8 * * 5#1#2"},
+		{"weekday out of range", "0 8 * * 7#1"},
+		{"last weekday unknown name", "0 8 * * xyzL"},
+		{"last day of month in dow", "0 8 * * L"},
+		{"every without duration", "@every"},
+		{"every with bad duration", "@every soon"},
+		{"every below one second", "@every 500ms"},
+		{"every with fractional seconds", "@every 1500ms"},
+		{"every with bad anchor", "@every 1h tomorrow"},
+		{"every too long for daily anchor", "@every 25h 07:00"},
+		{"every with extra arguments", "@every 1h 07:00 08:00"},
+		{"unknown descriptor", "@fortnightly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStandard(tt.spec)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -9,11 +9,48 @@ import (
 type JobSchedule struct {
 	Second, Minute, Hour, Dom, Month, Dow uint64
 
+	// LastDom matches the last day of the month ("L" in the day of month field).
+	LastDom bool
+	// NthDow matches the nth or last weekday of the month ("5#2", "5L").
+	NthDow []NthWeekday
+
+	// Every is the interval of an "@every" schedule, counted from Anchor. When
+	// DailyAnchor is set only the clock of Anchor is used and the interval
+	// restarts from it every day.
+	Every       time.Duration
+	Anchor      time.Time
+	DailyAnchor bool
+
 	*RuntimeJobStu
 	// Override location for this schedule.
 	Location *time.Location
 }
 
+// LastWeekOfMonth is the NthWeekday.Nth value of the last weekday of the month.
+const LastWeekOfMonth = -1
+
+// NthWeekday is the nth weekday of the month, Nth is 1..5 or LastWeekOfMonth.
+type NthWeekday struct {
+	Weekday time.Weekday
+	Nth     int
+}
+
+// matches reports whether t is the nth weekday of its month.
+func (n NthWeekday) matches(t time.Time) bool {
+	if t.Weekday() != n.Weekday {
+		return false
+	}
+	if n.Nth == LastWeekOfMonth {
+		return t.Day()+7 > daysIn(t)
+	}
+	return (t.Day()-1)/7+1 == n.Nth
+}
+
+// daysIn returns the number of days in the month of t.
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
 // bounds provides a range of acceptable values (plus a map of name to value).
 type bounds struct {
 	min, max uint
@@ -73,9 +110,40 @@ func (s *JobSchedule) Next(t time.Time) (time.Time, bool) {
 	//default:
 	//	return time.Time{}, true
 	//}
+	if s.Every > 0 {
+		return s.everyNext(t), false
+	}
 	return s.cronNext(t)
 }
 
+// everyNext returns the next activation of an "@every" schedule after t.
+func (s *JobSchedule) everyNext(t time.Time) time.Time {
+	origLocation := t.Location()
+	if !s.DailyAnchor {
+		if t.Before(s.Anchor) {
+			return s.Anchor.In(origLocation)
+		}
+		n := t.Sub(s.Anchor)/s.Every + 1
+		return s.Anchor.Add(n * s.Every).In(origLocation)
+	}
+
+	loc := s.Location
+	if loc == time.Local {
+		loc = origLocation
+	}
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), s.Anchor.Hour(), s.Anchor.Minute(), s.Anchor.Second(), 0, loc)
+	if t.Before(start) {
+		return start.In(origLocation)
+	}
+	next := start.Add((t.Sub(start)/s.Every + 1) * s.Every)
+	if next.Before(time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)) {
+		return next.In(origLocation)
+	}
+	// the interval restarts from the anchor the next day
+	return time.Date(t.Year(), t.Month(), t.Day()+1, s.Anchor.Hour(), s.Anchor.Minute(), s.Anchor.Second(), 0, loc).In(origLocation)
+}
+
 func (s *JobSchedule) cronNext(t time.Time) (time.Time, bool) {
 	// General approach
 	//
@@ -265,9 +333,15 @@ func (s *JobSchedule) ScheduleAdd1() int64 {
 // restrictions are satisfied by the given time.
 func dayMatches(s *JobSchedule, t time.Time) bool {
 	var (
-		domMatch bool = 1<<uint(t.Day())&s.Dom > 0
+		domMatch bool = 1<<uint(t.Day())&s.Dom > 0 || s.LastDom && t.Day() == daysIn(t)
 		dowMatch bool = 1<<uint(t.Weekday())&s.Dow > 0
 	)
+	for _, nth := range s.NthDow {
+		if dowMatch {
+			break
+		}
+		dowMatch = nth.matches(t)
+	}
 	if s.Dom&starBit > 0 || s.Dow&starBit > 0 {
 		return domMatch && dowMatch
 	}
@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobScheduleNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		from     string
+		expected []string
+	}{
+		{
+			name:     "minute cron",
+			spec:     "30 8 * * *",
+			from:     "2023-05-01T08:30:00Z",
+			expected: []string{"2023-05-02T08:30:00Z", "2023-05-03T08:30:00Z"},
+		},
+		{
+			name:     "every 15 seconds with seconds field",
+			spec:     "*/15 * * * * *",
+			from:     "2023-05-01T08:00:07Z",
+			expected: []string{"2023-05-01T08:00:15Z", "2023-05-01T08:00:30Z", "2023-05-01T08:00:45Z", "2023-05-01T08:01:00Z"},
+		},
+		{
+			name:     "seconds field with fixed minute",
+			spec:     "10 0 12 * * *",
+			from:     "2023-05-01T12:00:10Z",
+			expected: []string{"2023-05-02T12:00:10Z"},
+		},
+		{
+			name:     "last day of month",
+			spec:     "0 8 L * *",
+			from:     "2023-01-15T00:00:00Z",
+			expected: []string{"2023-01-31T08:00:00Z", "2023-02-28T08:00:00Z", "2023-03-31T08:00:00Z", "2023-04-30T08:00:00Z"},
+		},
+		{
+			name:     "last day of month in leap year",
+			spec:     "0 8 L * *",
+			from:     "2024-02-01T00:00:00Z",
+			expected: []string{"2024-02-29T08:00:00Z"},
+		},
+		{
+			name:     "last day of month or fifteenth",
+			spec:     "0 8 15,L * *",
+			from:     "2023-04-01T00:00:00Z",
+			expected: []string{"2023-04-15T08:00:00Z", "2023-04-30T08:00:00Z", "2023-05-15T08:00:00Z"},
+		},
+		{
+			name:     "second friday",
+			spec:     "0 8 * * 5#2",
+			from:     "2023-05-01T00:00:00Z",
+			expected: []string{"2023-05-12T08:00:00Z", "2023-06-09T08:00:00Z", "2023-07-14T08:00:00Z"},
+		},
+		{
+			name:     "fifth monday skips months without one",
+			spec:     "0 8 * * 1#5",
+			from:     "2023-05-01T00:00:00Z",
+			expected: []string{"2023-05-29T08:00:00Z", "2023-07-31T08:00:00Z", "2023-10-30T08:00:00Z"},
+		},
+		{
+			name:     "last friday",
+			spec:     "0 18 * * 5L",
+			from:     "2023-05-01T00:00:00Z",
+			expected: []string{"2023-05-26T18:00:00Z", "2023-06-30T18:00:00Z", "2023-07-28T18:00:00Z"},
+		},
+		{
+			name:     "first monday or any sunday",
+			spec:     "0 9 * * 0,1#1",
+			from:     "2023-05-01T10:00:00Z",
+			expected: []string{"2023-05-07T09:00:00Z", "2023-05-14T09:00:00Z", "2023-05-21T09:00:00Z", "2023-05-28T09:00:00Z", "2023-06-04T09:00:00Z", "2023-06-05T09:00:00Z"},
+		},
+		{
+			name:     "every 15 seconds",
+			spec:     "@every 15s",
+			from:     "2023-05-01T08:00:07Z",
+			expected: []string{"2023-05-01T08:00:15Z", "2023-05-01T08:00:30Z", "2023-05-01T08:00:45Z", "2023-05-01T08:01:00Z"},
+		},
+		{
+			name:     "every 15 seconds on the boundary",
+			spec:     "@every 15s",
+			from:     "2023-05-01T08:00:15Z",
+			expected: []string{"2023-05-01T08:00:30Z"},
+		},
+		{
+			name:     "every 90 minutes starting at 07:00",
+			spec:     "@every 90m 07:00",
+			from:     "2023-05-01T00:00:00Z",
+			expected: []string{"2023-05-01T07:00:00Z", "2023-05-01T08:30:00Z", "2023-05-01T10:00:00Z"},
+		},
+		{
+			name:     "every 5 hours restarts at anchor next day",
+			spec:     "@every 5h 07:00",
+			from:     "2023-05-01T21:00:00Z",
+			expected: []string{"2023-05-01T22:00:00Z", "2023-05-02T07:00:00Z", "2023-05-02T12:00:00Z"},
+		},
+		{
+			name:     "every with anchor in the future",
+			spec:     "@every 2h 2023-05-01T06:30:00Z",
+			from:     "2023-04-30T00:00:00Z",
+			expected: []string{"2023-05-01T06:30:00Z", "2023-05-01T08:30:00Z"},
+		},
+		{
+			name:     "every with anchor in the past",
+			spec:     "@every 2h 2023-05-01T06:30:00Z",
+			from:     "2023-05-03T07:00:00Z",
+			expected: []string{"2023-05-03T08:30:00Z", "2023-05-03T10:30:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := NewParser(SecondOptional | Minute | Hour | Dom | Month | Dow | Descriptor).Parse("TZ=UTC " + tt.spec)
+			require.NoError(t, err)
+			from, err := time.Parse(time.RFC3339, tt.from)
+			require.NoError(t, err)
+
+			next := from
+			for _, expected := range tt.expected {
+				var end bool
+				next, end = schedule.Next(next)
+				require.False(t, end)
+				assert.Equal(t, expected, next.UTC().Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestNthWeekdayMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		nth      NthWeekday
+		date     string
+		expected bool
+	}{
+		{"first monday", NthWeekday{time.Monday, 1}, "2023-05-01", true},
+		{"second monday", NthWeekday{time.Monday, 2}, "2023-05-08", true},
+		{"not second monday", NthWeekday{time.Monday, 2}, "2023-05-01", false},
+		{"wrong weekday", NthWeekday{time.Tuesday, 1}, "2023-05-01", false},
+		{"last wednesday", NthWeekday{time.Wednesday, LastWeekOfMonth}, "2023-05-31", true},
+		{"not last wednesday", NthWeekday{time.Wednesday, LastWeekOfMonth}, "2023-05-24", false},
+		{"last sunday of february", NthWeekday{time.Sunday, LastWeekOfMonth}, "2023-02-26", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, err := time.Parse("2006-01-02", tt.date)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tt.nth.matches(date))
+		})
+	}
+}
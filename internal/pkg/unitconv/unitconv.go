@@ -0,0 +1,202 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+// Package unitconv 物模型单位换算，单位使用单位库(unit 表)中的符号
+package unitconv
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrUnknownUnit  = errors.New("unknown unit")
+	ErrIncompatible = errors.New("units are not compatible")
+)
+
+// unit 单位换算到所属物理量基准单位：base = value*factor + offset
+type unit struct {
+	quantity string
+	factor   float64
+	offset   float64
+}
+
+// aliases 常见的其他写法
+var aliases = map[string]string{
+	"°F":  "℉",
+	"℃":   "°C",
+	"s":   "″",
+	"m2":  "㎡",
+	"m3":  "m³",
+	"kWh": "kW·h",
+}
+
+var units = map[string]unit{
+	// 温度，基准 °C
+	"°C": {"temperature", 1, 0},
+	"℉":  {"temperature", 5.0 / 9, -32 * 5.0 / 9},
+	"K":  {"temperature", 1, -273.15},
+	// 长度，基准 m
+	"nm": {"length", 1e-9, 0},
+	"μm": {"length", 1e-6, 0},
+	"mm": {"length", 1e-3, 0},
+	"cm": {"length", 1e-2, 0},
+	"dm": {"length", 1e-1, 0},
+	"m":  {"length", 1, 0},
+	"km": {"length", 1e3, 0},
+	// 面积，基准 ㎡
+	"m㎡": {"area", 1e-6, 0},
+	"c㎡": {"area", 1e-4, 0},
+	"㎡":  {"area", 1, 0},
+	"h㎡": {"area", 1e4, 0},
+	"k㎡": {"area", 1e6, 0},
+	// 体积，基准 m³
+	"mm³": {"volume", 1e-9, 0},
+	"cm³": {"volume", 1e-6, 0},
+	"mL":  {"volume", 1e-6, 0},
+	"L":   {"volume", 1e-3, 0},
+	"m³":  {"volume", 1, 0},
+	"km³": {"volume", 1e9, 0},
+	// 质量，基准 kg
+	"mg": {"mass", 1e-6, 0},
+	"g":  {"mass", 1e-3, 0},
+	"kg": {"mass", 1, 0},
+	"t":  {"mass", 1e3, 0},
+	// 时间，基准秒
+	"ms":   {"time", 1e-3, 0},
+	"″":    {"time", 1, 0},
+	"min":  {"time", 60, 0},
+	"h":    {"time", 3600, 0},
+	"day":  {"time", 86400, 0},
+	"week": {"time", 604800, 0},
+	// 速度，基准 m/s
+	"mm/s": {"speed", 1e-3, 0},
+	"m/s":  {"speed", 1, 0},
+	"km/h": {"speed", 1 / 3.6, 0},
+	"kn":   {"speed", 1852.0 / 3600, 0},
+	// 压强，基准 Pa
+	"mPa":  {"pressure", 1e-3, 0},
+	"Pa":   {"pressure", 1, 0},
+	"hPa":  {"pressure", 1e2, 0},
+	"kPa":  {"pressure", 1e3, 0},
+	"Mpa":  {"pressure", 1e6, 0},
+	"bar":  {"pressure", 1e5, 0},
+	"mmHg": {"pressure", 133.322, 0},
+	// 能量，基准 J
+	"J":    {"energy", 1, 0},
+	"kJ":   {"energy", 1e3, 0},
+	"Wh":   {"energy", 3600, 0},
+	"kW·h": {"energy", 3.6e6, 0},
+	"cal":  {"energy", 4.184, 0},
+	"kcal": {"energy", 4184, 0},
+	// 功率，基准 W
+	"μW": {"power", 1e-6, 0},
+	"mW": {"power", 1e-3, 0},
+	"W":  {"power", 1, 0},
+	"kW": {"power", 1e3, 0},
+	// 电压，基准 V
+	"mV": {"voltage", 1e-3, 0},
+	"V":  {"voltage", 1, 0},
+	"kV": {"voltage", 1e3, 0},
+	// 电流，基准 A
+	"μA": {"current", 1e-6, 0},
+	"mA": {"current", 1e-3, 0},
+	"A":  {"current", 1, 0},
+	"kA": {"current", 1e3, 0},
+	// 电容，基准 F
+	"pF": {"capacitance", 1e-12, 0},
+	"nF": {"capacitance", 1e-9, 0},
+	"μF": {"capacitance", 1e-6, 0},
+	"F":  {"capacitance", 1, 0},
+	// 数据量，基准字节
+	"bit": {"data", 1.0 / 8, 0},
+	"B":   {"data", 1, 0},
+	"KB":  {"data", 1 << 10, 0},
+	"MB":  {"data", 1 << 20, 0},
+	"GB":  {"data", 1 << 30, 0},
+	// 流量，基准 m³/s
+	"L/s":  {"flow", 1e-3, 0},
+	"m³/h": {"flow", 1.0 / 3600, 0},
+	"m³/s": {"flow", 1, 0},
+	// 质量浓度，基准 kg/m³
+	"μg/m³": {"concentration", 1e-9, 0},
+	"mg/m³": {"concentration", 1e-6, 0},
+	"g/m³":  {"concentration", 1e-3, 0},
+	"kg/m³": {"concentration", 1, 0},
+	"μg/L":  {"concentration", 1e-6, 0},
+	"mg/L":  {"concentration", 1e-3, 0},
+	"g/L":   {"concentration", 1, 0},
+	"g/mL":  {"concentration", 1e3, 0},
+	// 角度，基准弧度
+	"rad": {"angle", 1, 0},
+	"°":   {"angle", math.Pi / 180, 0},
+	"′":   {"angle", math.Pi / 10800, 0},
+}
+
+func lookup(symbol string) (unit, bool) {
+	if alias, ok := aliases[symbol]; ok {
+		symbol = alias
+	}
+	u, ok := units[symbol]
+	return u, ok
+}
+
+// Known 单位是否在换算表中
+func Known(symbol string) bool {
+	_, ok := lookup(symbol)
+	return ok
+}
+
+// Convertible 两个单位是否属于同一物理量，可以互相换算
+func Convertible(from, to string) bool {
+	f, ok := lookup(from)
+	if !ok {
+		return false
+	}
+	t, ok := lookup(to)
+	return ok && f.quantity == t.quantity
+}
+
+// Affine 两个单位的换算是否带偏移量(如 °C 与 °F)，带偏移量时多个值的和不能直接换算
+func Affine(from, to string) bool {
+	if from == to {
+		return false
+	}
+	f, ok := lookup(from)
+	if !ok {
+		return false
+	}
+	t, ok := lookup(to)
+	return ok && f.offset != t.offset
+}
+
+// Convert 将 from 单位的值换算为 to 单位
+func Convert(value float64, from, to string) (float64, error) {
+	if from == to {
+		return value, nil
+	}
+	f, ok := lookup(from)
+	if !ok {
+		return 0, ErrUnknownUnit
+	}
+	t, ok := lookup(to)
+	if !ok {
+		return 0, ErrUnknownUnit
+	}
+	if f.quantity != t.quantity {
+		return 0, ErrIncompatible
+	}
+	base := value*f.factor + f.offset
+	return (base - t.offset) / t.factor, nil
+}
@@ -0,0 +1,48 @@
+package unitconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		value    float64
+		from, to string
+		expect   float64
+	}{
+		{100, "°C", "°F", 212},
+		{32, "℉", "°C", 0},
+		{0, "°C", "K", 273.15},
+		{1500, "mm", "m", 1.5},
+		{2, "kW·h", "J", 7.2e6},
+		{36, "km/h", "m/s", 10},
+		{1, "bar", "kPa", 100},
+		{180, "°", "rad", 3.141592653589793},
+		{5, "V", "V", 5},
+	}
+	for _, c := range cases {
+		v, err := Convert(c.value, c.from, c.to)
+		require.NoError(t, err)
+		assert.InDelta(t, c.expect, v, 1e-9, "%v %s -> %s", c.value, c.from, c.to)
+	}
+}
+
+func TestConvertError(t *testing.T) {
+	_, err := Convert(1, "m", "kg")
+	assert.ErrorIs(t, err, ErrIncompatible)
+	_, err = Convert(1, "m", "furlong")
+	assert.ErrorIs(t, err, ErrUnknownUnit)
+	assert.True(t, Convertible("°C", "°F"))
+	assert.False(t, Convertible("F", "°F"))
+}
+
+func TestAffine(t *testing.T) {
+	assert.True(t, Affine("°C", "°F"))
+	assert.True(t, Affine("K", "℃"))
+	assert.False(t, Affine("°C", "°C"))
+	assert.False(t, Affine("mm", "m"))
+	assert.False(t, Affine("m", "kg"))
+}
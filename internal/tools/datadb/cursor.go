@@ -0,0 +1,182 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package datadb
+
+import (
+	"math"
+	"sort"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+// HistoryCursor 历史数据的读取位置，可以序列化保存，继续读取时恢复
+type HistoryCursor struct {
+	// Time 下一个窗口的起始时间，大于结束时间时已读完
+	Time int64 `json:"time"`
+	// Span 下一个窗口的时长，毫秒
+	Span int64 `json:"span"`
+}
+
+// HistoryRecord 一条历史数据，Value 为属性值、dtos.EventData 或 dtos.SaveServiceIssueData
+type HistoryRecord struct {
+	Time  int64
+	Value interface{}
+}
+
+// HistoryReader 按时间从早到晚读取一个标识符在 [start, end] 内的历史数据。
+// 时序库只支持按时间倒序分页，且每次查询都会统计整个时间范围内的条数，按页号读取全部数据时总耗时与条数的平方成正比。
+// HistoryReader 每次查询一个 [Time, Time+Span) 的时间窗口，窗口内的数据一次读完后移动到下一个窗口，
+// 窗口时长按上一次读到的条数调整，每个窗口约一页数据，每次查询只涉及窗口内的数据
+type HistoryReader struct {
+	client   interfaces.DataDBClient
+	device   models.Device
+	product  models.Product
+	kind     string
+	code     string
+	end      int64
+	pageSize int
+	Cursor   HistoryCursor
+}
+
+// NewHistoryReader kind 为 constants.Property、constants.Event 或 constants.Action
+func NewHistoryReader(client interfaces.DataDBClient, device models.Device, product models.Product, kind, code string, start, end int64, pageSize int) *HistoryReader {
+	if start > end {
+		start, end = end, start
+	}
+	// 各时序库查询的结束时间有的包含有的不包含，窗口的结束时间需要能够加一
+	if end == math.MaxInt64 {
+		end--
+	}
+	return &HistoryReader{
+		client:   client,
+		device:   device,
+		product:  product,
+		kind:     kind,
+		code:     code,
+		end:      end,
+		pageSize: pageSize,
+		Cursor:   HistoryCursor{Time: start, Span: end - start + 1},
+	}
+}
+
+// Done 是否已读完
+func (r *HistoryReader) Done() bool {
+	return r.Cursor.Time > r.end
+}
+
+// Next 返回下一个有数据的窗口内的全部数据，按时间正序，读完时返回空。
+// 窗口内的数据超过两页时缩短窗口重新查询，同一毫秒的数据超过两页时一次读取
+func (r *HistoryReader) Next() ([]HistoryRecord, error) {
+	for !r.Done() {
+		limit := r.end + 1
+		if r.Cursor.Span < limit-r.Cursor.Time {
+			limit = r.Cursor.Time + r.Cursor.Span
+		}
+		maxCount := 2 * r.pageSize
+		records, count, err := r.read(r.Cursor.Time, limit, maxCount)
+		if err != nil {
+			return nil, err
+		}
+		if count > maxCount {
+			if r.Cursor.Span > 1 {
+				r.Cursor.Span = int64(float64(r.Cursor.Span) * float64(r.pageSize) / float64(count))
+				if r.Cursor.Span < 1 {
+					r.Cursor.Span = 1
+				}
+				continue
+			}
+			if records, _, err = r.read(r.Cursor.Time, limit, count); err != nil {
+				return nil, err
+			}
+		}
+
+		// 查询包含结束时间的时序库会多返回 limit 时刻的数据，由下一个窗口读取
+		window := records[:0]
+		for _, record := range records {
+			if record.Time >= r.Cursor.Time && record.Time < limit {
+				window = append(window, record)
+			}
+		}
+		sort.SliceStable(window, func(i, j int) bool {
+			return window[i].Time < window[j].Time
+		})
+		r.Cursor.Time = limit
+		if count < r.pageSize/2 && r.Cursor.Span < math.MaxInt64/2 {
+			r.Cursor.Span *= 2
+		}
+		if len(window) > 0 {
+			return window, nil
+		}
+	}
+	return nil, nil
+}
+
+// read 查询 [start, limit] 内最新的 pageSize 条数据，按时间倒序，并返回范围内的总条数。属性值为空的数据不返回
+func (r *HistoryReader) read(start, limit int64, pageSize int) ([]HistoryRecord, int, error) {
+	timeRange := []int64{start, limit}
+	var records []HistoryRecord
+	switch r.kind {
+	case constants.Property:
+		req := dtos.ThingModelPropertyDataRequest{DeviceId: r.device.Id, Code: r.code}
+		req.Range = timeRange
+		req.Page, req.PageSize = 1, pageSize
+		data, count, err := GetDeviceProperty(r.client, req, r.device, r.property())
+		if err != nil {
+			return nil, count, err
+		}
+		for _, d := range data {
+			if d.Value != nil {
+				records = append(records, HistoryRecord{Time: d.Time, Value: d.Value})
+			}
+		}
+		return records, count, nil
+	case constants.Event:
+		req := dtos.ThingModelEventDataRequest{DeviceId: r.device.Id, EventCode: r.code}
+		req.Range = timeRange
+		req.Page, req.PageSize = 1, pageSize
+		data, count, err := r.client.GetDeviceEvent(req, r.device, r.product)
+		if err != nil {
+			return nil, count, err
+		}
+		for _, d := range data {
+			records = append(records, HistoryRecord{Time: d.EventTime, Value: d})
+		}
+		return records, count, nil
+	default:
+		req := dtos.ThingModelServiceDataRequest{DeviceId: r.device.Id, Code: r.code}
+		req.Range = timeRange
+		req.Page, req.PageSize = 1, pageSize
+		data, count, err := r.client.GetDeviceService(req, r.device, r.product)
+		if err != nil {
+			return nil, count, err
+		}
+		for _, d := range data {
+			records = append(records, HistoryRecord{Time: d.Time, Value: d})
+		}
+		return records, count, nil
+	}
+}
+
+func (r *HistoryReader) property() models.Properties {
+	for _, property := range r.product.Properties {
+		if property.Code == r.code {
+			return property
+		}
+	}
+	return models.Properties{Code: r.code}
+}
@@ -0,0 +1,130 @@
+package datadb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/leveldb"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/sqlite/sqlitetest"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/tstorage"
+)
+
+var (
+	cursorProduct = models.Product{
+		Id:         "product1",
+		Properties: []models.Properties{{Code: "temperature", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat}}},
+		Events:     []models.Events{{Code: "alarm"}},
+	}
+	cursorDevice = models.Device{Id: "device1", ProductId: "product1"}
+)
+
+func readAll(t *testing.T, r *HistoryReader) []int64 {
+	var times []int64
+	for !r.Done() {
+		records, err := r.Next()
+		require.NoError(t, err)
+		for _, record := range records {
+			times = append(times, record.Time)
+		}
+	}
+	return times
+}
+
+func TestHistoryReader(t *testing.T) {
+	lc := logger.NewMockClient()
+	clients := map[string]func() interfaces.DataDBClient{
+		"leveldb": func() interfaces.DataDBClient {
+			c, err := leveldb.NewClient(dtos.Configuration{DataSource: t.TempDir()}, lc)
+			require.NoError(t, err)
+			t.Cleanup(c.CloseSession)
+			return c
+		},
+		"tstorage": func() interfaces.DataDBClient {
+			c, err := tstorage.NewClient(dtos.Configuration{DataSource: t.TempDir() + "/data"}, lc)
+			require.NoError(t, err)
+			t.Cleanup(c.CloseSession)
+			return c
+		},
+		"sqlite": func() interfaces.DataDBClient {
+			return sqlitetest.NewClientWithDevices(t, cursorProduct, cursorDevice)
+		},
+	}
+	// 前 40 秒每秒一条，间隔较长后再有 5 条，事件在最后一条属性的时间
+	table := constants.DB_PREFIX + cursorDevice.Id
+	var records []dtos.DataRecord
+	var expected []int64
+	for i := int64(1); i <= 45; i++ {
+		ts := i * 1000
+		if i > 40 {
+			ts += 10000000
+		}
+		records = append(records, dtos.DataRecord{Table: table, Time: ts, Values: map[string]interface{}{"temperature": float64(i)}})
+		expected = append(expected, ts)
+	}
+	records = append(records, dtos.DataRecord{Table: table, Time: expected[44], Events: map[string]dtos.EventData{
+		"alarm": {EventCode: "alarm", EventTime: expected[44]},
+	}})
+
+	for name, newClient := range clients {
+		t.Run(name, func(t *testing.T) {
+			client := newClient()
+			require.NoError(t, client.BatchInsert(context.Background(), records))
+
+			r := NewHistoryReader(client, cursorDevice, cursorProduct, constants.Property, "temperature", 0, expected[44], 4)
+			first, err := r.Next()
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(first), 8)
+			assert.Equal(t, int64(1000), first[0].Time)
+			assert.Equal(t, 1.0, first[0].Value)
+
+			// 从保存的位置继续读取
+			resumed := NewHistoryReader(client, cursorDevice, cursorProduct, constants.Property, "temperature", 0, expected[44], 4)
+			resumed.Cursor = r.Cursor
+			var times []int64
+			for _, record := range first {
+				times = append(times, record.Time)
+			}
+			times = append(times, readAll(t, resumed)...)
+			assert.ElementsMatch(t, expected, times)
+			assert.IsIncreasing(t, times)
+
+			r = NewHistoryReader(client, cursorDevice, cursorProduct, constants.Property, "temperature", 3000, 5000, 4)
+			assert.Equal(t, []int64{3000, 4000, 5000}, readAll(t, r))
+
+			r = NewHistoryReader(client, cursorDevice, cursorProduct, constants.Event, "alarm", 0, expected[44], 4)
+			events, err := r.Next()
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, "alarm", events[0].Value.(dtos.EventData).EventCode)
+			assert.True(t, r.Done())
+		})
+	}
+}
+
+func TestHistoryReaderSameMillisecond(t *testing.T) {
+	client := sqlitetest.NewClientWithDevices(t, cursorProduct, cursorDevice)
+	table := constants.DB_PREFIX + cursorDevice.Id
+	var records []dtos.DataRecord
+	for i := 0; i < 20; i++ {
+		records = append(records, dtos.DataRecord{Table: table, Time: 2000, Values: map[string]interface{}{"temperature": float64(i)}})
+	}
+	records = append(records,
+		dtos.DataRecord{Table: table, Time: 1000, Values: map[string]interface{}{"temperature": 1.0}},
+		dtos.DataRecord{Table: table, Time: 3000, Values: map[string]interface{}{"temperature": 3.0}},
+	)
+	require.NoError(t, client.BatchInsert(context.Background(), records))
+
+	// 同一毫秒的数据超过两页时一次读完，不会丢失或重复
+	r := NewHistoryReader(client, cursorDevice, cursorProduct, constants.Property, "temperature", 0, 5000, 2)
+	times := readAll(t, r)
+	require.Len(t, times, 22)
+	assert.Equal(t, int64(1000), times[0])
+	assert.Equal(t, int64(3000), times[21])
+}
@@ -0,0 +1,91 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package datadb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/config"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/leveldb"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/sqlite"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/tdengine"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/tstorage"
+)
+
+// NewClient 按配置的类型创建时序库客户端，retentionDays 为 tstorage 打开时设置的保存天数
+func NewClient(info config.Database, retentionDays int, lc logger.LoggingClient) (interfaces.DataDBClient, error) {
+	switch info.Type {
+	case string(constants.LevelDB):
+		return leveldb.NewClient(dtos.Configuration{
+			DataSource: info.DataSource,
+		}, lc)
+	case string(constants.Tstorage):
+		return tstorage.NewClient(dtos.Configuration{
+			DataSource:    info.DataSource,
+			RetentionDays: retentionDays,
+		}, lc)
+	case string(constants.TDengine):
+		return tdengine.NewClient(dtos.Configuration{
+			Dsn: info.Dsn,
+		}, lc)
+	case string(constants.SQLiteDB):
+		// 可以和元数据使用同一个数据库文件
+		return sqlite.NewClient(dtos.Configuration{
+			DataSource: info.DataSource,
+		}, lc)
+	default:
+		return nil, fmt.Errorf("unsupported data database type %q", info.Type)
+	}
+}
+
+// GetDeviceProperty 查询属性数据，结构体和数组属性按存储方式还原为原始结构：
+// tdengine 和 sqlite 以 json 字符串存储，tstorage 按子字段拆分存储
+func GetDeviceProperty(client interfaces.DataDBClient, req dtos.ThingModelPropertyDataRequest, device models.Device, property models.Properties) ([]dtos.ReportData, int, error) {
+	nested := property.TypeSpec.Type.IsNested()
+	dbType := client.GetDataDBType()
+	if nested && dbType == constants.Tstorage {
+		req.Codes = property.TypeSpec.FlattenCodes(property.Code)
+	}
+	response, count, err := client.GetDeviceProperty(req, device)
+	if err != nil || !nested {
+		return response, count, err
+	}
+	for i := range response {
+		switch v := response[i].Value.(type) {
+		case string:
+			if dbType != constants.TDengine && dbType != constants.SQLiteDB {
+				continue
+			}
+			var value interface{}
+			if json.Unmarshal([]byte(v), &value) == nil {
+				response[i].Value = value
+			}
+		case map[string]interface{}:
+			if dbType != constants.Tstorage {
+				continue
+			}
+			if value, ok := property.TypeSpec.Unflatten(property.Code, v); ok {
+				response[i].Value = value
+			}
+		}
+	}
+	return response, count, nil
+}
@@ -0,0 +1,40 @@
+package datadb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/tstorage"
+)
+
+func TestGetDevicePropertyDeclaredArraySize(t *testing.T) {
+	client, err := tstorage.NewClient(dtos.Configuration{DataSource: t.TempDir() + "/data"}, logger.NewMockClient())
+	require.NoError(t, err)
+	t.Cleanup(client.CloseSession)
+
+	// 定义的元素个数超过未定义时的默认上限，超出部分也能查询
+	property := models.Properties{Code: "points", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeArray, Specs: `{"size":"40","item":{"type":"float"}}`}}
+	device := models.Device{Id: "device1"}
+	value := make([]interface{}, 40)
+	for i := range value {
+		value[i] = float64(i)
+	}
+	require.NoError(t, client.BatchInsert(context.Background(), []dtos.DataRecord{
+		{Table: constants.DB_PREFIX + device.Id, Time: 1000, Values: map[string]interface{}{"points": value}},
+	}))
+
+	req := dtos.ThingModelPropertyDataRequest{DeviceId: device.Id, Code: property.Code}
+	req.Range = []int64{0, 2000}
+	req.Page, req.PageSize = 1, 10
+	data, count, err := GetDeviceProperty(client, req, device, property)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	require.Len(t, data, 1)
+	assert.Equal(t, value, data[0].Value)
+}
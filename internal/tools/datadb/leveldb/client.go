@@ -23,12 +23,16 @@ import (
 	"github.com/winc-link/hummingbird/internal/dtos"
 	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
 	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/aggregate"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/errort"
 	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/syndtr/goleveldb/leveldb"
 )
@@ -36,6 +40,10 @@ import (
 type Client struct {
 	client        *LevelDB
 	loggingClient logger.LoggingClient
+	// mutex 保证最新值的读取和覆盖不会交错
+	mutex sync.Mutex
+	exit  chan struct{}
+	wg    sync.WaitGroup
 }
 
 func (c *Client) AddDatabaseField(ctx context.Context, tableName string, specsType constants.SpecsType, code string, name string) (err error) {
@@ -90,28 +98,46 @@ func NewClient(config dtos.Configuration, lc logger.LoggingClient) (c interfaces
 	ldb := &LevelDB{
 		DB: client,
 	}
-	c = &Client{
+	ldbClient := &Client{
 		client:        ldb,
 		loggingClient: lc,
+		exit:          make(chan struct{}),
 	}
+	if err = ldbClient.startMigration(); err != nil {
+		client.Close()
+		errEdgeX = errort.NewCommonEdgeX(errort.KindDatabaseError, "database failed to migrate", err)
+		return
+	}
+	c = ldbClient
 
 	return
 }
 
 func (c *Client) CloseSession() {
+	close(c.exit)
+	c.wg.Wait()
 	c.client.Close()
 }
 
+// Insert 写入 HistoryKey 生成的历史数据，同时更新各标识符的最新值
 func (c *Client) Insert(ctx context.Context, table string, data map[string]interface{}) (err error) {
 	batch := new(leveldb.Batch)
 	defer batch.Reset()
 
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	latest := make(map[string]latestRecord)
 	for k, v := range data {
 		b, ok := v.([]byte)
 		if ok {
 			batch.Put([]byte(k), b)
+			collectLatest(latest, []byte(k), b)
 		}
 	}
+	if err = c.putLatest(batch, latest); err != nil {
+		return errort.NewCommonEdgeX(errort.KindDatabaseError, "read latest value", err)
+	}
 	if err = c.client.Write(batch, &opt.WriteOptions{
 		//NoWriteMerge: true,
 		//Sync:         true,
@@ -121,282 +147,348 @@ func (c *Client) Insert(ctx context.Context, table string, data map[string]inter
 	return nil
 }
 
-func (c *Client) GetDeviceService(req dtos.ThingModelServiceDataRequest, device models.Device, product models.Product) ([]dtos.SaveServiceIssueData, int, error) {
-	var baseKey string
-	var response []dtos.SaveServiceIssueData
-	var count int
-	if req.DeviceId == "" {
-		return response, count, fmt.Errorf("deviceId is nill")
+// BatchInsert 按 HistoryKey 转换为 key 后写入，Table 为 constants.DB_PREFIX 加设备ID
+func (c *Client) BatchInsert(ctx context.Context, records []dtos.DataRecord) error {
+	kvs := make(map[string]interface{})
+	for _, record := range records {
+		deviceId := strings.TrimPrefix(record.Table, constants.DB_PREFIX)
+		for code, value := range record.Values {
+			data := dtos.ReportData{Value: value, Time: record.Time}
+			b, err := data.Marshal()
+			if err != nil {
+				return err
+			}
+			kvs[HistoryKey(deviceId, constants.Property, code, record.Time)] = b
+		}
+		for code, event := range record.Events {
+			ts := event.EventTime
+			if ts <= 0 {
+				ts = record.Time
+			}
+			b, err := event.Marshal()
+			if err != nil {
+				return err
+			}
+			kvs[HistoryKey(deviceId, constants.Event, code, ts)] = b
+		}
+		for code, service := range record.Services {
+			ts := service.Time
+			if ts <= 0 {
+				ts = record.Time
+			}
+			b, err := service.Marshal()
+			if err != nil {
+				return err
+			}
+			kvs[HistoryKey(deviceId, constants.Action, code, ts)] = b
+		}
 	}
-	if req.Code == "" {
-		baseKey = req.DeviceId + "-" + constants.Action + "-"
-	} else {
-		baseKey = req.DeviceId + "-" + constants.Action + "-" + req.Code + "-"
+	if len(kvs) == 0 {
+		return nil
 	}
-	if len(req.Range) == 2 {
-		var firstTime, lastTime string
-		if req.Range[0] < req.Range[1] {
-			firstTime = strconv.Itoa(int(req.Range[0]))
-			lastTime = strconv.Itoa(int(req.Range[1]))
-		} else {
-			firstTime = strconv.Itoa(int(req.Range[1]))
-			lastTime = strconv.Itoa(int(req.Range[0]))
-		}
-		iter := c.client.NewIterator(&util.Range{Start: []byte(baseKey + firstTime), Limit: []byte(baseKey + lastTime)}, &opt.ReadOptions{
-			DontFillCache: true,
-		})
+	return c.Insert(ctx, "", kvs)
+}
 
-		var start int
-		var end int
+type latestRecord struct {
+	ts    int64
+	value []byte
+}
 
-		start = (req.Page-1)*req.PageSize + 1
-		end = (req.Page-1)*req.PageSize + req.PageSize
+// collectLatest 记录批次中每个标识符时间最新的一条历史数据
+func collectLatest(latest map[string]latestRecord, key, value []byte) {
+	deviceId, kind, code, ts, ok := parseHistoryKey(key)
+	if !ok {
+		return
+	}
+	k := string(latestKey(deviceId, kind, code))
+	if record, ok := latest[k]; !ok || ts >= record.ts {
+		latest[k] = latestRecord{ts: ts, value: value}
+	}
+}
 
-		if iter.Last() {
-			count++
-			var dbvalue dtos.SaveServiceIssueData
-			err := json.Unmarshal(iter.Value(), &dbvalue)
-			if err != nil {
-				fmt.Println(err)
+// putLatest 比已保存的最新值更新时才覆盖，补传的历史数据不影响最新值，调用方需持有 c.mutex
+func (c *Client) putLatest(batch *leveldb.Batch, latest map[string]latestRecord) error {
+	for k, record := range latest {
+		old, err := c.client.Get([]byte(k), nil)
+		if err != nil && err != leveldb.ErrNotFound {
+			return err
+		}
+		if err == nil {
+			if ts, _, ok := parseLatestValue(old); ok && ts > record.ts {
+				continue
 			}
-			response = append(response, dbvalue)
-			for iter.Prev() {
-				count++
-				if count >= start && count <= end {
-					var dbvalue dtos.SaveServiceIssueData
-					err := json.Unmarshal(iter.Value(), &dbvalue)
-					if err != nil {
-						fmt.Println(err)
-					}
-					response = append(response, dbvalue)
-				}
+		}
+		batch.Put([]byte(k), latestValue(record.ts, record.value))
+	}
+	return nil
+}
 
-			}
+// seriesCodes 设备某类数据的全部标识符，每个标识符只 seek 一次
+func (c *Client) seriesCodes(deviceId, kind string) ([]string, error) {
+	prefix := kindPrefix(prefixHistory, deviceId, kind)
+	iter := c.client.NewIterator(util.BytesPrefix(prefix), &opt.ReadOptions{
+		DontFillCache: true,
+	})
+	defer iter.Release()
+
+	var codes []string
+	for ok := iter.First(); ok; {
+		code, _, valid := readComponent(iter.Key()[len(prefix):])
+		if !valid {
+			ok = iter.Next()
+			continue
 		}
-		iter.Release()
+		codes = append(codes, code)
+		ok = iter.Seek(util.BytesPrefix(seriesPrefix(deviceId, kind, code)).Limit)
 	}
-	return response, count, nil
+	return codes, iter.Error()
 }
 
-func (c *Client) GetDeviceEventCount(req dtos.ThingModelEventDataRequest) (int, error) {
-	var baseKey string
-	var count int
-	if req.DeviceId == "" {
-		return 0, fmt.Errorf("deviceId is nill")
+// queryRecords 查询时间范围内的历史数据，按时间倒序分页，pageSize 小于等于 0 时返回全部。
+// code 为空时查询该类型下全部标识符并按时间合并
+func (c *Client) queryRecords(deviceId, kind, code string, start, end int64, page, pageSize int) ([][]byte, int, error) {
+	offset, limit := 0, -1
+	if pageSize > 0 {
+		if page < 1 {
+			page = 1
+		}
+		offset, limit = (page-1)*pageSize, pageSize
 	}
-	if req.EventCode == "" {
-		baseKey = req.DeviceId + "-" + constants.Event + "-"
-	} else {
-		baseKey = req.DeviceId + "-" + constants.Event + "-" + req.EventCode + "-"
+	if code != "" {
+		return c.queryRange(timeRange(deviceId, kind, code, start, end), offset, limit)
 	}
-	if len(req.Range) == 2 {
-		var firstTime, lastTime string
-		if req.Range[0] < req.Range[1] {
-			firstTime = strconv.Itoa(int(req.Range[0]))
-			lastTime = strconv.Itoa(int(req.Range[1]))
-		} else {
-			firstTime = strconv.Itoa(int(req.Range[1]))
-			lastTime = strconv.Itoa(int(req.Range[0]))
-		}
 
-		iter := c.client.NewIterator(&util.Range{Start: []byte(baseKey + firstTime), Limit: []byte(baseKey + lastTime)}, &opt.ReadOptions{
+	codes, err := c.seriesCodes(deviceId, kind)
+	if err != nil {
+		return nil, 0, err
+	}
+	type entry struct {
+		ts  int64
+		key []byte
+	}
+	var entries []entry
+	for _, code := range codes {
+		iter := c.client.NewIterator(timeRange(deviceId, kind, code, start, end), &opt.ReadOptions{
 			DontFillCache: true,
 		})
 		for iter.Next() {
-			count++
+			key := append([]byte(nil), iter.Key()...)
+			ts, _ := readTimestamp(key[len(key)-timestampWidth:])
+			entries = append(entries, entry{ts: ts, key: key})
 		}
 		iter.Release()
+		if err = iter.Error(); err != nil {
+			return nil, 0, err
+		}
 	}
-	return count, nil
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ts > entries[j].ts
+	})
+	count := len(entries)
+	if offset >= len(entries) {
+		return nil, count, nil
+	}
+	entries = entries[offset:]
+	if limit >= 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	values := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		value, err := c.client.Get(e.key, nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, count, err
+		}
+		values = append(values, value)
+	}
+	return values, count, nil
 }
 
-func (c *Client) GetDeviceEvent(req dtos.ThingModelEventDataRequest, device models.Device, product models.Product) ([]dtos.EventData, int, error) {
-	var baseKey string
-	var response []dtos.EventData
-	var count int
+// queryRange 从范围的末尾倒序遍历，跳过 offset 条后最多返回 limit 条，limit 小于 0 时不限制，并返回范围内的总数
+func (c *Client) queryRange(r *util.Range, offset, limit int) ([][]byte, int, error) {
+	iter := c.client.NewIterator(r, &opt.ReadOptions{
+		DontFillCache: true,
+	})
+	defer iter.Release()
 
-	if req.DeviceId == "" {
-		return response, count, fmt.Errorf("deviceId is nill")
-	}
-	if req.EventCode == "" {
-		baseKey = req.DeviceId + "-" + constants.Event + "-"
-	} else {
-		baseKey = req.DeviceId + "-" + constants.Event + "-" + req.EventCode + "-"
-	}
-	if len(req.Range) == 2 {
-		var firstTime, lastTime string
-		if req.Range[0] < req.Range[1] {
-			firstTime = strconv.Itoa(int(req.Range[0]))
-			lastTime = strconv.Itoa(int(req.Range[1]))
-		} else {
-			firstTime = strconv.Itoa(int(req.Range[1]))
-			lastTime = strconv.Itoa(int(req.Range[0]))
+	var values [][]byte
+	var count int
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		if count >= offset && (limit < 0 || count < offset+limit) {
+			values = append(values, append([]byte(nil), iter.Value()...))
 		}
+		count++
+	}
+	return values, count, iter.Error()
+}
 
-		iter := c.client.NewIterator(&util.Range{Start: []byte(baseKey + firstTime), Limit: []byte(baseKey + lastTime)}, &opt.ReadOptions{
+func (c *Client) countRecords(deviceId, kind, code string, start, end int64) (int, error) {
+	codes := []string{code}
+	if code == "" {
+		var err error
+		if codes, err = c.seriesCodes(deviceId, kind); err != nil {
+			return 0, err
+		}
+	}
+	var count int
+	for _, code := range codes {
+		iter := c.client.NewIterator(timeRange(deviceId, kind, code, start, end), &opt.ReadOptions{
 			DontFillCache: true,
 		})
-		var start int
-		var end int
-
-		start = (req.Page-1)*req.PageSize + 1
-		end = (req.Page-1)*req.PageSize + req.PageSize
-
-		if iter.Last() {
+		for iter.Next() {
 			count++
-			var dbvalue dtos.EventData
-			_ = json.Unmarshal(iter.Value(), &dbvalue)
-			response = append(response, dbvalue)
-			for iter.Prev() {
-				count++
-				if count >= start && count <= end {
-					var dbvalue dtos.EventData
-					_ = json.Unmarshal(iter.Value(), &dbvalue)
-					response = append(response, dbvalue)
-				}
-
-			}
 		}
-
 		iter.Release()
+		if err := iter.Error(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+func reqRange(r []int64) (int64, int64) {
+	if r[0] < r[1] {
+		return r[0], r[1]
+	}
+	return r[1], r[0]
+}
 
+func (c *Client) GetDeviceService(req dtos.ThingModelServiceDataRequest, device models.Device, product models.Product) ([]dtos.SaveServiceIssueData, int, error) {
+	var response []dtos.SaveServiceIssueData
+	if req.DeviceId == "" {
+		return response, 0, fmt.Errorf("deviceId is nill")
+	}
+	if len(req.Range) != 2 {
+		return response, 0, nil
+	}
+	start, end := reqRange(req.Range)
+	values, count, err := c.queryRecords(req.DeviceId, constants.Action, req.Code, start, end, req.Page, req.PageSize)
+	if err != nil {
+		return response, count, err
+	}
+	for _, value := range values {
+		var dbvalue dtos.SaveServiceIssueData
+		if err = json.Unmarshal(value, &dbvalue); err != nil {
+			c.loggingClient.Error("err:", err)
+			continue
+		}
+		response = append(response, dbvalue)
 	}
 	return response, count, nil
 }
 
-func (c *Client) GetDevicePropertyCount(req dtos.ThingModelPropertyDataRequest) (int, error) {
-	var baseKey string
-	var count int
+func (c *Client) GetDeviceEventCount(req dtos.ThingModelEventDataRequest) (int, error) {
 	if req.DeviceId == "" {
 		return 0, fmt.Errorf("deviceId is nill")
 	}
-	if req.Code == "" {
-		baseKey = req.DeviceId + "-" + constants.Property + "-"
-	} else {
-		baseKey = req.DeviceId + "-" + constants.Property + "-" + req.Code + "-"
+	if len(req.Range) != 2 {
+		return 0, nil
 	}
+	start, end := reqRange(req.Range)
+	return c.countRecords(req.DeviceId, constants.Event, req.EventCode, start, end)
+}
 
-	if len(req.Range) == 2 {
-		var firstTime, lastTime string
-		if req.Range[0] < req.Range[1] {
-			firstTime = strconv.Itoa(int(req.Range[0]))
-			lastTime = strconv.Itoa(int(req.Range[1]))
-		} else {
-			firstTime = strconv.Itoa(int(req.Range[1]))
-			lastTime = strconv.Itoa(int(req.Range[0]))
-		}
-		iter := c.client.NewIterator(&util.Range{Start: []byte(baseKey + firstTime), Limit: []byte(baseKey + lastTime)}, &opt.ReadOptions{
-			DontFillCache: true,
-		})
-		for iter.Next() {
-			count++
+func (c *Client) GetDeviceEvent(req dtos.ThingModelEventDataRequest, device models.Device, product models.Product) ([]dtos.EventData, int, error) {
+	var response []dtos.EventData
+	if req.DeviceId == "" {
+		return response, 0, fmt.Errorf("deviceId is nill")
+	}
+	if len(req.Range) != 2 {
+		return response, 0, nil
+	}
+	start, end := reqRange(req.Range)
+	values, count, err := c.queryRecords(req.DeviceId, constants.Event, req.EventCode, start, end, req.Page, req.PageSize)
+	if err != nil {
+		return response, count, err
+	}
+	for _, value := range values {
+		var dbvalue dtos.EventData
+		if err = json.Unmarshal(value, &dbvalue); err != nil {
+			c.loggingClient.Error("err:", err)
+			continue
 		}
-		iter.Release()
+		response = append(response, dbvalue)
 	}
-	return count, nil
+	return response, count, nil
+}
+
+func (c *Client) GetDevicePropertyCount(req dtos.ThingModelPropertyDataRequest) (int, error) {
+	if req.DeviceId == "" {
+		return 0, fmt.Errorf("deviceId is nill")
+	}
+	if len(req.Range) != 2 {
+		return 0, nil
+	}
+	start, end := reqRange(req.Range)
+	return c.countRecords(req.DeviceId, constants.Property, req.Code, start, end)
 }
 
 func (c *Client) GetDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device) ([]dtos.ReportData, int, error) {
-	var baseKey string
 	var response []dtos.ReportData
 	var count int
 	if req.DeviceId == "" {
 		return response, count, fmt.Errorf("deviceId is nill")
 	}
-	if req.Code == "" {
-		baseKey = req.DeviceId + "-" + constants.Property + "-"
-	} else {
-		baseKey = req.DeviceId + "-" + constants.Property + "-" + req.Code + "-"
-	}
 
+	var values [][]byte
+	var err error
 	if len(req.Range) == 2 {
-		var firstTime, lastTime string
-		if req.Range[0] < req.Range[1] {
-			firstTime = strconv.Itoa(int(req.Range[0]))
-			lastTime = strconv.Itoa(int(req.Range[1]))
-		} else {
-			firstTime = strconv.Itoa(int(req.Range[1]))
-			lastTime = strconv.Itoa(int(req.Range[0]))
-		}
-		iter := c.client.NewIterator(&util.Range{Start: []byte(baseKey + firstTime), Limit: []byte(baseKey + lastTime)}, &opt.ReadOptions{
-			DontFillCache: true,
-		})
+		start, end := reqRange(req.Range)
+		page, pageSize := req.Page, req.PageSize
 		if req.IsAll {
-			if iter.Last() {
-				for iter.Prev() {
-					var dbvalue dtos.ReportData
-					_ = json.Unmarshal(iter.Value(), &dbvalue)
-					response = append(response, dbvalue)
-
-				}
-			}
-		} else {
-
-			var start int
-			var end int
-
-			start = (req.Page-1)*req.PageSize + 1
-			end = (req.Page-1)*req.PageSize + req.PageSize
-			if iter.Last() {
-				count++
-				if req.Page == 1 {
-					var dbvalue dtos.ReportData
-					_ = json.Unmarshal(iter.Value(), &dbvalue)
-					response = append(response, dbvalue)
-				}
-				for iter.Prev() {
-					count++
-					if count >= start && count <= end {
-						var dbvalue dtos.ReportData
-						_ = json.Unmarshal(iter.Value(), &dbvalue)
-						response = append(response, dbvalue)
-					}
-
-				}
-			}
+			pageSize = 0
+		}
+		if values, count, err = c.queryRecords(req.DeviceId, constants.Property, req.Code, start, end, page, pageSize); err != nil {
+			return response, count, err
 		}
-		iter.Release()
-
 	} else if req.First {
-		iter := c.client.NewIterator(util.BytesPrefix([]byte(baseKey)), &opt.ReadOptions{
+		iter := c.client.NewIterator(util.BytesPrefix(seriesPrefix(req.DeviceId, constants.Property, req.Code)), &opt.ReadOptions{
 			DontFillCache: true,
 		})
 		if iter.First() {
-			var dbvalue dtos.ReportData
-			_ = json.Unmarshal(iter.Value(), &dbvalue)
-			response = append(response, dbvalue)
+			values = append(values, append([]byte(nil), iter.Value()...))
 		}
 		iter.Release()
 	} else if req.Last {
-		iter := c.client.NewIterator(util.BytesPrefix([]byte(baseKey)), nil)
-		if iter.Last() {
-			var dbvalue dtos.ReportData
-			_ = json.Unmarshal(iter.Value(), &dbvalue)
-			response = append(response, dbvalue)
-			//break
+		value, err := c.client.Get(latestKey(req.DeviceId, constants.Property, req.Code), nil)
+		if err != nil && err != leveldb.ErrNotFound {
+			return response, count, err
+		}
+		if _, data, ok := parseLatestValue(value); err == nil && ok {
+			values = append(values, data)
 		}
-		iter.Release()
+	}
+	for _, value := range values {
+		var dbvalue dtos.ReportData
+		_ = json.Unmarshal(value, &dbvalue)
+		response = append(response, dbvalue)
 	}
 	return response, count, nil
 }
 
-func (c *Client) GetDeviceMsgCountByGiveTime(deviceId string, startTime, endTime int64) (int, error) {
-	baseKey := deviceId + "-" + constants.Property + "-"
-	firstTime := strconv.Itoa(int(startTime))
-	lastTime := strconv.Itoa(int(endTime))
-
-	var count int
-
-	iter := c.client.NewIterator(&util.Range{Start: []byte(baseKey + firstTime), Limit: []byte(baseKey + lastTime)}, &opt.ReadOptions{
+// GetDevicePropertyAggregate 遍历时间范围内的数据点，边读边按窗口聚合
+func (c *Client) GetDevicePropertyAggregate(req dtos.ThingModelPropertyDataRequest, device models.Device, query aggregate.Query) ([]dtos.ReportData, error) {
+	iter := c.client.NewIterator(timeRange(device.Id, constants.Property, req.Code, query.Start, query.End), &opt.ReadOptions{
 		DontFillCache: true,
 	})
-
-	count++
-	if iter.Prev() {
-		count++
+	defer iter.Release()
+	aggregator := aggregate.NewAggregator(query)
+	for iter.Next() {
+		var dbvalue dtos.ReportData
+		if err := json.Unmarshal(iter.Value(), &dbvalue); err != nil || dbvalue.Value == nil {
+			continue
+		}
+		aggregator.Add(aggregate.Point{Time: dbvalue.Time, Value: utils.ConvertToFloat64(dbvalue.Value)})
 	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return dtos.ReportDataFromBuckets(aggregator.Result()), nil
+}
 
-	iter.Release()
-
-	return count, nil
+// GetDeviceMsgCountByGiveTime 设备在时间范围内上报的属性数据条数
+func (c *Client) GetDeviceMsgCountByGiveTime(deviceId string, startTime, endTime int64) (int, error) {
+	return c.countRecords(deviceId, constants.Property, "", startTime, endTime)
 }
@@ -0,0 +1,149 @@
+package leveldb
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// 时序数据的 key 布局:
+//
+//	历史数据  0x01 | 设备ID | 类型 | 标识符 | 时间戳
+//	最新值    0x02 | 设备ID | 类型 | 标识符
+//	元数据    0x00 | 名称
+//
+// 字符串组件中的 0x00 转义为 0x00 0xFF，并以 0x00 0x01 结尾，组件之间不会互为前缀，
+// 字节序与组件的字典序一致；时间戳为符号位取反后的 8 字节大端整数，字节序与时间顺序一致。
+// 同一标识符的历史数据按时间连续存放，时间范围查询和取最近 N 条都只需一次 seek。
+// 旧版本的 key 为 "设备ID-类型-标识符-时间戳" 字符串，以可打印字符开头，与以上前缀不冲突
+const (
+	prefixMeta    byte = 0x00
+	prefixHistory byte = 0x01
+	prefixLatest  byte = 0x02
+
+	escapeByte     byte = 0x00
+	escapedZero    byte = 0xFF
+	componentEnd   byte = 0x01
+	timestampWidth      = 8
+)
+
+func appendComponent(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if s[i] == escapeByte {
+			dst = append(dst, escapeByte, escapedZero)
+		} else {
+			dst = append(dst, s[i])
+		}
+	}
+	return append(dst, escapeByte, componentEnd)
+}
+
+// readComponent 读取一个字符串组件，返回组件和剩余的字节
+func readComponent(b []byte) (string, []byte, bool) {
+	s := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] != escapeByte {
+			s = append(s, b[i])
+			continue
+		}
+		if i+1 >= len(b) {
+			return "", nil, false
+		}
+		switch b[i+1] {
+		case escapedZero:
+			s = append(s, escapeByte)
+			i++
+		case componentEnd:
+			return string(s), b[i+2:], true
+		default:
+			return "", nil, false
+		}
+	}
+	return "", nil, false
+}
+
+func appendTimestamp(dst []byte, ts int64) []byte {
+	var b [timestampWidth]byte
+	binary.BigEndian.PutUint64(b[:], uint64(ts)^(1<<63))
+	return append(dst, b[:]...)
+}
+
+func readTimestamp(b []byte) (int64, bool) {
+	if len(b) != timestampWidth {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(b) ^ (1 << 63)), true
+}
+
+// HistoryKey 设备历史数据的 key，kind 为 constants.Property、constants.Event 或 constants.Action
+func HistoryKey(deviceId, kind, code string, ts int64) string {
+	return string(appendTimestamp(seriesPrefix(deviceId, kind, code), ts))
+}
+
+func parseHistoryKey(key []byte) (deviceId, kind, code string, ts int64, ok bool) {
+	if len(key) == 0 || key[0] != prefixHistory {
+		return
+	}
+	rest := key[1:]
+	if deviceId, rest, ok = readComponent(rest); !ok {
+		return
+	}
+	if kind, rest, ok = readComponent(rest); !ok {
+		return
+	}
+	if code, rest, ok = readComponent(rest); !ok {
+		return
+	}
+	ts, ok = readTimestamp(rest)
+	return
+}
+
+func devicePrefix(prefix byte, deviceId string) []byte {
+	return appendComponent([]byte{prefix}, deviceId)
+}
+
+func kindPrefix(prefix byte, deviceId, kind string) []byte {
+	return appendComponent(devicePrefix(prefix, deviceId), kind)
+}
+
+// seriesPrefix 同一标识符全部历史数据的公共前缀
+func seriesPrefix(deviceId, kind, code string) []byte {
+	return appendComponent(kindPrefix(prefixHistory, deviceId, kind), code)
+}
+
+func latestKey(deviceId, kind, code string) []byte {
+	return appendComponent(kindPrefix(prefixLatest, deviceId, kind), code)
+}
+
+func metaKey(name string) []byte {
+	return append([]byte{prefixMeta}, name...)
+}
+
+// timeRange 标识符在 [start, end] 内的历史数据
+func timeRange(deviceId, kind, code string, start, end int64) *util.Range {
+	if start > end {
+		start, end = end, start
+	}
+	prefix := seriesPrefix(deviceId, kind, code)
+	r := &util.Range{Start: appendTimestamp(append([]byte(nil), prefix...), start)}
+	if end == math.MaxInt64 {
+		r.Limit = util.BytesPrefix(prefix).Limit
+	} else {
+		r.Limit = appendTimestamp(prefix, end+1)
+	}
+	return r
+}
+
+// latestValue 最新值的 value 为 8 字节时间戳加原始数据
+func latestValue(ts int64, value []byte) []byte {
+	return append(appendTimestamp(make([]byte, 0, timestampWidth+len(value)), ts), value...)
+}
+
+func parseLatestValue(b []byte) (int64, []byte, bool) {
+	if len(b) < timestampWidth {
+		return 0, nil, false
+	}
+	ts, _ := readTimestamp(b[:timestampWidth])
+	return ts, b[timestampWidth:], true
+}
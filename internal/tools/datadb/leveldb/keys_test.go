@@ -0,0 +1,298 @@
+package leveldb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+func newTestClient(t testing.TB, dir string) *Client {
+	c, err := NewClient(dtos.Configuration{DataSource: dir + "/data/"}, logger.NewMockClient())
+	require.NoError(t, err)
+	return c.(*Client)
+}
+
+func legacyKey(deviceId, kind, code string, ts int64) string {
+	return deviceId + "-" + kind + "-" + code + "-" + strconv.Itoa(int(ts))
+}
+
+func reportData(ts int64, value interface{}) []byte {
+	b, _ := json.Marshal(dtos.ReportData{Time: ts, Value: value})
+	return b
+}
+
+func testDevice(id string) models.Device {
+	return models.Device{Id: id}
+}
+
+func times(data []dtos.ReportData) []int64 {
+	var result []int64
+	for _, d := range data {
+		result = append(result, d.Time)
+	}
+	return result
+}
+
+func TestHistoryKeyOrder(t *testing.T) {
+	// 不同位数的时间戳按时间排序
+	times := []int64{-5, 0, 999, 1000, 99999999999, 100000000000, 1700000000000}
+	for i := 1; i < len(times); i++ {
+		assert.Negative(t, bytes.Compare(
+			[]byte(HistoryKey("d1", constants.Property, "temp", times[i-1])),
+			[]byte(HistoryKey("d1", constants.Property, "temp", times[i]))), times[i])
+	}
+
+	// 标识符互为前缀或包含分隔符时范围不重叠
+	r := util.BytesPrefix(seriesPrefix("d1", constants.Property, "a"))
+	for _, code := range []string{"a-b", "a\x00", "ab", "a-1"} {
+		key := []byte(HistoryKey("d1", constants.Property, code, 1))
+		assert.False(t, bytes.Compare(key, r.Start) >= 0 && bytes.Compare(key, r.Limit) < 0, code)
+	}
+
+	deviceId, kind, code, ts, ok := parseHistoryKey([]byte(HistoryKey("d-1", constants.Event, "a\x00-b", 1700000000000)))
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"d-1", constants.Event, "a\x00-b", int64(1700000000000)}, []interface{}{deviceId, kind, code, ts})
+}
+
+func TestParseLegacyKey(t *testing.T) {
+	deviceId, kind, code, ts, ok := parseLegacyKey("d1-property-a-b-1700000000000")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"d1", constants.Property, "a-b", int64(1700000000000)}, []interface{}{deviceId, kind, code, ts})
+
+	_, kind, code, _, ok = parseLegacyKey("d1-property-x-event-1")
+	require.True(t, ok)
+	assert.Equal(t, constants.Property, kind)
+	assert.Equal(t, "x-event", code)
+
+	for _, key := range []string{"d1-property-temp", "abc", "d1-event-a-x"} {
+		_, _, _, _, ok = parseLegacyKey(key)
+		assert.False(t, ok, key)
+	}
+}
+
+func TestQueryRecords(t *testing.T) {
+	c := newTestClient(t, t.TempDir())
+	defer c.CloseSession()
+
+	kvs := make(map[string]interface{})
+	for ts := int64(1); ts <= 20; ts++ {
+		kvs[HistoryKey("d1", constants.Property, "temp", ts*1000)] = reportData(ts*1000, ts)
+		kvs[HistoryKey("d1", constants.Property, "temp-x", ts*1000)] = reportData(ts*1000, -ts)
+	}
+	require.NoError(t, c.Insert(context.Background(), "", kvs))
+	// 补传的历史数据不覆盖最新值
+	require.NoError(t, c.Insert(context.Background(), "", map[string]interface{}{
+		HistoryKey("d1", constants.Property, "temp", 500): reportData(500, 0),
+	}))
+
+	req := dtos.ThingModelPropertyDataRequest{DeviceId: "d1", Code: "temp"}
+	req.Range, req.Page, req.PageSize = []int64{5000, 15000}, 2, 3
+	data, count, err := c.GetDeviceProperty(req, testDevice("d1"))
+	require.NoError(t, err)
+	assert.Equal(t, 11, count)
+	assert.Equal(t, []int64{12000, 11000, 10000}, times(data))
+
+	req = dtos.ThingModelPropertyDataRequest{DeviceId: "d1", Code: "temp"}
+	req.Last = true
+	data, _, err = c.GetDeviceProperty(req, testDevice("d1"))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{20000}, times(data))
+
+	req = dtos.ThingModelPropertyDataRequest{DeviceId: "d1", Code: "temp"}
+	req.First = true
+	data, _, err = c.GetDeviceProperty(req, testDevice("d1"))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{500}, times(data))
+
+	count, err = c.GetDeviceMsgCountByGiveTime("d1", 1000, 2000)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+}
+
+func TestMigrateLegacyKeys(t *testing.T) {
+	dir := t.TempDir()
+	db, err := leveldb.OpenFile(dir+"/data", nil)
+	require.NoError(t, err)
+	batch := new(leveldb.Batch)
+	for ts := int64(1); ts <= 2500; ts++ {
+		batch.Put([]byte(legacyKey("d1", constants.Property, "a-b", ts*1000)), reportData(ts*1000, ts))
+	}
+	batch.Put([]byte("d1-property-once"), []byte("{}"))
+	require.NoError(t, db.Write(batch, nil))
+	require.NoError(t, db.Close())
+
+	c := newTestClient(t, dir)
+	c.wg.Wait()
+	version, err := c.client.Get(metaKey(metaSchemaVersion), nil)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(schemaVersion), string(version))
+
+	count, err := c.countRecords("d1", constants.Property, "a-b", 0, time.Now().UnixMilli())
+	require.NoError(t, err)
+	assert.Equal(t, 2500, count)
+	req := dtos.ThingModelPropertyDataRequest{DeviceId: "d1", Code: "a-b"}
+	req.Last = true
+	data, _, err := c.GetDeviceProperty(req, testDevice("d1"))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2500000}, times(data))
+	// 无法解析的旧 key 保留
+	_, err = c.client.Get([]byte("d1-property-once"), nil)
+	assert.NoError(t, err)
+	c.CloseSession()
+
+	// 再次打开时不再迁移
+	c = newTestClient(t, dir)
+	defer c.CloseSession()
+	c.wg.Wait()
+	count, err = c.countRecords("d1", constants.Property, "a-b", 0, time.Now().UnixMilli())
+	require.NoError(t, err)
+	assert.Equal(t, 2500, count)
+}
+
+const (
+	benchCodes  = 10
+	benchPoints = 10000
+	benchPage   = 20
+)
+
+// benchRange 查询时间窗口为数据中间的 10%
+func benchRange() (int64, int64) {
+	return benchPoints * 450, benchPoints * 550
+}
+
+func populateLegacy(b *testing.B, db *leveldb.DB) {
+	batch := new(leveldb.Batch)
+	for i := 0; i < benchCodes; i++ {
+		for ts := int64(1); ts <= benchPoints; ts++ {
+			batch.Put([]byte(legacyKey("d1", constants.Property, fmt.Sprintf("code%d", i), ts*1000)), reportData(ts*1000, ts))
+		}
+		require.NoError(b, db.Write(batch, nil))
+		batch.Reset()
+	}
+}
+
+func populate(b *testing.B, c *Client) {
+	for i := 0; i < benchCodes; i++ {
+		kvs := make(map[string]interface{}, benchPoints)
+		for ts := int64(1); ts <= benchPoints; ts++ {
+			kvs[HistoryKey("d1", constants.Property, fmt.Sprintf("code%d", i), ts*1000)] = reportData(ts*1000, ts)
+		}
+		require.NoError(b, c.Insert(context.Background(), "", kvs))
+	}
+}
+
+// BenchmarkLegacyLayoutRange 旧布局按字符串时间戳的范围查询一页数据，与 GetDeviceProperty 原实现相同
+func BenchmarkLegacyLayoutRange(b *testing.B) {
+	db, err := leveldb.OpenFile(b.TempDir(), nil)
+	require.NoError(b, err)
+	defer db.Close()
+	populateLegacy(b, db)
+	start, end := benchRange()
+	baseKey := "d1-" + constants.Property + "-code5-"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := db.NewIterator(&util.Range{Start: []byte(baseKey + strconv.Itoa(int(start))), Limit: []byte(baseKey + strconv.Itoa(int(end)))}, &opt.ReadOptions{
+			DontFillCache: true,
+		})
+		var count int
+		var page [][]byte
+		for ok := iter.Last(); ok; ok = iter.Prev() {
+			if count < benchPage {
+				page = append(page, append([]byte(nil), iter.Value()...))
+			}
+			count++
+		}
+		iter.Release()
+	}
+}
+
+func BenchmarkLayoutRange(b *testing.B) {
+	c := newTestClient(b, b.TempDir())
+	defer c.CloseSession()
+	populate(b, c)
+	start, end := benchRange()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.queryRecords("d1", constants.Property, "code5", start, end, 1, benchPage); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLegacyLayoutLast 旧布局取最新值，与 GetDeviceProperty 原实现相同
+func BenchmarkLegacyLayoutLast(b *testing.B) {
+	db, err := leveldb.OpenFile(b.TempDir(), nil)
+	require.NoError(b, err)
+	defer db.Close()
+	populateLegacy(b, db)
+	baseKey := "d1-" + constants.Property + "-code5-"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := db.NewIterator(util.BytesPrefix([]byte(baseKey)), nil)
+		iter.Last()
+		_ = append([]byte(nil), iter.Value()...)
+		iter.Release()
+	}
+}
+
+func BenchmarkLayoutLast(b *testing.B) {
+	c := newTestClient(b, b.TempDir())
+	defer c.CloseSession()
+	populate(b, c)
+	req := dtos.ThingModelPropertyDataRequest{DeviceId: "d1", Code: "code5"}
+	req.Last = true
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.GetDeviceProperty(req, testDevice("d1")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLegacyLayoutInsert(b *testing.B) {
+	db, err := leveldb.OpenFile(b.TempDir(), nil)
+	require.NoError(b, err)
+	defer db.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := new(leveldb.Batch)
+		for code := 0; code < benchCodes; code++ {
+			batch.Put([]byte(legacyKey("d1", constants.Property, fmt.Sprintf("code%d", code), int64(i))), reportData(int64(i), i))
+		}
+		require.NoError(b, db.Write(batch, nil))
+	}
+}
+
+// BenchmarkLayoutInsert 新布局写入时需读取并更新各标识符的最新值
+func BenchmarkLayoutInsert(b *testing.B) {
+	c := newTestClient(b, b.TempDir())
+	defer c.CloseSession()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		kvs := make(map[string]interface{}, benchCodes)
+		for code := 0; code < benchCodes; code++ {
+			kvs[HistoryKey("d1", constants.Property, fmt.Sprintf("code%d", code), int64(i))] = reportData(int64(i), i)
+		}
+		require.NoError(b, c.Insert(context.Background(), "", kvs))
+	}
+}
@@ -0,0 +1,152 @@
+package leveldb
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+const (
+	metaSchemaVersion = "schema_version"
+	// schemaVersion 2 为 keys.go 中的 key 布局，之前的版本没有记录版本号
+	schemaVersion = 2
+
+	migrateBatchSize = 1000
+)
+
+// legacyRange 旧版本的 key 以可打印字符开头，排在新布局的前缀之后
+var legacyRange = &util.Range{Start: []byte{prefixLatest + 1}}
+
+// startMigration 数据目录中存在旧版本的 key 时在后台迁移到新布局，迁移期间服务正常读写，
+// 每个批次原子地写入新 key 并删除旧 key，已迁移的历史数据立即可以查询，重启后从剩余的旧 key 继续
+func (c *Client) startMigration() error {
+	version, err := c.client.Get(metaKey(metaSchemaVersion), nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	if err == nil && string(version) == strconv.Itoa(schemaVersion) {
+		return nil
+	}
+
+	iter := c.client.NewIterator(legacyRange, nil)
+	hasLegacy := iter.First()
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		return err
+	}
+	if !hasLegacy {
+		return c.client.Put(metaKey(metaSchemaVersion), []byte(strconv.Itoa(schemaVersion)), nil)
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.loggingClient.Info("leveldb migrate legacy keys start")
+		migrated, skipped, err := c.migrateLegacyKeys()
+		if err != nil {
+			c.loggingClient.Errorf("leveldb migrate legacy keys err: %v, migrated %d", err, migrated)
+			return
+		}
+		if err = c.client.Put(metaKey(metaSchemaVersion), []byte(strconv.Itoa(schemaVersion)), nil); err != nil {
+			c.loggingClient.Errorf("leveldb save schema version err: %v", err)
+			return
+		}
+		c.loggingClient.Infof("leveldb migrate legacy keys done, migrated %d, skipped %d", migrated, skipped)
+	}()
+	return nil
+}
+
+// migrateLegacyKeys 无法解析的旧 key 保留不动
+func (c *Client) migrateLegacyKeys() (migrated, skipped int, err error) {
+	iter := c.client.NewIterator(legacyRange, &opt.ReadOptions{
+		DontFillCache: true,
+	})
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	latest := make(map[string]latestRecord)
+	flush := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		if err := c.putLatest(batch, latest); err != nil {
+			return err
+		}
+		if err := c.client.Write(batch, nil); err != nil {
+			return err
+		}
+		batch.Reset()
+		latest = make(map[string]latestRecord)
+		return nil
+	}
+	var pending int
+	for iter.Next() {
+		deviceId, kind, code, ts, ok := parseLegacyKey(string(iter.Key()))
+		if !ok {
+			skipped++
+			continue
+		}
+		key := []byte(HistoryKey(deviceId, kind, code, ts))
+		value := append([]byte(nil), iter.Value()...)
+		batch.Put(key, value)
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		collectLatest(latest, key, value)
+		pending++
+		if pending < migrateBatchSize {
+			continue
+		}
+		if err = flush(); err != nil {
+			return
+		}
+		migrated += pending
+		pending = 0
+		select {
+		case <-c.exit:
+			c.loggingClient.Infof("leveldb migrate legacy keys interrupted, migrated %d", migrated)
+			return migrated, skipped, leveldb.ErrClosed
+		default:
+		}
+		if migrated%(100*migrateBatchSize) == 0 {
+			c.loggingClient.Infof("leveldb migrate legacy keys, migrated %d", migrated)
+		}
+	}
+	if err = iter.Error(); err != nil {
+		return
+	}
+	if err = flush(); err != nil {
+		return
+	}
+	migrated += pending
+	return
+}
+
+// parseLegacyKey 解析 "设备ID-类型-标识符-时间戳" 格式的旧 key，标识符中可能包含 "-"，
+// 以最先出现的 "-类型-" 作为设备ID的结尾，以最后一个 "-" 之后的数字作为时间戳
+func parseLegacyKey(key string) (deviceId, kind, code string, ts int64, ok bool) {
+	index := -1
+	for _, k := range []string{constants.Property, constants.Event, constants.Action} {
+		if i := strings.Index(key, "-"+k+"-"); i > 0 && (index < 0 || i < index) {
+			index, kind = i, k
+		}
+	}
+	if index < 0 {
+		return
+	}
+	deviceId = key[:index]
+	rest := key[index+len(kind)+2:]
+	i := strings.LastIndex(rest, "-")
+	if i <= 0 {
+		return
+	}
+	ts, err := strconv.ParseInt(rest[i+1:], 10, 64)
+	if err != nil {
+		return
+	}
+	return deviceId, kind, rest[:i], ts, true
+}
@@ -0,0 +1,109 @@
+package leveldb
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+)
+
+// deleteBatchSize 清理过期数据时单个批次删除的 key 数量
+const deleteBatchSize = 1000
+
+// DeleteExpiredData 按标识符删除过期时间之前的历史数据，最新值保留，
+// 删除后压缩该设备的 key 范围以释放磁盘空间
+func (c *Client) DeleteExpiredData(ctx context.Context, device models.Device, product models.Product, retention models.DataRetention) error {
+	now := time.Now()
+	var ranges []*util.Range
+	// 按数据库中已有的标识符删除，包含已从物模型中删除的标识符
+	for kind, days := range map[string]int{
+		constants.Property: retention.Property,
+		constants.Event:    retention.Event,
+		constants.Action:   retention.Service,
+	} {
+		if days <= 0 {
+			continue
+		}
+		codes, err := c.seriesCodes(device.Id, kind)
+		if err != nil {
+			return err
+		}
+		before := now.AddDate(0, 0, -days).UnixMilli()
+		for _, code := range codes {
+			ranges = append(ranges, timeRange(device.Id, kind, code, math.MinInt64, before-1))
+		}
+	}
+
+	var deleted int
+	for _, r := range ranges {
+		n, err := c.deleteRange(ctx, r)
+		deleted += n
+		if err != nil {
+			return err
+		}
+	}
+	if deleted == 0 {
+		return nil
+	}
+	c.loggingClient.Infof("leveldb delete %d expired keys of device %s", deleted, device.Id)
+	return c.client.CompactRange(*util.BytesPrefix(devicePrefix(prefixHistory, device.Id)))
+}
+
+func (c *Client) deleteRange(ctx context.Context, r *util.Range) (int, error) {
+	iter := c.client.NewIterator(r, &opt.ReadOptions{
+		DontFillCache: true,
+	})
+	defer iter.Release()
+
+	var deleted int
+	batch := new(leveldb.Batch)
+	flush := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		if err := c.client.Write(batch, nil); err != nil {
+			return errort.NewCommonEdgeX(errort.KindDatabaseError, "batch transaction delete", err)
+		}
+		deleted += batch.Len()
+		batch.Reset()
+		return nil
+	}
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		if batch.Len() >= deleteBatchSize {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+			if err := ctx.Err(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// SetRetention leveldb 没有数据过期机制，由 DeleteExpiredData 清理
+func (c *Client) SetRetention(ctx context.Context, days int) error {
+	return nil
+}
+
+// GetDeviceStorageSize 设备全部 key 在磁盘上的近似大小
+func (c *Client) GetDeviceStorageSize(ctx context.Context, device models.Device, product models.Product) (int64, error) {
+	sizes, err := c.client.SizeOf([]util.Range{
+		*util.BytesPrefix(devicePrefix(prefixHistory, device.Id)),
+		*util.BytesPrefix(devicePrefix(prefixLatest, device.Id)),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sizes.Sum(), nil
+}
@@ -0,0 +1,312 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+// 每个产品一张表 data_product_<产品ID>，列为 ts(毫秒时间戳)、device_id 和物模型标识符，
+// 与 tdengine 的超级表对应；设备所属的产品记录在 data_device 中，与 tdengine 的子表对应。
+// 结构体、数组属性和事件、服务以 json 字符串存储。表名带 data_ 前缀，可以和元数据共用一个数据库文件
+const (
+	productTablePrefix = "data_product_"
+	deviceTable        = "data_device"
+)
+
+type Client struct {
+	client        *sql.DB
+	loggingClient logger.LoggingClient
+
+	mutex    sync.RWMutex
+	products map[string]string
+}
+
+func (c *Client) GetDataDBType() constants.DataType {
+	return constants.SQLiteDB
+}
+
+func (c *Client) CloseSession() {
+	c.client.Close()
+}
+
+func NewClient(config dtos.Configuration, lc logger.LoggingClient) (c interfaces.DataDBClient, errEdgeX error) {
+	dataSource := config.DataSource
+	if dir := filepath.Dir(strings.SplitN(dataSource, "?", 2)[0]); dir != "" {
+		_ = os.MkdirAll(dir, os.ModePerm)
+	}
+	db, err := sql.Open("sqlite3", dsn(dataSource))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (device_id TEXT PRIMARY KEY, product_id TEXT NOT NULL)", deviceTable)); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Client{
+		client:        db,
+		loggingClient: lc,
+		products:      make(map[string]string),
+	}, nil
+}
+
+// dsn 默认开启 WAL，写入时不阻塞查询，并设置锁等待时间
+func dsn(dataSource string) string {
+	var params []string
+	if !strings.Contains(dataSource, "_journal") {
+		params = append(params, "_journal_mode=WAL")
+	}
+	if !strings.Contains(dataSource, "_timeout") && !strings.Contains(dataSource, "_busy_timeout") {
+		params = append(params, "_busy_timeout=5000")
+	}
+	if len(params) == 0 {
+		return dataSource
+	}
+	if strings.Contains(dataSource, "?") {
+		return dataSource + "&" + strings.Join(params, "&")
+	}
+	return dataSource + "?" + strings.Join(params, "&")
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func productTable(productId string) string {
+	return quote(productTablePrefix + productId)
+}
+
+func column(specsType constants.SpecsType) string {
+	switch specsType {
+	case constants.SpecsTypeInt, constants.SpecsTypeDate:
+		return "INTEGER"
+	case constants.SpecsTypeFloat:
+		return "REAL"
+	case constants.SpecsTypeBool:
+		// 声明为 BOOLEAN 的列查询时返回 bool
+		return "BOOLEAN"
+	default:
+		return "TEXT"
+	}
+}
+
+func (c *Client) CreateStable(ctx context.Context, product models.Product) (err error) {
+	columns := []string{"ts INTEGER NOT NULL", "device_id TEXT NOT NULL"}
+	for _, property := range product.Properties {
+		columns = append(columns, quote(property.Code)+" "+column(property.TypeSpec.Type))
+	}
+	for _, event := range product.Events {
+		columns = append(columns, quote(event.Code)+" TEXT")
+	}
+	for _, action := range product.Actions {
+		columns = append(columns, quote(action.Code)+" TEXT")
+	}
+	tx, err := c.client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", productTable(product.Id), strings.Join(columns, ","))); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (device_id, ts)",
+		quote("idx_"+productTablePrefix+product.Id), productTable(product.Id))); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateTable 记录设备所属的产品
+func (c *Client) CreateTable(ctx context.Context, stable, table string) (err error) {
+	_, err = c.client.ExecContext(ctx, fmt.Sprintf("INSERT OR REPLACE INTO %s (device_id, product_id) VALUES (?, ?)", deviceTable), table, stable)
+	if err == nil {
+		c.mutex.Lock()
+		c.products[table] = stable
+		c.mutex.Unlock()
+	}
+	return
+}
+
+func (c *Client) DropStable(ctx context.Context, table string) (err error) {
+	if _, err = c.client.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", productTable(table))); err != nil {
+		return
+	}
+	if _, err = c.client.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE product_id = ?", deviceTable), table); err != nil {
+		return
+	}
+	c.mutex.Lock()
+	for deviceId, productId := range c.products {
+		if productId == table {
+			delete(c.products, deviceId)
+		}
+	}
+	c.mutex.Unlock()
+	return
+}
+
+// DropTable 删除设备的全部数据
+func (c *Client) DropTable(ctx context.Context, table string) (err error) {
+	productId, err := c.productId(table)
+	if err != nil {
+		return nil
+	}
+	if _, err = c.client.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE device_id = ?", productTable(productId)), table); err != nil {
+		return
+	}
+	if _, err = c.client.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE device_id = ?", deviceTable), table); err != nil {
+		return
+	}
+	c.mutex.Lock()
+	delete(c.products, table)
+	c.mutex.Unlock()
+	return
+}
+
+func (c *Client) AddDatabaseField(ctx context.Context, tableName string, specsType constants.SpecsType, code string, name string) (err error) {
+	_, err = c.client.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", productTable(tableName), quote(code), column(specsType)))
+	return
+}
+
+func (c *Client) DelDatabaseField(ctx context.Context, tableName, code string) (err error) {
+	_, err = c.client.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", productTable(tableName), quote(code)))
+	return
+}
+
+// ModifyDatabaseField sqlite 不能修改列的类型，列可以存储任意类型的值，只在列不存在时添加
+func (c *Client) ModifyDatabaseField(ctx context.Context, tableName string, specsType constants.SpecsType, code string, name string) (err error) {
+	columns, err := c.columns(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	for _, col := range columns {
+		if strings.EqualFold(col, code) {
+			return nil
+		}
+	}
+	return c.AddDatabaseField(ctx, tableName, specsType, code, name)
+}
+
+// columns 产品表中物模型标识符对应的列
+func (c *Client) columns(ctx context.Context, productId string) ([]string, error) {
+	rows, err := c.client.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", productTablePrefix+productId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name != "ts" && name != "device_id" {
+			columns = append(columns, name)
+		}
+	}
+	return columns, rows.Err()
+}
+
+// productId 设备所属的产品，设备未创建时返回错误
+func (c *Client) productId(deviceId string) (string, error) {
+	c.mutex.RLock()
+	productId, ok := c.products[deviceId]
+	c.mutex.RUnlock()
+	if ok {
+		return productId, nil
+	}
+	err := c.client.QueryRow(fmt.Sprintf("SELECT product_id FROM %s WHERE device_id = ?", deviceTable), deviceId).Scan(&productId)
+	if err == sql.ErrNoRows {
+		return "", errort.NewCommonEdgeX(errort.DeviceNotExist, fmt.Sprintf("device %s table not exist", deviceId), nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	c.mutex.Lock()
+	c.products[deviceId] = productId
+	c.mutex.Unlock()
+	return productId, nil
+}
+
+func (c *Client) Insert(ctx context.Context, table string, data map[string]interface{}) (err error) {
+	return c.BatchInsert(ctx, []dtos.DataRecord{{
+		Table:  table,
+		Time:   time.Now().UnixMilli(),
+		Values: data,
+	}})
+}
+
+// BatchInsert 在一个事务中写入全部记录
+func (c *Client) BatchInsert(ctx context.Context, records []dtos.DataRecord) error {
+	tx, err := c.client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, record := range records {
+		fields := record.Fields()
+		if len(fields) == 0 {
+			continue
+		}
+		deviceId := strings.TrimPrefix(record.Table, constants.DB_PREFIX)
+		productId, err := c.productId(deviceId)
+		if err != nil {
+			return err
+		}
+		columns := []string{"ts", "device_id"}
+		args := []interface{}{record.Time, deviceId}
+		for code, value := range fields {
+			columns = append(columns, quote(code))
+			args = append(args, sqlValue(value))
+		}
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?%s)", productTable(productId),
+			strings.Join(columns, ","), strings.Repeat(",?", len(columns)-1))
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// sqlValue 基本类型直接写入，结构体、数组、事件和服务编码为 json
+func sqlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,173 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/aggregate"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+func newTestClient(t testing.TB) *Client {
+	c, err := NewClient(dtos.Configuration{DataSource: t.TempDir() + "/core.db?_timeout=5000"}, logger.NewMockClient())
+	require.NoError(t, err)
+	t.Cleanup(c.CloseSession)
+	return c.(*Client)
+}
+
+func testProduct() models.Product {
+	return models.Product{
+		Id: "product1",
+		Properties: []models.Properties{
+			{Code: "temperature", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat}},
+			{Code: "switch", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeBool}},
+			{Code: "location", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeStruct}},
+		},
+		Events:  []models.Events{{Code: "alarm"}},
+		Actions: []models.Actions{{Code: "reboot"}},
+	}
+}
+
+func setupDevice(t *testing.T, c *Client) (models.Device, models.Product) {
+	ctx := context.Background()
+	product := testProduct()
+	device := models.Device{Id: "device1", ProductId: product.Id}
+	require.NoError(t, c.CreateStable(ctx, product))
+	require.NoError(t, c.CreateTable(ctx, product.Id, device.Id))
+	return device, product
+}
+
+func TestPropertyHistory(t *testing.T) {
+	c := newTestClient(t)
+	device, _ := setupDevice(t, c)
+	table := constants.DB_PREFIX + device.Id
+
+	var records []dtos.DataRecord
+	for i := int64(1); i <= 10; i++ {
+		records = append(records, dtos.DataRecord{
+			Table: table,
+			Time:  i * 1000,
+			Values: map[string]interface{}{
+				"temperature": float64(i),
+				"switch":      i%2 == 0,
+				"location":    map[string]interface{}{"lat": 1.5},
+			},
+		})
+	}
+	require.NoError(t, c.BatchInsert(context.Background(), records))
+
+	req := dtos.ThingModelPropertyDataRequest{Code: "temperature"}
+	req.Range = []int64{10000, 3000}
+	req.Page, req.PageSize = 2, 3
+	data, count, err := c.GetDeviceProperty(req, device)
+	require.NoError(t, err)
+	assert.Equal(t, 8, count)
+	require.Len(t, data, 3)
+	assert.Equal(t, dtos.ReportData{Time: 7000, Value: 7.0}, data[0])
+
+	req.Range = nil
+	req.Last = true
+	data, _, err = c.GetDeviceProperty(req, device)
+	require.NoError(t, err)
+	assert.Equal(t, []dtos.ReportData{{Time: 10000, Value: 10.0}}, data)
+
+	req.Code = "switch"
+	data, _, err = c.GetDeviceProperty(req, device)
+	require.NoError(t, err)
+	assert.Equal(t, true, data[0].Value)
+
+	req.Code = "location"
+	data, _, err = c.GetDeviceProperty(req, device)
+	require.NoError(t, err)
+	assert.Equal(t, `{"lat":1.5}`, data[0].Value)
+
+	req.Code = "temperature"
+	buckets, err := c.GetDevicePropertyAggregate(req, device, aggregate.Query{
+		Start: 0, End: 9999, Interval: 5 * time.Second, Function: aggregate.Avg, Fill: aggregate.FillNone,
+	})
+	require.NoError(t, err)
+	require.Len(t, buckets, 2)
+	assert.Equal(t, 2.5, buckets[0].Value)
+	assert.Equal(t, 7.0, buckets[1].Value)
+
+	count, err = c.GetDeviceMsgCountByGiveTime(device.Id, 1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func TestEventAndServiceHistory(t *testing.T) {
+	c := newTestClient(t)
+	device, product := setupDevice(t, c)
+	table := constants.DB_PREFIX + device.Id
+	require.NoError(t, c.BatchInsert(context.Background(), []dtos.DataRecord{
+		{Table: table, Time: 1000, Events: map[string]dtos.EventData{
+			"alarm": {EventCode: "alarm", EventTime: 1000, OutputParams: map[string]interface{}{"level": 1.0}},
+		}},
+		{Table: table, Time: 2000, Services: map[string]dtos.SaveServiceIssueData{
+			"reboot": {Code: "reboot", Time: 2000, InputParams: map[string]interface{}{"delay": 5.0}},
+		}},
+		{Table: table, Time: 3000, Values: map[string]interface{}{"temperature": 20.0}},
+	}))
+
+	eventReq := dtos.ThingModelEventDataRequest{}
+	eventReq.Range = []int64{0, 5000}
+	eventReq.Page, eventReq.PageSize = 1, 10
+	events, count, err := c.GetDeviceEvent(eventReq, device, product)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1.0, events[0].OutputParams["level"])
+
+	serviceReq := dtos.ThingModelServiceDataRequest{Code: "reboot"}
+	serviceReq.Range = []int64{0, 5000}
+	serviceReq.Page, serviceReq.PageSize = 1, 10
+	services, count, err := c.GetDeviceService(serviceReq, device, product)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	require.Len(t, services, 1)
+	assert.Equal(t, 5.0, services[0].InputParams["delay"])
+}
+
+func TestSchemaChangeAndRetention(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+	device, product := setupDevice(t, c)
+	require.NoError(t, c.AddDatabaseField(ctx, product.Id, constants.SpecsTypeInt, "humidity", ""))
+	require.NoError(t, c.ModifyDatabaseField(ctx, product.Id, constants.SpecsTypeFloat, "humidity", ""))
+	require.NoError(t, c.DelDatabaseField(ctx, product.Id, "switch"))
+	product.Properties = append(product.Properties[:1], models.Properties{Code: "humidity"})
+
+	old := time.Now().AddDate(0, 0, -10).UnixMilli()
+	table := constants.DB_PREFIX + device.Id
+	require.NoError(t, c.BatchInsert(ctx, []dtos.DataRecord{
+		{Table: table, Time: old, Values: map[string]interface{}{"humidity": 50}},
+		{Table: table, Time: old, Events: map[string]dtos.EventData{"alarm": {EventCode: "alarm"}}},
+	}))
+	require.NoError(t, c.Insert(ctx, table, map[string]interface{}{"humidity": 60}))
+	size, err := c.GetDeviceStorageSize(ctx, device, product)
+	require.NoError(t, err)
+	assert.Positive(t, size)
+
+	// 属性保存 5 天，事件永久保存
+	require.NoError(t, c.DeleteExpiredData(ctx, device, product, models.DataRetention{Property: 5}))
+	req := dtos.ThingModelPropertyDataRequest{Code: "humidity"}
+	req.DeviceId = device.Id
+	req.Range = []int64{0, time.Now().UnixMilli()}
+	count, err := c.GetDevicePropertyCount(req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	req.Code = ""
+	count, err = c.GetDevicePropertyCount(req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	require.NoError(t, c.DropTable(ctx, device.Id))
+	assert.Error(t, c.Insert(ctx, table, map[string]interface{}{"humidity": 60}))
+	require.NoError(t, c.DropStable(ctx, product.Id))
+}
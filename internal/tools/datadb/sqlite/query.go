@@ -0,0 +1,300 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/aggregate"
+	"github.com/winc-link/hummingbird/internal/pkg/errort"
+	"github.com/winc-link/hummingbird/internal/pkg/utils"
+)
+
+func reqRange(r []int64) (int64, int64) {
+	if r[0] < r[1] {
+		return r[0], r[1]
+	}
+	return r[1], r[0]
+}
+
+// limit 分页条件，pageSize 不大于 0 时返回全部
+func limit(page, pageSize int) string {
+	if pageSize <= 0 {
+		return ""
+	}
+	if page < 1 {
+		page = 1
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", pageSize, (page-1)*pageSize)
+}
+
+// notNull 任一标识符有值的条件
+func notNull(codes []string) string {
+	conditions := make([]string, 0, len(codes))
+	for _, code := range codes {
+		conditions = append(conditions, quote(code)+" IS NOT NULL")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+func quoteAll(codes []string) string {
+	quoted := make([]string, 0, len(codes))
+	for _, code := range codes {
+		quoted = append(quoted, quote(code))
+	}
+	return strings.Join(quoted, ",")
+}
+
+// count 统计设备在时间范围内 codes 任一有值的行数，codes 为空时统计全部行
+func (c *Client) count(productId, deviceId string, codes []string, start, end int64) (int, error) {
+	query := fmt.Sprintf("SELECT count(*) FROM %s WHERE device_id = ? AND ts >= ? AND ts <= ?", productTable(productId))
+	if len(codes) > 0 {
+		query += " AND " + notNull(codes)
+	}
+	var count int
+	err := c.client.QueryRow(query, deviceId, start, end).Scan(&count)
+	return count, err
+}
+
+// selectValues 按时间倒序查询 codes 的值，每行返回时间和各列的值，没有值的列为 nil
+func (c *Client) selectValues(productId, deviceId string, codes []string, start, end int64, page, pageSize int) ([]int64, [][]interface{}, error) {
+	query := fmt.Sprintf("SELECT ts,%s FROM %s WHERE device_id = ? AND ts >= ? AND ts <= ? AND %s ORDER BY ts DESC%s",
+		quoteAll(codes), productTable(productId), notNull(codes), limit(page, pageSize))
+	rows, err := c.client.Query(query, deviceId, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var times []int64
+	var values [][]interface{}
+	for rows.Next() {
+		var ts int64
+		row := make([]interface{}, len(codes))
+		dest := []interface{}{&ts}
+		for i := range row {
+			dest = append(dest, &row[i])
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+		for i := range row {
+			if b, ok := row[i].([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		times = append(times, ts)
+		values = append(values, row)
+	}
+	return times, values, rows.Err()
+}
+
+func (c *Client) GetDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device) ([]dtos.ReportData, int, error) {
+	var response []dtos.ReportData
+	var count int
+	productId, err := c.productId(device.Id)
+	if err != nil {
+		return response, count, err
+	}
+	codes := []string{req.Code}
+
+	var times []int64
+	var values [][]interface{}
+	if len(req.Range) == 2 {
+		start, end := reqRange(req.Range)
+		if count, err = c.count(productId, device.Id, codes, start, end); err != nil {
+			return response, count, err
+		}
+		pageSize := req.PageSize
+		if req.IsAll {
+			pageSize = 0
+		}
+		times, values, err = c.selectValues(productId, device.Id, codes, start, end, req.Page, pageSize)
+	} else if req.First || req.Last {
+		order := "DESC"
+		if req.First {
+			order = "ASC"
+		}
+		query := fmt.Sprintf("SELECT ts,%s FROM %s WHERE device_id = ? AND %s IS NOT NULL ORDER BY ts %s LIMIT 1",
+			quote(req.Code), productTable(productId), quote(req.Code), order)
+		var ts int64
+		var value interface{}
+		err = c.client.QueryRow(query, device.Id).Scan(&ts, &value)
+		if err == nil {
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			times, values = []int64{ts}, [][]interface{}{{value}}
+		} else if err == sql.ErrNoRows {
+			err = nil
+		}
+	}
+	if err != nil {
+		return response, count, err
+	}
+	for i, ts := range times {
+		response = append(response, dtos.ReportData{Time: ts, Value: values[i][0]})
+	}
+	return response, count, nil
+}
+
+// GetDevicePropertyAggregate 查询时间范围内的数据点在内存中聚合
+func (c *Client) GetDevicePropertyAggregate(req dtos.ThingModelPropertyDataRequest, device models.Device, query aggregate.Query) ([]dtos.ReportData, error) {
+	productId, err := c.productId(device.Id)
+	if err != nil {
+		return nil, err
+	}
+	times, values, err := c.selectValues(productId, device.Id, []string{req.Code}, query.Start, query.End, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]aggregate.Point, 0, len(times))
+	for i, ts := range times {
+		points = append(points, aggregate.Point{Time: ts, Value: utils.ConvertToFloat64(values[i][0])})
+	}
+	return dtos.ReportDataFromBuckets(aggregate.Aggregate(points, query)), nil
+}
+
+// GetDeviceService 指定服务标识符时查询该服务，否则查询产品的全部服务
+func (c *Client) GetDeviceService(req dtos.ThingModelServiceDataRequest, device models.Device, product models.Product) ([]dtos.SaveServiceIssueData, int, error) {
+	var response []dtos.SaveServiceIssueData
+	codes := []string{req.Code}
+	if req.Code == "" {
+		codes = codes[:0]
+		for _, action := range product.Actions {
+			codes = append(codes, action.Code)
+		}
+	}
+	if len(req.Range) != 2 || len(codes) == 0 {
+		return response, 0, nil
+	}
+	start, end := reqRange(req.Range)
+	count, err := c.count(product.Id, device.Id, codes, start, end)
+	if err != nil {
+		return response, count, err
+	}
+	_, values, err := c.selectValues(product.Id, device.Id, codes, start, end, req.Page, req.PageSize)
+	if err != nil {
+		return response, count, err
+	}
+	for _, row := range values {
+		for _, value := range row {
+			if value == nil {
+				continue
+			}
+			var data dtos.SaveServiceIssueData
+			if err = json.Unmarshal([]byte(fmt.Sprint(value)), &data); err != nil {
+				c.loggingClient.Error("err:", err)
+				continue
+			}
+			response = append(response, data)
+		}
+	}
+	return response, count, nil
+}
+
+// GetDeviceEvent 指定事件标识符时查询该事件，否则查询产品的全部事件
+func (c *Client) GetDeviceEvent(req dtos.ThingModelEventDataRequest, device models.Device, product models.Product) ([]dtos.EventData, int, error) {
+	var response []dtos.EventData
+	codes := []string{req.EventCode}
+	if req.EventCode == "" {
+		codes = codes[:0]
+		for _, event := range product.Events {
+			codes = append(codes, event.Code)
+		}
+	}
+	if len(req.Range) != 2 || len(codes) == 0 {
+		return response, 0, nil
+	}
+	start, end := reqRange(req.Range)
+	count, err := c.count(product.Id, device.Id, codes, start, end)
+	if err != nil {
+		return response, count, err
+	}
+	_, values, err := c.selectValues(product.Id, device.Id, codes, start, end, req.Page, req.PageSize)
+	if err != nil {
+		return response, count, err
+	}
+	for _, row := range values {
+		for _, value := range row {
+			if value == nil {
+				continue
+			}
+			var data dtos.EventData
+			if err = json.Unmarshal([]byte(fmt.Sprint(value)), &data); err != nil {
+				c.loggingClient.Error("err:", err)
+				continue
+			}
+			response = append(response, data)
+		}
+	}
+	return response, count, nil
+}
+
+// GetDevicePropertyCount 标识符为空时统计设备的全部数据行
+func (c *Client) GetDevicePropertyCount(req dtos.ThingModelPropertyDataRequest) (int, error) {
+	if req.DeviceId == "" {
+		return 0, fmt.Errorf("deviceId is nill")
+	}
+	if len(req.Range) != 2 {
+		return 0, nil
+	}
+	productId, err := c.productId(req.DeviceId)
+	if err != nil {
+		return 0, err
+	}
+	var codes []string
+	if req.Code != "" {
+		codes = append(codes, req.Code)
+	}
+	start, end := reqRange(req.Range)
+	return c.count(productId, req.DeviceId, codes, start, end)
+}
+
+// GetDeviceEventCount 标识符为空时统计设备的全部数据行
+func (c *Client) GetDeviceEventCount(req dtos.ThingModelEventDataRequest) (int, error) {
+	if req.DeviceId == "" {
+		return 0, fmt.Errorf("deviceId is nill")
+	}
+	if len(req.Range) != 2 {
+		return 0, nil
+	}
+	productId, err := c.productId(req.DeviceId)
+	if err != nil {
+		return 0, err
+	}
+	var codes []string
+	if req.EventCode != "" {
+		codes = append(codes, req.EventCode)
+	}
+	start, end := reqRange(req.Range)
+	return c.count(productId, req.DeviceId, codes, start, end)
+}
+
+// GetDeviceMsgCountByGiveTime 与 tdengine 相同，startTime、endTime 单位为秒。设备没有数据表时返回 0
+func (c *Client) GetDeviceMsgCountByGiveTime(deviceId string, startTime, endTime int64) (int, error) {
+	productId, err := c.productId(deviceId)
+	if errort.Is(errort.DeviceNotExist, err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return c.count(productId, deviceId, nil, startTime*1000, endTime*1000+999)
+}
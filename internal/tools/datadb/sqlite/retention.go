@@ -0,0 +1,121 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/winc-link/hummingbird/internal/models"
+)
+
+// storageRowOverhead 估算存储大小时每行 ts、device_id 及行头的字节数
+const storageRowOverhead = 16
+
+// DeleteExpiredData 属性、事件和服务共用一行，按各自的保存天数把过期的列置空，再删除全部列为空的行
+func (c *Client) DeleteExpiredData(ctx context.Context, device models.Device, product models.Product, retention models.DataRetention) error {
+	columns, err := c.columns(ctx, product.Id)
+	if err != nil || len(columns) == 0 {
+		return err
+	}
+	exists := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		exists[strings.ToLower(column)] = true
+	}
+	codes := func(days int, all []string) []string {
+		var codes []string
+		if days <= 0 {
+			return codes
+		}
+		for _, code := range all {
+			if exists[strings.ToLower(code)] {
+				codes = append(codes, code)
+			}
+		}
+		return codes
+	}
+	var properties, events, actions []string
+	for _, property := range product.Properties {
+		properties = append(properties, property.Code)
+	}
+	for _, event := range product.Events {
+		events = append(events, event.Code)
+	}
+	for _, action := range product.Actions {
+		actions = append(actions, action.Code)
+	}
+	expired := []struct {
+		days  int
+		codes []string
+	}{
+		{retention.Property, codes(retention.Property, properties)},
+		{retention.Event, codes(retention.Event, events)},
+		{retention.Service, codes(retention.Service, actions)},
+	}
+
+	tx, err := c.client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var updated bool
+	for _, e := range expired {
+		if len(e.codes) == 0 {
+			continue
+		}
+		sets := make([]string, 0, len(e.codes))
+		for _, code := range e.codes {
+			sets = append(sets, quote(code)+" = NULL")
+		}
+		before := time.Now().AddDate(0, 0, -e.days).UnixMilli()
+		query := fmt.Sprintf("UPDATE %s SET %s WHERE device_id = ? AND ts < ? AND %s",
+			productTable(product.Id), strings.Join(sets, ","), notNull(e.codes))
+		if _, err = tx.ExecContext(ctx, query, device.Id, before); err != nil {
+			return err
+		}
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE device_id = ? AND NOT %s", productTable(product.Id), notNull(columns))
+	if _, err = tx.ExecContext(ctx, query, device.Id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetRetention sqlite 没有数据库级别的保存时间，过期数据由 DeleteExpiredData 删除
+func (c *Client) SetRetention(ctx context.Context, days int) error {
+	return nil
+}
+
+// GetDeviceStorageSize 按设备各列值的长度估算占用的存储
+func (c *Client) GetDeviceStorageSize(ctx context.Context, device models.Device, product models.Product) (int64, error) {
+	columns, err := c.columns(ctx, product.Id)
+	if err != nil || len(columns) == 0 {
+		return 0, err
+	}
+	sizes := []string{fmt.Sprintf("count(*) * %d", storageRowOverhead)}
+	for _, column := range columns {
+		sizes = append(sizes, fmt.Sprintf("coalesce(sum(length(%s)), 0)", quote(column)))
+	}
+	var size int64
+	err = c.client.QueryRowContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE device_id = ?",
+		strings.Join(sizes, " + "), productTable(product.Id)), device.Id).Scan(&size)
+	return size, err
+}
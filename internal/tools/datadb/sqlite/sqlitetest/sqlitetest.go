@@ -0,0 +1,46 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+// Package sqlitetest 提供测试使用的 sqlite 时序数据库
+package sqlitetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+	"github.com/winc-link/hummingbird/internal/tools/datadb/sqlite"
+)
+
+// NewClient 在测试临时目录中打开 sqlite 时序数据库，测试结束时关闭
+func NewClient(t testing.TB) interfaces.DataDBClient {
+	client, err := sqlite.NewClient(dtos.Configuration{DataSource: t.TempDir() + "/data.db?_timeout=5000"}, logger.NewMockClient())
+	require.NoError(t, err)
+	t.Cleanup(client.CloseSession)
+	return client
+}
+
+// NewClientWithDevices 打开数据库并创建产品的超级表和设备子表
+func NewClientWithDevices(t testing.TB, product models.Product, devices ...models.Device) interfaces.DataDBClient {
+	client := NewClient(t)
+	require.NoError(t, client.CreateStable(context.Background(), product))
+	for _, device := range devices {
+		require.NoError(t, client.CreateTable(context.Background(), product.Id, device.Id))
+	}
+	return client
+}
@@ -0,0 +1,102 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package tdengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/winc-link/hummingbird/internal/dtos"
+)
+
+// maxBatchSqlLen 单条 INSERT 语句的最大长度，tdengine 限制为 1MB
+const maxBatchSqlLen = 512 << 10
+
+var valueEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// BatchInsert 使用多表插入语法 INSERT INTO t1 (...) VALUES (...) t2 (...) VALUES (...)，
+// 语句超过 maxBatchSqlLen 时拆分为多条
+func (c *Client) BatchInsert(ctx context.Context, records []dtos.DataRecord) error {
+	var sql strings.Builder
+	exec := func() error {
+		if sql.Len() == 0 {
+			return nil
+		}
+		_, err := c.client.ExecContext(ctx, sql.String())
+		sql.Reset()
+		return err
+	}
+	for _, record := range records {
+		fields := record.Fields()
+		if len(fields) == 0 {
+			continue
+		}
+		clause, err := insertClause(record.Table, record.Time, fields)
+		if err != nil {
+			return err
+		}
+		if sql.Len() > 0 && sql.Len()+len(clause) > maxBatchSqlLen {
+			if err := exec(); err != nil {
+				return err
+			}
+		}
+		if sql.Len() == 0 {
+			sql.WriteString("INSERT INTO")
+		}
+		sql.WriteString(clause)
+	}
+	return exec()
+}
+
+func insertClause(table string, ts int64, fields map[string]interface{}) (string, error) {
+	var (
+		field = []string{"ts"}
+		value = []string{"'" + time.UnixMilli(ts).Format("2006-01-02 15:04:05.000") + "'"}
+	)
+	for k, v := range fields {
+		s, err := fieldValue(k, v)
+		if err != nil {
+			return "", err
+		}
+		field = append(field, strings.ToLower(k))
+		value = append(value, s)
+	}
+	return " " + table + " (" + strings.Join(field, ",") + ") VALUES (" + strings.Join(value, ",") + ")", nil
+}
+
+// fieldValue 转换为转义后的 sql 字符串值，结构体和数组转换为 json，
+// json 超过列长度时返回错误，避免截断后无法还原
+func fieldValue(code string, v interface{}) (string, error) {
+	var s string
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		if n := utf8.RuneCount(b); n > nestedColumnLength {
+			return "", fmt.Errorf("field %s json length %d exceeds %d", code, n, nestedColumnLength)
+		}
+		s = string(b)
+	default:
+		s = gvar.New(v).String()
+	}
+	return "'" + valueEscaper.Replace(s) + "'", nil
+}
@@ -0,0 +1,43 @@
+package tdengine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldValue(t *testing.T) {
+	cases := []struct {
+		value  interface{}
+		expect string
+	}{
+		{float64(1.5), `'1.5'`},
+		{true, `'true'`},
+		{`it's`, `'it\'s'`},
+		{`a\'); DROP TABLE x; --`, `'a\\\'); DROP TABLE x; --'`},
+		{map[string]interface{}{"name": "O'Brien"}, `'{"name":"O\'Brien"}'`},
+		{[]interface{}{float64(1), "x"}, `'[1,"x"]'`},
+	}
+	for _, c := range cases {
+		v, err := fieldValue("code", c.value)
+		require.NoError(t, err)
+		assert.Equal(t, c.expect, v)
+	}
+
+	_, err := fieldValue("code", []interface{}{strings.Repeat("中", nestedColumnLength)})
+	assert.Error(t, err)
+	_, err = fieldValue("code", strings.Repeat("a", nestedColumnLength+1))
+	assert.NoError(t, err)
+}
+
+func TestInsertClause(t *testing.T) {
+	clause, err := insertClause("t1", 0, map[string]interface{}{"Temp": "x'"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(clause, " t1 (ts,temp) VALUES ('"))
+	assert.True(t, strings.HasSuffix(clause, `,'x\'')`))
+
+	_, err = insertClause("t1", 0, map[string]interface{}{"pos": map[string]interface{}{"name": strings.Repeat("a", nestedColumnLength)}})
+	assert.Error(t, err)
+}
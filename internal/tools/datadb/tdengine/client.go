@@ -23,6 +23,7 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
 	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/aggregate"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/errort"
 	"strconv"
@@ -54,6 +55,9 @@ func (c *Client) CloseSession() {
 
 var dbName = "hummingbird"
 
+// nestedColumnLength 结构体和数组属性以 json 存储的列长度(字符数)
+const nestedColumnLength = 2048
+
 func NewClient(config dtos.Configuration, lc logger.LoggingClient) (c interfaces.DataDBClient, errEdgeX error) {
 
 	dsn := config.Dsn
@@ -75,22 +79,12 @@ func NewClient(config dtos.Configuration, lc logger.LoggingClient) (c interfaces
 }
 
 func (c *Client) Insert(ctx context.Context, table string, data map[string]interface{}) (err error) {
-	ts := time.Now().Format("2006-01-02 15:04:05.000")
-
-	var (
-		field = []string{"ts"}
-		value = []string{"'" + ts + "'"}
-	)
-
-	for k, v := range data {
-		field = append(field, strings.ToLower(k))
-		value = append(value, "'"+gvar.New(v).String()+"'")
+	clause, err := insertClause(table, time.Now().UnixMilli(), data)
+	if err != nil {
+		return err
 	}
-
-	sql := "INSERT INTO ? (?) VALUES (?)"
-	_, err = c.client.Exec(sql, table, strings.Join(field, ","), strings.Join(value, ","))
+	_, err = c.client.ExecContext(ctx, "INSERT INTO"+clause)
 	return
-
 }
 
 func (c *Client) CreateDatabase(ctx context.Context) (err error) {
@@ -158,6 +152,9 @@ func (c *Client) column(specsType constants.SpecsType, code string, name string)
 		tdType = "TIMESTAMP"
 	case constants.SpecsTypeBool:
 		tdType = "BOOL"
+	case constants.SpecsTypeStruct, constants.SpecsTypeArray:
+		// 结构体和数组以 json 存储
+		tdType = fmt.Sprintf("NCHAR(%d)", nestedColumnLength)
 	default:
 		tdType = "NCHAR(255)"
 	}
@@ -180,7 +177,7 @@ func (c *Client) DelDatabaseField(ctx context.Context, stableName, code string)
 func (c *Client) ModifyDatabaseField(ctx context.Context, stableName string, specsType constants.SpecsType, code string, name string) (err error) {
 	sql := fmt.Sprintf("ALTER STABLE %s.%s MODIFY COLUMN %s", dbName, "product_"+stableName, c.column(specsType, code, name))
 	_, err = c.client.Exec(sql)
-	if strings.Contains(err.Error(), "column length could be modified") {
+	if err != nil && strings.Contains(err.Error(), "column length could be modified") {
 		return errort.NewCommonEdgeX(errort.ThingModeTypeCannotBeModified, "Only varbinary/binary/nchar/geometry column length could be modified, and the length can only be increased, not decreased", nil)
 	}
 	return
@@ -587,6 +584,46 @@ func (c *Client) GetOne(ctx context.Context, sql string, args ...any) (rs gdb.Re
 	return
 }
 
+// GetDevicePropertyAggregate 使用 INTERVAL 和 FILL 在 tdengine 中聚合
+func (c *Client) GetDevicePropertyAggregate(req dtos.ThingModelPropertyDataRequest, device models.Device, query aggregate.Query) ([]dtos.ReportData, error) {
+	fill := strings.ToUpper(string(query.Fill))
+	if query.Fill == aggregate.FillValue {
+		fill = fmt.Sprintf("VALUE, %s", strconv.FormatFloat(query.FillValue, 'f', -1, 64))
+	}
+	interval := query.Interval.Milliseconds()
+	sql := fmt.Sprintf("select _wstart as ts, %s(?) as v from ? where ts >= '?' and ts <= '?' interval(%da) fill(%s)", query.Function, interval, fill)
+	startTime := time.UnixMilli(query.Start).UTC().Format("2006-01-02 15:04:05.000")
+	endTime := time.UnixMilli(query.End).UTC().Format("2006-01-02 15:04:05.000")
+	rows, err := c.client.Query(sql, strings.ToLower(req.Code), "device_"+device.Id, startTime, endTime)
+	if err != nil {
+		c.loggingClient.Error("query data:", err)
+		return nil, err
+	}
+	defer rows.Close()
+	response := make([]dtos.ReportData, 0)
+	for rows.Next() {
+		columns, _ := rows.Columns()
+		values := make([]any, len(columns))
+		rs := make(gdb.Record, len(columns))
+		for i := range values {
+			values[i] = new(any)
+		}
+		if err = rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		for i, cs := range columns {
+			rs[cs] = gvar.New(values[i])
+		}
+		var reportData dtos.ReportData
+		reportData.Time = rs["ts"].Time().UnixMilli()
+		if v := rs["v"]; v != nil && !v.IsNil() && v.String() != "" {
+			reportData.Value = v.Float64()
+		}
+		response = append(response, reportData)
+	}
+	return response, rows.Err()
+}
+
 func (c *Client) GetDevicePropertyCount(request dtos.ThingModelPropertyDataRequest) (int, error) {
 	//TODO implement me
 	panic("implement me")
@@ -0,0 +1,93 @@
+/*******************************************************************************
+ * Copyright 2017.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package tdengine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/winc-link/hummingbird/internal/models"
+)
+
+// minKeepDays tdengine 要求 KEEP 不小于 3 倍的 DURATION(默认 10 天)，
+// KEEP 为全部产品中最长的保存天数，各产品更短的保存天数由 DeleteExpiredData 按时间删除
+const minKeepDays = 30
+
+var (
+	totalSizePattern = regexp.MustCompile(`Total_Size=\[([\d.]+) ?([KMGT]?B)\]`)
+	sizeUnits        = map[string]float64{"B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30, "TB": 1 << 40}
+)
+
+// DeleteExpiredData 删除设备子表中过期的数据。
+// 属性、事件和服务共用子表的同一行，按三者中最长的保存天数删除
+func (c *Client) DeleteExpiredData(ctx context.Context, device models.Device, product models.Product, retention models.DataRetention) error {
+	days := retention.Max()
+	if days <= 0 {
+		return nil
+	}
+	before := time.Now().AddDate(0, 0, -days).UTC().Format("2006-01-02 15:04:05.000")
+	_, err := c.client.ExecContext(ctx, "DELETE FROM ? WHERE ts < '?'", "device_"+device.Id, before)
+	if err != nil && strings.Contains(err.Error(), "Table does not exist") {
+		return nil
+	}
+	return err
+}
+
+// SetRetention 设置数据库的 KEEP 选项，days 为 0 时不修改
+func (c *Client) SetRetention(ctx context.Context, days int) error {
+	if days <= 0 {
+		return nil
+	}
+	if days < minKeepDays {
+		days = minKeepDays
+	}
+	_, err := c.client.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s KEEP %d", dbName, days))
+	return err
+}
+
+// GetDeviceStorageSize 解析 SHOW TABLE DISTRIBUTED 返回的 Total_Size
+func (c *Client) GetDeviceStorageSize(ctx context.Context, device models.Device, product models.Product) (int64, error) {
+	rows, err := c.client.QueryContext(ctx, "SHOW TABLE DISTRIBUTED ?", "device_"+device.Id)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line string
+		if err = rows.Scan(&line); err != nil {
+			return 0, err
+		}
+		if size, ok := parseTotalSize(line); ok {
+			return size, nil
+		}
+	}
+	return 0, rows.Err()
+}
+
+func parseTotalSize(line string) (int64, bool) {
+	match := totalSizePattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	size, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(size * sizeUnits[match[2]]), true
+}
@@ -0,0 +1,45 @@
+package tstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/aggregate"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+func TestGetDevicePropertyAggregateSpans(t *testing.T) {
+	client, err := NewClient(dtos.Configuration{DataSource: t.TempDir() + "/data"}, logger.NewMockClient())
+	require.NoError(t, err)
+	t.Cleanup(client.CloseSession)
+
+	device := models.Device{Id: "device1"}
+	hour := int64(time.Hour / time.Millisecond)
+	start := time.Now().UnixMilli()/hour*hour - 3*hour
+	var records []dtos.DataRecord
+	// 查询范围跨越多个分段查询，分段边界上的数据点只统计一次
+	for i, offset := range []int64{0, hour / 2, hour, hour + 1, 2*hour + 10, 3 * hour} {
+		records = append(records, dtos.DataRecord{
+			Table:  constants.DB_PREFIX + device.Id,
+			Time:   start + offset,
+			Values: map[string]interface{}{"temperature": float64(i + 1)},
+		})
+	}
+	require.NoError(t, client.BatchInsert(context.Background(), records))
+
+	query := aggregate.Query{Start: start, End: start + 3*hour - 1, Interval: time.Hour, Function: aggregate.Sum, Fill: aggregate.FillNull}
+	require.NoError(t, query.Check())
+	req := dtos.ThingModelPropertyDataRequest{Code: "temperature"}
+	response, err := client.GetDevicePropertyAggregate(req, device, query)
+	require.NoError(t, err)
+	require.Len(t, response, 3)
+	assert.Equal(t, 3.0, response[0].Value)
+	assert.Equal(t, 7.0, response[1].Value)
+	assert.Equal(t, 5.0, response[2].Value)
+}
@@ -6,17 +6,25 @@ import (
 	"github.com/winc-link/hummingbird/internal/dtos"
 	interfaces "github.com/winc-link/hummingbird/internal/hummingbird/core/interface"
 	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/aggregate"
 	"github.com/winc-link/hummingbird/internal/pkg/constants"
 	"github.com/winc-link/hummingbird/internal/pkg/logger"
 	"github.com/winc-link/hummingbird/internal/pkg/utils"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Client struct {
 	client        tstorage.Storage
 	loggingClient logger.LoggingClient
+	nonces        *payloadNonces
+	// retentionDays 打开时设置的保存天数，0 表示永久保存
+	retentionDays int
+	partitions    *partitionUsages
 }
 
 func (c *Client) GetDataDBType() constants.DataType {
@@ -28,12 +36,52 @@ func (c *Client) CloseSession() {
 }
 
 func (c *Client) Insert(ctx context.Context, table string, data map[string]interface{}) (err error) {
-	metric := table
-	var rows []tstorage.Row
+	rows, err := recordRows(c.nonces, table, time.Now().UnixMilli(), data)
+	if err != nil {
+		return err
+	}
+	return c.client.InsertRows(rows)
+}
 
-	timestamp := time.Now().UnixMilli()
+// BatchInsert 全部记录的数据点一次写入。tstorage 只能写入最近几个分区，
+// 早于可写分区的数据点会被丢弃
+func (c *Client) BatchInsert(ctx context.Context, records []dtos.DataRecord) error {
+	var rows []tstorage.Row
+	for _, record := range records {
+		recordRows, err := recordRows(c.nonces, record.Table, record.Time, record.Fields())
+		if err != nil {
+			return err
+		}
+		rows = append(rows, recordRows...)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return c.client.InsertRows(rows)
+}
 
+func recordRows(nonces *payloadNonces, metric string, timestamp int64, data map[string]interface{}) ([]tstorage.Row, error) {
+	var rows []tstorage.Row
+	values := make(map[string]interface{})
 	for code, value := range data {
+		var payload []tstorage.Row
+		var err error
+		switch v := value.(type) {
+		case dtos.EventData:
+			payload, err = payloadRows(nonces, metric, labelEvent, code, v.EventTime, v)
+		case dtos.SaveServiceIssueData:
+			payload, err = payloadRows(nonces, metric, labelService, code, v.Time, v)
+		default:
+			// tstorage 只能存储数值，结构体和数组拆分为子字段存储
+			models.FlattenValue(code, value, values)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, payload...)
+	}
+	for code, value := range values {
 		var labels []tstorage.Label
 		labels = append(labels, tstorage.Label{
 			Name: "code", Value: code,
@@ -48,7 +96,7 @@ func (c *Client) Insert(ctx context.Context, table string, data map[string]inter
 			},
 		})
 	}
-	return c.client.InsertRows(rows)
+	return rows, nil
 }
 
 func paginate(arr []*tstorage.DataPoint, page, pageSize int) []*tstorage.DataPoint {
@@ -79,6 +127,9 @@ func reverseArray(arr []*tstorage.DataPoint) []*tstorage.DataPoint {
 }
 
 func (c *Client) GetDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device) ([]dtos.ReportData, int, error) {
+	if len(req.Codes) > 0 {
+		return c.getNestedDeviceProperty(req, device)
+	}
 	var response []dtos.ReportData
 	var count int
 	if len(req.Range) == 2 {
@@ -96,6 +147,9 @@ func (c *Client) GetDeviceProperty(req dtos.ThingModelPropertyDataRequest, devic
 			Name: "code", Value: req.Code,
 		})
 		points, err := c.client.Select(constants.DB_PREFIX+device.Id, labels, startTime, endTime)
+		if err == tstorage.ErrNoDataPoints {
+			return []dtos.ReportData{}, count, nil
+		}
 		if err != nil {
 			c.loggingClient.Error("tstorage query data:", err)
 			return []dtos.ReportData{}, count, err
@@ -111,47 +165,233 @@ func (c *Client) GetDeviceProperty(req dtos.ThingModelPropertyDataRequest, devic
 		}
 
 	} else if req.Last {
-		var labels []tstorage.Label
-		labels = append(labels, tstorage.Label{
-			Name: "code", Value: req.Code,
-		})
-		var startTime, endTime int64
-		// 获取当前时间
-		now := time.Now()
-		// 计算半小时前的时间
-		past := now.Add(-30 * time.Minute)
-		// 转换为毫秒时间戳
-		endTime = now.UnixMilli()
-		startTime = past.UnixMilli()
+		labels := []tstorage.Label{{Name: "code", Value: req.Code}}
+		dataPoint, err := c.selectLast(constants.DB_PREFIX+device.Id, labels)
+		if err != nil {
+			c.loggingClient.Error("tstorage query data:", err)
+			return []dtos.ReportData{}, count, err
+		}
+		if dataPoint != nil {
+			response = append(response, dtos.ReportData{
+				Value: dataPoint.Value,
+				Time:  dataPoint.Timestamp,
+			})
+		}
+	}
+	return response, count, nil
+}
 
-		points, err := c.client.Select(constants.DB_PREFIX+device.Id, labels, startTime, endTime)
+// aggregateSelectSpan tstorage 没有迭代器，聚合时按该时长分段查询，每段的数据点累加后即释放。
+// 与 tstorage 默认的分区时长相同，每段最多涉及两个分区
+const aggregateSelectSpan = int64(time.Hour / time.Millisecond)
+
+// GetDevicePropertyAggregate 按时间分段查询数据点，边读边按窗口聚合
+func (c *Client) GetDevicePropertyAggregate(req dtos.ThingModelPropertyDataRequest, device models.Device, query aggregate.Query) ([]dtos.ReportData, error) {
+	labels := []tstorage.Label{{Name: "code", Value: req.Code}}
+	aggregator := aggregate.NewAggregator(query)
+	for start := query.Start; start <= query.End; start += aggregateSelectSpan {
+		end := start + aggregateSelectSpan
+		if end > query.End+1 {
+			end = query.End + 1
+		}
+		points, err := c.client.Select(constants.DB_PREFIX+device.Id, labels, start, end)
+		if err == tstorage.ErrNoDataPoints {
+			continue
+		}
 		if err != nil {
 			c.loggingClient.Error("tstorage query data:", err)
-			return []dtos.ReportData{}, count, nil
+			return nil, err
+		}
+		for _, point := range points {
+			aggregator.Add(aggregate.Point{Time: point.Timestamp, Value: point.Value})
 		}
+	}
+	return dtos.ReportDataFromBuckets(aggregator.Result()), nil
+}
 
-		dataPoint := points[len(points)-1]
-		var reportData dtos.ReportData
-		reportData.Time = dataPoint.Timestamp
-		reportData.Value = dataPoint.Value
-		response = append(response, reportData)
+// getNestedDeviceProperty 结构体和数组属性按子字段拆分存储，查询全部子字段后按上报时间合并，
+// 返回的 Value 为子字段标识符到值的映射，由调用方按物模型还原
+func (c *Client) getNestedDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device) ([]dtos.ReportData, int, error) {
+	var startTime, endTime int64
+	if len(req.Range) == 2 {
+		startTime, endTime = req.Range[0], req.Range[1]
+		if startTime > endTime {
+			startTime, endTime = endTime, startTime
+		}
+	} else if req.Last {
+		return c.getNestedLastDeviceProperty(req, device)
+	} else {
+		return nil, 0, nil
+	}
 
+	merged := make(map[int64]map[string]interface{})
+	err := selectNestedCodes(req.Codes, func(code string) (bool, error) {
+		labels := []tstorage.Label{{Name: "code", Value: code}}
+		points, err := c.client.Select(constants.DB_PREFIX+device.Id, labels, startTime, endTime)
+		if err == tstorage.ErrNoDataPoints {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		for _, point := range points {
+			values, ok := merged[point.Timestamp]
+			if !ok {
+				values = make(map[string]interface{})
+				merged[point.Timestamp] = values
+			}
+			values[code] = point.Value
+		}
+		return len(points) > 0, nil
+	})
+	if err != nil {
+		c.loggingClient.Error("tstorage query data:", err)
+		return []dtos.ReportData{}, 0, err
+	}
+	times := make([]int64, 0, len(merged))
+	for t := range merged {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] > times[j]
+	})
+	count := len(times)
+	if req.PageSize > 0 {
+		start := (req.Page - 1) * req.PageSize
+		if start < 0 || start >= len(times) {
+			times = nil
+		} else if end := start + req.PageSize; end < len(times) {
+			times = times[start:end]
+		} else {
+			times = times[start:]
+		}
+	}
+	response := make([]dtos.ReportData, 0, len(times))
+	for _, t := range times {
+		response = append(response, dtos.ReportData{
+			Value: merged[t],
+			Time:  t,
+		})
 	}
 	return response, count, nil
 }
 
-func (c *Client) GetDeviceService(req dtos.ThingModelServiceDataRequest, device models.Device, product models.Product) ([]dtos.SaveServiceIssueData, int, error) {
-	// tstorage 不支持服务查询
-	var response []dtos.SaveServiceIssueData
-	var count int
-	return response, count, nil
+// selectNestedCodes 按 FlattenCodes 的顺序逐个查询子字段，select 返回该子字段是否有数据。
+// 上报的数组是连续的，数组某个元素的全部子字段都没有数据时，不再查询之后的元素，
+// 避免按数组最大长度逐个元素查询
+func selectNestedCodes(codes []string, selectCode func(code string) (bool, error)) error {
+	// empty 数组路径 -> 没有数据的元素下标，如 pts -> 2
+	empty := make(map[string]int)
+	// found 有数据的数组元素，如 pts[0]
+	found := make(map[string]bool)
+	for _, code := range codes {
+		paths, indexes := nestedArrayIndexes(code)
+		skip := false
+		for i, path := range paths {
+			index := indexes[i]
+			if first, ok := empty[path]; ok {
+				skip = index > first
+			} else if index > 0 && !found[path+"["+strconv.Itoa(index-1)+"]"] {
+				empty[path] = index - 1
+				skip = true
+			}
+			if skip {
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+		ok, err := selectCode(code)
+		if err != nil {
+			return err
+		}
+		if ok {
+			for i, path := range paths {
+				found[path+"["+strconv.Itoa(indexes[i])+"]"] = true
+			}
+		}
+	}
+	return nil
 }
 
-func (c *Client) GetDeviceEvent(req dtos.ThingModelEventDataRequest, device models.Device, product models.Product) ([]dtos.EventData, int, error) {
-	// tstorage 不支持事件查询
-	var response []dtos.EventData
-	var count int
-	return response, count, nil
+// nestedArrayIndexes 子字段标识符中各级数组的路径和下标，如 pts[1].v[0] 返回 pts、pts[1].v 和 1、0
+func nestedArrayIndexes(code string) ([]string, []int) {
+	var paths []string
+	var indexes []int
+	for i := 0; i < len(code); i++ {
+		if code[i] != '[' {
+			continue
+		}
+		end := strings.IndexByte(code[i:], ']')
+		if end < 0 {
+			break
+		}
+		index, err := strconv.Atoi(code[i+1 : i+end])
+		if err != nil {
+			break
+		}
+		paths = append(paths, code[:i])
+		indexes = append(indexes, index)
+		i += end
+	}
+	return paths, indexes
+}
+
+// lastPointWindows 查询最新值时依次扩大的时间窗口，0 表示查询全部数据
+var lastPointWindows = []time.Duration{30 * time.Minute, 24 * time.Hour, 30 * 24 * time.Hour, 0}
+
+// selectLast 查询最新的数据点，没有数据时返回 nil
+func (c *Client) selectLast(metric string, labels []tstorage.Label) (*tstorage.DataPoint, error) {
+	end := time.Now().UnixMilli() + 1
+	for _, window := range lastPointWindows {
+		var start int64
+		if window > 0 {
+			start = end - window.Milliseconds()
+		}
+		points, err := c.client.Select(metric, labels, start, end)
+		if err == tstorage.ErrNoDataPoints {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(points) > 0 {
+			return points[len(points)-1], nil
+		}
+	}
+	return nil, nil
+}
+
+// getNestedLastDeviceProperty 合并各子字段中时间最新的数据点
+func (c *Client) getNestedLastDeviceProperty(req dtos.ThingModelPropertyDataRequest, device models.Device) ([]dtos.ReportData, int, error) {
+	points := make(map[string]*tstorage.DataPoint, len(req.Codes))
+	var last int64
+	err := selectNestedCodes(req.Codes, func(code string) (bool, error) {
+		point, err := c.selectLast(constants.DB_PREFIX+device.Id, []tstorage.Label{{Name: "code", Value: code}})
+		if err != nil || point == nil {
+			return false, err
+		}
+		points[code] = point
+		if point.Timestamp > last {
+			last = point.Timestamp
+		}
+		return true, nil
+	})
+	if err != nil {
+		c.loggingClient.Error("tstorage query data:", err)
+		return []dtos.ReportData{}, 0, err
+	}
+	if len(points) == 0 {
+		return []dtos.ReportData{}, 0, nil
+	}
+	values := make(map[string]interface{}, len(points))
+	for code, point := range points {
+		if point.Timestamp == last {
+			values[code] = point.Value
+		}
+	}
+	return []dtos.ReportData{{Value: values, Time: last}}, 1, nil
 }
 
 func (c *Client) CreateTable(ctx context.Context, stable, table string) (err error) {
@@ -215,14 +455,21 @@ func NewClient(config dtos.Configuration, lc logger.LoggingClient) (c interfaces
 	storage, err := tstorage.NewStorage(
 		tstorage.WithTimestampPrecision(tstorage.Milliseconds),
 		tstorage.WithDataPath(dataSourceDir),
-		//tstorage.WithRetention(365*24*time.Hour),
+		tstorage.WithRetention(retention(config.RetentionDays)),
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	retentionDays := config.RetentionDays
+	if retentionDays < 0 {
+		retentionDays = 0
+	}
 	return &Client{
 		client:        storage,
 		loggingClient: lc,
+		nonces:        newPayloadNonces(),
+		retentionDays: retentionDays,
+		partitions:    newPartitionUsages(dataSourceDir),
 	}, nil
 }
@@ -0,0 +1,45 @@
+package tstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectNestedCodes(t *testing.T) {
+	// pts 最多 4 个元素，设备只上报了 2 个，每个元素的 vals 只上报了 1 个
+	codes := []string{"pos.lat", "pos.lng"}
+	for _, i := range []string{"0", "1", "2", "3"} {
+		codes = append(codes, "pts["+i+"].x")
+		for _, j := range []string{"0", "1", "2"} {
+			codes = append(codes, "pts["+i+"].vals["+j+"]")
+		}
+	}
+	stored := map[string]bool{
+		"pos.lat":        true,
+		"pts[0].x":       true,
+		"pts[0].vals[0]": true,
+		"pts[1].vals[0]": true,
+	}
+	var selected []string
+	err := selectNestedCodes(codes, func(code string) (bool, error) {
+		selected = append(selected, code)
+		return stored[code], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"pos.lat", "pos.lng",
+		"pts[0].x", "pts[0].vals[0]", "pts[0].vals[1]",
+		"pts[1].x", "pts[1].vals[0]", "pts[1].vals[1]",
+		"pts[2].x", "pts[2].vals[0]",
+	}, selected)
+}
+
+func TestNestedArrayIndexes(t *testing.T) {
+	paths, indexes := nestedArrayIndexes("pts[12].v[0].x")
+	assert.Equal(t, []string{"pts", "pts[12].v"}, paths)
+	assert.Equal(t, []int{12, 0}, indexes)
+	paths, _ = nestedArrayIndexes("pos.lat")
+	assert.Empty(t, paths)
+}
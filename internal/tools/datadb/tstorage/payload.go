@@ -0,0 +1,305 @@
+package tstorage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	tstorage "github.com/nakabonne/tstorage"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tstorage 只能存储 float64，事件和服务记录编码为 json 后按 6 字节一组拆分为多个数据点
+// (float64 可以精确表示 48 位整数)。标签 event/service 为事件或服务标识符，seq 为分组序号，
+// 序号 0 的数据点为 json 的字节数，同一条记录的全部数据点时间戳相同。
+// 同一毫秒内同一标识符的多条记录以标签 nonce 区分，第一条记录没有该标签
+const (
+	labelEvent   = "event"
+	labelService = "service"
+	labelSeq     = "seq"
+	labelNonce   = "nonce"
+
+	payloadChunkSize = 6
+
+	// payloadNonceLimit 记录的毫秒数超过该值时清理 payloadNonceWindow 之前的记录
+	payloadNonceLimit  = 4096
+	payloadNonceWindow = int64(time.Minute / time.Millisecond)
+)
+
+type payloadHeader struct {
+	code      string
+	nonce     int
+	timestamp int64
+	size      int
+}
+
+type payloadSeries struct {
+	code  string
+	nonce int
+}
+
+type payloadNonceKey struct {
+	metric    string
+	labelName string
+	code      string
+	timestamp int64
+}
+
+// payloadNonces 为同一毫秒内同一标识符的记录分配 nonce。只在内存中记录最近写入的毫秒，
+// 重启后或与一分钟前写入的记录时间戳相同时无法区分，查询时只保留其中一条
+type payloadNonces struct {
+	mu     sync.Mutex
+	nonces map[payloadNonceKey]int
+	latest int64
+}
+
+func newPayloadNonces() *payloadNonces {
+	return &payloadNonces{nonces: make(map[payloadNonceKey]int)}
+}
+
+func (n *payloadNonces) next(metric, labelName, code string, timestamp int64) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if timestamp > n.latest {
+		n.latest = timestamp
+	}
+	if len(n.nonces) >= payloadNonceLimit {
+		for key := range n.nonces {
+			if key.timestamp < n.latest-payloadNonceWindow {
+				delete(n.nonces, key)
+			}
+		}
+	}
+	key := payloadNonceKey{metric: metric, labelName: labelName, code: code, timestamp: timestamp}
+	nonce := n.nonces[key]
+	n.nonces[key] = nonce + 1
+	return nonce
+}
+
+type payloadRecord struct {
+	code      string
+	timestamp int64
+	data      []byte
+}
+
+func payloadLabels(labelName, code string, seq, nonce int) []tstorage.Label {
+	labels := []tstorage.Label{
+		{Name: labelName, Value: code},
+		{Name: labelSeq, Value: strconv.Itoa(seq)},
+	}
+	if nonce > 0 {
+		labels = append(labels, tstorage.Label{Name: labelNonce, Value: strconv.Itoa(nonce)})
+	}
+	return labels
+}
+
+func payloadRows(nonces *payloadNonces, metric, labelName, code string, timestamp int64, v interface{}) ([]tstorage.Row, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if timestamp <= 0 {
+		timestamp = time.Now().UnixMilli()
+	}
+	nonce := nonces.next(metric, labelName, code, timestamp)
+	rows := make([]tstorage.Row, 0, len(payload)/payloadChunkSize+2)
+	rows = append(rows, tstorage.Row{
+		Metric:    metric,
+		Labels:    payloadLabels(labelName, code, 0, nonce),
+		DataPoint: tstorage.DataPoint{Timestamp: timestamp, Value: float64(len(payload))},
+	})
+	for seq, offset := 1, 0; offset < len(payload); seq, offset = seq+1, offset+payloadChunkSize {
+		var chunk [8]byte
+		copy(chunk[2:], payload[offset:])
+		rows = append(rows, tstorage.Row{
+			Metric:    metric,
+			Labels:    payloadLabels(labelName, code, seq, nonce),
+			DataPoint: tstorage.DataPoint{Timestamp: timestamp, Value: float64(binary.BigEndian.Uint64(chunk[:]))},
+		})
+	}
+	return rows, nil
+}
+
+// selectPayloads 查询时间范围内各标识符的记录，按时间倒序分页，返回当前页的记录和总数
+func (c *Client) selectPayloads(metric, labelName string, codes []string, startTime, endTime int64, page, pageSize int) ([]payloadRecord, int, error) {
+	if startTime > endTime {
+		startTime, endTime = endTime, startTime
+	}
+	var headers []payloadHeader
+	for _, code := range codes {
+		// nonce 依次分配，某个 nonce 在时间范围内没有记录时更大的 nonce 也没有
+		for nonce := 0; ; nonce++ {
+			points, err := c.client.Select(metric, payloadLabels(labelName, code, 0, nonce), startTime, endTime+1)
+			if err == tstorage.ErrNoDataPoints {
+				break
+			}
+			if err != nil {
+				c.loggingClient.Error("tstorage query data:", err)
+				return nil, 0, err
+			}
+			// 无法区分的同一毫秒记录只保留一条
+			seen := make(map[int64]bool, len(points))
+			for _, point := range points {
+				if seen[point.Timestamp] {
+					continue
+				}
+				seen[point.Timestamp] = true
+				headers = append(headers, payloadHeader{code: code, nonce: nonce, timestamp: point.Timestamp, size: int(point.Value)})
+			}
+		}
+	}
+	sort.SliceStable(headers, func(i, j int) bool {
+		if headers[i].timestamp != headers[j].timestamp {
+			return headers[i].timestamp > headers[j].timestamp
+		}
+		return headers[i].nonce > headers[j].nonce
+	})
+	count := len(headers)
+	if pageSize > 0 {
+		start := (page - 1) * pageSize
+		if start < 0 || start >= len(headers) {
+			headers = nil
+		} else if end := start + pageSize; end < len(headers) {
+			headers = headers[start:end]
+		} else {
+			headers = headers[start:]
+		}
+	}
+
+	// 按标识符和 nonce 批量读取当前页记录的分组
+	type span struct {
+		min, max int64
+		size     int
+	}
+	spans := make(map[payloadSeries]*span)
+	for _, header := range headers {
+		series := payloadSeries{code: header.code, nonce: header.nonce}
+		s, ok := spans[series]
+		if !ok {
+			s = &span{min: header.timestamp, max: header.timestamp}
+			spans[series] = s
+		}
+		if header.timestamp < s.min {
+			s.min = header.timestamp
+		}
+		if header.timestamp > s.max {
+			s.max = header.timestamp
+		}
+		if header.size > s.size {
+			s.size = header.size
+		}
+	}
+	chunks := make(map[payloadSeries][]map[int64]float64)
+	for series, s := range spans {
+		n := (s.size + payloadChunkSize - 1) / payloadChunkSize
+		values := make([]map[int64]float64, n+1)
+		for seq := 1; seq <= n; seq++ {
+			values[seq] = make(map[int64]float64)
+			points, err := c.client.Select(metric, payloadLabels(labelName, series.code, seq, series.nonce), s.min, s.max+1)
+			if err == tstorage.ErrNoDataPoints {
+				continue
+			}
+			if err != nil {
+				c.loggingClient.Error("tstorage query data:", err)
+				return nil, count, err
+			}
+			for _, point := range points {
+				values[seq][point.Timestamp] = point.Value
+			}
+		}
+		chunks[series] = values
+	}
+
+	records := make([]payloadRecord, 0, len(headers))
+	for _, header := range headers {
+		data, ok := decodePayload(header, chunks[payloadSeries{code: header.code, nonce: header.nonce}])
+		if !ok {
+			c.loggingClient.Errorf("tstorage %s %s at %d is incomplete", labelName, header.code, header.timestamp)
+			continue
+		}
+		records = append(records, payloadRecord{code: header.code, timestamp: header.timestamp, data: data})
+	}
+	return records, count, nil
+}
+
+func decodePayload(header payloadHeader, chunks []map[int64]float64) ([]byte, bool) {
+	n := (header.size + payloadChunkSize - 1) / payloadChunkSize
+	if header.size <= 0 || len(chunks) <= n {
+		return nil, false
+	}
+	data := make([]byte, 0, n*payloadChunkSize)
+	for seq := 1; seq <= n; seq++ {
+		value, ok := chunks[seq][header.timestamp]
+		if !ok {
+			return nil, false
+		}
+		var chunk [8]byte
+		binary.BigEndian.PutUint64(chunk[:], uint64(value))
+		data = append(data, chunk[2:]...)
+	}
+	return data[:header.size], true
+}
+
+func (c *Client) GetDeviceService(req dtos.ThingModelServiceDataRequest, device models.Device, product models.Product) ([]dtos.SaveServiceIssueData, int, error) {
+	var response []dtos.SaveServiceIssueData
+	if len(req.Range) != 2 {
+		return response, 0, nil
+	}
+	var codes []string
+	if req.Code != "" {
+		codes = append(codes, req.Code)
+	} else {
+		for _, action := range product.Actions {
+			codes = append(codes, action.Code)
+		}
+	}
+	records, count, err := c.selectPayloads(constants.DB_PREFIX+device.Id, labelService, codes, req.Range[0], req.Range[1], req.Page, req.PageSize)
+	if err != nil {
+		return response, count, err
+	}
+	for _, record := range records {
+		var data dtos.SaveServiceIssueData
+		if err = json.Unmarshal(record.data, &data); err != nil {
+			c.loggingClient.Error("err:", err)
+			continue
+		}
+		data.Code = record.code
+		data.Time = record.timestamp
+		response = append(response, data)
+	}
+	return response, count, nil
+}
+
+func (c *Client) GetDeviceEvent(req dtos.ThingModelEventDataRequest, device models.Device, product models.Product) ([]dtos.EventData, int, error) {
+	var response []dtos.EventData
+	if len(req.Range) != 2 {
+		return response, 0, nil
+	}
+	var codes []string
+	if req.EventCode != "" {
+		codes = append(codes, req.EventCode)
+	} else {
+		for _, event := range product.Events {
+			codes = append(codes, event.Code)
+		}
+	}
+	records, count, err := c.selectPayloads(constants.DB_PREFIX+device.Id, labelEvent, codes, req.Range[0], req.Range[1], req.Page, req.PageSize)
+	if err != nil {
+		return response, count, err
+	}
+	for _, record := range records {
+		var data dtos.EventData
+		if err = json.Unmarshal(record.data, &data); err != nil {
+			c.loggingClient.Error("err:", err)
+			continue
+		}
+		data.EventCode = record.code
+		data.EventTime = record.timestamp
+		response = append(response, data)
+	}
+	return response, count, nil
+}
@@ -0,0 +1,58 @@
+package tstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+func TestPayloadSameMillisecond(t *testing.T) {
+	client, err := NewClient(dtos.Configuration{DataSource: t.TempDir() + "/data"}, logger.NewMockClient())
+	require.NoError(t, err)
+	t.Cleanup(client.CloseSession)
+
+	device := models.Device{Id: "device1"}
+	product := models.Product{Events: []models.Events{{Code: "alarm"}}}
+	table := constants.DB_PREFIX + device.Id
+	now := time.Now().UnixMilli()
+	event := func(ts int64, message string) dtos.DataRecord {
+		return dtos.DataRecord{Table: table, Time: ts, Events: map[string]dtos.EventData{
+			"alarm": {EventCode: "alarm", EventTime: ts, OutputParams: map[string]interface{}{"message": message}},
+		}}
+	}
+	// 前两条记录在同一毫秒，长度不同，分组数量也不同
+	require.NoError(t, client.BatchInsert(context.Background(), []dtos.DataRecord{
+		event(now, "temperature is too high"),
+		event(now, "short"),
+	}))
+	require.NoError(t, client.BatchInsert(context.Background(), []dtos.DataRecord{event(now, "third"), event(now+1, "later")}))
+
+	req := dtos.ThingModelEventDataRequest{ThingModelDataBaseRequest: dtos.ThingModelDataBaseRequest{Range: []int64{now - 1000, now + 1000}}}
+	events, count, err := client.GetDeviceEvent(req, device, product)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	var messages []string
+	for _, e := range events {
+		messages = append(messages, e.OutputParams["message"].(string))
+	}
+	assert.Equal(t, []string{"later", "third", "short", "temperature is too high"}, messages)
+
+	req.Page, req.PageSize = 2, 2
+	events, count, err = client.GetDeviceEvent(req, device, product)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	require.Len(t, events, 2)
+	assert.Equal(t, "short", events[0].OutputParams["message"])
+	assert.Equal(t, now, events[1].EventTime)
+
+	size, err := client.GetDeviceStorageSize(context.Background(), device, product)
+	require.NoError(t, err)
+	assert.Greater(t, size, int64(0))
+}
@@ -0,0 +1,218 @@
+package tstorage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tstorage "github.com/nakabonne/tstorage"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+)
+
+// pointSize 估算未落盘数据的存储大小时每个数据点的字节数(时间戳和值)
+const pointSize = 16
+
+// foreverRetention 永久保存时使用的保存时间
+const foreverRetention = 100 * 365 * 24 * time.Hour
+
+// tstorage 落盘分区的目录名前缀及文件名
+const (
+	partitionDirPrefix = "p-"
+	partitionDataFile  = "data"
+	partitionMetaFile  = "meta.json"
+)
+
+func retention(days int) time.Duration {
+	if days <= 0 {
+		return foreverRetention
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DeleteExpiredData tstorage 按分区整体过期，保存时间在打开时按全局配置设置，不能按产品删除数据。
+// 产品设置了保存天数时返回错误，该产品的数据按全局保存时间过期
+func (c *Client) DeleteExpiredData(ctx context.Context, device models.Device, product models.Product, retention models.DataRetention) error {
+	if !product.Retention.IsZero() {
+		return fmt.Errorf("%s does not support product retention, product %s data expires after %d days", constants.Tstorage, product.Id, c.retentionDays)
+	}
+	return nil
+}
+
+// SetRetention tstorage 不支持修改打开后的保存时间，与打开时不同时返回错误，重启后生效
+func (c *Client) SetRetention(ctx context.Context, days int) error {
+	if days != c.retentionDays {
+		return fmt.Errorf("%s retention is %d days, %d days takes effect after restart", constants.Tstorage, c.retentionDays, days)
+	}
+	return nil
+}
+
+// GetDeviceStorageSize 落盘分区按设备数据点占分区的比例估算，尚未落盘的最近分区按数据点数量估算
+func (c *Client) GetDeviceStorageSize(ctx context.Context, device models.Device, product models.Product) (int64, error) {
+	metric := constants.DB_PREFIX + device.Id
+	size, flushed, err := c.partitions.deviceSize(metric)
+	if err != nil {
+		return 0, err
+	}
+	start, end := flushed+1, time.Now().UnixMilli()+1
+	count := func(labels []tstorage.Label) (int64, error) {
+		points, err := c.client.Select(metric, labels, start, end)
+		if err == tstorage.ErrNoDataPoints {
+			return 0, nil
+		}
+		return int64(len(points)), err
+	}
+	var total int64
+	for _, property := range product.Properties {
+		for _, code := range property.TypeSpec.FlattenCodes(property.Code) {
+			n, err := count([]tstorage.Label{{Name: "code", Value: code}})
+			if err != nil {
+				return 0, err
+			}
+			total += n
+		}
+	}
+	// 事件和服务记录的数据点数量由序号 0 的字节数计算
+	payloads := func(labelName, code string) error {
+		for nonce := 0; ; nonce++ {
+			points, err := c.client.Select(metric, payloadLabels(labelName, code, 0, nonce), start, end)
+			if err == tstorage.ErrNoDataPoints {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			for _, point := range points {
+				total += 1 + (int64(point.Value)+payloadChunkSize-1)/payloadChunkSize
+			}
+		}
+	}
+	for _, event := range product.Events {
+		if err := payloads(labelEvent, event.Code); err != nil {
+			return 0, err
+		}
+	}
+	for _, action := range product.Actions {
+		if err := payloads(labelService, action.Code); err != nil {
+			return 0, err
+		}
+	}
+	return size + total*pointSize, nil
+}
+
+// partitionMeta tstorage 落盘分区 meta.json 中用到的字段
+type partitionMeta struct {
+	MaxTimestamp  int64 `json:"maxTimestamp"`
+	NumDataPoints int64 `json:"numDataPoints"`
+	Metrics       map[string]struct {
+		Name          string `json:"name"`
+		NumDataPoints int64  `json:"numDataPoints"`
+	} `json:"metrics"`
+}
+
+// partitionUsage 一个落盘分区中各设备数据的估算大小
+type partitionUsage struct {
+	maxTimestamp int64
+	sizes        map[string]int64
+}
+
+// partitionUsages 分区落盘后不再修改，按目录名缓存解析结果，过期删除的分区从缓存中移除
+type partitionUsages struct {
+	mu         sync.Mutex
+	dataPath   string
+	partitions map[string]partitionUsage
+}
+
+func newPartitionUsages(dataPath string) *partitionUsages {
+	return &partitionUsages{dataPath: dataPath, partitions: make(map[string]partitionUsage)}
+}
+
+// deviceSize 返回 metric 在全部落盘分区中的估算大小及落盘数据的最大时间戳
+func (u *partitionUsages) deviceSize(metric string) (int64, int64, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	entries, err := os.ReadDir(u.dataPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	exists := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, partitionDirPrefix) {
+			continue
+		}
+		exists[name] = true
+		if _, ok := u.partitions[name]; ok {
+			continue
+		}
+		usage, ok, err := readPartitionUsage(filepath.Join(u.dataPath, name))
+		if err != nil {
+			return 0, 0, err
+		}
+		if ok {
+			u.partitions[name] = usage
+		}
+	}
+	var size, flushed int64
+	for name, usage := range u.partitions {
+		if !exists[name] {
+			delete(u.partitions, name)
+			continue
+		}
+		size += usage.sizes[metric]
+		if usage.maxTimestamp > flushed {
+			flushed = usage.maxTimestamp
+		}
+	}
+	return size, flushed, nil
+}
+
+// readPartitionUsage 按各设备数据点占分区全部数据点的比例分摊 data 文件的大小。
+// meta.json 最后写入，不存在时分区正在落盘，ok 为 false
+func readPartitionUsage(dir string) (partitionUsage, bool, error) {
+	b, err := os.ReadFile(filepath.Join(dir, partitionMetaFile))
+	if os.IsNotExist(err) {
+		return partitionUsage{}, false, nil
+	}
+	if err != nil {
+		return partitionUsage{}, false, err
+	}
+	var meta partitionMeta
+	if err = json.Unmarshal(b, &meta); err != nil {
+		return partitionUsage{}, false, err
+	}
+	info, err := os.Stat(filepath.Join(dir, partitionDataFile))
+	if err != nil {
+		return partitionUsage{}, false, err
+	}
+	points := make(map[string]int64)
+	for _, m := range meta.Metrics {
+		points[metricOfSeries(m.Name)] += m.NumDataPoints
+	}
+	usage := partitionUsage{maxTimestamp: meta.MaxTimestamp, sizes: make(map[string]int64, len(points))}
+	if meta.NumDataPoints <= 0 {
+		return usage, true, nil
+	}
+	for metric, n := range points {
+		usage.sizes[metric] = info.Size() * n / meta.NumDataPoints
+	}
+	return usage, true, nil
+}
+
+// metricOfSeries 从 tstorage 序列化的序列名中取出 metric，带标签时前 2 字节为 metric 的长度
+func metricOfSeries(name string) string {
+	if len(name) < 2 {
+		return name
+	}
+	n := int(binary.BigEndian.Uint16([]byte(name[:2])))
+	if len(name) < 2+n {
+		return name
+	}
+	return name[2 : 2+n]
+}
@@ -0,0 +1,81 @@
+package tstorage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/winc-link/hummingbird/internal/dtos"
+	"github.com/winc-link/hummingbird/internal/models"
+	"github.com/winc-link/hummingbird/internal/pkg/constants"
+	"github.com/winc-link/hummingbird/internal/pkg/logger"
+)
+
+func TestGetDeviceStorageSizeFromPartitions(t *testing.T) {
+	dir := t.TempDir()
+	config := dtos.Configuration{DataSource: dir + "/data", RetentionDays: 30}
+	product := models.Product{Properties: []models.Properties{{Code: "temperature", TypeSpec: models.TypeSpec{Type: constants.SpecsTypeFloat}}}}
+	devices := []models.Device{{Id: "device1"}, {Id: "device2"}}
+
+	client, err := NewClient(config, logger.NewMockClient())
+	require.NoError(t, err)
+	now := time.Now().UnixMilli()
+	var records []dtos.DataRecord
+	for i := int64(0); i < 300; i++ {
+		records = append(records, dtos.DataRecord{Table: constants.DB_PREFIX + "device1", Time: now - 1000 + i, Values: map[string]interface{}{"temperature": float64(i)}})
+		if i%3 == 0 {
+			records = append(records, dtos.DataRecord{Table: constants.DB_PREFIX + "device2", Time: now - 1000 + i, Values: map[string]interface{}{"temperature": float64(i)}})
+		}
+	}
+	require.NoError(t, client.BatchInsert(context.Background(), records))
+	// 未落盘的数据按数据点数量估算
+	size, err := client.GetDeviceStorageSize(context.Background(), devices[0], product)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300*pointSize), size)
+	// 关闭时全部分区落盘
+	client.CloseSession()
+
+	client, err = NewClient(config, logger.NewMockClient())
+	require.NoError(t, err)
+	t.Cleanup(client.CloseSession)
+	var dataSize int64
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), partitionDirPrefix) {
+			info, err := os.Stat(filepath.Join(dir, entry.Name(), partitionDataFile))
+			require.NoError(t, err)
+			dataSize += info.Size()
+		}
+	}
+	require.Greater(t, dataSize, int64(0))
+
+	size1, err := client.GetDeviceStorageSize(context.Background(), devices[0], product)
+	require.NoError(t, err)
+	size2, err := client.GetDeviceStorageSize(context.Background(), devices[1], product)
+	require.NoError(t, err)
+	assert.Greater(t, size2, int64(0))
+	assert.Greater(t, size1, size2)
+	assert.InDelta(t, dataSize, size1+size2, 2)
+}
+
+func TestTstorageRetention(t *testing.T) {
+	client, err := NewClient(dtos.Configuration{DataSource: t.TempDir() + "/data", RetentionDays: 30}, logger.NewMockClient())
+	require.NoError(t, err)
+	t.Cleanup(client.CloseSession)
+	ctx := context.Background()
+	device := models.Device{Id: "device1"}
+	global := models.DataRetention{Property: 30, Event: 30, Service: 30}
+
+	assert.NoError(t, client.DeleteExpiredData(ctx, device, models.Product{}, global))
+	override := models.Product{Retention: models.DataRetention{Property: 90}}
+	assert.Error(t, client.DeleteExpiredData(ctx, device, override, override.Retention.Merge(global)))
+
+	assert.NoError(t, client.SetRetention(ctx, 30))
+	assert.Error(t, client.SetRetention(ctx, 90))
+}
@@ -2,6 +2,8 @@ package mqttclient
 
 import (
 	"context"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/winc-link/hummingbird/internal/dtos"
 )
 
@@ -9,6 +11,8 @@ type MQTTClient interface {
 	RegisterConnectCallback(dtos.ConnectHandler)
 	RegisterDisconnectCallback(dtos.CallbackHandler)
 	AsyncPublish(ctx context.Context, topic string, payload []byte, isSync bool)
+	// Publish 以 qos 1 发送，通过返回的 token 等待 broker 确认
+	Publish(topic string, payload []byte) mqtt.Token
 	Close()
 	GetConnectStatus() bool
 }
@@ -224,6 +224,10 @@ func (c *mqttClient) AsyncPublish(ctx context.Context, topic string, payload []b
 
 }
 
+func (c *mqttClient) Publish(topic string, payload []byte) mqtt.Token {
+	return c.client.Publish(topic, 1, false, payload)
+}
+
 func (c *mqttClient) GetConnectStatus() bool {
 	return c.getStatus()
 }
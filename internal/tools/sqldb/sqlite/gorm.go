@@ -75,6 +75,20 @@ func (c *GormClient) InitTable(dos ...DataObject) error {
 	return c.Pool.AutoMigrate(tables...)
 }
 
+// AddColumns 为存量表补充新增字段，字段已存在时跳过
+func (c *GormClient) AddColumns(do DataObject, fields ...string) error {
+	migrator := c.Pool.Migrator()
+	for _, field := range fields {
+		if migrator.HasColumn(do, field) {
+			continue
+		}
+		if err := migrator.AddColumn(do, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *GormClient) CloseSession() {
 	return
 }
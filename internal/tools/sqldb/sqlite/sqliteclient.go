@@ -14,6 +14,8 @@ type ClientSQLite interface {
     Close()
     // 初始化建表操作
     InitTable(dos ...DataObject) error
+    // 存量表补充新增字段
+    AddColumns(do DataObject, fields ...string) error
     // 添加数据
     CreateObject(do DataObject) error
     // 判断数据是否存在